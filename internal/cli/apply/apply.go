@@ -1,37 +1,66 @@
 package apply
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
+	"strings"
 
+	"github.com/charmbracelet/x/term"
 	"github.com/frodi/workshed/internal/cli"
 	"github.com/frodi/workshed/internal/logger"
+	"github.com/frodi/workshed/internal/workspace"
 	"github.com/spf13/cobra"
 )
 
 func Command() *cobra.Command {
 	var name string
 	var dryRun bool
+	var resume bool
+	var latest bool
+	var latestTag string
+	var yes bool
 
 	cmd := &cobra.Command{
 		Use:   "apply [<handle>] <capture-id>",
 		Short: "Apply a captured state",
 		Long: `Apply a captured git state to all repositories in a workspace.
 
+Run from a terminal, apply first lists each repository's current and
+captured commit and asks for confirmation; -y skips the prompt. Without a
+terminal it applies directly.
+
 Examples:
   # Apply capture by ID in current workspace
   workshed apply 01HVABCDEFG
 
-  # Apply capture by name
+  # Apply capture by name, as a flag or in place of the ID
   workshed apply --name "Before refactor"
+  workshed apply "Before refactor"
 
   # Apply capture in specific workspace
-  workshed apply my-workspace 01HVABCDEFG`,
+  workshed apply my-workspace 01HVABCDEFG
+
+  # Undo back to the most recent capture, or the newest one tagged "keep"
+  workshed apply --latest my-workspace
+  workshed apply --latest-tag keep
+
+  # Review the exact checkouts and any preflight blocks first
+  workshed apply --dry-run my-workspace 01HVABCDEFG
+
+  # Apply without the confirmation prompt
+  workshed apply -y my-workspace 01HVABCDEFG
+
+  # Finish an apply that failed partway through
+  workshed apply --continue my-workspace 01HVABCDEFG`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r := cli.NewRunner("")
+			r.DryRun = dryRun
 
 			ctx := context.Background()
 
@@ -42,22 +71,27 @@ Examples:
 			}
 
 			captureID := ""
-			if name != "" {
+			if latest || latestTag != "" {
 				captures, err := r.GetStore().ListCaptures(ctx, handle)
 				if err != nil {
 					return fmt.Errorf("failed to list captures: %w", err)
 				}
-				found := false
-				for _, c := range captures {
-					if c.Name == name {
-						captureID = c.ID
-						found = true
-						break
-					}
+				capture, err := workspace.LatestCapture(captures, latestTag)
+				if err != nil {
+					return err
 				}
-				if !found {
-					return fmt.Errorf("capture not found: %s", name)
+				captureID = capture.ID
+				r.GetLogger().Info("resolved latest capture", "id", captureID, "name", capture.Name)
+			} else if name != "" {
+				captures, err := r.GetStore().ListCaptures(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to list captures: %w", err)
+				}
+				capture, err := workspace.CaptureByName(captures, name)
+				if err != nil {
+					return err
 				}
+				captureID = capture.ID
 			} else if len(remaining) > 0 {
 				captureID = remaining[0]
 			} else {
@@ -68,12 +102,56 @@ Examples:
 			if err != nil {
 				return fmt.Errorf("failed to get capture: %w", err)
 			}
+			// The argument may have been a capture name; use its ID from here on.
+			captureID = capture.ID
+
+			if resume {
+				if dryRun {
+					return cli.RenderPlan(cmd, []cli.PlanStep{
+						{Action: "continue apply", Target: handle, Detail: captureID},
+					})
+				}
+
+				applied, err := r.GetStore().ContinueApply(ctx, handle, captureID)
+				if err != nil {
+					return fmt.Errorf("continue failed: %w", err)
+				}
+
+				format := cmd.Flags().Lookup("format").Value.String()
+				if format == "json" {
+					data, _ := json.MarshalIndent(map[string]any{"id": captureID, "name": capture.Name, "applied": applied}, "", "  ")
+					_, _ = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+					return nil
+				}
+
+				appliedList := strings.Join(applied, ", ")
+				if appliedList == "" {
+					appliedList = "none"
+				}
+				return cli.RenderKeyValue(map[string]string{
+					"id":      captureID,
+					"name":    capture.Name,
+					"applied": appliedList,
+					"skipped": strconv.Itoa(len(capture.GitState) - len(applied)),
+				}, format, cmd.OutOrStdout())
+			}
 
 			preflight, err := r.GetStore().PreflightApply(ctx, handle, captureID)
 			if err != nil {
 				return fmt.Errorf("preflight check failed: %w", err)
 			}
 
+			if dryRun {
+				format := cmd.Flags().Lookup("format").Value.String()
+				if err := cli.Render(planOutput(capture.GitState, preflight), format, cmd.OutOrStdout()); err != nil {
+					return fmt.Errorf("failed to render output: %w", err)
+				}
+				if !preflight.Valid {
+					return fmt.Errorf("preflight validation failed")
+				}
+				return nil
+			}
+
 			if !preflight.Valid {
 				logger.UncheckedFprintf(cmd.ErrOrStderr(), "ERROR: apply blocked by preflight errors\n\n")
 				logger.UncheckedFprintf(cmd.ErrOrStderr(), "Problems found:\n")
@@ -88,9 +166,24 @@ Examples:
 				return fmt.Errorf("preflight validation failed")
 			}
 
-			if dryRun {
-				r.GetLogger().Info("dry run - would apply capture", "handle", handle, "capture", captureID)
-				return nil
+			if !yes && term.IsTerminal(os.Stdin.Fd()) {
+				changes, err := r.GetStore().PlanApply(ctx, handle, captureID)
+				if err != nil {
+					return fmt.Errorf("failed to plan apply: %w", err)
+				}
+				writeSummary(cmd.OutOrStdout(), capture, changes)
+				if _, err := fmt.Fprint(cmd.OutOrStdout(), "Apply? [y/N]: "); err != nil {
+					return fmt.Errorf("failed to write prompt: %w", err)
+				}
+				response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil {
+					return fmt.Errorf("failed to read user input: %w", err)
+				}
+				response = strings.TrimSpace(strings.ToLower(response))
+				if response != "y" && response != "yes" {
+					r.GetLogger().Info("operation cancelled")
+					return nil
+				}
 			}
 
 			if err := r.GetStore().ApplyCapture(ctx, handle, captureID); err != nil {
@@ -113,8 +206,87 @@ Examples:
 	}
 
 	cmd.Flags().StringVar(&name, "name", "", "Capture name")
-	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be applied")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the checkout per repository and preflight result without applying")
+	cmd.Flags().BoolVar(&latest, "latest", false, "Apply the most recent capture")
+	cmd.Flags().StringVar(&latestTag, "latest-tag", "", "Apply the most recent capture carrying this tag")
+	cmd.Flags().BoolVar(&resume, "continue", false, "Only apply repositories not yet at the captured commit")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip the confirmation shown when run from a terminal")
 	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
 
 	return cmd
 }
+
+// planOutput lists the checkout apply would run for each captured repository,
+// alongside any preflight problem blocking it.
+func planOutput(refs []workspace.GitRef, preflight workspace.ApplyPreflightResult) cli.Output {
+	blocked := make(map[string][]string)
+	var order []string
+	for _, e := range preflight.Errors {
+		if _, ok := blocked[e.Repository]; !ok {
+			order = append(order, e.Repository)
+		}
+		blocked[e.Repository] = append(blocked[e.Repository], e.Reason+": "+e.Details)
+	}
+
+	var rows [][]string
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		seen[ref.Repository] = true
+		rows = append(rows, planRow(ref.Repository, "git checkout "+ref.Commit, blocked[ref.Repository]))
+	}
+	for _, name := range order {
+		if !seen[name] {
+			rows = append(rows, planRow(name, "", blocked[name]))
+		}
+	}
+
+	return cli.Output{
+		Columns: []cli.ColumnConfig{
+			{Type: cli.Rigid, Name: "REPOSITORY", Min: 10, Max: 20},
+			{Type: cli.Rigid, Name: "CHECKOUT", Min: 20, Max: 0},
+			{Type: cli.Rigid, Name: "STATUS", Min: 7, Max: 7},
+			{Type: cli.Shrinkable, Name: "REASON", Min: 10, Max: 0},
+		},
+		Rows: rows,
+	}
+}
+
+func planRow(repo, checkout string, problems []string) []string {
+	if len(problems) == 0 {
+		return []string{repo, checkout, "clean", ""}
+	}
+	return []string{repo, checkout, "blocked", strings.Join(problems, "; ")}
+}
+
+// writeSummary lists, per captured repository, the commit checked out now and
+// the one applying capture moves it to.
+func writeSummary(w io.Writer, capture *workspace.Capture, changes []workspace.RefChange) {
+	label := capture.ID
+	if capture.Name != "" {
+		label += " (" + capture.Name + ")"
+	}
+	_, _ = fmt.Fprintf(w, "Applying capture %s checks out:\n", label)
+
+	width := 0
+	for _, c := range changes {
+		width = max(width, len(c.Repository))
+	}
+	for _, c := range changes {
+		from := shortCommit(c.From)
+		if from == "" {
+			from = "(unknown)"
+		}
+		if c.From == c.To {
+			_, _ = fmt.Fprintf(w, "  %-*s  %s (unchanged)\n", width, c.Repository, from)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "  %-*s  %s -> %s\n", width, c.Repository, from, shortCommit(c.To))
+	}
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
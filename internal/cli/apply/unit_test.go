@@ -1,8 +1,10 @@
 package apply
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/frodi/workshed/internal/workspace"
 	"github.com/spf13/cobra"
 )
 
@@ -13,7 +15,7 @@ func flagExists(cmd *cobra.Command, name string) bool {
 func TestApplyCommand(t *testing.T) {
 	t.Run("has required flags", func(t *testing.T) {
 		cmd := Command()
-		requiredFlags := []string{"name", "dry-run", "format"}
+		requiredFlags := []string{"name", "latest", "latest-tag", "dry-run", "continue", "yes", "format"}
 		for _, f := range requiredFlags {
 			if !flagExists(cmd, f) {
 				t.Errorf("apply should have --%s flag", f)
@@ -45,3 +47,23 @@ func TestApplyCommand(t *testing.T) {
 		}
 	})
 }
+
+func TestWriteSummary(t *testing.T) {
+	capture := &workspace.Capture{ID: "01HVABCDEFG", Name: "before refactor"}
+	changes := []workspace.RefChange{
+		{Repository: "api", From: "1a2b3c4d5e6f", To: "9f8e7d6c5b4a"},
+		{Repository: "web", From: "5d6e7f8a9b0c", To: "5d6e7f8a9b0c"},
+		{Repository: "docs", To: "0a1b2c3d4e5f"},
+	}
+
+	var buf bytes.Buffer
+	writeSummary(&buf, capture, changes)
+
+	want := "Applying capture 01HVABCDEFG (before refactor) checks out:\n" +
+		"  api   1a2b3c4 -> 9f8e7d6\n" +
+		"  web   5d6e7f8 (unchanged)\n" +
+		"  docs  (unknown) -> 0a1b2c3\n"
+	if buf.String() != want {
+		t.Errorf("writeSummary() =\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
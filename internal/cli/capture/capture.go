@@ -16,6 +16,12 @@ func Command() *cobra.Command {
 	var kind string
 	var description string
 	var tags []string
+	var uniqueName bool
+	var parent string
+	var backupOf string
+	var autoIntent bool
+	var compress bool
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "capture [<handle>] --name <name>",
@@ -25,10 +31,25 @@ func Command() *cobra.Command {
 Examples:
   workshed capture --name "Before refactor"
   workshed capture --name "Checkpoint 1" --description "API changes"
-  workshed capture --name "Starting point" --tag test`,
+  workshed capture --name "Starting point" --tag test
+  workshed capture --name "release-1.2" --unique-name
+  workshed capture --name "Step 2" --parent 01HVABCDEFG
+  workshed capture --name "Before applying" --backup-of 01HVABCDEFG
+  workshed capture --name "Tests green" --auto-intent
+  workshed capture --name "Checkpoint 2" --unique-name --dry-run
+
+With --auto-intent and no --description, the description is taken from the
+latest exec in the workspace ("after running: make test") if it finished in
+the last 15 minutes.
+
+--compress-captures stores the capture as a single capture.tar.gz instead of a
+plain capture.json. Both formats list, show and apply the same way. Set
+compress-captures in the config file to make it the default.`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r := cli.NewRunner("")
+			r.CompressCaptures = compress
+			r.DryRun = dryRun
 
 			if name == "" {
 				return fmt.Errorf("missing required flag: --name")
@@ -46,12 +67,36 @@ Examples:
 				return fmt.Errorf("failed to resolve workspace: %w", err)
 			}
 
-			capture, err := r.GetStore().CaptureState(ctx, handle, workspace.CaptureOptions{
+			opts := workspace.CaptureOptions{
 				Name:        name,
 				Kind:        kind,
 				Description: description,
 				Tags:        tags,
-			})
+				UniqueName:  uniqueName,
+				Parent:      parent,
+				BackupOf:    backupOf,
+				AutoIntent:  autoIntent,
+			}
+
+			if dryRun {
+				ws, err := r.GetStore().Get(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to read workspace: %w", err)
+				}
+				planned, err := r.GetStore().PlanCapture(ctx, handle, opts)
+				if err != nil {
+					return fmt.Errorf("capture failed: %w", err)
+				}
+				detail := fmt.Sprintf("%d repositories", len(ws.Repositories))
+				if planned.Description != "" {
+					detail += ", " + planned.Description
+				}
+				return cli.RenderPlan(cmd, []cli.PlanStep{
+					{Action: "record capture", Target: planned.Name, Detail: detail},
+				})
+			}
+
+			capture, err := r.GetStore().CaptureState(ctx, handle, opts)
 			if err != nil {
 				return fmt.Errorf("capture failed: %w", err)
 			}
@@ -81,6 +126,12 @@ Examples:
 	cmd.Flags().StringVar(&kind, "kind", "", "Capture kind (default: state)")
 	cmd.Flags().StringVar(&description, "description", "", "Capture description")
 	cmd.Flags().StringSliceVar(&tags, "tag", nil, "Tags for the capture")
+	cmd.Flags().BoolVar(&uniqueName, "unique-name", false, "Fail if a capture with this name already exists")
+	cmd.Flags().StringVar(&parent, "parent", "", "ID of the capture this one builds on")
+	cmd.Flags().StringVar(&backupOf, "backup-of", "", "ID of the capture about to be applied, recording this one as its backup")
+	cmd.Flags().BoolVar(&autoIntent, "auto-intent", false, "Describe the capture by the latest exec when no --description is given")
+	cmd.Flags().BoolVar(&compress, "compress-captures", false, "Store the capture as a compressed archive")
+	cli.AddDryRunFlag(cmd, &dryRun)
 	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
 	_ = cmd.MarkFlagRequired("name")
 
@@ -13,7 +13,7 @@ func flagExists(cmd *cobra.Command, name string) bool {
 func TestCaptureCommand(t *testing.T) {
 	t.Run("has required flags", func(t *testing.T) {
 		cmd := Command()
-		requiredFlags := []string{"name", "kind", "description", "tag", "format"}
+		requiredFlags := []string{"name", "kind", "description", "tag", "unique-name", "parent", "backup-of", "auto-intent", "format"}
 		for _, f := range requiredFlags {
 			if !flagExists(cmd, f) {
 				t.Errorf("capture should have --%s flag", f)
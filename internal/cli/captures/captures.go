@@ -3,6 +3,8 @@ package captures
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/frodi/workshed/internal/cli"
 	"github.com/frodi/workshed/internal/workspace"
@@ -12,6 +14,9 @@ import (
 func Command() *cobra.Command {
 	var filter string
 	var reverse bool
+	var wide bool
+	var withSize bool
+	var all bool
 
 	cmd := &cobra.Command{
 		Use:   "captures [<handle>]",
@@ -29,12 +34,34 @@ Examples:
   workshed captures --filter api
 
   # Filter captures by tag
-  workshed captures --filter tag:debug`,
+  workshed captures --filter tag:debug
+
+  # Search captures in every workspace
+  workshed captures --all --filter tag:release
+
+  # Show how much disk each capture uses
+  workshed captures --with-size
+
+  # Check that captures can still be restored
+  workshed captures verify
+
+  # Remove capture directories left without a capture.json
+  workshed captures prune
+
+  # Delete a capture that is no longer needed
+  workshed captures delete 01HVABCDEFG
+
+  # Show captures as a tree of parents and backups
+  workshed captures tree`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r := cli.NewRunner("")
 
 			ctx := context.Background()
+			if all {
+				return listAll(ctx, cmd, r, filter, reverse, wide)
+			}
+
 			providedHandle, _ := cli.ExtractHandleFromArgs(args)
 			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
 			if err != nil {
@@ -81,15 +108,42 @@ Examples:
 				return nil
 			}
 
+			now := time.Now()
 			var rows [][]string
 			for _, cap := range displayCaptures {
 				created := cap.Timestamp.Format("2006-01-02 15:04")
-				rows = append(rows, []string{cap.ID, cap.Name, cap.Kind, fmt.Sprintf("%d", len(cap.GitState)), created})
+				dirty := "no"
+				if capturedDirty(cap) {
+					dirty = "yes"
+				}
+				row := []string{
+					cap.ID,
+					cap.Name,
+					cap.Kind,
+					fmt.Sprintf("%d", len(cap.GitState)),
+					dirty,
+					cli.RelativeTime(cap.Timestamp, now),
+					created,
+				}
+				if withSize {
+					size, err := r.GetStore().CaptureSize(ctx, handle, cap.ID)
+					if err != nil {
+						return fmt.Errorf("failed to measure capture %s: %w", cap.ID, err)
+					}
+					row = append(row, cli.FormatSize(size))
+				}
+				rows = append(rows, row)
+			}
+
+			columns := cli.CapturesColumns
+			if withSize {
+				columns = append(columns[:len(columns):len(columns)], cli.ColumnConfig{Type: cli.Rigid, Name: "SIZE", Min: 8, Max: 10})
 			}
 
 			output := cli.Output{
-				Columns: cli.CapturesColumns,
+				Columns: columns,
 				Rows:    rows,
+				Wide:    wide,
 			}
 
 			return cli.Render(output, format, cmd.OutOrStdout())
@@ -98,7 +152,76 @@ Examples:
 
 	cmd.Flags().StringVar(&filter, "filter", "", "Filter captures by name or tag")
 	cmd.Flags().BoolVar(&reverse, "reverse", false, "Reverse order")
+	cmd.Flags().BoolVar(&all, "all", false, "List captures from every workspace")
+	cmd.Flags().BoolVar(&withSize, "with-size", false, "Show the disk space each capture uses")
+	cmd.Flags().BoolVar(&wide, "wide", false, "Show full values instead of truncating to the terminal width")
 	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
 
+	cmd.AddCommand(VerifyCommand())
+	cmd.AddCommand(PruneCommand())
+	cmd.AddCommand(DeleteCommand())
+	cmd.AddCommand(TreeCommand())
+
 	return cmd
 }
+
+// listAll renders the captures of every workspace that match filter, with
+// the handle each belongs to.
+func listAll(ctx context.Context, cmd *cobra.Command, r *cli.Runner, filter string, reverse, wide bool) error {
+	format := cmd.Flags().Lookup("format").Value.String()
+
+	captures, err := r.SearchCaptures(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to list captures: %w", err)
+	}
+	if len(captures) == 0 {
+		msg := "no captures found"
+		if filter != "" {
+			msg = "no captures match filter: " + filter
+		}
+		return cli.RenderEmptyList(format, msg, cmd.OutOrStdout(), r.GetLogger())
+	}
+
+	if reverse {
+		for i, j := 0, len(captures)-1; i < j; i, j = i+1, j-1 {
+			captures[i], captures[j] = captures[j], captures[i]
+		}
+	}
+
+	if format == "raw" {
+		for _, cap := range captures {
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", cap.Handle, cap.ID)
+		}
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([][]string, 0, len(captures))
+	for _, cap := range captures {
+		rows = append(rows, []string{
+			cap.Handle,
+			cap.ID,
+			cap.Name,
+			strings.Join(cap.Metadata.Tags, ","),
+			cli.RelativeTime(cap.Timestamp, now),
+		})
+	}
+
+	output := cli.Output{
+		Columns: cli.AllCapturesColumns,
+		Rows:    rows,
+		Wide:    wide,
+	}
+	return cli.Render(output, format, cmd.OutOrStdout())
+}
+
+// capturedDirty reports whether any repository had uncommitted changes when captured,
+// meaning apply can only restore its commit, not the working tree.
+func capturedDirty(cap workspace.Capture) bool {
+	for _, ref := range cap.GitState {
+		if ref.Dirty {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package captures
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+func DeleteCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "delete [<handle>] <capture-id>",
+		Short: "Delete a capture",
+		Long: `Delete a capture and everything stored with it. The capture is given by ID,
+as shown by 'workshed captures'; names are not accepted, so the wrong capture
+is never deleted. Repositories are not touched.
+
+Examples:
+  workshed captures delete 01HVABCDEFG
+  workshed captures delete my-workspace 01HVABCDEFG
+  workshed captures delete my-workspace 01HVABCDEFG --dry-run`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			providedHandle, captureID := "", args[0]
+			if len(args) == 2 {
+				providedHandle, captureID = args[0], args[1]
+			}
+
+			ctx := context.Background()
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if dryRun {
+				size, err := r.GetStore().CaptureSize(ctx, handle, captureID)
+				if err != nil {
+					return err
+				}
+				return cli.RenderPlan(cmd, []cli.PlanStep{
+					{Action: "delete capture", Target: captureID, Detail: cli.FormatSize(size)},
+				})
+			}
+
+			if err := r.GetStore().DeleteCapture(ctx, handle, captureID); err != nil {
+				return fmt.Errorf("failed to delete capture: %w", err)
+			}
+
+			r.GetLogger().Success("capture deleted", "id", captureID)
+			return nil
+		},
+	}
+
+	cli.AddDryRunFlag(cmd, &dryRun)
+
+	return cmd
+}
@@ -0,0 +1,73 @@
+package captures
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+func PruneCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "prune [<handle>]",
+		Short: "Remove orphaned capture directories",
+		Long: `Remove capture directories whose capture.json is missing or does not parse.
+
+These are left behind by interrupted captures or manual edits; 'workshed
+captures' skips them and they are never cleaned up otherwise. Captures that
+reference missing repositories or commits are kept, since they still record
+a state; 'workshed captures verify' reports them.
+
+Directories modified in the last minute are skipped, so a capture being
+written concurrently is not removed.
+
+Examples:
+  workshed captures prune --dry-run
+  workshed captures prune my-workspace`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if dryRun {
+				orphaned, err := r.GetStore().OrphanedCaptures(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to verify captures: %w", err)
+				}
+				var steps []cli.PlanStep
+				for _, res := range orphaned {
+					steps = append(steps, cli.PlanStep{Action: "delete capture", Target: res.CaptureID, Detail: res.Problems[0].Details})
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
+			removed, err := r.GetStore().PruneOrphanedCaptures(ctx, handle)
+			for _, id := range removed {
+				r.GetLogger().Info("removed orphaned capture", "id", id)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to prune captures: %w", err)
+			}
+			if len(removed) == 0 {
+				r.GetLogger().Info("no orphaned captures found")
+				return nil
+			}
+			r.GetLogger().Success("captures pruned", "removed", len(removed))
+			return nil
+		},
+	}
+
+	cli.AddDryRunFlag(cmd, &dryRun)
+
+	return cmd
+}
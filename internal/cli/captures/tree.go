@@ -0,0 +1,129 @@
+package captures
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+// TreeEntry is one capture in the --format json adjacency list.
+type TreeEntry struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Timestamp time.Time `json:"timestamp"`
+	Parent    string    `json:"parent,omitempty"`
+	BackupOf  string    `json:"backup_of,omitempty"`
+	// Children and Backups are the captures shown directly under this one.
+	Children []string `json:"children"`
+	Backups  []string `json:"backups"`
+}
+
+func TreeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tree [<handle>]",
+		Short: "Show captures as a tree of parents and backups",
+		Long: `Show how captures relate to each other.
+
+A capture taken with --parent sits under that capture. A capture taken with
+--backup-of, before applying another capture, sits under the capture it was
+a backup for and is marked [backup].
+
+Examples:
+  workshed captures tree
+  workshed captures tree my-workspace --format json`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			captures, err := r.GetStore().ListCaptures(ctx, handle)
+			if err != nil {
+				return fmt.Errorf("failed to list captures: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if len(captures) == 0 {
+				return cli.RenderEmptyList(format, "no captures found", cmd.OutOrStdout(), r.GetLogger())
+			}
+
+			roots := workspace.CaptureLineage(captures)
+			if format == "json" {
+				data, _ := json.MarshalIndent(adjacency(roots), "", "  ")
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+
+			now := time.Now()
+			for _, root := range roots {
+				writeNode(cmd.OutOrStdout(), root, "", "", now)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("format", "table", "Output format (table|json)")
+
+	return cmd
+}
+
+// adjacency flattens the trees into one entry per capture, oldest first
+// within each tree.
+func adjacency(roots []*workspace.CaptureNode) []TreeEntry {
+	var entries []TreeEntry
+	var walk func(node *workspace.CaptureNode)
+	walk = func(node *workspace.CaptureNode) {
+		entry := TreeEntry{
+			ID:        node.Capture.ID,
+			Name:      node.Capture.Name,
+			Timestamp: node.Capture.Timestamp,
+			Parent:    node.Capture.Parent,
+			BackupOf:  node.Capture.BackupOf,
+			Children:  []string{},
+			Backups:   []string{},
+		}
+		for _, child := range node.Children {
+			if child.Relation == workspace.CaptureRelationBackup {
+				entry.Backups = append(entry.Backups, child.Capture.ID)
+			} else {
+				entry.Children = append(entry.Children, child.Capture.ID)
+			}
+		}
+		entries = append(entries, entry)
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	for _, root := range roots {
+		walk(root)
+	}
+	return entries
+}
+
+// writeNode prints node behind branch and its children indented by prefix.
+func writeNode(w io.Writer, node *workspace.CaptureNode, branch, prefix string, now time.Time) {
+	label := node.Capture.ID + "  " + node.Capture.Name
+	if node.Relation == workspace.CaptureRelationBackup {
+		label += "  [backup]"
+	}
+	_, _ = fmt.Fprintf(w, "%s%s  (%s)\n", branch, label, cli.RelativeTime(node.Capture.Timestamp, now))
+
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			writeNode(w, child, prefix+"└── ", prefix+"    ", now)
+		} else {
+			writeNode(w, child, prefix+"├── ", prefix+"│   ", now)
+		}
+	}
+}
@@ -18,6 +18,27 @@ func TestCapturesCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("has --wide flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "wide") {
+			t.Error("captures should have --wide flag")
+		}
+	})
+
+	t.Run("has --all flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "all") {
+			t.Error("captures should have --all flag")
+		}
+	})
+
+	t.Run("has --with-size flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "with-size") {
+			t.Error("captures should have --with-size flag")
+		}
+	})
+
 	t.Run("has --reverse flag", func(t *testing.T) {
 		cmd := Command()
 		if !flagExists(cmd, "reverse") {
@@ -40,3 +61,52 @@ func TestCapturesCommand(t *testing.T) {
 		}
 	})
 }
+
+func TestVerifyCommand(t *testing.T) {
+	t.Run("is a captures subcommand", func(t *testing.T) {
+		cmd, _, err := Command().Find([]string{"verify"})
+		if err != nil || cmd.Name() != "verify" {
+			t.Errorf("captures should have a verify subcommand, got %v (%v)", cmd, err)
+		}
+	})
+
+	t.Run("has --format and --wide flags", func(t *testing.T) {
+		cmd := VerifyCommand()
+		if !flagExists(cmd, "format") {
+			t.Error("captures verify should have --format flag")
+		}
+		if !flagExists(cmd, "wide") {
+			t.Error("captures verify should have --wide flag")
+		}
+	})
+}
+
+func TestPruneCommand(t *testing.T) {
+	t.Run("is a captures subcommand", func(t *testing.T) {
+		cmd, _, err := Command().Find([]string{"prune"})
+		if err != nil || cmd.Name() != "prune" {
+			t.Errorf("captures should have a prune subcommand, got %v (%v)", cmd, err)
+		}
+	})
+
+	t.Run("has --dry-run flag", func(t *testing.T) {
+		if !flagExists(PruneCommand(), "dry-run") {
+			t.Error("captures prune should have --dry-run flag")
+		}
+	})
+}
+
+func TestDeleteCommand(t *testing.T) {
+	t.Run("is a captures subcommand", func(t *testing.T) {
+		cmd, _, err := Command().Find([]string{"delete"})
+		if err != nil || cmd.Name() != "delete" {
+			t.Errorf("captures should have a delete subcommand, got %v (%v)", cmd, err)
+		}
+	})
+
+	t.Run("has --dry-run flag", func(t *testing.T) {
+		if !flagExists(DeleteCommand(), "dry-run") {
+			t.Error("captures delete should have --dry-run flag")
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package captures
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var verifyColumns = []cli.ColumnConfig{
+	{Type: cli.Rigid, Name: "ID", Min: 26, Max: 26},
+	{Type: cli.Rigid, Name: "NAME", Min: 10, Max: 20},
+	{Type: cli.Rigid, Name: "STATUS", Min: 8, Max: 8},
+	{Type: cli.Shrinkable, Name: "PROBLEMS", Min: 10, Max: 0},
+}
+
+func VerifyCommand() *cobra.Command {
+	var wide bool
+
+	cmd := &cobra.Command{
+		Use:   "verify [<handle>] [<capture-id>]",
+		Short: "Check that captures can still be restored",
+		Long: `Check each capture's on-disk integrity without changing anything.
+
+A capture is reported broken when its capture.json does not parse, a
+repository it references is missing from the workspace, or a recorded commit
+is no longer in the repository (for example after garbage collection).
+A capture directory whose capture.json is missing or unreadable is reported
+as orphaned; 'workshed captures prune' removes those.
+Uncommitted changes are not reported; see 'workshed apply --dry-run' for that.
+
+Examples:
+  workshed captures verify
+  workshed captures verify my-workspace
+  workshed captures verify my-workspace 01HVABCDEFG`,
+		Args: cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+
+			ctx := context.Background()
+
+			// A lone argument is a handle only when it names an existing workspace.
+			var providedHandle, captureID string
+			switch len(args) {
+			case 2:
+				providedHandle, captureID = args[0], args[1]
+			case 1:
+				if _, err := r.GetStore().Get(ctx, args[0]); err == nil {
+					providedHandle = args[0]
+				} else {
+					captureID = args[0]
+				}
+			}
+
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			var results []workspace.CaptureVerification
+			if captureID != "" {
+				result, err := r.GetStore().VerifyCapture(ctx, handle, captureID)
+				if err != nil {
+					return fmt.Errorf("failed to verify capture: %w", err)
+				}
+				results = append(results, *result)
+			} else {
+				results, err = r.GetStore().VerifyCaptures(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to verify captures: %w", err)
+				}
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if len(results) == 0 {
+				return cli.RenderEmptyList(format, "no captures found", cmd.OutOrStdout(), r.GetLogger())
+			}
+
+			broken := 0
+			var rows [][]string
+			for _, res := range results {
+				status := "ok"
+				switch {
+				case res.Orphaned:
+					status = "orphaned"
+					broken++
+				case !res.Valid:
+					status = "broken"
+					broken++
+				}
+				var problems []string
+				for _, p := range res.Problems {
+					if p.Repository != "" {
+						problems = append(problems, p.Repository+": "+p.Details)
+					} else {
+						problems = append(problems, p.Details)
+					}
+				}
+				rows = append(rows, []string{res.CaptureID, res.Name, status, strings.Join(problems, "; ")})
+			}
+
+			if err := cli.Render(cli.Output{Columns: verifyColumns, Rows: rows, Wide: wide}, format, cmd.OutOrStdout()); err != nil {
+				return fmt.Errorf("failed to render output: %w", err)
+			}
+
+			if broken > 0 {
+				return fmt.Errorf("%d of %d captures failed verification", broken, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&wide, "wide", false, "Show full values instead of truncating to the terminal width")
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
@@ -2,25 +2,40 @@ package clitest
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/frodi/workshed/internal/cli"
 	"github.com/frodi/workshed/internal/cli/apply"
 	"github.com/frodi/workshed/internal/cli/capture"
 	"github.com/frodi/workshed/internal/cli/captures"
 	"github.com/frodi/workshed/internal/cli/create"
+	"github.com/frodi/workshed/internal/cli/executions"
 	"github.com/frodi/workshed/internal/cli/export"
 	"github.com/frodi/workshed/internal/cli/health"
 	"github.com/frodi/workshed/internal/cli/importcmd"
 	"github.com/frodi/workshed/internal/cli/inspect"
+	"github.com/frodi/workshed/internal/cli/last"
 	"github.com/frodi/workshed/internal/cli/list"
+	"github.com/frodi/workshed/internal/cli/lock"
 	"github.com/frodi/workshed/internal/cli/path"
 	"github.com/frodi/workshed/internal/cli/remove"
+	"github.com/frodi/workshed/internal/cli/rename"
 	"github.com/frodi/workshed/internal/cli/repos"
+	"github.com/frodi/workshed/internal/cli/trash"
 	"github.com/frodi/workshed/internal/cli/update"
+	"github.com/frodi/workshed/internal/git"
 	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
 )
 
 func TestCapturesCommand(t *testing.T) {
@@ -46,6 +61,74 @@ func TestCapturesCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("with size column", func(t *testing.T) {
+		capture, err := env.Store.CaptureState(env.Ctx, ws.Handle, workspace.CaptureOptions{Name: "sized", Kind: workspace.CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+
+		if err := env.Run(captures.Command(), []string{ws.Handle, "--format", "json"}); err != nil {
+			t.Fatalf("captures failed: %v", err)
+		}
+		if strings.Contains(env.Output(), "SIZE") {
+			t.Errorf("SIZE should only be shown with --with-size, got: %s", env.Output())
+		}
+
+		if err := env.Run(captures.Command(), []string{ws.Handle, "--with-size", "--format", "json"}); err != nil {
+			t.Fatalf("captures --with-size failed: %v", err)
+		}
+		var rows []map[string]string
+		if err := json.Unmarshal([]byte(env.Output()), &rows); err != nil {
+			t.Fatalf("Invalid JSON: %v\n%s", err, env.Output())
+		}
+		if len(rows) != 1 || rows[0]["ID"] != capture.ID {
+			t.Fatalf("Expected one row for %s, got %v", capture.ID, rows)
+		}
+		if size := rows[0]["SIZE"]; size == "" || size == "0 B" {
+			t.Errorf("Expected a non-zero size, got %q", size)
+		}
+	})
+
+	t.Run("across all workspaces", func(t *testing.T) {
+		other := env.CreateWorkspace("other purpose", nil)
+		first, err := env.Store.CaptureState(env.Ctx, ws.Handle, workspace.CaptureOptions{Name: "first", Kind: workspace.CaptureKindManual, Tags: []string{"release"}})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		second, err := env.Store.CaptureState(env.Ctx, other.Handle, workspace.CaptureOptions{Name: "second", Kind: workspace.CaptureKindManual, Tags: []string{"release"}})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		if _, err := env.Store.CaptureState(env.Ctx, other.Handle, workspace.CaptureOptions{Name: "scratch", Kind: workspace.CaptureKindManual}); err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+
+		if err := env.Run(captures.Command(), []string{"--all", "--filter", "tag:release", "--format", "json"}); err != nil {
+			t.Fatalf("captures --all failed: %v", err)
+		}
+		var rows []map[string]string
+		if err := json.Unmarshal([]byte(env.Output()), &rows); err != nil {
+			t.Fatalf("Invalid JSON: %v\n%s", err, env.Output())
+		}
+		got := map[string]string{}
+		for _, row := range rows {
+			got[row["ID"]] = row["HANDLE"]
+			if row["TAGS"] != "release" {
+				t.Errorf("Expected only release captures, got %v", row)
+			}
+		}
+		if len(rows) != 2 || got[first.ID] != ws.Handle || got[second.ID] != other.Handle {
+			t.Errorf("Expected %s in %s and %s in %s, got %v", first.ID, ws.Handle, second.ID, other.Handle, rows)
+		}
+
+		if err := env.Run(captures.Command(), []string{"--all", "--format", "raw"}); err != nil {
+			t.Fatalf("captures --all failed: %v", err)
+		}
+		if lines := strings.Count(env.Output(), "\n"); lines != 4 {
+			t.Errorf("Expected all 4 captures without a filter, got %d: %s", lines, env.Output())
+		}
+	})
+
 	t.Run("with invalid handle", func(t *testing.T) {
 		err := env.Run(captures.Command(), []string{"nonexistent-handle"})
 		if err == nil {
@@ -57,6 +140,142 @@ func TestCapturesCommand(t *testing.T) {
 	})
 }
 
+func TestCapturesTreeCommand(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("tree purpose", nil)
+	capture := func(opts workspace.CaptureOptions) *workspace.Capture {
+		t.Helper()
+		opts.Kind = workspace.CaptureKindManual
+		c, err := env.Store.CaptureState(env.Ctx, ws.Handle, opts)
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		return c
+	}
+	base := capture(workspace.CaptureOptions{Name: "base"})
+	step := capture(workspace.CaptureOptions{Name: "step", Parent: base.ID})
+	backup := capture(workspace.CaptureOptions{Name: "pre-apply", BackupOf: step.ID})
+
+	t.Run("json adjacency", func(t *testing.T) {
+		if err := env.Run(captures.Command(), []string{"tree", ws.Handle, "--format", "json"}); err != nil {
+			t.Fatalf("captures tree failed: %v", err)
+		}
+		var entries []captures.TreeEntry
+		if err := json.Unmarshal([]byte(env.Output()), &entries); err != nil {
+			t.Fatalf("Invalid JSON: %v\n%s", err, env.Output())
+		}
+		byID := map[string]captures.TreeEntry{}
+		for _, e := range entries {
+			byID[e.ID] = e
+		}
+		if len(entries) != 3 {
+			t.Fatalf("Expected 3 entries, got %+v", entries)
+		}
+		if got := byID[base.ID].Children; len(got) != 1 || got[0] != step.ID {
+			t.Errorf("Expected %s as the child of base, got %v", step.ID, got)
+		}
+		if got := byID[step.ID].Backups; len(got) != 1 || got[0] != backup.ID {
+			t.Errorf("Expected %s as the backup of step, got %v", backup.ID, got)
+		}
+		if byID[backup.ID].BackupOf != step.ID {
+			t.Errorf("Expected backup_of %s, got %+v", step.ID, byID[backup.ID])
+		}
+	})
+
+	t.Run("rendered tree", func(t *testing.T) {
+		if err := env.Run(captures.Command(), []string{"tree", ws.Handle}); err != nil {
+			t.Fatalf("captures tree failed: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(env.Output()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected 3 lines, got: %s", env.Output())
+		}
+		if !strings.HasPrefix(lines[0], base.ID) ||
+			!strings.HasPrefix(lines[1], "└── "+step.ID) ||
+			!strings.HasPrefix(lines[2], "    └── "+backup.ID) || !strings.Contains(lines[2], "[backup]") {
+			t.Errorf("Unexpected tree:\n%s", env.Output())
+		}
+	})
+}
+
+func TestReadOnlyCommandsWithoutGit(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("no git", nil)
+	if _, err := env.Store.CaptureState(env.Ctx, ws.Handle, workspace.CaptureOptions{Name: "before", Kind: workspace.CaptureKindCheckpoint}); err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+
+	for _, tc := range []struct {
+		name string
+		cmd  *cobra.Command
+		args []string
+	}{
+		{"list", list.Command(), nil},
+		{"inspect", inspect.Command(), []string{ws.Handle}},
+		{"path", path.Command(), []string{ws.Handle}},
+		{"captures", captures.Command(), []string{ws.Handle}},
+		{"repos list", repos.ListCommand(), []string{ws.Handle}},
+	} {
+		t.Run(tc.name+" works without git", func(t *testing.T) {
+			if err := env.Run(tc.cmd, tc.args); err != nil {
+				t.Errorf("%s should not need git: %v", tc.name, err)
+			}
+		})
+	}
+
+	t.Run("capture reports that git is required", func(t *testing.T) {
+		_, err := env.Store.CaptureState(env.Ctx, ws.Handle, workspace.CaptureOptions{Kind: workspace.CaptureKindCheckpoint})
+		if !errors.Is(err, git.ErrGitNotFound) {
+			t.Errorf("Expected ErrGitNotFound, got %v", err)
+		}
+	})
+}
+
+func TestLastCommand(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	t.Run("fails with no history", func(t *testing.T) {
+		if err := env.Run(last.Command(), nil); err == nil {
+			t.Error("last with no recent workspace should fail")
+		}
+	})
+
+	first := env.CreateWorkspace("first", nil)
+	second := env.CreateWorkspace("second", nil)
+
+	for _, ws := range []*workspace.Workspace{second, first} {
+		if err := env.Run(path.Command(), []string{ws.Handle}); err != nil {
+			t.Fatalf("path failed: %v", err)
+		}
+	}
+
+	t.Run("prints the most recently resolved handle", func(t *testing.T) {
+		if err := env.Run(last.Command(), nil); err != nil {
+			t.Fatalf("last failed: %v", err)
+		}
+		if got := strings.TrimSpace(env.Output()); got != first.Handle {
+			t.Errorf("expected %s, got %q", first.Handle, got)
+		}
+	})
+
+	t.Run("list --recent sorts by recency", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--recent", "--format", "raw"}); err != nil {
+			t.Fatalf("list --recent failed: %v", err)
+		}
+		want := first.Handle + "\n" + second.Handle + "\n"
+		if env.Output() != want {
+			t.Errorf("expected %q, got %q", want, env.Output())
+		}
+	})
+}
+
 func TestPathCommand(t *testing.T) {
 	env := NewCLIEnv(t)
 	defer env.Cleanup()
@@ -120,6 +339,113 @@ func TestInspectCommand(t *testing.T) {
 			t.Error("inspect with invalid handle should fail")
 		}
 	})
+
+	t.Run("json includes internal directories", func(t *testing.T) {
+		capture, err := env.Store.CaptureState(env.Ctx, ws.Handle, workspace.CaptureOptions{Name: "located", Kind: workspace.CaptureKindCheckpoint})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+
+		if err := env.Run(inspect.Command(), []string{ws.Handle, "--format", "json"}); err != nil {
+			t.Fatalf("inspect --format json should succeed: %v", err)
+		}
+		var data map[string]string
+		if err := json.Unmarshal([]byte(env.Output()), &data); err != nil {
+			t.Fatalf("Expected valid JSON output: %v, got: %s", err, env.Output())
+		}
+		wantCaptures := filepath.Join(ws.Path, ".workshed", "captures")
+		wantExecutions := filepath.Join(ws.Path, ".workshed", "executions")
+		if data["captures_dir"] != wantCaptures || data["executions_dir"] != wantExecutions {
+			t.Errorf("Expected captures_dir %s and executions_dir %s, got %v", wantCaptures, wantExecutions, data)
+		}
+		if !filepath.IsAbs(data["captures_dir"]) || !filepath.IsAbs(data["executions_dir"]) {
+			t.Errorf("Expected absolute paths, got %v", data)
+		}
+		if _, err := os.Stat(filepath.Join(data["captures_dir"], capture.ID)); err != nil {
+			t.Errorf("Expected the capture under captures_dir: %v", err)
+		}
+
+		if err := env.Run(inspect.Command(), []string{ws.Handle}); err != nil {
+			t.Fatalf("inspect should succeed: %v", err)
+		}
+		if strings.Contains(env.Output(), "captures_dir") || strings.Contains(env.Output(), "executions_dir") {
+			t.Errorf("Expected no internal directories in table output, got: %s", env.Output())
+		}
+	})
+
+	t.Run("--diff reports repository differences as json", func(t *testing.T) {
+		otherRepo := workspace.CreateLocalGitRepo(t, "otherrepo", map[string]string{"README.md": "# Other"})
+		other := env.CreateWorkspace("other purpose", []workspace.RepositoryOption{{URL: otherRepo, Ref: "main"}})
+
+		if err := env.Run(inspect.Command(), []string{ws.Handle, "--diff", other.Handle, "--format", "json"}); err != nil {
+			t.Fatalf("inspect --diff should succeed: %v", err)
+		}
+		var diff workspace.WorkspaceDiff
+		if err := json.Unmarshal([]byte(env.Output()), &diff); err != nil {
+			t.Fatalf("Expected valid JSON output: %v, got: %s", err, env.Output())
+		}
+		if len(diff.OnlyInLeft) != 1 || diff.OnlyInLeft[0] != "testrepo" {
+			t.Errorf("Expected testrepo only in left, got: %v", diff.OnlyInLeft)
+		}
+		if len(diff.OnlyInRight) != 1 || diff.OnlyInRight[0] != "otherrepo" {
+			t.Errorf("Expected otherrepo only in right, got: %v", diff.OnlyInRight)
+		}
+		if diff.RightPurpose != "other purpose" {
+			t.Errorf("Expected purpose difference, got: %+v", diff)
+		}
+	})
+
+	t.Run("--diff table output", func(t *testing.T) {
+		otherRepo := workspace.CreateLocalGitRepo(t, "tablerepo", map[string]string{"README.md": "# Table"})
+		other := env.CreateWorkspace("table purpose", []workspace.RepositoryOption{{URL: otherRepo, Ref: "main"}})
+
+		if err := env.Run(inspect.Command(), []string{ws.Handle, "--diff", other.Handle}); err != nil {
+			t.Fatalf("inspect --diff should succeed: %v", err)
+		}
+		if !strings.Contains(env.Output(), "only right") || !strings.Contains(env.Output(), "tablerepo") {
+			t.Errorf("Expected diff table with tablerepo, got: %s", env.Output())
+		}
+	})
+
+	t.Run("--diff with invalid handle", func(t *testing.T) {
+		if err := env.Run(inspect.Command(), []string{ws.Handle, "--diff", "nonexistent"}); err == nil {
+			t.Error("inspect --diff with invalid handle should fail")
+		}
+	})
+
+	t.Run("--repo shows one repository", func(t *testing.T) {
+		if err := env.Run(inspect.Command(), []string{ws.Handle, "--repo", "testrepo"}); err != nil {
+			t.Fatalf("inspect --repo should succeed: %v", err)
+		}
+		for _, want := range []string{"testrepo", "main", "clean"} {
+			if !strings.Contains(env.Output(), want) {
+				t.Errorf("Expected %q in output, got: %s", want, env.Output())
+			}
+		}
+	})
+
+	t.Run("--repo json", func(t *testing.T) {
+		if err := env.Run(inspect.Command(), []string{ws.Handle, "--repo", "testrepo", "--format", "json"}); err != nil {
+			t.Fatalf("inspect --repo --format json should succeed: %v", err)
+		}
+		var view inspect.RepoView
+		if err := json.Unmarshal([]byte(env.Output()), &view); err != nil {
+			t.Fatalf("Expected valid JSON output: %v, got: %s", err, env.Output())
+		}
+		if view.Name != "testrepo" || view.Branch != "main" || len(view.Commit) != 40 || view.Dirty {
+			t.Errorf("Unexpected repository view: %+v", view)
+		}
+		if view.Path != filepath.Join(ws.Path, "testrepo") {
+			t.Errorf("Expected path %s, got %s", filepath.Join(ws.Path, "testrepo"), view.Path)
+		}
+	})
+
+	t.Run("--repo with unknown name lists available repositories", func(t *testing.T) {
+		err := env.Run(inspect.Command(), []string{ws.Handle, "--repo", "missing"})
+		if err == nil || !strings.Contains(err.Error(), "repository not found: missing (available: testrepo)") {
+			t.Errorf("Expected not found error listing testrepo, got %v", err)
+		}
+	})
 }
 
 func TestHealthCommand(t *testing.T) {
@@ -141,6 +467,38 @@ func TestHealthCommand(t *testing.T) {
 			t.Error("health with invalid handle should fail")
 		}
 	})
+	t.Run("with an unknown --fail-on", func(t *testing.T) {
+		if err := env.Run(health.Command(), []string{ws.Handle, "--fail-on", "fatal"}); err == nil {
+			t.Error("health with an unknown --fail-on should fail")
+		}
+	})
+
+	t.Run("--fail-on decides whether a dirty tree fails", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(ws.Path, "testrepo", "wip.txt"), []byte("wip"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		if err := env.Run(health.Command(), []string{ws.Handle, "--fail-on", "error", "--format", "json"}); err != nil {
+			t.Errorf("a dirty tree should pass --fail-on error: %v", err)
+		}
+		var report struct {
+			Status string `json:"status"`
+			Issues []struct {
+				Kind     string `json:"kind"`
+				Severity string `json:"severity"`
+			} `json:"issues"`
+		}
+		if err := json.Unmarshal([]byte(env.Output()), &report); err != nil {
+			t.Fatalf("Invalid JSON: %v\n%s", err, env.Output())
+		}
+		if report.Status != "issues found" || len(report.Issues) != 1 || report.Issues[0].Severity != "warning" {
+			t.Errorf("Expected one warning in JSON output, got %+v", report)
+		}
+
+		if err := env.Run(health.Command(), []string{ws.Handle, "--fail-on", "warning"}); err == nil {
+			t.Error("a dirty tree should fail --fail-on warning")
+		}
+	})
 }
 
 func TestExportCommand(t *testing.T) {
@@ -172,6 +530,16 @@ func TestExportCommand(t *testing.T) {
 			t.Errorf("export json should contain handle, got: %s", env.Output())
 		}
 	})
+
+	t.Run("warns about local-path repositories", func(t *testing.T) {
+		err := env.Run(export.Command(), []string{ws.Handle})
+		if err != nil {
+			t.Fatalf("export should succeed: %v", err)
+		}
+		if !strings.Contains(env.ErrorOutput(), "will not import on another machine: testrepo") {
+			t.Errorf("export should warn about the local repo, stderr: %s", env.ErrorOutput())
+		}
+	})
 }
 
 func TestRemoveCommand(t *testing.T) {
@@ -194,6 +562,30 @@ func TestRemoveCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("--trash keeps the workspace restorable", func(t *testing.T) {
+		ws := env.CreateWorkspace("trash test", nil)
+		if err := env.Run(remove.Command(), []string{"-y", "--trash", ws.Handle}); err != nil {
+			t.Fatalf("remove --trash should succeed: %v", err)
+		}
+		if _, err := env.Store.Get(env.Ctx, ws.Handle); err == nil {
+			t.Fatal("Expected trashed workspace to be gone")
+		}
+
+		if err := env.Run(trash.ListCommand(), []string{"--format", "raw"}); err != nil {
+			t.Fatalf("trash list failed: %v", err)
+		}
+		if !strings.Contains(env.Output(), ws.Handle) {
+			t.Errorf("Expected trash list to show %s, got: %s", ws.Handle, env.Output())
+		}
+
+		if err := env.Run(trash.RestoreCommand(), []string{ws.Handle, "--format", "raw"}); err != nil {
+			t.Fatalf("trash restore failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(ws.Path, "testrepo", "README.md")); err != nil {
+			t.Errorf("Expected restored repository files: %v", err)
+		}
+	})
+
 	t.Run("dry-run flag", func(t *testing.T) {
 		ws := env.CreateWorkspace("dry-run test", nil)
 		err := env.Run(remove.Command(), []string{"--dry-run", ws.Handle})
@@ -201,6 +593,48 @@ func TestRemoveCommand(t *testing.T) {
 			t.Errorf("remove --dry-run should work: %v", err)
 		}
 	})
+
+	t.Run("mismatched --confirm-handle aborts", func(t *testing.T) {
+		ws := env.CreateWorkspace("confirm mismatch", nil)
+		err := env.Run(remove.Command(), []string{"-y", "--confirm-handle", "wrong-handle", ws.Handle})
+		if err == nil {
+			t.Error("remove with mismatched --confirm-handle should fail")
+		}
+		if _, err := env.Store.Get(env.Ctx, ws.Handle); err != nil {
+			t.Errorf("workspace should still exist after aborted remove: %v", err)
+		}
+	})
+
+	t.Run("matching --confirm-handle proceeds", func(t *testing.T) {
+		ws := env.CreateWorkspace("confirm match", nil)
+		err := env.Run(remove.Command(), []string{"--require-confirm", "--confirm-handle", ws.Handle, ws.Handle})
+		if err != nil {
+			t.Errorf("remove with matching --confirm-handle should succeed: %v", err)
+		}
+		if _, err := env.Store.Get(env.Ctx, ws.Handle); err == nil {
+			t.Error("workspace should be removed")
+		}
+	})
+
+	t.Run("--require-confirm without --confirm-handle aborts", func(t *testing.T) {
+		ws := env.CreateWorkspace("confirm required", nil)
+		err := env.Run(remove.Command(), []string{"-y", "--require-confirm", ws.Handle})
+		if err == nil {
+			t.Error("remove with --require-confirm and no --confirm-handle should fail")
+		}
+		if _, err := env.Store.Get(env.Ctx, ws.Handle); err != nil {
+			t.Errorf("workspace should still exist after aborted remove: %v", err)
+		}
+	})
+
+	t.Run("WORKSHED_REQUIRE_CONFIRM requires --confirm-handle", func(t *testing.T) {
+		ws := env.CreateWorkspace("confirm env", nil)
+		t.Setenv("WORKSHED_REQUIRE_CONFIRM", "1")
+		err := env.Run(remove.Command(), []string{"-y", ws.Handle})
+		if err == nil {
+			t.Error("remove with WORKSHED_REQUIRE_CONFIRM and no --confirm-handle should fail")
+		}
+	})
 }
 
 func TestUpdateCommand(t *testing.T) {
@@ -231,6 +665,53 @@ func TestUpdateCommand(t *testing.T) {
 	})
 }
 
+func TestRenameCommand(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("rename me", nil)
+	other := env.CreateWorkspace("taken", nil)
+
+	t.Run("renames to the new handle", func(t *testing.T) {
+		if err := env.Run(rename.Command(), []string{ws.Handle, "my-feature", "--format", "raw"}); err != nil {
+			t.Fatalf("rename should succeed: %v", err)
+		}
+		if !strings.Contains(env.Output(), "handle=my-feature") || !strings.Contains(env.Output(), "previous="+ws.Handle) {
+			t.Errorf("Expected old and new handle in output, got: %s", env.Output())
+		}
+		got, err := env.Store.Get(env.Ctx, "my-feature")
+		if err != nil || got.Purpose != "rename me" {
+			t.Errorf("Expected the workspace under its new handle, got %+v (err %v)", got, err)
+		}
+	})
+
+	t.Run("rejects a handle in use", func(t *testing.T) {
+		err := env.Run(rename.Command(), []string{"my-feature", other.Handle})
+		if err == nil || !strings.Contains(err.Error(), "workspace already exists") {
+			t.Errorf("Expected collision error, got %v", err)
+		}
+		if _, err := env.Store.Get(env.Ctx, "my-feature"); err != nil {
+			t.Errorf("Expected the workspace untouched: %v", err)
+		}
+	})
+
+	t.Run("dry run validates without renaming", func(t *testing.T) {
+		if err := env.Run(rename.Command(), []string{"my-feature", "planned", "--dry-run"}); err != nil {
+			t.Fatalf("rename --dry-run should succeed: %v", err)
+		}
+		if !strings.Contains(env.Output(), "rename workspace") {
+			t.Errorf("Expected a plan, got: %s", env.Output())
+		}
+		if _, err := env.Store.Get(env.Ctx, "planned"); err == nil {
+			t.Error("dry run renamed the workspace")
+		}
+
+		if err := env.Run(rename.Command(), []string{"my-feature", "../planned", "--dry-run"}); err == nil {
+			t.Error("Expected an invalid handle to fail under --dry-run")
+		}
+	})
+}
+
 func TestReposListCommand(t *testing.T) {
 	env := NewCLIEnv(t)
 	defer env.Cleanup()
@@ -244,6 +725,15 @@ func TestReposListCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("--with-status shows upstream tracking", func(t *testing.T) {
+		if err := env.Run(repos.ListCommand(), []string{ws.Handle, "--with-status", "--format", "json"}); err != nil {
+			t.Fatalf("repos list --with-status failed: %v", err)
+		}
+		if !strings.Contains(env.Output(), "clean, up to date") {
+			t.Errorf("expected a fresh clone to be clean and up to date, got: %s", env.Output())
+		}
+	})
+
 	t.Run("with invalid handle", func(t *testing.T) {
 		err := env.Run(repos.ListCommand(), []string{"nonexistent"})
 		if err == nil {
@@ -295,6 +785,40 @@ func TestReposRemoveCommand(t *testing.T) {
 	})
 }
 
+func TestReposFetchCommand(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("test purpose", nil)
+
+	t.Run("reports updated refs", func(t *testing.T) {
+		origin := ws.Repositories[0].URL
+		if err := workspace.AddGitCommit(origin, "Upstream change", map[string]string{"new.txt": "new"}); err != nil {
+			t.Fatalf("AddGitCommit failed: %v", err)
+		}
+
+		err := env.Run(repos.FetchCommand(), []string{ws.Handle, "--prune", "--format", "json"})
+		if err != nil {
+			t.Fatalf("repos fetch should work: %v", err)
+		}
+
+		var rows []map[string]string
+		if err := json.Unmarshal([]byte(env.Output()), &rows); err != nil {
+			t.Fatalf("Expected JSON output: %v, got: %s", err, env.Output())
+		}
+		if len(rows) != 1 || rows[0]["REPO"] != "testrepo" || rows[0]["UPDATED"] != "1" || rows[0]["STATUS"] != "ok" {
+			t.Errorf("Unexpected fetch summary: %v", rows)
+		}
+	})
+
+	t.Run("with unknown --repo", func(t *testing.T) {
+		err := env.Run(repos.FetchCommand(), []string{ws.Handle, "--repo", "missing"})
+		if err == nil {
+			t.Error("repos fetch with unknown repo should fail")
+		}
+	})
+}
+
 func TestApplyCommand(t *testing.T) {
 	env := NewCLIEnv(t)
 	defer env.Cleanup()
@@ -317,6 +841,164 @@ func TestApplyCommand(t *testing.T) {
 			t.Error("apply with invalid handle should fail")
 		}
 	})
+
+	t.Run("--continue with nothing remaining", func(t *testing.T) {
+		capture, err := env.Store.CaptureState(env.Ctx, ws.Handle, workspace.CaptureOptions{Name: "continue", Kind: workspace.CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		if err := env.Run(apply.Command(), []string{ws.Handle, capture.ID, "--continue", "--format", "raw"}); err != nil {
+			t.Fatalf("apply --continue should succeed: %v", err)
+		}
+		if !strings.Contains(env.Output(), "applied=none") {
+			t.Errorf("Expected nothing applied, got: %s", env.Output())
+		}
+	})
+
+	t.Run("-y applies and records the transitions", func(t *testing.T) {
+		capture, err := env.Store.CaptureState(env.Ctx, ws.Handle, workspace.CaptureOptions{Name: "confirm", Kind: workspace.CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		if err := env.Run(apply.Command(), []string{ws.Handle, capture.ID, "-y"}); err != nil {
+			t.Fatalf("apply -y should succeed: %v", err)
+		}
+
+		history, err := env.Store.ListHistory(env.Ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ListHistory failed: %v", err)
+		}
+		if len(history) == 0 || history[0].Operation != workspace.HistoryApply || history[0].CaptureID != capture.ID {
+			t.Fatalf("Expected the apply in history, got %+v", history)
+		}
+		changes := history[0].Changes
+		if len(changes) != 1 || changes[0].Repository != "testrepo" || changes[0].To != capture.GitState[0].Commit || changes[0].From != capture.GitState[0].Commit {
+			t.Errorf("Unexpected transitions: %+v", changes)
+		}
+	})
+}
+
+func TestApplyDryRun(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("dry-run apply", nil)
+	repoDir := filepath.Join(ws.Path, ws.Repositories[0].Name)
+	for _, kv := range [][]string{{"user.email", "test@test.com"}, {"user.name", "Test"}} {
+		cmd := exec.Command("git", "config", kv[0], kv[1])
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git config failed: %v\n%s", err, out)
+		}
+	}
+
+	capture, err := env.Store.CaptureState(env.Ctx, ws.Handle, workspace.CaptureOptions{Name: "before", Kind: workspace.CaptureKindManual})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+	target := capture.GitState[0].Commit
+
+	if err := workspace.AddGitCommit(repoDir, "Move on", map[string]string{"next.txt": "next"}); err != nil {
+		t.Fatalf("AddGitCommit failed: %v", err)
+	}
+	head := func() string {
+		out, err := exec.Command("git", "-C", repoDir, "rev-parse", "HEAD").Output()
+		if err != nil {
+			t.Fatalf("rev-parse failed: %v", err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	before := head()
+
+	t.Run("lists the checkout per repo", func(t *testing.T) {
+		if err := env.Run(apply.Command(), []string{ws.Handle, capture.ID, "--dry-run"}); err != nil {
+			t.Fatalf("apply --dry-run should succeed: %v", err)
+		}
+		output := env.Output()
+		if !strings.Contains(output, "git checkout "+target) {
+			t.Errorf("Expected checkout of %s, got: %s", target, output)
+		}
+		if !strings.Contains(output, "clean") {
+			t.Errorf("Expected clean status, got: %s", output)
+		}
+		if got := head(); got != before {
+			t.Errorf("HEAD moved from %s to %s", before, got)
+		}
+	})
+
+	t.Run("reports dirty tree blocks", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(repoDir, "next.txt"), []byte("dirty"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := env.Run(apply.Command(), []string{ws.Handle, capture.ID, "--dry-run", "--format", "json"}); err == nil {
+			t.Error("apply --dry-run with a dirty tree should fail")
+		}
+		output := env.Output()
+		if !strings.Contains(output, "git checkout "+target) {
+			t.Errorf("Expected checkout of %s, got: %s", target, output)
+		}
+		if !strings.Contains(output, "blocked") || !strings.Contains(output, workspace.ReasonDirtyWorkingTree) {
+			t.Errorf("Expected dirty tree block, got: %s", output)
+		}
+		if got := head(); got != before {
+			t.Errorf("HEAD moved from %s to %s", before, got)
+		}
+	})
+}
+
+func TestInvocationCWDFlag(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	withRoot := func(cmd *cobra.Command) *cobra.Command {
+		root := &cobra.Command{Use: "workshed"}
+		root.PersistentFlags().String("cwd", "", "")
+		root.AddCommand(cmd)
+		return root
+	}
+
+	t.Run("create resolves a relative repo against --cwd", func(t *testing.T) {
+		localRepo := workspace.CreateLocalGitRepo(t, "cwdrepo", map[string]string{"README.md": "# Test"})
+		err := env.Run(withRoot(create.Command()), []string{"create", "--cwd", filepath.Dir(localRepo), "--purpose", "cwd test", "--repo", "./cwdrepo@main", "--format", "json"})
+		if err != nil {
+			t.Fatalf("create with --cwd should work: %v", err)
+		}
+		var created map[string]any
+		if err := json.Unmarshal(env.OutBuf.Bytes(), &created); err != nil {
+			t.Fatalf("Expected JSON output: %v\n%s", err, env.Output())
+		}
+		ws, err := env.Store.Get(env.Ctx, created["handle"].(string))
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if len(ws.Repositories) != 1 || ws.Repositories[0].URL != localRepo {
+			t.Errorf("Expected repository %s, got %+v", localRepo, ws.Repositories)
+		}
+	})
+
+	t.Run("repos add resolves a relative repo against --cwd", func(t *testing.T) {
+		ws := env.CreateWorkspace("cwd add test", nil)
+		localRepo := workspace.CreateLocalGitRepo(t, "cwdadd", map[string]string{"README.md": "# Add"})
+		err := env.Run(withRoot(repos.Command()), []string{"repos", "add", ws.Handle, "--cwd", filepath.Dir(localRepo), "--repo", "cwdadd@main"})
+		if err != nil {
+			t.Fatalf("repos add with --cwd should work: %v", err)
+		}
+		updated, err := env.Store.Get(env.Ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if repo := updated.Repositories[len(updated.Repositories)-1]; repo.URL != localRepo {
+			t.Errorf("Expected repository %s, got %s", localRepo, repo.URL)
+		}
+	})
+
+	t.Run("rejects a missing directory", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing")
+		err := env.Run(withRoot(create.Command()), []string{"create", "--cwd", missing, "--purpose", "cwd missing", "--repo", "./repo"})
+		if err == nil || !strings.Contains(err.Error(), "invalid --cwd") {
+			t.Errorf("Expected an invalid --cwd error, got %v", err)
+		}
+	})
 }
 
 func TestCreateCommand(t *testing.T) {
@@ -349,6 +1031,273 @@ func TestCreateCommand(t *testing.T) {
 			t.Errorf("create json should contain purpose, got: %s", output)
 		}
 	})
+
+	t.Run("events jsonl", func(t *testing.T) {
+		repoA := workspace.CreateLocalGitRepo(t, "events-a", map[string]string{"README.md": "# A"})
+		repoB := workspace.CreateLocalGitRepo(t, "events-b", map[string]string{"README.md": "# B"})
+		err := env.Run(create.Command(), []string{"--purpose", "events test", "--repo", repoA + "@main", "--repo", repoB + "@main", "--events", "jsonl", "--concurrency", "1"})
+		if err != nil {
+			t.Fatalf("create --events jsonl should work: %v", err)
+		}
+
+		events := parseEvents(t, env.Output())
+		want := []string{"clone_start:events-a", "clone_done:events-a", "clone_start:events-b", "clone_done:events-b", "summary:"}
+		if len(events) != len(want) {
+			t.Fatalf("Expected %d events, got %d: %s", len(want), len(events), env.Output())
+		}
+		for i, event := range events {
+			if got := event.Type + ":" + event.Repo; got != want[i] {
+				t.Errorf("event %d = %s, want %s", i, got, want[i])
+			}
+		}
+		summary := events[len(events)-1]
+		if summary.Handle == "" || summary.Path == "" {
+			t.Errorf("summary should include handle and path, got: %+v", summary)
+		}
+		if summary.Repos != 2 {
+			t.Errorf("summary repos = %d, want 2", summary.Repos)
+		}
+	})
+
+	t.Run("--verbose prints git output on failure", func(t *testing.T) {
+		localRepo := workspace.CreateLocalGitRepo(t, "verboserepo", map[string]string{"README.md": "# Test"})
+		err := env.Run(create.Command(), []string{"--purpose", "verbose test", "--repo", localRepo + "@no-such-branch", "--verbose"})
+		if err == nil {
+			t.Fatal("create with missing ref should fail")
+		}
+		if !strings.Contains(err.Error(), "no-such-branch") {
+			t.Errorf("error should include git's message, got: %v", err)
+		}
+		if !strings.Contains(env.ErrorOutput(), "git output:") {
+			t.Errorf("--verbose should print git output, stderr: %s", env.ErrorOutput())
+		}
+	})
+
+	t.Run("unknown events format", func(t *testing.T) {
+		err := env.Run(create.Command(), []string{"--purpose", "bad events", "--events", "xml"})
+		if err == nil {
+			t.Error("create with unknown --events format should fail")
+		}
+	})
+}
+
+func parseEvents(t *testing.T, output string) []cli.Event {
+	t.Helper()
+	var events []cli.Event
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var event cli.Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("Expected JSON line, got %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestCloneDepth(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	withHistory := func(t *testing.T, name string) string {
+		t.Helper()
+		dir := workspace.CreateLocalGitRepo(t, name, map[string]string{"README.md": "# " + name})
+		for i := range 3 {
+			if err := workspace.AddGitCommit(dir, fmt.Sprintf("Commit %d", i), map[string]string{"file.txt": strconv.Itoa(i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+	commits := func(t *testing.T, dir string) string {
+		t.Helper()
+		cmd := exec.Command("git", "rev-list", "--count", "HEAD")
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("git rev-list failed: %v", err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	api, web := withHistory(t, "api"), withHistory(t, "web")
+	if err := env.Run(create.Command(), []string{"--purpose", "shallow", "--depth", "2", "--repo", api + "@main", "--repo", web + "@main::1"}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	workspaces, err := env.Store.List(env.Ctx, workspace.ListOptions{PurposeFilter: "shallow"})
+	if err != nil || len(workspaces) != 1 {
+		t.Fatalf("Expected one workspace, got %d (%v)", len(workspaces), err)
+	}
+	ws := workspaces[0]
+
+	t.Run("--depth applies to every repository and ::N overrides it", func(t *testing.T) {
+		want := map[string]int{"api": 2, "web": 1}
+		for _, repo := range ws.Repositories {
+			if repo.Depth != want[repo.Name] {
+				t.Errorf("Expected %s to record depth %d, got %d", repo.Name, want[repo.Name], repo.Depth)
+			}
+			if got := commits(t, filepath.Join(ws.Path, repo.Name)); got != strconv.Itoa(want[repo.Name]) {
+				t.Errorf("Expected %s to be cloned with %d commits, got %s", repo.Name, want[repo.Name], got)
+			}
+		}
+	})
+
+	t.Run("repos add honours ::N", func(t *testing.T) {
+		docs := withHistory(t, "docs")
+		if err := env.Run(repos.AddCommand(), []string{ws.Handle, "--repo", docs + "@main::1"}); err != nil {
+			t.Fatalf("repos add failed: %v", err)
+		}
+		if got := commits(t, filepath.Join(ws.Path, "docs")); got != "1" {
+			t.Errorf("Expected docs to be cloned with 1 commit, got %s", got)
+		}
+	})
+}
+
+func TestLockCommand(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	sourceRepo := workspace.CreateLocalGitRepo(t, "lockrepo", map[string]string{"README.md": "# Lock"})
+	ws := env.CreateWorkspace("lock test", []workspace.RepositoryOption{{URL: sourceRepo, Ref: "main"}})
+	lockPath := filepath.Join(t.TempDir(), "workshed.lock")
+
+	if err := env.Run(lock.Command(), []string{ws.Handle, "--output", lockPath}); err != nil {
+		t.Fatalf("lock should work: %v", err)
+	}
+
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		t.Fatalf("lockfile should be written: %v", err)
+	}
+	var lockfile workspace.Lockfile
+	if err := json.Unmarshal(data, &lockfile); err != nil {
+		t.Fatalf("lockfile should be valid JSON: %v", err)
+	}
+	if len(lockfile.Repositories) != 1 || lockfile.Repositories[0].Commit == "" {
+		t.Fatalf("lockfile should pin one commit, got: %+v", lockfile.Repositories)
+	}
+
+	t.Run("create --lock restores locked commits", func(t *testing.T) {
+		if err := workspace.AddGitCommit(sourceRepo, "Advance", map[string]string{"CHANGES.md": "advanced"}); err != nil {
+			t.Fatalf("AddGitCommit failed: %v", err)
+		}
+
+		if err := env.Run(create.Command(), []string{"--purpose", "from lock", "--lock", lockPath, "--format", "raw"}); err != nil {
+			t.Fatalf("create --lock should work: %v", err)
+		}
+		handle := strings.TrimSpace(env.Output())
+
+		restored, err := env.Store.Lock(env.Ctx, handle)
+		if err != nil {
+			t.Fatalf("Lock failed: %v", err)
+		}
+		if restored.Repositories[0].Commit != lockfile.Repositories[0].Commit {
+			t.Errorf("restored commit = %s, want %s", restored.Repositories[0].Commit, lockfile.Repositories[0].Commit)
+		}
+	})
+
+	t.Run("create --like copies the source commits", func(t *testing.T) {
+		source := env.CreateWorkspace("like source", []workspace.RepositoryOption{{URL: sourceRepo, Ref: "main"}})
+		want, err := env.Store.Lock(env.Ctx, source.Handle)
+		if err != nil {
+			t.Fatalf("Lock failed: %v", err)
+		}
+		if err := workspace.AddGitCommit(sourceRepo, "Advance again", map[string]string{"LATER.md": "later"}); err != nil {
+			t.Fatalf("AddGitCommit failed: %v", err)
+		}
+
+		if err := env.Run(create.Command(), []string{"--purpose", "like source", "--like", source.Handle, "--format", "raw"}); err != nil {
+			t.Fatalf("create --like should work: %v", err)
+		}
+		handle := strings.TrimSpace(env.Output())
+		if handle == source.Handle {
+			t.Fatal("create --like should create a new workspace")
+		}
+
+		got, err := env.Store.Lock(env.Ctx, handle)
+		if err != nil {
+			t.Fatalf("Lock failed: %v", err)
+		}
+		if len(got.Repositories) != len(want.Repositories) {
+			t.Fatalf("Expected %d repositories, got %+v", len(want.Repositories), got.Repositories)
+		}
+		for i, repo := range got.Repositories {
+			if repo.URL != want.Repositories[i].URL || repo.Commit != want.Repositories[i].Commit {
+				t.Errorf("Expected %s at %s, got %s at %s", want.Repositories[i].URL, want.Repositories[i].Commit, repo.URL, repo.Commit)
+			}
+		}
+	})
+
+	t.Run("create --like with unknown workspace fails", func(t *testing.T) {
+		if err := env.Run(create.Command(), []string{"--purpose", "missing source", "--like", "no-such-workspace"}); err == nil {
+			t.Error("create --like with an unknown workspace should fail")
+		}
+	})
+
+	t.Run("create --lock with missing file fails", func(t *testing.T) {
+		err := env.Run(create.Command(), []string{"--purpose", "missing lock", "--lock", filepath.Join(t.TempDir(), "missing.lock")})
+		if err == nil {
+			t.Error("create with missing lockfile should fail")
+		}
+	})
+}
+
+func TestExecutionsPruneCommand(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("prune test", nil)
+	for i := 1; i <= 3; i++ {
+		record := workspace.ExecutionRecord{ID: fmt.Sprintf("exec-%02d", i), Command: []string{"pwd"}}
+		if err := env.Store.RecordExecution(env.Ctx, ws.Handle, record, nil); err != nil {
+			t.Fatalf("RecordExecution failed: %v", err)
+		}
+	}
+
+	if err := env.Run(executions.Command(), []string{"prune", ws.Handle, "--keep", "1", "--format", "raw"}); err != nil {
+		t.Fatalf("executions prune should work: %v", err)
+	}
+	if output := env.Output(); output != "exec-02\nexec-01\n" {
+		t.Errorf("Expected oldest executions pruned, got: %q", output)
+	}
+
+	records, err := env.Store.ListExecutions(env.Ctx, ws.Handle, workspace.ListExecutionsOptions{})
+	if err != nil {
+		t.Fatalf("ListExecutions failed: %v", err)
+	}
+	if len(records) != 1 || records[0].ID != "exec-03" {
+		t.Errorf("Expected only exec-03 retained, got: %+v", records)
+	}
+}
+
+func TestExecutionsRetentionCommand(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("retention test", nil)
+
+	if err := env.Run(executions.Command(), []string{"retention", ws.Handle, "--keep", "2", "--format", "json"}); err != nil {
+		t.Fatalf("executions retention should work: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		record := workspace.ExecutionRecord{ID: fmt.Sprintf("exec-%02d", i), Command: []string{"pwd"}}
+		if err := env.Store.RecordExecution(env.Ctx, ws.Handle, record, nil); err != nil {
+			t.Fatalf("RecordExecution failed: %v", err)
+		}
+	}
+	records, err := env.Store.ListExecutions(env.Ctx, ws.Handle, workspace.ListExecutionsOptions{})
+	if err != nil {
+		t.Fatalf("ListExecutions failed: %v", err)
+	}
+	if len(records) != 2 {
+		t.Errorf("Expected the workspace policy to keep 2 executions, got: %+v", records)
+	}
+
+	if err := env.Run(executions.Command(), []string{"retention", ws.Handle, "--clear"}); err != nil {
+		t.Fatalf("executions retention --clear should work: %v", err)
+	}
+	if got, _ := env.Store.Get(env.Ctx, ws.Handle); got.Retention != nil {
+		t.Errorf("Expected the workspace policy to be cleared, got %+v", got.Retention)
+	}
 }
 
 func TestListCommand(t *testing.T) {
@@ -371,6 +1320,67 @@ func TestListCommand(t *testing.T) {
 			t.Errorf("list --format json should work: %v", err)
 		}
 	})
+
+	t.Run("created range", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--created-after", "1d", "--format", "raw"}); err != nil {
+			t.Fatalf("list --created-after should work: %v", err)
+		}
+		if lines := strings.Fields(env.Output()); len(lines) != 2 {
+			t.Errorf("Expected both workspaces, got: %q", env.Output())
+		}
+
+		if err := env.Run(list.Command(), []string{"--created-before", "1d", "--format", "json"}); err != nil {
+			t.Fatalf("list --created-before should work: %v", err)
+		}
+		if strings.TrimSpace(env.Output()) != "[]" {
+			t.Errorf("Expected empty list, got: %q", env.Output())
+		}
+	})
+
+	t.Run("invalid created range", func(t *testing.T) {
+		err := env.Run(list.Command(), []string{"--created-after", "last tuesday"})
+		if err == nil || !strings.Contains(err.Error(), "invalid --created-after") {
+			t.Errorf("Expected invalid --created-after error, got %v", err)
+		}
+
+		err = env.Run(list.Command(), []string{"--created-after", "1d", "--created-before", "7d"})
+		if err == nil {
+			t.Error("Expected error when --created-after is later than --created-before")
+		}
+	})
+
+	t.Run("active", func(t *testing.T) {
+		recent := env.CreateWorkspace("recently run", nil)
+		old := env.CreateWorkspace("run long ago", nil)
+		for ws, finished := range map[string]time.Time{recent.Handle: time.Now().Add(-time.Minute), old.Handle: time.Now().Add(-3 * time.Hour)} {
+			record := workspace.ExecutionRecord{ID: "exec-" + ws, Handle: ws, Timestamp: finished, StartedAt: finished, CompletedAt: finished, Command: []string{"make"}}
+			if err := env.Store.RecordExecution(env.Ctx, ws, record, nil); err != nil {
+				t.Fatalf("RecordExecution failed: %v", err)
+			}
+		}
+
+		if err := env.Run(list.Command(), []string{"--active", "--format", "json"}); err != nil {
+			t.Fatalf("list --active should work: %v", err)
+		}
+		var rows []map[string]any
+		if err := json.Unmarshal([]byte(env.Output()), &rows); err != nil {
+			t.Fatalf("Expected JSON output: %v, got: %s", err, env.Output())
+		}
+		if len(rows) != 1 || rows[0]["HANDLE"] != recent.Handle {
+			t.Errorf("Expected only %s, got: %v", recent.Handle, rows)
+		}
+
+		if err := env.Run(list.Command(), []string{"--active", "--active-within", "4h", "--format", "raw"}); err != nil {
+			t.Fatalf("list --active --active-within should work: %v", err)
+		}
+		if lines := strings.Fields(env.Output()); len(lines) != 2 {
+			t.Errorf("Expected both run workspaces within 4h, got: %q", env.Output())
+		}
+
+		if err := env.Run(list.Command(), []string{"--active-within", "1h"}); err == nil {
+			t.Error("Expected --active-within without --active to fail")
+		}
+	})
 }
 
 func TestCaptureCommand(t *testing.T) {
@@ -399,6 +1409,28 @@ func TestCaptureCommand(t *testing.T) {
 			t.Errorf("capture --format json should work: %v", err)
 		}
 	})
+
+	t.Run("--compress-captures", func(t *testing.T) {
+		if err := env.Run(capture.Command(), []string{"--name", "packed", "--compress-captures", "--format", "raw", ws.Handle}); err != nil {
+			t.Fatalf("capture --compress-captures should work: %v", err)
+		}
+		id := strings.TrimSpace(env.Output())
+		archive := filepath.Join(ws.Path, ".workshed", "captures", id, "capture.tar.gz")
+		if _, err := os.Stat(archive); err != nil {
+			t.Fatalf("Expected %s: %v", archive, err)
+		}
+
+		if err := env.Run(captures.Command(), []string{ws.Handle, "--format", "json"}); err != nil {
+			t.Fatalf("captures should work: %v", err)
+		}
+		if !strings.Contains(env.Output(), id) {
+			t.Errorf("Expected compressed capture in list, got: %s", env.Output())
+		}
+
+		if err := env.Run(apply.Command(), []string{ws.Handle, "packed"}); err != nil {
+			t.Errorf("apply of a compressed capture should work: %v", err)
+		}
+	})
 }
 
 func TestImportCommand(t *testing.T) {
@@ -424,6 +1456,26 @@ func TestImportCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("rejects an export from a newer version", func(t *testing.T) {
+		ws := env.CreateWorkspace("future workspace", nil)
+		exportData, err := env.Store.ExportContext(env.Ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		exportData.Version = workspace.ContextVersion + 1
+
+		jsonData, _ := json.MarshalIndent(exportData, "", "  ")
+		tmpFile := filepath.Join(env.Root, "future.json")
+		if err := os.WriteFile(tmpFile, jsonData, 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		err = env.Run(importcmd.Command(), []string{tmpFile})
+		if err == nil || !strings.Contains(err.Error(), "newer workshed") {
+			t.Errorf("Expected upgrade error, got %v", err)
+		}
+	})
+
 	t.Run("with positional arg", func(t *testing.T) {
 		ws := env.CreateWorkspace("another workspace", nil)
 		exportData, err := env.Store.ExportContext(env.Ctx, ws.Handle)
@@ -494,6 +1546,63 @@ func TestImportCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("with --url", func(t *testing.T) {
+		ws := env.CreateWorkspace("fetched workspace", nil)
+		exportData, err := env.Store.ExportContext(env.Ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		jsonData, _ := json.MarshalIndent(exportData, "", "  ")
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(jsonData)
+		}))
+		defer server.Close()
+
+		err = env.Run(importcmd.Command(), []string{"--url", server.URL + "/workspace.json", "--insecure", "--format", "json"})
+		if err != nil {
+			t.Fatalf("import --url should work: %v", err)
+		}
+
+		var rows []map[string]string
+		if err := json.Unmarshal([]byte(env.Output()), &rows); err != nil {
+			t.Fatalf("Invalid JSON: %v\n%s", err, env.Output())
+		}
+		var handle string
+		for _, row := range rows {
+			if row["KEY"] == "handle" {
+				handle = row["VALUE"]
+			}
+		}
+		imported, err := env.Store.Get(env.Ctx, handle)
+		if err != nil {
+			t.Fatalf("imported workspace %q not found: %v", handle, err)
+		}
+		if imported.Purpose != "fetched workspace" {
+			t.Errorf("Expected purpose %q, got %q", "fetched workspace", imported.Purpose)
+		}
+	})
+
+	t.Run("rejects plain http without --insecure", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("server should not be contacted")
+		}))
+		defer server.Close()
+
+		err := env.Run(importcmd.Command(), []string{"--url", server.URL})
+		if err == nil || !strings.Contains(err.Error(), "--insecure") {
+			t.Errorf("Expected plain HTTP to be refused, got: %v", err)
+		}
+	})
+
+	t.Run("with --url and a file", func(t *testing.T) {
+		err := env.Run(importcmd.Command(), []string{"workspace.json", "--url", "https://example.com/workspace.json"})
+		if err == nil {
+			t.Error("import with both a file and --url should fail")
+		}
+	})
+
 	t.Run("missing file", func(t *testing.T) {
 		err := env.Run(importcmd.Command(), []string{"--file", "/nonexistent/file.json"})
 		if err == nil {
@@ -555,3 +1664,65 @@ func TestImportCommand(t *testing.T) {
 		}
 	})
 }
+
+func TestApplyLatest(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws, err := env.Store.Create(env.Ctx, workspace.CreateOptions{
+		Purpose:      "apply latest",
+		Repositories: []workspace.RepositoryOption{},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	appliedID := func(args ...string) string {
+		t.Helper()
+		if err := env.Run(apply.Command(), append(args, "--format", "json")); err != nil {
+			t.Fatalf("apply %v failed: %v", args, err)
+		}
+		var applied workspace.Capture
+		if err := json.Unmarshal([]byte(env.Output()), &applied); err != nil {
+			t.Fatalf("Invalid JSON: %v\n%s", err, env.Output())
+		}
+		return applied.ID
+	}
+
+	t.Run("no captures is a clear error", func(t *testing.T) {
+		err := env.Run(apply.Command(), []string{ws.Handle, "--latest"})
+		if err == nil || !strings.Contains(err.Error(), "no captures found") {
+			t.Errorf("Expected no captures error, got: %v", err)
+		}
+	})
+
+	capture := func(name string, tags ...string) *workspace.Capture {
+		c, err := env.Store.CaptureState(env.Ctx, ws.Handle, workspace.CaptureOptions{Name: name, Kind: workspace.CaptureKindCheckpoint, Tags: tags})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		return c
+	}
+	capture("old keep", "keep")
+	newestKeep := capture("new keep", "keep")
+	newest := capture("newest")
+
+	t.Run("latest applies the most recent capture", func(t *testing.T) {
+		if got := appliedID(ws.Handle, "--latest"); got != newest.ID {
+			t.Errorf("Expected %s, got %s", newest.ID, got)
+		}
+	})
+
+	t.Run("latest-tag picks the newest tagged capture", func(t *testing.T) {
+		if got := appliedID(ws.Handle, "--latest-tag", "keep"); got != newestKeep.ID {
+			t.Errorf("Expected %s, got %s", newestKeep.ID, got)
+		}
+	})
+
+	t.Run("unknown tag fails", func(t *testing.T) {
+		err := env.Run(apply.Command(), []string{ws.Handle, "--latest-tag", "missing"})
+		if err == nil || !strings.Contains(err.Error(), `no captures tagged "missing"`) {
+			t.Errorf("Expected missing tag error, got: %v", err)
+		}
+	})
+}
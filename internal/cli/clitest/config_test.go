@@ -0,0 +1,165 @@
+package clitest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/cli/configcmd"
+	"github.com/frodi/workshed/internal/cli/create"
+	"github.com/frodi/workshed/internal/workspace"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("# defaults\ndepth: 1\ndefault-ref: \"main\"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	depthOf := func(t *testing.T, purpose string) int {
+		t.Helper()
+		workspaces, err := env.Store.List(env.Ctx, workspace.ListOptions{PurposeFilter: purpose})
+		if err != nil || len(workspaces) != 1 {
+			t.Fatalf("Expected one workspace for %q, got %d (%v)", purpose, len(workspaces), err)
+		}
+		return workspaces[0].Repositories[0].Depth
+	}
+
+	run := func(t *testing.T, args ...string) {
+		t.Helper()
+		defaults, err := cli.LoadDefaults(configPath)
+		if err != nil {
+			t.Fatalf("LoadDefaults failed: %v", err)
+		}
+		cmd := create.Command()
+		if err := cli.ApplyConfigDefaults(cmd, defaults); err != nil {
+			t.Fatalf("ApplyConfigDefaults failed: %v", err)
+		}
+		if err := env.Run(cmd, args); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+
+	t.Run("config sets the default depth", func(t *testing.T) {
+		repo := workspace.CreateLocalGitRepo(t, "configrepo", map[string]string{"README.md": "# Test"})
+		run(t, "--purpose", "config default", "--repo", repo)
+		if got := depthOf(t, "config default"); got != 1 {
+			t.Errorf("Expected depth 1 from config, got %d", got)
+		}
+	})
+
+	t.Run("environment overrides config", func(t *testing.T) {
+		t.Setenv("WORKSHED_DEPTH", "2")
+		repo := workspace.CreateLocalGitRepo(t, "configrepo", map[string]string{"README.md": "# Test"})
+		run(t, "--purpose", "env override", "--repo", repo)
+		if got := depthOf(t, "env override"); got != 2 {
+			t.Errorf("Expected depth 2 from environment, got %d", got)
+		}
+	})
+
+	t.Run("explicit flag overrides config", func(t *testing.T) {
+		t.Setenv("WORKSHED_DEPTH", "2")
+		repo := workspace.CreateLocalGitRepo(t, "configrepo", map[string]string{"README.md": "# Test"})
+		run(t, "--purpose", "flag override", "--repo", repo, "--depth", "3")
+		if got := depthOf(t, "flag override"); got != 3 {
+			t.Errorf("Expected depth 3 from --depth, got %d", got)
+		}
+	})
+
+	t.Run("missing config file changes nothing", func(t *testing.T) {
+		defaults, err := cli.LoadDefaults(filepath.Join(t.TempDir(), "missing.yaml"))
+		if err != nil {
+			t.Fatalf("LoadDefaults failed: %v", err)
+		}
+		if len(defaults) != 0 {
+			t.Errorf("Expected no defaults, got %v", defaults)
+		}
+	})
+
+	t.Run("rejects unknown settings and bad values", func(t *testing.T) {
+		if _, err := cli.ParseConfig(strings.NewReader("deph: 1\n")); err == nil {
+			t.Error("Expected an unknown setting to be rejected")
+		}
+		if err := cli.ApplyConfigDefaults(create.Command(), map[string]string{"depth": "deep"}); err == nil {
+			t.Error("Expected a non-numeric depth to be rejected")
+		}
+	})
+}
+
+func TestConfigCommand(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	configPath := filepath.Join(t.TempDir(), "workshed", "config.yaml")
+	t.Setenv("WORKSHED_CONFIG", configPath)
+	t.Setenv("WORKSHED_DEPTH", "")
+
+	t.Run("set then get reads the value back", func(t *testing.T) {
+		if err := env.Run(configcmd.SetCommand(), []string{"depth", "1"}); err != nil {
+			t.Fatalf("config set failed: %v", err)
+		}
+		if err := env.Run(configcmd.GetCommand(), []string{"depth"}); err != nil {
+			t.Fatalf("config get failed: %v", err)
+		}
+		if got := strings.TrimSpace(env.Output()); got != "1" {
+			t.Errorf("Expected depth 1, got %q", got)
+		}
+	})
+
+	t.Run("set replaces the existing line and keeps comments", func(t *testing.T) {
+		if err := os.WriteFile(configPath, []byte("# defaults\ndepth: 1\n"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := env.Run(configcmd.SetCommand(), []string{"depth", "5"}); err != nil {
+			t.Fatalf("config set failed: %v", err)
+		}
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if string(data) != "# defaults\ndepth: 5\n" {
+			t.Errorf("Unexpected config file:\n%s", data)
+		}
+	})
+
+	t.Run("list shows the source of each value", func(t *testing.T) {
+		t.Setenv("WORKSHED_CONCURRENCY", "3")
+		if err := env.Run(configcmd.ListCommand(), []string{"--format", "json"}); err != nil {
+			t.Fatalf("config list failed: %v", err)
+		}
+		for _, want := range []string{`"file"`, `"WORKSHED_CONCURRENCY"`, `"unset"`} {
+			if !strings.Contains(env.Output(), want) {
+				t.Errorf("Expected list output to contain %s, got: %s", want, env.Output())
+			}
+		}
+	})
+
+	t.Run("rejects an unknown key", func(t *testing.T) {
+		if err := env.Run(configcmd.SetCommand(), []string{"root", "/tmp"}); err == nil || !strings.Contains(err.Error(), "unknown setting") {
+			t.Errorf("Expected unknown setting error, got %v", err)
+		}
+		if err := env.Run(configcmd.GetCommand(), []string{"root"}); err == nil {
+			t.Error("Expected get of an unknown key to fail")
+		}
+	})
+
+	t.Run("rejects a value of the wrong type", func(t *testing.T) {
+		for _, args := range [][]string{{"depth", "deep"}, {"trash", "maybe"}, {"concurrency", "0"}, {"compress-captures", "gzip"}} {
+			if err := env.Run(configcmd.SetCommand(), args); err == nil || !strings.Contains(err.Error(), "invalid value") {
+				t.Errorf("Expected %v to be rejected, got %v", args, err)
+			}
+		}
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if strings.Contains(string(data), "deep") {
+			t.Error("Expected a rejected value not to be written")
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package clitest
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frodi/workshed/internal/cli/apply"
+	"github.com/frodi/workshed/internal/cli/capture"
+	"github.com/frodi/workshed/internal/cli/captures"
+	"github.com/frodi/workshed/internal/cli/configcmd"
+	"github.com/frodi/workshed/internal/cli/create"
+	"github.com/frodi/workshed/internal/cli/doctor"
+	"github.com/frodi/workshed/internal/cli/envcmd"
+	"github.com/frodi/workshed/internal/cli/executions"
+	"github.com/frodi/workshed/internal/cli/export"
+	"github.com/frodi/workshed/internal/cli/importcmd"
+	"github.com/frodi/workshed/internal/cli/lock"
+	"github.com/frodi/workshed/internal/cli/remove"
+	"github.com/frodi/workshed/internal/cli/repos"
+	"github.com/frodi/workshed/internal/cli/stash"
+	"github.com/frodi/workshed/internal/cli/trash"
+	"github.com/frodi/workshed/internal/cli/update"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+type fileState struct {
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// snapshotTree records every file and directory under root.
+func snapshotTree(t *testing.T, root string) map[string]fileState {
+	t.Helper()
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[path] = fileState{size: info.Size(), mode: info.Mode(), modTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDir failed: %v", err)
+	}
+	return files
+}
+
+func TestDryRunMakesNoChanges(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("dry run", nil)
+	base, err := env.Store.CaptureState(env.Ctx, ws.Handle, workspace.CaptureOptions{Name: "base", Kind: workspace.CaptureKindManual})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+	extra := workspace.CreateLocalGitRepo(t, "extra", map[string]string{"README.md": "# Extra"})
+
+	exported, err := env.Store.ExportContext(env.Ctx, ws.Handle)
+	if err != nil {
+		t.Fatalf("ExportContext failed: %v", err)
+	}
+	data, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	exportFile := filepath.Join(t.TempDir(), "export.json")
+	if err := os.WriteFile(exportFile, data, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	manifest := filepath.Join(t.TempDir(), "repos.txt")
+	if err := os.WriteFile(manifest, []byte(extra+"@main\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	record := workspace.ExecutionRecord{ID: "01HVABCDEFGHJKMNPQRSTVWXYZ", Command: []string{"true"}}
+	if err := env.Store.RecordExecution(env.Ctx, ws.Handle, record, nil); err != nil {
+		t.Fatalf("RecordExecution failed: %v", err)
+	}
+	if err := env.Store.SetWorkspaceEnv(env.Ctx, ws.Handle, map[string]string{"DEBUG": "1"}); err != nil {
+		t.Fatalf("SetWorkspaceEnv failed: %v", err)
+	}
+
+	shallow := env.CreateWorkspace("shallow", []workspace.RepositoryOption{{URL: extra, Ref: "main", Depth: 1}})
+	missing := env.CreateWorkspace("missing", nil)
+	if err := os.RemoveAll(filepath.Join(missing.Path, "testrepo")); err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+
+	dirty := env.CreateWorkspace("dirty", nil)
+	dirtyFile := filepath.Join(dirty.Path, "testrepo", "notes.txt")
+	if err := os.WriteFile(dirtyFile, []byte("stashed"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := env.Store.Stash(env.Ctx, dirty.Handle); err != nil {
+		t.Fatalf("Stash failed: %v", err)
+	}
+	if err := os.WriteFile(dirtyFile, []byte("still dirty"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	trashed := env.CreateWorkspace("trashed", nil)
+	if _, err := env.Store.TrashWorkspace(env.Ctx, trashed.Handle); err != nil {
+		t.Fatalf("TrashWorkspace failed: %v", err)
+	}
+
+	// Point the config file and doctor's completion directory into the
+	// snapshotted root, so writes to either show up as changes.
+	configFile := filepath.Join(env.Root, "config.yaml")
+	if err := os.WriteFile(configFile, []byte("depth: 1\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv("WORKSHED_CONFIG", configFile)
+	t.Setenv("SHELL", "/bin/bash")
+	t.Setenv("XDG_DATA_HOME", filepath.Join(env.Root, "data"))
+	doctorCommand := func() *cobra.Command { return doctor.NewCommand(&cobra.Command{Use: "workshed"}) }
+
+	tests := []struct {
+		name string
+		cmd  func() *cobra.Command
+		args []string
+		want string
+	}{
+		{"create", create.Command, []string{"--purpose", "planned", "--repo", extra, "--dry-run"}, "clone repository"},
+		{"create --like", create.Command, []string{"--purpose", "planned", "--like", ws.Handle, "--dry-run"}, "checkout"},
+		{"repos add", repos.AddCommand, []string{ws.Handle, "--repo", extra, "--dry-run"}, "clone repository"},
+		{"repos remove", repos.RemoveCommand, []string{ws.Handle, "--repo", "testrepo", "--dry-run"}, "delete repository"},
+		{"repos rename", repos.RenameCommand, []string{ws.Handle, "--repo", "testrepo", "--to", "renamed", "--dry-run"}, "rename repository"},
+		{"update", update.Command, []string{ws.Handle, "--purpose", "changed", "--dry-run"}, "set purpose"},
+		{"import", importcmd.Command, []string{exportFile, "--dry-run"}, "create workspace"},
+		{"apply", apply.Command, []string{ws.Handle, base.ID, "--dry-run"}, "git checkout"},
+		{"remove", remove.Command, []string{ws.Handle, "--dry-run"}, "remove workspace"},
+		{"remove --trash", remove.Command, []string{ws.Handle, "--trash", "--dry-run"}, "move to trash"},
+		{"captures prune", captures.PruneCommand, []string{ws.Handle, "--dry-run"}, "Dry run"},
+		{"captures delete", captures.DeleteCommand, []string{ws.Handle, base.ID, "--dry-run"}, "delete capture"},
+		{"repos apply", repos.ApplyCommand, []string{ws.Handle, "--manifest", manifest, "--dry-run"}, "delete repository"},
+		{"repos checkout", repos.CheckoutCommand, []string{ws.Handle, "--repo", "testrepo", "--ref", "main", "--dry-run"}, "checkout"},
+		{"repos fetch", repos.FetchCommand, []string{ws.Handle, "--prune", "--dry-run"}, "fetch"},
+		{"repos unshallow", repos.UnshallowCommand, []string{shallow.Handle, "--repo", "extra", "--dry-run"}, "fetch full history"},
+		{"repos clone-missing", repos.CloneMissingCommand, []string{missing.Handle, "--dry-run"}, "clone repository"},
+		{"executions prune", executions.PruneCommand, []string{ws.Handle, "--max-age", "1ns", "--dry-run"}, "delete execution"},
+		{"env set", envcmd.SetCommand, []string{ws.Handle, "API_URL=http://localhost", "--dry-run"}, "set variable"},
+		{"env unset", envcmd.UnsetCommand, []string{ws.Handle, "DEBUG", "--dry-run"}, "unset variable"},
+		{"stash", stash.Command, []string{dirty.Handle, "--dry-run"}, "stash changes"},
+		{"stash pop", stash.PopCommand, []string{dirty.Handle, "--dry-run"}, "restore stash"},
+		{"trash empty", trash.EmptyCommand, []string{"--all", "--dry-run"}, "delete workspace"},
+		{"trash restore", trash.RestoreCommand, []string{trashed.Handle, "--dry-run"}, "restore workspace"},
+		{"capture", capture.Command, []string{ws.Handle, "--name", "planned", "--dry-run"}, "record capture"},
+		{"lock", lock.Command, []string{ws.Handle, "--output", filepath.Join(env.Root, "workshed.lock"), "--dry-run"}, "write lockfile"},
+		{"export", export.Command, []string{ws.Handle, "--dry-run"}, "write export"},
+		{"config set", configcmd.SetCommand, []string{"depth", "2", "--dry-run"}, "set config"},
+		{"doctor --fix", doctorCommand, []string{"--fix", "--dry-run"}, "install completion"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			before := snapshotTree(t, env.Root)
+
+			if err := env.Run(tc.cmd(), tc.args); err != nil {
+				t.Fatalf("%s --dry-run failed: %v\n%s", tc.name, err, env.ErrorOutput())
+			}
+			if !strings.Contains(env.Output(), tc.want) {
+				t.Errorf("Expected plan to mention %q, got: %s", tc.want, env.Output())
+			}
+
+			after := snapshotTree(t, env.Root)
+			for path, state := range after {
+				if prev, ok := before[path]; !ok {
+					t.Errorf("%s created %s", tc.name, path)
+				} else if prev != state && !state.mode.IsDir() {
+					t.Errorf("%s modified %s", tc.name, path)
+				}
+			}
+			for path := range before {
+				if _, ok := after[path]; !ok {
+					t.Errorf("%s removed %s", tc.name, path)
+				}
+			}
+		})
+	}
+
+	t.Run("plan as json", func(t *testing.T) {
+		if err := env.Run(repos.AddCommand(), []string{ws.Handle, "--repo", extra + "@main", "--dry-run", "--format", "json"}); err != nil {
+			t.Fatalf("repos add --dry-run failed: %v", err)
+		}
+		var steps []map[string]string
+		if err := json.Unmarshal([]byte(env.Output()), &steps); err != nil {
+			t.Fatalf("Expected JSON plan, got %q: %v", env.Output(), err)
+		}
+		if len(steps) != 1 || steps[0]["ACTION"] != "clone repository" || steps[0]["DETAIL"] != "ref main" {
+			t.Errorf("Unexpected plan: %v", steps)
+		}
+	})
+
+	t.Run("invalid input still fails", func(t *testing.T) {
+		err := env.Run(repos.RenameCommand(), []string{ws.Handle, "--repo", "missing", "--to", "renamed", "--dry-run"})
+		if err == nil || !strings.Contains(err.Error(), "repository not found") {
+			t.Errorf("Expected repository not found error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package clitest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/frodi/workshed/internal/cli/envcmd"
+	"github.com/frodi/workshed/internal/cli/exec"
+)
+
+func TestEnvCommand(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("env test", nil)
+
+	t.Run("set and list", func(t *testing.T) {
+		if err := env.Run(envcmd.SetCommand(), []string{ws.Handle, "API_URL=http://localhost:8080", "DEBUG=1"}); err != nil {
+			t.Fatalf("env set should succeed: %v", err)
+		}
+		if err := env.Run(envcmd.ListCommand(), []string{ws.Handle, "--format", "raw"}); err != nil {
+			t.Fatalf("env list should succeed: %v", err)
+		}
+		if got := env.Output(); got != "API_URL=http://localhost:8080\nDEBUG=1\n" {
+			t.Errorf("Unexpected env list output: %q", got)
+		}
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		if err := env.Run(envcmd.UnsetCommand(), []string{ws.Handle, "DEBUG"}); err != nil {
+			t.Fatalf("env unset should succeed: %v", err)
+		}
+		vars, err := env.Store.WorkspaceEnv(env.Ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("WorkspaceEnv failed: %v", err)
+		}
+		if _, ok := vars["DEBUG"]; ok {
+			t.Errorf("DEBUG should be unset, got: %v", vars)
+		}
+	})
+
+	t.Run("set rejects extra bare arguments", func(t *testing.T) {
+		if err := env.Run(envcmd.SetCommand(), []string{ws.Handle, "NOT_AN_ASSIGNMENT"}); err == nil {
+			t.Error("env set should reject arguments without =")
+		}
+	})
+
+	t.Run("exec sees env file and --env overrides it", func(t *testing.T) {
+		if err := env.Run(envcmd.SetCommand(), []string{ws.Handle, "WORKSHED_TEST_A=file", "WORKSHED_TEST_B=file"}); err != nil {
+			t.Fatalf("env set should succeed: %v", err)
+		}
+		err := env.Run(exec.Command(), []string{"--no-headers", "--no-record", "--env", "WORKSHED_TEST_B=flag", ws.Handle, "--", "sh", "-c", `echo "$WORKSHED_TEST_A $WORKSHED_TEST_B"`})
+		if err != nil {
+			t.Fatalf("exec should succeed: %v", err)
+		}
+		if got := strings.TrimSpace(env.Output()); got != "file flag" {
+			t.Errorf("exec output = %q, want %q", got, "file flag")
+		}
+	})
+
+	t.Run("exec rejects malformed --env", func(t *testing.T) {
+		if err := env.Run(exec.Command(), []string{"--env", "NOPE", ws.Handle, "--", "true"}); err == nil {
+			t.Error("exec should reject --env without =")
+		}
+	})
+}
@@ -1,9 +1,12 @@
 package clitest
 
 import (
+	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
 
+	"github.com/frodi/workshed/internal/cli"
 	"github.com/frodi/workshed/internal/cli/apply"
 	"github.com/frodi/workshed/internal/cli/capture"
 	"github.com/frodi/workshed/internal/cli/captures"
@@ -86,3 +89,58 @@ func TestInvalidArgumentCombinations(t *testing.T) {
 		}
 	})
 }
+
+func TestJSONErrors(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	execute := func(args ...string) (int, string, string) {
+		t.Helper()
+		root := &cobra.Command{Use: "workshed"}
+		root.PersistentFlags().Bool("json-errors", false, "")
+		root.AddCommand(inspect.Command())
+		var stdout, stderr bytes.Buffer
+		root.SetOut(&stdout)
+		root.SetErr(&stderr)
+		return cli.Execute(root, args), stdout.String(), stderr.String()
+	}
+
+	t.Run("--format json emits a not_found error object on stderr", func(t *testing.T) {
+		code, stdout, stderr := execute("inspect", "nonexistent", "--format", "json")
+		if code != 1 {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+		if stdout != "" {
+			t.Errorf("Expected nothing on stdout, got: %s", stdout)
+		}
+		var body struct {
+			Error cli.ErrorInfo `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(stderr), &body); err != nil {
+			t.Fatalf("Expected a JSON error on stderr: %v\n%s", err, stderr)
+		}
+		if body.Error.Code != cli.ErrorCodeNotFound || body.Error.Details["handle"] != "nonexistent" {
+			t.Errorf("Unexpected error object: %+v", body.Error)
+		}
+	})
+
+	t.Run("--json-errors works with other formats", func(t *testing.T) {
+		_, _, stderr := execute("inspect", "nonexistent", "--json-errors")
+		if !strings.HasPrefix(stderr, `{"error":`) {
+			t.Errorf("Expected a JSON error, got: %s", stderr)
+		}
+	})
+
+	t.Run("errors stay human-readable by default", func(t *testing.T) {
+		_, _, stderr := execute("inspect", "nonexistent")
+		if !strings.HasPrefix(stderr, "Error: ") || !strings.Contains(stderr, `workspace "nonexistent" not found`) {
+			t.Errorf("Expected a text error, got: %s", stderr)
+		}
+	})
+
+	t.Run("arguments after -- do not switch modes", func(t *testing.T) {
+		if cli.JSONErrorsRequested([]string{"exec", "--", "tool", "--format", "json"}) {
+			t.Error("Expected arguments after -- to be ignored")
+		}
+	})
+}
@@ -3,10 +3,14 @@ package clitest
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/frodi/workshed/internal/cli/capture"
 	"github.com/frodi/workshed/internal/cli/exec"
+	"github.com/frodi/workshed/internal/cli/repos"
+	"github.com/frodi/workshed/internal/workspace"
 )
 
 func TestExecCommand(t *testing.T) {
@@ -47,13 +51,16 @@ func TestExecCommand(t *testing.T) {
 			t.Errorf("Run failed: %v", err)
 		}
 		output := env.Output()
-		var results []exec.ExecResultOutput
-		err := json.Unmarshal([]byte(output), &results)
+		var doc exec.ExecOutput
+		err := json.Unmarshal([]byte(output), &doc)
 		if err != nil {
 			t.Errorf("Expected valid JSON output: %v, got: %s", err, output)
 		}
-		if len(results) != 1 {
-			t.Errorf("Expected 1 result, got %d", len(results))
+		if len(doc.Results) != 1 {
+			t.Errorf("Expected 1 result, got %d", len(doc.Results))
+		}
+		if doc.Summary.Repos != 1 || doc.Summary.Passed != 1 {
+			t.Errorf("Expected a passing summary for 1 repo, got %+v", doc.Summary)
 		}
 	})
 
@@ -76,6 +83,35 @@ func TestExecCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("stream output has per-repo headers", func(t *testing.T) {
+		if err := env.Run(exec.Command(), []string{ws.Handle, "--", "echo", "hello"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		output := env.Output()
+		if !strings.Contains(output, "=== testrepo (exit 0, ") {
+			t.Errorf("Expected header with repo and exit code, got: %s", output)
+		}
+		if !strings.Contains(output, "$ echo hello") {
+			t.Errorf("Expected header with command, got: %s", output)
+		}
+		if !strings.Contains(output, "dir: "+filepath.Join(ws.Path, "testrepo")) {
+			t.Errorf("Expected header with repo directory, got: %s", output)
+		}
+	})
+
+	t.Run("with --no-headers flag", func(t *testing.T) {
+		if err := env.Run(exec.Command(), []string{ws.Handle, "--no-headers", "--", "echo", "hello"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		output := env.Output()
+		if strings.Contains(output, "===") {
+			t.Errorf("Expected no headers, got: %s", output)
+		}
+		if !strings.Contains(output, "hello") {
+			t.Errorf("Expected command output, got: %s", output)
+		}
+	})
+
 	t.Run("with --no-record flag", func(t *testing.T) {
 		err := env.Run(exec.Command(), []string{ws.Handle, "pwd", "--no-record"})
 		if err != nil {
@@ -84,6 +120,75 @@ func TestExecCommand(t *testing.T) {
 	})
 }
 
+func TestExecSummary(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	passing := workspace.CreateLocalGitRepo(t, "passing", map[string]string{"marker": "ok"})
+	failing := workspace.CreateLocalGitRepo(t, "failing", map[string]string{"README.md": "# Test"})
+	ws := env.CreateWorkspace("summary", []workspace.RepositoryOption{
+		{URL: passing, Ref: "main"},
+		{URL: failing, Ref: "main"},
+	})
+
+	t.Run("json summary counts a mixed run", func(t *testing.T) {
+		if err := env.Run(exec.Command(), []string{ws.Handle, "-a", "--format", "json", "--", "sh", "-c", "sleep 0.01; test -f marker"}); err == nil {
+			t.Fatal("Expected exec to fail when a repository fails")
+		}
+		var doc exec.ExecOutput
+		if err := json.Unmarshal([]byte(env.Output()), &doc); err != nil {
+			t.Fatalf("Invalid JSON: %v\n%s", err, env.Output())
+		}
+		if doc.Summary.Repos != 2 || doc.Summary.Passed != 1 || doc.Summary.Failed != 1 {
+			t.Errorf("Expected 1 passed and 1 failed of 2, got %+v", doc.Summary)
+		}
+		if doc.Success || doc.MaxExitCode != 1 {
+			t.Errorf("Expected success=false and max_exit_code=1, got %v and %d", doc.Success, doc.MaxExitCode)
+		}
+		if len(doc.Results) != 2 || doc.Results[1].Repository != "failing" || doc.Results[1].ExitCode != 1 {
+			t.Errorf("Expected the failing repository in results, got %+v", doc.Results)
+		}
+		if doc.Summary.TotalMs <= 0 {
+			t.Errorf("Expected a non-zero total time, got %d", doc.Summary.TotalMs)
+		}
+	})
+
+	t.Run("json reports success when every repository passes", func(t *testing.T) {
+		if err := env.Run(exec.Command(), []string{ws.Handle, "-a", "--format", "json", "--", "true"}); err != nil {
+			t.Fatalf("exec failed: %v", err)
+		}
+		var doc exec.ExecOutput
+		if err := json.Unmarshal([]byte(env.Output()), &doc); err != nil {
+			t.Fatalf("Invalid JSON: %v\n%s", err, env.Output())
+		}
+		if !doc.Success || doc.MaxExitCode != 0 {
+			t.Errorf("Expected success=true and max_exit_code=0, got %v and %d", doc.Success, doc.MaxExitCode)
+		}
+	})
+
+	t.Run("stream output ends with a summary line", func(t *testing.T) {
+		if err := env.Run(exec.Command(), []string{ws.Handle, "-a", "--", "test", "-f", "marker"}); err == nil {
+			t.Fatal("Expected exec to fail when a repository fails")
+		}
+		if !strings.Contains(env.Output(), "=== 2 repos: 1 passed, 1 failed (") {
+			t.Errorf("Expected summary line, got: %s", env.Output())
+		}
+	})
+}
+
+func TestExecSignalHeader(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("signal", nil)
+	if err := env.Run(exec.Command(), []string{ws.Handle, "-a", "--", "sh", "-c", "kill -KILL $$"}); err == nil {
+		t.Fatal("Expected exec to fail when the command is killed")
+	}
+	if !strings.Contains(env.Output(), "(exit 137, killed by SIGKILL,") {
+		t.Errorf("Expected the header to name the signal, got: %s", env.Output())
+	}
+}
+
 func TestExecCommandNoWorkspace(t *testing.T) {
 	env := NewCLIEnv(t)
 	defer env.Cleanup()
@@ -128,3 +233,318 @@ func TestExecCommandFromWorkspaceDir(t *testing.T) {
 		}
 	})
 }
+
+func TestExecCommandEvents(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	repoA := workspace.CreateLocalGitRepo(t, "events-a", map[string]string{"README.md": "# A"})
+	repoB := workspace.CreateLocalGitRepo(t, "events-b", map[string]string{"README.md": "# B"})
+	ws := env.CreateWorkspace("events", []workspace.RepositoryOption{
+		{URL: repoA, Ref: "main"},
+		{URL: repoB, Ref: "main"},
+	})
+
+	t.Run("jsonl events are ordered and end with a summary", func(t *testing.T) {
+		if err := env.Run(exec.Command(), []string{ws.Handle, "--events", "jsonl", "--", "echo", "hello"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		events := parseEvents(t, env.Output())
+		want := []string{"repo_start:events-a", "repo_result:events-a", "repo_start:events-b", "repo_result:events-b", "summary:"}
+		if len(events) != len(want) {
+			t.Fatalf("Expected %d events, got %d: %s", len(want), len(events), env.Output())
+		}
+		for i, event := range events {
+			if got := event.Type + ":" + event.Repo; got != want[i] {
+				t.Errorf("event %d = %s, want %s", i, got, want[i])
+			}
+			if event.Type == "repo_result" && (event.Exit == nil || *event.Exit != 0) {
+				t.Errorf("event %d should report exit 0, got: %+v", i, event)
+			}
+		}
+		summary := events[len(events)-1]
+		if summary.Handle != ws.Handle {
+			t.Errorf("summary handle = %q, want %q", summary.Handle, ws.Handle)
+		}
+		if summary.Repos != 2 || summary.Exit == nil || *summary.Exit != 0 {
+			t.Errorf("summary should report 2 repos and exit 0, got: %+v", summary)
+		}
+	})
+
+	t.Run("failing command reports exit and error in summary", func(t *testing.T) {
+		err := env.Run(exec.Command(), []string{ws.Handle, "--events", "jsonl", "--", "false"})
+		if err == nil {
+			t.Fatal("exec of failing command should fail")
+		}
+
+		events := parseEvents(t, env.Output())
+		summary := events[len(events)-1]
+		if summary.Type != "summary" {
+			t.Fatalf("last event should be summary, got: %+v", summary)
+		}
+		if summary.Exit == nil || *summary.Exit == 0 || summary.Error == "" {
+			t.Errorf("summary should report failure, got: %+v", summary)
+		}
+	})
+}
+
+func TestExecCommandRetries(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	repo := workspace.CreateLocalGitRepo(t, "flaky", map[string]string{"README.md": "# Flaky"})
+	ws := env.CreateWorkspace("retries", []workspace.RepositoryOption{{URL: repo, Ref: "main"}})
+	counter := filepath.Join(t.TempDir(), "attempted")
+	failOnce := "if [ -f " + counter + " ]; then exit 0; fi; touch " + counter + "; exit 1"
+
+	if err := env.Run(exec.Command(), []string{ws.Handle, "--retries", "2", "--format", "json", "--", "sh", "-c", failOnce}); err != nil {
+		t.Fatalf("exec should pass within the retry budget: %v", err)
+	}
+	var doc exec.ExecOutput
+	if err := json.Unmarshal([]byte(env.Output()), &doc); err != nil {
+		t.Fatalf("Expected valid JSON output: %v, got: %s", err, env.Output())
+	}
+	if len(doc.Results) != 1 || doc.Results[0].ExitCode != 0 || doc.Results[0].Attempts != 2 {
+		t.Errorf("Expected a pass on the second attempt, got: %+v", doc.Results)
+	}
+
+	records, err := env.Store.ListExecutions(env.Ctx, ws.Handle, workspace.ListExecutionsOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("ListExecutions failed: %v", err)
+	}
+	if len(records) != 1 || len(records[0].Results) != 1 {
+		t.Fatalf("Expected the run to be recorded, got: %+v", records)
+	}
+	attempts := records[0].Results[0].Attempts
+	if len(attempts) != 2 || attempts[0].ExitCode != 1 || attempts[1].ExitCode != 0 {
+		t.Errorf("Expected both attempts in the history, got: %+v", attempts)
+	}
+}
+
+func TestExecCommandRequireAny(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	pass := workspace.CreateLocalGitRepo(t, "pass", map[string]string{"README.md": "# Pass"})
+	fail := workspace.CreateLocalGitRepo(t, "fail", map[string]string{"README.md": "# Fail"})
+	ws := env.CreateWorkspace("gating", []workspace.RepositoryOption{
+		{URL: fail, Ref: "main"},
+		{URL: pass, Ref: "main"},
+	})
+	script := []string{"--expand", "--format", "json", "--", "sh", "-c", "test {{repo}} = pass"}
+
+	t.Run("require all runs every repository with continue-on-error", func(t *testing.T) {
+		err := env.Run(exec.Command(), append([]string{ws.Handle, "--continue-on-error"}, script...))
+		if err == nil || !strings.Contains(err.Error(), "failed in 1 of 2 repositories: fail") {
+			t.Fatalf("Expected a require-all failure, got %v", err)
+		}
+		var doc exec.ExecOutput
+		if err := json.Unmarshal([]byte(env.Output()), &doc); err != nil {
+			t.Fatalf("Expected valid JSON output: %v, got: %s", err, env.Output())
+		}
+		if doc.Summary.Passed != 1 || doc.Summary.Failed != 1 {
+			t.Errorf("Expected both repositories to run, got: %+v", doc.Summary)
+		}
+	})
+
+	t.Run("require any succeeds when one repository passes", func(t *testing.T) {
+		if err := env.Run(exec.Command(), append([]string{ws.Handle, "--require-any"}, script...)); err != nil {
+			t.Errorf("Expected --require-any to pass: %v", err)
+		}
+	})
+
+	t.Run("require all and require any are exclusive", func(t *testing.T) {
+		if err := env.Run(exec.Command(), append([]string{ws.Handle, "--require-all", "--require-any"}, script...)); err == nil {
+			t.Error("Expected an error combining --require-all and --require-any")
+		}
+	})
+}
+
+func TestExecCommandParallel(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	fail := workspace.CreateLocalGitRepo(t, "fail", map[string]string{"README.md": "# Fail"})
+	pass := workspace.CreateLocalGitRepo(t, "pass", map[string]string{"README.md": "# Pass"})
+	ws := env.CreateWorkspace("parallel", []workspace.RepositoryOption{
+		{URL: fail, Ref: "main"},
+		{URL: pass, Ref: "main"},
+	})
+	script := []string{"--expand", "--format", "json", "--", "sh", "-c", "test {{repo}} = pass"}
+
+	run := func(flags ...string) []exec.ExecResultOutput {
+		t.Helper()
+		if err := env.Run(exec.Command(), append(append([]string{ws.Handle}, flags...), script...)); err == nil {
+			t.Fatal("Expected exec to fail when a repository fails")
+		}
+		var doc exec.ExecOutput
+		if err := json.Unmarshal([]byte(env.Output()), &doc); err != nil {
+			t.Fatalf("Expected valid JSON output: %v, got: %s", err, env.Output())
+		}
+		return doc.Results
+	}
+
+	t.Run("all alone is sequential and stops at the first failure", func(t *testing.T) {
+		if results := run("-a"); len(results) != 1 || results[0].Repository != "fail" {
+			t.Errorf("Expected exec to stop after fail, got: %+v", results)
+		}
+	})
+
+	t.Run("parallel runs every repository in order", func(t *testing.T) {
+		results := run("-a", "--parallel")
+		if len(results) != 2 || results[0].Repository != "fail" || results[1].Repository != "pass" || results[1].ExitCode != 0 {
+			t.Errorf("Expected both repositories in order, got: %+v", results)
+		}
+	})
+}
+
+func TestExecDryRun(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	var repos []workspace.RepositoryOption
+	for _, name := range []string{"svc-api", "docs", "svc-web"} {
+		repos = append(repos, workspace.RepositoryOption{URL: workspace.CreateLocalGitRepo(t, name, map[string]string{"README.md": "# " + name}), Ref: "main"})
+	}
+	ws := env.CreateWorkspace("dry run", repos)
+
+	t.Run("glob lists the matching repositories without running", func(t *testing.T) {
+		err := env.Run(exec.Command(), []string{ws.Handle, "--repo", "svc-*", "--dry-run", "--", "touch", "sentinel file"})
+		if err != nil {
+			t.Fatalf("exec --dry-run failed: %v", err)
+		}
+		output := env.Output()
+		for _, name := range []string{"svc-api", "svc-web"} {
+			if !strings.Contains(output, "dir: "+filepath.Join(ws.Path, name)) {
+				t.Errorf("Expected %s in plan, got: %s", name, output)
+			}
+		}
+		if strings.Contains(output, "docs") {
+			t.Errorf("Expected docs to be excluded, got: %s", output)
+		}
+		if !strings.Contains(output, "$ touch 'sentinel file'") {
+			t.Errorf("Expected the command in the plan, got: %s", output)
+		}
+
+		for _, name := range []string{"svc-api", "docs", "svc-web"} {
+			if _, err := os.Stat(filepath.Join(ws.Path, name, "sentinel file")); err == nil {
+				t.Errorf("dry run ran the command in %s", name)
+			}
+		}
+		records, err := env.Store.ListExecutions(env.Ctx, ws.Handle, workspace.ListExecutionsOptions{})
+		if err != nil {
+			t.Fatalf("ListExecutions failed: %v", err)
+		}
+		if len(records) != 0 {
+			t.Errorf("Expected no recorded executions, got %d", len(records))
+		}
+	})
+
+	t.Run("json lists each directory and argv", func(t *testing.T) {
+		err := env.Run(exec.Command(), []string{ws.Handle, "--repo", "docs,svc-web", "--dry-run", "--expand", "--format", "json", "--", "echo", "{{repo}}"})
+		if err != nil {
+			t.Fatalf("exec --dry-run failed: %v", err)
+		}
+		var plan []exec.PlanOutput
+		if err := json.Unmarshal([]byte(env.Output()), &plan); err != nil {
+			t.Fatalf("Expected valid JSON output: %v, got: %s", err, env.Output())
+		}
+		if len(plan) != 2 || plan[0].Repository != "docs" || plan[1].Repository != "svc-web" {
+			t.Fatalf("Unexpected plan: %+v", plan)
+		}
+		if got := strings.Join(plan[1].Command, " "); got != "echo svc-web" {
+			t.Errorf("Expected expanded command, got %q", got)
+		}
+	})
+
+	t.Run("unmatched glob fails", func(t *testing.T) {
+		err := env.Run(exec.Command(), []string{ws.Handle, "--repo", "web-*", "--dry-run", "--", "true"})
+		if err == nil || !strings.Contains(err.Error(), "no repository matches web-*") {
+			t.Errorf("Expected no match error, got %v", err)
+		}
+	})
+}
+
+func TestReposExec(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	var options []workspace.RepositoryOption
+	for _, name := range []string{"api", "web"} {
+		options = append(options, workspace.RepositoryOption{URL: workspace.CreateLocalGitRepo(t, name, map[string]string{"README.md": "# " + name}), Ref: "main"})
+	}
+	ws := env.CreateWorkspace("repos exec", options)
+
+	t.Run("runs in the named repository only", func(t *testing.T) {
+		err := env.Run(repos.ExecCommand(), []string{ws.Handle, "web", "--env", "GREETING=hi", "--", "sh", "-c", "echo $GREETING; pwd"})
+		if err != nil {
+			t.Fatalf("repos exec failed: %v", err)
+		}
+		output := env.Output()
+		if !strings.Contains(output, "hi\n") || !strings.Contains(output, filepath.Join(ws.Path, "web")) {
+			t.Errorf("Expected output from web with the env set, got: %s", output)
+		}
+		if strings.Contains(output, filepath.Join(ws.Path, "api")) {
+			t.Errorf("Expected api not to run, got: %s", output)
+		}
+	})
+
+	t.Run("unknown repository lists the available ones", func(t *testing.T) {
+		err := env.Run(repos.ExecCommand(), []string{ws.Handle, "cli", "--", "pwd"})
+		if err == nil || !strings.Contains(err.Error(), "repository not found: cli (available: api, web)") {
+			t.Errorf("Expected repository not found error, got %v", err)
+		}
+	})
+
+	t.Run("repository argument is required", func(t *testing.T) {
+		err := env.Run(repos.ExecCommand(), []string{"--", "pwd"})
+		if err == nil || !strings.Contains(err.Error(), "missing repository") {
+			t.Errorf("Expected missing repository error, got %v", err)
+		}
+	})
+
+	t.Run("patterns are rejected", func(t *testing.T) {
+		err := env.Run(repos.ExecCommand(), []string{ws.Handle, "*", "--", "pwd"})
+		if err == nil || !strings.Contains(err.Error(), "single repository") {
+			t.Errorf("Expected single repository error, got %v", err)
+		}
+	})
+
+	t.Run("timeout stops the command", func(t *testing.T) {
+		err := env.Run(repos.ExecCommand(), []string{ws.Handle, "api", "--timeout", "100ms", "--", "sleep", "5"})
+		if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+			t.Errorf("Expected timeout error, got %v", err)
+		}
+	})
+}
+
+func TestCaptureAutoIntent(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("auto intent", nil)
+	if err := env.Run(exec.Command(), []string{ws.Handle, "--", "echo", "built"}); err != nil {
+		t.Fatalf("exec failed: %v", err)
+	}
+
+	if err := env.Run(capture.Command(), []string{ws.Handle, "--name", "after build", "--auto-intent", "--format", "json"}); err != nil {
+		t.Fatalf("capture --auto-intent failed: %v", err)
+	}
+	var captured workspace.Capture
+	if err := json.Unmarshal([]byte(env.Output()), &captured); err != nil {
+		t.Fatalf("Expected capture JSON: %v, got: %s", err, env.Output())
+	}
+	if captured.Metadata.Description != "after running: echo built" {
+		t.Errorf("Expected the description to name the command, got %q", captured.Metadata.Description)
+	}
+
+	t.Run("explicit description wins", func(t *testing.T) {
+		if err := env.Run(capture.Command(), []string{ws.Handle, "--name", "mine", "--auto-intent", "--description", "by hand", "--format", "json"}); err != nil {
+			t.Fatalf("capture failed: %v", err)
+		}
+		if !strings.Contains(env.Output(), `"description": "by hand"`) {
+			t.Errorf("Expected the given description, got: %s", env.Output())
+		}
+	})
+}
@@ -2,6 +2,8 @@ package clitest
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/frodi/workshed/internal/cli/export"
 	"github.com/frodi/workshed/internal/cli/list"
 	"github.com/frodi/workshed/internal/cli/path"
+	"github.com/frodi/workshed/internal/workspace"
 )
 
 func TestCapturesOutputFormats(t *testing.T) {
@@ -57,6 +60,44 @@ func TestCapturesOutputFormats(t *testing.T) {
 	})
 }
 
+func TestCapturesDirtyAndAge(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("dirty test", nil)
+	if err := env.Run(capture.Command(), []string{"--name", "clean", ws.Handle}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(ws.Path, "testrepo", "untracked.txt"), []byte("wip"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := env.Run(capture.Command(), []string{"--name", "dirty", ws.Handle}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if err := env.Run(captures.Command(), []string{ws.Handle, "--format", "json"}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	var rows []map[string]string
+	if err := json.Unmarshal([]byte(env.Output()), &rows); err != nil {
+		t.Fatalf("Expected valid JSON output: %v, got: %s", err, env.Output())
+	}
+
+	dirtyByName := make(map[string]string)
+	for _, row := range rows {
+		dirtyByName[row["NAME"]] = row["DIRTY"]
+		if row["AGE"] != "just now" {
+			t.Errorf("Expected AGE 'just now' for %s, got: %q", row["NAME"], row["AGE"])
+		}
+	}
+	if dirtyByName["clean"] != "no" {
+		t.Errorf("Expected clean capture not flagged dirty, got: %q", dirtyByName["clean"])
+	}
+	if dirtyByName["dirty"] != "yes" {
+		t.Errorf("Expected dirty capture flagged dirty, got: %q", dirtyByName["dirty"])
+	}
+}
+
 func TestExportOutputFormats(t *testing.T) {
 	env := NewCLIEnv(t)
 	defer env.Cleanup()
@@ -101,6 +142,121 @@ func TestExportOutputFormats(t *testing.T) {
 	})
 }
 
+func TestListRepoCounts(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	single := env.CreateWorkspace("one repo", nil)
+	double := env.CreateWorkspace("two repos", []workspace.RepositoryOption{
+		{URL: workspace.CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"})},
+		{URL: workspace.CreateLocalGitRepo(t, "web", map[string]string{"README.md": "# Web"})},
+	})
+
+	t.Run("repos column matches each workspace", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--format", "json"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		var rows []map[string]string
+		if err := json.Unmarshal([]byte(env.Output()), &rows); err != nil {
+			t.Fatalf("Expected valid JSON array: %v", err)
+		}
+		want := map[string]string{single.Handle: "1", double.Handle: "2"}
+		for _, row := range rows {
+			if row["REPOS"] != want[row["HANDLE"]] {
+				t.Errorf("Expected %s to have REPOS %s, got %q", row["HANDLE"], want[row["HANDLE"]], row["REPOS"])
+			}
+		}
+	})
+
+	t.Run("table footer totals workspaces and repositories", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--format", "table"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if !strings.Contains(env.Output(), "2 workspaces, 3 repositories") {
+			t.Errorf("Expected totals footer, got: %s", env.Output())
+		}
+	})
+}
+
+func TestListWithRepos(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	env.CreateWorkspace("one repo", nil)
+	env.CreateWorkspace("two repos", []workspace.RepositoryOption{
+		{URL: workspace.CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"}), Ref: "main"},
+		{URL: workspace.CreateLocalGitRepo(t, "web", map[string]string{"README.md": "# Web"})},
+	})
+
+	type row struct {
+		Handle       string                 `json:"HANDLE"`
+		Repositories []workspace.Repository `json:"REPOSITORIES"`
+	}
+
+	checkRows := func(t *testing.T, rows []row) {
+		t.Helper()
+		if len(rows) != 2 {
+			t.Fatalf("Expected 2 workspaces, got %d", len(rows))
+		}
+		for _, r := range rows {
+			ws, err := env.Store.Get(env.Ctx, r.Handle)
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			if len(r.Repositories) != len(ws.Repositories) {
+				t.Fatalf("Expected %d repositories for %s, got %d", len(ws.Repositories), r.Handle, len(r.Repositories))
+			}
+			for i, repo := range r.Repositories {
+				want := ws.Repositories[i]
+				if repo.Name != want.Name || repo.URL != want.URL || repo.Ref != want.Ref {
+					t.Errorf("Expected %+v, got %+v", want, repo)
+				}
+			}
+		}
+	}
+
+	t.Run("json includes repositories", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--format", "json", "--with-repos"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		var rows []row
+		if err := json.Unmarshal([]byte(env.Output()), &rows); err != nil {
+			t.Fatalf("Expected valid JSON array: %v", err)
+		}
+		checkRows(t, rows)
+	})
+
+	t.Run("ndjson includes repositories", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--format", "ndjson", "--with-repos"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		var rows []row
+		for _, line := range strings.Split(strings.TrimSpace(env.Output()), "\n") {
+			var r row
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatalf("Expected a JSON object per line: %v", err)
+			}
+			rows = append(rows, r)
+		}
+		checkRows(t, rows)
+	})
+
+	t.Run("omitted by default", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--format", "json"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if strings.Contains(env.Output(), "REPOSITORIES") {
+			t.Errorf("Expected no repositories without --with-repos, got: %s", env.Output())
+		}
+	})
+
+	t.Run("rejected for table output", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--with-repos"}); err == nil {
+			t.Error("Expected --with-repos to require json or ndjson")
+		}
+	})
+}
+
 func TestListOutputFormats(t *testing.T) {
 	env := NewCLIEnv(t)
 	defer env.Cleanup()
@@ -123,6 +279,35 @@ func TestListOutputFormats(t *testing.T) {
 		}
 	})
 
+	t.Run("list ndjson format streams one object per workspace", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--format", "ndjson"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		workspaces, err := env.Store.List(env.Ctx, workspace.ListOptions{})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(env.Output()), "\n")
+		if len(lines) != len(workspaces) {
+			t.Fatalf("Expected %d lines, got %d: %q", len(workspaces), len(lines), lines)
+		}
+		for i, ws := range workspaces {
+			var row map[string]string
+			if err := json.Unmarshal([]byte(lines[i]), &row); err != nil {
+				t.Fatalf("Line %d is not a JSON object: %v (%q)", i, err, lines[i])
+			}
+			if row["HANDLE"] != ws.Handle || row["PURPOSE"] != ws.Purpose {
+				t.Errorf("Line %d = %v, want handle %q purpose %q", i, row, ws.Handle, ws.Purpose)
+			}
+		}
+	})
+
+	t.Run("list ndjson rejects --group-by", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--format", "ndjson", "--group-by", "project"}); err == nil {
+			t.Error("Expected --group-by to be rejected with --format ndjson")
+		}
+	})
+
 	t.Run("list raw format", func(t *testing.T) {
 		if err := env.Run(list.Command(), []string{"--format", "raw"}); err != nil {
 			t.Errorf("Run failed: %v", err)
@@ -139,6 +324,63 @@ func TestListOutputFormats(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("list raw format with columns", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--columns", "handle,purpose", "--format", "raw"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		workspaces, err := env.Store.List(env.Ctx, workspace.ListOptions{})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(env.Output()), "\n")
+		if len(lines) != len(workspaces) {
+			t.Fatalf("Expected %d lines, got %d: %q", len(workspaces), len(lines), lines)
+		}
+		for i, ws := range workspaces {
+			if want := ws.Handle + "\t" + ws.Purpose; lines[i] != want {
+				t.Errorf("Line %d = %q, want %q", i, lines[i], want)
+			}
+		}
+	})
+
+	t.Run("list table format with columns", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--columns", "purpose,handle"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		output := env.Output()
+		if strings.Contains(output, "CREATED") || strings.Contains(output, "REPO") {
+			t.Errorf("Table should only contain selected columns, got: %s", output)
+		}
+		if strings.Index(output, "PURPOSE") > strings.Index(output, "HANDLE") {
+			t.Errorf("Columns should follow requested order, got: %s", output)
+		}
+	})
+
+	t.Run("list json includes last activity", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--columns", "handle,activity", "--format", "json"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		var rows []map[string]string
+		if err := json.Unmarshal([]byte(env.Output()), &rows); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		for _, row := range rows {
+			if row["ACTIVITY"] != "just now" {
+				t.Errorf("Expected recent activity for %s, got %q", row["HANDLE"], row["ACTIVITY"])
+			}
+		}
+	})
+
+	t.Run("list rejects unknown columns", func(t *testing.T) {
+		err := env.Run(list.Command(), []string{"--columns", "handle,tags", "--format", "raw"})
+		if err == nil {
+			t.Fatal("Expected error for unknown column")
+		}
+		if !strings.Contains(err.Error(), `unknown column "tags"`) {
+			t.Errorf("Expected unknown column error, got: %v", err)
+		}
+	})
 }
 
 func TestPathOutputFormats(t *testing.T) {
@@ -176,3 +418,25 @@ func TestPathOutputFormats(t *testing.T) {
 		}
 	})
 }
+
+func TestCapturesVerify(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	ws := env.CreateWorkspace("verify test", nil)
+	capture, err := env.Store.CaptureState(env.Ctx, ws.Handle, workspace.CaptureOptions{Name: "verified", Kind: workspace.CaptureKindManual})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+
+	if err := env.Run(captures.Command(), []string{"verify", ws.Handle, "--format", "json"}); err != nil {
+		t.Fatalf("captures verify should succeed: %v", err)
+	}
+	var rows []map[string]string
+	if err := json.Unmarshal([]byte(env.Output()), &rows); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, env.Output())
+	}
+	if len(rows) != 1 || rows[0]["ID"] != capture.ID || rows[0]["STATUS"] != "ok" {
+		t.Errorf("Expected one ok capture, got %v", rows)
+	}
+}
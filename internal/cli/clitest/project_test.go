@@ -0,0 +1,95 @@
+package clitest
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/frodi/workshed/internal/cli/list"
+	"github.com/frodi/workshed/internal/workspace"
+)
+
+func TestListProjects(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	create := func(purpose, project string) *workspace.Workspace {
+		ws, err := env.Store.Create(env.Ctx, workspace.CreateOptions{
+			Purpose:      purpose,
+			Project:      project,
+			Repositories: []workspace.RepositoryOption{},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		return ws
+	}
+
+	pay1 := create("pay one", "payments")
+	pay2 := create("pay two", "payments")
+	search := create("search one", "search")
+	loose := create("loose", "")
+
+	t.Run("filters by project", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--project", "payments", "--format", "raw"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		lines := strings.Fields(env.Output())
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 workspaces, got %q", lines)
+		}
+		for _, handle := range lines {
+			if handle != pay1.Handle && handle != pay2.Handle {
+				t.Errorf("Unexpected workspace %s in payments", handle)
+			}
+		}
+	})
+
+	t.Run("groups table output by project", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--group-by", "project", "--wide"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		output := env.Output()
+		payments := strings.Index(output, "payments\n")
+		searchIdx := strings.Index(output, "search\n")
+		none := strings.Index(output, "(no project)\n")
+		if payments < 0 || searchIdx < 0 || none < 0 {
+			t.Fatalf("Expected a heading per project, got: %s", output)
+		}
+		if !(payments < searchIdx && searchIdx < none) {
+			t.Errorf("Expected payments, search, then (no project), got: %s", output)
+		}
+		if idx := strings.Index(output, search.Handle); idx < searchIdx || idx > none {
+			t.Errorf("Expected %s under search, got: %s", search.Handle, output)
+		}
+		if idx := strings.Index(output, loose.Handle); idx < none {
+			t.Errorf("Expected %s under (no project), got: %s", loose.Handle, output)
+		}
+	})
+
+	t.Run("grouped json includes project", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--group-by", "project", "--format", "json"}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		var rows []map[string]string
+		if err := json.Unmarshal([]byte(env.Output()), &rows); err != nil {
+			t.Fatalf("Invalid JSON: %v\n%s", err, env.Output())
+		}
+		if len(rows) != 4 {
+			t.Fatalf("Expected 4 rows, got %d", len(rows))
+		}
+		projects := map[string]string{}
+		for _, row := range rows {
+			projects[row["HANDLE"]] = row["PROJECT"]
+		}
+		if projects[pay1.Handle] != "payments" || projects[search.Handle] != "search" || projects[loose.Handle] != "" {
+			t.Errorf("Unexpected projects: %v", projects)
+		}
+	})
+
+	t.Run("rejects unknown grouping", func(t *testing.T) {
+		if err := env.Run(list.Command(), []string{"--group-by", "purpose"}); err == nil {
+			t.Error("Expected --group-by purpose to fail")
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package clitest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frodi/workshed/internal/cli/prune"
+	"github.com/frodi/workshed/internal/workspace"
+)
+
+// backdate rewrites the workspace's creation time in its metadata file.
+func backdate(t *testing.T, ws *workspace.Workspace, age time.Duration) {
+	t.Helper()
+	path := filepath.Join(ws.Path, ".workshed.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	meta["created_at"] = time.Now().Add(-age).UTC().Format(time.RFC3339Nano)
+	data, err = json.Marshal(meta)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestPruneEmptyCommand(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	emptied := func(purpose string) *workspace.Workspace {
+		ws := env.CreateWorkspace(purpose, nil)
+		if err := env.Store.RemoveRepository(env.Ctx, ws.Handle, "testrepo"); err != nil {
+			t.Fatalf("RemoveRepository failed: %v", err)
+		}
+		return ws
+	}
+
+	empty := emptied("empty and idle")
+	backdate(t, empty, 30*24*time.Hour)
+
+	recent := emptied("empty but recent")
+
+	captured := env.CreateWorkspace("has a capture", nil)
+	if _, err := env.Store.CaptureState(env.Ctx, captured.Handle, workspace.CaptureOptions{Name: "keep", Kind: workspace.CaptureKindManual}); err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+	if err := env.Store.RemoveRepository(env.Ctx, captured.Handle, "testrepo"); err != nil {
+		t.Fatalf("RemoveRepository failed: %v", err)
+	}
+	backdate(t, captured, 30*24*time.Hour)
+
+	withRepo := env.CreateWorkspace("has a repo", nil)
+	backdate(t, withRepo, 30*24*time.Hour)
+
+	exists := func(handle string) bool {
+		_, err := env.Store.Get(env.Ctx, handle)
+		return err == nil
+	}
+
+	t.Run("captures and repositories keep a workspace", func(t *testing.T) {
+		candidates, err := prune.EmptyWorkspaces(env.Ctx, env.Store, 0, time.Now())
+		if err != nil {
+			t.Fatalf("EmptyWorkspaces failed: %v", err)
+		}
+		found := make(map[string]bool)
+		for _, c := range candidates {
+			found[c.Handle] = true
+		}
+		if len(candidates) != 2 || !found[empty.Handle] || !found[recent.Handle] {
+			t.Errorf("Expected only the two empty workspaces, got %v", candidates)
+		}
+	})
+
+	t.Run("dry run lists without removing", func(t *testing.T) {
+		if err := env.Run(prune.Command(), []string{"--empty", "--dry-run"}); err != nil {
+			t.Fatalf("prune --dry-run failed: %v", err)
+		}
+		out := env.Output()
+		if !strings.Contains(out, empty.Handle) {
+			t.Errorf("Expected plan to include %s, got: %s", empty.Handle, out)
+		}
+		for _, kept := range []string{recent.Handle, captured.Handle, withRepo.Handle} {
+			if strings.Contains(out, kept) {
+				t.Errorf("Expected plan to leave out %s, got: %s", kept, out)
+			}
+		}
+		if !exists(empty.Handle) {
+			t.Error("Expected dry run to keep the workspace")
+		}
+	})
+
+	t.Run("removes only empty inactive workspaces", func(t *testing.T) {
+		if err := env.Run(prune.Command(), []string{"--empty", "--yes"}); err != nil {
+			t.Fatalf("prune --empty failed: %v", err)
+		}
+		if exists(empty.Handle) {
+			t.Error("Expected the empty, idle workspace to be removed")
+		}
+		for _, kept := range []string{recent.Handle, captured.Handle, withRepo.Handle} {
+			if !exists(kept) {
+				t.Errorf("Expected %s to be kept", kept)
+			}
+		}
+	})
+
+	t.Run("requires --empty", func(t *testing.T) {
+		if err := env.Run(prune.Command(), []string{"--yes"}); err == nil {
+			t.Error("Expected an error without --empty")
+		}
+	})
+}
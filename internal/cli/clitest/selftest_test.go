@@ -0,0 +1,88 @@
+package clitest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frodi/workshed/internal/cli/selftest"
+	"github.com/frodi/workshed/internal/workspace"
+)
+
+func TestSelfTestCommand(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	if err := env.Run(selftest.Command(), []string{"--format", "json"}); err != nil {
+		t.Fatalf("self-test should pass: %v\n%s", err, env.Output())
+	}
+
+	var steps []map[string]string
+	if err := json.Unmarshal([]byte(env.Output()), &steps); err != nil {
+		t.Fatalf("Expected JSON output: %v\n%s", err, env.Output())
+	}
+
+	want := []string{"git", "store", "repo", "create", "exec", "capture", "apply", "remove"}
+	if len(steps) != len(want) {
+		t.Fatalf("Expected %d steps, got %d: %v", len(want), len(steps), steps)
+	}
+	for i, step := range steps {
+		if step["STEP"] != want[i] {
+			t.Errorf("Step %d = %q, want %q", i, step["STEP"], want[i])
+		}
+		if step["STATUS"] != selftest.StatusPass {
+			t.Errorf("Step %s status = %q (%s), want pass", step["STEP"], step["STATUS"], step["DETAIL"])
+		}
+	}
+
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("self-test should clean up its temp root, found %d entries", len(entries))
+	}
+
+	workspaces, err := env.Store.List(env.Ctx, workspace.ListOptions{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(workspaces) != 0 {
+		t.Errorf("self-test must not touch the configured store, found %d workspaces", len(workspaces))
+	}
+}
+
+func TestSelfTestSkipsAfterFailure(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "repos"), []byte("not a directory"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	results := selftest.Run(context.Background(), dir)
+	if results[2].Status != selftest.StatusFail {
+		t.Fatalf("Expected repo step to fail, got: %+v", results[2])
+	}
+	for _, res := range results[3:] {
+		if res.Status != selftest.StatusSkip {
+			t.Errorf("Step %s should be skipped after failure, got %s", res.Name, res.Status)
+		}
+	}
+}
+
+func TestSelfTestWithoutGit(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	results := selftest.Run(context.Background(), t.TempDir())
+	if results[0].Name != "git" || results[0].Status != selftest.StatusFail {
+		t.Fatalf("Expected the git step to fail, got: %+v", results[0])
+	}
+	if !strings.Contains(results[0].Detail, "git is required") {
+		t.Errorf("Expected a clear missing-git message, got %q", results[0].Detail)
+	}
+}
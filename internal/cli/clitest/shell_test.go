@@ -0,0 +1,66 @@
+package clitest
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/cli/shellcmd"
+)
+
+func TestShellCommand(t *testing.T) {
+	env := NewCLIEnv(t)
+	defer env.Cleanup()
+	t.Setenv("SHELL", "/bin/sh")
+
+	ws := env.CreateWorkspace("shell test", nil)
+
+	t.Run("sets workspace env vars and cwd", func(t *testing.T) {
+		err := env.Run(shellcmd.Command(), []string{ws.Handle, "-c", `echo "$WORKSHED_HANDLE"; echo "$WORKSHED_PATH"; pwd -P`})
+		if err != nil {
+			t.Fatalf("shell -c should succeed: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(env.Output()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected 3 lines, got: %q", env.Output())
+		}
+		if lines[0] != ws.Handle {
+			t.Errorf("WORKSHED_HANDLE = %q, want %q", lines[0], ws.Handle)
+		}
+		if lines[1] != ws.Path {
+			t.Errorf("WORKSHED_PATH = %q, want %q", lines[1], ws.Path)
+		}
+		wantDir, _ := filepath.EvalSymlinks(ws.Path)
+		if lines[2] != wantDir {
+			t.Errorf("cwd = %q, want %q", lines[2], wantDir)
+		}
+	})
+
+	t.Run("with --repo starts in the repository", func(t *testing.T) {
+		if err := env.Run(shellcmd.Command(), []string{ws.Handle, "--repo", "testrepo", "-c", "pwd -P"}); err != nil {
+			t.Fatalf("shell --repo should succeed: %v", err)
+		}
+		if filepath.Base(strings.TrimSpace(env.Output())) != "testrepo" {
+			t.Errorf("Expected cwd in testrepo, got: %s", env.Output())
+		}
+	})
+
+	t.Run("with unknown --repo", func(t *testing.T) {
+		if err := env.Run(shellcmd.Command(), []string{ws.Handle, "--repo", "missing", "-c", "true"}); err == nil {
+			t.Error("shell with unknown --repo should fail")
+		}
+	})
+
+	t.Run("returns the shell exit code", func(t *testing.T) {
+		err := env.Run(shellcmd.Command(), []string{ws.Handle, "-c", "exit 3"})
+		var exitErr *cli.ExitCodeError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("Expected ExitCodeError, got: %v", err)
+		}
+		if exitErr.Code != 3 {
+			t.Errorf("exit code = %d, want 3", exitErr.Code)
+		}
+	})
+}
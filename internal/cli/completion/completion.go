@@ -2,7 +2,9 @@ package completion
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 )
@@ -13,13 +15,16 @@ func NewCommand(root *cobra.Command) *cobra.Command {
 		Short: "Generate shell completion",
 		Long: `Generate shell completion scripts.
 
+'workshed doctor --fix' installs the script for your shell in its completion
+directory.
+
 Examples:
   workshed completion --shell bash >> ~/.bash_completion
   workshed completion --shell zsh > _workshed`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			shell, _ := cmd.Flags().GetString("shell")
-			return generateCompletion(root, shell, os.Stdout)
+			return Generate(root, shell, os.Stdout)
 		},
 	}
 
@@ -28,7 +33,8 @@ Examples:
 	return cmd
 }
 
-func generateCompletion(root *cobra.Command, shell string, out *os.File) error {
+// Generate writes root's completion script for shell to out.
+func Generate(root *cobra.Command, shell string, out io.Writer) error {
 	switch shell {
 	case "bash":
 		return root.GenBashCompletion(out)
@@ -40,3 +46,31 @@ func generateCompletion(root *cobra.Command, shell string, out *os.File) error {
 		return fmt.Errorf("unsupported shell: %q (supported: bash, zsh, fish)", shell)
 	}
 }
+
+// InstallPath returns where shell loads the completion script for the
+// command name from without any change to the user's shell configuration,
+// except for zsh, where ~/.zfunc must be on $fpath.
+func InstallPath(shell, name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("determining home directory: %w", err)
+	}
+	switch shell {
+	case "bash":
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(dataHome, "bash-completion", "completions", name), nil
+	case "zsh":
+		return filepath.Join(home, ".zfunc", "_"+name), nil
+	case "fish":
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			configHome = filepath.Join(home, ".config")
+		}
+		return filepath.Join(configHome, "fish", "completions", name+".fish"), nil
+	default:
+		return "", fmt.Errorf("unsupported shell: %q (supported: bash, zsh, fish)", shell)
+	}
+}
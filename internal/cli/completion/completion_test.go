@@ -79,7 +79,7 @@ func TestUnsupportedShellError(t *testing.T) {
 	}
 	defer func() { _ = f.Close() }()
 
-	err = generateCompletion(root, "unsupported", f)
+	err = Generate(root, "unsupported", f)
 	if err == nil {
 		t.Error("expected error for unsupported shell")
 	}
@@ -99,8 +99,34 @@ func TestShellCompletionWithRealRoot(t *testing.T) {
 	}
 	defer func() { _ = f.Close() }()
 
-	err = generateCompletion(root, "bash", f)
+	err = Generate(root, "bash", f)
 	if err != nil {
 		t.Errorf("expected no error for bash, got: %v", err)
 	}
 }
+
+func TestInstallPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	want := map[string]string{
+		"bash": filepath.Join(home, ".local", "share", "bash-completion", "completions", "workshed"),
+		"zsh":  filepath.Join(home, ".zfunc", "_workshed"),
+		"fish": filepath.Join(home, ".config", "fish", "completions", "workshed.fish"),
+	}
+	for shell, path := range want {
+		got, err := InstallPath(shell, "workshed")
+		if err != nil {
+			t.Fatalf("InstallPath(%s) failed: %v", shell, err)
+		}
+		if got != path {
+			t.Errorf("InstallPath(%s) = %s, want %s", shell, got, path)
+		}
+	}
+
+	if _, err := InstallPath("tcsh", "workshed"); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+}
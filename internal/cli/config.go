@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// configKeys are the flags a config file may set defaults for, each with the
+// environment variable that overrides the file.
+var configKeys = map[string]string{
+	"depth":             "WORKSHED_DEPTH",
+	"default-ref":       "WORKSHED_DEFAULT_REF",
+	"concurrency":       "WORKSHED_CONCURRENCY",
+	"trash":             "WORKSHED_TRASH",
+	"compress-captures": "WORKSHED_COMPRESS_CAPTURES",
+}
+
+// ConfigKeys returns the settings a config file may hold, sorted.
+func ConfigKeys() []string {
+	keys := make([]string, 0, len(configKeys))
+	for key := range configKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ConfigEnvVar returns the environment variable that overrides key.
+func ConfigEnvVar(key string) string {
+	return configKeys[key]
+}
+
+// ValidateConfigValue checks that key is a known setting and value parses as
+// the type of the flag it sets.
+func ValidateConfigValue(key, value string) error {
+	if _, ok := configKeys[key]; !ok {
+		return fmt.Errorf("unknown setting %q (valid: %s)", key, strings.Join(ConfigKeys(), ", "))
+	}
+	switch key {
+	case "depth", "concurrency":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || (key == "concurrency" && n == 0) {
+			return fmt.Errorf("invalid value %q for %s: expected a positive number", value, key)
+		}
+	case "trash", "compress-captures":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: expected true or false", value, key)
+		}
+	case "default-ref":
+		if value == "" {
+			return errors.New("invalid value for default-ref: expected a ref name")
+		}
+	}
+	return nil
+}
+
+// ConfigPath returns the config file location: $WORKSHED_CONFIG, else
+// $XDG_CONFIG_HOME/workshed/config.yaml, else ~/.config/workshed/config.yaml.
+func ConfigPath() string {
+	if path := os.Getenv("WORKSHED_CONFIG"); path != "" {
+		return path
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "workshed", "config.yaml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "workshed", "config.yaml")
+}
+
+// ParseConfig reads flat "flag: value" lines, the subset of YAML the config
+// file uses. Blank lines and lines starting with # are ignored; values may be
+// quoted.
+func ParseConfig(r io.Reader) (map[string]string, error) {
+	cfg := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d: expected key: value", lineNo)
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if err := ValidateConfigValue(key, value); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		cfg[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	return cfg, nil
+}
+
+// LoadConfig parses the config file at path. A missing file is not an error
+// and yields no settings, so workshed works unchanged without one.
+func LoadConfig(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("opening config: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	cfg, err := ParseConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// LoadDefaults returns the flag defaults from the config file at path with
+// any WORKSHED_DEPTH, WORKSHED_DEFAULT_REF, WORKSHED_CONCURRENCY,
+// WORKSHED_TRASH or WORKSHED_COMPRESS_CAPTURES environment variables layered
+// on top.
+func LoadDefaults(path string) (map[string]string, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = make(map[string]string)
+	}
+	for key, env := range configKeys {
+		if value := os.Getenv(env); value != "" {
+			cfg[key] = value
+		}
+	}
+	return cfg, nil
+}
+
+// ApplyConfigDefaults makes cfg the default of every matching flag on cmd and
+// its subcommands. It must run before Execute so flags given on the command
+// line still override the config.
+func ApplyConfigDefaults(cmd *cobra.Command, cfg map[string]string) error {
+	for key, value := range cfg {
+		flag := cmd.Flags().Lookup(key)
+		if flag == nil {
+			continue
+		}
+		if err := flag.Value.Set(value); err != nil {
+			return fmt.Errorf("config %s: invalid value %q: %w", key, value, err)
+		}
+		flag.DefValue = value
+	}
+	for _, sub := range cmd.Commands() {
+		if err := ApplyConfigDefaults(sub, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetConfigValue validates and stores key in the config file at path,
+// replacing an existing line for key or appending one. Comments and other
+// lines are kept. The file is replaced atomically so a failed write never
+// leaves it half written.
+func SetConfigValue(path, key, value string) error {
+	if path == "" {
+		return errors.New("no config file location (set WORKSHED_CONFIG)")
+	}
+	if err := ValidateConfigValue(key, value); err != nil {
+		return err
+	}
+
+	var lines []string
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	entry := key + ": " + value
+	replaced := false
+	for i, line := range lines {
+		lineKey, _, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok && !strings.HasPrefix(strings.TrimSpace(line), "#") && strings.TrimSpace(lineKey) == key {
+			lines[i] = entry
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		lines = append(lines, entry)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing config: %w", err)
+	}
+	if _, err := tmp.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
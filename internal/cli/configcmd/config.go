@@ -0,0 +1,30 @@
+package configcmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "View and change persistent settings",
+		Long: `View and change the defaults in the workshed config file.
+
+The file lives at $WORKSHED_CONFIG, else $XDG_CONFIG_HOME/workshed/config.yaml,
+else ~/.config/workshed/config.yaml. Settings: compress-captures, concurrency, default-ref, depth, trash.
+
+Precedence: config file < environment < command-line flags.
+
+Examples:
+  workshed config list
+  workshed config get depth
+  workshed config set depth 1
+  workshed config set trash true`,
+	}
+
+	cmd.AddCommand(ListCommand())
+	cmd.AddCommand(GetCommand())
+	cmd.AddCommand(SetCommand())
+
+	return cmd
+}
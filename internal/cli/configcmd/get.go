@@ -0,0 +1,42 @@
+package configcmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+func GetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print a setting's effective value",
+		Long: `Print a setting's value from the config file, or from its environment
+variable when that is set.
+
+Examples:
+  workshed config get depth`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := args[0]
+			if cli.ConfigEnvVar(key) == "" {
+				return fmt.Errorf("unknown setting %q (valid: %s)", key, strings.Join(cli.ConfigKeys(), ", "))
+			}
+
+			defaults, err := cli.LoadDefaults(cli.ConfigPath())
+			if err != nil {
+				return err
+			}
+			value, ok := defaults[key]
+			if !ok {
+				return fmt.Errorf("%s is not set", key)
+			}
+
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+	}
+
+	return cmd
+}
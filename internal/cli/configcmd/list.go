@@ -0,0 +1,60 @@
+package configcmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+func ListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List settings and where each value comes from",
+		Long: `List every setting with its effective value and source: the config
+file, an environment variable, or unset (the flag's built-in default applies).
+
+Examples:
+  workshed config list
+  workshed config list --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := cli.ConfigPath()
+			cfg, err := cli.LoadConfig(path)
+			if err != nil {
+				return err
+			}
+
+			var rows [][]string
+			for _, key := range cli.ConfigKeys() {
+				value, source := cfg[key], "file"
+				if env := cli.ConfigEnvVar(key); os.Getenv(env) != "" {
+					value, source = os.Getenv(env), env
+				} else if _, ok := cfg[key]; !ok {
+					source = "unset"
+				}
+				rows = append(rows, []string{key, value, source})
+			}
+
+			output := cli.Output{
+				Columns: []cli.ColumnConfig{
+					{Type: cli.Rigid, Name: "KEY", Min: 11, Max: 11},
+					{Type: cli.Shrinkable, Name: "VALUE", Min: 10, Max: 0},
+					{Type: cli.Rigid, Name: "SOURCE", Min: 6, Max: 20},
+				},
+				Rows: rows,
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if err := cli.Render(output, format, cmd.OutOrStdout()); err != nil {
+				return fmt.Errorf("failed to render output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
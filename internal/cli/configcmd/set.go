@@ -0,0 +1,51 @@
+package configcmd
+
+import (
+	"fmt"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+func SetCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Store a setting in the config file",
+		Long: `Store a setting in the config file, creating the file if needed.
+
+Examples:
+  workshed config set depth 1
+  workshed config set default-ref main
+  workshed config set concurrency 8 --dry-run`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			path := cli.ConfigPath()
+			if dryRun {
+				if path == "" {
+					return fmt.Errorf("failed to set %s: no config file location (set WORKSHED_CONFIG)", args[0])
+				}
+				if err := cli.ValidateConfigValue(args[0], args[1]); err != nil {
+					return fmt.Errorf("failed to set %s: %w", args[0], err)
+				}
+				return cli.RenderPlan(cmd, []cli.PlanStep{
+					{Action: "set config", Target: args[0], Detail: fmt.Sprintf("%s in %s", args[1], path)},
+				})
+			}
+			if err := cli.SetConfigValue(path, args[0], args[1]); err != nil {
+				return fmt.Errorf("failed to set %s: %w", args[0], err)
+			}
+
+			r.GetLogger().Success("config updated", "key", args[0], "value", args[1], "path", path)
+			return nil
+		},
+	}
+
+	cli.AddDryRunFlag(cmd, &dryRun)
+
+	return cmd
+}
@@ -0,0 +1,27 @@
+package configcmd
+
+import "testing"
+
+func TestConfigCommand(t *testing.T) {
+	t.Run("has list, get and set subcommands", func(t *testing.T) {
+		cmd := Command()
+		for _, name := range []string{"list", "get", "set"} {
+			found := false
+			for _, sub := range cmd.Commands() {
+				if sub.Name() == name {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("config should have %s subcommand", name)
+			}
+		}
+	})
+
+	t.Run("list has --format flag", func(t *testing.T) {
+		if ListCommand().Flags().Lookup("format") == nil {
+			t.Error("config list should have --format flag")
+		}
+	})
+}
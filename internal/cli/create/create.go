@@ -2,9 +2,11 @@ package create
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,6 +27,25 @@ func Command() *cobra.Command {
 	var template string
 	var templateVars []string
 	var depth int
+	var concurrency int
+	var defaultRef string
+	var eventsMode string
+	var lockPath string
+	var like string
+	var verbose bool
+	var host string
+	var project string
+	var copyWorkingTree bool
+	var includeIgnored bool
+	var noCheckout bool
+	var sparse []string
+	var lfs bool
+	var remotes []string
+	var repoSetups []string
+	var idempotencyKey string
+	var newBranch string
+	var newBranchFrom string
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "create",
@@ -34,13 +55,34 @@ func Command() *cobra.Command {
 Examples:
   workshed create --purpose "Debug payment timeout" --repo github.com/org/api@main
   workshed create -r github.com/org/frontend@feature -r github.com/org/backend@feature
+  workshed create --purpose "Shorthand" -r org/api@main -r org/web --host gitlab.com
   workshed create --purpose "Shallow clone" --repo github.com/org/large-repo::10
   workshed create --purpose "Shallow with ref" --repo github.com/org/repo@main::5
+  workshed create --purpose "Checkout flow" --project payments -r github.com/org/api@main
+  workshed create --purpose "Release fix" --default-ref release -r github.com/org/api -r github.com/org/web
   workshed create --purpose "New feature" --template ~/templates/react-app --map name=myapp
-  workshed create --purpose "Local exploration"`,
+  workshed create --purpose "Reproduce bug" --lock workshed.lock
+  workshed create --purpose "Second opinion" --like aquatic-fox
+  workshed create --purpose "Private repo" --repo git@github.com:org/private.git --verbose
+  workshed create --purpose "Local exploration"
+  workshed create --purpose "Carry my WIP" --copy-working-tree --repo ../api
+  workshed create --purpose "History only" --no-checkout --repo github.com/org/monorepo
+  workshed create --purpose "Login page" --new-branch feature/login -r github.com/org/api -r github.com/org/web
+  workshed create --purpose "Hotfix" --new-branch fix/timeout --new-branch-from release -r github.com/org/api
+  workshed create --purpose "CI run" --idempotency-key "$CI_JOB_ID" --repo github.com/org/api
+  workshed create --purpose "One service" --repo github.com/org/monorepo --sparse services/api
+  workshed create --purpose "Fork fix" --repo github.com/me/tool --remote upstream=github.com/org/tool
+  workshed create --purpose "Deps ready" --repo github.com/org/api --repo-setup "api=make deps"
+  workshed create --purpose "Texture fix" --lfs --repo github.com/org/game-assets
+  workshed create --purpose "Check first" --repo github.com/org/api --dry-run`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			r := cli.NewRunner("")
+			cwd, err := cli.InvocationDir(cmd)
+			if err != nil {
+				return err
+			}
+			r := cli.NewRunner(cwd)
+			r.DryRun = dryRun
 			ctx := context.Background()
 
 			isInteractive := term.IsTerminal(int(os.Stdin.Fd()))
@@ -52,9 +94,41 @@ Examples:
 				return fmt.Errorf("missing required flag: --purpose")
 			}
 
+			events, err := cli.NewEventWriter(eventsMode, cmd.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			if events != nil {
+				// Keep stdout parseable as JSONL when the command fails.
+				cmd.SilenceUsage = true
+			}
+
 			repos = append(repos, reposAlias...)
 
-			if len(repos) == 0 && isInteractive {
+			var lockfile *workspace.Lockfile
+			if lockPath != "" {
+				lockfile, err = readLockfile(lockPath)
+				if err != nil {
+					return err
+				}
+			}
+			if like != "" {
+				if lockfile != nil {
+					return fmt.Errorf("--like cannot be combined with --lock")
+				}
+				// The source's repositories are pinned at their current commits,
+				// then restored exactly as a lockfile would be.
+				lockfile, err = r.GetStore().Lock(ctx, like)
+				if err != nil {
+					return fmt.Errorf("reading repositories of %s: %w", like, err)
+				}
+			}
+			lockFlag := "--lock"
+			if like != "" {
+				lockFlag = "--like"
+			}
+
+			if len(repos) == 0 && lockfile == nil && isInteractive {
 				fmt.Print("Repository URL (optional, press Enter to use current directory's git remote): ")
 				repoInput, err := cli.ReadLine(r.Stdin)
 				if err != nil {
@@ -74,8 +148,20 @@ Examples:
 
 			repoOpts := make([]workspace.RepositoryOption, 0)
 
-			if len(repos) == 0 {
-				currentURL, err := git.RealGit{}.GetRemoteURL(context.Background(), ".")
+			if lockfile != nil {
+				for _, locked := range lockfile.Repositories {
+					repoOpts = append(repoOpts, workspace.RepositoryOption{URL: locked.URL})
+				}
+			}
+
+			if len(repos) == 0 && lockfile == nil && copyWorkingTree {
+				repoOpts = append(repoOpts, workspace.RepositoryOption{URL: r.GetInvocationCWD()})
+			} else if len(repos) == 0 && lockfile == nil {
+				dir := r.GetInvocationCWD()
+				if dir == "" {
+					dir = "."
+				}
+				currentURL, err := git.RealGit{}.GetRemoteURL(context.Background(), dir)
 				if err != nil {
 					return fmt.Errorf("no repository specified and not in a git repository with origin: %w", err)
 				}
@@ -88,6 +174,7 @@ Examples:
 					}
 
 					url, ref, repoDepth := workspace.ParseRepoFlag(repo)
+					url = workspace.ExpandRepoShorthand(url, host, r.GetInvocationCWD())
 					d := depth
 					if repoDepth > 0 {
 						d = repoDepth
@@ -100,6 +187,70 @@ Examples:
 				}
 			}
 
+			if copyWorkingTree {
+				for i := range repoOpts {
+					repoOpts[i].CopyWorkingTree = true
+					repoOpts[i].IncludeIgnored = includeIgnored
+				}
+			} else if includeIgnored {
+				return fmt.Errorf("--include-ignored requires --copy-working-tree")
+			}
+
+			if noCheckout {
+				if copyWorkingTree {
+					return fmt.Errorf("--no-checkout cannot be combined with --copy-working-tree")
+				}
+				if lockfile != nil {
+					return fmt.Errorf("--no-checkout cannot be combined with %s", lockFlag)
+				}
+				for i := range repoOpts {
+					repoOpts[i].NoCheckout = true
+				}
+			}
+
+			if lfs {
+				if copyWorkingTree {
+					return fmt.Errorf("--lfs cannot be combined with --copy-working-tree")
+				}
+				for i := range repoOpts {
+					repoOpts[i].LFS = true
+				}
+			}
+
+			if len(sparse) > 0 {
+				if copyWorkingTree {
+					return fmt.Errorf("--sparse cannot be combined with --copy-working-tree")
+				}
+				for i := range repoOpts {
+					repoOpts[i].Sparse = sparse
+				}
+			}
+
+			if newBranch != "" {
+				if noCheckout {
+					return fmt.Errorf("--new-branch cannot be combined with --no-checkout")
+				}
+				if lockfile != nil {
+					return fmt.Errorf("--new-branch cannot be combined with %s", lockFlag)
+				}
+			} else if newBranchFrom != "" {
+				return fmt.Errorf("--new-branch-from requires --new-branch")
+			}
+
+			if len(remotes) > 0 {
+				remoteMap, err := workspace.ParseRemoteFlags(remotes)
+				if err != nil {
+					return err
+				}
+				if copyWorkingTree {
+					return fmt.Errorf("--remote cannot be combined with --copy-working-tree")
+				}
+				if len(repoOpts) != 1 {
+					return fmt.Errorf("--remote requires exactly one repository")
+				}
+				repoOpts[0].Remotes = remoteMap
+			}
+
 			for _, local := range localMap {
 				if err := validateLocalMapFlag(local); err != nil {
 					return fmt.Errorf("invalid local-map %q: %w", local, err)
@@ -113,6 +264,19 @@ Examples:
 				}
 			}
 
+			if len(repoSetups) > 0 {
+				setups, err := workspace.ParseSetupFlags(repoSetups)
+				if err != nil {
+					return err
+				}
+				if noCheckout {
+					return fmt.Errorf("--repo-setup cannot be combined with --no-checkout")
+				}
+				if err := workspace.AssignPostClone(repoOpts, setups, r.GetInvocationCWD()); err != nil {
+					return err
+				}
+			}
+
 			templateVarsMap := make(map[string]string)
 			for _, kv := range templateVars {
 				parts := strings.SplitN(kv, "=", 2)
@@ -128,12 +292,26 @@ Examples:
 				}
 			}
 
+			if dryRun {
+				return cli.RenderPlan(cmd, createPlan(purpose, project, template, repoOpts, lockfile, newBranch))
+			}
+
 			opts := workspace.CreateOptions{
-				Purpose:       purpose,
-				Template:      template,
-				TemplateVars:  templateVarsMap,
-				Repositories:  repoOpts,
-				InvocationCWD: r.GetInvocationCWD(),
+				Purpose:        purpose,
+				Project:        project,
+				Template:       template,
+				TemplateVars:   templateVarsMap,
+				Repositories:   repoOpts,
+				DefaultRef:     defaultRef,
+				InvocationCWD:  r.GetInvocationCWD(),
+				Concurrency:    concurrency,
+				IdempotencyKey: idempotencyKey,
+				NewBranch:      newBranch,
+				NewBranchFrom:  newBranchFrom,
+			}
+
+			if events != nil {
+				opts.OnProgress = events.Progress
 			}
 
 			createCtx, cancel := context.WithTimeout(ctx, defaultCloneTimeout)
@@ -141,9 +319,42 @@ Examples:
 
 			ws, err := r.GetStore().Create(createCtx, opts)
 			if err != nil {
+				if verbose {
+					cli.WriteGitDetails(cmd.ErrOrStderr(), err)
+				}
+				if events != nil {
+					events.Emit(cli.Event{Type: cli.EventSummary, Error: err.Error()})
+				}
 				return fmt.Errorf("workspace creation failed: %w", err)
 			}
 
+			if lockfile != nil && !ws.Existing {
+				if err := r.GetStore().RestoreLock(createCtx, ws.Handle, lockfile); err != nil {
+					if events != nil {
+						events.Emit(cli.Event{Type: cli.EventSummary, Handle: ws.Handle, Error: err.Error()})
+					}
+					return fmt.Errorf("restoring lockfile: %w", err)
+				}
+			}
+
+			if !ws.Existing {
+				names := make([]string, len(ws.Repositories))
+				for i, repo := range ws.Repositories {
+					names[i] = repo.Name
+				}
+				cli.WarnLFS(ctx, cmd.ErrOrStderr(), ws, names)
+			}
+
+			if events != nil {
+				events.Emit(cli.Event{
+					Type:   cli.EventSummary,
+					Handle: ws.Handle,
+					Path:   ws.Path,
+					Repos:  len(ws.Repositories),
+				})
+				return nil
+			}
+
 			format := cmd.Flags().Lookup("format").Value.String()
 			if format == "raw" {
 				_, _ = fmt.Fprintln(cmd.OutOrStdout(), ws.Handle)
@@ -154,6 +365,10 @@ Examples:
 				"handle":  ws.Handle,
 				"path":    ws.Path,
 				"purpose": ws.Purpose,
+				"created": strconv.FormatBool(!ws.Existing),
+			}
+			if ws.Project != "" {
+				data["project"] = ws.Project
 			}
 			for _, repo := range ws.Repositories {
 				var repoInfo string
@@ -170,18 +385,78 @@ Examples:
 	}
 
 	cmd.Flags().StringVar(&purpose, "purpose", "", "Workspace purpose")
+	cmd.Flags().StringVar(&project, "project", "", "Project to group the workspace under")
 	cmd.Flags().StringSliceVarP(&repos, "repo", "r", nil, "Repository URL with optional @ref and ::depth")
 	cmd.Flags().StringSliceVar(&reposAlias, "repos", nil, "Alias for --repo (can be specified multiple times)")
 	cmd.Flags().StringSliceVar(&localMap, "local-map", nil, "Map a local directory as a repository")
 	cmd.Flags().StringVar(&template, "template", "", "Template name or path")
 	cmd.Flags().StringSliceVar(&templateVars, "map", nil, "Template variable (key=value)")
+	cmd.Flags().BoolVar(&copyWorkingTree, "copy-working-tree", false, "Copy local repositories as they are on disk, uncommitted changes included, instead of cloning")
+	cmd.Flags().BoolVar(&includeIgnored, "include-ignored", false, "With --copy-working-tree, also copy gitignored files")
+	cmd.Flags().BoolVar(&noCheckout, "no-checkout", false, "Clone history without checking out a working tree (see repos checkout)")
+	cmd.Flags().StringArrayVar(&remotes, "remote", nil, "Additional remote as name=url, e.g. upstream=github.com/org/repo (can be specified multiple times)")
+	cmd.Flags().BoolVar(&lfs, "lfs", false, "Pull Git LFS files after cloning each repository")
+	cmd.Flags().StringArrayVar(&repoSetups, "repo-setup", nil, "Command to run in a repository after cloning, as name=command (can be specified multiple times)")
+	cmd.Flags().StringSliceVar(&sparse, "sparse", nil, "Only check out these directories of each repository (sparse checkout)")
+	cmd.Flags().StringVar(&newBranch, "new-branch", "", "Create and check out this branch in every repository after cloning")
+	cmd.Flags().StringVar(&newBranchFrom, "new-branch-from", "", "Ref to clone every repository at before creating --new-branch")
+	cmd.Flags().StringVar(&idempotencyKey, "idempotency-key", "", "Return the workspace created with this key instead of creating a duplicate")
 	cmd.Flags().IntVar(&depth, "depth", 0, "Default clone depth (overridden by ::depth in repo URL)")
+	cmd.Flags().IntVar(&concurrency, "concurrency", workspace.DefaultCloneConcurrency, "Maximum repositories to clone at once")
+	cmd.Flags().StringVar(&defaultRef, "default-ref", "", "Ref for repositories without @ref (default: detected branch)")
+	cmd.Flags().StringVar(&eventsMode, "events", "", "Stream progress events to stdout (jsonl)")
+	cmd.Flags().StringVar(&lockPath, "lock", "", "Lockfile from 'workshed lock' to restore exact commits")
+	cmd.Flags().StringVar(&like, "like", "", "Clone the repositories of this workspace at the commits it has checked out")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Print full git output on failure")
+	cmd.Flags().StringVar(&host, "host", "", "Host for owner/repo shorthand (default: $WORKSHED_DEFAULT_HOST or github.com)")
+	cli.AddDryRunFlag(cmd, &dryRun)
 	cmd.Flags().String("format", "table", "Output format (table|json)")
 	_ = cmd.MarkFlagRequired("purpose")
 
 	return cmd
 }
 
+// createPlan lists what create would do, in the order it does it.
+func createPlan(purpose, project, template string, repoOpts []workspace.RepositoryOption, lockfile *workspace.Lockfile, newBranch string) []cli.PlanStep {
+	detail := "purpose " + strconv.Quote(purpose)
+	if project != "" {
+		detail += ", project " + project
+	}
+	steps := []cli.PlanStep{{Action: "create workspace", Target: "(new handle)", Detail: detail}}
+	if template != "" {
+		steps = append(steps, cli.PlanStep{Action: "copy template", Target: template})
+	}
+	for _, opt := range repoOpts {
+		steps = append(steps, cli.CloneStep(opt))
+	}
+	if lockfile != nil {
+		for _, locked := range lockfile.Repositories {
+			steps = append(steps, cli.PlanStep{Action: "checkout", Target: locked.Name, Detail: locked.Commit})
+		}
+	}
+	if newBranch != "" {
+		steps = append(steps, cli.PlanStep{Action: "create branch", Target: newBranch, Detail: "in every repository"})
+	}
+	return steps
+}
+
+func readLockfile(path string) (*workspace.Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading lockfile: %w", err)
+	}
+	var lockfile workspace.Lockfile
+	if err := json.Unmarshal(data, &lockfile); err != nil {
+		return nil, fmt.Errorf("parsing lockfile: %w", err)
+	}
+	for _, repo := range lockfile.Repositories {
+		if repo.URL == "" || repo.Commit == "" {
+			return nil, fmt.Errorf("invalid lockfile entry %q: url and commit are required", repo.Name)
+		}
+	}
+	return &lockfile, nil
+}
+
 func validateRepoFlag(repo string) error {
 	repo = strings.TrimSpace(repo)
 	if repo == "" {
@@ -42,6 +42,48 @@ func TestCreateCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("has --default-ref flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "default-ref") {
+			t.Error("create should have --default-ref flag")
+		}
+	})
+
+	t.Run("has --lock flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "lock") {
+			t.Error("create should have --lock flag")
+		}
+	})
+
+	t.Run("has --like flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "like") {
+			t.Error("create should have --like flag")
+		}
+	})
+
+	t.Run("has --host flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "host") {
+			t.Error("create should have --host flag")
+		}
+	})
+
+	t.Run("has --verbose flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "verbose") {
+			t.Error("create should have --verbose flag")
+		}
+	})
+
+	t.Run("has --events flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "events") {
+			t.Error("create should have --events flag")
+		}
+	})
+
 	t.Run("has --map flag for template variables", func(t *testing.T) {
 		cmd := Command()
 		if !flagExists(cmd, "map") {
@@ -55,4 +97,72 @@ func TestCreateCommand(t *testing.T) {
 			t.Error("create should have --local-map flag")
 		}
 	})
+
+	t.Run("has --concurrency flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "concurrency") {
+			t.Error("create should have --concurrency flag")
+		}
+	})
+
+	t.Run("has --project flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "project") {
+			t.Error("create should have --project flag")
+		}
+	})
+
+	t.Run("has --copy-working-tree and --include-ignored flags", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "copy-working-tree") {
+			t.Error("create should have --copy-working-tree flag")
+		}
+		if !flagExists(cmd, "include-ignored") {
+			t.Error("create should have --include-ignored flag")
+		}
+	})
+
+	t.Run("has --no-checkout flag", func(t *testing.T) {
+		if !flagExists(Command(), "no-checkout") {
+			t.Error("create should have --no-checkout flag")
+		}
+	})
+
+	t.Run("has --lfs flag", func(t *testing.T) {
+		if !flagExists(Command(), "lfs") {
+			t.Error("create should have --lfs flag")
+		}
+	})
+
+	t.Run("has --sparse flag", func(t *testing.T) {
+		if !flagExists(Command(), "sparse") {
+			t.Error("create should have --sparse flag")
+		}
+	})
+
+	t.Run("has --remote flag", func(t *testing.T) {
+		if !flagExists(Command(), "remote") {
+			t.Error("create should have --remote flag")
+		}
+	})
+
+	t.Run("has --repo-setup flag", func(t *testing.T) {
+		if !flagExists(Command(), "repo-setup") {
+			t.Error("create should have --repo-setup flag")
+		}
+	})
+
+	t.Run("has --new-branch flags", func(t *testing.T) {
+		for _, name := range []string{"new-branch", "new-branch-from"} {
+			if !flagExists(Command(), name) {
+				t.Errorf("create should have --%s flag", name)
+			}
+		}
+	})
+
+	t.Run("has --idempotency-key flag", func(t *testing.T) {
+		if !flagExists(Command(), "idempotency-key") {
+			t.Error("create should have --idempotency-key flag")
+		}
+	})
 }
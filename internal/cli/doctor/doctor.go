@@ -0,0 +1,226 @@
+package doctor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/cli/completion"
+	"github.com/frodi/workshed/internal/git"
+	"github.com/frodi/workshed/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// StatusOK means nothing needs doing.
+	StatusOK = "ok"
+	// StatusFixed means --fix just set the item up.
+	StatusFixed = "fixed"
+	// StatusMissing means --fix would set the item up.
+	StatusMissing = "missing"
+	// StatusWarn means the item needs attention --fix cannot give it.
+	StatusWarn = "warn"
+)
+
+// Check is the outcome of one doctor check.
+type Check struct {
+	Name   string
+	Status string
+	Detail string
+	// Fix is what --fix would do about a StatusMissing check.
+	Fix string
+}
+
+// Env is what the checks look at, so tests can point them at a temp HOME.
+type Env struct {
+	// Root is the command tree completion scripts are generated from.
+	Root *cobra.Command
+	// StoreRoot is the workspace root directory.
+	StoreRoot string
+	// Shell is the path of the user's shell, e.g. /bin/zsh.
+	Shell string
+}
+
+var columns = []cli.ColumnConfig{
+	{Type: cli.Rigid, Name: "CHECK", Min: 10, Max: 12},
+	{Type: cli.Rigid, Name: "STATUS", Min: 7, Max: 7},
+	{Type: cli.Shrinkable, Name: "DETAIL", Min: 10, Max: 0},
+}
+
+func NewCommand(root *cobra.Command) *cobra.Command {
+	var fix bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check and set up the workshed environment",
+		Long: `Check that git is installed, the workspace root exists and shell completion
+is installed for your shell ($SHELL).
+
+--fix creates the workspace root and writes the completion script into the
+shell's completion directory. Fixes are safe to repeat: anything already in
+place is left alone. Problems doctor cannot fix, such as a missing git, are
+reported as warnings. The command exits non-zero while any check is not ok.
+With --dry-run it lists what --fix would do without doing it.
+
+Examples:
+  workshed doctor
+  workshed doctor --fix
+  workshed doctor --fix --dry-run
+  workshed doctor --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			shellPath, _ := shell.Detect()
+			checks := Run(Env{Root: root, StoreRoot: r.GetWorkshedRoot(), Shell: shellPath}, fix && !dryRun)
+
+			if dryRun {
+				var steps []cli.PlanStep
+				for _, c := range checks {
+					if c.Status == StatusMissing {
+						steps = append(steps, cli.PlanStep{Action: c.Fix, Target: c.Detail, Detail: c.Name})
+					}
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
+			var rows [][]string
+			problems := 0
+			for _, c := range checks {
+				if c.Status != StatusOK && c.Status != StatusFixed {
+					problems++
+				}
+				rows = append(rows, []string{c.Name, c.Status, c.Detail})
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if err := cli.Render(cli.Output{Columns: columns, Rows: rows}, format, cmd.OutOrStdout()); err != nil {
+				return fmt.Errorf("failed to render output: %w", err)
+			}
+
+			if problems > 0 {
+				cmd.SilenceUsage = true
+				if fix {
+					return fmt.Errorf("%d problem(s) need fixing by hand", problems)
+				}
+				return fmt.Errorf("%d problem(s) found: run 'workshed doctor --fix'", problems)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&fix, "fix", false, "Create the workspace root and install shell completion")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
+
+// Run performs every check against env, fixing what it can when fix is set.
+func Run(env Env, fix bool) []Check {
+	return []Check{
+		checkGit(),
+		checkStoreRoot(env.StoreRoot, fix),
+		checkCompletion(env.Root, env.Shell, fix),
+	}
+}
+
+func checkGit() Check {
+	if err := git.Available(); err != nil {
+		return Check{Name: "git", Status: StatusWarn, Detail: "install git and make sure it is on $PATH"}
+	}
+	return Check{Name: "git", Status: StatusOK, Detail: "found"}
+}
+
+func checkStoreRoot(root string, fix bool) Check {
+	check := Check{Name: "store root", Detail: root}
+	info, err := os.Stat(root)
+	switch {
+	case err == nil && info.IsDir():
+		check.Status = StatusOK
+	case err == nil:
+		check.Status = StatusWarn
+		check.Detail = root + " is not a directory"
+	case !errors.Is(err, os.ErrNotExist):
+		check.Status = StatusWarn
+		check.Detail = err.Error()
+	case !fix:
+		check.Status = StatusMissing
+		check.Fix = "create directory"
+	default:
+		if err := os.MkdirAll(root, 0755); err != nil {
+			check.Status = StatusWarn
+			check.Detail = err.Error()
+		} else {
+			check.Status = StatusFixed
+		}
+	}
+	return check
+}
+
+// checkCompletion compares the installed completion script with the one the
+// current binary generates, so an outdated script is replaced by --fix.
+func checkCompletion(root *cobra.Command, shellPath string, fix bool) Check {
+	check := Check{Name: "completion"}
+	name := detectShell(shellPath)
+	if name == "" {
+		check.Status = StatusWarn
+		check.Detail = "cannot detect your shell: set $SHELL"
+		return check
+	}
+
+	path, err := completion.InstallPath(name, root.Name())
+	if err != nil {
+		check.Status = StatusWarn
+		check.Detail = fmt.Sprintf("%v; see 'workshed completion'", err)
+		return check
+	}
+	check.Detail = path
+
+	var script bytes.Buffer
+	if err := completion.Generate(root, name, &script); err != nil {
+		check.Status = StatusWarn
+		check.Detail = err.Error()
+		return check
+	}
+
+	if installed, err := os.ReadFile(path); err == nil && bytes.Equal(installed, script.Bytes()) {
+		check.Status = StatusOK
+		return check
+	}
+	if !fix {
+		check.Status = StatusMissing
+		check.Fix = "install completion"
+		return check
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		check.Status = StatusWarn
+		check.Detail = err.Error()
+		return check
+	}
+	if err := os.WriteFile(path, script.Bytes(), 0644); err != nil {
+		check.Status = StatusWarn
+		check.Detail = err.Error()
+		return check
+	}
+	check.Status = StatusFixed
+	if name == "zsh" {
+		check.Detail += " (add ~/.zfunc to $fpath before compinit)"
+	}
+	return check
+}
+
+// detectShell returns the name of the shell at shellPath, e.g. "zsh" for
+// /usr/bin/zsh, or "" when there is none.
+func detectShell(shellPath string) string {
+	if shellPath == "" {
+		return ""
+	}
+	return filepath.Base(shellPath)
+}
@@ -0,0 +1,97 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestDoctorCommand(t *testing.T) {
+	cmd := NewCommand(&cobra.Command{Use: "workshed"})
+	for _, flag := range []string{"fix", "dry-run", "format"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("doctor should have --%s flag", flag)
+		}
+	}
+}
+
+func statuses(checks []Check) map[string]string {
+	out := make(map[string]string, len(checks))
+	for _, c := range checks {
+		out[c.Name] = c.Status
+	}
+	return out
+}
+
+func TestRunFix(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	root := &cobra.Command{Use: "workshed"}
+	root.AddCommand(&cobra.Command{Use: "list"})
+	env := Env{Root: root, StoreRoot: filepath.Join(home, ".workshed", "workspaces"), Shell: "/usr/bin/fish"}
+	script := filepath.Join(home, ".config", "fish", "completions", "workshed.fish")
+
+	t.Run("without --fix only reports", func(t *testing.T) {
+		got := statuses(Run(env, false))
+		if got["store root"] != StatusMissing || got["completion"] != StatusMissing {
+			t.Errorf("Expected store root and completion to be missing, got %v", got)
+		}
+		if _, err := os.Stat(env.StoreRoot); !os.IsNotExist(err) {
+			t.Errorf("Expected no store root to be created, got err=%v", err)
+		}
+	})
+
+	t.Run("creates the store root and installs completion", func(t *testing.T) {
+		got := statuses(Run(env, true))
+		if got["store root"] != StatusFixed || got["completion"] != StatusFixed {
+			t.Errorf("Expected store root and completion to be fixed, got %v", got)
+		}
+		if info, err := os.Stat(env.StoreRoot); err != nil || !info.IsDir() {
+			t.Errorf("Expected the store root to be created: %v", err)
+		}
+		data, err := os.ReadFile(script)
+		if err != nil {
+			t.Fatalf("Expected a completion script at %s: %v", script, err)
+		}
+		if !strings.Contains(string(data), "complete -c workshed") {
+			t.Errorf("Expected a fish completion script, got:\n%s", data)
+		}
+	})
+
+	t.Run("re-running is a no-op", func(t *testing.T) {
+		before, err := os.Stat(script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		past := before.ModTime().Add(-time.Hour)
+		if err := os.Chtimes(script, past, past); err != nil {
+			t.Fatal(err)
+		}
+
+		got := statuses(Run(env, true))
+		if got["store root"] != StatusOK || got["completion"] != StatusOK {
+			t.Errorf("Expected everything to be ok, got %v", got)
+		}
+		after, err := os.Stat(script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !after.ModTime().Equal(past) {
+			t.Error("Expected the completion script not to be rewritten")
+		}
+	})
+
+	t.Run("an unsupported shell is a warning", func(t *testing.T) {
+		checks := Run(Env{Root: root, StoreRoot: env.StoreRoot, Shell: "/bin/tcsh"}, true)
+		if got := statuses(checks); got["completion"] != StatusWarn {
+			t.Errorf("Expected a completion warning, got %v", got)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+// AddDryRunFlag registers --dry-run on a mutating command. Under --dry-run a
+// command validates its input and prints the plan from RenderPlan. It must not
+// write to the workspace store or any repository, and must not clone or fetch.
+//
+// Every command that writes registers it, with these exceptions: apply
+// defines its own --dry-run that also reports the preflight result, repos
+// exec and watch only run the user's command, and selftest works in a
+// temporary store it removes afterwards.
+func AddDryRunFlag(cmd *cobra.Command, dryRun *bool) {
+	cmd.Flags().BoolVar(dryRun, "dry-run", false, "Validate and print the planned changes without making them")
+}
+
+// PlanStep is one change a command would make, as printed under --dry-run.
+type PlanStep struct {
+	Action string
+	Target string
+	Detail string
+}
+
+// PlanColumns are the columns of a --dry-run plan.
+var PlanColumns = []ColumnConfig{
+	{Type: Rigid, Name: "ACTION", Min: 10, Max: 20},
+	{Type: Rigid, Name: "TARGET", Min: 10, Max: 0},
+	{Type: Shrinkable, Name: "DETAIL", Min: 10, Max: 0},
+}
+
+// RenderPlan prints steps in the format chosen by the command's --format flag,
+// or as a table when it has none. Table output is headed by a note that
+// nothing was changed.
+func RenderPlan(cmd *cobra.Command, steps []PlanStep) error {
+	format := "table"
+	if flag := cmd.Flags().Lookup("format"); flag != nil {
+		format = flag.Value.String()
+	}
+
+	rows := make([][]string, len(steps))
+	for i, step := range steps {
+		rows[i] = []string{step.Action, step.Target, step.Detail}
+	}
+
+	if format == "table" {
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Dry run - no changes made")
+		if len(rows) == 0 {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Nothing to do")
+			return nil
+		}
+	}
+	if err := Render(Output{Columns: PlanColumns, Rows: rows}, format, cmd.OutOrStdout()); err != nil {
+		return fmt.Errorf("failed to render output: %w", err)
+	}
+	return nil
+}
+
+// CloneStep describes cloning opt, or copying it with --copy-working-tree.
+func CloneStep(opt workspace.RepositoryOption) PlanStep {
+	step := PlanStep{Action: "clone repository", Target: opt.URL}
+	if opt.CopyWorkingTree {
+		step.Action = "copy repository"
+	}
+
+	var details []string
+	if opt.Ref != "" {
+		details = append(details, "ref "+opt.Ref)
+	}
+	if opt.Depth > 0 {
+		details = append(details, "depth "+strconv.Itoa(opt.Depth))
+	}
+	if opt.NoCheckout {
+		details = append(details, "no checkout")
+	}
+	if len(opt.Sparse) > 0 {
+		details = append(details, "sparse "+strings.Join(opt.Sparse, ","))
+	}
+	if opt.LFS {
+		details = append(details, "lfs")
+	}
+	if len(opt.PostClone) > 0 {
+		details = append(details, "setup "+strconv.Quote(strings.Join(opt.PostClone, " ")))
+	}
+	step.Detail = strings.Join(details, ", ")
+	return step
+}
@@ -0,0 +1,30 @@
+package envcmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Manage workspace environment variables",
+		Long: `Manage workspace environment variables.
+
+Variables are stored in the workspace's .workshed/env file and passed to every
+exec in that workspace.
+
+Environment precedence: process env < workspace env file < exec --env flags.
+
+Examples:
+  workshed env list
+  workshed env set API_URL=http://localhost:8080
+  workshed env set my-workspace API_URL=http://localhost:8080 DEBUG=1
+  workshed env unset my-workspace DEBUG`,
+	}
+
+	cmd.AddCommand(ListCommand())
+	cmd.AddCommand(SetCommand())
+	cmd.AddCommand(UnsetCommand())
+
+	return cmd
+}
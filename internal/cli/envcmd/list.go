@@ -0,0 +1,75 @@
+package envcmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+func ListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list [<handle>]",
+		Short: "List workspace environment variables",
+		Long: `List workspace environment variables.
+
+Examples:
+  workshed env list
+  workshed env list my-workspace --format raw`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			vars, err := r.GetStore().WorkspaceEnv(ctx, handle)
+			if err != nil {
+				return fmt.Errorf("failed to read workspace env: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+
+			if format == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(vars)
+			}
+
+			if len(vars) == 0 {
+				return cli.RenderEmptyList(format, "no environment variables set", cmd.OutOrStdout(), r.GetLogger())
+			}
+
+			keys := make([]string, 0, len(vars))
+			for key := range vars {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			if format == "raw" {
+				for _, key := range keys {
+					_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s=%s\n", key, vars[key])
+				}
+				return nil
+			}
+
+			var rows [][]string
+			for _, key := range keys {
+				rows = append(rows, []string{key, vars[key]})
+			}
+
+			return cli.Render(cli.Output{Columns: cli.KeyValueColumns, Rows: rows}, format, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
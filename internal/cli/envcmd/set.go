@@ -0,0 +1,83 @@
+package envcmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func SetCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "set [<handle>] KEY=VALUE...",
+		Short: "Set workspace environment variables",
+		Long: `Set workspace environment variables.
+
+Examples:
+  workshed env set API_URL=http://localhost:8080
+  workshed env set my-workspace API_URL=http://localhost:8080 DEBUG=1
+  workshed env set DEBUG=1 --dry-run`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			ctx := context.Background()
+
+			var providedHandle string
+			vars := make(map[string]string)
+			for _, arg := range args {
+				key, value, ok := strings.Cut(arg, "=")
+				if !ok {
+					if providedHandle != "" {
+						return fmt.Errorf("invalid assignment %q: expected KEY=VALUE", arg)
+					}
+					providedHandle = arg
+					continue
+				}
+				vars[key] = value
+			}
+			if len(vars) == 0 {
+				return fmt.Errorf("missing KEY=VALUE")
+			}
+
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if dryRun {
+				keys := make([]string, 0, len(vars))
+				for key, value := range vars {
+					if err := workspace.ValidateEnvVar(key, value); err != nil {
+						return fmt.Errorf("failed to set workspace env: %w", err)
+					}
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				steps := make([]cli.PlanStep, len(keys))
+				for i, key := range keys {
+					steps[i] = cli.PlanStep{Action: "set variable", Target: key, Detail: vars[key]}
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
+			if err := r.GetStore().SetWorkspaceEnv(ctx, handle, vars); err != nil {
+				return fmt.Errorf("failed to set workspace env: %w", err)
+			}
+
+			r.GetLogger().Success("environment updated", "handle", handle, "set", len(vars))
+			return nil
+		},
+	}
+
+	cli.AddDryRunFlag(cmd, &dryRun)
+
+	return cmd
+}
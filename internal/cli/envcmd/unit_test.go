@@ -0,0 +1,27 @@
+package envcmd
+
+import "testing"
+
+func TestEnvCommand(t *testing.T) {
+	t.Run("has list, set and unset subcommands", func(t *testing.T) {
+		cmd := Command()
+		for _, name := range []string{"list", "set", "unset"} {
+			found := false
+			for _, sub := range cmd.Commands() {
+				if sub.Name() == name {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("env should have %s subcommand", name)
+			}
+		}
+	})
+
+	t.Run("list has --format flag", func(t *testing.T) {
+		if ListCommand().Flags().Lookup("format") == nil {
+			t.Error("env list should have --format flag")
+		}
+	})
+}
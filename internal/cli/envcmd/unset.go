@@ -0,0 +1,72 @@
+package envcmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+func UnsetCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "unset [<handle>] KEY...",
+		Short: "Remove workspace environment variables",
+		Long: `Remove workspace environment variables.
+
+Examples:
+  workshed env unset DEBUG
+  workshed env unset my-workspace DEBUG API_URL
+  workshed env unset DEBUG --dry-run`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			ctx := context.Background()
+
+			// The first argument is a handle only when it names an existing workspace.
+			var providedHandle string
+			keys := args
+			if len(args) > 1 {
+				if _, err := r.GetStore().Get(ctx, args[0]); err == nil {
+					providedHandle = args[0]
+					keys = args[1:]
+				}
+			}
+
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if dryRun {
+				vars, err := r.GetStore().WorkspaceEnv(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to read workspace env: %w", err)
+				}
+				steps := make([]cli.PlanStep, len(keys))
+				for i, key := range keys {
+					if _, ok := vars[key]; !ok {
+						return fmt.Errorf("failed to unset workspace env: variable not set: %s", key)
+					}
+					steps[i] = cli.PlanStep{Action: "unset variable", Target: key}
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
+			if err := r.GetStore().UnsetWorkspaceEnv(ctx, handle, keys); err != nil {
+				return fmt.Errorf("failed to unset workspace env: %w", err)
+			}
+
+			r.GetLogger().Success("environment updated", "handle", handle, "unset", len(keys))
+			return nil
+		},
+	}
+
+	cli.AddDryRunFlag(cmd, &dryRun)
+
+	return cmd
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/frodi/workshed/internal/git"
+	"github.com/spf13/cobra"
+)
+
+// Error codes reported in JSON error mode.
+const (
+	ErrorCodeNotFound = "not_found"
+	ErrorCodeGit      = "git"
+	ErrorCodeExit     = "exit_code"
+	ErrorCodeGeneric  = "error"
+)
+
+// ErrorInfo is the body of a JSON error: a stable code for scripts to branch
+// on, the human message, and any fields the error type carries.
+type ErrorInfo struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// DescribeError maps err onto an ErrorInfo using the typed errors in its chain.
+func DescribeError(err error) ErrorInfo {
+	info := ErrorInfo{Code: ErrorCodeGeneric, Message: err.Error()}
+
+	var notFound *WorkspaceNotFoundError
+	var gitErr *git.GitError
+	var exitErr *ExitCodeError
+	switch {
+	case errors.As(err, &notFound):
+		info.Code = ErrorCodeNotFound
+		if notFound.Handle != "" {
+			info.Details = map[string]string{"handle": notFound.Handle}
+		}
+	case errors.As(err, &gitErr):
+		info.Code = ErrorCodeGit
+		info.Details = map[string]string{"operation": gitErr.Operation}
+		if gitErr.Hint != "" {
+			info.Details["hint"] = gitErr.Hint
+		}
+		if gitErr.Suggestion != "" {
+			info.Details["suggestion"] = gitErr.Suggestion
+		}
+	case errors.Is(err, git.ErrGitNotFound):
+		info.Code = ErrorCodeGit
+	case errors.As(err, &exitErr):
+		info.Code = ErrorCodeExit
+		info.Details = map[string]string{"exit_code": strconv.Itoa(exitErr.Code)}
+	}
+	return info
+}
+
+// WriteJSONError writes err to w as {"error": {...}} on a single line.
+func WriteJSONError(w io.Writer, err error) {
+	data, marshalErr := json.Marshal(map[string]ErrorInfo{"error": DescribeError(err)})
+	if marshalErr != nil {
+		_, _ = fmt.Fprintf(w, "Error: %v\n", err)
+		return
+	}
+	_, _ = fmt.Fprintln(w, string(data))
+}
+
+// JSONErrorsRequested reports whether args ask for JSON errors, through
+// --json-errors or --format json. Arguments after "--" belong to the command
+// being run and are ignored.
+func JSONErrorsRequested(args []string) bool {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return false
+		case arg == "--json-errors" || arg == "--json-errors=true":
+			return true
+		case arg == "--format=json":
+			return true
+		case arg == "--format" && i+1 < len(args) && args[i+1] == "json":
+			return true
+		}
+	}
+	return false
+}
+
+// Execute runs root with args, reports a failure on root's stderr and returns
+// the process exit code. In JSON error mode the failure is a JSON object and
+// no usage text is printed, so stderr stays machine-readable.
+func Execute(root *cobra.Command, args []string) int {
+	jsonErrors := JSONErrorsRequested(args)
+	root.SetArgs(args)
+	root.SilenceErrors = true
+	if jsonErrors {
+		root.SilenceUsage = true
+	}
+
+	cmd, err := root.ExecuteC()
+	if err == nil {
+		return 0
+	}
+
+	switch {
+	case jsonErrors:
+		WriteJSONError(root.ErrOrStderr(), err)
+	case cmd == nil || !cmd.SilenceErrors:
+		_, _ = fmt.Fprintf(root.ErrOrStderr(), "%s %v\n", root.ErrPrefix(), err)
+	}
+
+	var exitErr *ExitCodeError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/frodi/workshed/internal/workspace"
+)
+
+const EventsJSONL = "jsonl"
+
+const EventSummary = "summary"
+
+// Event is a single line of the --events jsonl stream.
+type Event struct {
+	Type       string `json:"type"`
+	Repo       string `json:"repo,omitempty"`
+	Exit       *int   `json:"exit,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Handle     string `json:"handle,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Repos      int    `json:"repos,omitempty"`
+	Stage      string `json:"stage,omitempty"`
+	Percent    *int   `json:"percent,omitempty"`
+}
+
+// EventWriter emits newline-delimited JSON events as work happens.
+type EventWriter struct {
+	enc *json.Encoder
+}
+
+// NewEventWriter returns a writer for the given --events mode, or nil when events are disabled.
+func NewEventWriter(mode string, w io.Writer) (*EventWriter, error) {
+	switch mode {
+	case "":
+		return nil, nil
+	case EventsJSONL:
+		return &EventWriter{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown events format: %s (expected jsonl)", mode)
+	}
+}
+
+func (e *EventWriter) Emit(event Event) {
+	_ = e.enc.Encode(event)
+}
+
+// Progress converts a store progress event into a stream event.
+func (e *EventWriter) Progress(p workspace.ProgressEvent) {
+	event := Event{
+		Type:       p.Type,
+		Repo:       p.Repository,
+		DurationMs: p.Duration.Milliseconds(),
+	}
+	if p.Type == workspace.EventRepoResult {
+		exit := p.ExitCode
+		event.Exit = &exit
+	}
+	if p.Type == workspace.EventCloneProgress {
+		percent := p.Percent
+		event.Stage = p.Stage
+		event.Percent = &percent
+	}
+	if p.Err != nil {
+		event.Error = p.Err.Error()
+	}
+	e.Emit(event)
+}
@@ -4,26 +4,72 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	osexec "os/exec"
+	"strings"
 	"time"
 
 	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/tui"
 	"github.com/frodi/workshed/internal/workspace"
 	"github.com/oklog/ulid/v2"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 type ExecResultOutput struct {
 	Repository string `json:"repository"`
 	ExitCode   int    `json:"exit_code"`
+	Signal     string `json:"signal,omitempty"`
 	Output     string `json:"output"`
 	DurationMs int64  `json:"duration_ms"`
+	// Attempts is how many times the command ran, set when --retries is used.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// ExecSummaryOutput totals a run. TotalMs is wall-clock time, which is less
+// than the sum of per-repository durations when repositories run in parallel.
+type ExecSummaryOutput struct {
+	Repos   int   `json:"repos"`
+	Passed  int   `json:"passed"`
+	Failed  int   `json:"failed"`
+	TotalMs int64 `json:"total_ms"`
+}
+
+// ExecOutput is the --format json document. Success and MaxExitCode mirror
+// the MCP exec tool so scripts can check the outcome without walking Results.
+type ExecOutput struct {
+	Success     bool               `json:"success"`
+	MaxExitCode int                `json:"max_exit_code"`
+	Results     []ExecResultOutput `json:"results"`
+	Summary     ExecSummaryOutput  `json:"summary"`
+}
+
+// PlanOutput is one entry of the --dry-run --format json document.
+type PlanOutput struct {
+	Repository string   `json:"repository"`
+	Dir        string   `json:"dir"`
+	Command    []string `json:"command"`
 }
 
 func Command() *cobra.Command {
 	var repo string
 	var all bool
 	var noRecord bool
+	var noHeaders bool
+	var eventsMode string
+	var envVars []string
+	var expand bool
+	var nice int
+	var interactive bool
+	var retries int
+	var retryDelay time.Duration
+	var continueOnError bool
+	var requireAll bool
+	var requireAny bool
+	var dryRun bool
+	var parallel bool
 
 	cmd := &cobra.Command{
 		Use:   "exec [<handle>] <command> [args...]",
@@ -33,7 +79,48 @@ func Command() *cobra.Command {
 Examples:
   workshed exec make test
   workshed exec -a go test ./...
-  workshed exec my-workspace make build`,
+  workshed exec -a --parallel -- make build
+  workshed exec my-workspace make build
+  workshed exec --env API_URL=http://localhost:8080 -- make test
+  workshed exec --expand -- sh -c 'echo building {{repo}} at {{path}}'
+  workshed exec --nice 10 -a make build
+  workshed exec --interactive -- make lint
+  workshed exec --retries 2 --retry-delay 5s -a -- go test ./...
+  workshed exec --continue-on-error -a -- make test
+  workshed exec --require-any -a -- make build
+  workshed exec --repo 'svc-*' --dry-run -- git push --force
+  workshed exec -a --format json -- go test ./... | jq '.results[] | select(.exit_code != 0)'
+
+--format json prints one document with each repository's exit_code,
+duration_ms and output, plus an overall success flag, max_exit_code and a
+summary with pass/fail counts and the wall-clock total_ms.
+
+Environment precedence: process env < workspace env file (workshed env) < --env flags.
+
+With --expand, {{handle}}, {{repo}}, {{path}} and {{ref}} in the command are
+replaced per repository before it runs.
+
+--nice runs the command at a lower CPU priority (1-19, like nice -n) so long
+builds don't starve the machine. It applies on Linux and is ignored on other
+platforms.
+
+--retries re-runs the command in a repository that exits non-zero, up to N
+more times, before reporting it as failed. Repositories that pass are not
+re-run.
+
+Repositories run one at a time, in order, and exec stops at the first one
+that fails. With --continue-on-error it runs in every repository first, then
+succeeds only if all of them passed (--require-all, the default).
+--require-any succeeds when at least one repository passed, and implies
+--continue-on-error.
+
+--parallel runs the repositories concurrently, up to one per CPU. Every
+repository runs even if another fails, and the results are still printed in
+repository order.
+
+--repo takes a repository name, a glob such as 'svc-*', or a comma-separated
+list of both. --dry-run prints each directory the command would run in and
+the command itself, then exits without running anything.`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r := cli.NewRunner("")
@@ -74,15 +161,49 @@ Examples:
 				}
 			}
 
+			for _, kv := range envVars {
+				if key, _, ok := strings.Cut(kv, "="); !ok || key == "" {
+					return fmt.Errorf("invalid --env value %q: expected KEY=VALUE", kv)
+				}
+			}
+
+			if retries < 0 {
+				return fmt.Errorf("invalid --retries %d: must not be negative", retries)
+			}
+			if retryDelay < 0 {
+				return fmt.Errorf("invalid --retry-delay %s: must not be negative", retryDelay)
+			}
+
+			if requireAll && requireAny {
+				return fmt.Errorf("--require-all cannot be combined with --require-any")
+			}
+			if requireAny {
+				continueOnError = true
+			}
+
 			format := cmd.Flags().Lookup("format").Value.String()
 
-			explicitAll := all
-			if repo != "" {
-				explicitAll = true
+			events, err := cli.NewEventWriter(eventsMode, cmd.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			if events != nil {
+				// Keep stdout parseable as JSONL when the command fails.
+				cmd.SilenceUsage = true
+			}
+
+			if interactive {
+				if repo != "" || all {
+					return fmt.Errorf("--interactive cannot be combined with --repo or --all")
+				}
+				if !term.IsTerminal(int(os.Stdin.Fd())) || !tui.IsHumanMode() {
+					return fmt.Errorf("--interactive requires a terminal; use --repo or --all")
+				}
 			}
 
 			ctx := context.Background()
 
+			r.DryRun = dryRun
 			providedHandle, _ := cli.ExtractHandleFromArgs(flagArgs)
 			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
 			if err != nil {
@@ -92,55 +213,115 @@ Examples:
 				return fmt.Errorf("failed to resolve workspace: %w", err)
 			}
 
+			var targets []string
+			if interactive {
+				ws, err := r.GetStore().Get(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to get workspace: %w", err)
+				}
+				names := make([]string, 0, len(ws.Repositories))
+				for _, repo := range ws.Repositories {
+					names = append(names, repo.Name)
+				}
+				targets, err = tui.RunSelector("Run "+strings.Join(command, " ")+" in:", names)
+				if err != nil {
+					return err
+				}
+			}
+
 			opts := workspace.ExecOptions{
-				Target:   repo,
-				Command:  command,
-				Parallel: explicitAll,
+				Target:          repo,
+				Targets:         targets,
+				Command:         command,
+				Parallel:        parallel,
+				Env:             envVars,
+				Expand:          expand,
+				Nice:            nice,
+				Retries:         retries,
+				RetryDelay:      retryDelay,
+				ContinueOnError: continueOnError,
+			}
+
+			if dryRun {
+				targets, err := r.GetStore().PlanExec(ctx, handle, opts)
+				if err != nil {
+					return fmt.Errorf("exec failed: %w", err)
+				}
+				return writePlan(cmd.OutOrStdout(), targets, format)
+			}
+
+			if events != nil {
+				opts.OnProgress = events.Progress
 			}
 
 			startedAt := time.Now()
 			results, err := r.GetStore().Exec(ctx, handle, opts)
-			if err != nil {
+			elapsed := time.Since(startedAt)
+			if events != nil {
+				summary := cli.Event{
+					Type:       cli.EventSummary,
+					Handle:     handle,
+					Repos:      len(results),
+					DurationMs: elapsed.Milliseconds(),
+				}
+				exit := maxExitCode(results)
+				summary.Exit = &exit
+				if err != nil {
+					summary.Error = err.Error()
+				}
+				events.Emit(summary)
+			}
+			// A failing command still returns the results gathered so far;
+			// show them and the summary before reporting the failure.
+			if err != nil && len(results) == 0 {
 				return fmt.Errorf("exec failed: %w", err)
 			}
 
-			switch format {
-			case "json":
-				var outputResults []ExecResultOutput
+			switch {
+			case events != nil:
+				// Results were already streamed as events.
+			case format == "json":
+				outputResults := make([]ExecResultOutput, 0, len(results))
 				for _, result := range results {
-					outputResults = append(outputResults, ExecResultOutput{
-						Repository: result.Repository,
-						ExitCode:   result.ExitCode,
-						Output:     string(result.Output),
-						DurationMs: result.Duration.Milliseconds(),
-					})
+					outputResults = append(outputResults, resultOutput(result))
 				}
-				data, _ := json.MarshalIndent(outputResults, "", "  ")
+				exit := maxExitCode(results)
+				data, _ := json.MarshalIndent(ExecOutput{
+					Success:     exit == 0 && err == nil,
+					MaxExitCode: exit,
+					Results:     outputResults,
+					Summary:     summarize(results, elapsed),
+				}, "", "  ")
 				_, _ = fmt.Fprintln(cmd.OutOrStdout(), string(data))
-			case "raw":
+			case format == "raw":
 				var outputResults []ExecResultOutput
 				for _, result := range results {
-					outputResults = append(outputResults, ExecResultOutput{
-						Repository: result.Repository,
-						ExitCode:   result.ExitCode,
-						Output:     string(result.Output),
-						DurationMs: result.Duration.Milliseconds(),
-					})
+					outputResults = append(outputResults, resultOutput(result))
 				}
 				data, _ := json.Marshal(outputResults)
 				_, _ = fmt.Fprintln(cmd.OutOrStdout(), string(data))
 			default:
+				out := cmd.OutOrStdout()
 				for _, result := range results {
-					if result.Repository != "root" {
-						fmt.Printf("=== %s ===\n", result.Repository)
+					if result.Repository != "root" && !noHeaders {
+						writeResultHeader(out, result, command)
 					}
-					if _, err := cmd.OutOrStdout().Write(result.Output); err != nil {
+					if _, err := out.Write(result.Output); err != nil {
 						r.GetLogger().Error("failed to write output", "error", err)
 					}
 					if len(results) > 1 {
-						fmt.Println()
+						_, _ = fmt.Fprintln(out)
 					}
 				}
+				if len(results) > 1 && !noHeaders {
+					writeSummary(out, summarize(results, elapsed))
+				}
+			}
+
+			if err != nil {
+				// The results are already on stdout; keep usage out of them.
+				cmd.SilenceUsage = true
+				return fmt.Errorf("exec failed: %w", err)
 			}
 
 			if !noRecord {
@@ -150,18 +331,30 @@ Examples:
 					if result.ExitCode > maxExitCode {
 						maxExitCode = result.ExitCode
 					}
-					repoResults = append(repoResults, workspace.ExecutionRepoResult{
+					repoResult := workspace.ExecutionRepoResult{
 						Repository: result.Repository,
 						ExitCode:   result.ExitCode,
+						Signal:     result.Signal,
 						Duration:   result.Duration.Milliseconds(),
-					})
+					}
+					for _, attempt := range result.Attempts {
+						repoResult.Attempts = append(repoResult.Attempts, workspace.ExecutionAttempt{
+							ExitCode: attempt.ExitCode,
+							Duration: attempt.Duration.Milliseconds(),
+						})
+					}
+					repoResults = append(repoResults, repoResult)
 				}
 
+				target := repo
+				if len(targets) > 0 {
+					target = strings.Join(targets, ",")
+				}
 				record := workspace.ExecutionRecord{
 					ID:          ulid.Make().String(),
 					Timestamp:   startedAt,
 					Handle:      handle,
-					Target:      repo,
+					Target:      target,
 					Command:     command,
 					ExitCode:    maxExitCode,
 					StartedAt:   startedAt,
@@ -175,14 +368,144 @@ Examples:
 				}
 			}
 
+			if err := gate(results, requireAny); err != nil {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("exec failed: %w", err)
+			}
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVar(&repo, "repo", "", "Repository name to exec in")
 	cmd.Flags().BoolVarP(&all, "all", "a", false, "Exec in all repositories")
+	cmd.Flags().BoolVar(&parallel, "parallel", false, "Run in the repositories concurrently instead of one at a time; failures don't stop the others")
+	cmd.Flags().BoolVar(&interactive, "interactive", false, "Pick the repositories to exec in from a checklist (requires a terminal)")
 	cmd.Flags().BoolVar(&noRecord, "no-record", false, "Don't record command execution")
+	cmd.Flags().BoolVar(&noHeaders, "no-headers", false, "Don't print per-repository headers in stream output")
+	cmd.Flags().StringArrayVar(&envVars, "env", nil, "Set an environment variable for the command (KEY=VALUE, repeatable)")
+	cmd.Flags().BoolVar(&expand, "expand", false, "Expand {{handle}}, {{repo}}, {{path}} and {{ref}} in the command per repository")
+	cmd.Flags().IntVar(&nice, "nice", 0, "Run the command at this niceness, 1-19 (Linux only; ignored elsewhere)")
+	cmd.Flags().IntVar(&retries, "retries", 0, "Re-run the command up to this many more times in a repository where it fails")
+	cmd.Flags().DurationVar(&retryDelay, "retry-delay", 0, "Wait this long between retries (e.g. 5s)")
+	cmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "Keep running in the remaining repositories after one fails")
+	cmd.Flags().BoolVar(&requireAll, "require-all", false, "Succeed only if the command passed in every repository (default)")
+	cmd.Flags().BoolVar(&requireAny, "require-any", false, "Succeed if the command passed in at least one repository; implies --continue-on-error")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().StringVar(&eventsMode, "events", "", "Stream progress events to stdout (jsonl)")
 	cmd.Flags().String("format", "stream", "Output format (stream|json|raw)")
 
 	return cmd
 }
+
+func resultOutput(result workspace.ExecResult) ExecResultOutput {
+	return ExecResultOutput{
+		Repository: result.Repository,
+		ExitCode:   result.ExitCode,
+		Signal:     result.Signal,
+		Output:     string(result.Output),
+		DurationMs: result.Duration.Milliseconds(),
+		Attempts:   len(result.Attempts),
+	}
+}
+
+// gate derives the overall outcome from the per-repository results: every
+// repository must have passed, or with requireAny at least one.
+func gate(results []workspace.ExecResult, requireAny bool) error {
+	var failed []string
+	for _, result := range results {
+		if result.ExitCode != 0 {
+			failed = append(failed, result.Repository)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	if requireAny {
+		if len(failed) < len(results) {
+			return nil
+		}
+		return fmt.Errorf("command failed in every repository: %s", strings.Join(failed, ", "))
+	}
+	return fmt.Errorf("command failed in %d of %d repositories: %s", len(failed), len(results), strings.Join(failed, ", "))
+}
+
+func writeResultHeader(w io.Writer, result workspace.ExecResult, command []string) {
+	status := fmt.Sprintf("exit %d", result.ExitCode)
+	if result.Signal != "" {
+		status += ", killed by " + result.Signal
+	}
+	if len(result.Attempts) > 1 {
+		_, _ = fmt.Fprintf(w, "=== %s (%s, %.1fs, %d attempts) ===\n", result.Repository, status, result.Duration.Seconds(), len(result.Attempts))
+	} else {
+		_, _ = fmt.Fprintf(w, "=== %s (%s, %.1fs) ===\n", result.Repository, status, result.Duration.Seconds())
+	}
+	_, _ = fmt.Fprintf(w, "$ %s\n", strings.Join(command, " "))
+	_, _ = fmt.Fprintf(w, "dir: %s\n", result.Dir)
+}
+
+// writePlan prints the targets of a --dry-run. The stream format mirrors the
+// per-repository headers of a real run.
+func writePlan(w io.Writer, targets []workspace.ExecTarget, format string) error {
+	if format == "json" || format == "raw" {
+		plan := make([]PlanOutput, len(targets))
+		for i, target := range targets {
+			plan[i] = PlanOutput{Repository: target.Repository, Dir: target.Dir, Command: target.Command}
+		}
+		var data []byte
+		if format == "json" {
+			data, _ = json.MarshalIndent(plan, "", "  ")
+		} else {
+			data, _ = json.Marshal(plan)
+		}
+		_, _ = fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(w, "Dry run - would run in %d location(s)\n", len(targets))
+	for _, target := range targets {
+		_, _ = fmt.Fprintf(w, "=== %s ===\n", target.Repository)
+		_, _ = fmt.Fprintf(w, "$ %s\n", quoteArgs(target.Command))
+		_, _ = fmt.Fprintf(w, "dir: %s\n", target.Dir)
+	}
+	return nil
+}
+
+// quoteArgs joins args for display, quoting any that would not survive a
+// round trip through the shell as written.
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~") {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		} else {
+			quoted[i] = arg
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+func summarize(results []workspace.ExecResult, elapsed time.Duration) ExecSummaryOutput {
+	summary := ExecSummaryOutput{Repos: len(results), TotalMs: elapsed.Milliseconds()}
+	for _, result := range results {
+		if result.ExitCode == 0 {
+			summary.Passed++
+		} else {
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// maxExitCode returns the highest exit code among results, zero when every
+// repository passed.
+func maxExitCode(results []workspace.ExecResult) int {
+	exit := 0
+	for _, result := range results {
+		exit = max(exit, result.ExitCode)
+	}
+	return exit
+}
+
+func writeSummary(w io.Writer, summary ExecSummaryOutput) {
+	_, _ = fmt.Fprintf(w, "=== %d repos: %d passed, %d failed (%.1fs) ===\n", summary.Repos, summary.Passed, summary.Failed, float64(summary.TotalMs)/1000)
+}
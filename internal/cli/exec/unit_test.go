@@ -3,6 +3,7 @@ package exec
 import (
 	"testing"
 
+	"github.com/frodi/workshed/internal/workspace"
 	"github.com/spf13/cobra"
 )
 
@@ -42,6 +43,41 @@ func TestExecCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("has --retries and --retry-delay flags", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "retries") || !flagExists(cmd, "retry-delay") {
+			t.Error("exec should have --retries and --retry-delay flags")
+		}
+	})
+
+	t.Run("has --no-headers flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "no-headers") {
+			t.Error("exec should have --no-headers flag")
+		}
+	})
+
+	t.Run("has --env flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "env") {
+			t.Error("exec should have --env flag")
+		}
+	})
+
+	t.Run("has --interactive flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "interactive") {
+			t.Error("exec should have --interactive flag")
+		}
+	})
+
+	t.Run("has --events flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "events") {
+			t.Error("exec should have --events flag")
+		}
+	})
+
 	t.Run("all defaults to false", func(t *testing.T) {
 		cmd := Command()
 		flag := cmd.Flags().Lookup("all")
@@ -50,6 +86,16 @@ func TestExecCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("parallel defaults to false", func(t *testing.T) {
+		cmd := Command()
+		flag := cmd.Flags().Lookup("parallel")
+		if flag == nil {
+			t.Error("exec should have --parallel flag")
+		} else if flag.DefValue != "false" {
+			t.Errorf("parallel default should be false, got: %s", flag.DefValue)
+		}
+	})
+
 	t.Run("format defaults to stream", func(t *testing.T) {
 		cmd := Command()
 		flag := cmd.Flags().Lookup("format")
@@ -59,4 +105,90 @@ func TestExecCommand(t *testing.T) {
 			t.Errorf("format default should be 'stream', got: %s", flag.DefValue)
 		}
 	})
+
+	t.Run("has --expand flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "expand") {
+			t.Error("exec should have --expand flag")
+		}
+	})
+
+	t.Run("has --nice flag", func(t *testing.T) {
+		if !flagExists(Command(), "nice") {
+			t.Error("exec should have --nice flag")
+		}
+	})
+
+	t.Run("has --dry-run flag", func(t *testing.T) {
+		if !flagExists(Command(), "dry-run") {
+			t.Error("exec should have --dry-run flag")
+		}
+	})
+
+	t.Run("has success gating flags", func(t *testing.T) {
+		cmd := Command()
+		for _, name := range []string{"continue-on-error", "require-all", "require-any"} {
+			if !flagExists(cmd, name) {
+				t.Errorf("exec should have --%s flag", name)
+			}
+		}
+	})
+}
+
+func TestGate(t *testing.T) {
+	mixed := []workspace.ExecResult{
+		{Repository: "api", ExitCode: 0},
+		{Repository: "web", ExitCode: 2},
+		{Repository: "docs", ExitCode: 1},
+	}
+	failing := []workspace.ExecResult{
+		{Repository: "api", ExitCode: 1},
+		{Repository: "web", ExitCode: 1},
+	}
+
+	t.Run("require any passes on a mixed set", func(t *testing.T) {
+		if err := gate(mixed, true); err != nil {
+			t.Errorf("Expected success, got %v", err)
+		}
+	})
+
+	t.Run("require all fails on a mixed set", func(t *testing.T) {
+		err := gate(mixed, false)
+		if err == nil {
+			t.Fatal("Expected failure")
+		}
+		if err.Error() != "command failed in 2 of 3 repositories: web, docs" {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("require any fails when nothing passed", func(t *testing.T) {
+		if err := gate(failing, true); err == nil {
+			t.Error("Expected failure")
+		}
+	})
+
+	t.Run("all passing succeeds", func(t *testing.T) {
+		if err := gate(mixed[:1], false); err != nil {
+			t.Errorf("Expected success, got %v", err)
+		}
+	})
+}
+
+func TestQuoteArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"go", "test", "./..."}, "go test ./..."},
+		{[]string{"touch", "two words"}, "touch 'two words'"},
+		{[]string{"echo", "it's"}, `echo 'it'\''s'`},
+		{[]string{"echo", ""}, "echo ''"},
+		{[]string{"ls", "*.go"}, "ls '*.go'"},
+	}
+	for _, tc := range tests {
+		if got := quoteArgs(tc.args); got != tc.want {
+			t.Errorf("quoteArgs(%q) = %s, want %s", tc.args, got, tc.want)
+		}
+	}
 }
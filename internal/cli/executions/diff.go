@@ -0,0 +1,88 @@
+package executions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func DiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff [<handle>] <exec-id-a> <exec-id-b>",
+		Short: "Diff the output of two executions",
+		Long: `Show a unified diff of the stored output of two executions, per repository.
+
+A repository present in only one execution is diffed against empty output.
+
+Examples:
+  workshed executions diff 01HVAAAAAAAA 01HVBBBBBBBB
+  workshed executions diff my-workspace 01HVAAAAAAAA 01HVBBBBBBBB
+  workshed executions diff 01HVAAAAAAAA 01HVBBBBBBBB --format json | jq '.[].hunks'`,
+		Args: cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+
+			ctx := context.Background()
+			providedHandle := ""
+			if len(args) == 3 {
+				providedHandle, args = args[0], args[1:]
+			}
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			diffs, err := r.GetStore().DiffExecutions(ctx, handle, args[0], args[1])
+			if err != nil {
+				return fmt.Errorf("diff failed: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			switch format {
+			case "json":
+				data, err := json.MarshalIndent(diffs, "", "  ")
+				if err != nil {
+					return fmt.Errorf("marshaling diff: %w", err)
+				}
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			case "text":
+				writeUnified(cmd.OutOrStdout(), args[0], args[1], diffs)
+				return nil
+			default:
+				return fmt.Errorf("unknown format: %s", format)
+			}
+		},
+	}
+
+	cmd.Flags().String("format", "text", "Output format (text|json)")
+
+	return cmd
+}
+
+// writeUnified prints diffs in unified diff form, one file section per
+// repository whose output changed.
+func writeUnified(w io.Writer, fromID, toID string, diffs []workspace.OutputDiff) {
+	changed := 0
+	for _, diff := range diffs {
+		if len(diff.Hunks) == 0 {
+			continue
+		}
+		changed++
+		_, _ = fmt.Fprintf(w, "--- %s/%s\n+++ %s/%s\n", fromID, diff.Repository, toID, diff.Repository)
+		for _, hunk := range diff.Hunks {
+			_, _ = fmt.Fprintln(w, hunk.Header())
+			for _, line := range hunk.Lines {
+				_, _ = fmt.Fprintln(w, line)
+			}
+		}
+	}
+	if changed == 0 {
+		_, _ = fmt.Fprintln(w, "no differences in output")
+	}
+}
@@ -0,0 +1,25 @@
+package executions
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "executions",
+		Short: "Manage recorded executions in a workspace",
+		Long: `Manage recorded executions in a workspace.
+
+Examples:
+  workshed executions prune
+  workshed executions prune --keep 20 --max-age 168h
+  workshed executions retention --keep 50
+  workshed executions diff 01HVAAAAAAAA 01HVBBBBBBBB`,
+	}
+
+	cmd.AddCommand(PruneCommand())
+	cmd.AddCommand(RetentionCommand())
+	cmd.AddCommand(DiffCommand())
+
+	return cmd
+}
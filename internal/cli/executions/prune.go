@@ -0,0 +1,97 @@
+package executions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func PruneCommand() *cobra.Command {
+	var keep int
+	var maxAge time.Duration
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "prune [<handle>]",
+		Short: "Delete old execution records",
+		Long: `Delete execution records (and their stored output) outside the retention policy.
+
+Without flags the workspace's policy is used (see 'workshed executions
+retention'), else the one from WORKSHED_MAX_EXECUTIONS and
+WORKSHED_EXECUTION_MAX_AGE. Without any policy nothing is pruned. The same
+policy is applied automatically after every recorded exec.
+
+Examples:
+  workshed executions prune
+  workshed executions prune --keep 10
+  workshed executions prune my-workspace --max-age 168h
+  workshed executions prune --keep 10 --dry-run`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			var policy *workspace.RetentionPolicy
+			if cmd.Flags().Changed("keep") || cmd.Flags().Changed("max-age") {
+				policy = &workspace.RetentionPolicy{MaxExecutions: keep, MaxAge: maxAge}
+			}
+
+			if dryRun {
+				ids, err := r.GetStore().PrunableExecutions(ctx, handle, policy)
+				if err != nil {
+					return fmt.Errorf("prune failed: %w", err)
+				}
+				steps := make([]cli.PlanStep, len(ids))
+				for i, id := range ids {
+					steps[i] = cli.PlanStep{Action: "delete execution", Target: id}
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
+			removed, err := r.GetStore().PruneExecutions(ctx, handle, policy)
+			if err != nil {
+				return fmt.Errorf("prune failed: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if len(removed) == 0 {
+				return cli.RenderEmptyList(format, "no executions to prune", cmd.OutOrStdout(), r.GetLogger())
+			}
+
+			var rows [][]string
+			for _, id := range removed {
+				rows = append(rows, []string{id})
+			}
+
+			output := cli.Output{
+				Columns: []cli.ColumnConfig{
+					{Type: cli.Rigid, Name: "REMOVED", Min: 26, Max: 0},
+				},
+				Rows: rows,
+			}
+
+			if err := cli.Render(output, format, cmd.OutOrStdout()); err != nil {
+				return fmt.Errorf("failed to render output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&keep, "keep", 0, "Keep only the newest N executions (0 = no limit)")
+	cmd.Flags().DurationVar(&maxAge, "max-age", 0, "Delete executions older than this duration (0 = no limit)")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
@@ -0,0 +1,132 @@
+package executions
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func RetentionCommand() *cobra.Command {
+	var keep int
+	var maxAge time.Duration
+	var clear bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "retention [<handle>]",
+		Short: "Show or set a workspace's execution retention",
+		Long: `Show or set how many execution records a workspace keeps.
+
+By default every execution is kept. WORKSHED_MAX_EXECUTIONS and
+WORKSHED_EXECUTION_MAX_AGE set a policy for all workspaces; --keep and
+--max-age set one for this workspace only, which takes precedence. The policy
+is applied after every recorded exec and by 'workshed executions prune'.
+--clear reverts the workspace to the policy for all workspaces.
+
+Examples:
+  workshed executions retention
+  workshed executions retention --keep 50
+  workshed executions retention my-workspace --keep 20 --max-age 168h
+  workshed executions retention --clear`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			setting := cmd.Flags().Changed("keep") || cmd.Flags().Changed("max-age")
+			if clear && setting {
+				return fmt.Errorf("--clear cannot be combined with --keep or --max-age")
+			}
+			if keep < 0 || maxAge < 0 {
+				return fmt.Errorf("--keep and --max-age cannot be negative")
+			}
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			ws, err := r.GetStore().Get(ctx, handle)
+			if err != nil {
+				return fmt.Errorf("failed to read workspace: %w", err)
+			}
+
+			var policy *workspace.RetentionPolicy
+			switch {
+			case setting:
+				policy = &workspace.RetentionPolicy{MaxExecutions: keep, MaxAge: maxAge}
+			case clear:
+			default:
+				return renderRetention(cmd, r, ws.Handle, ws.Retention)
+			}
+
+			if dryRun {
+				return cli.RenderPlan(cmd, []cli.PlanStep{
+					{Action: "set retention", Target: handle, Detail: describeRetention(r, policy)},
+				})
+			}
+
+			if err := r.GetStore().SetWorkspaceRetention(ctx, handle, policy); err != nil {
+				return fmt.Errorf("failed to set retention: %w", err)
+			}
+			return renderRetention(cmd, r, handle, policy)
+		},
+	}
+
+	cmd.Flags().IntVar(&keep, "keep", 0, "Keep only the newest N executions (0 = no limit)")
+	cmd.Flags().DurationVar(&maxAge, "max-age", 0, "Delete executions older than this duration (0 = no limit)")
+	cmd.Flags().BoolVar(&clear, "clear", false, "Use the policy for all workspaces again")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
+
+func renderRetention(cmd *cobra.Command, r *cli.Runner, handle string, policy *workspace.RetentionPolicy) error {
+	source := "workspace"
+	effective := policy
+	if effective == nil {
+		source = "all workspaces"
+		p := r.RetentionPolicy()
+		effective = &p
+	}
+	format := cmd.Flags().Lookup("format").Value.String()
+	return cli.RenderKeyValue(map[string]string{
+		"handle":  handle,
+		"source":  source,
+		"keep":    strconv.Itoa(effective.MaxExecutions),
+		"max_age": effective.MaxAge.String(),
+	}, format, cmd.OutOrStdout())
+}
+
+func describeRetention(r *cli.Runner, policy *workspace.RetentionPolicy) string {
+	if policy == nil {
+		p := r.RetentionPolicy()
+		return "use the policy for all workspaces (" + describeLimits(p) + ")"
+	}
+	return describeLimits(*policy)
+}
+
+func describeLimits(p workspace.RetentionPolicy) string {
+	if p.MaxExecutions <= 0 && p.MaxAge <= 0 {
+		return "keep everything"
+	}
+	detail := ""
+	if p.MaxExecutions > 0 {
+		detail = fmt.Sprintf("keep %d", p.MaxExecutions)
+	}
+	if p.MaxAge > 0 {
+		if detail != "" {
+			detail += ", "
+		}
+		detail += "max age " + p.MaxAge.String()
+	}
+	return detail
+}
@@ -0,0 +1,58 @@
+package executions
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func flagExists(cmd *cobra.Command, name string) bool {
+	return cmd.Flags().Lookup(name) != nil
+}
+
+func TestExecutionsCommand(t *testing.T) {
+	t.Run("has prune subcommand", func(t *testing.T) {
+		cmd := Command()
+		for _, c := range cmd.Commands() {
+			if c.Name() == "prune" {
+				return
+			}
+		}
+		t.Error("executions should have prune subcommand")
+	})
+
+	t.Run("has diff subcommand", func(t *testing.T) {
+		cmd := Command()
+		for _, c := range cmd.Commands() {
+			if c.Name() == "diff" {
+				return
+			}
+		}
+		t.Error("executions should have diff subcommand")
+	})
+
+	t.Run("prune has --keep and --max-age flags", func(t *testing.T) {
+		cmd := PruneCommand()
+		if !flagExists(cmd, "keep") {
+			t.Error("executions prune should have --keep flag")
+		}
+		if !flagExists(cmd, "max-age") {
+			t.Error("executions prune should have --max-age flag")
+		}
+	})
+}
+
+func TestRetentionCommand(t *testing.T) {
+	t.Run("is an executions subcommand", func(t *testing.T) {
+		cmd, _, err := Command().Find([]string{"retention"})
+		if err != nil || cmd.Name() != "retention" {
+			t.Errorf("executions should have a retention subcommand, got %v (%v)", cmd, err)
+		}
+	})
+
+	for _, flag := range []string{"keep", "max-age", "clear", "dry-run", "format"} {
+		if !flagExists(RetentionCommand(), flag) {
+			t.Errorf("executions retention should have --%s flag", flag)
+		}
+	}
+}
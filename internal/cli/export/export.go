@@ -6,15 +6,19 @@ import (
 	"fmt"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/frodi/workshed/internal/cli"
 	fsutil "github.com/frodi/workshed/internal/fs"
+	"github.com/frodi/workshed/internal/logger"
+	"github.com/frodi/workshed/internal/workspace"
 	"github.com/spf13/cobra"
 )
 
 func Command() *cobra.Command {
 	var output string
 	var compact bool
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "export [<handle>]",
@@ -25,10 +29,12 @@ Examples:
   workshed export
   workshed export --format json | jq '.captures'
   workshed export --output /tmp/context.json
-  workshed export --compact --format json | jq '{purpose, repositories}'`,
+  workshed export --compact --format json | jq '{purpose, repositories}'
+  workshed export --output /tmp/context.json --dry-run`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r := cli.NewRunner("")
+			r.DryRun = dryRun
 
 			ctx := context.Background()
 			providedHandle, _ := cli.ExtractHandleFromArgs(args)
@@ -51,11 +57,27 @@ Examples:
 				contextData.Captures = nil
 			}
 
+			var localRepos []string
+			for _, repo := range contextData.Repositories {
+				if workspace.RepositoryLocation(repo.URL) == workspace.RepositoryLocal {
+					localRepos = append(localRepos, repo.Name)
+				}
+			}
+			if len(localRepos) > 0 {
+				logger.UncheckedFprintf(cmd.ErrOrStderr(), "WARNING: local-path repositories will not import on another machine: %s\n", strings.Join(localRepos, ", "))
+			}
+
 			outputPath := output
 			if outputPath == "" {
 				outputPath = filepath.Join(wsPath, ".workshed", "context.json")
 			}
 
+			if dryRun {
+				return cli.RenderPlan(cmd, []cli.PlanStep{
+					{Action: "write export", Target: outputPath, Detail: fmt.Sprintf("%d repositories", len(contextData.Repositories))},
+				})
+			}
+
 			data, err := json.MarshalIndent(contextData, "", "  ")
 			if err != nil {
 				return fmt.Errorf("marshaling context: %w", err)
@@ -84,6 +106,7 @@ Examples:
 
 	cmd.Flags().StringVar(&output, "output", "", "Output file path")
 	cmd.Flags().BoolVar(&compact, "compact", false, "Exclude captures from export")
+	cli.AddDryRunFlag(cmd, &dryRun)
 	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
 
 	return cmd
@@ -2,34 +2,55 @@ package health
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
-	"time"
 
 	"github.com/frodi/workshed/internal/cli"
-	"github.com/frodi/workshed/internal/git"
 	"github.com/frodi/workshed/internal/workspace"
 	"github.com/spf13/cobra"
 )
 
+// failOnNone never fails on issues, only on errors running the check.
+const failOnNone = "none"
+
+// jsonReport is the --format json output: the key-value fields plus every
+// issue with its severity.
+type jsonReport struct {
+	Handle string                  `json:"handle"`
+	Status string                  `json:"status"`
+	Issues []workspace.HealthIssue `json:"issues"`
+}
+
 func Command() *cobra.Command {
+	var failOn string
+
 	cmd := &cobra.Command{
 		Use:   "health [<handle>]",
 		Short: "Check workspace health",
 		Long: `Check workspace health and report issues.
 
+Each issue has a severity: missing or non-git repositories are errors, dirty
+working trees, ref drift and captures of missing repositories are warnings,
+and stale executions are info. The command exits non-zero when any issue is
+at or above --fail-on (default: error).
+
 Examples:
   # Check health of current workspace
   workshed health
 
   # Check health of specific workspace
-  workshed health my-workspace`,
+  workshed health my-workspace
+
+  # Fail a CI gate on warnings too, with machine-readable output
+  workshed health --fail-on warning --format json`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r := cli.NewRunner("")
 
+			if failOn != failOnNone && !workspace.ValidHealthSeverity(failOn) {
+				return fmt.Errorf("unknown --fail-on %q (valid: error, warning, info, none)", failOn)
+			}
+
 			ctx := context.Background()
 			providedHandle, _ := cli.ExtractHandleFromArgs(args)
 			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
@@ -37,95 +58,54 @@ Examples:
 				return fmt.Errorf("failed to resolve workspace: %w", err)
 			}
 
-			ws, err := r.GetStore().Get(ctx, handle)
-			if err != nil {
-				return fmt.Errorf("workspace not found: %w", err)
-			}
-
-			execs, err := r.GetStore().ListExecutions(ctx, handle, workspace.ListExecutionsOptions{Limit: 100})
+			report, err := r.GetStore().CheckHealth(ctx, handle)
 			if err != nil {
-				return fmt.Errorf("failed to list executions: %w", err)
+				return fmt.Errorf("health check failed: %w", err)
 			}
 
-			captures, _ := r.GetStore().ListCaptures(ctx, handle)
-
-			healthIssues := runHealthChecks(ctx, ws, execs, captures)
-
 			status := "healthy"
-			if len(healthIssues) > 0 {
+			if !report.Healthy {
 				status = "issues found"
 			}
 
 			format := cmd.Flags().Lookup("format").Value.String()
-			if format == "table" && len(healthIssues) > 0 {
-				fmt.Printf("Issues found:\n\n")
-				for _, issue := range healthIssues {
-					fmt.Printf("  %s\n", issue)
+			switch format {
+			case "json":
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(jsonReport{Handle: handle, Status: status, Issues: report.Issues}); err != nil {
+					return err
+				}
+			default:
+				if format == "table" && len(report.Issues) > 0 {
+					fmt.Printf("Issues found:\n\n")
+					for _, issue := range report.Issues {
+						fmt.Printf("  [%s] %s\n", issue.Severity, issue.Message)
+					}
+					fmt.Println()
 				}
-				fmt.Println()
-			}
-
-			return cli.RenderKeyValue(map[string]string{
-				"handle": handle,
-				"status": status,
-			}, format, cmd.OutOrStdout())
-		},
-	}
-
-	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
-
-	return cmd
-}
-
-func runHealthChecks(ctx context.Context, ws *workspace.Workspace, execs []workspace.ExecutionRecord, captures []workspace.Capture) []string {
-	var issues []string
-
-	staleThreshold := 30 * 24 * time.Hour
-	staleCount := 0
-	for _, e := range execs {
-		if time.Since(e.Timestamp) > staleThreshold {
-			staleCount++
-		}
-	}
-	if staleCount > 0 {
-		issues = append(issues, fmt.Sprintf("%d stale executions older than 30 days", staleCount))
-	}
-
-	gitClient := git.RealGit{}
 
-	for _, repo := range ws.Repositories {
-		repoDir := filepath.Join(ws.Path, repo.Name)
-		_, err := os.Stat(repoDir)
-		if err != nil {
-			if os.IsNotExist(err) {
-				issues = append(issues, fmt.Sprintf("missing repository directory: %s", repo.Name))
+				if err := cli.RenderKeyValue(map[string]string{
+					"handle": handle,
+					"status": status,
+				}, format, cmd.OutOrStdout()); err != nil {
+					return err
+				}
 			}
-			continue
-		}
 
-		gitDir := filepath.Join(repoDir, ".git")
-		if _, err := os.Stat(gitDir); err != nil {
-			if os.IsNotExist(err) {
-				issues = append(issues, fmt.Sprintf("%s is not a git repository", repo.Name))
+			if failOn == failOnNone {
+				return nil
 			}
-		} else {
-			status, _ := gitClient.StatusPorcelain(ctx, repoDir)
-			if strings.TrimSpace(status) != "" {
-				issues = append(issues, fmt.Sprintf("%s has uncommitted changes", repo.Name))
+			if failing := report.CountAtLeast(failOn); failing > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d health issues at or above %s", failing, failOn)
 			}
-		}
+			return nil
+		},
 	}
 
-	for _, cap := range captures {
-		for _, ref := range cap.GitState {
-			repoDir := filepath.Join(ws.Path, ref.Repository)
-			if _, err := os.Stat(repoDir); err != nil {
-				if os.IsNotExist(err) {
-					issues = append(issues, fmt.Sprintf("capture '%s' references missing repository: %s", cap.Name, ref.Repository))
-				}
-			}
-		}
-	}
+	cmd.Flags().StringVar(&failOn, "fail-on", workspace.HealthSeverityError, "Lowest issue severity that exits non-zero (error|warning|info|none)")
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
 
-	return issues
+	return cmd
 }
@@ -18,6 +18,13 @@ func TestHealthCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("has --fail-on flag defaulting to error", func(t *testing.T) {
+		flag := Command().Flags().Lookup("fail-on")
+		if flag == nil || flag.DefValue != "error" {
+			t.Error("health should have --fail-on flag defaulting to error")
+		}
+	})
+
 	t.Run("use format is correct", func(t *testing.T) {
 		cmd := Command()
 		expected := "health [<handle>]"
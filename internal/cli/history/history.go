@@ -0,0 +1,92 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+var historyColumns = []cli.ColumnConfig{
+	{Type: cli.Rigid, Name: "WHEN", Min: 8, Max: 10},
+	{Type: cli.Rigid, Name: "OPERATION", Min: 12, Max: 12},
+	{Type: cli.Rigid, Name: "CAPTURE", Min: 10, Max: 20},
+	{Type: cli.Rigid, Name: "RESULT", Min: 6, Max: 6},
+	{Type: cli.Shrinkable, Name: "CHANGES", Min: 15, Max: 0},
+	{Type: cli.Rigid, Name: "TIME", Min: 16, Max: 16},
+}
+
+func Command() *cobra.Command {
+	var wide bool
+
+	cmd := &cobra.Command{
+		Use:   "history [<handle>]",
+		Short: "Show operations that changed a workspace's repositories",
+		Long: `Show the operations that changed what a workspace's repositories have
+checked out, newest first: creation, applied captures, checkouts and lockfile
+restores. Each lists the commits repositories moved between.
+
+Examples:
+  workshed history
+  workshed history my-workspace
+  workshed history --format json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			entries, err := r.GetStore().ListHistory(ctx, handle)
+			if err != nil {
+				return fmt.Errorf("failed to read history: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if len(entries) == 0 {
+				return cli.RenderEmptyList(format, "no history recorded", cmd.OutOrStdout(), r.GetLogger())
+			}
+
+			if format == "raw" {
+				for _, entry := range entries {
+					_, _ = fmt.Fprintln(cmd.OutOrStdout(), entry.ID)
+				}
+				return nil
+			}
+
+			now := time.Now()
+			var rows [][]string
+			for _, entry := range entries {
+				capture := entry.CaptureName
+				if capture == "" {
+					capture = entry.CaptureID
+				}
+				changes := entry.Summary()
+				if entry.Error != "" {
+					changes = entry.Error
+				}
+				rows = append(rows, []string{
+					cli.RelativeTime(entry.Timestamp, now),
+					entry.Operation,
+					capture,
+					entry.Result,
+					changes,
+					entry.Timestamp.Format("2006-01-02 15:04"),
+				})
+			}
+
+			return cli.Render(cli.Output{Columns: historyColumns, Rows: rows, Wide: wide}, format, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().BoolVar(&wide, "wide", false, "Show full values instead of truncating to the terminal width")
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
@@ -0,0 +1,27 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func flagExists(cmd *cobra.Command, name string) bool {
+	return cmd.Flags().Lookup(name) != nil
+}
+
+func TestHistoryCommand(t *testing.T) {
+	t.Run("has --format flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "format") {
+			t.Error("history should have --format flag")
+		}
+	})
+
+	t.Run("has --wide flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "wide") {
+			t.Error("history should have --wide flag")
+		}
+	})
+}
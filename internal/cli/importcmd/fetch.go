@@ -0,0 +1,91 @@
+package importcmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	fetchTimeout = 30 * time.Second
+	// maxFetchSize bounds how much of a response is read; exports are small
+	// JSON documents, so anything larger is almost certainly the wrong URL.
+	maxFetchSize = 10 << 20
+	// maxFetchRedirects matches the limit of Go's default client.
+	maxFetchRedirects = 10
+)
+
+// fetchExport downloads an export document from rawURL. Only https URLs are
+// accepted unless insecure is set, and redirects are held to the same rule.
+func fetchExport(ctx context.Context, rawURL string, insecure bool) ([]byte, error) {
+	return fetchWith(ctx, newFetchClient(insecure), rawURL, insecure)
+}
+
+// newFetchClient returns a client with a timeout that refuses redirects to
+// URLs fetchExport would not accept itself.
+func newFetchClient(insecure bool) *http.Client {
+	return &http.Client{
+		Timeout: fetchTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			if err := checkFetchScheme(req.URL, insecure); err != nil {
+				return fmt.Errorf("refusing redirect to %s: %w", req.URL, err)
+			}
+			return nil
+		},
+	}
+}
+
+func checkFetchScheme(u *url.URL, insecure bool) error {
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if !insecure {
+			return fmt.Errorf("refusing to fetch over plain HTTP: %s (use --insecure to allow)", u)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported URL scheme %q: expected https", u.Scheme)
+	}
+}
+
+func fetchWith(ctx context.Context, client *http.Client, rawURL string, insecure bool) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if err := checkFetchScheme(u, insecure); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", rawURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: server returned %s", rawURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if len(data) > maxFetchSize {
+		return nil, fmt.Errorf("export at %s exceeds %d bytes", rawURL, maxFetchSize)
+	}
+	return data, nil
+}
@@ -17,34 +17,57 @@ func Command() *cobra.Command {
 	var preserveHandle bool
 	var force bool
 	var file string
+	var concurrency int
+	var fromURL string
+	var insecure bool
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "import [<file.json>]",
 		Short: "Import workspace from JSON",
 		Long: `Create a workspace from an exported JSON file.
 
+With --url the export is fetched over HTTPS instead, e.g. from a gist's raw
+URL. Plain HTTP is refused unless --insecure is given.
+
 Examples:
   workshed import workspace.json
   workshed import workspace.json --preserve-handle
   cat workspace.json | workshed import -
-  workshed import --file workspace.json`,
+  workshed import --file workspace.json
+  workshed import --url https://gist.githubusercontent.com/me/abc/raw/workspace.json
+  workshed import workspace.json --dry-run`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			r := cli.NewRunner("")
+			cwd, err := cli.InvocationDir(cmd)
+			if err != nil {
+				return err
+			}
+			r := cli.NewRunner(cwd)
 
 			inputFile := file
 			if len(args) > 0 {
 				inputFile = args[0]
 			}
 
-			if inputFile == "" {
-				return fmt.Errorf("missing required argument: <file.json> or --file flag")
+			if fromURL != "" && inputFile != "" {
+				return fmt.Errorf("--url cannot be combined with a file argument or --file")
 			}
+			if fromURL == "" && inputFile == "" {
+				return fmt.Errorf("missing required argument: <file.json>, --file or --url flag")
+			}
+
+			ctx := context.Background()
 
 			var data []byte
-			var err error
 
-			if inputFile == "-" {
+			if fromURL != "" {
+				inputFile = fromURL
+				data, err = fetchExport(ctx, fromURL, insecure)
+				if err != nil {
+					return err
+				}
+			} else if inputFile == "-" {
 				data, err = io.ReadAll(os.Stdin)
 				if err != nil {
 					return fmt.Errorf("reading from stdin: %w", err)
@@ -65,27 +88,24 @@ Examples:
 				return fmt.Errorf("parsing JSON: %w", err)
 			}
 
-			if wsContext.Purpose == "" {
-				return fmt.Errorf("missing required field: purpose")
-			}
-
-			if wsContext.Repositories == nil {
-				return fmt.Errorf("missing required field: repositories")
+			if err := workspace.ValidateContext(&wsContext); err != nil {
+				return fmt.Errorf("import failed: %w", err)
 			}
 
-			for _, repo := range wsContext.Repositories {
-				if repo.URL == "" {
-					return fmt.Errorf("invalid repository: URL is required")
+			if dryRun {
+				steps, err := importPlan(ctx, r.GetStore(), &wsContext, preserveHandle, force)
+				if err != nil {
+					return fmt.Errorf("import failed: %w", err)
 				}
+				return cli.RenderPlan(cmd, steps)
 			}
 
-			ctx := context.Background()
-
 			ws, err := r.GetStore().ImportContext(ctx, workspace.ImportOptions{
 				Context:        &wsContext,
 				InvocationCWD:  r.GetInvocationCWD(),
 				PreserveHandle: preserveHandle,
 				Force:          force,
+				Concurrency:    concurrency,
 			})
 			if err != nil {
 				return fmt.Errorf("import failed: %w", err)
@@ -115,8 +135,38 @@ Examples:
 
 	cmd.Flags().BoolVar(&preserveHandle, "preserve-handle", false, "Preserve the handle from the imported file")
 	cmd.Flags().BoolVar(&force, "force", false, "Overwrite existing workspace if it exists")
+	cmd.Flags().IntVar(&concurrency, "concurrency", workspace.DefaultCloneConcurrency, "Maximum repositories to clone at once")
 	cmd.Flags().StringVar(&file, "file", "", "Input file path (- for stdin)")
+	cmd.Flags().StringVar(&fromURL, "url", "", "Fetch the export JSON from an https URL")
+	cmd.Flags().BoolVar(&insecure, "insecure", false, "Allow --url to fetch over plain HTTP")
+	cli.AddDryRunFlag(cmd, &dryRun)
 	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
 
 	return cmd
 }
+
+// importPlan lists the changes importing wsContext would make. wsContext
+// must already have passed workspace.ValidateContext.
+func importPlan(ctx context.Context, store workspace.Store, wsContext *workspace.WorkspaceContext, preserveHandle, force bool) ([]cli.PlanStep, error) {
+	var steps []cli.PlanStep
+	target := "(new handle)"
+	if preserveHandle {
+		target = wsContext.Handle
+		if _, err := store.Get(ctx, wsContext.Handle); err == nil {
+			if !force {
+				return nil, fmt.Errorf("workspace with handle '%s' already exists; use --force to overwrite", wsContext.Handle)
+			}
+			steps = append(steps, cli.PlanStep{Action: "remove workspace", Target: wsContext.Handle, Detail: "replaced by --force"})
+		}
+	}
+	steps = append(steps, cli.PlanStep{Action: "create workspace", Target: target, Detail: "purpose " + strconv.Quote(wsContext.Purpose)})
+	for _, repo := range wsContext.Repositories {
+		steps = append(steps, cli.CloneStep(workspace.RepositoryOption{
+			URL:    repo.URL,
+			Ref:    repo.Ref,
+			Sparse: repo.Sparse,
+			LFS:    repo.LFS,
+		}))
+	}
+	return steps, nil
+}
@@ -1,6 +1,10 @@
 package importcmd
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -38,4 +42,97 @@ func TestImportCommand(t *testing.T) {
 			t.Error("import should have --force flag")
 		}
 	})
+
+	t.Run("has --url and --insecure flags", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "url") || !flagExists(cmd, "insecure") {
+			t.Error("import should have --url and --insecure flags")
+		}
+	})
+
+	t.Run("has --concurrency flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "concurrency") {
+			t.Error("import should have --concurrency flag")
+		}
+	})
+}
+
+// testFetchClient is newFetchClient trusting srv's certificate.
+func testFetchClient(srv *httptest.Server, insecure bool) *http.Client {
+	client := newFetchClient(insecure)
+	client.Transport = srv.Client().Transport
+	return client
+}
+
+func TestFetchExport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejects plain http without --insecure", func(t *testing.T) {
+		_, err := fetchExport(ctx, "http://example.com/export.json", false)
+		if err == nil || !strings.Contains(err.Error(), "plain HTTP") {
+			t.Errorf("Expected a plain HTTP error, got %v", err)
+		}
+	})
+
+	t.Run("rejects a redirect to http", func(t *testing.T) {
+		var served bool
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = true
+			_, _ = w.Write([]byte("{}"))
+		}))
+		defer target.Close()
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, target.URL+"/export.json", http.StatusFound)
+		}))
+		defer srv.Close()
+
+		_, err := fetchWith(ctx, testFetchClient(srv, false), srv.URL, false)
+		if err == nil || !strings.Contains(err.Error(), "refusing redirect") {
+			t.Errorf("Expected the redirect to be refused, got %v", err)
+		}
+		if served {
+			t.Error("Expected the http target never to be requested")
+		}
+	})
+
+	t.Run("follows a redirect to https", func(t *testing.T) {
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/export.json" {
+				http.Redirect(w, r, "/export.json", http.StatusFound)
+				return
+			}
+			_, _ = w.Write([]byte("{}"))
+		}))
+		defer srv.Close()
+
+		data, err := fetchWith(ctx, testFetchClient(srv, false), srv.URL, false)
+		if err != nil || string(data) != "{}" {
+			t.Errorf("Expected the redirected export, got %q (%v)", data, err)
+		}
+	})
+
+	t.Run("rejects a body over the size limit", func(t *testing.T) {
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(make([]byte, maxFetchSize+1))
+		}))
+		defer srv.Close()
+
+		_, err := fetchWith(ctx, testFetchClient(srv, false), srv.URL, false)
+		if err == nil || !strings.Contains(err.Error(), "exceeds") {
+			t.Errorf("Expected a size limit error, got %v", err)
+		}
+	})
+
+	t.Run("accepts a body at the size limit", func(t *testing.T) {
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(make([]byte, maxFetchSize))
+		}))
+		defer srv.Close()
+
+		data, err := fetchWith(ctx, testFetchClient(srv, false), srv.URL, false)
+		if err != nil || len(data) != maxFetchSize {
+			t.Errorf("Expected %d bytes, got %d (%v)", maxFetchSize, len(data), err)
+		}
+	})
 }
@@ -2,21 +2,40 @@ package inspect
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
 	"github.com/spf13/cobra"
 )
 
 func Command() *cobra.Command {
+	var diffHandle string
+	var wide bool
+	var withStatus bool
+	var repoName string
+
 	cmd := &cobra.Command{
 		Use:   "inspect [<handle>]",
 		Short: "Show workspace details",
 		Long: `Show workspace details including repositories and creation time.
 
+With --format json the output also includes captures_dir and executions_dir,
+the absolute paths of the directories holding the workspace's captures and
+execution records.
+
 Examples:
   workshed inspect
-  workshed inspect aquatic-fish-motion`,
+  workshed inspect aquatic-fish-motion
+  workshed inspect --with-status
+  workshed inspect --repo api
+  workshed inspect --repo api --format json
+  workshed inspect aquatic-fish-motion --diff quiet-river-stone
+  workshed inspect --diff quiet-river-stone --format json`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r := cli.NewRunner("")
@@ -28,18 +47,68 @@ Examples:
 				return fmt.Errorf("failed to resolve workspace: %w", err)
 			}
 
+			format := cmd.Flags().Lookup("format").Value.String()
+
+			if repoName != "" {
+				if diffHandle != "" {
+					return fmt.Errorf("--repo and --diff cannot be used together")
+				}
+				detail, err := r.GetStore().InspectRepository(ctx, handle, repoName)
+				if err != nil {
+					return err
+				}
+				return renderRepo(detail, format, wide, cmd.OutOrStdout())
+			}
+
+			if diffHandle != "" {
+				diff, err := r.GetStore().Compare(ctx, handle, diffHandle)
+				if err != nil {
+					return fmt.Errorf("failed to compare workspaces: %w", err)
+				}
+				if format == "json" {
+					data, _ := json.MarshalIndent(diff, "", "  ")
+					_, _ = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+					return nil
+				}
+				if diff.Empty() {
+					r.GetLogger().Info("workspaces are identical", "left", diff.Left, "right", diff.Right)
+					return nil
+				}
+				output := diffOutput(diff)
+				output.Wide = wide
+				return cli.Render(output, format, cmd.OutOrStdout())
+			}
+
 			ws, err := r.GetStore().Get(ctx, handle)
 			if err != nil {
 				return fmt.Errorf("failed to get workspace: %w", err)
 			}
 
-			format := cmd.Flags().Lookup("format").Value.String()
+			lastActivity, err := r.GetStore().LastActivity(ctx, handle)
+			if err != nil {
+				return fmt.Errorf("failed to read last activity: %w", err)
+			}
 
 			data := map[string]string{
-				"handle":  ws.Handle,
-				"purpose": ws.Purpose,
-				"path":    ws.Path,
-				"created": ws.CreatedAt.Format("2006-01-02 15:04:05"),
+				"handle":        ws.Handle,
+				"purpose":       ws.Purpose,
+				"path":          ws.Path,
+				"created":       ws.CreatedAt.Format("2006-01-02 15:04:05"),
+				"last_activity": lastActivity.Format("2006-01-02 15:04:05"),
+			}
+			if ws.Project != "" {
+				data["project"] = ws.Project
+			}
+			data["repos"] = strconv.Itoa(len(ws.Repositories))
+			statuses := make(map[string]string)
+			if withStatus {
+				results, err := r.GetStore().RepositoryStatuses(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to read repository status: %w", err)
+				}
+				for _, status := range results {
+					statuses[status.Repository] = status.Summary()
+				}
 			}
 			for _, repo := range ws.Repositories {
 				var repoInfo string
@@ -48,14 +117,89 @@ Examples:
 				} else {
 					repoInfo = repo.Name
 				}
+				repoInfo += " (" + repo.Location() + ")"
+				if repo.Source == workspace.RepositorySourceWorkingTree {
+					repoInfo += " (working tree copy)"
+				}
+				if repo.NoCheckout {
+					repoInfo += " (not checked out)"
+				}
+				if len(repo.Sparse) > 0 {
+					repoInfo += " (sparse: " + strings.Join(repo.Sparse, ", ") + ")"
+				}
+				if status, ok := statuses[repo.Name]; ok {
+					repoInfo += " [" + status + "]"
+				}
 				data["repo"] = repoInfo
 			}
 
+			if format == "table" {
+				return cli.Render(cli.Output{Columns: cli.KeyValueColumns, Rows: cli.KeyValueRows(data), Wide: wide}, format, cmd.OutOrStdout())
+			}
+			if format == "json" {
+				// Internal paths are for tooling reading captures and
+				// executions directly, so they are only in JSON.
+				if data["captures_dir"], err = filepath.Abs(ws.CapturesDir()); err != nil {
+					return fmt.Errorf("failed to resolve captures directory: %w", err)
+				}
+				if data["executions_dir"], err = filepath.Abs(ws.ExecutionsDir()); err != nil {
+					return fmt.Errorf("failed to resolve executions directory: %w", err)
+				}
+			}
 			return cli.RenderKeyValue(data, format, cmd.OutOrStdout())
 		},
 	}
 
+	cmd.Flags().StringVar(&diffHandle, "diff", "", "Compare against another workspace")
+	cmd.Flags().BoolVar(&withStatus, "with-status", false, "Show each repository's dirty state and commits ahead/behind upstream")
+	cmd.Flags().StringVar(&repoName, "repo", "", "Show one repository's configuration, branch, commit and upstream status")
+	cmd.Flags().BoolVar(&wide, "wide", false, "Show full values instead of truncating to the terminal width")
 	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
 
 	return cmd
 }
+
+func diffOutput(diff *workspace.WorkspaceDiff) cli.Output {
+	output := cli.Output{
+		Columns: []cli.ColumnConfig{
+			{Type: cli.Rigid, Name: "CHANGE", Min: 10, Max: 12},
+			{Type: cli.Rigid, Name: "NAME", Min: 8, Max: 20},
+			{Type: cli.Shrinkable, Name: strings.ToUpper(diff.Left), Min: 15, Max: 0},
+			{Type: cli.Shrinkable, Name: strings.ToUpper(diff.Right), Min: 15, Max: 0},
+		},
+	}
+
+	if diff.LeftPurpose != "" || diff.RightPurpose != "" {
+		output.Rows = append(output.Rows, []string{"purpose", "-", diff.LeftPurpose, diff.RightPurpose})
+	}
+	for _, name := range diff.OnlyInLeft {
+		output.Rows = append(output.Rows, []string{"only left", name, "present", "-"})
+	}
+	for _, name := range diff.OnlyInRight {
+		output.Rows = append(output.Rows, []string{"only right", name, "-", "present"})
+	}
+	for _, change := range diff.Changed {
+		output.Rows = append(output.Rows, []string{
+			"changed",
+			change.Repository,
+			refWithCommit(change.LeftRef, change.LeftCommit),
+			refWithCommit(change.RightRef, change.RightCommit),
+		})
+	}
+
+	return output
+}
+
+func refWithCommit(ref, commit string) string {
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	switch {
+	case ref == "":
+		return commit
+	case commit == "":
+		return ref
+	default:
+		return ref + " (" + commit + ")"
+	}
+}
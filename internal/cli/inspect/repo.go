@@ -0,0 +1,106 @@
+package inspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+)
+
+// RepoView is the --repo --format json output.
+type RepoView struct {
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Ref         string   `json:"ref,omitempty"`
+	Path        string   `json:"path"`
+	Source      string   `json:"source,omitempty"`
+	Sparse      []string `json:"sparse,omitempty"`
+	NoCheckout  bool     `json:"no_checkout,omitempty"`
+	Branch      string   `json:"branch,omitempty"`
+	Commit      string   `json:"commit,omitempty"`
+	Dirty       bool     `json:"dirty"`
+	HasUpstream bool     `json:"has_upstream"`
+	Ahead       int      `json:"ahead"`
+	Behind      int      `json:"behind"`
+	Status      string   `json:"status"`
+	Error       string   `json:"error,omitempty"`
+}
+
+func newRepoView(detail *workspace.RepositoryDetail) RepoView {
+	repo := detail.Repository
+	view := RepoView{
+		Name:        repo.Name,
+		URL:         repo.URL,
+		Ref:         repo.Ref,
+		Path:        detail.Path,
+		Source:      repo.Source,
+		Sparse:      repo.Sparse,
+		NoCheckout:  repo.NoCheckout,
+		Dirty:       detail.Status.Dirty,
+		HasUpstream: detail.Status.HasUpstream,
+		Ahead:       detail.Status.Ahead,
+		Behind:      detail.Status.Behind,
+		Status:      detail.Status.Summary(),
+	}
+	if detail.State != nil {
+		view.Branch = detail.State.Branch
+		view.Commit = detail.State.Commit
+	}
+	switch {
+	case detail.StateErr != nil:
+		view.Error = detail.StateErr.Error()
+	case detail.Status.Err != nil:
+		view.Error = detail.Status.Err.Error()
+	}
+	return view
+}
+
+func renderRepo(detail *workspace.RepositoryDetail, format string, wide bool, w io.Writer) error {
+	view := newRepoView(detail)
+	if format == "json" {
+		data, _ := json.MarshalIndent(view, "", "  ")
+		_, _ = fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	data := map[string]string{
+		"name":   view.Name,
+		"url":    view.URL,
+		"path":   view.Path,
+		"status": view.Status,
+	}
+	if view.Ref != "" {
+		data["ref"] = view.Ref
+	}
+	if view.Source == workspace.RepositorySourceWorkingTree {
+		data["source"] = "working tree copy"
+	}
+	if view.NoCheckout {
+		data["checkout"] = "none"
+	}
+	if len(view.Sparse) > 0 {
+		data["sparse"] = strings.Join(view.Sparse, ", ")
+	}
+	if view.Branch != "" {
+		data["branch"] = view.Branch
+	}
+	if view.Commit != "" {
+		data["commit"] = view.Commit
+	}
+	if view.HasUpstream {
+		data["ahead"] = strconv.Itoa(view.Ahead)
+		data["behind"] = strconv.Itoa(view.Behind)
+	}
+	if view.Error != "" {
+		data["error"] = view.Error
+	}
+
+	if format == "table" {
+		return cli.Render(cli.Output{Columns: cli.KeyValueColumns, Rows: cli.KeyValueRows(data), Wide: wide}, format, w)
+	}
+	return cli.RenderKeyValue(data, format, w)
+}
@@ -11,6 +11,13 @@ func flagExists(cmd *cobra.Command, name string) bool {
 }
 
 func TestInspectCommand(t *testing.T) {
+	t.Run("has --wide flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "wide") {
+			t.Error("inspect should have --wide flag")
+		}
+	})
+
 	t.Run("has --format flag", func(t *testing.T) {
 		cmd := Command()
 		if !flagExists(cmd, "format") {
@@ -18,6 +25,27 @@ func TestInspectCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("has --with-status flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "with-status") {
+			t.Error("inspect should have --with-status flag")
+		}
+	})
+
+	t.Run("has --diff flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "diff") {
+			t.Error("inspect should have --diff flag")
+		}
+	})
+
+	t.Run("has --repo flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "repo") {
+			t.Error("inspect should have --repo flag")
+		}
+	})
+
 	t.Run("accepts arbitrary args", func(t *testing.T) {
 		cmd := Command()
 		if cmd.Args == nil {
@@ -0,0 +1,50 @@
+package last
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "last",
+		Short: "Print the most recently used workspace handle",
+		Long: `Print the handle of the workspace a command most recently resolved.
+
+Examples:
+  workshed last
+  cd $(workshed path $(workshed last))
+  workshed last --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+
+			ctx := context.Background()
+			entries, err := r.GetStore().RecentHandles(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to read recent workspaces: %w", err)
+			}
+			if len(entries) == 0 {
+				return errors.New("no recently used workspace")
+			}
+			handle := entries[0].Handle
+
+			format := cmd.Flags().Lookup("format").Value.String()
+
+			if format == "raw" {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), handle)
+				return nil
+			}
+
+			return cli.RenderKeyValue(map[string]string{"handle": handle}, format, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().String("format", "raw", "Output format (raw|table|json)")
+
+	return cmd
+}
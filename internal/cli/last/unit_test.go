@@ -0,0 +1,30 @@
+package last
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func flagExists(cmd *cobra.Command, name string) bool {
+	return cmd.Flags().Lookup(name) != nil
+}
+
+func TestLastCommand(t *testing.T) {
+	t.Run("has --format flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "format") {
+			t.Error("last should have --format flag")
+		}
+	})
+
+	t.Run("format defaults to raw", func(t *testing.T) {
+		cmd := Command()
+		flag := cmd.Flags().Lookup("format")
+		if flag == nil {
+			t.Error("last should have --format flag")
+		} else if flag.DefValue != "raw" {
+			t.Errorf("format default should be 'raw', got: %s", flag.DefValue)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+
+	"github.com/frodi/workshed/internal/git"
+	"github.com/frodi/workshed/internal/workspace"
+)
+
+// WarnLFS writes a warning to w for each repository in ws named in names
+// that tracks files with Git LFS but was cloned without pulling them, so its
+// working tree holds pointer files.
+func WarnLFS(ctx context.Context, w io.Writer, ws *workspace.Workspace, names []string) {
+	var checked, available bool
+	for _, repo := range ws.Repositories {
+		if !slices.Contains(names, repo.Name) || repo.NoCheckout {
+			continue
+		}
+		if !workspace.UsesLFS(filepath.Join(ws.Path, repo.Name)) {
+			continue
+		}
+		if !checked {
+			available = git.RealGit{}.LFSAvailable(ctx)
+			checked = true
+		}
+		switch {
+		case !available:
+			_, _ = fmt.Fprintf(w, "Warning: %s uses Git LFS but git-lfs is not installed; LFS files are pointers\n", repo.Name)
+		case !repo.LFS:
+			_, _ = fmt.Fprintf(w, "Warning: %s uses Git LFS; pass --lfs or run 'git lfs pull' in it to fetch LFS files\n", repo.Name)
+		}
+	}
+}
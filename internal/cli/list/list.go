@@ -3,16 +3,34 @@ package list
 import (
 	"context"
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/frodi/workshed/internal/cli"
 	"github.com/frodi/workshed/internal/workspace"
 	"github.com/spf13/cobra"
 )
 
+// defaultActiveWithin is how recently a workspace must have run a command to
+// count as active for list --active.
+const defaultActiveWithin = 30 * time.Minute
+
 func Command() *cobra.Command {
 	var purpose string
+	var project string
+	var groupBy string
 	var page int
 	var pageSize int
+	var columns []string
+	var wide bool
+	var recent bool
+	var withRepos bool
+	var createdAfter string
+	var createdBefore string
+	var active bool
+	var activeWithin time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "list",
@@ -23,15 +41,83 @@ Examples:
   workshed list
   workshed list --purpose payment
   workshed list --purpose "API" --format json
-  workshed list --page 2 --page-size 10`,
+  workshed list --project payments
+  workshed list --group-by project
+  workshed list --page 2 --page-size 10
+  workshed list --recent
+  workshed list --columns handle,purpose --format raw
+  workshed list --format ndjson
+  workshed list --format json --with-repos
+  workshed list --created-after 7d
+  workshed list --created-after 2024-05-01 --created-before 2024-06-01
+  workshed list --active --recent
+  workshed list --active --active-within 2h --format json
+
+--created-after and --created-before take an RFC3339 time, a date, or an age
+such as 12h, 7d or 2w.
+
+--active shows only workspaces where a command finished through exec within
+--active-within (30 minutes by default). With --recent, this answers "what am
+I working on right now".`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r := cli.NewRunner("")
 
 			ctx := context.Background()
 
+			if _, err := cli.SelectColumns(cli.Output{Columns: cli.ListColumns}, columns); err != nil {
+				return err
+			}
+			if groupBy != "" && groupBy != "project" {
+				return fmt.Errorf("unknown --group-by %q (valid: project)", groupBy)
+			}
+			if format := cmd.Flags().Lookup("format").Value.String(); withRepos && format != "json" && format != "ndjson" {
+				return fmt.Errorf("--with-repos requires --format json or ndjson")
+			}
+
 			opts := workspace.ListOptions{
 				PurposeFilter: purpose,
+				ProjectFilter: project,
+			}
+			now := time.Now()
+			if createdAfter != "" {
+				t, err := workspace.ParseTimeBound(createdAfter, now)
+				if err != nil {
+					return fmt.Errorf("invalid --created-after: %w", err)
+				}
+				opts.CreatedAfter = t
+			}
+			if createdBefore != "" {
+				t, err := workspace.ParseTimeBound(createdBefore, now)
+				if err != nil {
+					return fmt.Errorf("invalid --created-before: %w", err)
+				}
+				opts.CreatedBefore = t
+			}
+			if !opts.CreatedAfter.IsZero() && !opts.CreatedBefore.IsZero() && opts.CreatedAfter.After(opts.CreatedBefore) {
+				return fmt.Errorf("--created-after must not be later than --created-before")
+			}
+			if cmd.Flags().Changed("active-within") && !active {
+				return fmt.Errorf("--active-within requires --active")
+			}
+			if active {
+				if activeWithin <= 0 {
+					return fmt.Errorf("--active-within must be positive")
+				}
+				opts.ActiveWithin = activeWithin
+			}
+
+			if cmd.Flags().Lookup("format").Value.String() == "ndjson" {
+				if groupBy != "" {
+					return fmt.Errorf("--group-by cannot be combined with --format ndjson")
+				}
+				if recent {
+					return fmt.Errorf("--recent cannot be combined with --format ndjson")
+				}
+				if cmd.Flags().Changed("page") || cmd.Flags().Changed("page-size") {
+					return fmt.Errorf("--page and --page-size cannot be combined with --format ndjson")
+				}
+				return streamNDJSON(ctx, r.GetStore(), opts, columns, withRepos, cmd.OutOrStdout())
 			}
 
 			workspaces, err := r.GetStore().List(ctx, opts)
@@ -44,6 +130,14 @@ Examples:
 				return cli.RenderEmptyList(format, "no workspaces found", cmd.OutOrStdout(), r.GetLogger())
 			}
 
+			if recent {
+				entries, err := r.GetStore().RecentHandles(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to read recent workspaces: %w", err)
+				}
+				sortByRecent(workspaces, entries)
+			}
+
 			total := len(workspaces)
 			if page < 1 {
 				page = 1
@@ -67,35 +161,39 @@ Examples:
 			pagedWorkspaces := workspaces[startIdx:endIdx]
 
 			format := cmd.Flags().Lookup("format").Value.String()
-			if format == "raw" {
+			if format == "raw" && len(columns) == 0 && groupBy == "" {
 				for _, ws := range pagedWorkspaces {
 					_, _ = fmt.Fprintln(cmd.OutOrStdout(), ws.Handle)
 				}
 				return nil
 			}
 
-			var rows [][]string
+			activity := make(map[string]time.Time, len(pagedWorkspaces))
 			for _, ws := range pagedWorkspaces {
-				repoCount := len(ws.Repositories)
-				var repoInfo string
-				if repoCount == 1 {
-					repoInfo = ws.Repositories[0].Name
-				} else if repoCount > 1 {
-					repoInfo = fmt.Sprintf("%d repos", repoCount)
-				} else {
-					repoInfo = "(empty)"
+				last, err := r.GetStore().LastActivity(ctx, ws.Handle)
+				if err != nil {
+					return fmt.Errorf("failed to read last activity: %w", err)
 				}
-				created := ws.CreatedAt.Format("2006-01-02 15:04")
-				rows = append(rows, []string{ws.Handle, ws.Purpose, repoInfo, created})
+				activity[ws.Handle] = last
 			}
 
-			output := cli.Output{
-				Columns: cli.ListColumns,
-				Rows:    rows,
+			if groupBy != "" {
+				if err := renderGroups(pagedWorkspaces, activity, columns, wide, withRepos, format, cmd.OutOrStdout()); err != nil {
+					return err
+				}
+			} else {
+				output, err := listOutput(pagedWorkspaces, activity, columns)
+				if err != nil {
+					return err
+				}
+				output.Wide = wide
+				if err := render(output, pagedWorkspaces, withRepos, format, cmd.OutOrStdout()); err != nil {
+					return fmt.Errorf("failed to render output: %w", err)
+				}
 			}
 
-			if err := cli.Render(output, format, cmd.OutOrStdout()); err != nil {
-				return fmt.Errorf("failed to render output: %w", err)
+			if format == "table" {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\n%s\n", summaryLine(workspaces))
 			}
 
 			if format != "json" && total > pageSize {
@@ -107,9 +205,182 @@ Examples:
 	}
 
 	cmd.Flags().StringVar(&purpose, "purpose", "", "Filter by purpose")
+	cmd.Flags().StringVar(&project, "project", "", "Filter by project")
+	cmd.Flags().StringVar(&groupBy, "group-by", "", "Group workspaces (project)")
 	cmd.Flags().IntVar(&page, "page", 1, "Page number")
 	cmd.Flags().IntVar(&pageSize, "page-size", 20, "Items per page")
-	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+	cmd.Flags().StringSliceVar(&columns, "columns", nil, "Columns to show, in order (handle,purpose,repo,repos,created,activity)")
+	cmd.Flags().BoolVar(&wide, "wide", false, "Show full values instead of truncating to the terminal width")
+	cmd.Flags().BoolVar(&recent, "recent", false, "Sort by most recently used first")
+	cmd.Flags().BoolVar(&withRepos, "with-repos", false, "Include each workspace's repositories (json and ndjson only)")
+	cmd.Flags().StringVar(&createdAfter, "created-after", "", "Only workspaces created at or after this time (RFC3339, date or age like 7d)")
+	cmd.Flags().StringVar(&createdBefore, "created-before", "", "Only workspaces created at or before this time (RFC3339, date or age like 7d)")
+	cmd.Flags().BoolVar(&active, "active", false, "Only workspaces with an execution within --active-within")
+	cmd.Flags().DurationVar(&activeWithin, "active-within", defaultActiveWithin, "How recent an execution must be for --active")
+	cmd.Flags().String("format", "table", "Output format (table|json|ndjson|raw)")
 
 	return cmd
 }
+
+// summaryLine totals the workspaces and repositories listed, across all pages.
+func summaryLine(workspaces []*workspace.Workspace) string {
+	repos := 0
+	for _, ws := range workspaces {
+		repos += len(ws.Repositories)
+	}
+	return fmt.Sprintf("%s, %s", plural(len(workspaces), "workspace", "workspaces"), plural(repos, "repository", "repositories"))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// sortByRecent orders workspaces by position in the recent list. Workspaces
+// never used keep their listing order after the used ones.
+func sortByRecent(workspaces []*workspace.Workspace, entries []workspace.RecentEntry) {
+	rank := make(map[string]int, len(entries))
+	for i, e := range entries {
+		rank[e.Handle] = i
+	}
+	sort.SliceStable(workspaces, func(i, j int) bool {
+		ri, iok := rank[workspaces[i].Handle]
+		rj, jok := rank[workspaces[j].Handle]
+		if iok != jok {
+			return iok
+		}
+		return iok && ri < rj
+	})
+}
+
+// render writes output, adding a REPOSITORIES array per workspace when
+// withRepos is set. workspaces must be in the same order as output's rows.
+func render(output cli.Output, workspaces []*workspace.Workspace, withRepos bool, format string, w io.Writer) error {
+	if !withRepos {
+		return cli.Render(output, format, w)
+	}
+	extra := make([]map[string]any, len(workspaces))
+	for i, ws := range workspaces {
+		repos := ws.Repositories
+		if repos == nil {
+			repos = []workspace.Repository{}
+		}
+		extra[i] = map[string]any{"REPOSITORIES": repos}
+	}
+	return cli.RenderJSONWith(output, extra, format, w)
+}
+
+// streamNDJSON writes one JSON object per workspace as the store reads it,
+// so output starts immediately and memory stays flat for large stores.
+func streamNDJSON(ctx context.Context, store workspace.Store, opts workspace.ListOptions, columns []string, withRepos bool, w io.Writer) error {
+	err := store.ListStream(ctx, opts, func(ws *workspace.Workspace) error {
+		last, err := store.LastActivity(ctx, ws.Handle)
+		if err != nil {
+			return fmt.Errorf("failed to read last activity: %w", err)
+		}
+		output, err := listOutput([]*workspace.Workspace{ws}, map[string]time.Time{ws.Handle: last}, columns)
+		if err != nil {
+			return err
+		}
+		return render(output, []*workspace.Workspace{ws}, withRepos, "ndjson", w)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	return nil
+}
+
+const noProject = "(no project)"
+
+var projectColumn = cli.ColumnConfig{Type: cli.Rigid, Name: "PROJECT", Min: 8, Max: 20}
+
+func listOutput(workspaces []*workspace.Workspace, activity map[string]time.Time, columns []string) (cli.Output, error) {
+	now := time.Now()
+	var rows [][]string
+	for _, ws := range workspaces {
+		repoCount := len(ws.Repositories)
+		var repoInfo string
+		if repoCount == 1 {
+			repoInfo = ws.Repositories[0].Name
+		} else if repoCount > 1 {
+			repoInfo = fmt.Sprintf("%d repos", repoCount)
+		} else {
+			repoInfo = "(empty)"
+		}
+		created := ws.CreatedAt.Format("2006-01-02 15:04")
+		rows = append(rows, []string{ws.Handle, ws.Purpose, repoInfo, strconv.Itoa(repoCount), created, cli.RelativeTime(activity[ws.Handle], now)})
+	}
+
+	output := cli.Output{
+		Columns: cli.ListColumns,
+		Rows:    rows,
+	}
+
+	if len(columns) > 0 {
+		return cli.SelectColumns(output, columns)
+	}
+	return output, nil
+}
+
+// renderGroups prints one titled table per project for table output, and a
+// single listing with a leading PROJECT column for json and raw.
+// Named projects sort first; workspaces without one come last.
+func renderGroups(workspaces []*workspace.Workspace, activity map[string]time.Time, columns []string, wide, withRepos bool, format string, w io.Writer) error {
+	byProject := make(map[string][]*workspace.Workspace)
+	var names []string
+	for _, ws := range workspaces {
+		if _, ok := byProject[ws.Project]; !ok {
+			names = append(names, ws.Project)
+		}
+		byProject[ws.Project] = append(byProject[ws.Project], ws)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == "" || names[j] == "" {
+			return names[j] == ""
+		}
+		return names[i] < names[j]
+	})
+
+	if format == "table" {
+		for i, name := range names {
+			output, err := listOutput(byProject[name], activity, columns)
+			if err != nil {
+				return err
+			}
+			output.Wide = wide
+			if i > 0 {
+				_, _ = fmt.Fprintln(w)
+			}
+			if name == "" {
+				name = noProject
+			}
+			_, _ = fmt.Fprintln(w, name)
+			if err := cli.Render(output, format, w); err != nil {
+				return fmt.Errorf("failed to render output: %w", err)
+			}
+		}
+		return nil
+	}
+
+	grouped := cli.Output{Wide: wide}
+	var ordered []*workspace.Workspace
+	for _, name := range names {
+		ordered = append(ordered, byProject[name]...)
+		output, err := listOutput(byProject[name], activity, columns)
+		if err != nil {
+			return err
+		}
+		if grouped.Columns == nil {
+			grouped.Columns = append([]cli.ColumnConfig{projectColumn}, output.Columns...)
+		}
+		for _, row := range output.Rows {
+			grouped.Rows = append(grouped.Rows, append([]string{name}, row...))
+		}
+	}
+	if err := render(grouped, ordered, withRepos, format, w); err != nil {
+		return fmt.Errorf("failed to render output: %w", err)
+	}
+	return nil
+}
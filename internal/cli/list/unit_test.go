@@ -18,6 +18,27 @@ func TestListCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("has --wide flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "wide") {
+			t.Error("list should have --wide flag")
+		}
+	})
+
+	t.Run("has --recent flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "recent") {
+			t.Error("list should have --recent flag")
+		}
+	})
+
+	t.Run("has --with-repos flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "with-repos") {
+			t.Error("list should have --with-repos flag")
+		}
+	})
+
 	t.Run("has --format flag", func(t *testing.T) {
 		cmd := Command()
 		if !flagExists(cmd, "format") {
@@ -25,6 +46,13 @@ func TestListCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("has --columns flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "columns") {
+			t.Error("list should have --columns flag")
+		}
+	})
+
 	t.Run("has --page flags", func(t *testing.T) {
 		cmd := Command()
 		if !flagExists(cmd, "page") {
@@ -34,4 +62,14 @@ func TestListCommand(t *testing.T) {
 			t.Error("list should have --page-size flag")
 		}
 	})
+
+	t.Run("has --project and --group-by flags", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "project") {
+			t.Error("list should have --project flag")
+		}
+		if !flagExists(cmd, "group-by") {
+			t.Error("list should have --group-by flag")
+		}
+	})
 }
@@ -0,0 +1,86 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/frodi/workshed/internal/cli"
+	fsutil "github.com/frodi/workshed/internal/fs"
+	"github.com/spf13/cobra"
+)
+
+const defaultLockfile = "workshed.lock"
+
+func Command() *cobra.Command {
+	var output string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "lock [<handle>]",
+		Short: "Write a lockfile of exact repository commits",
+		Long: `Write each repository's URL and current commit to a lockfile.
+
+Recreate the same state later with 'workshed create --lock <file>'.
+
+Examples:
+  workshed lock
+  workshed lock my-workspace --output workshed.lock
+  workshed lock --format json | jq '.repositories[].commit'
+  workshed lock --dry-run`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			lockfile, err := r.GetStore().Lock(ctx, handle)
+			if err != nil {
+				return fmt.Errorf("lock failed: %w", err)
+			}
+
+			if dryRun {
+				return cli.RenderPlan(cmd, []cli.PlanStep{
+					{Action: "write lockfile", Target: output, Detail: fmt.Sprintf("%d repositories", len(lockfile.Repositories))},
+				})
+			}
+
+			data, err := json.MarshalIndent(lockfile, "", "  ")
+			if err != nil {
+				return fmt.Errorf("marshaling lockfile: %w", err)
+			}
+
+			if err := fsutil.WriteJson(output, data); err != nil {
+				return fmt.Errorf("writing lockfile: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			switch format {
+			case "json":
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			case "raw":
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), output)
+			default:
+				return cli.RenderKeyValue(map[string]string{
+					"path":  output,
+					"repos": strconv.Itoa(len(lockfile.Repositories)),
+				}, "table", cmd.OutOrStdout())
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&output, "output", defaultLockfile, "Lockfile path")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
@@ -0,0 +1,35 @@
+package lock
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func flagExists(cmd *cobra.Command, name string) bool {
+	return cmd.Flags().Lookup(name) != nil
+}
+
+func TestLockCommand(t *testing.T) {
+	t.Run("has --output flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "output") {
+			t.Error("lock should have --output flag")
+		}
+	})
+
+	t.Run("has --format flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "format") {
+			t.Error("lock should have --format flag")
+		}
+	})
+
+	t.Run("output defaults to workshed.lock", func(t *testing.T) {
+		cmd := Command()
+		flag := cmd.Flags().Lookup("output")
+		if flag.DefValue != "workshed.lock" {
+			t.Errorf("output default should be workshed.lock, got: %s", flag.DefValue)
+		}
+	})
+}
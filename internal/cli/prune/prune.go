@@ -0,0 +1,165 @@
+package prune
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+// defaultInactiveFor is how long an empty workspace must go unused before
+// prune --empty removes it.
+const defaultInactiveFor = 7 * 24 * time.Hour
+
+// Candidate is an empty workspace that prune would remove.
+type Candidate struct {
+	Handle       string
+	Purpose      string
+	LastActivity time.Time
+}
+
+func Command() *cobra.Command {
+	var empty bool
+	var inactiveFor time.Duration
+	var yes bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "prune --empty",
+		Short: "Remove empty, unused workspaces",
+		Long: `Remove workspaces that have no repositories, no captures and no activity
+within --inactive-for (7 days by default).
+
+Such workspaces are left behind when every repository was removed and nothing
+was ever captured. Anything with a repository or a capture is always kept.
+
+Examples:
+  workshed prune --empty --dry-run
+  workshed prune --empty --yes
+  workshed prune --empty --inactive-for 24h`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+
+			if !empty {
+				return fmt.Errorf("missing required flag: --empty")
+			}
+			if inactiveFor < 0 {
+				return fmt.Errorf("--inactive-for must not be negative")
+			}
+
+			ctx := context.Background()
+			candidates, err := EmptyWorkspaces(ctx, r.GetStore(), inactiveFor, time.Now())
+			if err != nil {
+				return err
+			}
+
+			if dryRun {
+				steps := make([]cli.PlanStep, len(candidates))
+				for i, c := range candidates {
+					steps[i] = cli.PlanStep{Action: "remove workspace", Target: c.Handle, Detail: c.Purpose}
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if len(candidates) == 0 {
+				return cli.RenderEmptyList(format, "no empty workspaces found", cmd.OutOrStdout(), r.GetLogger())
+			}
+
+			if !yes {
+				if !term.IsTerminal(os.Stdin.Fd()) {
+					return fmt.Errorf("stdin is not a tty, cannot prompt: use --yes to remove %d empty workspace(s)", len(candidates))
+				}
+				for _, c := range candidates {
+					_, _ = fmt.Fprintf(cmd.OutOrStdout(), "  %s (%s)\n", c.Handle, c.Purpose)
+				}
+				prompt := fmt.Sprintf("Remove %d empty workspace(s)? [y/N]: ", len(candidates))
+				if _, err := fmt.Fprint(cmd.OutOrStdout(), prompt); err != nil {
+					return fmt.Errorf("failed to write prompt: %w", err)
+				}
+				response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil {
+					return fmt.Errorf("failed to read user input: %w", err)
+				}
+				response = strings.TrimSpace(strings.ToLower(response))
+				if response != "y" && response != "yes" {
+					r.GetLogger().Info("operation cancelled")
+					return nil
+				}
+			}
+
+			var rows [][]string
+			for _, c := range candidates {
+				if err := r.GetStore().Remove(ctx, c.Handle); err != nil {
+					return fmt.Errorf("failed to remove workspace %s: %w", c.Handle, err)
+				}
+				rows = append(rows, []string{c.Handle, c.Purpose, cli.RelativeTime(c.LastActivity, time.Now())})
+			}
+
+			output := cli.Output{
+				Columns: []cli.ColumnConfig{
+					{Type: cli.Rigid, Name: "REMOVED", Min: 15, Max: 25},
+					{Type: cli.Shrinkable, Name: "PURPOSE", Min: 10, Max: 0},
+					{Type: cli.Rigid, Name: "LAST ACTIVE", Min: 10, Max: 15},
+				},
+				Rows: rows,
+			}
+			if err := cli.Render(output, format, cmd.OutOrStdout()); err != nil {
+				return fmt.Errorf("failed to render output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&empty, "empty", false, "Remove workspaces with no repositories and no captures")
+	cmd.Flags().DurationVar(&inactiveFor, "inactive-for", defaultInactiveFor, "Only remove workspaces with no activity for this long")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
+
+// EmptyWorkspaces returns the workspaces in store with no repositories and no
+// captures whose last activity is at least inactiveFor before now.
+func EmptyWorkspaces(ctx context.Context, store workspace.Store, inactiveFor time.Duration, now time.Time) ([]Candidate, error) {
+	workspaces, err := store.List(ctx, workspace.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing workspaces: %w", err)
+	}
+
+	var candidates []Candidate
+	for _, listed := range workspaces {
+		ws, err := store.Get(ctx, listed.Handle)
+		if err != nil {
+			return nil, fmt.Errorf("reading workspace %s: %w", listed.Handle, err)
+		}
+		if len(ws.Repositories) > 0 {
+			continue
+		}
+		captures, err := store.ListCaptures(ctx, ws.Handle)
+		if err != nil {
+			return nil, fmt.Errorf("listing captures of %s: %w", ws.Handle, err)
+		}
+		if len(captures) > 0 {
+			continue
+		}
+		last, err := store.LastActivity(ctx, ws.Handle)
+		if err != nil {
+			return nil, fmt.Errorf("reading activity of %s: %w", ws.Handle, err)
+		}
+		if now.Sub(last) < inactiveFor {
+			continue
+		}
+		candidates = append(candidates, Candidate{Handle: ws.Handle, Purpose: ws.Purpose, LastActivity: last})
+	}
+	return candidates, nil
+}
@@ -0,0 +1,20 @@
+package prune
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func flagExists(cmd *cobra.Command, name string) bool {
+	return cmd.Flags().Lookup(name) != nil
+}
+
+func TestPruneCommand(t *testing.T) {
+	cmd := Command()
+	for _, flag := range []string{"empty", "inactive-for", "yes", "dry-run", "format"} {
+		if !flagExists(cmd, flag) {
+			t.Errorf("prune should have --%s flag", flag)
+		}
+	}
+}
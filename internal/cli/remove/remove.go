@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/x/term"
@@ -12,23 +13,39 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// envRequireConfirm makes --confirm-handle mandatory for every removal when set to a true value.
+const envRequireConfirm = "WORKSHED_REQUIRE_CONFIRM"
+
 func Command() *cobra.Command {
 	var yes bool
 	var dryRun bool
+	var confirmHandle string
+	var requireConfirm bool
+	var trash bool
 
 	cmd := &cobra.Command{
 		Use:   "remove [<handle>]",
 		Short: "Remove a workspace",
 		Long: `Delete a workspace and all its repositories.
 
+With --trash the workspace is moved into the trash instead, where
+"workshed trash restore" can bring it back. Set "trash: true" in the config
+file (or WORKSHED_TRASH=1) to make that the default.
+
 Examples:
   workshed remove
   workshed remove my-workspace
   workshed remove -y
-  workshed remove --dry-run`,
+  workshed remove my-workspace --confirm-handle my-workspace
+  workshed remove --dry-run
+  workshed remove my-workspace --trash
+
+Set WORKSHED_REQUIRE_CONFIRM=1 (or pass --require-confirm) to refuse removal
+unless --confirm-handle repeats the workspace handle.`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r := cli.NewRunner("")
+			r.DryRun = dryRun
 
 			ctx := context.Background()
 			providedHandle, _ := cli.ExtractHandleFromArgs(args)
@@ -43,11 +60,27 @@ Examples:
 			}
 
 			if dryRun {
-				r.GetLogger().Info("dry run - would remove workspace", "handle", handle, "purpose", ws.Purpose)
+				action := "remove workspace"
+				if trash {
+					action = "move to trash"
+				}
+				steps := []cli.PlanStep{{Action: action, Target: handle, Detail: ws.Path}}
 				for _, repo := range ws.Repositories {
-					r.GetLogger().Info("  - repository", "name", repo.Name)
+					steps = append(steps, cli.PlanStep{Action: "delete repository", Target: repo.Name, Detail: repo.URL})
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
+			if requireConfirm || requireConfirmFromEnv() {
+				if confirmHandle == "" {
+					return fmt.Errorf("--confirm-handle is required: repeat the workspace handle %q to remove it", ws.Handle)
 				}
-				return nil
+			}
+			if confirmHandle != "" {
+				if confirmHandle != ws.Handle {
+					return fmt.Errorf("confirmation handle %q does not match workspace %q", confirmHandle, ws.Handle)
+				}
+				yes = true
 			}
 
 			if !yes {
@@ -75,6 +108,14 @@ Examples:
 				}
 			}
 
+			if trash {
+				if _, err := r.GetStore().TrashWorkspace(ctx, handle); err != nil {
+					return fmt.Errorf("failed to move workspace to trash: %w", err)
+				}
+				r.GetLogger().Success("workspace moved to trash", "handle", handle, "restore", "workshed trash restore "+handle)
+				return nil
+			}
+
 			if err := r.GetStore().Remove(ctx, handle); err != nil {
 				return fmt.Errorf("failed to remove workspace: %w", err)
 			}
@@ -85,7 +126,15 @@ Examples:
 	}
 
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation")
-	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be removed")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().StringVar(&confirmHandle, "confirm-handle", "", "Repeat the workspace handle to confirm removal")
+	cmd.Flags().BoolVar(&trash, "trash", false, "Move the workspace to the trash instead of deleting it")
+	cmd.Flags().BoolVar(&requireConfirm, "require-confirm", false, "Refuse removal unless --confirm-handle matches")
 
 	return cmd
 }
+
+func requireConfirmFromEnv() bool {
+	enabled, err := strconv.ParseBool(os.Getenv(envRequireConfirm))
+	return err == nil && enabled
+}
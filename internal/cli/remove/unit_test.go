@@ -18,6 +18,12 @@ func TestRemoveCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("has --trash flag", func(t *testing.T) {
+		if !flagExists(Command(), "trash") {
+			t.Error("remove should have --trash flag")
+		}
+	})
+
 	t.Run("-y is shorthand for --yes", func(t *testing.T) {
 		cmd := Command()
 		flag := cmd.Flags().Lookup("yes")
@@ -34,4 +40,18 @@ func TestRemoveCommand(t *testing.T) {
 			t.Error("remove should have --dry-run flag")
 		}
 	})
+
+	t.Run("has --confirm-handle flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "confirm-handle") {
+			t.Error("remove should have --confirm-handle flag")
+		}
+	})
+
+	t.Run("has --require-confirm flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "require-confirm") {
+			t.Error("remove should have --require-confirm flag")
+		}
+	})
 }
@@ -0,0 +1,75 @@
+package rename
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "rename [<handle>] <new-handle>",
+		Short: "Change a workspace's handle",
+		Long: `Give a workspace a handle of your choosing. The workspace directory is moved
+to match, so 'workshed path' and running commands from inside the workspace
+keep working under the new name.
+
+The new handle may use letters, digits, '-', '_' and '.', and must not be
+taken by another workspace. On any error the workspace is left as it was.
+
+Examples:
+  workshed rename payments-timeout
+  workshed rename aquatic-fish-motion payments-timeout
+  workshed rename aquatic-fish-motion payments-timeout --dry-run`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			providedHandle, newHandle := "", args[0]
+			if len(args) == 2 {
+				providedHandle, newHandle = args[0], args[1]
+			}
+
+			ctx := context.Background()
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if dryRun {
+				if err := workspace.ValidateHandle(newHandle); err != nil {
+					return err
+				}
+				if _, err := r.GetStore().Get(ctx, newHandle); err == nil {
+					return fmt.Errorf("workspace already exists: %s", newHandle)
+				}
+				return cli.RenderPlan(cmd, []cli.PlanStep{
+					{Action: "rename workspace", Target: handle, Detail: "to " + newHandle},
+				})
+			}
+
+			ws, err := r.GetStore().RenameWorkspace(ctx, handle, newHandle)
+			if err != nil {
+				return fmt.Errorf("failed to rename workspace: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			return cli.RenderKeyValue(map[string]string{
+				"handle":   ws.Handle,
+				"previous": handle,
+				"path":     ws.Path,
+			}, format, cmd.OutOrStdout())
+		},
+	}
+
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
@@ -0,0 +1,26 @@
+package rename
+
+import (
+	"testing"
+)
+
+func TestRenameCommand(t *testing.T) {
+	t.Run("has --dry-run and --format flags", func(t *testing.T) {
+		cmd := Command()
+		for _, f := range []string{"dry-run", "format"} {
+			if cmd.Flags().Lookup(f) == nil {
+				t.Errorf("rename should have --%s flag", f)
+			}
+		}
+	})
+
+	t.Run("requires the new handle", func(t *testing.T) {
+		cmd := Command()
+		if err := cmd.Args(cmd, []string{}); err == nil {
+			t.Error("rename without arguments should be rejected")
+		}
+		if err := cmd.Args(cmd, []string{"a", "b", "c"}); err == nil {
+			t.Error("rename with three arguments should be rejected")
+		}
+	})
+}
@@ -15,6 +15,13 @@ func AddCommand() *cobra.Command {
 	var repos []string
 	var reposAlias []string
 	var depth int
+	var verbose bool
+	var host string
+	var sparse []string
+	var remotes []string
+	var repoSetups []string
+	var lfs bool
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "add [<handle>] --repo url[@ref][::depth]...",
@@ -25,10 +32,22 @@ Examples:
   workshed repos add --repo github.com/org/repo@main
   workshed repos add -r github.com/org/repo1 -r github.com/org/repo2
   workshed repos add --repo github.com/org/large-repo::10
-  workshed repos add my-workspace --repo ./local-lib`,
+  workshed repos add --repo github.com/org/monorepo --sparse services/api --sparse libs/common
+  workshed repos add --repo github.com/me/tool --remote upstream=github.com/org/tool
+  workshed repos add my-workspace --repo ./local-lib
+  workshed repos add --repo org/repo@main
+  workshed repos add --repo github.com/org/private --verbose
+  workshed repos add --repo github.com/org/api --repo-setup "api=make deps"
+  workshed repos add --repo github.com/org/game-assets --lfs
+  workshed repos add --repo github.com/org/api --dry-run`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			r := cli.NewRunner("")
+			cwd, err := cli.InvocationDir(cmd)
+			if err != nil {
+				return err
+			}
+			r := cli.NewRunner(cwd)
+			r.DryRun = dryRun
 
 			repos = append(repos, reposAlias...)
 
@@ -50,24 +69,77 @@ Examples:
 					continue
 				}
 				url, ref, repoDepth := workspace.ParseRepoFlag(repo)
+				url = workspace.ExpandRepoShorthand(url, host, r.GetInvocationCWD())
 				d := depth
 				if repoDepth > 0 {
 					d = repoDepth
 				}
 				repoOpts = append(repoOpts, workspace.RepositoryOption{
-					URL:   url,
-					Ref:   ref,
-					Depth: d,
+					URL:    url,
+					Ref:    ref,
+					Depth:  d,
+					Sparse: sparse,
+					LFS:    lfs,
 				})
 			}
 
+			if len(remotes) > 0 {
+				remoteMap, err := workspace.ParseRemoteFlags(remotes)
+				if err != nil {
+					return err
+				}
+				if len(repoOpts) != 1 {
+					return fmt.Errorf("--remote requires exactly one --repo")
+				}
+				repoOpts[0].Remotes = remoteMap
+			}
+
+			if len(repoSetups) > 0 {
+				setups, err := workspace.ParseSetupFlags(repoSetups)
+				if err != nil {
+					return err
+				}
+				if err := workspace.AssignPostClone(repoOpts, setups, r.GetInvocationCWD()); err != nil {
+					return err
+				}
+			}
+
+			if dryRun {
+				if _, err := r.GetStore().Get(ctx, handle); err != nil {
+					return fmt.Errorf("failed to read workspace: %w", err)
+				}
+				steps := make([]cli.PlanStep, len(repoOpts))
+				for i, opt := range repoOpts {
+					steps[i] = cli.CloneStep(opt)
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
 			addCtx, cancel := context.WithTimeout(ctx, defaultCloneTimeout*time.Duration(len(repoOpts)+1))
 			defer cancel()
 
+			before, err := r.GetStore().Get(ctx, handle)
+			if err != nil {
+				return fmt.Errorf("failed to read workspace: %w", err)
+			}
+
 			if err := r.GetStore().AddRepositories(addCtx, handle, repoOpts, r.GetInvocationCWD()); err != nil {
+				if verbose {
+					cli.WriteGitDetails(cmd.ErrOrStderr(), err)
+				}
 				return fmt.Errorf("failed to add repository: %w", err)
 			}
 
+			if ws, err := r.GetStore().Get(ctx, handle); err == nil {
+				var added []string
+				for _, repo := range ws.Repositories {
+					if before.GetRepositoryByName(repo.Name) == nil {
+						added = append(added, repo.Name)
+					}
+				}
+				cli.WarnLFS(ctx, cmd.ErrOrStderr(), ws, added)
+			}
+
 			format := cmd.Flags().Lookup("format").Value.String()
 			if format == "raw" {
 				for _, opt := range repoOpts {
@@ -91,7 +163,14 @@ Examples:
 
 	cmd.Flags().StringSliceVarP(&repos, "repo", "r", nil, "Repository URL with optional @ref and ::depth")
 	cmd.Flags().StringSliceVar(&reposAlias, "repos", nil, "Alias for --repo (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&remotes, "remote", nil, "Additional remote as name=url, e.g. upstream=github.com/org/repo (can be specified multiple times)")
+	cmd.Flags().StringSliceVar(&sparse, "sparse", nil, "Only check out these directories (sparse checkout)")
+	cmd.Flags().BoolVar(&lfs, "lfs", false, "Pull Git LFS files after cloning")
+	cmd.Flags().StringArrayVar(&repoSetups, "repo-setup", nil, "Command to run in a repository after cloning, as name=command (can be specified multiple times)")
 	cmd.Flags().IntVar(&depth, "depth", 0, "Default clone depth (overridden by ::depth in repo URL)")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Print full git output on failure")
+	cmd.Flags().StringVar(&host, "host", "", "Host for owner/repo shorthand (default: $WORKSHED_DEFAULT_HOST or github.com)")
+	cli.AddDryRunFlag(cmd, &dryRun)
 	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
 	_ = cmd.MarkFlagRequired("repo")
 
@@ -0,0 +1,146 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func ApplyCommand() *cobra.Command {
+	var manifest string
+	var host string
+	var verbose bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "apply [<handle>] --manifest <file>",
+		Short: "Make a workspace's repositories match a manifest",
+		Long: `Make a workspace's repositories match a manifest.
+
+The manifest lists one repository per line using the --repo syntax
+(url[@ref][::depth]), optionally followed by a setup command to run in the
+repository after it is cloned; blank lines and # comments are ignored. Missing
+repositories are cloned, repositories not listed are removed, and listed
+repositories whose ref changed are checked out at the new ref. If any step
+fails, every change is rolled back and the workspace is left as it was.
+
+Examples:
+  workshed repos apply --manifest repos.txt
+  workshed repos apply my-workspace --manifest repos.txt --format json
+  workshed repos apply --manifest repos.txt --dry-run`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := cli.InvocationDir(cmd)
+			if err != nil {
+				return err
+			}
+			r := cli.NewRunner(cwd)
+			r.DryRun = dryRun
+
+			if manifest == "" {
+				return fmt.Errorf("missing required flag: --manifest")
+			}
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			f, err := os.Open(manifest)
+			if err != nil {
+				return fmt.Errorf("failed to open manifest: %w", err)
+			}
+			desired, err := workspace.ParseManifest(f)
+			_ = f.Close()
+			if err != nil {
+				return fmt.Errorf("failed to parse manifest: %w", err)
+			}
+			if len(desired) == 0 {
+				return fmt.Errorf("manifest %s lists no repositories", manifest)
+			}
+			for i := range desired {
+				desired[i].URL = workspace.ExpandRepoShorthand(desired[i].URL, host, r.GetInvocationCWD())
+			}
+
+			if dryRun {
+				plan, err := r.GetStore().PlanReconcile(ctx, handle, desired, r.GetInvocationCWD())
+				if err != nil {
+					return fmt.Errorf("failed to apply manifest: %w", err)
+				}
+				ws, err := r.GetStore().Get(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to read workspace: %w", err)
+				}
+				var steps []cli.PlanStep
+				for _, repo := range plan.Add {
+					steps = append(steps, cli.CloneStep(workspace.RepositoryOption{URL: repo.URL, Ref: repo.Ref, Depth: repo.Depth, PostClone: repo.PostClone}))
+				}
+				for _, repo := range ws.Repositories {
+					if ref, ok := plan.Checkout[repo.Name]; ok {
+						steps = append(steps, cli.PlanStep{Action: "checkout", Target: repo.Name, Detail: ref})
+					}
+				}
+				for _, repo := range plan.Remove {
+					steps = append(steps, cli.PlanStep{Action: "delete repository", Target: repo.Name, Detail: filepath.Join(ws.Path, repo.Name)})
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
+			applyCtx, cancel := context.WithTimeout(ctx, defaultCloneTimeout*time.Duration(len(desired)+1))
+			defer cancel()
+
+			result, err := r.GetStore().ReconcileRepositories(applyCtx, handle, desired, r.GetInvocationCWD())
+			if err != nil {
+				if verbose {
+					cli.WriteGitDetails(cmd.ErrOrStderr(), err)
+				}
+				return fmt.Errorf("failed to apply manifest: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if result.Empty() {
+				return cli.RenderEmptyList(format, "repositories already match manifest", cmd.OutOrStdout(), r.GetLogger())
+			}
+
+			var rows [][]string
+			for _, name := range result.Added {
+				rows = append(rows, []string{"added", name})
+			}
+			for _, name := range result.Updated {
+				rows = append(rows, []string{"updated", name})
+			}
+			for _, name := range result.Removed {
+				rows = append(rows, []string{"removed", name})
+			}
+
+			output := cli.Output{
+				Columns: []cli.ColumnConfig{
+					{Type: cli.Rigid, Name: "CHANGE", Min: 7, Max: 7},
+					{Type: cli.Shrinkable, Name: "REPO", Min: 15, Max: 0},
+				},
+				Rows: rows,
+			}
+			if err := cli.Render(output, format, cmd.OutOrStdout()); err != nil {
+				return fmt.Errorf("failed to render output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&manifest, "manifest", "", "File listing the desired repositories, one url[@ref][::depth] per line")
+	cmd.Flags().StringVar(&host, "host", "", "Host for owner/repo shorthand (default: $WORKSHED_DEFAULT_HOST or github.com)")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Print full git output on failure")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+	_ = cmd.MarkFlagRequired("manifest")
+
+	return cmd
+}
@@ -0,0 +1,89 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+func CheckoutCommand() *cobra.Command {
+	var repo string
+	var ref string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "checkout [<handle>] --repo <name> [--ref <ref>]",
+		Short: "Check out a ref in a repository",
+		Long: `Check out a ref in a repository and record it in the workspace.
+
+Use this to materialize a working tree for a repository created with
+--no-checkout. Without --ref the recorded ref is checked out.
+
+Examples:
+  workshed repos checkout --repo my-repo
+  workshed repos checkout my-workspace --repo my-repo --ref release-1.2
+  workshed repos checkout --repo my-repo --dry-run`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			if repo == "" {
+				return fmt.Errorf("missing required flag: --repo")
+			}
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if dryRun {
+				ws, err := r.GetStore().Get(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to read workspace: %w", err)
+				}
+				target := ws.GetRepositoryByName(repo)
+				if target == nil {
+					return fmt.Errorf("repository not found: %s", repo)
+				}
+				detail := ref
+				if detail == "" {
+					detail = target.Ref
+				}
+				if detail == "" {
+					detail = "current branch"
+				}
+				steps := []cli.PlanStep{{Action: "checkout", Target: repo, Detail: detail}}
+				if target.LFS {
+					steps = append(steps, cli.PlanStep{Action: "pull lfs objects", Target: repo})
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
+			if err := r.GetStore().CheckoutRepository(ctx, handle, repo, ref); err != nil {
+				return fmt.Errorf("failed to check out repository: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if format == "raw" {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), repo)
+				return nil
+			}
+
+			r.GetLogger().Success("repository checked out", "handle", handle, "repo", repo)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repo, "repo", "", "Repository name to check out")
+	cmd.Flags().StringVar(&ref, "ref", "", "Ref to check out (default: the recorded ref)")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+	_ = cmd.MarkFlagRequired("repo")
+
+	return cmd
+}
@@ -0,0 +1,108 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func CloneMissingCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "clone-missing [<handle>]",
+		Short: "Re-clone repositories whose directories are missing",
+		Long: `Re-clone repositories whose directories are missing from a workspace.
+
+Each repository recorded in the workspace metadata but absent on disk is cloned
+again from its recorded URL, ref and depth. Repositories that are present are
+left untouched. This repairs the "missing repository" issue reported by health.
+
+Examples:
+  workshed repos clone-missing
+  workshed repos clone-missing my-workspace
+  workshed repos clone-missing --dry-run`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if dryRun {
+				ws, err := r.GetStore().Get(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to read workspace: %w", err)
+				}
+				var steps []cli.PlanStep
+				for _, repo := range ws.Repositories {
+					if _, err := os.Stat(filepath.Join(ws.Path, repo.Name)); !os.IsNotExist(err) {
+						continue
+					}
+					steps = append(steps, cli.CloneStep(workspace.RepositoryOption{
+						URL:        repo.URL,
+						Ref:        repo.Ref,
+						Depth:      repo.Depth,
+						NoCheckout: repo.NoCheckout,
+						Sparse:     repo.Sparse,
+						LFS:        repo.LFS,
+					}))
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
+			results, err := r.GetStore().CloneMissingRepositories(ctx, handle)
+			if err != nil {
+				return fmt.Errorf("clone-missing failed: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if len(results) == 0 {
+				return cli.RenderEmptyList(format, "no missing repositories", cmd.OutOrStdout(), r.GetLogger())
+			}
+
+			failed := 0
+			var rows [][]string
+			for _, result := range results {
+				status := "restored"
+				if result.Err != nil {
+					failed++
+					status = result.Err.Error()
+				}
+				rows = append(rows, []string{result.Repository, status})
+			}
+
+			output := cli.Output{
+				Columns: []cli.ColumnConfig{
+					{Type: cli.Rigid, Name: "REPO", Min: 15, Max: 30},
+					{Type: cli.Shrinkable, Name: "STATUS", Min: 10, Max: 0},
+				},
+				Rows: rows,
+			}
+
+			if err := cli.Render(output, format, cmd.OutOrStdout()); err != nil {
+				return fmt.Errorf("failed to render output: %w", err)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("clone failed for %d of %d missing repositories", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
@@ -0,0 +1,137 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/oklog/ulid/v2"
+	"github.com/spf13/cobra"
+)
+
+func ExecCommand() *cobra.Command {
+	var envVars []string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "exec [<handle>] <repo> -- <command> [args...]",
+		Short: "Run a command in one repository",
+		Long: `Run a command in a single, named repository.
+
+Unlike exec, the repository is required: a missing or mistyped name is an
+error listing the workspace's repositories, never a run in all of them.
+Globs and comma-separated lists are not accepted; use exec --repo for those.
+
+--timeout stops the command once it has run for that long. The global --cwd
+flag applies as it does to every command.
+
+Examples:
+  workshed repos exec api -- go test ./...
+  workshed repos exec my-workspace web -- npm run lint
+  workshed repos exec api --env GOFLAGS=-count=1 --timeout 5m -- make test`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+
+			sepIdx := cmd.ArgsLenAtDash()
+			if sepIdx == -1 || sepIdx == len(args) {
+				return fmt.Errorf("missing command to run: use -- <command>")
+			}
+			command := args[sepIdx:]
+
+			var providedHandle, repo string
+			switch positional := args[:sepIdx]; len(positional) {
+			case 0:
+				return fmt.Errorf("missing repository: use repos exec [<handle>] <repo> -- <command>")
+			case 1:
+				repo = positional[0]
+			case 2:
+				providedHandle, repo = positional[0], positional[1]
+			default:
+				return fmt.Errorf("too many arguments before --: expected [<handle>] <repo>")
+			}
+			if strings.ContainsAny(repo, "*?[,") || repo == "all" || repo == "root" {
+				return fmt.Errorf("repos exec runs in a single repository, got %q: use exec --repo for patterns", repo)
+			}
+
+			for _, kv := range envVars {
+				if key, _, ok := strings.Cut(kv, "="); !ok || key == "" {
+					return fmt.Errorf("invalid --env value %q: expected KEY=VALUE", kv)
+				}
+			}
+			if timeout < 0 {
+				return fmt.Errorf("invalid --timeout %s: must not be negative", timeout)
+			}
+
+			ctx := context.Background()
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			startedAt := time.Now()
+			results, err := r.GetStore().Exec(ctx, handle, workspace.ExecOptions{Target: repo, Command: command, Env: envVars})
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				err = fmt.Errorf("command timed out after %s", timeout)
+			}
+			if err != nil && len(results) == 0 {
+				return fmt.Errorf("exec failed: %w", err)
+			}
+
+			for _, result := range results {
+				if _, werr := cmd.OutOrStdout().Write(result.Output); werr != nil {
+					r.GetLogger().Error("failed to write output", "error", werr)
+				}
+			}
+
+			exitCode := 0
+			repoResults := make([]workspace.ExecutionRepoResult, 0, len(results))
+			for _, result := range results {
+				exitCode = max(exitCode, result.ExitCode)
+				repoResults = append(repoResults, workspace.ExecutionRepoResult{
+					Repository: result.Repository,
+					ExitCode:   result.ExitCode,
+					Signal:     result.Signal,
+					Duration:   result.Duration.Milliseconds(),
+				})
+			}
+			record := workspace.ExecutionRecord{
+				ID:          ulid.Make().String(),
+				Timestamp:   startedAt,
+				Handle:      handle,
+				Target:      repo,
+				Command:     command,
+				ExitCode:    exitCode,
+				StartedAt:   startedAt,
+				CompletedAt: time.Now(),
+				Duration:    time.Since(startedAt).Milliseconds(),
+				Results:     repoResults,
+			}
+			if rerr := r.GetStore().RecordExecution(context.Background(), handle, record, nil); rerr != nil {
+				r.GetLogger().Debug("failed to record execution", "error", rerr)
+			}
+
+			if err != nil {
+				// The output is already on stdout; keep usage out of it.
+				cmd.SilenceUsage = true
+				return fmt.Errorf("exec failed: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&envVars, "env", nil, "Set an environment variable for the command (KEY=VALUE, repeatable)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Stop the command after this long (e.g. 30s, 5m; 0 means no limit)")
+
+	return cmd
+}
@@ -0,0 +1,138 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func FetchCommand() *cobra.Command {
+	var repo string
+	var prune bool
+	var remote string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "fetch [<handle>]",
+		Short: "Fetch remote refs for repositories in a workspace",
+		Long: `Fetch remote refs for repositories in a workspace without changing working trees.
+
+Reports how many refs were created, updated, and deleted per repository.
+
+Examples:
+  workshed repos fetch
+  workshed repos fetch --prune
+  workshed repos fetch my-workspace --repo api
+  workshed repos fetch --repo tool --remote upstream
+  workshed repos fetch --prune --dry-run`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if dryRun {
+				return planFetch(cmd, r, handle, repo, remote, prune)
+			}
+
+			results, err := r.GetStore().FetchRepositories(ctx, handle, workspace.FetchOptions{
+				Target: repo,
+				Prune:  prune,
+				Remote: remote,
+			})
+			if err != nil {
+				return fmt.Errorf("fetch failed: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if len(results) == 0 {
+				return cli.RenderEmptyList(format, "no repositories in workspace", cmd.OutOrStdout(), r.GetLogger())
+			}
+
+			failed := 0
+			var rows [][]string
+			for _, result := range results {
+				status := "ok"
+				if result.Err != nil {
+					failed++
+					status = result.Err.Error()
+				}
+				rows = append(rows, []string{
+					result.Repository,
+					strconv.Itoa(result.Summary.New),
+					strconv.Itoa(result.Summary.Updated),
+					strconv.Itoa(result.Summary.Deleted),
+					status,
+				})
+			}
+
+			output := cli.Output{
+				Columns: []cli.ColumnConfig{
+					{Type: cli.Rigid, Name: "REPO", Min: 15, Max: 30},
+					{Type: cli.Rigid, Name: "NEW", Min: 3, Max: 7},
+					{Type: cli.Rigid, Name: "UPDATED", Min: 7, Max: 7},
+					{Type: cli.Rigid, Name: "DELETED", Min: 7, Max: 7},
+					{Type: cli.Shrinkable, Name: "STATUS", Min: 10, Max: 0},
+				},
+				Rows: rows,
+			}
+
+			if err := cli.Render(output, format, cmd.OutOrStdout()); err != nil {
+				return fmt.Errorf("failed to render output: %w", err)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("fetch failed for %d of %d repositories", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repo, "repo", "", "Repository name to fetch")
+	cmd.Flags().StringVar(&remote, "remote", "", "Fetch only this remote (default: all remotes)")
+	cmd.Flags().BoolVar(&prune, "prune", false, "Remove remote-tracking refs deleted upstream")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
+
+// planFetch prints the repositories a fetch would touch without contacting
+// any remote.
+func planFetch(cmd *cobra.Command, r *cli.Runner, handle, repo, remote string, prune bool) error {
+	ws, err := r.GetStore().Get(context.Background(), handle)
+	if err != nil {
+		return fmt.Errorf("failed to read workspace: %w", err)
+	}
+	repos := ws.Repositories
+	if repo != "" {
+		target := ws.GetRepositoryByName(repo)
+		if target == nil {
+			return fmt.Errorf("repository not found: %s", repo)
+		}
+		repos = []workspace.Repository{*target}
+	}
+
+	detail := "all remotes"
+	if remote != "" {
+		detail = "remote " + remote
+	}
+	if prune {
+		detail += ", prune"
+	}
+	steps := make([]cli.PlanStep, len(repos))
+	for i, target := range repos {
+		steps[i] = cli.PlanStep{Action: "fetch", Target: target.Name, Detail: detail}
+	}
+	return cli.RenderPlan(cmd, steps)
+}
@@ -9,6 +9,8 @@ import (
 )
 
 func ListCommand() *cobra.Command {
+	var withStatus bool
+
 	cmd := &cobra.Command{
 		Use:   "list [<handle>]",
 		Short: "List repositories in a workspace",
@@ -16,7 +18,8 @@ func ListCommand() *cobra.Command {
 
 Examples:
   workshed repos list
-  workshed repos list my-workspace`,
+  workshed repos list my-workspace
+  workshed repos list --with-status`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r := cli.NewRunner("")
@@ -52,21 +55,34 @@ Examples:
 				if repo.Ref != "" {
 					refInfo = " @ " + repo.Ref
 				}
-				rows = append(rows, []string{repo.Name, repo.URL + refInfo})
+				rows = append(rows, []string{repo.Name, repo.Location(), repo.URL + refInfo})
 			}
 
 			output := cli.Output{
 				Columns: []cli.ColumnConfig{
 					{Type: cli.Rigid, Name: "NAME", Min: 15, Max: 30},
+					{Type: cli.Rigid, Name: "SOURCE", Min: 6, Max: 6},
 					{Type: cli.Shrinkable, Name: "URL", Min: 30, Max: 0},
 				},
 				Rows: rows,
 			}
 
+			if withStatus {
+				statuses, err := r.GetStore().RepositoryStatuses(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to read repository status: %w", err)
+				}
+				for i, status := range statuses {
+					output.Rows[i] = append(output.Rows[i], status.Summary())
+				}
+				output.Columns = append(output.Columns, cli.ColumnConfig{Type: cli.Rigid, Name: "STATUS", Min: 12, Max: 30})
+			}
+
 			return cli.Render(output, format, cmd.OutOrStdout())
 		},
 	}
 
+	cmd.Flags().BoolVar(&withStatus, "with-status", false, "Show dirty state and commits ahead/behind upstream")
 	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
 
 	return cmd
@@ -3,6 +3,7 @@ package repos
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 
 	"github.com/frodi/workshed/internal/cli"
 	"github.com/spf13/cobra"
@@ -24,6 +25,7 @@ Examples:
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r := cli.NewRunner("")
+			r.DryRun = dryRun
 
 			if repo == "" {
 				return fmt.Errorf("missing required flag: --repo")
@@ -37,8 +39,17 @@ Examples:
 			}
 
 			if dryRun {
-				r.GetLogger().Info("dry run - would remove repository", "handle", handle, "repo", repo)
-				return nil
+				ws, err := r.GetStore().Get(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to read workspace: %w", err)
+				}
+				target := ws.GetRepositoryByName(repo)
+				if target == nil {
+					return fmt.Errorf("repository not found: %s", repo)
+				}
+				return cli.RenderPlan(cmd, []cli.PlanStep{
+					{Action: "delete repository", Target: repo, Detail: filepath.Join(ws.Path, repo)},
+				})
 			}
 
 			if err := r.GetStore().RemoveRepository(ctx, handle, repo); err != nil {
@@ -57,7 +68,7 @@ Examples:
 	}
 
 	cmd.Flags().StringVar(&repo, "repo", "", "Repository name to remove")
-	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be removed")
+	cli.AddDryRunFlag(cmd, &dryRun)
 	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
 	_ = cmd.MarkFlagRequired("repo")
 
@@ -0,0 +1,93 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func RenameCommand() *cobra.Command {
+	var repo string
+	var to string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "rename [<handle>] --repo <name> --to <new-name>",
+		Short: "Rename a repository in a workspace",
+		Long: `Rename a repository in a workspace without re-cloning it.
+
+The repository directory is moved and recorded executions and captures are
+updated to use the new name.
+
+Examples:
+  workshed repos rename --repo api --to api-v2
+  workshed repos rename my-workspace --repo api --to api-v2
+  workshed repos rename --repo api --to api-v2 --dry-run`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			if repo == "" {
+				return fmt.Errorf("missing required flag: --repo")
+			}
+			if to == "" {
+				return fmt.Errorf("missing required flag: --to")
+			}
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if dryRun {
+				ws, err := r.GetStore().Get(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to read workspace: %w", err)
+				}
+				if ws.GetRepositoryByName(repo) == nil {
+					return fmt.Errorf("failed to rename repository: repository not found: %s", repo)
+				}
+				if err := workspace.ValidateRepoName(to); err != nil {
+					return fmt.Errorf("failed to rename repository: %w", err)
+				}
+				if to == repo {
+					return cli.RenderPlan(cmd, nil)
+				}
+				if ws.GetRepositoryByName(to) != nil {
+					return fmt.Errorf("failed to rename repository: repository already exists: %s", to)
+				}
+				return cli.RenderPlan(cmd, []cli.PlanStep{
+					{Action: "rename repository", Target: repo, Detail: "-> " + to},
+				})
+			}
+
+			if err := r.GetStore().RenameRepository(ctx, handle, repo, to); err != nil {
+				return fmt.Errorf("failed to rename repository: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if format == "raw" {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), to)
+				return nil
+			}
+
+			r.GetLogger().Success("repository renamed", "handle", handle, "from", repo, "to", to)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repo, "repo", "", "Repository name to rename")
+	cmd.Flags().StringVar(&to, "to", "", "New repository name")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+	_ = cmd.MarkFlagRequired("repo")
+	_ = cmd.MarkFlagRequired("to")
+
+	return cmd
+}
@@ -13,12 +13,26 @@ func Command() *cobra.Command {
 Examples:
   workshed repos list
   workshed repos add --repo github.com/org/repo@main
-  workshed repos remove --repo my-repo`,
+  workshed repos remove --repo my-repo
+  workshed repos rename --repo my-repo --to my-repo-v2
+  workshed repos fetch --prune
+  workshed repos unshallow --repo my-repo
+  workshed repos apply --manifest repos.txt
+  workshed repos checkout --repo my-repo
+  workshed repos clone-missing
+  workshed repos exec my-repo -- make test`,
 	}
 
 	cmd.AddCommand(ListCommand())
 	cmd.AddCommand(AddCommand())
 	cmd.AddCommand(RemoveCommand())
+	cmd.AddCommand(RenameCommand())
+	cmd.AddCommand(FetchCommand())
+	cmd.AddCommand(UnshallowCommand())
+	cmd.AddCommand(ApplyCommand())
+	cmd.AddCommand(CheckoutCommand())
+	cmd.AddCommand(CloneMissingCommand())
+	cmd.AddCommand(ExecCommand())
 
 	return cmd
 }
@@ -13,7 +13,7 @@ func flagExists(cmd *cobra.Command, name string) bool {
 func TestReposCommand(t *testing.T) {
 	t.Run("has subcommands", func(t *testing.T) {
 		cmd := Command()
-		subcommands := []string{"list", "add", "remove"}
+		subcommands := []string{"list", "add", "remove", "rename", "fetch", "unshallow", "apply", "checkout", "clone-missing", "exec"}
 		for _, sub := range subcommands {
 			found := false
 			for _, c := range cmd.Commands() {
@@ -41,6 +41,71 @@ func TestReposCommand(t *testing.T) {
 		t.Error("repos add subcommand not found")
 	})
 
+	t.Run("list has --with-status flag", func(t *testing.T) {
+		if !flagExists(ListCommand(), "with-status") {
+			t.Error("repos list should have --with-status flag")
+		}
+	})
+
+	t.Run("apply has --manifest flag", func(t *testing.T) {
+		if !flagExists(ApplyCommand(), "manifest") {
+			t.Error("repos apply should have --manifest flag")
+		}
+	})
+
+	t.Run("rename has --repo and --to flags", func(t *testing.T) {
+		cmd := RenameCommand()
+		if !flagExists(cmd, "repo") || !flagExists(cmd, "to") {
+			t.Error("repos rename should have --repo and --to flags")
+		}
+	})
+
+	t.Run("checkout has --repo and --ref flags", func(t *testing.T) {
+		cmd := CheckoutCommand()
+		if !flagExists(cmd, "repo") || !flagExists(cmd, "ref") {
+			t.Error("repos checkout should have --repo and --ref flags")
+		}
+	})
+
+	t.Run("add has --lfs flag", func(t *testing.T) {
+		if !flagExists(AddCommand(), "lfs") {
+			t.Error("repos add should have --lfs flag")
+		}
+	})
+
+	t.Run("add has --sparse flag", func(t *testing.T) {
+		if !flagExists(AddCommand(), "sparse") {
+			t.Error("repos add should have --sparse flag")
+		}
+	})
+
+	t.Run("add and fetch have --remote flag", func(t *testing.T) {
+		if !flagExists(AddCommand(), "remote") {
+			t.Error("repos add should have --remote flag")
+		}
+		if !flagExists(FetchCommand(), "remote") {
+			t.Error("repos fetch should have --remote flag")
+		}
+	})
+
+	t.Run("add has --repo-setup flag", func(t *testing.T) {
+		if !flagExists(AddCommand(), "repo-setup") {
+			t.Error("repos add should have --repo-setup flag")
+		}
+	})
+
+	t.Run("add has --host flag", func(t *testing.T) {
+		if !flagExists(AddCommand(), "host") {
+			t.Error("repos add should have --host flag")
+		}
+	})
+
+	t.Run("add has --verbose flag", func(t *testing.T) {
+		if !flagExists(AddCommand(), "verbose") {
+			t.Error("repos add should have --verbose flag")
+		}
+	})
+
 	t.Run("add has -r shorthand for --repo", func(t *testing.T) {
 		cmd := Command()
 		for _, c := range cmd.Commands() {
@@ -79,4 +144,32 @@ func TestReposCommand(t *testing.T) {
 		}
 		t.Error("repos add subcommand not found")
 	})
+
+	t.Run("fetch has --prune and --repo flags", func(t *testing.T) {
+		cmd := Command()
+		for _, c := range cmd.Commands() {
+			if c.Name() == "fetch" {
+				for _, f := range []string{"prune", "repo"} {
+					if !flagExists(c, f) {
+						t.Errorf("repos fetch should have --%s flag", f)
+					}
+				}
+				return
+			}
+		}
+		t.Error("repos fetch subcommand not found")
+	})
+
+	t.Run("unshallow has --repo flag", func(t *testing.T) {
+		if !flagExists(UnshallowCommand(), "repo") {
+			t.Error("repos unshallow should have --repo flag")
+		}
+	})
+
+	t.Run("exec has --env and --timeout flags", func(t *testing.T) {
+		cmd := ExecCommand()
+		if !flagExists(cmd, "env") || !flagExists(cmd, "timeout") {
+			t.Error("repos exec should have --env and --timeout flags")
+		}
+	})
 }
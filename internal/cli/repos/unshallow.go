@@ -0,0 +1,81 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func UnshallowCommand() *cobra.Command {
+	var repo string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "unshallow [<handle>] --repo <name>",
+		Short: "Fetch full history for a shallow-cloned repository",
+		Long: `Fetch full history for a repository that was cloned with --depth. A
+repository that is already a full clone is left as it is.
+
+Examples:
+  workshed repos unshallow --repo my-repo
+  workshed repos unshallow my-workspace --repo my-repo
+  workshed repos unshallow --repo my-repo --dry-run`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			if repo == "" {
+				return fmt.Errorf("missing required flag: --repo")
+			}
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if dryRun {
+				ws, err := r.GetStore().Get(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to read workspace: %w", err)
+				}
+				target := ws.GetRepositoryByName(repo)
+				if target == nil {
+					return fmt.Errorf("repository not found: %s", repo)
+				}
+				if !workspace.IsShallow(*target, filepath.Join(ws.Path, repo)) {
+					return cli.RenderPlan(cmd, nil)
+				}
+				return cli.RenderPlan(cmd, []cli.PlanStep{
+					{Action: "fetch full history", Target: repo, Detail: "git fetch --unshallow"},
+				})
+			}
+
+			if err := r.GetStore().UnshallowRepository(ctx, handle, repo); err != nil {
+				return fmt.Errorf("failed to unshallow repository: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if format == "raw" {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), repo)
+				return nil
+			}
+
+			r.GetLogger().Success("repository unshallowed", "handle", handle, "repo", repo)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repo, "repo", "", "Repository name to unshallow")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+	_ = cmd.MarkFlagRequired("repo")
+
+	return cmd
+}
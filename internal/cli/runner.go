@@ -2,14 +2,20 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/frodi/workshed/internal/git"
 	"github.com/frodi/workshed/internal/logger"
 	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
 )
 
 type Runner struct {
@@ -20,6 +26,12 @@ type Runner struct {
 	Store         workspace.Store
 	Logger        *logger.Logger
 	InvocationCWD string
+	// DryRun stops ResolveHandle from recording the workspace as recently
+	// used, so a --dry-run command writes nothing.
+	DryRun bool
+	// CompressCaptures stores captures taken through GetStore as
+	// capture.tar.gz archives.
+	CompressCaptures bool
 }
 
 func (r *Runner) GetInvocationCWD() string {
@@ -36,7 +48,30 @@ func NewRunner(invocationCWD string) *Runner {
 	}
 }
 
-func (r *Runner) getWorkshedRoot() string {
+// InvocationDir returns the directory given by the global --cwd flag, made
+// absolute, for resolving relative repository paths. It returns "" when the
+// flag is unset, which resolves against the process working directory.
+func InvocationDir(cmd *cobra.Command) (string, error) {
+	flag := cmd.Flags().Lookup("cwd")
+	if flag == nil || flag.Value.String() == "" {
+		return "", nil
+	}
+	dir, err := filepath.Abs(flag.Value.String())
+	if err != nil {
+		return "", fmt.Errorf("invalid --cwd: %w", err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid --cwd: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("invalid --cwd: %s is not a directory", dir)
+	}
+	return dir, nil
+}
+
+// GetWorkshedRoot returns the store root: $WORKSHED_ROOT, or ~/.workshed/workspaces.
+func (r *Runner) GetWorkshedRoot() string {
 	if root := os.Getenv("WORKSHED_ROOT"); root != "" {
 		return root
 	}
@@ -66,15 +101,69 @@ func (r *Runner) GetStore() workspace.Store {
 		return r.Store
 	}
 	l := r.getLogger()
-	s, err := workspace.NewFSStore(r.getWorkshedRoot())
+	s, err := workspace.NewFSStore(r.GetWorkshedRoot())
 	if err != nil {
 		l.Error("failed to create workspace store", "error", err)
 		r.ExitFunc(1)
 		return nil
 	}
+	s.SetRetention(r.getRetentionPolicy())
+	s.SetCompressCaptures(r.CompressCaptures)
+	if sink := r.getEventSink(); sink != nil {
+		s.SetEventSink(sink)
+	}
 	return s
 }
 
+// RetentionPolicy returns the execution retention policy for all workspaces.
+func (r *Runner) RetentionPolicy() workspace.RetentionPolicy {
+	return r.getRetentionPolicy()
+}
+
+// getRetentionPolicy builds the store-wide policy from WORKSHED_MAX_EXECUTIONS
+// and WORKSHED_EXECUTION_MAX_AGE. Without them every execution is kept.
+func (r *Runner) getRetentionPolicy() workspace.RetentionPolicy {
+	var policy workspace.RetentionPolicy
+	if v := os.Getenv("WORKSHED_MAX_EXECUTIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			r.getLogger().Warn("ignoring invalid WORKSHED_MAX_EXECUTIONS", "value", v)
+		} else {
+			policy.MaxExecutions = n
+		}
+	}
+	if v := os.Getenv("WORKSHED_EXECUTION_MAX_AGE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			r.getLogger().Warn("ignoring invalid WORKSHED_EXECUTION_MAX_AGE", "value", v)
+		} else {
+			policy.MaxAge = d
+		}
+	}
+	return policy
+}
+
+// getEventSink builds a lifecycle hook from WORKSHED_HOOK_URL and WORKSHED_HOOK_COMMAND.
+// Hook failures are logged as warnings and never fail the command.
+func (r *Runner) getEventSink() workspace.EventSink {
+	var sinks workspace.MultiSink
+	if url := os.Getenv("WORKSHED_HOOK_URL"); url != "" {
+		sinks = append(sinks, workspace.WebhookSink{URL: url})
+	}
+	if command := os.Getenv("WORKSHED_HOOK_COMMAND"); command != "" {
+		sinks = append(sinks, workspace.CommandSink{Command: command})
+	}
+	if len(sinks) == 0 {
+		return nil
+	}
+	return workspace.SinkFunc(func(ctx context.Context, event workspace.StoreEvent) error {
+		if err := sinks.Emit(ctx, event); err != nil {
+			r.getLogger().Warn("event hook failed", "event", event.Type, "error", err)
+		}
+		return nil
+	})
+}
+
 func (r *Runner) getStore() workspace.Store {
 	return r.GetStore()
 }
@@ -91,15 +180,24 @@ Usage:
   inspect    Show workspace details
   path       Show workspace path
   exec       Run a command in repositories
+  watch      Re-run a command when files change
+  executions Manage recorded executions
+  env        Manage workspace environment variables
+  shell      Open a shell in a workspace
   repos      Manage repositories in a workspace
   captures   List captures
   capture    Create a capture
   apply      Apply a captured state
+  stash      Stash uncommitted changes in every repository
   export     Export workspace configuration
+  lock       Write a lockfile of repository commits
   remove     Remove a workspace
+  prune      Remove empty, unused workspaces
   update     Update workspace purpose
+  rename     Change a workspace's handle
   health     Check workspace health
   completion Generate shell completion
+  doctor     Check and set up the workshed environment
 
  Flags:
   -h, --help     Show help
@@ -143,16 +241,52 @@ Usage:
 type WorkspaceNotFoundError struct {
 	Handle  string
 	Context string
+	// Err is the discovery error when no handle was given; it may carry a
+	// suggestion for the nearest workspace.
+	Err error
 }
 
 func (e *WorkspaceNotFoundError) Error() string {
 	if e.Handle != "" {
 		return fmt.Sprintf("workspace %q not found", e.Handle)
 	}
+	var notIn *workspace.NotInWorkspaceError
+	if errors.As(e.Err, &notIn) {
+		return notIn.Error()
+	}
 	return "not in a workspace directory"
 }
 
+func (e *WorkspaceNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCodeError reports that a child process exited non-zero; main exits with Code.
+type ExitCodeError struct {
+	Code int
+}
+
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("exited with code %d", e.Code)
+}
+
 func (r *Runner) ResolveHandle(ctx context.Context, providedHandle string, validate bool, l *logger.Logger) (string, error) {
+	handle, err := r.resolveHandle(ctx, providedHandle, validate)
+	if err != nil {
+		return "", err
+	}
+	// The recent list only backs 'workshed last' and 'list --recent', so a
+	// failure to record it must not fail the command.
+	if r.DryRun {
+		return handle, nil
+	}
+	if err := r.getStore().TouchRecent(ctx, handle); err != nil {
+		l.Debug("recording recent workspace", "handle", handle, "error", err)
+	}
+	return handle, nil
+}
+
+func (r *Runner) resolveHandle(ctx context.Context, providedHandle string, validate bool) (string, error) {
 	if providedHandle != "" {
 		if validate {
 			s := r.getStore()
@@ -171,11 +305,51 @@ func (r *Runner) ResolveHandle(ctx context.Context, providedHandle string, valid
 	s := r.getStore()
 	ws, err := s.FindWorkspace(ctx, ".")
 	if err != nil {
-		return "", &WorkspaceNotFoundError{Context: "run from workspace directory or use -- <handle>"}
+		return "", &WorkspaceNotFoundError{Context: "run from workspace directory or use -- <handle>", Err: err}
 	}
 	return ws.Handle, nil
 }
 
+// WorkspaceCapture is a capture together with the handle of the workspace it
+// belongs to.
+type WorkspaceCapture struct {
+	Handle string
+	workspace.Capture
+}
+
+// SearchCaptures lists the captures matching filter in every workspace of
+// store, newest first. An empty filter matches every capture.
+func SearchCaptures(ctx context.Context, store workspace.Store, filter string) ([]WorkspaceCapture, error) {
+	workspaces, err := store.List(ctx, workspace.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing workspaces: %w", err)
+	}
+
+	var matches []WorkspaceCapture
+	for _, ws := range workspaces {
+		captures, err := store.ListCaptures(ctx, ws.Handle)
+		if err != nil {
+			return nil, fmt.Errorf("listing captures of %s: %w", ws.Handle, err)
+		}
+		for _, cap := range captures {
+			if filter == "" || MatchesCaptureFilter(cap, filter) {
+				matches = append(matches, WorkspaceCapture{Handle: ws.Handle, Capture: cap})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Timestamp.After(matches[j].Timestamp)
+	})
+	return matches, nil
+}
+
+// SearchCaptures lists matching captures across every workspace in the
+// runner's store.
+func (r *Runner) SearchCaptures(ctx context.Context, filter string) ([]WorkspaceCapture, error) {
+	return SearchCaptures(ctx, r.getStore(), filter)
+}
+
 func PreflightErrorHint(reason string) string {
 	switch reason {
 	case "dirty_working_tree":
@@ -188,11 +362,25 @@ func PreflightErrorHint(reason string) string {
 		return "Check that the ref exists in the repository"
 	case "head_mismatch":
 		return "The branch has diverged; reset or merge first"
+	case "commit_missing":
+		return "The captured commit is no longer available; fetch it or choose another capture"
+	case "shallow_commit_missing":
+		return "The repository is a shallow clone; run 'workshed repos unshallow <handle> --repo <name>' to fetch full history"
 	default:
 		return ""
 	}
 }
 
+// WriteGitDetails prints git's full output for a failed operation, for --verbose.
+// Nothing is written when err carries no git output.
+func WriteGitDetails(w io.Writer, err error) {
+	details := strings.TrimSpace(git.Details(err))
+	if details == "" {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "git output:\n%s\n", details)
+}
+
 func MatchesCaptureFilter(cap workspace.Capture, filter string) bool {
 	filterLower := strings.ToLower(filter)
 
@@ -0,0 +1,202 @@
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/git"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// StepResult records the outcome of a single self-test step.
+type StepResult struct {
+	Name     string
+	Status   string
+	Duration time.Duration
+	Detail   string
+}
+
+var columns = []cli.ColumnConfig{
+	{Type: cli.Rigid, Name: "STEP", Min: 8, Max: 12},
+	{Type: cli.Rigid, Name: "STATUS", Min: 6, Max: 6},
+	{Type: cli.Rigid, Name: "DURATION", Min: 8, Max: 12},
+	{Type: cli.Shrinkable, Name: "DETAIL", Min: 10, Max: 0},
+}
+
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "self-test",
+		Short:  "Run an end-to-end smoke test in a temporary store",
+		Hidden: true,
+		Long: `Run an end-to-end smoke test in a temporary store.
+
+Creates a workspace around a generated local git repository, runs a command,
+takes and applies a capture, then removes the workspace. Your real workspace
+root is never touched.
+
+Examples:
+  workshed self-test
+  workshed self-test --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			tmp, err := os.MkdirTemp("", "workshed-self-test-")
+			if err != nil {
+				return fmt.Errorf("failed to create temp directory: %w", err)
+			}
+			defer func() { _ = os.RemoveAll(tmp) }()
+
+			results := Run(ctx, tmp)
+
+			var rows [][]string
+			failed := false
+			for _, res := range results {
+				if res.Status == StatusFail {
+					failed = true
+				}
+				rows = append(rows, []string{res.Name, res.Status, res.Duration.Round(time.Millisecond).String(), res.Detail})
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if err := cli.Render(cli.Output{Columns: columns, Rows: rows}, format, cmd.OutOrStdout()); err != nil {
+				return fmt.Errorf("failed to render output: %w", err)
+			}
+
+			if failed {
+				return errors.New("self-test failed")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
+
+// Run executes the smoke test steps under dir and returns one result per step.
+// Once a step fails the remaining steps are reported as skipped.
+func Run(ctx context.Context, dir string) []StepResult {
+	var (
+		store   *workspace.FSStore
+		repoDir string
+		ws      *workspace.Workspace
+		capture *workspace.Capture
+	)
+
+	steps := []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{"git", func() (string, error) {
+			if err := git.Available(); err != nil {
+				return "", err
+			}
+			return "found", nil
+		}},
+		{"store", func() (string, error) {
+			var err error
+			store, err = workspace.NewFSStore(filepath.Join(dir, "workspaces"))
+			if err != nil {
+				return "", err
+			}
+			return dir, nil
+		}},
+		{"repo", func() (string, error) {
+			var err error
+			repoDir, err = initRepo(ctx, filepath.Join(dir, "repos", "smoke"))
+			return repoDir, err
+		}},
+		{"create", func() (string, error) {
+			var err error
+			ws, err = store.Create(ctx, workspace.CreateOptions{
+				Purpose:      "workshed self-test",
+				Repositories: []workspace.RepositoryOption{{URL: repoDir}},
+			})
+			if err != nil {
+				return "", err
+			}
+			return ws.Handle, nil
+		}},
+		{"exec", func() (string, error) {
+			results, err := store.Exec(ctx, ws.Handle, workspace.ExecOptions{Target: "all", Command: []string{"git", "rev-parse", "HEAD"}})
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d repo(s)", len(results)), nil
+		}},
+		{"capture", func() (string, error) {
+			var err error
+			capture, err = store.CaptureState(ctx, ws.Handle, workspace.CaptureOptions{Name: "self-test", Kind: workspace.CaptureKindCheckpoint})
+			if err != nil {
+				return "", err
+			}
+			return capture.ID, nil
+		}},
+		{"apply", func() (string, error) {
+			return capture.ID, store.ApplyCapture(ctx, ws.Handle, capture.ID)
+		}},
+		{"remove", func() (string, error) {
+			return ws.Handle, store.Remove(ctx, ws.Handle)
+		}},
+	}
+
+	results := make([]StepResult, 0, len(steps))
+	failed := false
+	for _, step := range steps {
+		if failed {
+			results = append(results, StepResult{Name: step.name, Status: StatusSkip})
+			continue
+		}
+
+		start := time.Now()
+		detail, err := step.run()
+		res := StepResult{Name: step.name, Status: StatusPass, Duration: time.Since(start), Detail: detail}
+		if err != nil {
+			res.Status = StatusFail
+			res.Detail = err.Error()
+			failed = true
+		}
+		results = append(results, res)
+	}
+
+	return results
+}
+
+func initRepo(ctx context.Context, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating repository directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# workshed self-test\n"), 0644); err != nil {
+		return "", fmt.Errorf("writing README: %w", err)
+	}
+
+	identity := []string{"-c", "user.name=workshed", "-c", "user.email=self-test@workshed.invalid"}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"checkout", "-q", "-b", "main"},
+		{"add", "README.md"},
+		{"commit", "-q", "-m", "Initial commit"},
+	} {
+		cmd := exec.CommandContext(ctx, "git", append(identity, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, out)
+		}
+	}
+	return dir, nil
+}
@@ -0,0 +1,17 @@
+package selftest
+
+import "testing"
+
+func TestSelfTestCommand(t *testing.T) {
+	t.Run("is hidden", func(t *testing.T) {
+		if !Command().Hidden {
+			t.Error("self-test should be hidden")
+		}
+	})
+
+	t.Run("has --format flag", func(t *testing.T) {
+		if Command().Flags().Lookup("format") == nil {
+			t.Error("self-test should have --format flag")
+		}
+	})
+}
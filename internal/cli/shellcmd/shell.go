@@ -0,0 +1,95 @@
+package shellcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	var repo string
+	var command string
+
+	cmd := &cobra.Command{
+		Use:   "shell [<handle>]",
+		Short: "Open an interactive shell in a workspace",
+		Long: `Open $SHELL in the workspace root (or a repository with --repo).
+
+The shell inherits WORKSHED_HANDLE and WORKSHED_PATH, and exits with
+the shell's own exit code.
+
+Examples:
+  workshed shell
+  workshed shell my-workspace
+  workshed shell --repo api
+  workshed shell -c 'git status'`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			ws, err := r.GetStore().Get(ctx, handle)
+			if err != nil {
+				return fmt.Errorf("failed to get workspace: %w", err)
+			}
+
+			dir := ws.Path
+			if repo != "" {
+				if ws.GetRepositoryByName(repo) == nil {
+					return fmt.Errorf("repository not found: %s", repo)
+				}
+				dir = filepath.Join(ws.Path, repo)
+			}
+
+			shellPath, err := shell.Detect()
+			if err != nil {
+				return fmt.Errorf("%w; set $SHELL to an installed shell", err)
+			}
+
+			shellArgs := []string{}
+			if command != "" {
+				shellArgs = append(shellArgs, "-c", command)
+			}
+
+			child := exec.Command(shellPath, shellArgs...)
+			child.Dir = dir
+			child.Env = append(os.Environ(),
+				"WORKSHED_HANDLE="+ws.Handle,
+				"WORKSHED_PATH="+ws.Path,
+			)
+			child.Stdin = os.Stdin
+			child.Stdout = cmd.OutOrStdout()
+			child.Stderr = cmd.ErrOrStderr()
+
+			if err := child.Run(); err != nil {
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					cmd.SilenceUsage = true
+					cmd.SilenceErrors = true
+					return &cli.ExitCodeError{Code: exitErr.ExitCode()}
+				}
+				return fmt.Errorf("failed to run shell: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repo, "repo", "", "Start in this repository instead of the workspace root")
+	cmd.Flags().StringVarP(&command, "command", "c", "", "Run a command in the shell instead of an interactive session")
+
+	return cmd
+}
@@ -0,0 +1,31 @@
+package shellcmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func flagExists(cmd *cobra.Command, name string) bool {
+	return cmd.Flags().Lookup(name) != nil
+}
+
+func TestShellCommand(t *testing.T) {
+	t.Run("has --repo flag", func(t *testing.T) {
+		cmd := Command()
+		if !flagExists(cmd, "repo") {
+			t.Error("shell should have --repo flag")
+		}
+	})
+
+	t.Run("-c is shorthand for --command", func(t *testing.T) {
+		cmd := Command()
+		flag := cmd.Flags().Lookup("command")
+		if flag == nil {
+			t.Fatal("shell should have --command flag")
+		}
+		if flag.Shorthand != "c" {
+			t.Errorf("command flag should have -c shorthand, got: %q", flag.Shorthand)
+		}
+	})
+}
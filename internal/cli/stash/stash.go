@@ -0,0 +1,186 @@
+package stash
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var columns = []cli.ColumnConfig{
+	{Type: cli.Rigid, Name: "REPOSITORY", Min: 10, Max: 30},
+	{Type: cli.Shrinkable, Name: "RESULT", Min: 10, Max: 0},
+}
+
+func Command() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "stash [<handle>]",
+		Short: "Stash uncommitted changes in every repository",
+		Long: `Stash uncommitted changes, untracked files included, in every repository of
+a workspace as one checkpoint. Repositories without changes are left alone.
+'workshed stash pop' restores the newest checkpoint.
+
+Stashes are ordinary git stashes, so 'git stash list' in a repository shows
+them too. If stashing fails in one repository, the stashes already made are
+restored.
+
+Examples:
+  workshed stash
+  workshed stash my-workspace
+  workshed stash --dry-run
+  workshed stash pop`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if dryRun {
+				statuses, err := r.GetStore().RepositoryStatuses(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to stash: %w", err)
+				}
+				var steps []cli.PlanStep
+				for _, status := range statuses {
+					if status.Err != nil {
+						return fmt.Errorf("failed to stash: %s: %w", status.Repository, status.Err)
+					}
+					if status.Dirty {
+						steps = append(steps, cli.PlanStep{Action: "stash changes", Target: status.Repository, Detail: "untracked files included"})
+					}
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
+			results, err := r.GetStore().Stash(ctx, handle)
+			if err != nil {
+				return fmt.Errorf("failed to stash: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			return render(cmd, results, format, func(res workspace.StashResult) string {
+				if res.Commit == "" {
+					return "nothing to stash"
+				}
+				return "stashed " + shortCommit(res.Commit)
+			})
+		},
+	}
+
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+	cmd.AddCommand(PopCommand())
+
+	return cmd
+}
+
+func PopCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "pop [<handle>]",
+		Short: "Restore the newest stash",
+		Long: `Restore the newest checkpoint made by 'workshed stash' in each repository it
+covers and drop it.
+
+If restoring fails in a repository, for example on a conflict, the
+repositories already restored stay restored and the rest are kept in the
+checkpoint: resolve the problem and run 'workshed stash pop' again.
+
+Examples:
+  workshed stash pop
+  workshed stash pop my-workspace
+  workshed stash pop --dry-run`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			r.DryRun = dryRun
+
+			ctx := context.Background()
+			providedHandle, _ := cli.ExtractHandleFromArgs(args)
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+
+			if dryRun {
+				return planPop(cmd, r, handle)
+			}
+
+			results, err := r.GetStore().StashPop(ctx, handle)
+			if err != nil {
+				return fmt.Errorf("failed to pop stash: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			return render(cmd, results, format, func(res workspace.StashResult) string {
+				if res.Commit == "" {
+					return "nothing stashed"
+				}
+				return "restored " + shortCommit(res.Commit)
+			})
+		},
+	}
+
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
+
+// planPop prints the repositories the newest stash would be restored in.
+func planPop(cmd *cobra.Command, r *cli.Runner, handle string) error {
+	ctx := context.Background()
+	entries, err := r.GetStore().ListStashes(ctx, handle)
+	if err != nil {
+		return fmt.Errorf("failed to pop stash: %w", err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("failed to pop stash: %w", workspace.ErrNoStash)
+	}
+	ws, err := r.GetStore().Get(ctx, handle)
+	if err != nil {
+		return fmt.Errorf("failed to read workspace: %w", err)
+	}
+	for name := range entries[0].Commits {
+		if ws.GetRepositoryByName(name) == nil {
+			return fmt.Errorf("failed to pop stash: stashed repository %s is no longer in the workspace", name)
+		}
+	}
+
+	var steps []cli.PlanStep
+	for _, repo := range ws.Repositories {
+		if commit, ok := entries[0].Commits[repo.Name]; ok {
+			steps = append(steps, cli.PlanStep{Action: "restore stash", Target: repo.Name, Detail: shortCommit(commit)})
+		}
+	}
+	return cli.RenderPlan(cmd, steps)
+}
+
+func render(cmd *cobra.Command, results []workspace.StashResult, format string, describe func(workspace.StashResult) string) error {
+	rows := make([][]string, 0, len(results))
+	for _, res := range results {
+		rows = append(rows, []string{res.Repository, describe(res)})
+	}
+	if err := cli.Render(cli.Output{Columns: columns, Rows: rows}, format, cmd.OutOrStdout()); err != nil {
+		return fmt.Errorf("failed to render output: %w", err)
+	}
+	return nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
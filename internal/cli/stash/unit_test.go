@@ -0,0 +1,17 @@
+package stash
+
+import "testing"
+
+func TestStashCommand(t *testing.T) {
+	cmd := Command()
+	if cmd.Flags().Lookup("format") == nil {
+		t.Error("stash should have --format flag")
+	}
+	pop, _, err := cmd.Find([]string{"pop"})
+	if err != nil || pop.Name() != "pop" {
+		t.Fatalf("stash should have a pop subcommand, got %v", err)
+	}
+	if pop.Flags().Lookup("format") == nil {
+		t.Error("stash pop should have --format flag")
+	}
+}
@@ -4,9 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/x/ansi"
 	"github.com/frodi/workshed/internal/logger"
 	"github.com/hchargois/flexwriter"
+	"golang.org/x/term"
 )
 
 type ColumnType int
@@ -35,19 +43,59 @@ const (
 type Output struct {
 	Columns []ColumnConfig
 	Rows    [][]string
+
+	// Wide disables truncation so table cells are shown in full.
+	Wide bool
+}
+
+// DefaultTableWidth is used when the output is not a terminal and COLUMNS is unset.
+const DefaultTableWidth = 80
+
+const ellipsis = "…"
+
+// TableWidth returns the width tables should fit: COLUMNS if set, otherwise
+// the terminal size of w, otherwise DefaultTableWidth.
+func TableWidth(w io.Writer) int {
+	if v, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && v > 0 {
+		return v
+	}
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width
+		}
+	}
+	return DefaultTableWidth
 }
 
 type TableRenderer interface {
 	Render(columns []ColumnConfig, rows [][]string, out io.Writer) error
 }
 
-type FlexTableRenderer struct{}
+// FlexTableRenderer draws box tables. Unless Wide is set, Shrinkable columns are
+// truncated with an ellipsis so the table fits TableWidth.
+type FlexTableRenderer struct {
+	Wide bool
+}
 
 func (r *FlexTableRenderer) Render(columns []ColumnConfig, rows [][]string, out io.Writer) error {
 	writer := flexwriter.New()
 	writer.SetOutput(out)
 	writer.SetDecorator(flexwriter.BoxDrawingTableDecorator())
 
+	if r.Wide {
+		writer.SetWidth(math.MaxInt32)
+		wide := make([]ColumnConfig, len(columns))
+		for i, col := range columns {
+			col.Max = 0
+			wide[i] = col
+		}
+		columns = wide
+	} else {
+		width := TableWidth(out)
+		writer.SetWidth(width)
+		rows = fitRows(columns, rows, width)
+	}
+
 	flexCols := make([]flexwriter.Column, len(columns))
 	for i, col := range columns {
 		switch col.Type {
@@ -76,14 +124,85 @@ func (r *FlexTableRenderer) Render(columns []ColumnConfig, rows [][]string, out
 	return writer.Flush()
 }
 
+// tableOverhead is the width taken by box-drawing borders and padding for n columns.
+func tableOverhead(n int) int {
+	return 3*n + 1
+}
+
+// fitRows truncates Shrinkable cells so the table fits width. Rigid columns keep
+// their natural size; the remaining space is shared among Shrinkable columns,
+// never going below a column's Min or header width.
+func fitRows(columns []ColumnConfig, rows [][]string, width int) [][]string {
+	natural := make([]int, len(columns))
+	for i, col := range columns {
+		natural[i] = max(col.Min, ansi.StringWidth(col.Name))
+		for _, row := range rows {
+			if i < len(row) {
+				natural[i] = max(natural[i], ansi.StringWidth(row[i]))
+			}
+		}
+		if col.Type == Rigid && col.Max > 0 {
+			natural[i] = min(natural[i], col.Max)
+		}
+	}
+
+	budget := width - tableOverhead(len(columns))
+	var shrinkable []int
+	for i, col := range columns {
+		if col.Type == Shrinkable {
+			shrinkable = append(shrinkable, i)
+		} else {
+			budget -= natural[i]
+		}
+	}
+
+	limits := make(map[int]int)
+	remaining := shrinkable
+	for len(remaining) > 0 {
+		share := budget / len(remaining)
+		var next []int
+		for _, i := range remaining {
+			if natural[i] <= share {
+				budget -= natural[i]
+			} else {
+				next = append(next, i)
+			}
+		}
+		if len(next) == len(remaining) {
+			for _, i := range next {
+				limits[i] = max(share, columns[i].Min, ansi.StringWidth(columns[i].Name))
+			}
+			break
+		}
+		remaining = next
+	}
+
+	if len(limits) == 0 {
+		return rows
+	}
+
+	fitted := make([][]string, len(rows))
+	for r, row := range rows {
+		fitted[r] = append([]string(nil), row...)
+		for i, limit := range limits {
+			if i < len(row) && ansi.StringWidth(row[i]) > limit {
+				fitted[r][i] = ansi.Truncate(row[i], limit, ellipsis)
+			}
+		}
+	}
+	return fitted
+}
+
 func Render(output Output, format string, w io.Writer) error {
 	switch format {
 	case "json":
 		return renderJSONToWriter(output, w)
+	case "ndjson":
+		return renderNDJSONToWriter(output, w)
 	case "raw":
 		return renderRawToWriter(output, w)
 	case "table":
-		renderer := &FlexTableRenderer{}
+		renderer := &FlexTableRenderer{Wide: output.Wide}
 		return renderer.Render(output.Columns, output.Rows, w)
 	default:
 		return fmt.Errorf("unknown format: %s", format)
@@ -91,6 +210,59 @@ func Render(output Output, format string, w io.Writer) error {
 }
 
 func renderJSONToWriter(output Output, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rowObjects(output))
+}
+
+// renderNDJSONToWriter writes each row as a compact JSON object on its own
+// line, so callers can stream rows as they are produced.
+func renderNDJSONToWriter(output Output, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, m := range rowObjects(output) {
+		if err := enc.Encode(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderJSONWith renders output in the json or ndjson format with the fields
+// of extra[i] added to the object for row i, for nested values that do not
+// fit a table cell.
+func RenderJSONWith(output Output, extra []map[string]any, format string, w io.Writer) error {
+	objects := make([]map[string]any, 0, len(output.Rows))
+	for i, row := range rowObjects(output) {
+		m := make(map[string]any, len(row))
+		for k, v := range row {
+			m[k] = v
+		}
+		if i < len(extra) {
+			for k, v := range extra[i] {
+				m[k] = v
+			}
+		}
+		objects = append(objects, m)
+	}
+
+	enc := json.NewEncoder(w)
+	switch format {
+	case "json":
+		enc.SetIndent("", "  ")
+		return enc.Encode(objects)
+	case "ndjson":
+		for _, m := range objects {
+			if err := enc.Encode(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("format %s cannot include nested fields (use json or ndjson)", format)
+	}
+}
+
+func rowObjects(output Output) []map[string]string {
 	headers := make([]string, len(output.Columns))
 	for i, col := range output.Columns {
 		headers[i] = col.Name
@@ -107,10 +279,7 @@ func renderJSONToWriter(output Output, w io.Writer) error {
 		}
 		result = append(result, m)
 	}
-
-	enc := json.NewEncoder(w)
-	enc.SetIndent("", "  ")
-	return enc.Encode(result)
+	return result
 }
 
 func renderRawToWriter(output Output, w io.Writer) error {
@@ -126,16 +295,72 @@ func renderRawToWriter(output Output, w io.Writer) error {
 	return nil
 }
 
+// SelectColumns narrows output to the named columns, in the order given.
+// Names match column headers case-insensitively.
+func SelectColumns(output Output, names []string) (Output, error) {
+	indexes := make([]int, 0, len(names))
+	columns := make([]ColumnConfig, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		idx := -1
+		for i, col := range output.Columns {
+			if strings.EqualFold(col.Name, name) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			valid := make([]string, len(output.Columns))
+			for i, col := range output.Columns {
+				valid[i] = strings.ToLower(col.Name)
+			}
+			return Output{}, fmt.Errorf("unknown column %q (valid: %s)", name, strings.Join(valid, ", "))
+		}
+		indexes = append(indexes, idx)
+		columns = append(columns, output.Columns[idx])
+	}
+
+	rows := make([][]string, 0, len(output.Rows))
+	for _, row := range output.Rows {
+		selected := make([]string, len(indexes))
+		for i, idx := range indexes {
+			if idx < len(row) {
+				selected[i] = row[idx]
+			}
+		}
+		rows = append(rows, selected)
+	}
+
+	return Output{Columns: columns, Rows: rows}, nil
+}
+
 var KeyValueColumns = []ColumnConfig{
 	{Type: Rigid, Name: "KEY", Min: 10, Max: 20},
-	{Type: Rigid, Name: "VALUE", Min: 20, Max: 0},
+	{Type: Shrinkable, Name: "VALUE", Min: 20, Max: 0},
+}
+
+// KeyValueRows returns data as KEY/VALUE rows sorted by key.
+func KeyValueRows(data map[string]string) [][]string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	rows := make([][]string, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, []string{k, data[k]})
+	}
+	return rows
 }
 
 var ListColumns = []ColumnConfig{
 	{Type: Rigid, Name: "HANDLE", Min: 15, Max: 20},
 	{Type: Shrinkable, Name: "PURPOSE", Min: 15, Max: 0},
 	{Type: Rigid, Name: "REPO", Min: 8, Max: 15},
+	{Type: Rigid, Name: "REPOS", Min: 5, Max: 5},
 	{Type: Rigid, Name: "CREATED", Min: 16, Max: 16},
+	{Type: Rigid, Name: "ACTIVITY", Min: 8, Max: 10},
 }
 
 var CapturesColumns = []ColumnConfig{
@@ -143,14 +368,54 @@ var CapturesColumns = []ColumnConfig{
 	{Type: Shrinkable, Name: "NAME", Min: 15, Max: 0},
 	{Type: Rigid, Name: "KIND", Min: 8, Max: 15},
 	{Type: Rigid, Name: "REPOS", Min: 6, Max: 8},
+	{Type: Rigid, Name: "DIRTY", Min: 5, Max: 5},
+	{Type: Rigid, Name: "AGE", Min: 8, Max: 10},
 	{Type: Rigid, Name: "CREATED", Min: 16, Max: 16},
 }
 
-func RenderKeyValue(data map[string]string, format string, w io.Writer) error {
-	var rows [][]string
-	for k, v := range data {
-		rows = append(rows, []string{k, v})
+var AllCapturesColumns = []ColumnConfig{
+	{Type: Rigid, Name: "HANDLE", Min: 15, Max: 30},
+	{Type: Rigid, Name: "ID", Min: 26, Max: 26},
+	{Type: Shrinkable, Name: "NAME", Min: 15, Max: 0},
+	{Type: Shrinkable, Name: "TAGS", Min: 8, Max: 0},
+	{Type: Rigid, Name: "AGE", Min: 8, Max: 10},
+}
+
+// RelativeTime formats how long before now t was, e.g. "5m ago" or "3d ago".
+func RelativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d/(30*24*time.Hour)))
+	default:
+		return fmt.Sprintf("%dy ago", int(d/(365*24*time.Hour)))
+	}
+}
+
+// FormatSize formats a byte count using binary units, e.g. "512 B" or "1.5 KiB".
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
 	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+func RenderKeyValue(data map[string]string, format string, w io.Writer) error {
+	rows := KeyValueRows(data)
 
 	switch format {
 	case "raw":
@@ -0,0 +1,88 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func EmptyCommand() *cobra.Command {
+	var olderThan time.Duration
+	var all bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Permanently delete old trashed workspaces",
+		Long: `Permanently delete trashed workspaces older than the retention window.
+
+Without flags, workspaces trashed more than 30 days ago are deleted.
+
+Examples:
+  workshed trash empty
+  workshed trash empty --older-than 168h
+  workshed trash empty --all
+  workshed trash empty --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+
+			if all {
+				olderThan = 0
+			} else if olderThan <= 0 {
+				return fmt.Errorf("--older-than must be positive (use --all to delete everything)")
+			}
+
+			if dryRun {
+				expired, err := r.GetStore().ExpiredTrash(context.Background(), olderThan)
+				if err != nil {
+					return fmt.Errorf("failed to read trash: %w", err)
+				}
+				steps := make([]cli.PlanStep, len(expired))
+				for i, entry := range expired {
+					steps[i] = cli.PlanStep{Action: "delete workspace", Target: entry.Handle, Detail: entry.ID}
+				}
+				return cli.RenderPlan(cmd, steps)
+			}
+
+			removed, err := r.GetStore().EmptyTrash(context.Background(), olderThan)
+			if err != nil {
+				return fmt.Errorf("failed to empty trash: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if len(removed) == 0 {
+				return cli.RenderEmptyList(format, "nothing to delete", cmd.OutOrStdout(), r.GetLogger())
+			}
+
+			var rows [][]string
+			for _, entry := range removed {
+				rows = append(rows, []string{entry.Handle, entry.ID})
+			}
+
+			output := cli.Output{
+				Columns: []cli.ColumnConfig{
+					{Type: cli.Rigid, Name: "DELETED", Min: 15, Max: 20},
+					{Type: cli.Rigid, Name: "ID", Min: 26, Max: 26},
+				},
+				Rows: rows,
+			}
+
+			if err := cli.Render(output, format, cmd.OutOrStdout()); err != nil {
+				return fmt.Errorf("failed to render output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&olderThan, "older-than", workspace.DefaultTrashRetention, "Delete workspaces trashed longer ago than this")
+	cmd.Flags().BoolVar(&all, "all", false, "Delete everything in the trash")
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
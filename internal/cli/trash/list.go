@@ -0,0 +1,61 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/spf13/cobra"
+)
+
+func ListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List trashed workspaces",
+		Long: `List trashed workspaces, newest first.
+
+Examples:
+  workshed trash list
+  workshed trash list --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+
+			entries, err := r.GetStore().ListTrash(context.Background())
+			if err != nil {
+				return fmt.Errorf("failed to list trash: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if len(entries) == 0 {
+				return cli.RenderEmptyList(format, "trash is empty", cmd.OutOrStdout(), r.GetLogger())
+			}
+
+			now := time.Now()
+			var rows [][]string
+			for _, entry := range entries {
+				rows = append(rows, []string{entry.Handle, entry.Purpose, cli.RelativeTime(entry.TrashedAt, now), entry.ID})
+			}
+
+			output := cli.Output{
+				Columns: []cli.ColumnConfig{
+					{Type: cli.Rigid, Name: "HANDLE", Min: 15, Max: 20},
+					{Type: cli.Shrinkable, Name: "PURPOSE", Min: 15, Max: 0},
+					{Type: cli.Rigid, Name: "TRASHED", Min: 8, Max: 10},
+					{Type: cli.Rigid, Name: "ID", Min: 26, Max: 26},
+				},
+				Rows: rows,
+			}
+
+			if err := cli.Render(output, format, cmd.OutOrStdout()); err != nil {
+				return fmt.Errorf("failed to render output: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
@@ -0,0 +1,74 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func RestoreCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "restore <handle|id>",
+		Short: "Restore a trashed workspace",
+		Long: `Move a trashed workspace back into the store.
+
+A handle restores the most recently trashed workspace with that handle; use
+the ID from "workshed trash list" to pick an older one. If another workspace
+has taken the handle since, the restored workspace gets a new one.
+
+Examples:
+  workshed trash restore my-workspace
+  workshed trash restore 01HVABCDEFGHJKMNPQRSTVWXYZ
+  workshed trash restore my-workspace --dry-run`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+			ctx := context.Background()
+
+			if dryRun {
+				entries, err := r.GetStore().ListTrash(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to read trash: %w", err)
+				}
+				entry, err := workspace.FindTrashEntry(entries, args[0])
+				if err != nil {
+					return fmt.Errorf("failed to restore workspace: %w", err)
+				}
+				detail := entry.ID
+				if _, err := r.GetStore().Get(ctx, entry.Handle); err == nil {
+					detail += ", handle taken: a new one is picked"
+				}
+				return cli.RenderPlan(cmd, []cli.PlanStep{
+					{Action: "restore workspace", Target: entry.Handle, Detail: detail},
+				})
+			}
+
+			ws, err := r.GetStore().RestoreWorkspace(ctx, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to restore workspace: %w", err)
+			}
+
+			format := cmd.Flags().Lookup("format").Value.String()
+			if format == "raw" {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), ws.Handle)
+				return nil
+			}
+
+			return cli.RenderKeyValue(map[string]string{
+				"handle":  ws.Handle,
+				"purpose": ws.Purpose,
+				"path":    ws.Path,
+			}, format, cmd.OutOrStdout())
+		},
+	}
+
+	cli.AddDryRunFlag(cmd, &dryRun)
+	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
+
+	return cmd
+}
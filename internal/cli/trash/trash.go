@@ -0,0 +1,25 @@
+package trash
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Manage workspaces removed with --trash",
+		Long: `Manage workspaces removed with "workshed remove --trash".
+
+Examples:
+  workshed trash list
+  workshed trash restore my-workspace
+  workshed trash empty
+  workshed trash empty --all`,
+	}
+
+	cmd.AddCommand(ListCommand())
+	cmd.AddCommand(RestoreCommand())
+	cmd.AddCommand(EmptyCommand())
+
+	return cmd
+}
@@ -0,0 +1,36 @@
+package trash
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func flagExists(cmd *cobra.Command, name string) bool {
+	return cmd.Flags().Lookup(name) != nil
+}
+
+func TestTrashCommand(t *testing.T) {
+	t.Run("has subcommands", func(t *testing.T) {
+		cmd := Command()
+		for _, sub := range []string{"list", "restore", "empty"} {
+			found := false
+			for _, c := range cmd.Commands() {
+				if c.Name() == sub {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("trash should have %s subcommand", sub)
+			}
+		}
+	})
+
+	t.Run("empty has --older-than and --all flags", func(t *testing.T) {
+		cmd := EmptyCommand()
+		if !flagExists(cmd, "older-than") || !flagExists(cmd, "all") {
+			t.Error("trash empty should have --older-than and --all flags")
+		}
+	})
+}
@@ -1,10 +1,14 @@
 package unit
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/frodi/workshed/internal/cli"
 	"github.com/frodi/workshed/internal/cli/apply"
@@ -19,6 +23,7 @@ import (
 	"github.com/frodi/workshed/internal/cli/list"
 	"github.com/frodi/workshed/internal/cli/path"
 	"github.com/frodi/workshed/internal/cli/remove"
+	"github.com/frodi/workshed/internal/cli/rename"
 	"github.com/frodi/workshed/internal/cli/repos"
 	"github.com/frodi/workshed/internal/cli/update"
 	"github.com/frodi/workshed/internal/workspace"
@@ -43,6 +48,7 @@ func TestArgumentValidation(t *testing.T) {
 		{"export", export.Command()},
 		{"remove", remove.Command()},
 		{"update", update.Command()},
+		{"rename", rename.Command()},
 		{"apply", apply.Command()},
 		{"exec", exec.Command()},
 		{"repos list", repos.ListCommand()},
@@ -259,20 +265,21 @@ func TestLongFormFlagsExist(t *testing.T) {
 		cmd   *cobra.Command
 		flags []string
 	}{
-		{"list", list.Command(), []string{"format", "page", "page-size", "purpose"}},
+		{"list", list.Command(), []string{"format", "page", "page-size", "purpose", "created-after", "created-before", "active", "active-within"}},
 		{"captures", captures.Command(), []string{"format", "filter", "reverse"}},
-		{"create", create.Command(), []string{"format", "purpose", "repo", "template", "map", "local-map"}},
+		{"create", create.Command(), []string{"format", "purpose", "repo", "template", "map", "local-map", "dry-run"}},
 		{"export", export.Command(), []string{"format", "output"}},
-		{"import", importcmd.Command(), []string{"format", "file", "preserve-handle", "force"}},
-		{"capture", capture.Command(), []string{"format", "name", "kind", "description", "tag"}},
-		{"apply", apply.Command(), []string{"format", "name", "dry-run"}},
+		{"import", importcmd.Command(), []string{"format", "file", "preserve-handle", "force", "dry-run"}},
+		{"capture", capture.Command(), []string{"format", "name", "kind", "description", "tag", "compress-captures"}},
+		{"apply", apply.Command(), []string{"format", "name", "dry-run", "yes"}},
 		{"health", health.Command(), []string{"format"}},
-		{"inspect", inspect.Command(), []string{"format"}},
+		{"inspect", inspect.Command(), []string{"format", "repo"}},
 		{"path", path.Command(), []string{"format"}},
 		{"remove", remove.Command(), []string{"yes", "dry-run"}},
-		{"update", update.Command(), []string{"purpose"}},
+		{"update", update.Command(), []string{"purpose", "dry-run"}},
+		{"rename", rename.Command(), []string{"format", "dry-run"}},
 		{"repos list", repos.ListCommand(), []string{"format"}},
-		{"repos add", repos.AddCommand(), []string{"format", "repo"}},
+		{"repos add", repos.AddCommand(), []string{"format", "repo", "dry-run"}},
 		{"repos remove", repos.RemoveCommand(), []string{"format", "repo", "dry-run"}},
 	}
 
@@ -474,3 +481,85 @@ func TestResolveHandle(t *testing.T) {
 		}
 	})
 }
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{10 * time.Second, "just now"},
+		{5 * time.Minute, "5m ago"},
+		{3 * time.Hour, "3h ago"},
+		{2 * 24 * time.Hour, "2d ago"},
+		{70 * 24 * time.Hour, "2mo ago"},
+		{800 * 24 * time.Hour, "2y ago"},
+	}
+	for _, tt := range tests {
+		if got := cli.RelativeTime(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("RelativeTime(-%v) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+}
+
+func TestTableWidth(t *testing.T) {
+	longPurpose := strings.Repeat("investigate flaky payment retries ", 5)
+	output := cli.Output{
+		Columns: cli.ListColumns[:4],
+		Rows:    [][]string{{"calm-fox", longPurpose, "api", "2026-01-02 15:04"}},
+	}
+
+	t.Run("truncates shrinkable columns to COLUMNS", func(t *testing.T) {
+		t.Setenv("COLUMNS", "70")
+		var buf bytes.Buffer
+		if err := cli.Render(output, "table", &buf); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), "…") {
+			t.Errorf("Expected ellipsis in narrow table, got:\n%s", buf.String())
+		}
+		if strings.Contains(buf.String(), strings.TrimSpace(longPurpose)) {
+			t.Errorf("Narrow table should not contain the full purpose")
+		}
+		for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+			if w := utf8.RuneCountInString(line); w > 70 {
+				t.Errorf("Line exceeds 70 columns (%d): %s", w, line)
+			}
+		}
+	})
+
+	t.Run("wide shows full values", func(t *testing.T) {
+		t.Setenv("COLUMNS", "70")
+		wide := output
+		wide.Wide = true
+		var buf bytes.Buffer
+		if err := cli.Render(wide, "table", &buf); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), strings.TrimSpace(longPurpose)) {
+			t.Errorf("Wide table should contain the full purpose, got:\n%s", buf.String())
+		}
+		if strings.Contains(buf.String(), "…") {
+			t.Errorf("Wide table should not truncate")
+		}
+	})
+
+	t.Run("short values are not truncated", func(t *testing.T) {
+		t.Setenv("COLUMNS", "70")
+		short := cli.Output{Columns: cli.ListColumns[:4], Rows: [][]string{{"calm-fox", "short", "api", "2026-01-02 15:04"}}}
+		var buf bytes.Buffer
+		if err := cli.Render(short, "table", &buf); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if strings.Contains(buf.String(), "…") {
+			t.Errorf("Short values should not be truncated, got:\n%s", buf.String())
+		}
+	})
+
+	t.Run("falls back to the default width without a terminal", func(t *testing.T) {
+		t.Setenv("COLUMNS", "")
+		if got := cli.TableWidth(&bytes.Buffer{}); got != cli.DefaultTableWidth {
+			t.Errorf("TableWidth() = %d, want %d", got, cli.DefaultTableWidth)
+		}
+	})
+}
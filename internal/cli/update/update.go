@@ -10,6 +10,7 @@ import (
 
 func Command() *cobra.Command {
 	var purpose string
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "update [<handle>]",
@@ -18,10 +19,12 @@ func Command() *cobra.Command {
 
 Examples:
   workshed update --purpose "New focus area"
-  workshed update --purpose "Completed" my-workspace`,
+  workshed update --purpose "Completed" my-workspace
+  workshed update --purpose "Completed" --dry-run`,
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			r := cli.NewRunner("")
+			r.DryRun = dryRun
 
 			if purpose == "" {
 				return fmt.Errorf("missing required flag: --purpose")
@@ -34,6 +37,16 @@ Examples:
 				return fmt.Errorf("failed to resolve workspace: %w", err)
 			}
 
+			if dryRun {
+				ws, err := r.GetStore().Get(ctx, handle)
+				if err != nil {
+					return fmt.Errorf("failed to read workspace: %w", err)
+				}
+				return cli.RenderPlan(cmd, []cli.PlanStep{
+					{Action: "set purpose", Target: handle, Detail: fmt.Sprintf("%q -> %q", ws.Purpose, purpose)},
+				})
+			}
+
 			if err := r.GetStore().UpdatePurpose(ctx, handle, purpose); err != nil {
 				return fmt.Errorf("failed to update workspace purpose: %w", err)
 			}
@@ -47,6 +60,7 @@ Examples:
 	}
 
 	cmd.Flags().StringVar(&purpose, "purpose", "", "New workspace purpose")
+	cli.AddDryRunFlag(cmd, &dryRun)
 	cmd.Flags().String("format", "table", "Output format (table|json|raw)")
 	_ = cmd.MarkFlagRequired("purpose")
 
@@ -0,0 +1,138 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func flagExists(cmd *cobra.Command, name string) bool {
+	return cmd.Flags().Lookup(name) != nil
+}
+
+func TestWatchCommand(t *testing.T) {
+	cmd := Command()
+	for _, flag := range []string{"target", "clear", "debounce", "ignore"} {
+		if !flagExists(cmd, flag) {
+			t.Errorf("watch should have --%s flag", flag)
+		}
+	}
+}
+
+type fakeWatcher struct {
+	changes chan string
+}
+
+func (w *fakeWatcher) Changes() <-chan string {
+	return w.changes
+}
+
+func TestLoopDebounces(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := &fakeWatcher{changes: make(chan string)}
+	var runs atomic.Int32
+	ran := make(chan struct{}, 10)
+	done := make(chan struct{})
+	go func() {
+		Loop(ctx, w, 50*time.Millisecond, func() {
+			runs.Add(1)
+			ran <- struct{}{}
+		})
+		close(done)
+	}()
+
+	<-ran
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("Expected one initial run, got %d", got)
+	}
+
+	// A burst of saves is one change as far as the command is concerned.
+	for _, path := range []string{"main.go", "main.go", "util.go"} {
+		w.changes <- path
+	}
+	select {
+	case <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a run after the change")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if got := runs.Load(); got != 2 {
+		t.Errorf("Expected exactly one debounced run after the burst, got %d runs in total", got)
+	}
+
+	close(w.changes)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected Loop to return when the watcher stops")
+	}
+}
+
+func TestFSWatcher(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{".git", "node_modules", "src"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w, err := NewFSWatcher(ctx, root, nil)
+	if err != nil {
+		t.Fatalf("NewFSWatcher failed: %v", err)
+	}
+
+	// expectOnly waits for changes and fails on any path other than want.
+	expectOnly := func(want string) {
+		t.Helper()
+		select {
+		case path := <-w.Changes():
+			if path != want {
+				t.Errorf("Expected a change to %s, got %s", want, path)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected a change to %s to be reported", want)
+		}
+		for {
+			select {
+			case path := <-w.Changes():
+				if path != want {
+					t.Errorf("Expected only changes to %s, got %s", want, path)
+				}
+			case <-time.After(100 * time.Millisecond):
+				return
+			}
+		}
+	}
+
+	for _, ignored := range []string{".git/index", "node_modules/pkg.js"} {
+		if err := os.WriteFile(filepath.Join(root, ignored), []byte("x"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	watched := filepath.Join(root, "src", "main.go")
+	if err := os.WriteFile(watched, []byte("package main"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	expectOnly(watched)
+
+	// Directories created after the watch starts are watched too.
+	pkg := filepath.Join(root, "src", "pkg")
+	if err := os.Mkdir(pkg, 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	expectOnly(pkg)
+	nested := filepath.Join(pkg, "util.go")
+	if err := os.WriteFile(nested, []byte("package pkg"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	expectOnly(nested)
+}
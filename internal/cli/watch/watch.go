@@ -0,0 +1,104 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/frodi/workshed/internal/cli"
+	"github.com/frodi/workshed/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+func Command() *cobra.Command {
+	var target string
+	var clear bool
+	var debounce time.Duration
+	var ignore []string
+
+	cmd := &cobra.Command{
+		Use:   "watch [<handle>] --target <repo> -- <command> [args...]",
+		Short: "Re-run a command when a repository's files change",
+		Long: `Run a command in a repository, then run it again every time files in the
+repository change, until interrupted.
+
+Changes are debounced: a burst of saves triggers one run once the files have
+been quiet for --debounce. .git, .workshed and common dependency and build
+output directories (node_modules, dist, build, target, out, .next,
+__pycache__) are not watched; add more with --ignore.
+
+Examples:
+  workshed watch --target api -- go test ./...
+  workshed watch my-workspace --target web --clear -- npm test
+  workshed watch --target api --ignore coverage -- make lint`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cli.NewRunner("")
+
+			sepIdx := cmd.ArgsLenAtDash()
+			if sepIdx == -1 || sepIdx == len(args) {
+				return fmt.Errorf("missing command to run: use -- <command>")
+			}
+			command := args[sepIdx:]
+			if target == "" {
+				return fmt.Errorf("missing required flag: --target")
+			}
+			if debounce <= 0 {
+				return fmt.Errorf("--debounce must be positive")
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			providedHandle, _ := cli.ExtractHandleFromArgs(args[:sepIdx])
+			handle, err := r.ResolveHandle(ctx, providedHandle, true, r.GetLogger())
+			if err != nil {
+				return fmt.Errorf("failed to resolve workspace: %w", err)
+			}
+			ws, err := r.GetStore().Get(ctx, handle)
+			if err != nil {
+				return fmt.Errorf("failed to get workspace: %w", err)
+			}
+			if ws.GetRepositoryByName(target) == nil {
+				return fmt.Errorf("repository not found: %s", target)
+			}
+
+			out := cmd.OutOrStdout()
+			run := func() {
+				if clear {
+					_, _ = fmt.Fprint(out, clearScreen)
+				}
+				results, err := r.GetStore().Exec(ctx, handle, workspace.ExecOptions{Target: target, Command: command})
+				for _, result := range results {
+					_, _ = fmt.Fprintf(out, "=== %s (exit %d, %.1fs) ===\n", result.Repository, result.ExitCode, result.Duration.Seconds())
+					_, _ = out.Write(result.Output)
+				}
+				if err != nil && len(results) == 0 {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "exec failed: %v\n", err)
+				}
+				_, _ = fmt.Fprintf(out, "--- %s: waiting for changes to %s (Ctrl-C to stop) ---\n", strings.Join(command, " "), target)
+			}
+
+			watcher, err := NewFSWatcher(ctx, filepath.Join(ws.Path, target), ignore)
+			if err != nil {
+				return fmt.Errorf("failed to watch %s: %w", target, err)
+			}
+			Loop(ctx, watcher, debounce, run)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&target, "target", "", "Repository to watch and run the command in")
+	cmd.Flags().BoolVar(&clear, "clear", false, "Clear the screen before each run")
+	cmd.Flags().DurationVar(&debounce, "debounce", 300*time.Millisecond, "Wait for changes to settle this long before re-running")
+	cmd.Flags().StringSliceVar(&ignore, "ignore", nil, "Additional directory names to skip (can be specified multiple times)")
+
+	return cmd
+}
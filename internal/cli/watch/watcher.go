@@ -0,0 +1,163 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ignoredDirs are never watched: git metadata and the usual dependency and
+// build output directories, which change on every run of most commands.
+var ignoredDirs = map[string]bool{
+	".git":         true,
+	".workshed":    true,
+	"node_modules": true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"out":          true,
+	".next":        true,
+	"__pycache__":  true,
+}
+
+// Watcher reports changed files by path. The channel is closed when the
+// watcher stops.
+type Watcher interface {
+	Changes() <-chan string
+}
+
+// FSWatcher reports changes from fsnotify. fsnotify watches single
+// directories, so every directory under root is added up front and each new
+// one as it appears.
+type FSWatcher struct {
+	root    string
+	ignore  map[string]bool
+	watcher *fsnotify.Watcher
+	changes chan string
+}
+
+// NewFSWatcher watches root, skipping ignoredDirs and any directory named in
+// extraIgnores, and stops when ctx is done.
+func NewFSWatcher(ctx context.Context, root string, extraIgnores []string) (*FSWatcher, error) {
+	ignore := make(map[string]bool, len(ignoredDirs)+len(extraIgnores))
+	for name := range ignoredDirs {
+		ignore[name] = true
+	}
+	for _, name := range extraIgnores {
+		ignore[name] = true
+	}
+
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating watcher: %w", err)
+	}
+	w := &FSWatcher{root: root, ignore: ignore, watcher: fw, changes: make(chan string)}
+	if err := w.addTree(root); err != nil {
+		_ = fw.Close()
+		return nil, err
+	}
+	go w.run(ctx)
+	return w, nil
+}
+
+func (w *FSWatcher) Changes() <-chan string {
+	return w.changes
+}
+
+// addTree watches dir and every directory below it that is not ignored.
+// Subdirectories that cannot be read are skipped.
+func (w *FSWatcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != w.root && w.ignore[d.Name()] {
+			return filepath.SkipDir
+		}
+		if err := w.watcher.Add(path); err != nil {
+			return fmt.Errorf("watching %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+func (w *FSWatcher) run(ctx context.Context) {
+	defer close(w.changes)
+	defer func() { _ = w.watcher.Close() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// Editors and indexers touch permissions without changing content.
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if w.ignore[info.Name()] {
+						continue
+					}
+					// Files written before the watch is added are missed, but
+					// the directory's own event already triggers a run.
+					_ = w.addTree(event.Name)
+				}
+			}
+			select {
+			case w.changes <- event.Name:
+			case <-ctx.Done():
+				return
+			}
+		case _, ok := <-w.watcher.Errors:
+			// An error such as a queue overflow loses events, not the watch.
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// Loop calls run once, then again after each burst of changes from w once no
+// further change has arrived for debounce. It returns when ctx is done or w
+// stops reporting changes.
+func Loop(ctx context.Context, w Watcher, debounce time.Duration, run func()) {
+	run()
+
+	changes := w.Changes()
+	var timer *time.Timer
+	var fire <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-changes:
+			if !ok {
+				return
+			}
+			if timer == nil {
+				timer = time.NewTimer(debounce)
+			} else {
+				timer.Reset(debounce)
+			}
+			fire = timer.C
+		case <-fire:
+			fire = nil
+			run()
+		}
+	}
+}
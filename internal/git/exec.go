@@ -1,9 +1,14 @@
 package git
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -23,14 +28,25 @@ func (RealGit) Init(ctx context.Context, dir string) error {
 func (RealGit) Clone(ctx context.Context, url, dir string, opts CloneOptions) error {
 	args := []string{"clone"}
 	if opts.Depth > 0 {
-		args = append(args, "--depth", strconv.Itoa(opts.Depth))
+		// git ignores --depth for local paths unless it goes through its
+		// normal transport; --no-local has no effect on URLs.
+		args = append(args, "--depth", strconv.Itoa(opts.Depth), "--no-local")
 	}
 	if opts.Mirror {
 		args = append(args, "--mirror")
 	}
+	if opts.NoCheckout {
+		args = append(args, "--no-checkout")
+	}
+	if opts.Progress != nil {
+		args = append(args, "--progress")
+	}
 	args = append(args, url, dir)
 
 	cmd := exec.CommandContext(ctx, "git", args...)
+	if opts.Progress != nil {
+		return cloneWithProgress(cmd, opts.Progress)
+	}
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return ClassifyError("clone", err, output)
@@ -39,6 +55,25 @@ func (RealGit) Clone(ctx context.Context, url, dir string, opts CloneOptions) er
 	return nil
 }
 
+// cloneWithProgress runs a clone started with --progress, passing git's
+// progress updates to fn as they arrive on stderr.
+func cloneWithProgress(cmd *exec.Cmd, fn func(CloneProgress)) error {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	pipe, err := cmd.StderrPipe()
+	if err != nil {
+		return ClassifyError("clone", err, nil)
+	}
+	if err := cmd.Start(); err != nil {
+		return ClassifyError("clone", err, nil)
+	}
+	reportCloneProgress(io.TeeReader(pipe, &stderr), fn)
+	if err := cmd.Wait(); err != nil {
+		return ClassifyError("clone", err, append(stdout.Bytes(), stderr.Bytes()...))
+	}
+	return nil
+}
+
 func (RealGit) Checkout(ctx context.Context, dir, ref string) error {
 	cmd := exec.CommandContext(ctx, "git", "checkout", ref)
 	cmd.Dir = dir
@@ -127,7 +162,10 @@ func (RealGit) StatusPorcelain(ctx context.Context, dir string) (string, error)
 		return "", err
 	}
 
-	cmd := exec.CommandContext(ctx, "git", "status", "--porcelain")
+	// Without --no-optional-locks, status refreshes and rewrites the index,
+	// which would make read-only checks such as apply --dry-run touch the
+	// repository.
+	cmd := exec.CommandContext(ctx, "git", "--no-optional-locks", "status", "--porcelain")
 	cmd.Dir = absDir
 	output, err := cmd.Output()
 	if err != nil {
@@ -136,3 +174,268 @@ func (RealGit) StatusPorcelain(ctx context.Context, dir string) (string, error)
 
 	return strings.TrimSpace(string(output)), nil
 }
+
+func (RealGit) CommitExists(ctx context.Context, dir, commit string) (bool, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "-e", commit+"^{commit}")
+	cmd.Dir = absDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return false, nil
+		}
+		return false, ClassifyError("cat-file", err, output)
+	}
+
+	return true, nil
+}
+
+func (RealGit) Fetch(ctx context.Context, dir string, opts FetchOptions) (FetchSummary, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return FetchSummary{}, err
+	}
+
+	args := []string{"fetch", "--all"}
+	if opts.Remote != "" {
+		args = []string{"fetch", opts.Remote}
+	}
+	if opts.Prune {
+		args = append(args, "--prune")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = absDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return FetchSummary{}, ClassifyError("fetch", err, output)
+	}
+
+	return parseFetchOutput(string(output)), nil
+}
+
+func (RealGit) Unshallow(ctx context.Context, dir string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "fetch", "--unshallow")
+	cmd.Dir = absDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return ClassifyError("unshallow", err, output)
+	}
+
+	return nil
+}
+
+func (RealGit) SparseCheckout(ctx context.Context, dir string, paths []string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"sparse-checkout", "set"}, paths...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = absDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return ClassifyError("sparse-checkout", err, output)
+	}
+
+	return nil
+}
+
+func (RealGit) RemoteAdd(ctx context.Context, dir, name, url string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "remote", "add", "--", name, url)
+	cmd.Dir = absDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return ClassifyError("remote-add", err, output)
+	}
+
+	return nil
+}
+
+func (RealGit) CreateBranch(ctx context.Context, dir, name string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "checkout", "-b", name)
+	cmd.Dir = absDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return ClassifyError("create-branch", err, output)
+	}
+
+	return nil
+}
+
+func (RealGit) AheadBehind(ctx context.Context, dir string) (int, int, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "rev-list", "--count", "--left-right", "@{upstream}...HEAD")
+	cmd.Dir = absDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "no upstream configured") ||
+			strings.Contains(string(output), "does not point to a branch") {
+			return 0, 0, ErrNoUpstream
+		}
+		return 0, 0, ClassifyError("rev-list", err, output)
+	}
+
+	// The left side is the upstream, so its count is how far HEAD is behind.
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", strings.TrimSpace(string(output)))
+	}
+	behind, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing rev-list output: %w", err)
+	}
+	ahead, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing rev-list output: %w", err)
+	}
+	return ahead, behind, nil
+}
+
+func (RealGit) ListFiles(ctx context.Context, dir string) ([]string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = absDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, ClassifyError("ls-files", err, output)
+	}
+
+	var files []string
+	for _, name := range strings.Split(string(output), "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// parseFetchOutput counts ref update lines such as
+// " * [new branch]  feature -> origin/feature", " - [deleted] (none) -> origin/old"
+// and "   1a2b3c4..5d6e7f8  main -> origin/main".
+func parseFetchOutput(output string) FetchSummary {
+	var summary FetchSummary
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.Contains(line, "[deleted]"):
+			summary.Deleted++
+		case strings.Contains(line, "[new "):
+			summary.New++
+		case strings.Contains(line, "->") && strings.Contains(line, ".."):
+			summary.Updated++
+		}
+	}
+	return summary
+}
+
+func (RealGit) LFSPull(ctx context.Context, dir string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "lfs", "pull")
+	cmd.Dir = absDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return ClassifyError("lfs-pull", err, output)
+	}
+
+	return nil
+}
+
+func (RealGit) LFSAvailable(ctx context.Context) bool {
+	return exec.CommandContext(ctx, "git", "lfs", "version").Run() == nil
+}
+
+func (RealGit) StashPush(ctx context.Context, dir, message string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	// git reports "No local changes to save" with a zero exit, so compare
+	// the top of the stash before and after instead of parsing output.
+	before := stashTop(ctx, absDir)
+	cmd := exec.CommandContext(ctx, "git", "stash", "push", "--include-untracked", "-m", message)
+	cmd.Dir = absDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", ClassifyError("stash-push", err, output)
+	}
+
+	after := stashTop(ctx, absDir)
+	if after == before {
+		return "", nil
+	}
+	return after, nil
+}
+
+// stashTop returns the commit of the newest stash in dir, or "" when there is
+// none.
+func stashTop(ctx context.Context, dir string) string {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "refs/stash")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func (RealGit) StashPop(ctx context.Context, dir, commit string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "stash", "list", "--format=%H")
+	cmd.Dir = absDir
+	output, err := cmd.Output()
+	if err != nil {
+		return ClassifyError("stash-list", err, output)
+	}
+	index := slices.Index(strings.Fields(string(output)), commit)
+	if index == -1 {
+		return fmt.Errorf("stash %s not found", commit)
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "stash", "pop", fmt.Sprintf("stash@{%d}", index))
+	cmd.Dir = absDir
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		return ClassifyError("stash-pop", err, output)
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package git
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os/exec"
 	"strings"
 )
 
@@ -13,6 +15,29 @@ type CloneOptions struct {
 
 	// Mirror creates a bare mirror repository.
 	Mirror bool
+
+	// NoCheckout clones history and objects without populating a working tree.
+	NoCheckout bool
+
+	// Progress, when set, is called with each percentage update git reports
+	// while cloning. Without it git's progress output is not requested.
+	Progress func(CloneProgress)
+}
+
+// FetchOptions configures how a fetch operation behaves.
+type FetchOptions struct {
+	// Prune removes remote-tracking refs that no longer exist on the remote.
+	Prune bool
+
+	// Remote limits the fetch to one named remote. Empty fetches all remotes.
+	Remote string
+}
+
+// FetchSummary counts the ref changes reported by a fetch.
+type FetchSummary struct {
+	New     int
+	Updated int
+	Deleted int
 }
 
 // Git error types for common failure scenarios.
@@ -28,6 +53,13 @@ var (
 
 	// ErrRefNotFound indicates the git reference (branch/tag/commit) doesn't exist.
 	ErrRefNotFound = errors.New("ref not found")
+
+	// ErrGitNotFound indicates the git executable is not installed or not on PATH.
+	ErrGitNotFound = errors.New("git is required for this operation but was not found on PATH")
+
+	// ErrNoUpstream indicates the checked out branch has no upstream to compare
+	// against, or HEAD is detached.
+	ErrNoUpstream = errors.New("no upstream")
 )
 
 // Git defines the interface for interacting with git repositories.
@@ -56,10 +88,76 @@ type Git interface {
 
 	// StatusPorcelain returns the git status in porcelain format.
 	StatusPorcelain(ctx context.Context, dir string) (string, error)
+
+	// CommitExists reports whether a commit object is present in the repository.
+	CommitExists(ctx context.Context, dir, commit string) (bool, error)
+
+	// Fetch downloads refs from all remotes, or opts.Remote, without touching
+	// the working tree.
+	Fetch(ctx context.Context, dir string, opts FetchOptions) (FetchSummary, error)
+
+	// Unshallow fetches the full history of a shallow clone.
+	Unshallow(ctx context.Context, dir string) error
+
+	// ListFiles returns the tracked and untracked, non-ignored files in the
+	// working tree, relative to dir.
+	ListFiles(ctx context.Context, dir string) ([]string, error)
+
+	// SparseCheckout limits the working tree to the given directories.
+	SparseCheckout(ctx context.Context, dir string, paths []string) error
+
+	// RemoteAdd configures an additional named remote.
+	RemoteAdd(ctx context.Context, dir, name, url string) error
+
+	// CreateBranch creates a branch at HEAD and checks it out.
+	CreateBranch(ctx context.Context, dir, name string) error
+
+	// AheadBehind counts the commits HEAD has that its upstream lacks (ahead)
+	// and the reverse (behind). It returns ErrNoUpstream when there is none.
+	AheadBehind(ctx context.Context, dir string) (ahead, behind int, err error)
+
+	// LFSPull downloads the Git LFS objects for the checked out commit and
+	// replaces pointer files in the working tree with their content.
+	LFSPull(ctx context.Context, dir string) error
+
+	// LFSAvailable reports whether the git-lfs extension is installed.
+	LFSAvailable(ctx context.Context) bool
+
+	// StashPush stashes the working tree changes, untracked files included,
+	// and returns the stash commit. It returns "" when there was nothing to
+	// stash.
+	StashPush(ctx context.Context, dir, message string) (string, error)
+
+	// StashPop restores the stash with the given commit and drops it. A
+	// conflicting pop keeps the stash.
+	StashPop(ctx context.Context, dir, commit string) error
+}
+
+// Available reports whether the git executable can be found, returning
+// ErrGitNotFound when it cannot. Operations that only read workspace metadata
+// never run git, so they keep working without it.
+func Available() error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return ErrGitNotFound
+	}
+	return nil
 }
 
 func ClassifyError(operation string, err error, output []byte) error {
+	// Without git there is no output to classify; say so instead of guessing.
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%s: %w", operation, ErrGitNotFound)
+	}
+
+	// cmd.Output() leaves stderr on the ExitError; keep it so Details carries git's own message.
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		output = append(output, exitErr.Stderr...)
+	}
 	outputStr := string(output)
+	if strings.TrimSpace(outputStr) == "" && err != nil {
+		outputStr = err.Error()
+	}
 	var hint string
 	var suggestion string
 
@@ -93,6 +191,9 @@ func ClassifyError(operation string, err error, output []byte) error {
 		strings.Contains(outputStr, "remote branch") && strings.Contains(outputStr, "not found"):
 		hint = "ref not found"
 		suggestion = "Check that the branch or tag exists in the repository."
+	case strings.Contains(outputStr, "a branch named") && strings.Contains(outputStr, "already exists"):
+		hint = "branch already exists"
+		suggestion = "Choose a different branch name, or check out the existing branch with @ref."
 	}
 
 	return classify(operation, hint, suggestion, outputStr)
@@ -111,11 +212,13 @@ type GitError struct {
 	Operation  string
 	Hint       string
 	Suggestion string
-	Details    string
+
+	// Details holds git's raw output verbatim. Error() shows only its key line.
+	Details string
 }
 
 func (e *GitError) Error() string {
-	result := gitErrorString(e.Operation, e.Hint, e.Details)
+	result := gitErrorString(e.Operation, e.Hint, detailsSummary(e.Details))
 	if e.Suggestion != "" {
 		result += "\nSuggestion: " + e.Suggestion
 	}
@@ -126,6 +229,32 @@ func (e *GitError) Unwrap() error {
 	return nil
 }
 
+// Details returns the full git output carried by the first GitError in err's chain.
+func Details(err error) string {
+	var gitErr *GitError
+	if errors.As(err, &gitErr) {
+		return gitErr.Details
+	}
+	return ""
+}
+
+// detailsSummary picks the line that explains the failure: the first fatal/error line,
+// or the last non-empty line when git printed neither (e.g. progress followed by a message).
+func detailsSummary(details string) string {
+	var last string
+	for _, line := range strings.Split(details, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "fatal:") || strings.HasPrefix(line, "error:") {
+			return line
+		}
+		last = line
+	}
+	return last
+}
+
 func gitErrorString(operation, hint, details string) string {
 	return strings.TrimSpace(operation + " failed (" + hint + "): " + details)
 }
@@ -1,7 +1,12 @@
 package git
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -207,6 +212,139 @@ func TestGitErrorInterface(t *testing.T) {
 	})
 }
 
+func TestGitErrorDetails(t *testing.T) {
+	t.Run("should capture stderr from cmd.Output failures", func(t *testing.T) {
+		cmd := exec.Command("git", "rev-parse", "--verify", "does-not-exist")
+		cmd.Dir = t.TempDir()
+		output, err := cmd.Output()
+		if err == nil {
+			t.Fatal("Expected rev-parse outside a repository to fail")
+		}
+
+		gitErr := ClassifyError("rev-parse", err, output)
+		if !strings.Contains(Details(gitErr), "not a git repository") {
+			t.Errorf("Details should contain git stderr, got: %q", Details(gitErr))
+		}
+	})
+
+	t.Run("Error should show the fatal line and Details keep everything", func(t *testing.T) {
+		details := "Cloning into 'api'...\nfatal: could not read Username for 'https://github.com': terminal prompts disabled\n"
+		err := &GitError{Operation: "clone", Hint: "authentication failed", Details: details}
+
+		errStr := err.Error()
+		if !strings.Contains(errStr, "fatal: could not read Username") {
+			t.Errorf("Error() should include fatal line, got: %s", errStr)
+		}
+		if strings.Contains(errStr, "Cloning into") {
+			t.Errorf("Error() should omit progress lines, got: %s", errStr)
+		}
+		if Details(fmt.Errorf("wrapped: %w", err)) != details {
+			t.Errorf("Details should return verbatim output through wrapping")
+		}
+	})
+
+	t.Run("Details should be empty for non-git errors", func(t *testing.T) {
+		if Details(errors.New("plain")) != "" {
+			t.Error("Details should be empty for non-git errors")
+		}
+	})
+}
+
+func TestParseFetchOutput(t *testing.T) {
+	output := `Fetching origin
+From /tmp/origin
+ * [new branch]      feature    -> origin/feature
+ * [new tag]         v1.0       -> v1.0
+ - [deleted]         (none)     -> origin/old
+   1a2b3c4..5d6e7f8  main       -> origin/main
+ + 9a8b7c6...1f2e3d4 rebased    -> origin/rebased  (forced update)
+`
+	summary := parseFetchOutput(output)
+
+	if summary.New != 2 {
+		t.Errorf("Expected 2 new refs, got: %d", summary.New)
+	}
+	if summary.Updated != 2 {
+		t.Errorf("Expected 2 updated refs, got: %d", summary.Updated)
+	}
+	if summary.Deleted != 1 {
+		t.Errorf("Expected 1 deleted ref, got: %d", summary.Deleted)
+	}
+}
+
+func TestReportCloneProgress(t *testing.T) {
+	output := "Cloning into 'repo'...\n" +
+		"remote: Enumerating objects: 1000, done.\n" +
+		"remote: Counting objects:  50% (500/1000)\rremote: Counting objects: 100% (1000/1000), done.\n" +
+		"Receiving objects:   0% (1/1000)\rReceiving objects:  42% (420/1000), 1.20 MiB | 2.40 MiB/s\r" +
+		"Receiving objects:  42% (421/1000), 1.21 MiB | 2.40 MiB/s\r" +
+		"Receiving objects: 100% (1000/1000), 3.00 MiB | 2.50 MiB/s, done.\n" +
+		"Resolving deltas: 100% (300/300), done.\n" +
+		"Updating files:  75% (3/4)\rUpdating files: 100% (4/4), done."
+
+	var got []CloneProgress
+	reportCloneProgress(strings.NewReader(output), func(p CloneProgress) {
+		got = append(got, p)
+	})
+
+	want := []CloneProgress{
+		{Stage: "Counting objects", Percent: 50},
+		{Stage: "Counting objects", Percent: 100},
+		{Stage: "Receiving objects", Percent: 0},
+		{Stage: "Receiving objects", Percent: 42},
+		{Stage: "Receiving objects", Percent: 100},
+		{Stage: "Resolving deltas", Percent: 100},
+		{Stage: "Updating files", Percent: 75},
+		{Stage: "Updating files", Percent: 100},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d updates, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Update %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestRealGit_CloneProgress(t *testing.T) {
+	src := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "first"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = src
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	var updates []CloneProgress
+	dst := filepath.Join(t.TempDir(), "clone")
+	err := (RealGit{}).Clone(context.Background(), "file://"+src, dst, CloneOptions{
+		Progress: func(p CloneProgress) { updates = append(updates, p) },
+	})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	if len(updates) == 0 {
+		t.Fatal("Expected progress updates from the clone")
+	}
+	if last := updates[len(updates)-1]; last.Percent != 100 {
+		t.Errorf("Expected the last update to be complete, got %+v", last)
+	}
+
+	t.Run("failure keeps git's message", func(t *testing.T) {
+		err := (RealGit{}).Clone(context.Background(), filepath.Join(t.TempDir(), "missing"), filepath.Join(t.TempDir(), "clone"), CloneOptions{
+			Progress: func(CloneProgress) {},
+		})
+		if err == nil || !strings.Contains(err.Error(), "clone") {
+			t.Errorf("Expected a clone error, got %v", err)
+		}
+	})
+}
+
 func TestRealGit_DefaultBranch(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping network test in short mode")
@@ -223,3 +361,304 @@ func TestRealGit_DefaultBranch(t *testing.T) {
 		}
 	})
 }
+
+func TestRealGit_Unshallow(t *testing.T) {
+	t.Run("should fetch full history of a shallow clone", func(t *testing.T) {
+		src := t.TempDir()
+		for _, args := range [][]string{
+			{"init", "-q"},
+			{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "first"},
+			{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "second"},
+		} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = src
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", args, err, out)
+			}
+		}
+
+		dst := filepath.Join(t.TempDir(), "clone")
+		ctx := context.Background()
+		if err := (RealGit{}).Clone(ctx, "file://"+src, dst, CloneOptions{Depth: 1}); err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dst, ".git", "shallow")); err != nil {
+			t.Fatalf("Expected a shallow clone: %v", err)
+		}
+
+		if err := (RealGit{}).Unshallow(ctx, dst); err != nil {
+			t.Fatalf("Unshallow failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dst, ".git", "shallow")); !os.IsNotExist(err) {
+			t.Errorf("Expected shallow marker to be removed, stat err: %v", err)
+		}
+	})
+}
+
+func TestRealGit_SparseCheckout(t *testing.T) {
+	t.Run("should limit the working tree to the given directories", func(t *testing.T) {
+		src := t.TempDir()
+		for _, dir := range []string{"services/api", "services/web"} {
+			if err := os.MkdirAll(filepath.Join(src, dir), 0755); err != nil {
+				t.Fatalf("MkdirAll failed: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(src, dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+		}
+		for _, args := range [][]string{
+			{"init", "-q"},
+			{"add", "."},
+			{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "first"},
+		} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = src
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", args, err, out)
+			}
+		}
+
+		dst := filepath.Join(t.TempDir(), "clone")
+		ctx := context.Background()
+		if err := (RealGit{}).Clone(ctx, src, dst, CloneOptions{NoCheckout: true}); err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		if err := (RealGit{}).SparseCheckout(ctx, dst, []string{"services/api"}); err != nil {
+			t.Fatalf("SparseCheckout failed: %v", err)
+		}
+		branch, err := (RealGit{}).CurrentBranch(ctx, dst)
+		if err != nil {
+			t.Fatalf("CurrentBranch failed: %v", err)
+		}
+		if err := (RealGit{}).Checkout(ctx, dst, branch); err != nil {
+			t.Fatalf("Checkout failed: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(dst, "services", "api", "main.go")); err != nil {
+			t.Errorf("Expected services/api to be checked out: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dst, "services", "web")); !os.IsNotExist(err) {
+			t.Errorf("Expected services/web to be left out, stat err: %v", err)
+		}
+	})
+}
+
+func TestRealGit_RemoteAdd(t *testing.T) {
+	t.Run("should add a named remote that can be fetched alone", func(t *testing.T) {
+		src := t.TempDir()
+		for _, args := range [][]string{
+			{"init", "-q"},
+			{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "first"},
+		} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = src
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", args, err, out)
+			}
+		}
+
+		dst := filepath.Join(t.TempDir(), "clone")
+		ctx := context.Background()
+		if err := (RealGit{}).Clone(ctx, src, dst, CloneOptions{}); err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		if err := (RealGit{}).RemoteAdd(ctx, dst, "upstream", src); err != nil {
+			t.Fatalf("RemoteAdd failed: %v", err)
+		}
+
+		cmd := exec.Command("git", "remote", "get-url", "upstream")
+		cmd.Dir = dst
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("git remote get-url failed: %v", err)
+		}
+		if got := strings.TrimSpace(string(out)); got != src {
+			t.Errorf("Expected upstream to point at %s, got %s", src, got)
+		}
+
+		summary, err := (RealGit{}).Fetch(ctx, dst, FetchOptions{Remote: "upstream"})
+		if err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+		if summary.New == 0 {
+			t.Errorf("Expected fetching upstream to create refs, got %+v", summary)
+		}
+
+		if err := (RealGit{}).RemoteAdd(ctx, dst, "upstream", src); err == nil {
+			t.Error("Expected adding a duplicate remote to fail")
+		}
+
+		// Arguments after -- are never options, whatever they look like.
+		if err := (RealGit{}).RemoteAdd(ctx, dst, "--mirror=fetch", src); err != nil {
+			t.Fatalf("RemoteAdd failed: %v", err)
+		}
+		cmd = exec.Command("git", "config", "--get", "remote.--mirror=fetch.url")
+		cmd.Dir = dst
+		if out, err := cmd.Output(); err != nil || strings.TrimSpace(string(out)) != src {
+			t.Errorf("Expected --mirror=fetch to be taken as a remote name, got %q (%v)", out, err)
+		}
+	})
+}
+
+func TestRealGit_CreateBranch(t *testing.T) {
+	src := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "first"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = src
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	ctx := context.Background()
+	t.Run("should create and check out the branch", func(t *testing.T) {
+		if err := (RealGit{}).CreateBranch(ctx, src, "feature/login"); err != nil {
+			t.Fatalf("CreateBranch failed: %v", err)
+		}
+		branch, err := (RealGit{}).CurrentBranch(ctx, src)
+		if err != nil {
+			t.Fatalf("CurrentBranch failed: %v", err)
+		}
+		if branch != "feature/login" {
+			t.Errorf("Expected feature/login to be checked out, got %q", branch)
+		}
+	})
+
+	t.Run("should fail clearly for an existing branch", func(t *testing.T) {
+		err := (RealGit{}).CreateBranch(ctx, src, "feature/login")
+		if err == nil {
+			t.Fatal("Expected creating an existing branch to fail")
+		}
+		if !strings.Contains(err.Error(), "branch already exists") {
+			t.Errorf("Expected 'branch already exists' hint, got: %v", err)
+		}
+	})
+}
+
+func TestRealGit_AheadBehind(t *testing.T) {
+	src := t.TempDir()
+	clone := t.TempDir()
+	commit := func(msg string) []string {
+		return []string{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", msg}
+	}
+	for _, step := range []struct {
+		dir  string
+		args []string
+	}{
+		{src, []string{"init", "-q"}},
+		{src, commit("first")},
+		{clone, []string{"clone", "-q", src, "."}},
+		{clone, commit("local one")},
+		{clone, commit("local two")},
+		{src, commit("upstream")},
+		{clone, []string{"fetch", "-q"}},
+	} {
+		cmd := exec.Command("git", step.args...)
+		cmd.Dir = step.dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", step.args, err, out)
+		}
+	}
+
+	ctx := context.Background()
+	t.Run("should count commits on each side", func(t *testing.T) {
+		ahead, behind, err := (RealGit{}).AheadBehind(ctx, clone)
+		if err != nil {
+			t.Fatalf("AheadBehind failed: %v", err)
+		}
+		if ahead != 2 || behind != 1 {
+			t.Errorf("Expected ahead 2, behind 1, got ahead %d, behind %d", ahead, behind)
+		}
+	})
+
+	t.Run("should report a branch without upstream", func(t *testing.T) {
+		_, _, err := (RealGit{}).AheadBehind(ctx, src)
+		if !errors.Is(err, ErrNoUpstream) {
+			t.Errorf("Expected ErrNoUpstream, got %v", err)
+		}
+	})
+}
+
+func TestRealGit_Stash(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	src := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"commit", "-q", "--allow-empty", "-m", "first"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = src
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	ctx := context.Background()
+	t.Run("should report a clean tree as nothing to stash", func(t *testing.T) {
+		commit, err := (RealGit{}).StashPush(ctx, src, "clean")
+		if err != nil {
+			t.Fatalf("StashPush failed: %v", err)
+		}
+		if commit != "" {
+			t.Errorf("Expected no stash commit for a clean tree, got %q", commit)
+		}
+	})
+
+	t.Run("should stash and restore untracked files", func(t *testing.T) {
+		file := filepath.Join(src, "notes.txt")
+		if err := os.WriteFile(file, []byte("wip"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		commit, err := (RealGit{}).StashPush(ctx, src, "wip")
+		if err != nil {
+			t.Fatalf("StashPush failed: %v", err)
+		}
+		if commit == "" {
+			t.Fatal("Expected a stash commit")
+		}
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Fatalf("Expected the untracked file to be stashed, got err=%v", err)
+		}
+
+		if err := (RealGit{}).StashPop(ctx, src, commit); err != nil {
+			t.Fatalf("StashPop failed: %v", err)
+		}
+		if data, err := os.ReadFile(file); err != nil || string(data) != "wip" {
+			t.Errorf("Expected the untracked file to be restored, got %q, err=%v", data, err)
+		}
+	})
+
+	t.Run("should fail for an unknown stash", func(t *testing.T) {
+		err := (RealGit{}).StashPop(ctx, src, "0123456789abcdef0123456789abcdef01234567")
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("Expected a not found error, got %v", err)
+		}
+	})
+}
+
+func TestGitNotFound(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	t.Run("Available reports missing git", func(t *testing.T) {
+		if err := Available(); !errors.Is(err, ErrGitNotFound) {
+			t.Errorf("Expected ErrGitNotFound, got %v", err)
+		}
+	})
+
+	t.Run("operations fail with a clear error", func(t *testing.T) {
+		err := (RealGit{}).Clone(context.Background(), "https://github.com/org/repo", t.TempDir(), CloneOptions{})
+		if !errors.Is(err, ErrGitNotFound) {
+			t.Fatalf("Expected ErrGitNotFound, got %v", err)
+		}
+		if strings.Contains(err.Error(), "repository not found") {
+			t.Errorf("Missing git should not be reported as a missing repository: %v", err)
+		}
+	})
+}
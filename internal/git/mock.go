@@ -20,6 +20,24 @@ type MockGit struct {
 	revParseResult        string
 	statusPorcelainErr    error
 	statusPorcelainResult string
+	commitExistsErr       error
+	missingCommits        map[string]bool
+	fetchErrs             map[string]error
+	fetchResult           FetchSummary
+	unshallowErr          error
+	listFilesErr          error
+	listFilesResult       []string
+	sparseCheckoutErr     error
+	remoteAddErr          error
+	createBranchErr       error
+	aheadBehindErr        error
+	aheadBehindAhead      int
+	aheadBehindBehind     int
+	lfsPullErr            error
+	lfsAvailable          bool
+	stashPushResult       string
+	stashPushErr          error
+	stashPopErr           error
 	initCalls             []InitCall
 	cloneCalls            []CloneCall
 	checkoutCalls         []CheckoutCall
@@ -28,6 +46,17 @@ type MockGit struct {
 	defaultBranchCalls    []DefaultBranchCall
 	revParseCalls         []RevParseCall
 	statusPorcelainCalls  []StatusPorcelainCall
+	commitExistsCalls     []CommitExistsCall
+	fetchCalls            []FetchCall
+	unshallowCalls        []UnshallowCall
+	listFilesCalls        []ListFilesCall
+	sparseCheckoutCalls   []SparseCheckoutCall
+	remoteAddCalls        []RemoteAddCall
+	createBranchCalls     []CreateBranchCall
+	aheadBehindCalls      []AheadBehindCall
+	lfsPullCalls          []LFSPullCall
+	stashPushCalls        []StashPushCall
+	stashPopCalls         []StashPopCall
 }
 
 type InitCall struct {
@@ -66,6 +95,58 @@ type StatusPorcelainCall struct {
 	Dir string
 }
 
+type UnshallowCall struct {
+	Dir string
+}
+
+type ListFilesCall struct {
+	Dir string
+}
+
+type SparseCheckoutCall struct {
+	Dir   string
+	Paths []string
+}
+
+type RemoteAddCall struct {
+	Dir  string
+	Name string
+	URL  string
+}
+
+type CreateBranchCall struct {
+	Dir  string
+	Name string
+}
+
+type AheadBehindCall struct {
+	Dir string
+}
+
+type LFSPullCall struct {
+	Dir string
+}
+
+type StashPushCall struct {
+	Dir     string
+	Message string
+}
+
+type StashPopCall struct {
+	Dir    string
+	Commit string
+}
+
+type FetchCall struct {
+	Dir  string
+	Opts FetchOptions
+}
+
+type CommitExistsCall struct {
+	Dir    string
+	Commit string
+}
+
 func (m *MockGit) Init(ctx context.Context, dir string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -270,3 +351,286 @@ func (m *MockGit) GetStatusPorcelainCalls() []StatusPorcelainCall {
 	defer m.mu.Unlock()
 	return append([]StatusPorcelainCall{}, m.statusPorcelainCalls...)
 }
+
+func (m *MockGit) CommitExists(ctx context.Context, dir, commit string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.commitExistsCalls = append(m.commitExistsCalls, CommitExistsCall{Dir: dir, Commit: commit})
+	if m.commitExistsErr != nil {
+		return false, m.commitExistsErr
+	}
+	return !m.missingCommits[commit], nil
+}
+
+func (m *MockGit) SetCommitExistsErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commitExistsErr = err
+}
+
+// SetCommitMissing marks a commit as absent. Commits exist unless marked missing.
+func (m *MockGit) SetCommitMissing(commit string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.missingCommits == nil {
+		m.missingCommits = make(map[string]bool)
+	}
+	m.missingCommits[commit] = true
+}
+
+func (m *MockGit) GetCommitExistsCalls() []CommitExistsCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]CommitExistsCall{}, m.commitExistsCalls...)
+}
+
+func (m *MockGit) Fetch(ctx context.Context, dir string, opts FetchOptions) (FetchSummary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.fetchCalls = append(m.fetchCalls, FetchCall{Dir: dir, Opts: opts})
+	if err := m.fetchErrs[dir]; err != nil {
+		return FetchSummary{}, err
+	}
+	return m.fetchResult, nil
+}
+
+// SetFetchErr makes Fetch fail for the given directory only.
+func (m *MockGit) SetFetchErr(dir string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fetchErrs == nil {
+		m.fetchErrs = make(map[string]error)
+	}
+	m.fetchErrs[dir] = err
+}
+
+func (m *MockGit) SetFetchResult(summary FetchSummary) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetchResult = summary
+}
+
+func (m *MockGit) GetFetchCalls() []FetchCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]FetchCall{}, m.fetchCalls...)
+}
+
+func (m *MockGit) Unshallow(ctx context.Context, dir string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.unshallowCalls = append(m.unshallowCalls, UnshallowCall{Dir: dir})
+	return m.unshallowErr
+}
+
+func (m *MockGit) SetUnshallowErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unshallowErr = err
+}
+
+func (m *MockGit) GetUnshallowCalls() []UnshallowCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]UnshallowCall{}, m.unshallowCalls...)
+}
+
+func (m *MockGit) ListFiles(ctx context.Context, dir string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.listFilesCalls = append(m.listFilesCalls, ListFilesCall{Dir: dir})
+	return append([]string{}, m.listFilesResult...), m.listFilesErr
+}
+
+func (m *MockGit) SetListFilesResult(files []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listFilesResult = files
+}
+
+func (m *MockGit) SetListFilesErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listFilesErr = err
+}
+
+func (m *MockGit) GetListFilesCalls() []ListFilesCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ListFilesCall{}, m.listFilesCalls...)
+}
+
+func (m *MockGit) SparseCheckout(ctx context.Context, dir string, paths []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sparseCheckoutCalls = append(m.sparseCheckoutCalls, SparseCheckoutCall{Dir: dir, Paths: append([]string{}, paths...)})
+	return m.sparseCheckoutErr
+}
+
+func (m *MockGit) SetSparseCheckoutErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sparseCheckoutErr = err
+}
+
+func (m *MockGit) GetSparseCheckoutCalls() []SparseCheckoutCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]SparseCheckoutCall{}, m.sparseCheckoutCalls...)
+}
+
+func (m *MockGit) RemoteAdd(ctx context.Context, dir, name, url string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remoteAddCalls = append(m.remoteAddCalls, RemoteAddCall{Dir: dir, Name: name, URL: url})
+	return m.remoteAddErr
+}
+
+func (m *MockGit) SetRemoteAddErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remoteAddErr = err
+}
+
+func (m *MockGit) GetRemoteAddCalls() []RemoteAddCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]RemoteAddCall{}, m.remoteAddCalls...)
+}
+
+func (m *MockGit) CreateBranch(ctx context.Context, dir, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.createBranchCalls = append(m.createBranchCalls, CreateBranchCall{Dir: dir, Name: name})
+	return m.createBranchErr
+}
+
+func (m *MockGit) SetCreateBranchErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.createBranchErr = err
+}
+
+func (m *MockGit) GetCreateBranchCalls() []CreateBranchCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]CreateBranchCall{}, m.createBranchCalls...)
+}
+
+func (m *MockGit) AheadBehind(ctx context.Context, dir string) (int, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.aheadBehindCalls = append(m.aheadBehindCalls, AheadBehindCall{Dir: dir})
+	if m.aheadBehindErr != nil {
+		return 0, 0, m.aheadBehindErr
+	}
+	return m.aheadBehindAhead, m.aheadBehindBehind, nil
+}
+
+func (m *MockGit) SetAheadBehindErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.aheadBehindErr = err
+}
+
+func (m *MockGit) SetAheadBehindResult(ahead, behind int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.aheadBehindAhead = ahead
+	m.aheadBehindBehind = behind
+}
+
+func (m *MockGit) GetAheadBehindCalls() []AheadBehindCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]AheadBehindCall{}, m.aheadBehindCalls...)
+}
+
+func (m *MockGit) LFSPull(ctx context.Context, dir string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lfsPullCalls = append(m.lfsPullCalls, LFSPullCall{Dir: dir})
+	return m.lfsPullErr
+}
+
+func (m *MockGit) SetLFSPullErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lfsPullErr = err
+}
+
+func (m *MockGit) GetLFSPullCalls() []LFSPullCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]LFSPullCall{}, m.lfsPullCalls...)
+}
+
+func (m *MockGit) LFSAvailable(ctx context.Context) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lfsAvailable
+}
+
+func (m *MockGit) SetLFSAvailable(available bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lfsAvailable = available
+}
+
+func (m *MockGit) StashPush(ctx context.Context, dir, message string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stashPushCalls = append(m.stashPushCalls, StashPushCall{Dir: dir, Message: message})
+	if m.stashPushErr != nil {
+		return "", m.stashPushErr
+	}
+	return m.stashPushResult, nil
+}
+
+func (m *MockGit) SetStashPushErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stashPushErr = err
+}
+
+func (m *MockGit) SetStashPushResult(commit string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stashPushResult = commit
+}
+
+func (m *MockGit) GetStashPushCalls() []StashPushCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]StashPushCall{}, m.stashPushCalls...)
+}
+
+func (m *MockGit) StashPop(ctx context.Context, dir, commit string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stashPopCalls = append(m.stashPopCalls, StashPopCall{Dir: dir, Commit: commit})
+	return m.stashPopErr
+}
+
+func (m *MockGit) SetStashPopErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stashPopErr = err
+}
+
+func (m *MockGit) GetStashPopCalls() []StashPopCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]StashPopCall{}, m.stashPopCalls...)
+}
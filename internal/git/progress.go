@@ -0,0 +1,71 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CloneProgress is a percentage update parsed from git clone --progress, such
+// as "Receiving objects: 42%".
+type CloneProgress struct {
+	// Stage is the phase git reports, e.g. "Counting objects", "Receiving
+	// objects", "Resolving deltas" or "Updating files".
+	Stage   string
+	Percent int
+}
+
+// parseCloneProgress extracts the stage and percentage from one line of git's
+// progress output. Lines without a percentage report false.
+func parseCloneProgress(line string) (CloneProgress, bool) {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "remote: ")
+	stage, rest, ok := strings.Cut(line, ":")
+	if !ok || stage == "" {
+		return CloneProgress{}, false
+	}
+	field, _, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	value, ok := strings.CutSuffix(field, "%")
+	if !ok {
+		return CloneProgress{}, false
+	}
+	percent, err := strconv.Atoi(value)
+	if err != nil || percent < 0 || percent > 100 {
+		return CloneProgress{}, false
+	}
+	return CloneProgress{Stage: stage, Percent: percent}, true
+}
+
+// scanProgressLines splits git's progress output into lines. git redraws a
+// progress line in place with \r and only ends a stage with \n, so both
+// terminate a line.
+func scanProgressLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// reportCloneProgress reads git's progress output from r and calls fn for each
+// change of stage or percentage, in the order git reported them. git repeats a
+// percentage while the object count behind it grows; repeats are dropped.
+func reportCloneProgress(r io.Reader, fn func(CloneProgress)) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanProgressLines)
+	var last CloneProgress
+	for scanner.Scan() {
+		progress, ok := parseCloneProgress(scanner.Text())
+		if !ok || progress == last {
+			continue
+		}
+		last = progress
+		fn(progress)
+	}
+	// Drain whatever is left so git never blocks writing to a full pipe.
+	_, _ = io.Copy(io.Discard, r)
+}
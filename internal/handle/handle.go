@@ -15,6 +15,15 @@ type Generator struct {
 	verbs      []string
 }
 
+// WithWords replaces the word lists handles are drawn from.
+func WithWords(adjectives, nouns, verbs []string) GeneratorOption {
+	return func(g *Generator) {
+		g.adjectives = adjectives
+		g.nouns = nouns
+		g.verbs = verbs
+	}
+}
+
 // NewGenerator creates a new handle generator with default word lists.
 func NewGenerator(opts ...GeneratorOption) *Generator {
 	g := &Generator{
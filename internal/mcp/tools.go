@@ -4,38 +4,29 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	iofs "io/fs"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/frodi/workshed/internal/git"
+	"github.com/frodi/workshed/internal/shell"
 	"github.com/frodi/workshed/internal/version"
 	"github.com/frodi/workshed/internal/workspace"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-func detectShell() (string, error) {
-	if shell := os.Getenv("SHELL"); shell != "" {
-		if _, err := os.Stat(shell); err == nil {
-			return shell, nil
-		}
-	}
-
-	for _, shell := range []string{"/bin/bash", "/bin/zsh", "/bin/sh"} {
-		if _, err := os.Stat(shell); err == nil {
-			return shell, nil
-		}
-	}
-
-	return "", fmt.Errorf("no suitable shell found")
-}
-
 type Server struct {
 	store        workspace.Store
+	git          git.Git
 	activeHandle *string
 }
 
 func NewServer(store workspace.Store) *Server {
-	return &Server{store: store}
+	return &Server{store: store, git: git.RealGit{}}
 }
 
 func (s *Server) resolveHandle(ctx context.Context, handle *string) (string, error) {
@@ -89,8 +80,25 @@ func (s *Server) captureNotFoundError(ctx context.Context, handle, captureID str
 	return NewToolError(fmt.Sprintf("capture %q not found in workspace %q. Available: %s", captureID, handle, strings.Join(captureNames, ", ")))
 }
 
-func (s *Server) listWorkspaces(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, ListWorkspacesOutput, error) {
-	workspaces, err := s.store.List(ctx, workspace.ListOptions{})
+func (s *Server) listWorkspaces(ctx context.Context, req *mcp.CallToolRequest, input ListWorkspacesInput) (*mcp.CallToolResult, ListWorkspacesOutput, error) {
+	opts := workspace.ListOptions{ProjectFilter: input.Project}
+	now := time.Now()
+	if input.CreatedAfter != "" {
+		t, err := workspace.ParseTimeBound(input.CreatedAfter, now)
+		if err != nil {
+			return nil, ListWorkspacesOutput{}, NewToolError(fmt.Sprintf("invalid created_after: %v", err))
+		}
+		opts.CreatedAfter = t
+	}
+	if input.CreatedBefore != "" {
+		t, err := workspace.ParseTimeBound(input.CreatedBefore, now)
+		if err != nil {
+			return nil, ListWorkspacesOutput{}, NewToolError(fmt.Sprintf("invalid created_before: %v", err))
+		}
+		opts.CreatedBefore = t
+	}
+
+	workspaces, err := s.store.List(ctx, opts)
 	if err != nil {
 		return nil, ListWorkspacesOutput{}, err
 	}
@@ -100,6 +108,7 @@ func (s *Server) listWorkspaces(ctx context.Context, req *mcp.CallToolRequest, _
 		result = append(result, WorkspaceInfo{
 			Handle:    ws.Handle,
 			Purpose:   ws.Purpose,
+			Project:   ws.Project,
 			RepoCount: len(ws.Repositories),
 			CreatedAt: ws.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		})
@@ -131,6 +140,7 @@ func (s *Server) getWorkspace(ctx context.Context, req *mcp.CallToolRequest, inp
 	return nil, WorkspaceDetail{
 		Handle:       ws.Handle,
 		Purpose:      ws.Purpose,
+		Project:      ws.Project,
 		Repositories: repos,
 		CreatedAt:    ws.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}, nil
@@ -160,10 +170,12 @@ func (s *Server) createWorkspace(ctx context.Context, req *mcp.CallToolRequest,
 	}
 
 	ws, err := s.store.Create(ctx, workspace.CreateOptions{
-		Purpose:      input.Purpose,
-		Template:     input.Template,
-		TemplateVars: templateVars,
-		Repositories: repoOpts,
+		Purpose:        input.Purpose,
+		Project:        input.Project,
+		Template:       input.Template,
+		TemplateVars:   templateVars,
+		Repositories:   repoOpts,
+		IdempotencyKey: input.IdempotencyKey,
 	})
 	if err != nil {
 		return nil, CreateWorkspaceOutput{}, err
@@ -183,6 +195,7 @@ func (s *Server) createWorkspace(ctx context.Context, req *mcp.CallToolRequest,
 		Handle:       ws.Handle,
 		Purpose:      ws.Purpose,
 		Path:         ws.Path,
+		Created:      !ws.Existing,
 		Repositories: repos,
 	}, nil
 }
@@ -231,7 +244,7 @@ func (s *Server) execCommand(ctx context.Context, req *mcp.CallToolRequest, inpu
 		return nil, ExecCommandOutput{}, NewToolError("command is required. Provide an array of command and arguments.\nExample: {command: [\"make\", \"test\"]}")
 	}
 
-	shellPath, _ := detectShell()
+	shellPath, _ := shell.Detect()
 	command := []string{shellPath, "-c", strings.Join(input.Command, " ")}
 
 	execCtx := ctx
@@ -244,10 +257,14 @@ func (s *Server) execCommand(ctx context.Context, req *mcp.CallToolRequest, inpu
 	opts := workspace.ExecOptions{
 		Command:  command,
 		Target:   input.Repo,
-		Parallel: input.All,
+		Parallel: input.Parallel,
 	}
 
+	// Time the whole call rather than summing DurationMs: with Parallel
+	// the repositories run at once and their durations overlap.
+	startedAt := time.Now()
 	results, err := s.store.Exec(execCtx, handle, opts)
+	elapsed := time.Since(startedAt)
 	if err != nil {
 		return nil, ExecCommandOutput{}, err
 	}
@@ -274,7 +291,7 @@ func (s *Server) execCommand(ctx context.Context, req *mcp.CallToolRequest, inpu
 		Success:   maxExitCode == 0,
 		ExitCode:  maxExitCode,
 		Results:   resultInfos,
-		TotalTime: 0,
+		TotalTime: elapsed.Milliseconds(),
 	}, nil
 }
 
@@ -364,9 +381,15 @@ Use exit_workspace() to clear the active workspace when you're done.
 1. enter_workspace({handle: "..."})
 2. capture_state({name: "Before changes", description: "State before refactoring"})
 3. exec_command({command: ["make", "changes"]})
-4. If failed: apply_capture({capture_id: "..."})
+4. If failed: apply_capture({latest: true})
 5. If successful: capture_state({name: "After changes"})
 
+### Check a workspace before operating on it
+
+1. enter_workspace({handle: "..."})
+2. check_health({})
+3. If a repository has dirty_working_tree: capture_state({name: "Before agent changes"})
+
 ### Run tests across all repositories
 
 1. enter_workspace({handle: "..."})
@@ -392,7 +415,13 @@ Use exit_workspace() to clear the active workspace when you're done.
 
 1. enter_workspace({handle: "..."})
 2. get_workspace_path({})
-3. get_workspace_repo_path({repo_name: "myrepo"})`,
+3. get_workspace_repo_path({repo_name: "myrepo"})
+
+### Browse and read files without running commands
+
+1. enter_workspace({handle: "..."})
+2. list_files({repo_name: "myrepo", max_depth: 1, respect_gitignore: true})
+3. read_file({repo_name: "myrepo", path: "go.mod"})`,
 	}, nil
 }
 
@@ -422,14 +451,55 @@ func (s *Server) listCaptures(ctx context.Context, req *mcp.CallToolRequest, inp
 	return nil, ListCapturesOutput{Captures: result}, nil
 }
 
+func (s *Server) checkHealth(ctx context.Context, req *mcp.CallToolRequest, input CheckHealthInput) (*mcp.CallToolResult, CheckHealthOutput, error) {
+	handle, err := s.resolveHandle(ctx, input.Handle)
+	if err != nil {
+		return nil, CheckHealthOutput{}, err
+	}
+
+	report, err := s.store.CheckHealth(ctx, handle)
+	if err != nil {
+		return nil, CheckHealthOutput{}, err
+	}
+
+	issues := make([]HealthIssueInfo, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		issues = append(issues, HealthIssueInfo{
+			Kind:       issue.Kind,
+			Repository: issue.Repository,
+			Capture:    issue.Capture,
+			Message:    issue.Message,
+		})
+	}
+
+	return nil, CheckHealthOutput{
+		Handle:          report.Handle,
+		Healthy:         report.Healthy,
+		StaleExecutions: report.StaleExecutions,
+		Issues:          issues,
+	}, nil
+}
+
 func (s *Server) applyCapture(ctx context.Context, req *mcp.CallToolRequest, input ApplyCaptureInput) (*mcp.CallToolResult, ApplyCaptureOutput, error) {
 	handle, err := s.resolveHandle(ctx, input.Handle)
 	if err != nil {
 		return nil, ApplyCaptureOutput{}, err
 	}
 
+	if input.Latest || input.LatestTag != "" {
+		captures, err := s.store.ListCaptures(ctx, handle)
+		if err != nil {
+			return nil, ApplyCaptureOutput{}, err
+		}
+		latest, err := workspace.LatestCapture(captures, input.LatestTag)
+		if err != nil {
+			return nil, ApplyCaptureOutput{}, NewToolError(err.Error() + ". Use capture_state() to create one.")
+		}
+		input.CaptureID = latest.ID
+	}
+
 	if input.CaptureID == "" {
-		return nil, ApplyCaptureOutput{}, NewToolError("capture_id is required. Use list_captures() to see available captures.")
+		return nil, ApplyCaptureOutput{}, NewToolError("capture_id is required (or set latest). Use list_captures() to see available captures.")
 	}
 
 	if input.DryRun {
@@ -446,14 +516,16 @@ func (s *Server) applyCapture(ctx context.Context, req *mcp.CallToolRequest, inp
 				errors = append(errors, fmt.Sprintf("%s: %s (%s)", e.Repository, e.Reason, e.Details))
 			}
 			return nil, ApplyCaptureOutput{
-				Success: false,
-				Message: "Preflight check failed",
-				Errors:  errors,
+				Success:   false,
+				CaptureID: input.CaptureID,
+				Message:   "Preflight check failed",
+				Errors:    errors,
 			}, nil
 		}
 		return nil, ApplyCaptureOutput{
-			Success: true,
-			Message: "Preflight check passed - would apply cleanly",
+			Success:   true,
+			CaptureID: input.CaptureID,
+			Message:   "Preflight check passed - would apply cleanly",
 		}, nil
 	}
 
@@ -466,8 +538,9 @@ func (s *Server) applyCapture(ctx context.Context, req *mcp.CallToolRequest, inp
 	}
 
 	return nil, ApplyCaptureOutput{
-		Success: true,
-		Message: "Capture applied successfully",
+		Success:   true,
+		CaptureID: input.CaptureID,
+		Message:   "Capture applied successfully",
 	}, nil
 }
 
@@ -566,6 +639,209 @@ func (s *Server) getWorkspaceRepoPath(ctx context.Context, req *mcp.CallToolRequ
 	return nil, GetWorkspacePathOutput{Path: repoPath}, nil
 }
 
+// readFileLimit caps how much of a file read_file returns, keeping large or
+// generated files from flooding the agent's context.
+const readFileLimit = 256 * 1024
+
+// repoFilePath resolves rel inside a workspace repository. Paths that are
+// absolute, climb out with "..", or leave the repository through a symlink
+// are rejected.
+func (s *Server) repoFilePath(ctx context.Context, handle, repoName, rel string) (string, error) {
+	repoPath, err := s.store.GetRepositoryPath(ctx, handle, repoName)
+	if err != nil {
+		if _, getErr := s.store.Get(ctx, handle); getErr != nil {
+			return "", s.workspaceNotFoundError(ctx, handle)
+		}
+		return "", NewToolError(fmt.Sprintf("repository %q not found in workspace %q. Use get_workspace() to see available repositories.", repoName, handle))
+	}
+
+	if filepath.IsAbs(rel) {
+		return "", NewToolError(fmt.Sprintf("path %q must be relative to the repository", rel))
+	}
+	for _, part := range strings.FieldsFunc(filepath.ToSlash(rel), func(r rune) bool { return r == '/' }) {
+		if part == ".." {
+			return "", NewToolError(fmt.Sprintf("path %q escapes the repository: \"..\" is not allowed", rel))
+		}
+	}
+
+	full := filepath.Join(repoPath, rel)
+	resolved, err := filepath.EvalSymlinks(full)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", NewToolError(fmt.Sprintf("path %q not found in repository %q", rel, repoName))
+		}
+		return "", err
+	}
+	root, err := filepath.EvalSymlinks(repoPath)
+	if err != nil {
+		return "", err
+	}
+	if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+		return "", NewToolError(fmt.Sprintf("path %q escapes the repository through a symlink", rel))
+	}
+	return resolved, nil
+}
+
+func (s *Server) readFile(ctx context.Context, req *mcp.CallToolRequest, input ReadFileInput) (*mcp.CallToolResult, ReadFileOutput, error) {
+	handle, err := s.resolveHandle(ctx, input.Handle)
+	if err != nil {
+		return nil, ReadFileOutput{}, err
+	}
+	if input.RepoName == "" {
+		return nil, ReadFileOutput{}, NewToolError("repo_name is required. Use get_workspace() to see available repositories, then provide the repository name.")
+	}
+	if input.Path == "" {
+		return nil, ReadFileOutput{}, NewToolError("path is required. Provide a file path relative to the repository, e.g. \"README.md\".")
+	}
+
+	path, err := s.repoFilePath(ctx, handle, input.RepoName, input.Path)
+	if err != nil {
+		return nil, ReadFileOutput{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, ReadFileOutput{}, err
+	}
+	if info.IsDir() {
+		return nil, ReadFileOutput{}, NewToolError(fmt.Sprintf("path %q is a directory", input.Path))
+	}
+
+	limit := int64(readFileLimit)
+	if input.MaxBytes > 0 && int64(input.MaxBytes) < limit {
+		limit = int64(input.MaxBytes)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, ReadFileOutput{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(f, limit))
+	if err != nil {
+		return nil, ReadFileOutput{}, err
+	}
+
+	out := ReadFileOutput{
+		Path:    input.Path,
+		Content: string(data),
+		Size:    info.Size(),
+	}
+	if info.Size() > limit {
+		out.Truncated = true
+		out.Content += fmt.Sprintf("\n... (truncated: showing %d of %d bytes)", limit, info.Size())
+	}
+	return nil, out, nil
+}
+
+// Defaults and caps for list_files, so a large repository cannot flood the
+// agent's context.
+const (
+	listFilesDefaultDepth = 2
+	listFilesLimit        = 500
+)
+
+func (s *Server) listFiles(ctx context.Context, req *mcp.CallToolRequest, input ListFilesInput) (*mcp.CallToolResult, ListFilesOutput, error) {
+	handle, err := s.resolveHandle(ctx, input.Handle)
+	if err != nil {
+		return nil, ListFilesOutput{}, err
+	}
+	if input.RepoName == "" {
+		return nil, ListFilesOutput{}, NewToolError("repo_name is required. Use get_workspace() to see available repositories, then provide the repository name.")
+	}
+
+	base, err := s.repoFilePath(ctx, handle, input.RepoName, input.Path)
+	if err != nil {
+		return nil, ListFilesOutput{}, err
+	}
+	info, err := os.Stat(base)
+	if err != nil {
+		return nil, ListFilesOutput{}, err
+	}
+	if !info.IsDir() {
+		return nil, ListFilesOutput{}, NewToolError(fmt.Sprintf("path %q is a file. Use read_file() to read it.", input.Path))
+	}
+
+	maxDepth := input.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = listFilesDefaultDepth
+	}
+	limit := listFilesLimit
+	if input.MaxEntries > 0 && input.MaxEntries < limit {
+		limit = input.MaxEntries
+	}
+
+	// With respect_gitignore, only paths git would track are listed: the
+	// tracked and untracked non-ignored files, and the directories holding them.
+	var visible map[string]bool
+	if input.RespectGitignore {
+		repoPath, err := s.store.GetRepositoryPath(ctx, handle, input.RepoName)
+		if err != nil {
+			return nil, ListFilesOutput{}, err
+		}
+		files, err := s.git.ListFiles(ctx, repoPath)
+		if err != nil {
+			return nil, ListFilesOutput{}, err
+		}
+		visible = make(map[string]bool, len(files))
+		for _, f := range files {
+			for p := f; p != "." && p != "/" && !visible[p]; p = path.Dir(p) {
+				visible[p] = true
+			}
+		}
+	}
+
+	prefix := filepath.ToSlash(filepath.Clean(input.Path))
+	out := ListFilesOutput{Path: prefix, Entries: []FileEntry{}}
+	err = filepath.WalkDir(base, func(p string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, relErr := filepath.Rel(base, p)
+		if relErr != nil || rel == "." {
+			return nil
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		repoRel := path.Join(prefix, filepath.ToSlash(rel))
+		if visible != nil && !visible[repoRel] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if len(out.Entries) >= limit {
+			out.Truncated = true
+			return filepath.SkipAll
+		}
+		entry := FileEntry{Path: repoRel, Type: "file"}
+		switch {
+		case d.Type()&os.ModeSymlink != 0:
+			entry.Type = "symlink"
+		case d.IsDir():
+			entry.Type = "dir"
+		default:
+			if fi, err := d.Info(); err == nil {
+				entry.Size = fi.Size()
+			}
+		}
+		out.Entries = append(out.Entries, entry)
+
+		if d.IsDir() && strings.Count(filepath.ToSlash(rel), "/")+1 >= maxDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, ListFilesOutput{}, err
+	}
+	return nil, out, nil
+}
+
 func (s *Server) addRepository(ctx context.Context, req *mcp.CallToolRequest, input AddRepositoryInput) (*mcp.CallToolResult, AddRepositoryOutput, error) {
 	handle, err := s.resolveHandle(ctx, input.Handle)
 	if err != nil {
@@ -672,7 +948,7 @@ func (s *Server) Run(ctx context.Context) error {
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "list_workspaces",
-		Description: "List all Workshed workspaces with their handles, purposes, and repository counts. Use this to discover available workspaces.",
+		Description: "List all Workshed workspaces with their handles, purposes, projects, and repository counts. Use this to discover available workspaces. Optionally pass project to list only that project's workspaces, and created_after or created_before (RFC3339, a date, or an age like 7d) to filter by creation time.",
 	}, s.listWorkspaces)
 
 	mcp.AddTool(server, &mcp.Tool{
@@ -687,7 +963,7 @@ func (s *Server) Run(ctx context.Context) error {
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "create_workspace",
-		Description: "Create a new workspace. Parameters: purpose (required, brief description), repos (array of git URLs with optional @ref, e.g., \"github.com/org/repo@main\"), template, template_vars. Returns a new workspace handle (random identifier like \"aquatic-fish-motion\"), path, and repository details.",
+		Description: "Create a new workspace. Parameters: purpose (required, brief description), project (optional group name), repos (array of git URLs with optional @ref, e.g., \"github.com/org/repo@main\"), template, template_vars, idempotency_key (optional; a retry with the same key returns the existing workspace with created=false). Returns a new workspace handle (random identifier like \"aquatic-fish-motion\"), path, and repository details.",
 	}, s.createWorkspace)
 
 	mcp.AddTool(server, &mcp.Tool{
@@ -707,7 +983,7 @@ func (s *Server) Run(ctx context.Context) error {
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "exec_command",
-		Description: "Execute a command in a workspace. Parameters: handle (workspace identifier), repo (repository name), all (run in all repos), timeout (max milliseconds), output_limit (max output characters). Command runs in a shell with detected $SHELL, falling back to /bin/sh.",
+		Description: "Execute a command in a workspace. Parameters: handle (workspace identifier), repo (repository name), all (run in all repos), parallel (run repos concurrently and keep going past failures), timeout (max milliseconds), output_limit (max output characters). Command runs in a shell with detected $SHELL, falling back to /bin/sh.",
 	}, s.execCommand)
 
 	mcp.AddTool(server, &mcp.Tool{
@@ -722,9 +998,14 @@ func (s *Server) Run(ctx context.Context) error {
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "apply_capture",
-		Description: "Apply (restore) git state from a capture. If handle is not provided, uses the active workspace (set with enter_workspace). Takes a capture ID. Set dry_run to true to check preflight without applying.",
+		Description: "Apply (restore) git state from a capture. If handle is not provided, uses the active workspace (set with enter_workspace). Takes a capture ID, or set latest to restore the most recent capture (latest_tag picks the newest capture with that tag); the applied capture_id is returned. Set dry_run to true to check preflight without applying.",
 	}, s.applyCapture)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "check_health",
+		Description: "Check whether a workspace is in a good state before operating on it. If handle is not provided, uses the active workspace (set with enter_workspace). Returns healthy plus a list of issues, each with a kind (stale_executions, missing_repository, not_a_git_repository, dirty_working_tree, ref_drift, capture_missing_repository), repository, and message. Capture first if a repository is dirty.",
+	}, s.checkHealth)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "export_workspace",
 		Description: "Export a workspace to portable JSON format. If handle is not provided, uses the active workspace (set with enter_workspace). Includes metadata, repository config, and optionally captures. Set compact to exclude captures.",
@@ -745,6 +1026,16 @@ func (s *Server) Run(ctx context.Context) error {
 		Description: "Get the filesystem path for a specific repository within a workspace. If handle is not provided, uses the active workspace (set with enter_workspace). Takes a repository name and returns its directory path.",
 	}, s.getWorkspaceRepoPath)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "read_file",
+		Description: "Read a file from a repository in a workspace. If handle is not provided, uses the active workspace (set with enter_workspace). Takes a repository name and a path relative to the repository; paths containing \"..\" are rejected. Content is capped at 256 KiB (lower it with max_bytes); truncated is true when the file was cut short.",
+	}, s.readFile)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_files",
+		Description: "List the files and directories of a repository in a workspace. If handle is not provided, uses the active workspace (set with enter_workspace). Takes a repository name, an optional path relative to the repository (paths containing \"..\" are rejected), max_depth (default 2), max_entries (default and cap 500) and respect_gitignore to hide ignored files. The .git directory is never listed; truncated is true when entries were cut off.",
+	}, s.listFiles)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "add_repository",
 		Description: "Add a repository to an existing workspace. If handle is not provided, uses the active workspace (set with enter_workspace). Takes a repo URL with optional @ref (e.g., github.com/org/repo@main). Returns the added repository details.",
@@ -3,6 +3,9 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -24,7 +27,7 @@ func TestListWorkspaces(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("empty list", func(t *testing.T) {
-		_, out, err := server.listWorkspaces(ctx, nil, struct{}{})
+		_, out, err := server.listWorkspaces(ctx, nil, ListWorkspacesInput{})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -37,7 +40,7 @@ func TestListWorkspaces(t *testing.T) {
 		_, _, _ = server.createWorkspace(ctx, nil, CreateWorkspaceInput{Purpose: "test 1"})
 		_, _, _ = server.createWorkspace(ctx, nil, CreateWorkspaceInput{Purpose: "test 2"})
 
-		_, out, err := server.listWorkspaces(ctx, nil, struct{}{})
+		_, out, err := server.listWorkspaces(ctx, nil, ListWorkspacesInput{})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -47,6 +50,56 @@ func TestListWorkspaces(t *testing.T) {
 	})
 }
 
+func TestListWorkspacesProjectFilter(t *testing.T) {
+	t.Parallel()
+	store, _ := workspace.CreateTestStore(t)
+	server := newTestServer(store)
+	ctx := context.Background()
+
+	_, _, _ = server.createWorkspace(ctx, nil, CreateWorkspaceInput{Purpose: "pay", Project: "payments"})
+	_, _, _ = server.createWorkspace(ctx, nil, CreateWorkspaceInput{Purpose: "search", Project: "search"})
+
+	_, out, err := server.listWorkspaces(ctx, nil, ListWorkspacesInput{Project: "payments"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Workspaces) != 1 {
+		t.Fatalf("expected 1 workspace, got %d", len(out.Workspaces))
+	}
+	if out.Workspaces[0].Project != "payments" {
+		t.Errorf("expected project payments, got %q", out.Workspaces[0].Project)
+	}
+}
+
+func TestListWorkspacesCreatedRange(t *testing.T) {
+	t.Parallel()
+	store, _ := workspace.CreateTestStore(t)
+	server := newTestServer(store)
+	ctx := context.Background()
+
+	_, _, _ = server.createWorkspace(ctx, nil, CreateWorkspaceInput{Purpose: "fresh"})
+
+	_, out, err := server.listWorkspaces(ctx, nil, ListWorkspacesInput{CreatedAfter: "1d"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Workspaces) != 1 {
+		t.Errorf("expected 1 workspace, got %d", len(out.Workspaces))
+	}
+
+	_, out, err = server.listWorkspaces(ctx, nil, ListWorkspacesInput{CreatedBefore: "1d"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out.Workspaces) != 0 {
+		t.Errorf("expected no workspaces, got %d", len(out.Workspaces))
+	}
+
+	if _, _, err := server.listWorkspaces(ctx, nil, ListWorkspacesInput{CreatedAfter: "soon"}); err == nil {
+		t.Error("expected error for invalid created_after")
+	}
+}
+
 func TestGetWorkspace(t *testing.T) {
 	t.Parallel()
 	store, _ := workspace.CreateTestStore(t)
@@ -283,6 +336,24 @@ func TestExecCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("total time covers the whole run", func(t *testing.T) {
+		_, out, err := server.execCommand(ctx, nil, ExecCommandInput{
+			Handle:  &createOut.Handle,
+			Command: []string{"sleep", "0.2"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.TotalTime < 200 {
+			t.Errorf("expected total time of at least 200ms, got %d", out.TotalTime)
+		}
+		for _, r := range out.Results {
+			if out.TotalTime < r.DurationMs {
+				t.Errorf("total time %dms is less than %s's %dms", out.TotalTime, r.Repository, r.DurationMs)
+			}
+		}
+	})
+
 	t.Run("with target repo", func(t *testing.T) {
 		_, out, err := server.execCommand(ctx, nil, ExecCommandInput{
 			Handle:  &createOut.Handle,
@@ -485,6 +556,83 @@ func TestApplyCapture(t *testing.T) {
 			t.Error("expected error for nonexistent capture")
 		}
 	})
+
+	t.Run("latest", func(t *testing.T) {
+		_, newer, err := server.captureState(ctx, nil, CaptureStateInput{Handle: &createOut.Handle, Name: "newer", Description: "newest capture"})
+		if err != nil {
+			t.Fatalf("captureState failed: %v", err)
+		}
+		_, out, err := server.applyCapture(ctx, nil, ApplyCaptureInput{Handle: &createOut.Handle, Latest: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !out.Success || out.CaptureID != newer.ID {
+			t.Errorf("expected newest capture %s applied, got %+v", newer.ID, out)
+		}
+	})
+
+	t.Run("latest tag without matches", func(t *testing.T) {
+		_, _, err := server.applyCapture(ctx, nil, ApplyCaptureInput{Handle: &createOut.Handle, LatestTag: "missing"})
+		if err == nil {
+			t.Error("expected error when no capture carries the tag")
+		}
+	})
+}
+
+func TestCheckHealth(t *testing.T) {
+	t.Parallel()
+	store, _ := workspace.CreateTestStore(t)
+	server := newTestServer(store)
+	ctx := context.Background()
+	localRepo := workspace.CreateLocalGitRepo(t, "healthtestrepo", map[string]string{"file.txt": "content"})
+	_, createOut, err := server.createWorkspace(ctx, nil, CreateWorkspaceInput{
+		Purpose: "health test",
+		Repos:   []string{localRepo},
+	})
+	if err != nil {
+		t.Fatalf("createWorkspace failed: %v", err)
+	}
+
+	t.Run("handle required", func(t *testing.T) {
+		_, _, err := server.checkHealth(ctx, nil, CheckHealthInput{})
+		if err == nil {
+			t.Error("expected error for empty handle")
+		}
+	})
+
+	t.Run("healthy", func(t *testing.T) {
+		_, out, err := server.checkHealth(ctx, nil, CheckHealthInput{Handle: &createOut.Handle})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !out.Healthy || len(out.Issues) != 0 {
+			t.Errorf("expected healthy workspace, got %+v", out)
+		}
+	})
+
+	t.Run("reports dirty repository", func(t *testing.T) {
+		repo := createOut.Repositories[0]
+		if err := os.WriteFile(filepath.Join(repo.Path, "file.txt"), []byte("changed"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		_, out, err := server.checkHealth(ctx, nil, CheckHealthInput{Handle: &createOut.Handle})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.Healthy {
+			t.Error("expected unhealthy workspace")
+		}
+		found := false
+		for _, issue := range out.Issues {
+			if issue.Kind == workspace.HealthDirtyWorkingTree && issue.Repository == repo.Name {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected dirty_working_tree issue for %s, got %+v", repo.Name, out.Issues)
+		}
+	})
 }
 
 func TestExportWorkspace(t *testing.T) {
@@ -553,6 +701,17 @@ func TestImportWorkspace(t *testing.T) {
 		}
 	})
 
+	t.Run("context from a newer version", func(t *testing.T) {
+		_, _, err := server.importWorkspace(ctx, nil, ImportWorkspaceInput{Context: map[string]any{
+			"version":      workspace.ContextVersion + 1,
+			"purpose":      "future",
+			"repositories": []any{map[string]any{"url": "https://github.com/org/repo"}},
+		}})
+		if err == nil || !strings.Contains(err.Error(), "please upgrade") {
+			t.Errorf("expected upgrade error, got %v", err)
+		}
+	})
+
 	t.Run("missing purpose in context", func(t *testing.T) {
 		_, _, err := server.importWorkspace(ctx, nil, ImportWorkspaceInput{Context: map[string]any{
 			"handle":       "test",
@@ -669,6 +828,193 @@ func TestGetWorkspaceRepoPath(t *testing.T) {
 	})
 }
 
+func TestReadFile(t *testing.T) {
+	t.Parallel()
+	store, _ := workspace.CreateTestStore(t)
+	server := newTestServer(store)
+	ctx := context.Background()
+	localRepo := workspace.CreateLocalGitRepo(t, "readrepo", map[string]string{
+		"README.md":    "# Read me",
+		"docs/big.txt": strings.Repeat("x", readFileLimit+100),
+	})
+	_, createOut, err := server.createWorkspace(ctx, nil, CreateWorkspaceInput{
+		Purpose: "read file test",
+		Repos:   []string{localRepo},
+	})
+	if err != nil {
+		t.Fatalf("createWorkspace failed: %v", err)
+	}
+
+	t.Run("reads a file", func(t *testing.T) {
+		_, out, err := server.readFile(ctx, nil, ReadFileInput{Handle: &createOut.Handle, RepoName: "readrepo", Path: "README.md"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.Content != "# Read me" || out.Truncated || out.Size != int64(len("# Read me")) {
+			t.Errorf("unexpected output: %+v", out)
+		}
+	})
+
+	t.Run("truncates large files", func(t *testing.T) {
+		_, out, err := server.readFile(ctx, nil, ReadFileInput{Handle: &createOut.Handle, RepoName: "readrepo", Path: "docs/big.txt"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !out.Truncated || out.Size != readFileLimit+100 {
+			t.Errorf("expected a truncated read of %d bytes, got truncated=%v size=%d", readFileLimit+100, out.Truncated, out.Size)
+		}
+		if !strings.HasPrefix(out.Content, strings.Repeat("x", readFileLimit)+"\n") || !strings.Contains(out.Content, "truncated") {
+			t.Errorf("expected the capped content followed by a note, got %q", out.Content[len(out.Content)-60:])
+		}
+	})
+
+	t.Run("max_bytes lowers the cap", func(t *testing.T) {
+		_, out, err := server.readFile(ctx, nil, ReadFileInput{Handle: &createOut.Handle, RepoName: "readrepo", Path: "README.md", MaxBytes: 3})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !out.Truncated || !strings.HasPrefix(out.Content, "# R\n") {
+			t.Errorf("expected 3 bytes and a note, got %+v", out)
+		}
+	})
+
+	t.Run("rejects traversal", func(t *testing.T) {
+		for _, path := range []string{"../../etc/passwd", "docs/../../readrepo/README.md", "/etc/passwd"} {
+			_, _, err := server.readFile(ctx, nil, ReadFileInput{Handle: &createOut.Handle, RepoName: "readrepo", Path: path})
+			var toolErr *ToolError
+			if !errors.As(err, &toolErr) {
+				t.Errorf("expected a tool error for %q, got %v", path, err)
+			}
+		}
+	})
+
+	t.Run("rejects symlinks out of the repository", func(t *testing.T) {
+		outside := t.TempDir()
+		if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := os.Symlink(outside, filepath.Join(createOut.Path, "readrepo", "outside")); err != nil {
+			t.Fatalf("Symlink failed: %v", err)
+		}
+		_, _, err := server.readFile(ctx, nil, ReadFileInput{Handle: &createOut.Handle, RepoName: "readrepo", Path: "outside/secret"})
+		if err == nil || !strings.Contains(err.Error(), "symlink") {
+			t.Errorf("expected a symlink escape error, got %v", err)
+		}
+	})
+
+	t.Run("unknown repository", func(t *testing.T) {
+		_, _, err := server.readFile(ctx, nil, ReadFileInput{Handle: &createOut.Handle, RepoName: "nonexistent", Path: "README.md"})
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("expected a not found error, got %v", err)
+		}
+	})
+}
+
+func TestListFiles(t *testing.T) {
+	t.Parallel()
+	store, _ := workspace.CreateTestStore(t)
+	server := newTestServer(store)
+	ctx := context.Background()
+	localRepo := workspace.CreateLocalGitRepo(t, "listrepo", map[string]string{
+		"README.md":           "# List",
+		".gitignore":          "build/\n",
+		"src/main.go":         "package main",
+		"src/pkg/util/a.go":   "package util",
+		"docs/guide/intro.md": "intro",
+	})
+	_, createOut, err := server.createWorkspace(ctx, nil, CreateWorkspaceInput{
+		Purpose: "list files test",
+		Repos:   []string{localRepo},
+	})
+	if err != nil {
+		t.Fatalf("createWorkspace failed: %v", err)
+	}
+	buildDir := filepath.Join(createOut.Path, "listrepo", "build")
+	if err := os.MkdirAll(buildDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(buildDir, "out.bin"), []byte("bin"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	list := func(t *testing.T, input ListFilesInput) map[string]string {
+		t.Helper()
+		input.Handle = &createOut.Handle
+		input.RepoName = "listrepo"
+		_, out, err := server.listFiles(ctx, nil, input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		entries := make(map[string]string, len(out.Entries))
+		for _, e := range out.Entries {
+			entries[e.Path] = e.Type
+		}
+		return entries
+	}
+
+	t.Run("lists repository contents", func(t *testing.T) {
+		entries := list(t, ListFilesInput{MaxDepth: 10})
+		for path, typ := range map[string]string{"README.md": "file", "src": "dir", "src/pkg/util/a.go": "file", "build/out.bin": "file"} {
+			if entries[path] != typ {
+				t.Errorf("expected %s as %s, got %v", path, typ, entries)
+			}
+		}
+		for path := range entries {
+			if path == ".git" || strings.HasPrefix(path, ".git/") {
+				t.Errorf("expected .git to be skipped, got %s", path)
+			}
+		}
+	})
+
+	t.Run("honors max_depth", func(t *testing.T) {
+		entries := list(t, ListFilesInput{MaxDepth: 1})
+		if entries["src"] != "dir" || entries["README.md"] != "file" {
+			t.Errorf("expected top-level entries, got %v", entries)
+		}
+		if _, ok := entries["src/main.go"]; ok {
+			t.Errorf("expected depth 1 to stop at the top level, got %v", entries)
+		}
+
+		entries = list(t, ListFilesInput{Path: "src", MaxDepth: 2})
+		if entries["src/main.go"] != "file" || entries["src/pkg/util"] != "dir" {
+			t.Errorf("expected two levels under src, got %v", entries)
+		}
+		if _, ok := entries["src/pkg/util/a.go"]; ok {
+			t.Errorf("expected depth 2 to stop before src/pkg/util/a.go, got %v", entries)
+		}
+	})
+
+	t.Run("respects gitignore", func(t *testing.T) {
+		entries := list(t, ListFilesInput{MaxDepth: 10, RespectGitignore: true})
+		if _, ok := entries["build"]; ok {
+			t.Errorf("expected ignored build/ to be hidden, got %v", entries)
+		}
+		if entries["docs/guide/intro.md"] != "file" {
+			t.Errorf("expected tracked files to be listed, got %v", entries)
+		}
+	})
+
+	t.Run("caps entries", func(t *testing.T) {
+		_, out, err := server.listFiles(ctx, nil, ListFilesInput{Handle: &createOut.Handle, RepoName: "listrepo", MaxDepth: 10, MaxEntries: 2})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(out.Entries) != 2 || !out.Truncated {
+			t.Errorf("expected 2 entries and truncated, got %+v", out)
+		}
+	})
+
+	t.Run("rejects escaping subpaths", func(t *testing.T) {
+		for _, path := range []string{"..", "src/../..", "/tmp"} {
+			_, _, err := server.listFiles(ctx, nil, ListFilesInput{Handle: &createOut.Handle, RepoName: "listrepo", Path: path})
+			var toolErr *ToolError
+			if !errors.As(err, &toolErr) {
+				t.Errorf("expected a tool error for %q, got %v", path, err)
+			}
+		}
+	})
+}
+
 func TestAddRepository(t *testing.T) {
 	t.Parallel()
 	store, _ := workspace.CreateTestStore(t)
@@ -3,6 +3,7 @@ package mcp
 type WorkspaceInfo struct {
 	Handle    string `json:"handle"`
 	Purpose   string `json:"purpose"`
+	Project   string `json:"project,omitempty"`
 	RepoCount int    `json:"repo_count"`
 	CreatedAt string `json:"created_at"`
 }
@@ -17,22 +18,26 @@ type RepositoryInfo struct {
 type WorkspaceDetail struct {
 	Handle       string           `json:"handle"`
 	Purpose      string           `json:"purpose"`
+	Project      string           `json:"project,omitempty"`
 	Repositories []RepositoryInfo `json:"repositories"`
 	CreatedAt    string           `json:"created_at"`
 }
 
 type CreateWorkspaceInput struct {
-	Purpose      string   `json:"purpose"`
-	Repos        []string `json:"repos,omitempty"`
-	Template     string   `json:"template,omitempty"`
-	TemplateVars []string `json:"template_vars,omitempty"`
-	Depth        int      `json:"depth,omitempty"`
+	Purpose        string   `json:"purpose"`
+	Project        string   `json:"project,omitempty"`
+	Repos          []string `json:"repos,omitempty"`
+	Template       string   `json:"template,omitempty"`
+	TemplateVars   []string `json:"template_vars,omitempty"`
+	Depth          int      `json:"depth,omitempty"`
+	IdempotencyKey string   `json:"idempotency_key,omitempty"`
 }
 
 type CreateWorkspaceOutput struct {
 	Handle       string           `json:"handle"`
 	Purpose      string           `json:"purpose"`
 	Path         string           `json:"path"`
+	Created      bool             `json:"created"`
 	Repositories []RepositoryInfo `json:"repositories"`
 }
 
@@ -55,6 +60,7 @@ type ExecCommandInput struct {
 	Command     []string `json:"command"`
 	Repo        string   `json:"repo,omitempty"`
 	All         bool     `json:"all,omitempty"`
+	Parallel    bool     `json:"parallel,omitempty"`
 	NoRecord    bool     `json:"no_record,omitempty"`
 	Timeout     int      `json:"timeout,omitempty"`
 	OutputLimit int      `json:"output_limit,omitempty"`
@@ -112,20 +118,49 @@ type ListCapturesOutput struct {
 	Captures []CaptureInfo `json:"captures"`
 }
 
+type CheckHealthInput struct {
+	Handle *string `json:"handle,omitempty"`
+}
+
+type HealthIssueInfo struct {
+	Kind       string `json:"kind"`
+	Repository string `json:"repository,omitempty"`
+	Capture    string `json:"capture,omitempty"`
+	Message    string `json:"message"`
+}
+
+type CheckHealthOutput struct {
+	Handle          string            `json:"handle"`
+	Healthy         bool              `json:"healthy"`
+	StaleExecutions int               `json:"stale_executions"`
+	Issues          []HealthIssueInfo `json:"issues"`
+}
+
+type ListWorkspacesInput struct {
+	Project string `json:"project,omitempty"`
+	// CreatedAfter and CreatedBefore take an RFC3339 time, a date or an age
+	// such as 7d, as list --created-after and --created-before do.
+	CreatedAfter  string `json:"created_after,omitempty"`
+	CreatedBefore string `json:"created_before,omitempty"`
+}
+
 type ListWorkspacesOutput struct {
 	Workspaces []WorkspaceInfo `json:"workspaces"`
 }
 
 type ApplyCaptureInput struct {
 	Handle    *string `json:"handle,omitempty"`
-	CaptureID string  `json:"capture_id"`
+	CaptureID string  `json:"capture_id,omitempty"`
+	Latest    bool    `json:"latest,omitempty"`
+	LatestTag string  `json:"latest_tag,omitempty"`
 	DryRun    bool    `json:"dry_run,omitempty"`
 }
 
 type ApplyCaptureOutput struct {
-	Success bool     `json:"success"`
-	Message string   `json:"message,omitempty"`
-	Errors  []string `json:"errors,omitempty"`
+	Success   bool     `json:"success"`
+	CaptureID string   `json:"capture_id,omitempty"`
+	Message   string   `json:"message,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
 }
 
 type ExportWorkspaceInput struct {
@@ -193,6 +228,41 @@ type GetWorkspaceRepoPathInput struct {
 	RepoName string  `json:"repo_name"`
 }
 
+type ReadFileInput struct {
+	Handle   *string `json:"handle,omitempty"`
+	RepoName string  `json:"repo_name"`
+	Path     string  `json:"path"`
+	MaxBytes int     `json:"max_bytes,omitempty"`
+}
+
+type ReadFileOutput struct {
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Size      int64  `json:"size"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+type ListFilesInput struct {
+	Handle           *string `json:"handle,omitempty"`
+	RepoName         string  `json:"repo_name"`
+	Path             string  `json:"path,omitempty"`
+	MaxDepth         int     `json:"max_depth,omitempty"`
+	MaxEntries       int     `json:"max_entries,omitempty"`
+	RespectGitignore bool    `json:"respect_gitignore,omitempty"`
+}
+
+type FileEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Size int64  `json:"size,omitempty"`
+}
+
+type ListFilesOutput struct {
+	Path      string      `json:"path"`
+	Entries   []FileEntry `json:"entries"`
+	Truncated bool        `json:"truncated,omitempty"`
+}
+
 type GetWorkspaceInput struct {
 	Handle *string `json:"handle,omitempty"`
 }
@@ -0,0 +1,23 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+)
+
+// Detect returns the user's $SHELL, falling back to common shells when it is unset or missing.
+func Detect() (string, error) {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		if _, err := os.Stat(shell); err == nil {
+			return shell, nil
+		}
+	}
+
+	for _, shell := range []string{"/bin/bash", "/bin/zsh", "/bin/sh"} {
+		if _, err := os.Stat(shell); err == nil {
+			return shell, nil
+		}
+	}
+
+	return "", fmt.Errorf("no suitable shell found")
+}
@@ -0,0 +1,168 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/frodi/workshed/internal/tui/components"
+)
+
+// ErrSelectionCanceled is returned by RunSelector when the user backs out.
+var ErrSelectionCanceled = errors.New("selection canceled")
+
+var selectorKeys = struct {
+	Up, Down, Toggle, All, Confirm, Cancel key.Binding
+}{
+	Up:      key.NewBinding(key.WithKeys("up", "k")),
+	Down:    key.NewBinding(key.WithKeys("down", "j")),
+	Toggle:  key.NewBinding(key.WithKeys(" ", "x")),
+	All:     key.NewBinding(key.WithKeys("a")),
+	Confirm: key.NewBinding(key.WithKeys("enter")),
+	Cancel:  key.NewBinding(key.WithKeys("esc", "q", "ctrl+c")),
+}
+
+var selectorHelp = components.RenderHelp([]components.HelpItem{
+	{Key: "↑↓/j/k", Label: "Navigate"},
+	{Key: "Space", Label: "Toggle"},
+	{Key: "a", Label: "All"},
+	{Key: "Enter", Label: "Confirm"},
+	{Key: "Esc", Label: "Cancel"},
+})
+
+// SelectorModel is a checkbox list for picking items from the command line
+// without opening the dashboard. Confirming with nothing checked picks the
+// item under the cursor.
+type SelectorModel struct {
+	title    string
+	items    []string
+	checked  []bool
+	cursor   int
+	done     bool
+	canceled bool
+}
+
+func NewSelectorModel(title string, items []string) SelectorModel {
+	return SelectorModel{
+		title:   title,
+		items:   items,
+		checked: make([]bool, len(items)),
+	}
+}
+
+func (m SelectorModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m SelectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, selectorKeys.Up):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case key.Matches(keyMsg, selectorKeys.Down):
+		if m.cursor < len(m.items)-1 {
+			m.cursor++
+		}
+	case key.Matches(keyMsg, selectorKeys.Toggle):
+		if len(m.items) > 0 {
+			m.checked[m.cursor] = !m.checked[m.cursor]
+		}
+	case key.Matches(keyMsg, selectorKeys.All):
+		all := !m.allChecked()
+		for i := range m.checked {
+			m.checked[i] = all
+		}
+	case key.Matches(keyMsg, selectorKeys.Confirm):
+		if len(m.items) == 0 {
+			return m, nil
+		}
+		if len(m.Selected()) == 0 {
+			m.checked[m.cursor] = true
+		}
+		m.done = true
+		return m, tea.Quit
+	case key.Matches(keyMsg, selectorKeys.Cancel):
+		m.canceled = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m SelectorModel) allChecked() bool {
+	for _, c := range m.checked {
+		if !c {
+			return false
+		}
+	}
+	return true
+}
+
+func (m SelectorModel) View() string {
+	if m.done || m.canceled {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(components.ColorText).Render(m.title))
+	b.WriteString("\n\n")
+	for i, item := range m.items {
+		box := "[ ]"
+		if m.checked[i] {
+			box = "[x]"
+		}
+		line := box + " " + item
+		if i == m.cursor {
+			line = lipgloss.NewStyle().Foreground(components.ColorHighlight).Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n" + selectorHelp + "\n")
+	return b.String()
+}
+
+// Selected returns the checked items in list order.
+func (m SelectorModel) Selected() []string {
+	var selected []string
+	for i, item := range m.items {
+		if m.checked[i] {
+			selected = append(selected, item)
+		}
+	}
+	return selected
+}
+
+// Canceled reports whether the user backed out of the selection.
+func (m SelectorModel) Canceled() bool {
+	return m.canceled
+}
+
+// RunSelector shows a checkbox list of items on stderr, keeping stdout free
+// for the command's own output, and returns the items picked.
+func RunSelector(title string, items []string) ([]string, error) {
+	if len(items) == 0 {
+		return nil, errors.New("nothing to select")
+	}
+
+	finalModel, err := tea.NewProgram(NewSelectorModel(title, items), tea.WithOutput(os.Stderr)).Run()
+	if err != nil {
+		return nil, fmt.Errorf("running selector: %w", err)
+	}
+
+	m, ok := finalModel.(SelectorModel)
+	if !ok || m.Canceled() {
+		return nil, ErrSelectionCanceled
+	}
+	return m.Selected(), nil
+}
@@ -284,18 +284,59 @@ func (s *mockStore) List(ctx context.Context, opts workspace.ListOptions) ([]*wo
 	return s.workspaces, nil
 }
 
+func (s *mockStore) ListStream(ctx context.Context, opts workspace.ListOptions, fn func(*workspace.Workspace) error) error {
+	workspaces, err := s.List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, ws := range workspaces {
+		if err := fn(ws); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *mockStore) Remove(ctx context.Context, handle string) error {
 	return nil
 }
 
+func (s *mockStore) TrashWorkspace(ctx context.Context, handle string) (*workspace.TrashEntry, error) {
+	return &workspace.TrashEntry{Handle: handle}, nil
+}
+
+func (s *mockStore) ListTrash(ctx context.Context) ([]workspace.TrashEntry, error) {
+	return []workspace.TrashEntry{}, nil
+}
+
+func (s *mockStore) RestoreWorkspace(ctx context.Context, ref string) (*workspace.Workspace, error) {
+	return nil, errors.New("not found")
+}
+
+func (s *mockStore) EmptyTrash(ctx context.Context, olderThan time.Duration) ([]workspace.TrashEntry, error) {
+	return []workspace.TrashEntry{}, nil
+}
+
+func (s *mockStore) ExpiredTrash(ctx context.Context, olderThan time.Duration) ([]workspace.TrashEntry, error) {
+	return []workspace.TrashEntry{}, nil
+}
+
 func (s *mockStore) Path(ctx context.Context, handle string) (string, error) {
 	return "", nil
 }
 
+func (s *mockStore) GetRepositoryPath(ctx context.Context, handle, repoName string) (string, error) {
+	return "", nil
+}
+
 func (s *mockStore) UpdatePurpose(ctx context.Context, handle string, purpose string) error {
 	return nil
 }
 
+func (s *mockStore) RenameWorkspace(ctx context.Context, handle, newHandle string) (*workspace.Workspace, error) {
+	return nil, nil
+}
+
 func (s *mockStore) FindWorkspace(ctx context.Context, dir string) (*workspace.Workspace, error) {
 	return nil, nil
 }
@@ -312,10 +353,70 @@ func (s *mockStore) AddRepositories(ctx context.Context, handle string, repos []
 	return nil
 }
 
+func (s *mockStore) CheckoutRepository(ctx context.Context, handle, repoName, ref string) error {
+	return nil
+}
+
+func (s *mockStore) ReconcileRepositories(ctx context.Context, handle string, desired []workspace.RepositoryOption, invocationCWD string) (*workspace.ReconcileResult, error) {
+	return &workspace.ReconcileResult{Added: []string{}, Removed: []string{}, Updated: []string{}}, nil
+}
+
+func (s *mockStore) PlanReconcile(ctx context.Context, handle string, desired []workspace.RepositoryOption, invocationCWD string) (*workspace.ReconcilePlan, error) {
+	return &workspace.ReconcilePlan{Checkout: map[string]string{}}, nil
+}
+
 func (s *mockStore) RemoveRepository(ctx context.Context, handle string, repoName string) error {
 	return nil
 }
 
+func (s *mockStore) RenameRepository(ctx context.Context, handle, oldName, newName string) error {
+	return nil
+}
+
+func (s *mockStore) FetchRepositories(ctx context.Context, handle string, opts workspace.FetchOptions) ([]workspace.FetchResult, error) {
+	return nil, nil
+}
+
+func (s *mockStore) CloneMissingRepositories(ctx context.Context, handle string) ([]workspace.CloneResult, error) {
+	return nil, nil
+}
+
+func (s *mockStore) TouchRecent(ctx context.Context, handle string) error {
+	return nil
+}
+
+func (s *mockStore) RecentHandles(ctx context.Context) ([]workspace.RecentEntry, error) {
+	return []workspace.RecentEntry{}, nil
+}
+
+func (s *mockStore) RepositoryStatuses(ctx context.Context, handle string) ([]workspace.RepositoryStatus, error) {
+	return nil, nil
+}
+
+func (s *mockStore) PlanExec(ctx context.Context, handle string, opts workspace.ExecOptions) ([]workspace.ExecTarget, error) {
+	return nil, nil
+}
+
+func (s *mockStore) InspectRepository(ctx context.Context, handle, repoName string) (*workspace.RepositoryDetail, error) {
+	return nil, nil
+}
+
+func (s *mockStore) DiffExecutions(ctx context.Context, handle, fromID, toID string) ([]workspace.OutputDiff, error) {
+	return nil, nil
+}
+
+func (s *mockStore) Lock(ctx context.Context, handle string) (*workspace.Lockfile, error) {
+	return &workspace.Lockfile{Version: workspace.LockVersion}, nil
+}
+
+func (s *mockStore) RestoreLock(ctx context.Context, handle string, lock *workspace.Lockfile) error {
+	return nil
+}
+
+func (s *mockStore) Compare(ctx context.Context, left, right string) (*workspace.WorkspaceDiff, error) {
+	return &workspace.WorkspaceDiff{Left: left, Right: right}, nil
+}
+
 func (s *mockStore) RecordExecution(ctx context.Context, handle string, record workspace.ExecutionRecord, outputs []workspace.ExecResult) error {
 	return nil
 }
@@ -324,6 +425,22 @@ func (s *mockStore) GetExecution(ctx context.Context, handle, execID string) (*w
 	return nil, nil
 }
 
+func (s *mockStore) PruneExecutions(ctx context.Context, handle string, policy *workspace.RetentionPolicy) ([]string, error) {
+	return nil, nil
+}
+
+func (s *mockStore) PrunableExecutions(ctx context.Context, handle string, policy *workspace.RetentionPolicy) ([]string, error) {
+	return nil, nil
+}
+
+func (s *mockStore) SetWorkspaceRetention(ctx context.Context, handle string, policy *workspace.RetentionPolicy) error {
+	return nil
+}
+
+func (s *mockStore) PlanCapture(ctx context.Context, handle string, opts workspace.CaptureOptions) (workspace.CaptureOptions, error) {
+	return opts, nil
+}
+
 func (s *mockStore) CaptureState(ctx context.Context, handle string, opts workspace.CaptureOptions) (*workspace.Capture, error) {
 	if s.captureErr != nil {
 		err := s.captureErr
@@ -373,10 +490,34 @@ func (s *mockStore) ApplyCapture(ctx context.Context, handle string, captureID s
 	return nil
 }
 
+func (s *mockStore) UnshallowRepository(ctx context.Context, handle string, repoName string) error {
+	return nil
+}
+
+func (s *mockStore) WorkspaceEnv(ctx context.Context, handle string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (s *mockStore) SetWorkspaceEnv(ctx context.Context, handle string, vars map[string]string) error {
+	return nil
+}
+
+func (s *mockStore) UnsetWorkspaceEnv(ctx context.Context, handle string, keys []string) error {
+	return nil
+}
+
+func (s *mockStore) ContinueApply(ctx context.Context, handle string, captureID string) ([]string, error) {
+	return nil, s.ApplyCapture(ctx, handle, captureID)
+}
+
 func (s *mockStore) PreflightApply(ctx context.Context, handle string, captureID string) (workspace.ApplyPreflightResult, error) {
 	return s.preflightResult, nil
 }
 
+func (s *mockStore) PlanApply(ctx context.Context, handle string, captureID string) ([]workspace.RefChange, error) {
+	return nil, nil
+}
+
 func (s *mockStore) GetCapture(ctx context.Context, handle, captureID string) (*workspace.Capture, error) {
 	for _, c := range s.captures {
 		if c.ID == captureID {
@@ -390,6 +531,67 @@ func (s *mockStore) ListCaptures(ctx context.Context, handle string) ([]workspac
 	return s.captures, nil
 }
 
+func (s *mockStore) CaptureSize(ctx context.Context, handle, captureID string) (int64, error) {
+	return 0, nil
+}
+
+func (s *mockStore) LastActivity(ctx context.Context, handle string) (time.Time, error) {
+	for _, ws := range s.workspaces {
+		if ws.Handle == handle {
+			return ws.CreatedAt, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+func (s *mockStore) CheckHealth(ctx context.Context, handle string) (*workspace.HealthReport, error) {
+	return &workspace.HealthReport{Handle: handle, Healthy: true, Issues: []workspace.HealthIssue{}}, nil
+}
+
+func (s *mockStore) VerifyCapture(ctx context.Context, handle, captureID string) (*workspace.CaptureVerification, error) {
+	return &workspace.CaptureVerification{CaptureID: captureID, Valid: true}, nil
+}
+
+func (s *mockStore) VerifyCaptures(ctx context.Context, handle string) ([]workspace.CaptureVerification, error) {
+	var results []workspace.CaptureVerification
+	for _, c := range s.captures {
+		results = append(results, workspace.CaptureVerification{CaptureID: c.ID, Name: c.Name, Valid: true})
+	}
+	return results, nil
+}
+
+func (s *mockStore) RecordHistory(ctx context.Context, handle string, entry workspace.HistoryEntry) error {
+	return nil
+}
+
+func (s *mockStore) ListHistory(ctx context.Context, handle string) ([]workspace.HistoryEntry, error) {
+	return []workspace.HistoryEntry{}, nil
+}
+
+func (s *mockStore) OrphanedCaptures(ctx context.Context, handle string) ([]workspace.CaptureVerification, error) {
+	return []workspace.CaptureVerification{}, nil
+}
+
+func (s *mockStore) PruneOrphanedCaptures(ctx context.Context, handle string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (s *mockStore) DeleteCapture(ctx context.Context, handle, captureID string) error {
+	return nil
+}
+
+func (s *mockStore) Stash(ctx context.Context, handle string) ([]workspace.StashResult, error) {
+	return nil, nil
+}
+
+func (s *mockStore) StashPop(ctx context.Context, handle string) ([]workspace.StashResult, error) {
+	return nil, nil
+}
+
+func (s *mockStore) ListStashes(ctx context.Context, handle string) ([]workspace.StashEntry, error) {
+	return nil, nil
+}
+
 func (s *mockStore) ExportContext(ctx context.Context, handle string) (*workspace.WorkspaceContext, error) {
 	if s.exportErr != nil {
 		return nil, s.exportErr
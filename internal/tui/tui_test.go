@@ -4,7 +4,10 @@
 package tui
 
 import (
+	"slices"
 	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 func TestIsHumanMode(t *testing.T) {
@@ -28,3 +31,74 @@ func TestIsHumanMode(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectorModel(t *testing.T) {
+	press := func(m SelectorModel, keys ...string) SelectorModel {
+		for _, k := range keys {
+			var msg tea.KeyMsg
+			switch k {
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "esc":
+				msg = tea.KeyMsg{Type: tea.KeyEsc}
+			case " ":
+				msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+			default:
+				msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			}
+			next, _ := m.Update(msg)
+			m = next.(SelectorModel)
+		}
+		return m
+	}
+	items := []string{"api", "web", "worker"}
+
+	t.Run("toggles and confirms in list order", func(t *testing.T) {
+		m := press(NewSelectorModel("Pick", items), "j", "j", " ", "k", "k", " ", "enter")
+		if got := m.Selected(); !slices.Equal(got, []string{"api", "worker"}) {
+			t.Errorf("Selected() = %v, want [api worker]", got)
+		}
+		if m.Canceled() {
+			t.Error("Expected a confirmed selection")
+		}
+	})
+
+	t.Run("toggling twice unchecks", func(t *testing.T) {
+		m := press(NewSelectorModel("Pick", items), " ", " ", "j", " ", "enter")
+		if got := m.Selected(); !slices.Equal(got, []string{"web"}) {
+			t.Errorf("Selected() = %v, want [web]", got)
+		}
+	})
+
+	t.Run("confirming with nothing checked picks the cursor item", func(t *testing.T) {
+		m := press(NewSelectorModel("Pick", items), "j", "enter")
+		if got := m.Selected(); !slices.Equal(got, []string{"web"}) {
+			t.Errorf("Selected() = %v, want [web]", got)
+		}
+	})
+
+	t.Run("a toggles all", func(t *testing.T) {
+		m := press(NewSelectorModel("Pick", items), "a")
+		if got := m.Selected(); !slices.Equal(got, items) {
+			t.Errorf("Selected() = %v, want all", got)
+		}
+		m = press(m, "a")
+		if got := m.Selected(); len(got) != 0 {
+			t.Errorf("Selected() = %v, want none", got)
+		}
+	})
+
+	t.Run("cursor stays in bounds", func(t *testing.T) {
+		m := press(NewSelectorModel("Pick", items), "k", " ", "j", "j", "j", "j", " ", "enter")
+		if got := m.Selected(); !slices.Equal(got, []string{"api", "worker"}) {
+			t.Errorf("Selected() = %v, want [api worker]", got)
+		}
+	})
+
+	t.Run("escape cancels", func(t *testing.T) {
+		m := press(NewSelectorModel("Pick", items), " ", "esc")
+		if !m.Canceled() {
+			t.Error("Expected escape to cancel")
+		}
+	})
+}
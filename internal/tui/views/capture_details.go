@@ -157,6 +157,10 @@ func (v *CaptureDetailsView) View() string {
 	content += subStyle.Render("Name: ") + name + "\n"
 	content += subStyle.Render("Created: ") + v.capture.Timestamp.Format("Jan 02 15:04") + "\n"
 
+	if v.capture.SourcePurpose != "" {
+		content += subStyle.Render("Workspace: ") + v.capture.Handle + " (" + v.capture.SourcePurpose + ")\n"
+	}
+
 	if v.capture.Metadata.Description != "" {
 		content += subStyle.Render("Description: ") + v.capture.Metadata.Description + "\n"
 	}
@@ -84,6 +84,7 @@ func (v *modal_ExecView) run() (ViewResult, tea.Cmd) {
 		repoResults = append(repoResults, workspace.ExecutionRepoResult{
 			Repository: result.Repository,
 			ExitCode:   result.ExitCode,
+			Signal:     result.Signal,
 			Duration:   result.Duration.Milliseconds(),
 		})
 	}
@@ -0,0 +1,123 @@
+package workspace
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/frodi/workshed/internal/fs"
+)
+
+const (
+	captureFileName    = "capture.json"
+	captureArchiveName = "capture.tar.gz"
+)
+
+// errCorruptCaptureArchive marks a capture.tar.gz that cannot be read back.
+var errCorruptCaptureArchive = errors.New("corrupt capture archive")
+
+// writeCaptureFile stores capture in captureDir, either as capture.json or,
+// with compress, as capture.json inside capture.tar.gz. Writing one format
+// removes the other, so a capture never has two diverging copies.
+func writeCaptureFile(captureDir string, capture *Capture, compress bool) error {
+	data, err := json.MarshalIndent(capture, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling capture: %w", err)
+	}
+
+	if !compress {
+		if err := fs.WriteJson(filepath.Join(captureDir, captureFileName), data); err != nil {
+			return fmt.Errorf("writing capture: %w", err)
+		}
+		return removeIfExists(filepath.Join(captureDir, captureArchiveName))
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	header := &tar.Header{
+		Name:    captureFileName,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: capture.Timestamp,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("archiving capture: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("archiving capture: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("archiving capture: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("compressing capture: %w", err)
+	}
+
+	if err := os.MkdirAll(captureDir, 0755); err != nil {
+		return fmt.Errorf("writing capture: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(captureDir, captureArchiveName), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("writing capture: %w", err)
+	}
+	return removeIfExists(filepath.Join(captureDir, captureFileName))
+}
+
+// readCaptureData returns the capture.json stored in captureDir, from the
+// plain file or the archive. It returns an os.ErrNotExist error when neither
+// exists.
+func readCaptureData(captureDir string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(captureDir, captureFileName))
+	if err == nil || !os.IsNotExist(err) {
+		return data, err
+	}
+
+	f, err := os.Open(filepath.Join(captureDir, captureArchiveName))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errCorruptCaptureArchive, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: no %s inside", errCorruptCaptureArchive, captureFileName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errCorruptCaptureArchive, err)
+		}
+		if header.Name != captureFileName {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errCorruptCaptureArchive, err)
+		}
+		return data, nil
+	}
+}
+
+// captureIsCompressed reports whether the capture in captureDir is stored as
+// an archive.
+func captureIsCompressed(captureDir string) bool {
+	_, err := os.Stat(filepath.Join(captureDir, captureArchiveName))
+	return err == nil
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package workspace
+
+import (
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Clock supplies the current time to the store. Timestamps written to
+// metadata, captures and execution records, and the age checks made against
+// them, all go through it.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// newID returns a ULID stamped with the store's clock, so IDs that double as
+// timestamps (trash, history, stashes, captures) agree with the clock. IDs made
+// within the same millisecond still sort in creation order.
+func (s *FSStore) newID() ulid.ULID {
+	return ulid.MustNew(ulid.Timestamp(s.clock.Now()), ulid.DefaultEntropy())
+}
+
+// FakeClock is a Clock that only moves when told to. It is safe for
+// concurrent use.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock returns a FakeClock stopped at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set moves the clock to now.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
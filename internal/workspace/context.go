@@ -0,0 +1,57 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+)
+
+// contextMigrations upgrade an exported context by one version, keyed by the
+// version they upgrade from. Changing the export format means bumping
+// ContextVersion and adding the migration from the previous version here, so
+// that exports from every older release keep importing.
+var contextMigrations = map[int]func(*WorkspaceContext){
+	// Version 0 is an export without a version field, as written by hand
+	// or by tools that build contexts themselves. Its fields match version 1.
+	0: func(c *WorkspaceContext) {},
+}
+
+// ValidateContext checks that c can be imported, migrating it in place to
+// ContextVersion first. Contexts from a newer workshed are rejected, since
+// fields this build does not know about would be silently dropped.
+func ValidateContext(c *WorkspaceContext) error {
+	if c == nil {
+		return errors.New("context is required")
+	}
+	if c.Version > ContextVersion {
+		return fmt.Errorf("export was created by a newer workshed (context version %d, this build reads up to %d); please upgrade", c.Version, ContextVersion)
+	}
+	if c.Version < 0 {
+		return fmt.Errorf("invalid context version: %d", c.Version)
+	}
+	for c.Version < ContextVersion {
+		migrate, ok := contextMigrations[c.Version]
+		if !ok {
+			return fmt.Errorf("unsupported context version: %d", c.Version)
+		}
+		migrate(c)
+		c.Version++
+	}
+
+	if c.Purpose == "" {
+		return errors.New("purpose is required")
+	}
+	if len(c.Repositories) == 0 {
+		return errors.New("at least one repository is required")
+	}
+	for _, repo := range c.Repositories {
+		if repo.URL == "" {
+			return errors.New("invalid repository: URL is required")
+		}
+		for name, url := range repo.Remotes {
+			if err := ValidateRemote(name, url); err != nil {
+				return fmt.Errorf("repository %s: %w", repo.URL, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Lifecycle event types emitted by the store.
+const (
+	EventWorkspaceCreated = "workspace.created"
+	EventWorkspaceRemoved = "workspace.removed"
+	EventCaptureCreated   = "capture.created"
+	EventCaptureApplied   = "capture.applied"
+)
+
+// StoreEvent is the payload delivered to an EventSink.
+type StoreEvent struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Handle    string    `json:"handle"`
+	Purpose   string    `json:"purpose,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	CaptureID string    `json:"capture_id,omitempty"`
+	Capture   string    `json:"capture_name,omitempty"`
+}
+
+// EventSink receives lifecycle events from the store.
+// Errors returned by a sink never fail the operation that fired the event.
+type EventSink interface {
+	Emit(ctx context.Context, event StoreEvent) error
+}
+
+type noopSink struct{}
+
+func (noopSink) Emit(context.Context, StoreEvent) error { return nil }
+
+// WebhookSink POSTs each event as JSON to URL.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+const webhookTimeout = 5 * time.Second
+
+func (w WebhookSink) Emit(ctx context.Context, event StoreEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting event: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// CommandSink runs Command through sh for each event, with the JSON payload
+// on stdin and the event type in WORKSHED_EVENT.
+type CommandSink struct {
+	Command string
+}
+
+func (c CommandSink) Emit(ctx context.Context, event StoreEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(), "WORKSHED_EVENT="+event.Type)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("running event hook: %w: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// SinkFunc adapts a function to the EventSink interface.
+type SinkFunc func(ctx context.Context, event StoreEvent) error
+
+func (f SinkFunc) Emit(ctx context.Context, event StoreEvent) error {
+	return f(ctx, event)
+}
+
+// MultiSink delivers each event to every sink and returns the first error.
+type MultiSink []EventSink
+
+func (m MultiSink) Emit(ctx context.Context, event StoreEvent) error {
+	var first error
+	for _, sink := range m {
+		if err := sink.Emit(ctx, event); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
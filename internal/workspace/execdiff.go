@@ -0,0 +1,243 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines kept around each change.
+const diffContext = 3
+
+// maxDiffCells bounds the line-by-line comparison table. Outputs larger than
+// this are reported as fully replaced rather than diffed.
+const maxDiffCells = 4 << 20
+
+// OutputDiff is the difference between one repository's stored output in two
+// executions. A repository missing from one execution diffs against empty output.
+type OutputDiff struct {
+	Repository string     `json:"repository"`
+	Hunks      []DiffHunk `json:"hunks"`
+}
+
+// DiffHunk is a unified diff hunk. Lines carry a " ", "-" or "+" prefix.
+type DiffHunk struct {
+	OldStart int      `json:"old_start"`
+	OldLines int      `json:"old_lines"`
+	NewStart int      `json:"new_start"`
+	NewLines int      `json:"new_lines"`
+	Lines    []string `json:"lines"`
+}
+
+// Header returns the hunk's "@@ -a,b +c,d @@" line.
+func (h DiffHunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// DiffExecutions compares the stored output of two executions repository by
+// repository, in the order repositories appear in from and then in to.
+func (s *FSStore) DiffExecutions(ctx context.Context, handle, fromID, toID string) ([]OutputDiff, error) {
+	from, err := s.executionOutputs(ctx, handle, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.executionOutputs(ctx, handle, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []string
+	seen := make(map[string]bool)
+	for _, outputs := range []*executionOutputs{from, to} {
+		for _, repo := range outputs.repos {
+			if !seen[repo] {
+				seen[repo] = true
+				repos = append(repos, repo)
+			}
+		}
+	}
+
+	diffs := make([]OutputDiff, 0, len(repos))
+	for _, repo := range repos {
+		diffs = append(diffs, OutputDiff{
+			Repository: repo,
+			Hunks:      DiffLines(splitLines(from.text[repo]), splitLines(to.text[repo])),
+		})
+	}
+	return diffs, nil
+}
+
+type executionOutputs struct {
+	repos []string
+	text  map[string]string
+}
+
+// executionOutputs reads the stored stdout of every repository in an execution.
+func (s *FSStore) executionOutputs(ctx context.Context, handle, execID string) (*executionOutputs, error) {
+	record, err := s.GetExecution(ctx, handle, execID)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	stdoutDir := filepath.Join(ws.Path, ".workshed", executionsDirName, execID, "stdout")
+	outputs := &executionOutputs{text: make(map[string]string)}
+	for _, result := range record.Results {
+		outputs.repos = append(outputs.repos, result.Repository)
+		data, err := os.ReadFile(filepath.Join(stdoutDir, result.Repository+".txt"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Empty output is not stored.
+				continue
+			}
+			return nil, fmt.Errorf("reading output for %s: %w", result.Repository, err)
+		}
+		outputs.text[result.Repository] = string(data)
+	}
+	return outputs, nil
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// DiffLines returns the unified diff hunks turning a into b, with
+// diffContext lines of context. Equal inputs yield no hunks.
+func DiffLines(a, b []string) []DiffHunk {
+	lines := editScript(a, b)
+
+	var changes []int
+	for i, line := range lines {
+		if line[0] != ' ' {
+			changes = append(changes, i)
+		}
+	}
+
+	var hunks []DiffHunk
+	for len(changes) > 0 {
+		// Changes separated by at most twice the context share a hunk.
+		last := 0
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*diffContext+1 {
+			last++
+		}
+		from := max(changes[0]-diffContext, 0)
+		to := min(changes[last]+1+diffContext, len(lines))
+		hunks = append(hunks, newHunk(lines, from, to))
+		changes = changes[last+1:]
+	}
+	return hunks
+}
+
+// newHunk builds the hunk covering lines[from:to] of an edit script.
+func newHunk(lines []string, from, to int) DiffHunk {
+	h := DiffHunk{OldStart: 1, NewStart: 1, Lines: lines[from:to]}
+	for _, line := range lines[:from] {
+		if line[0] != '+' {
+			h.OldStart++
+		}
+		if line[0] != '-' {
+			h.NewStart++
+		}
+	}
+	for _, line := range h.Lines {
+		if line[0] != '+' {
+			h.OldLines++
+		}
+		if line[0] != '-' {
+			h.NewLines++
+		}
+	}
+	// An empty range is numbered by the line before it, as diff -u does.
+	if h.OldLines == 0 {
+		h.OldStart--
+	}
+	if h.NewLines == 0 {
+		h.NewStart--
+	}
+	return h
+}
+
+// editScript returns a and b merged into prefixed lines: " " for lines in
+// both, "-" for lines only in a and "+" for lines only in b. It keeps the
+// longest common subsequence of lines.
+func editScript(a, b []string) []string {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []string
+	for _, line := range a[:prefix] {
+		lines = append(lines, " "+line)
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(midA)*len(midB) > maxDiffCells {
+		for _, line := range midA {
+			lines = append(lines, "-"+line)
+		}
+		for _, line := range midB {
+			lines = append(lines, "+"+line)
+		}
+	} else {
+		lines = append(lines, lcsScript(midA, midB)...)
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, " "+line)
+	}
+	return lines
+}
+
+func lcsScript(a, b []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, "+"+b[j])
+	}
+	return lines
+}
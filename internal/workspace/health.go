@@ -0,0 +1,211 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Health issue kinds reported by CheckHealth.
+const (
+	HealthStaleExecutions    = "stale_executions"
+	HealthMissingRepository  = "missing_repository"
+	HealthNotGitRepository   = "not_a_git_repository"
+	HealthDirtyWorkingTree   = "dirty_working_tree"
+	HealthRefDrift           = "ref_drift"
+	HealthCaptureMissingRepo = "capture_missing_repository"
+	HealthLFSUnavailable     = "lfs_unavailable"
+)
+
+// Health issue severities, from most to least severe.
+const (
+	HealthSeverityError   = "error"
+	HealthSeverityWarning = "warning"
+	HealthSeverityInfo    = "info"
+)
+
+// healthSeverities classifies each issue kind. Anything that stops commands
+// from running in a repository is an error; state that is merely unexpected
+// is a warning; housekeeping is info.
+var healthSeverities = map[string]string{
+	HealthMissingRepository:  HealthSeverityError,
+	HealthNotGitRepository:   HealthSeverityError,
+	HealthDirtyWorkingTree:   HealthSeverityWarning,
+	HealthRefDrift:           HealthSeverityWarning,
+	HealthCaptureMissingRepo: HealthSeverityWarning,
+	HealthLFSUnavailable:     HealthSeverityWarning,
+	HealthStaleExecutions:    HealthSeverityInfo,
+}
+
+var healthSeverityRank = map[string]int{
+	HealthSeverityInfo:    1,
+	HealthSeverityWarning: 2,
+	HealthSeverityError:   3,
+}
+
+// ValidHealthSeverity reports whether severity is error, warning or info.
+func ValidHealthSeverity(severity string) bool {
+	_, ok := healthSeverityRank[severity]
+	return ok
+}
+
+const (
+	healthStaleThreshold       = 30 * 24 * time.Hour
+	healthExecutionSampleLimit = 100
+)
+
+// HealthIssue is a single problem found by CheckHealth.
+type HealthIssue struct {
+	Kind       string `json:"kind"`
+	Severity   string `json:"severity"`
+	Repository string `json:"repository,omitempty"`
+	Capture    string `json:"capture,omitempty"`
+	Message    string `json:"message"`
+}
+
+// HealthReport summarizes the state of a workspace.
+type HealthReport struct {
+	Handle          string        `json:"handle"`
+	Healthy         bool          `json:"healthy"`
+	StaleExecutions int           `json:"stale_executions"`
+	Issues          []HealthIssue `json:"issues"`
+}
+
+// CheckHealth inspects a workspace for stale execution records, missing or
+// non-git repositories, uncommitted changes, repositories that have drifted
+// from their recorded ref, repositories using Git LFS without git-lfs
+// installed, and captures referencing missing repositories.
+func (s *FSStore) CheckHealth(ctx context.Context, handle string) (*HealthReport, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	execs, err := s.ListExecutions(ctx, handle, ListExecutionsOptions{Limit: healthExecutionSampleLimit})
+	if err != nil {
+		return nil, fmt.Errorf("listing executions: %w", err)
+	}
+
+	captures, err := s.ListCaptures(ctx, handle)
+	if err != nil {
+		return nil, fmt.Errorf("listing captures: %w", err)
+	}
+
+	report := &HealthReport{Handle: handle, Issues: []HealthIssue{}}
+
+	for _, e := range execs {
+		if s.clock.Now().Sub(e.Timestamp) > healthStaleThreshold {
+			report.StaleExecutions++
+		}
+	}
+	if report.StaleExecutions > 0 {
+		report.Issues = append(report.Issues, HealthIssue{
+			Kind:    HealthStaleExecutions,
+			Message: fmt.Sprintf("%d stale executions older than 30 days", report.StaleExecutions),
+		})
+	}
+
+	for _, repo := range ws.Repositories {
+		report.Issues = append(report.Issues, s.repoHealth(ctx, ws, repo)...)
+	}
+
+	for _, c := range captures {
+		for _, ref := range c.GitState {
+			if _, err := os.Stat(filepath.Join(ws.Path, ref.Repository)); os.IsNotExist(err) {
+				report.Issues = append(report.Issues, HealthIssue{
+					Kind:       HealthCaptureMissingRepo,
+					Repository: ref.Repository,
+					Capture:    c.Name,
+					Message:    fmt.Sprintf("capture '%s' references missing repository: %s", c.Name, ref.Repository),
+				})
+			}
+		}
+	}
+
+	for i := range report.Issues {
+		report.Issues[i].Severity = healthSeverities[report.Issues[i].Kind]
+	}
+
+	report.Healthy = len(report.Issues) == 0
+	return report, nil
+}
+
+// CountAtLeast returns how many issues are at or above severity.
+func (r *HealthReport) CountAtLeast(severity string) int {
+	threshold := healthSeverityRank[severity]
+	count := 0
+	for _, issue := range r.Issues {
+		if healthSeverityRank[issue.Severity] >= threshold {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *FSStore) repoHealth(ctx context.Context, ws *Workspace, repo Repository) []HealthIssue {
+	repoDir := filepath.Join(ws.Path, repo.Name)
+	if _, err := os.Stat(repoDir); err != nil {
+		if os.IsNotExist(err) {
+			return []HealthIssue{{
+				Kind:       HealthMissingRepository,
+				Repository: repo.Name,
+				Message:    fmt.Sprintf("missing repository directory: %s", repo.Name),
+			}}
+		}
+		return nil
+	}
+
+	if _, err := os.Stat(filepath.Join(repoDir, ".git")); err != nil {
+		if os.IsNotExist(err) {
+			return []HealthIssue{{
+				Kind:       HealthNotGitRepository,
+				Repository: repo.Name,
+				Message:    fmt.Sprintf("%s is not a git repository", repo.Name),
+			}}
+		}
+		return nil
+	}
+
+	// A --no-checkout clone has an empty index, so every file would look
+	// deleted; there is nothing to be dirty or drifted until it is checked out.
+	if repo.NoCheckout {
+		return nil
+	}
+
+	var issues []HealthIssue
+
+	if status, err := s.git.StatusPorcelain(ctx, repoDir); err == nil && strings.TrimSpace(status) != "" {
+		issues = append(issues, HealthIssue{
+			Kind:       HealthDirtyWorkingTree,
+			Repository: repo.Name,
+			Message:    fmt.Sprintf("%s has uncommitted changes", repo.Name),
+		})
+	}
+
+	// Drift is judged by commit so a detached checkout of a tag or SHA still
+	// counts as being on its ref.
+	if repo.Ref != "" {
+		head, headErr := s.git.RevParse(ctx, repoDir, "HEAD")
+		want, wantErr := s.git.RevParse(ctx, repoDir, repo.Ref)
+		if headErr == nil && wantErr == nil && head != want {
+			issues = append(issues, HealthIssue{
+				Kind:       HealthRefDrift,
+				Repository: repo.Name,
+				Message:    fmt.Sprintf("%s has drifted from %s", repo.Name, repo.Ref),
+			})
+		}
+	}
+
+	if UsesLFS(repoDir) && !s.git.LFSAvailable(ctx) {
+		issues = append(issues, HealthIssue{
+			Kind:       HealthLFSUnavailable,
+			Repository: repo.Name,
+			Message:    fmt.Sprintf("%s uses Git LFS but git-lfs is not installed; LFS files are pointers", repo.Name),
+		})
+	}
+
+	return issues
+}
@@ -0,0 +1,159 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/frodi/workshed/internal/fs"
+	"github.com/oklog/ulid/v2"
+)
+
+// historyDirName holds one JSON file per mutating operation under .workshed.
+const historyDirName = "history"
+
+// Operations recorded in the workspace history.
+const (
+	HistoryCreate      = "create"
+	HistoryApply       = "apply"
+	HistoryCheckout    = "checkout"
+	HistoryRestoreLock = "restore-lock"
+)
+
+// Results recorded in the workspace history.
+const (
+	HistoryResultOK     = "ok"
+	HistoryResultFailed = "failed"
+)
+
+// HistoryEntry records one operation that changed what the workspace's
+// repositories have checked out.
+type HistoryEntry struct {
+	// ID is a ULID assigned by RecordHistory; it names the entry's file and
+	// orders entries by time.
+	ID          string      `json:"id"`
+	Operation   string      `json:"operation"`
+	Timestamp   time.Time   `json:"timestamp"`
+	CaptureID   string      `json:"capture_id,omitempty"`
+	CaptureName string      `json:"capture_name,omitempty"`
+	Changes     []RefChange `json:"changes,omitempty"`
+	Result      string      `json:"result"`
+	Error       string      `json:"error,omitempty"`
+}
+
+// RefChange is a repository moving from one commit to another. From is empty
+// for a repository that was just cloned.
+type RefChange struct {
+	Repository string `json:"repository"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to"`
+}
+
+// Summary formats the changes as "repo from..to" pairs with abbreviated commits.
+func (e HistoryEntry) Summary() string {
+	parts := make([]string, 0, len(e.Changes))
+	for _, c := range e.Changes {
+		if c.From == "" {
+			parts = append(parts, c.Repository+" "+shortCommit(c.To))
+		} else {
+			parts = append(parts, c.Repository+" "+shortCommit(c.From)+".."+shortCommit(c.To))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+func historyDir(ws *Workspace) string {
+	return filepath.Join(ws.Path, ".workshed", historyDirName)
+}
+
+// RecordHistory stores entry in the workspace history, assigning its ID and
+// timestamp.
+func (s *FSStore) RecordHistory(ctx context.Context, handle string, entry HistoryEntry) error {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return err
+	}
+	return s.recordHistory(ws, entry)
+}
+
+func (s *FSStore) recordHistory(ws *Workspace, entry HistoryEntry) error {
+	if err := os.MkdirAll(historyDir(ws), 0755); err != nil {
+		return fmt.Errorf("creating history directory: %w", err)
+	}
+
+	id := s.newID()
+	entry.ID = id.String()
+	entry.Timestamp = ulid.Time(id.Time())
+	if entry.Result == "" {
+		entry.Result = HistoryResultOK
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling history entry: %w", err)
+	}
+	if err := fs.WriteJson(filepath.Join(historyDir(ws), entry.ID+".json"), data); err != nil {
+		return fmt.Errorf("writing history entry: %w", err)
+	}
+	return nil
+}
+
+// ListHistory returns the workspace history, newest first. Entries that no
+// longer parse are skipped.
+func (s *FSStore) ListHistory(ctx context.Context, handle string) ([]HistoryEntry, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	dirEntries, err := os.ReadDir(historyDir(ws))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []HistoryEntry{}, nil
+		}
+		return nil, fmt.Errorf("reading history directory: %w", err)
+	}
+
+	entries := []HistoryEntry{}
+	for _, e := range dirEntries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(historyDir(ws), e.Name()))
+		if err != nil {
+			continue
+		}
+		var entry HistoryEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID > entries[j].ID
+	})
+	return entries, nil
+}
+
+// headCommit returns the commit checked out in dir, or "" when it cannot be
+// read. History is best effort and never blocks the operation it describes.
+func (s *FSStore) headCommit(ctx context.Context, dir string) string {
+	commit, err := s.git.RevParse(ctx, dir, "HEAD")
+	if err != nil {
+		return ""
+	}
+	return commit
+}
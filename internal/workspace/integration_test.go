@@ -1187,6 +1187,66 @@ func TestIntegrationTemplate(t *testing.T) {
 			t.Errorf("Expected %s with variable substitution", expectedFile)
 		}
 	})
+
+	t.Run("should reject variables that escape the workspace", func(t *testing.T) {
+		root := t.TempDir()
+		store, err := NewFSStore(filepath.Join(root, "store"))
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		templateDir := filepath.Join(root, "template", "{{x}}")
+		if err := os.MkdirAll(templateDir, 0755); err != nil {
+			t.Fatalf("Failed to create template directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(templateDir, "file"), []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to write template file: %v", err)
+		}
+
+		ctx := context.Background()
+		_, err = store.Create(ctx, CreateOptions{
+			Purpose:      "Escaping template test",
+			Template:     filepath.Join(root, "template"),
+			TemplateVars: map[string]string{"x": "../../evil"},
+			Repositories: []RepositoryOption{},
+		})
+		if err == nil || !strings.Contains(err.Error(), "outside the workspace") {
+			t.Fatalf("Expected escaping template path to be rejected, got %v", err)
+		}
+		if FileExists(filepath.Join(root, "evil", "file")) || FileExists(filepath.Join(root, "store", "evil", "file")) {
+			t.Error("Expected nothing to be written outside the workspace")
+		}
+	})
+
+	t.Run("should accept variables that stay inside the workspace", func(t *testing.T) {
+		root := t.TempDir()
+		store, err := NewFSStore(root)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		templateDir := filepath.Join(root, "template", "{{x}}")
+		if err := os.MkdirAll(templateDir, 0755); err != nil {
+			t.Fatalf("Failed to create template directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(templateDir, "file"), []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to write template file: %v", err)
+		}
+
+		ctx := context.Background()
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Safe template test",
+			Template:     filepath.Join(root, "template"),
+			TemplateVars: map[string]string{"x": "config/../settings"},
+			Repositories: []RepositoryOption{},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if !FileExists(filepath.Join(ws.Path, "settings", "file")) {
+			t.Error("Expected settings/file from template")
+		}
+	})
 }
 
 func TestExportImport_RoundTrip(t *testing.T) {
@@ -0,0 +1,61 @@
+package workspace
+
+import "sort"
+
+// How a capture hangs off the node above it in a lineage tree.
+const (
+	CaptureRelationChild  = "child"
+	CaptureRelationBackup = "backup"
+)
+
+// CaptureNode is a capture in its lineage tree. Children are ordered oldest
+// first.
+type CaptureNode struct {
+	Capture Capture
+	// Relation is CaptureRelationChild or CaptureRelationBackup, and empty
+	// for a root.
+	Relation string
+	Children []*CaptureNode
+}
+
+// CaptureLineage arranges captures into trees: a capture sits under its
+// Parent, or, without one, under the capture it was a backup of. Captures
+// whose links point at a missing or newer capture are roots, which keeps the
+// result acyclic even when capture files were edited by hand. Roots are
+// ordered oldest first.
+func CaptureLineage(captures []Capture) []*CaptureNode {
+	sorted := append([]Capture(nil), captures...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	nodes := make(map[string]*CaptureNode, len(sorted))
+	for _, c := range sorted {
+		nodes[c.ID] = &CaptureNode{Capture: c}
+	}
+
+	var roots []*CaptureNode
+	for _, c := range sorted {
+		node := nodes[c.ID]
+		above, relation := captureLink(c, nodes)
+		if above == nil {
+			roots = append(roots, node)
+			continue
+		}
+		node.Relation = relation
+		above.Children = append(above.Children, node)
+	}
+	return roots
+}
+
+// captureLink returns the node c hangs under and how, or nil for a root.
+// ULIDs sort by creation time, so only older captures are linked to.
+func captureLink(c Capture, nodes map[string]*CaptureNode) (*CaptureNode, string) {
+	if node, ok := nodes[c.Parent]; ok && c.Parent < c.ID {
+		return node, CaptureRelationChild
+	}
+	if node, ok := nodes[c.BackupOf]; ok && c.BackupOf < c.ID {
+		return node, CaptureRelationBackup
+	}
+	return nil, ""
+}
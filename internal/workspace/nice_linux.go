@@ -0,0 +1,21 @@
+//go:build linux
+
+package workspace
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// NiceSupported reports whether ExecOptions.Nice has an effect on this platform.
+const NiceSupported = true
+
+// setNice lowers the scheduling priority of a started command. It runs right
+// after Start, so anything the command forks in its first instant keeps the
+// default priority; this is best-effort and errors are ignored.
+func setNice(cmd *exec.Cmd, nice int) {
+	if nice == 0 || cmd.Process == nil {
+		return
+	}
+	_ = syscall.Setpriority(syscall.PRIO_PROCESS, cmd.Process.Pid, nice)
+}
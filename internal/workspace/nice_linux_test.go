@@ -0,0 +1,46 @@
+//go:build linux && !integration
+
+package workspace
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExecNice(t *testing.T) {
+	ctx := context.Background()
+	store, _ := CreateTestStore(t)
+	ws, err := store.Create(ctx, CreateOptions{Purpose: "Nice test", Repositories: []RepositoryOption{}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// The sleep gives setNice time to run before the shell reads its own stat.
+	results, err := store.Exec(ctx, ws.Handle, ExecOptions{
+		Target:  "root",
+		Command: []string{"sh", "-c", "sleep 0.2; cat /proc/$$/stat"},
+		Nice:    7,
+	})
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+
+	stat := string(results[0].Output)
+	idx := strings.LastIndex(stat, ")")
+	if idx < 0 {
+		t.Fatalf("Unexpected /proc stat output: %q", stat)
+	}
+	// Fields after the command name start at state (field 3); nice is field 19.
+	fields := strings.Fields(stat[idx+1:])
+	if len(fields) < 17 {
+		t.Fatalf("Unexpected /proc stat output: %q", stat)
+	}
+	if fields[16] != "7" {
+		t.Errorf("Expected niceness 7, got %s", fields[16])
+	}
+
+	if _, err := store.Exec(ctx, ws.Handle, ExecOptions{Target: "root", Command: []string{"true"}, Nice: 20}); err == nil {
+		t.Error("Expected an error for niceness above 19")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package workspace
+
+import "os/exec"
+
+// NiceSupported reports whether ExecOptions.Nice has an effect on this platform.
+const NiceSupported = false
+
+func setNice(cmd *exec.Cmd, nice int) {}
@@ -0,0 +1,21 @@
+//go:build !linux && !integration
+
+package workspace
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExecNiceIgnored(t *testing.T) {
+	ctx := context.Background()
+	store, _ := CreateTestStore(t)
+	ws, err := store.Create(ctx, CreateOptions{Purpose: "Nice test", Repositories: []RepositoryOption{}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if _, err := store.Exec(ctx, ws.Handle, ExecOptions{Target: "root", Command: []string{"true"}, Nice: 7}); err != nil {
+		t.Errorf("Expected --nice to be a no-op, got: %v", err)
+	}
+}
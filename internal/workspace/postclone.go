@@ -0,0 +1,111 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ParseSetupFlags parses --repo-setup values of the form name=command into
+// per-repository setup commands, each run with sh -c.
+func ParseSetupFlags(values []string) (map[string][]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	setups := make(map[string][]string, len(values))
+	for _, value := range values {
+		name, command, ok := strings.Cut(value, "=")
+		name = strings.TrimSpace(name)
+		command = strings.TrimSpace(command)
+		if !ok || name == "" || command == "" {
+			return nil, fmt.Errorf("invalid repo setup %q (expected name=command)", value)
+		}
+		if _, dup := setups[name]; dup {
+			return nil, fmt.Errorf("setup for repository %q given more than once", name)
+		}
+		setups[name] = ShellCommand(command)
+	}
+	return setups, nil
+}
+
+// AssignPostClone sets the setup command of each repository in repos named
+// in setups. Names are matched the way repositories are named on clone; a
+// name that matches none of repos is an error.
+func AssignPostClone(repos []RepositoryOption, setups map[string][]string, invocationCWD string) error {
+	matched := make(map[string]bool, len(setups))
+	for i := range repos {
+		name := extractRepoName(repos[i].URL, invocationCWD)
+		if command, ok := setups[name]; ok {
+			repos[i].PostClone = command
+			matched[name] = true
+		}
+	}
+	for name := range setups {
+		if !matched[name] {
+			return fmt.Errorf("repo setup for %q matches no repository", name)
+		}
+	}
+	return nil
+}
+
+// ShellCommand wraps a command line so it runs through sh -c.
+func ShellCommand(command string) []string {
+	return []string{"sh", "-c", command}
+}
+
+// runPostClone runs the PostClone command of each repository that has one, in
+// the repository's directory under wsDir, in order. It stops at the first
+// command that fails and returns the results gathered so far with the error.
+func (s *FSStore) runPostClone(ctx context.Context, wsDir string, repos []Repository) ([]ExecResult, error) {
+	var results []ExecResult
+	var env []string
+	for _, repo := range repos {
+		if len(repo.PostClone) == 0 {
+			continue
+		}
+		if env == nil {
+			var err error
+			if env, err = s.execEnv(&Workspace{Path: wsDir}, nil); err != nil {
+				return results, err
+			}
+		}
+		result, err := s.execInRepository(ctx, repo, wsDir, repo.PostClone, env, 0)
+		results = append(results, result)
+		if err != nil {
+			output := strings.TrimSpace(string(result.Output))
+			if output == "" {
+				return results, fmt.Errorf("setup command in %s failed: %w", repo.Name, err)
+			}
+			return results, fmt.Errorf("setup command in %s failed: %w\n%s", repo.Name, err, output)
+		}
+	}
+	return results, nil
+}
+
+// recordPostClone adds one execution record per setup command that ran, so
+// its output can be read back with the workspace's execution history.
+// Recording is best effort: the repositories are already set up.
+func (s *FSStore) recordPostClone(ctx context.Context, handle string, repos []Repository, results []ExecResult) {
+	commands := make(map[string][]string, len(repos))
+	for _, repo := range repos {
+		commands[repo.Name] = repo.PostClone
+	}
+	for _, result := range results {
+		record := ExecutionRecord{
+			ID:          s.newID().String(),
+			Handle:      handle,
+			Target:      result.Repository,
+			Command:     commands[result.Repository],
+			ExitCode:    result.ExitCode,
+			CompletedAt: s.clock.Now(),
+			Duration:    result.Duration.Milliseconds(),
+			Results: []ExecutionRepoResult{{
+				Repository: result.Repository,
+				ExitCode:   result.ExitCode,
+				Signal:     result.Signal,
+				Duration:   result.Duration.Milliseconds(),
+			}},
+		}
+		_ = s.RecordExecution(ctx, handle, record, []ExecResult{result})
+	}
+}
@@ -0,0 +1,96 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/frodi/workshed/internal/fs"
+)
+
+// recentFileName holds the recently used handles under the store root. It is
+// a file, so List skips it.
+const recentFileName = ".workshed-recent.json"
+
+// maxRecent caps how many handles the recent list remembers.
+const maxRecent = 50
+
+// RecentEntry is a workspace handle and when a command last resolved it.
+type RecentEntry struct {
+	Handle string    `json:"handle"`
+	UsedAt time.Time `json:"used_at"`
+}
+
+func (s *FSStore) recentPath() string {
+	return filepath.Join(s.root, recentFileName)
+}
+
+// readRecent returns the stored recent list, newest first. A missing or
+// unreadable file yields an empty list; the history is a convenience.
+func (s *FSStore) readRecent() []RecentEntry {
+	data, err := os.ReadFile(s.recentPath())
+	if err != nil {
+		return nil
+	}
+	var entries []RecentEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil
+	}
+	return entries
+}
+
+// TouchRecent moves handle to the front of the recent list.
+func (s *FSStore) TouchRecent(ctx context.Context, handle string) error {
+	entries := []RecentEntry{{Handle: handle, UsedAt: s.clock.Now().UTC()}}
+	for _, e := range s.readRecent() {
+		if e.Handle != handle && len(entries) < maxRecent {
+			entries = append(entries, e)
+		}
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling recent workspaces: %w", err)
+	}
+	if err := fs.WriteJson(s.recentPath(), data); err != nil {
+		return fmt.Errorf("writing recent workspaces: %w", err)
+	}
+	return nil
+}
+
+// RecentHandles returns the recently used workspaces, newest first, skipping
+// any that no longer exist.
+func (s *FSStore) RecentHandles(ctx context.Context) ([]RecentEntry, error) {
+	entries := []RecentEntry{}
+	for _, e := range s.readRecent() {
+		if _, err := s.Get(ctx, e.Handle); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// renameRecent replaces handle with newHandle in the recent list, keeping its
+// position. The list is a convenience, so failures are ignored.
+func (s *FSStore) renameRecent(handle, newHandle string) {
+	entries := s.readRecent()
+	changed := false
+	for i := range entries {
+		if entries[i].Handle == handle {
+			entries[i].Handle = newHandle
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = fs.WriteJson(s.recentPath(), data)
+}
@@ -0,0 +1,259 @@
+package workspace
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ReconcileResult lists the repositories ReconcileRepositories changed.
+type ReconcileResult struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Updated []string `json:"updated"`
+}
+
+// Empty reports whether the workspace already matched the desired set.
+func (r *ReconcileResult) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Updated) == 0
+}
+
+// ParseManifest reads a repository manifest: one url[@ref][::depth] per line,
+// in the same syntax as --repo, optionally followed by whitespace and a setup
+// command run with sh -c after the repository is cloned. Blank lines and lines
+// starting with # are ignored.
+func ParseManifest(r io.Reader) ([]RepositoryOption, error) {
+	var repos []RepositoryOption
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		spec, setup := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			spec, setup = line[:i], line[i+1:]
+		}
+		url, ref, depth := ParseRepoFlag(spec)
+		opt := RepositoryOption{URL: url, Ref: ref, Depth: depth}
+		if setup = strings.TrimSpace(setup); setup != "" {
+			opt.PostClone = ShellCommand(setup)
+		}
+		repos = append(repos, opt)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	return repos, nil
+}
+
+// ReconcilePlan is what ReconcileRepositories would change, as reported by
+// PlanReconcile.
+type ReconcilePlan struct {
+	// Add holds the repositories that would be cloned, in manifest order.
+	Add []Repository
+	// Remove holds the repositories that would be deleted.
+	Remove []Repository
+	// Checkout maps each repository moving to a new ref to that ref.
+	Checkout map[string]string
+}
+
+// Empty reports whether the workspace already matches the desired set.
+func (p *ReconcilePlan) Empty() bool {
+	return len(p.Add) == 0 && len(p.Remove) == 0 && len(p.Checkout) == 0
+}
+
+// checkout records a repository moved to a new ref and where it was before.
+type checkout struct {
+	dir      string
+	previous string
+}
+
+// PlanReconcile reports what ReconcileRepositories would do with desired
+// without cloning, checking out or removing anything.
+func (s *FSStore) PlanReconcile(ctx context.Context, handle string, desired []RepositoryOption, invocationCWD string) (*ReconcilePlan, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRepositories(desired, invocationCWD); err != nil {
+		return nil, fmt.Errorf("invalid repository: %w", err)
+	}
+	return planReconcile(ws, desired, invocationCWD)
+}
+
+// planReconcile compares the workspace's repositories with desired.
+func planReconcile(ws *Workspace, desired []RepositoryOption, invocationCWD string) (*ReconcilePlan, error) {
+	existing := make(map[string]Repository, len(ws.Repositories))
+	for _, repo := range ws.Repositories {
+		existing[repo.Name] = repo
+	}
+
+	plan := &ReconcilePlan{Checkout: make(map[string]string)}
+	wanted := make(map[string]bool, len(desired))
+	for _, opt := range desired {
+		name := extractRepoName(opt.URL, invocationCWD)
+		wanted[name] = true
+
+		url := opt.URL
+		if isLocalPath(url) {
+			absPath, err := resolveLocalPath(url, invocationCWD)
+			if err != nil {
+				return nil, fmt.Errorf("resolving local path %s: %w", url, err)
+			}
+			url = absPath
+		}
+
+		current, ok := existing[name]
+		if !ok {
+			plan.Add = append(plan.Add, Repository{URL: url, Ref: opt.Ref, Name: name, Depth: opt.Depth, PostClone: opt.PostClone})
+			continue
+		}
+		if current.URL != url {
+			return nil, fmt.Errorf("repository %s is cloned from %s, not %s", name, current.URL, url)
+		}
+		if opt.Ref != "" && opt.Ref != current.Ref {
+			plan.Checkout[name] = opt.Ref
+		}
+	}
+
+	for _, repo := range ws.Repositories {
+		if !wanted[repo.Name] {
+			plan.Remove = append(plan.Remove, repo)
+		}
+	}
+	return plan, nil
+}
+
+// ReconcileRepositories makes the workspace's repositories match desired:
+// missing repositories are cloned, repositories not listed are removed and
+// repositories whose ref changed are checked out at the new ref. A desired
+// entry without a ref keeps the repository's current ref. Metadata is
+// written once at the end; if any step fails every change is rolled back.
+func (s *FSStore) ReconcileRepositories(ctx context.Context, handle string, desired []RepositoryOption, invocationCWD string) (*ReconcileResult, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRepositories(desired, invocationCWD); err != nil {
+		return nil, fmt.Errorf("invalid repository: %w", err)
+	}
+
+	plan, err := planReconcile(ws, desired, invocationCWD)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReconcileResult{Added: []string{}, Removed: []string{}, Updated: []string{}}
+	if plan.Empty() {
+		return result, nil
+	}
+	toAdd, toRemove, updatedRefs := plan.Add, plan.Remove, plan.Checkout
+	removing := make(map[string]bool, len(toRemove))
+	for _, repo := range toRemove {
+		removing[repo.Name] = true
+	}
+
+	var cloned []string
+	var checkedOut []checkout
+	stagingDir := filepath.Join(ws.Path, ".workshed", fmt.Sprintf("reconcile-%d", time.Now().UnixNano()))
+	var staged []string
+
+	success := false
+	defer func() {
+		if success {
+			_ = os.RemoveAll(stagingDir)
+			return
+		}
+		for _, name := range staged {
+			_ = os.Rename(filepath.Join(stagingDir, name), filepath.Join(ws.Path, name))
+		}
+		_ = os.RemoveAll(stagingDir)
+		for _, c := range checkedOut {
+			_ = s.git.Checkout(ctx, c.dir, c.previous)
+		}
+		for _, name := range cloned {
+			_ = os.RemoveAll(filepath.Join(ws.Path, name))
+		}
+	}()
+
+	for i := range toAdd {
+		cloned = append(cloned, toAdd[i].Name)
+		detectedRef, err := s.cloneRepo(ctx, toAdd[i], ws.Path, invocationCWD, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to clone %s: %w", toAdd[i].Name, err)
+		}
+		if detectedRef != "" && toAdd[i].Ref == "" {
+			toAdd[i].Ref = detectedRef
+		}
+		result.Added = append(result.Added, toAdd[i].Name)
+	}
+	setupResults, err := s.runPostClone(ctx, ws.Path, toAdd)
+	if err != nil {
+		return nil, fmt.Errorf("running setup commands: %w", err)
+	}
+
+	repos := make([]Repository, 0, len(ws.Repositories)+len(toAdd))
+	for _, repo := range ws.Repositories {
+		if removing[repo.Name] {
+			continue
+		}
+		if ref, ok := updatedRefs[repo.Name]; ok {
+			repoDir := filepath.Join(ws.Path, repo.Name)
+			previous, err := s.currentCheckout(ctx, repoDir)
+			if err != nil {
+				return nil, fmt.Errorf("reading current checkout of %s: %w", repo.Name, err)
+			}
+			if err := s.git.Checkout(ctx, repoDir, ref); err != nil {
+				return nil, fmt.Errorf("failed to check out %s in %s: %w", ref, repo.Name, err)
+			}
+			checkedOut = append(checkedOut, checkout{dir: repoDir, previous: previous})
+			repo.Ref = ref
+			result.Updated = append(result.Updated, repo.Name)
+		}
+		repos = append(repos, repo)
+	}
+	repos = append(repos, toAdd...)
+
+	// Removed repositories are moved aside rather than deleted so a later
+	// failure can put them back.
+	if len(toRemove) > 0 {
+		if err := os.MkdirAll(stagingDir, 0755); err != nil {
+			return nil, fmt.Errorf("creating staging directory: %w", err)
+		}
+	}
+	for _, repo := range toRemove {
+		repoDir := filepath.Join(ws.Path, repo.Name)
+		if _, err := os.Stat(repoDir); err == nil {
+			if err := os.Rename(repoDir, filepath.Join(stagingDir, repo.Name)); err != nil {
+				return nil, fmt.Errorf("removing repository directory: %w", err)
+			}
+			staged = append(staged, repo.Name)
+		}
+		result.Removed = append(result.Removed, repo.Name)
+	}
+
+	ws.Repositories = repos
+	if err := s.writeMetadataToDir(ws, ws.Path); err != nil {
+		return nil, fmt.Errorf("updating metadata: %w", err)
+	}
+
+	success = true
+	s.recordPostClone(ctx, ws.Handle, toAdd, setupResults)
+	return result, nil
+}
+
+// currentCheckout returns the branch checked out in dir, or the HEAD commit
+// when it is detached, so it can be restored with Checkout.
+func (s *FSStore) currentCheckout(ctx context.Context, dir string) (string, error) {
+	if branch, err := s.git.CurrentBranch(ctx, dir); err == nil && branch != "" {
+		return branch, nil
+	}
+	return s.git.RevParse(ctx, dir, "HEAD")
+}
@@ -1,10 +1,44 @@
 package workspace
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 )
 
+// DefaultRepoHost is the host used to expand owner/repo shorthand.
+const DefaultRepoHost = "github.com"
+
+const envDefaultHost = "WORKSHED_DEFAULT_HOST"
+
+// shorthandPattern matches owner/repo. Owners cannot contain dots, so host/owner
+// (e.g. github.com/org) and relative paths like ./lib or ../lib never match.
+var shorthandPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*/[A-Za-z0-9_.-]+$`)
+
+// ExpandRepoShorthand turns owner/repo into https://<host>/owner/repo.
+// An empty host falls back to WORKSHED_DEFAULT_HOST, then DefaultRepoHost.
+// Values that name an existing local path are returned unchanged.
+func ExpandRepoShorthand(url, host, invocationCWD string) string {
+	if !shorthandPattern.MatchString(url) {
+		return url
+	}
+	if _, err := os.Stat(filepath.Join(invocationCWD, url)); err == nil {
+		return url
+	}
+	if host == "" {
+		host = os.Getenv(envDefaultHost)
+	}
+	if host == "" {
+		host = DefaultRepoHost
+	}
+	return "https://" + strings.TrimSuffix(host, "/") + "/" + url
+}
+
 func ParseRepoFlag(repo string) (url, ref string, depth int) {
 	repo = strings.TrimSpace(repo)
 
@@ -50,3 +84,89 @@ func ParseRepoFlag(repo string) (url, ref string, depth int) {
 	url = strings.TrimSuffix(url, ".git")
 	return url, ref, depth
 }
+
+// ParseRemoteFlags turns name=url values into a map of additional remotes.
+// origin is reserved for the repository URL itself.
+func ParseRemoteFlags(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	remotes := make(map[string]string, len(values))
+	for _, value := range values {
+		name, url, ok := strings.Cut(strings.TrimSpace(value), "=")
+		name = strings.TrimSpace(name)
+		url = strings.TrimSpace(url)
+		if !ok || name == "" || url == "" {
+			return nil, fmt.Errorf("invalid remote %q (expected name=url)", value)
+		}
+		if name == "origin" {
+			return nil, fmt.Errorf("invalid remote %q: origin is the repository URL", value)
+		}
+		if err := ValidateRemote(name, url); err != nil {
+			return nil, err
+		}
+		if _, dup := remotes[name]; dup {
+			return nil, fmt.Errorf("remote %q given more than once", name)
+		}
+		remotes[name] = url
+	}
+	return remotes, nil
+}
+
+// ValidateRemote checks that name is usable as a git remote name, following
+// the rules of git check-ref-format for refs/remotes/<name>, and that neither
+// name nor url could be taken for a git option.
+func ValidateRemote(name, url string) error {
+	if err := validateRemoteName(name); err != nil {
+		return fmt.Errorf("invalid remote name %q: %w", name, err)
+	}
+	switch {
+	case url == "":
+		return fmt.Errorf("invalid remote %s: URL is required", name)
+	case strings.HasPrefix(url, "-"):
+		return fmt.Errorf("invalid remote %s: URL %q must not start with '-'", name, url)
+	}
+	return nil
+}
+
+func validateRemoteName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("must not be empty")
+	case strings.HasPrefix(name, "-"):
+		return errors.New("must not start with '-'")
+	case name == "@":
+		return errors.New("must not be '@'")
+	case strings.Contains(name, ".."), strings.Contains(name, "@{"), strings.Contains(name, "//"):
+		return errors.New("must not contain '..', '@{' or '//'")
+	case strings.HasPrefix(name, "/"), strings.HasSuffix(name, "/"), strings.HasSuffix(name, "."):
+		return errors.New("must not start or end with '/' or end with '.'")
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return fmt.Errorf("must not contain %q", r)
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return errors.New("path components must not start with '.' or end with '.lock'")
+		}
+	}
+	return nil
+}
+
+// UsesLFS reports whether the repository checked out in dir tracks files with
+// Git LFS, judged by a filter=lfs attribute in its top-level .gitattributes.
+func UsesLFS(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, ".gitattributes"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") && slices.Contains(strings.Fields(line), "filter=lfs") {
+			return true
+		}
+	}
+	return false
+}
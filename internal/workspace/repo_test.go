@@ -1,6 +1,8 @@
 package workspace
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -148,3 +150,104 @@ func TestParseRepoFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandRepoShorthand(t *testing.T) {
+	t.Run("should expand owner/repo to the default host", func(t *testing.T) {
+		t.Setenv("WORKSHED_DEFAULT_HOST", "")
+		got := ExpandRepoShorthand("org/repo", "", t.TempDir())
+		if got != "https://github.com/org/repo" {
+			t.Errorf("ExpandRepoShorthand() = %q, want https://github.com/org/repo", got)
+		}
+	})
+
+	t.Run("should use a custom host", func(t *testing.T) {
+		got := ExpandRepoShorthand("org/repo", "gitlab.example.com", t.TempDir())
+		if got != "https://gitlab.example.com/org/repo" {
+			t.Errorf("ExpandRepoShorthand() = %q, want https://gitlab.example.com/org/repo", got)
+		}
+	})
+
+	t.Run("should use WORKSHED_DEFAULT_HOST when no host is given", func(t *testing.T) {
+		t.Setenv("WORKSHED_DEFAULT_HOST", "git.example.com")
+		got := ExpandRepoShorthand("org/repo", "", t.TempDir())
+		if got != "https://git.example.com/org/repo" {
+			t.Errorf("ExpandRepoShorthand() = %q, want https://git.example.com/org/repo", got)
+		}
+	})
+
+	t.Run("should not expand an existing local relative path", func(t *testing.T) {
+		cwd := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(cwd, "libs", "shared"), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if got := ExpandRepoShorthand("libs/shared", "", cwd); got != "libs/shared" {
+			t.Errorf("ExpandRepoShorthand() = %q, want libs/shared", got)
+		}
+	})
+
+	t.Run("should leave other forms unchanged", func(t *testing.T) {
+		for _, url := range []string{
+			"https://github.com/org/repo",
+			"github.com/org",
+			"git@github.com:org/repo",
+			"./lib",
+			"../lib/repo",
+			"~/code/repo",
+			"/abs/path",
+			"repo",
+		} {
+			if got := ExpandRepoShorthand(url, "", t.TempDir()); got != url {
+				t.Errorf("ExpandRepoShorthand(%q) = %q, want unchanged", url, got)
+			}
+		}
+	})
+}
+
+func TestParseRemoteFlags(t *testing.T) {
+	t.Run("should parse name=url pairs", func(t *testing.T) {
+		got, err := ParseRemoteFlags([]string{"upstream=https://github.com/org/repo", " fork = git@github.com:me/repo "})
+		if err != nil {
+			t.Fatalf("ParseRemoteFlags failed: %v", err)
+		}
+		if len(got) != 2 || got["upstream"] != "https://github.com/org/repo" || got["fork"] != "git@github.com:me/repo" {
+			t.Errorf("ParseRemoteFlags() = %v", got)
+		}
+	})
+
+	t.Run("should reject malformed, duplicate and origin remotes", func(t *testing.T) {
+		for _, values := range [][]string{
+			{"upstream"},
+			{"=https://github.com/org/repo"},
+			{"upstream="},
+			{"origin=https://github.com/org/repo"},
+			{"upstream=a", "upstream=b"},
+		} {
+			if _, err := ParseRemoteFlags(values); err == nil {
+				t.Errorf("ParseRemoteFlags(%q) should fail", values)
+			}
+		}
+	})
+}
+
+func TestValidateRemote(t *testing.T) {
+	for _, name := range []string{"upstream", "fork-1", "team/mirror", "me_2.0"} {
+		if err := ValidateRemote(name, "https://github.com/org/repo"); err != nil {
+			t.Errorf("ValidateRemote(%q) failed: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{
+		"--mirror=fetch", "-v", "a..b", "a b", "a:b", "a~1", "a^", "a?", "a*", "a[b", `a\b`,
+		".hidden", "a/.b", "a.lock", "a/", "/a", "a//b", "a.", "@", "a@{1}", "a\tb",
+	} {
+		if err := ValidateRemote(name, "https://github.com/org/repo"); err == nil {
+			t.Errorf("ValidateRemote(%q) should fail", name)
+		}
+	}
+
+	for _, url := range []string{"", "--upload-pack=touch /tmp/x", "-oProxyCommand=x"} {
+		if err := ValidateRemote("upstream", url); err == nil {
+			t.Errorf("ValidateRemote with URL %q should fail", url)
+		}
+	}
+}
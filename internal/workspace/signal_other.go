@@ -0,0 +1,10 @@
+//go:build !unix
+
+package workspace
+
+import "os/exec"
+
+// exitSignal reports no signal: processes are not killed by signals here.
+func exitSignal(exitErr *exec.ExitError) (string, int) {
+	return "", 0
+}
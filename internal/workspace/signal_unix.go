@@ -0,0 +1,25 @@
+//go:build unix
+
+package workspace
+
+import (
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// exitSignal returns the name of the signal that killed the process, such as
+// "SIGKILL", and its number, or "" when the process exited on its own.
+func exitSignal(exitErr *exec.ExitError) (string, int) {
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return "", 0
+	}
+	sig := status.Signal()
+	name := unix.SignalName(sig)
+	if name == "" {
+		name = sig.String()
+	}
+	return name, int(sig)
+}
@@ -0,0 +1,65 @@
+//go:build unix && !integration
+
+package workspace
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExecSignal(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := CreateMockedTestStore(t)
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "Signals",
+		Repositories: []RepositoryOption{{URL: "https://github.com/org/api", Ref: "main"}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	CreateFakeRepo(t, ws.Path, "api")
+
+	t.Run("a killed command records the signal", func(t *testing.T) {
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Command: []string{"sh", "-c", "kill -KILL $$"}})
+		if err == nil || !strings.Contains(err.Error(), "command in api killed by SIGKILL") {
+			t.Errorf("Expected a killed-by error, got %v", err)
+		}
+		if len(results) != 1 || results[0].Signal != "SIGKILL" || results[0].ExitCode != 137 {
+			t.Fatalf("Expected SIGKILL with exit code 137, got %+v", results)
+		}
+	})
+
+	t.Run("a plain non-zero exit has no signal", func(t *testing.T) {
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Command: []string{"sh", "-c", "exit 137"}})
+		if err == nil || strings.Contains(err.Error(), "killed") {
+			t.Errorf("Expected a plain exit error, got %v", err)
+		}
+		if len(results) != 1 || results[0].Signal != "" || results[0].ExitCode != 137 {
+			t.Fatalf("Expected exit code 137 without a signal, got %+v", results)
+		}
+	})
+
+	t.Run("the signal is kept in the execution record", func(t *testing.T) {
+		results, _ := store.Exec(ctx, ws.Handle, ExecOptions{Command: []string{"sh", "-c", "kill -SEGV $$"}})
+		if len(results) != 1 || results[0].Signal != "SIGSEGV" {
+			t.Fatalf("Expected SIGSEGV, got %+v", results)
+		}
+		record := ExecutionRecord{
+			ID:       "exec-signal",
+			Command:  []string{"sh", "-c", "kill -SEGV $$"},
+			ExitCode: results[0].ExitCode,
+			Results:  []ExecutionRepoResult{{Repository: "api", ExitCode: results[0].ExitCode, Signal: results[0].Signal}},
+		}
+		if err := store.RecordExecution(ctx, ws.Handle, record, results); err != nil {
+			t.Fatalf("RecordExecution failed: %v", err)
+		}
+		got, err := store.GetExecution(ctx, ws.Handle, "exec-signal")
+		if err != nil {
+			t.Fatalf("GetExecution failed: %v", err)
+		}
+		if got.Results[0].Signal != "SIGSEGV" {
+			t.Errorf("Expected the recorded signal to be SIGSEGV, got %+v", got.Results[0])
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/frodi/workshed/internal/fs"
+	"github.com/oklog/ulid/v2"
+)
+
+// stashesDirName holds one JSON file per workshed stash under .workshed.
+const stashesDirName = "stashes"
+
+// ErrNoStash is returned by StashPop when the workspace has no stash.
+var ErrNoStash = errors.New("no stash to pop")
+
+// StashEntry records one Stash: the git stash commit pushed in each
+// repository that had changes. Entries form a stack; StashPop takes the
+// newest.
+type StashEntry struct {
+	// ID is a ULID; it names the entry's file and orders entries by time.
+	ID        string            `json:"id"`
+	Timestamp time.Time         `json:"timestamp"`
+	Commits   map[string]string `json:"commits"`
+}
+
+// StashResult is what Stash or StashPop did in one repository.
+type StashResult struct {
+	Repository string
+	// Commit is the stash commit pushed or popped, empty when the repository
+	// had nothing to stash or was not part of the stash being popped.
+	Commit string
+}
+
+func stashesDir(ws *Workspace) string {
+	return filepath.Join(ws.Path, ".workshed", stashesDirName)
+}
+
+// Stash runs git stash push, untracked files included, in every repository
+// of the workspace and records the stash commits as one entry. Repositories
+// without changes are reported with an empty Commit. If a push fails, the
+// stashes already pushed are popped again.
+func (s *FSStore) Stash(ctx context.Context, handle string) ([]StashResult, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	message := "workshed stash " + ws.Handle
+	results := make([]StashResult, 0, len(ws.Repositories))
+	commits := make(map[string]string)
+	for _, repo := range ws.Repositories {
+		result := StashResult{Repository: repo.Name}
+		if repo.NoCheckout {
+			results = append(results, result)
+			continue
+		}
+		commit, err := s.git.StashPush(ctx, filepath.Join(ws.Path, repo.Name), message)
+		if err != nil {
+			for name, pushed := range commits {
+				_ = s.git.StashPop(ctx, filepath.Join(ws.Path, name), pushed)
+			}
+			return nil, fmt.Errorf("stashing %s: %w", repo.Name, err)
+		}
+		if commit != "" {
+			commits[repo.Name] = commit
+			result.Commit = commit
+		}
+		results = append(results, result)
+	}
+
+	if len(commits) == 0 {
+		return results, nil
+	}
+
+	id := s.newID()
+	entry := StashEntry{ID: id.String(), Timestamp: ulid.Time(id.Time()), Commits: commits}
+	if err := s.writeStash(ws, entry); err != nil {
+		for name, pushed := range commits {
+			_ = s.git.StashPop(ctx, filepath.Join(ws.Path, name), pushed)
+		}
+		return nil, err
+	}
+	return results, nil
+}
+
+// StashPop restores the newest stash entry in each repository it covers and
+// removes the entry. If a pop fails, for example on a conflict, the
+// repositories not yet restored stay in the entry so StashPop can be rerun.
+func (s *FSStore) StashPop(ctx context.Context, handle string) ([]StashResult, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.listStashes(ws)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrNoStash
+	}
+	entry := entries[0]
+
+	for name := range entry.Commits {
+		if ws.GetRepositoryByName(name) == nil {
+			return nil, fmt.Errorf("stashed repository %s is no longer in the workspace", name)
+		}
+	}
+
+	results := make([]StashResult, 0, len(ws.Repositories))
+	for _, repo := range ws.Repositories {
+		commit, ok := entry.Commits[repo.Name]
+		if !ok {
+			results = append(results, StashResult{Repository: repo.Name})
+			continue
+		}
+		if err := s.git.StashPop(ctx, filepath.Join(ws.Path, repo.Name), commit); err != nil {
+			if werr := s.writeStash(ws, entry); werr != nil {
+				return results, fmt.Errorf("popping stash in %s: %w; %v", repo.Name, err, werr)
+			}
+			return results, fmt.Errorf("popping stash in %s: %w", repo.Name, err)
+		}
+		delete(entry.Commits, repo.Name)
+		results = append(results, StashResult{Repository: repo.Name, Commit: commit})
+	}
+
+	if err := os.Remove(filepath.Join(stashesDir(ws), entry.ID+".json")); err != nil && !os.IsNotExist(err) {
+		return results, fmt.Errorf("removing stash entry: %w", err)
+	}
+	return results, nil
+}
+
+// ListStashes returns the workspace's stash entries, newest first; the first
+// is the one StashPop restores.
+func (s *FSStore) ListStashes(ctx context.Context, handle string) ([]StashEntry, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	return s.listStashes(ws)
+}
+
+func (s *FSStore) writeStash(ws *Workspace, entry StashEntry) error {
+	if err := os.MkdirAll(stashesDir(ws), 0755); err != nil {
+		return fmt.Errorf("creating stashes directory: %w", err)
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling stash entry: %w", err)
+	}
+	if err := fs.WriteJson(filepath.Join(stashesDir(ws), entry.ID+".json"), data); err != nil {
+		return fmt.Errorf("writing stash entry: %w", err)
+	}
+	return nil
+}
+
+// listStashes returns the workspace's stash entries, newest first. Entries
+// that no longer parse are skipped.
+func (s *FSStore) listStashes(ws *Workspace) ([]StashEntry, error) {
+	dirEntries, err := os.ReadDir(stashesDir(ws))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading stashes directory: %w", err)
+	}
+
+	var entries []StashEntry
+	for _, e := range dirEntries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(stashesDir(ws), e.Name()))
+		if err != nil {
+			continue
+		}
+		var entry StashEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID > entries[j].ID
+	})
+	return entries, nil
+}
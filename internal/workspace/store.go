@@ -1,6 +1,7 @@
 package workspace
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -8,9 +9,14 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"os/user"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/atotto/clipboard"
@@ -18,7 +24,6 @@ import (
 	"github.com/frodi/workshed/internal/git"
 	"github.com/frodi/workshed/internal/handle"
 	"github.com/frodi/workshed/internal/version"
-	"github.com/oklog/ulid/v2"
 )
 
 type defaultClipboard struct{}
@@ -30,11 +35,18 @@ func (defaultClipboard) WriteAll(s string) error {
 const metadataFileName = ".workshed.json"
 const executionsDirName = "executions"
 const capturesDirName = "captures"
+const envFileName = "env"
 
 // FSStore is a filesystem-based workspace store that manages workspace directories and metadata.
 type FSStore struct {
-	root string
-	git  git.Git
+	root      string
+	git       git.Git
+	retention RetentionPolicy
+	events    EventSink
+	handles   *handle.Generator
+	clock     Clock
+	// compressCaptures stores new captures as capture.tar.gz.
+	compressCaptures bool
 }
 
 // NewFSStore creates a new filesystem-based workspace store at the specified root directory.
@@ -57,7 +69,51 @@ func NewFSStore(root string, g ...git.Git) (*FSStore, error) {
 		gitClient = g[0]
 	}
 
-	return &FSStore{root: absRoot, git: gitClient}, nil
+	return &FSStore{root: absRoot, git: gitClient, events: noopSink{}, handles: handle.NewGenerator(), clock: realClock{}}, nil
+}
+
+// SetHandleGenerator replaces the generator used to pick handles for new workspaces.
+func (s *FSStore) SetHandleGenerator(g *handle.Generator) {
+	s.handles = g
+}
+
+// SetRetention replaces the policy applied after each recorded execution in
+// workspaces without their own. The default policy keeps everything.
+func (s *FSStore) SetRetention(policy RetentionPolicy) {
+	s.retention = policy
+}
+
+// SetClock replaces the clock used for timestamps and age checks. A nil clock
+// restores the system clock.
+func (s *FSStore) SetClock(clock Clock) {
+	if clock == nil {
+		clock = realClock{}
+	}
+	s.clock = clock
+}
+
+// SetCompressCaptures makes new captures be stored as a single compressed
+// archive instead of a plain capture.json. Captures in either format are
+// always readable.
+func (s *FSStore) SetCompressCaptures(compress bool) {
+	s.compressCaptures = compress
+}
+
+// SetEventSink replaces the sink that receives lifecycle events. A nil sink disables events.
+func (s *FSStore) SetEventSink(sink EventSink) {
+	if sink == nil {
+		sink = noopSink{}
+	}
+	s.events = sink
+}
+
+// emit delivers an event to the configured sink. Sink failures are ignored so
+// that integrations can never break a store operation.
+func (s *FSStore) emit(ctx context.Context, event StoreEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = s.clock.Now()
+	}
+	_ = s.events.Emit(ctx, event)
 }
 
 // Create creates a new workspace with the given options and returns the workspace metadata.
@@ -66,6 +122,17 @@ func (s *FSStore) Create(ctx context.Context, opts CreateOptions) (*Workspace, e
 		return nil, errors.New("purpose is required")
 	}
 
+	if opts.IdempotencyKey != "" {
+		existing, err := s.findByIdempotencyKey(ctx, opts.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			existing.Existing = true
+			return existing, nil
+		}
+	}
+
 	if opts.Template != "" {
 		if err := validateTemplatePath(opts.Template); err != nil {
 			return nil, fmt.Errorf("invalid template: %w", err)
@@ -91,8 +158,7 @@ func (s *FSStore) Create(ctx context.Context, opts CreateOptions) (*Workspace, e
 		}
 	}
 
-	gen := handle.NewGenerator()
-	h, err := gen.GenerateUnique(func(h string) bool {
+	h, err := s.handles.GenerateUnique(func(h string) bool {
 		_, err := s.Get(ctx, h)
 		return err == nil
 	})
@@ -111,20 +177,39 @@ func (s *FSStore) Create(ctx context.Context, opts CreateOptions) (*Workspace, e
 			url = absPath
 		}
 
+		ref := opt.Ref
+		if ref == "" && !opt.CopyWorkingTree {
+			ref = opts.DefaultRef
+		}
+		if opts.NewBranchFrom != "" && !opt.CopyWorkingTree {
+			ref = opts.NewBranchFrom
+		}
+
 		clonedRepos[i] = Repository{
-			URL:   url,
-			Ref:   opt.Ref,
-			Name:  extractRepoName(opt.URL, opts.InvocationCWD),
-			Depth: opt.Depth,
+			URL:        url,
+			Ref:        ref,
+			Name:       extractRepoName(opt.URL, opts.InvocationCWD),
+			Depth:      opt.Depth,
+			NoCheckout: opt.NoCheckout,
+			Sparse:     opt.Sparse,
+			Remotes:    opt.Remotes,
+			LFS:        opt.LFS,
+			PostClone:  opt.PostClone,
+		}
+		if opt.CopyWorkingTree {
+			clonedRepos[i].Source = RepositorySourceWorkingTree
+			clonedRepos[i].IncludeIgnored = opt.IncludeIgnored
 		}
 	}
 
 	ws := &Workspace{
-		Version:      CurrentMetadataVersion,
-		Handle:       h,
-		Purpose:      opts.Purpose,
-		Repositories: clonedRepos,
-		CreatedAt:    time.Now(),
+		Version:        CurrentMetadataVersion,
+		Handle:         h,
+		Purpose:        opts.Purpose,
+		Project:        opts.Project,
+		Repositories:   clonedRepos,
+		CreatedAt:      s.clock.Now(),
+		IdempotencyKey: opts.IdempotencyKey,
 	}
 
 	tmpDir, err := os.MkdirTemp(s.root, ".tmp-")
@@ -158,15 +243,27 @@ func (s *FSStore) Create(ctx context.Context, opts CreateOptions) (*Workspace, e
 		}
 	}
 
-	if err := s.cloneRepositories(ctx, clonedRepos, tmpDir, opts.InvocationCWD); err != nil {
+	if err := s.cloneRepositories(ctx, clonedRepos, tmpDir, opts.InvocationCWD, opts.OnProgress, opts.Concurrency); err != nil {
 		if cleanupErr != nil {
 			return nil, fmt.Errorf("cloning repositories: %w; %v", err, cleanupErr)
 		}
 		return nil, fmt.Errorf("cloning repositories: %w", err)
 	}
 
-	finalDir := s.workspaceDir(h)
-	if err := os.Rename(tmpDir, finalDir); err != nil {
+	if opts.NewBranch != "" {
+		for i := range clonedRepos {
+			if err := s.git.CreateBranch(ctx, filepath.Join(tmpDir, clonedRepos[i].Name), opts.NewBranch); err != nil {
+				return nil, fmt.Errorf("creating branch %s in %s: %w", opts.NewBranch, clonedRepos[i].Name, err)
+			}
+			clonedRepos[i].Ref = opts.NewBranch
+		}
+		if err := s.writeMetadataToDir(ws, tmpDir); err != nil {
+			return nil, fmt.Errorf("writing metadata: %w", err)
+		}
+	}
+
+	finalDir, err := s.finalizeWorkspaceDir(ws, tmpDir)
+	if err != nil {
 		if cleanupErr != nil {
 			return nil, fmt.Errorf("finalizing workspace: %w; %v", err, cleanupErr)
 		}
@@ -175,9 +272,83 @@ func (s *FSStore) Create(ctx context.Context, opts CreateOptions) (*Workspace, e
 
 	success = true
 	ws.Path = finalDir
+
+	// Setup commands run in the final directory so paths they write into
+	// config (hooks, virtualenvs) stay valid. A failure removes the workspace
+	// again, keeping the create all or nothing.
+	setupResults, err := s.runPostClone(ctx, finalDir, clonedRepos)
+	if err != nil {
+		if rmErr := os.RemoveAll(finalDir); rmErr != nil {
+			return nil, fmt.Errorf("running setup commands: %w; removing workspace %s failed: %v", err, finalDir, rmErr)
+		}
+		return nil, fmt.Errorf("running setup commands: %w", err)
+	}
+
+	entry := HistoryEntry{Operation: HistoryCreate}
+	for _, repo := range ws.Repositories {
+		entry.Changes = append(entry.Changes, RefChange{Repository: repo.Name, To: s.headCommit(ctx, filepath.Join(ws.Path, repo.Name))})
+	}
+	_ = s.recordHistory(ws, entry)
+	s.recordPostClone(ctx, ws.Handle, ws.Repositories, setupResults)
+
+	s.emit(ctx, StoreEvent{Type: EventWorkspaceCreated, Handle: ws.Handle, Purpose: ws.Purpose, Path: ws.Path})
 	return ws, nil
 }
 
+// errStopScan ends a ListStream scan early once a match is found.
+var errStopScan = errors.New("stop scan")
+
+// findByIdempotencyKey returns the workspace created with key, or nil. Keys
+// are found by scanning metadata, which stays cheap because it is only done
+// for creates that pass a key.
+func (s *FSStore) findByIdempotencyKey(ctx context.Context, key string) (*Workspace, error) {
+	var found *Workspace
+	err := s.ListStream(ctx, ListOptions{}, func(ws *Workspace) error {
+		if ws.IdempotencyKey == key {
+			found = ws
+			return errStopScan
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopScan) {
+		return nil, fmt.Errorf("looking up idempotency key: %w", err)
+	}
+	return found, nil
+}
+
+// finalizeWorkspaceDir moves tmpDir into place under ws.Handle. os.Rename
+// refuses to replace an existing directory, so two creates racing on the same
+// handle cannot both finish; the loser picks a new handle, rewrites the
+// metadata in tmpDir and tries again.
+func (s *FSStore) finalizeWorkspaceDir(ws *Workspace, tmpDir string) (string, error) {
+	const maxAttempts = 100
+
+	for i := 0; i < maxAttempts; i++ {
+		finalDir := s.workspaceDir(ws.Handle)
+		err := os.Rename(tmpDir, finalDir)
+		if err == nil {
+			return finalDir, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+
+		h, err := s.handles.GenerateUnique(func(h string) bool {
+			_, err := os.Stat(s.workspaceDir(h))
+			return err == nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("generating handle: %w", err)
+		}
+		ws.Handle = h
+		if err := s.writeMetadataToDir(ws, tmpDir); err != nil {
+			return "", fmt.Errorf("writing metadata: %w", err)
+		}
+	}
+
+	return "", fmt.Errorf("failed to claim a unique handle after %d attempts", maxAttempts)
+}
+
 // Get retrieves workspace metadata by handle.
 func (s *FSStore) Get(ctx context.Context, handle string) (*Workspace, error) {
 	metaPath := filepath.Join(s.workspaceDir(handle), metadataFileName)
@@ -201,16 +372,37 @@ func (s *FSStore) Get(ctx context.Context, handle string) (*Workspace, error) {
 
 // List returns all workspaces matching the given filter options.
 func (s *FSStore) List(ctx context.Context, opts ListOptions) ([]*Workspace, error) {
+	var workspaces []*Workspace
+	err := s.ListStream(ctx, opts, func(ws *Workspace) error {
+		workspaces = append(workspaces, ws)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if workspaces == nil {
+		workspaces = []*Workspace{}
+	}
+	return workspaces, nil
+}
+
+// ListStream calls fn for each workspace matching the given filter options as
+// it is read from disk, in the same order as List. It stops at the first
+// error fn returns and returns it.
+func (s *FSStore) ListStream(ctx context.Context, opts ListOptions, fn func(*Workspace) error) error {
 	entries, err := os.ReadDir(s.root)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return []*Workspace{}, nil
+			return nil
 		}
-		return nil, fmt.Errorf("reading workspaces directory: %w", err)
+		return fmt.Errorf("reading workspaces directory: %w", err)
 	}
 
-	var workspaces []*Workspace
+	now := s.clock.Now()
 	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if !entry.IsDir() {
 			continue
 		}
@@ -224,10 +416,33 @@ func (s *FSStore) List(ctx context.Context, opts ListOptions) ([]*Workspace, err
 			continue
 		}
 
-		workspaces = append(workspaces, ws)
+		if opts.ProjectFilter != "" && !strings.EqualFold(ws.Project, opts.ProjectFilter) {
+			continue
+		}
+
+		if !opts.CreatedAfter.IsZero() && ws.CreatedAt.Before(opts.CreatedAfter) {
+			continue
+		}
+		if !opts.CreatedBefore.IsZero() && ws.CreatedAt.After(opts.CreatedBefore) {
+			continue
+		}
+
+		if opts.ActiveWithin > 0 {
+			active, err := s.executedSince(ctx, ws.Handle, now.Add(-opts.ActiveWithin))
+			if err != nil {
+				return err
+			}
+			if !active {
+				continue
+			}
+		}
+
+		if err := fn(ws); err != nil {
+			return err
+		}
 	}
 
-	return workspaces, nil
+	return nil
 }
 
 // Remove deletes the workspace with the given handle.
@@ -241,6 +456,7 @@ func (s *FSStore) Remove(ctx context.Context, handle string) error {
 		return fmt.Errorf("removing workspace directory: %w", err)
 	}
 
+	s.emit(ctx, StoreEvent{Type: EventWorkspaceRemoved, Handle: ws.Handle, Purpose: ws.Purpose, Path: ws.Path})
 	return nil
 }
 
@@ -273,6 +489,68 @@ func (s *FSStore) UpdatePurpose(ctx context.Context, handle string, purpose stri
 	return nil
 }
 
+// RenameWorkspace changes a workspace's handle to newHandle, moving its
+// directory to match. It fails without touching the workspace when newHandle
+// is invalid or already taken, and moves the directory back if the metadata
+// cannot be rewritten.
+func (s *FSStore) RenameWorkspace(ctx context.Context, handle, newHandle string) (*Workspace, error) {
+	if err := ValidateHandle(newHandle); err != nil {
+		return nil, err
+	}
+
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if newHandle == ws.Handle {
+		return nil, fmt.Errorf("workspace is already named %s", newHandle)
+	}
+
+	if _, err := s.Get(ctx, newHandle); err == nil {
+		return nil, fmt.Errorf("workspace already exists: %s", newHandle)
+	}
+	newDir := s.workspaceDir(newHandle)
+	if _, err := os.Lstat(newDir); err == nil {
+		return nil, fmt.Errorf("cannot rename to %s: %s already exists", newHandle, newDir)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("checking %s: %w", newDir, err)
+	}
+
+	oldDir := ws.Path
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return nil, fmt.Errorf("renaming workspace directory: %w", err)
+	}
+
+	ws.Handle = newHandle
+	ws.Path = newDir
+	if err := s.writeMetadataToDir(ws, newDir); err != nil {
+		if rbErr := os.Rename(newDir, oldDir); rbErr != nil {
+			return nil, fmt.Errorf("updating metadata: %w; moving the workspace back to %s also failed: %v", err, oldDir, rbErr)
+		}
+		return nil, fmt.Errorf("updating metadata: %w", err)
+	}
+
+	s.renameRecent(handle, newHandle)
+	return ws, nil
+}
+
+// ValidateHandle rejects handles that cannot safely name a directory in the
+// store root. Leading dots are reserved for the store's own files.
+func ValidateHandle(handle string) error {
+	if handle == "" {
+		return errors.New("handle cannot be empty")
+	}
+	if strings.HasPrefix(handle, ".") || strings.HasPrefix(handle, "-") {
+		return fmt.Errorf("invalid handle %q: must not start with %q", handle, handle[:1])
+	}
+	for _, r := range handle {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return fmt.Errorf("invalid handle %q: use letters, digits, '-', '_' and '.'", handle)
+		}
+	}
+	return nil
+}
+
 // AddRepository adds a single repository to an existing workspace.
 func (s *FSStore) AddRepository(ctx context.Context, handle string, repo RepositoryOption, invocationCWD string) error {
 	return s.AddRepositories(ctx, handle, []RepositoryOption{repo}, invocationCWD)
@@ -294,19 +572,19 @@ func (s *FSStore) AddRepositories(ctx context.Context, handle string, repos []Re
 	}
 
 	seenURLs := make(map[string]bool)
-	seenNames := make(map[string]bool)
+	seenNames := make(map[string]string)
 	for _, r := range ws.Repositories {
-		seenURLs[r.URL] = true
-		seenNames[r.Name] = true
+		seenURLs[repoKey(r.URL, invocationCWD)] = true
+		seenNames[strings.ToLower(r.Name)] = r.Name
 	}
 
 	for _, opt := range repos {
-		if seenURLs[opt.URL] {
+		if seenURLs[repoKey(opt.URL, invocationCWD)] {
 			return fmt.Errorf("repository already exists: %s", opt.URL)
 		}
 		name := extractRepoName(opt.URL, invocationCWD)
-		if seenNames[name] {
-			return fmt.Errorf("repository name already exists: %s", name)
+		if prev, ok := seenNames[strings.ToLower(name)]; ok {
+			return nameCollision("repository name already exists", prev, name)
 		}
 	}
 
@@ -322,10 +600,15 @@ func (s *FSStore) AddRepositories(ctx context.Context, handle string, repos []Re
 		}
 
 		clonedRepos[i] = Repository{
-			URL:   url,
-			Ref:   opt.Ref,
-			Name:  extractRepoName(opt.URL, invocationCWD),
-			Depth: opt.Depth,
+			URL:        url,
+			Ref:        opt.Ref,
+			Name:       extractRepoName(opt.URL, invocationCWD),
+			Depth:      opt.Depth,
+			NoCheckout: opt.NoCheckout,
+			Sparse:     opt.Sparse,
+			Remotes:    opt.Remotes,
+			LFS:        opt.LFS,
+			PostClone:  opt.PostClone,
 		}
 	}
 
@@ -343,7 +626,7 @@ func (s *FSStore) AddRepositories(ctx context.Context, handle string, repos []Re
 	}()
 
 	for i := range clonedRepos {
-		detectedRef, err := s.cloneRepo(ctx, clonedRepos[i], ws.Path, invocationCWD)
+		detectedRef, err := s.cloneRepo(ctx, clonedRepos[i], ws.Path, invocationCWD, nil)
 		if err != nil {
 			if cleanupErr != nil {
 				return fmt.Errorf("failed to clone %s: %w; %v", clonedRepos[i].Name, err, cleanupErr)
@@ -355,6 +638,11 @@ func (s *FSStore) AddRepositories(ctx context.Context, handle string, repos []Re
 		}
 	}
 
+	setupResults, err := s.runPostClone(ctx, ws.Path, clonedRepos)
+	if err != nil {
+		return fmt.Errorf("running setup commands: %w", err)
+	}
+
 	ws.Repositories = append(ws.Repositories, clonedRepos...)
 
 	if err := s.writeMetadataToDir(ws, ws.Path); err != nil {
@@ -365,6 +653,7 @@ func (s *FSStore) AddRepositories(ctx context.Context, handle string, repos []Re
 	}
 
 	success = true
+	s.recordPostClone(ctx, ws.Handle, clonedRepos, setupResults)
 	return nil
 }
 
@@ -402,142 +691,1209 @@ func (s *FSStore) RemoveRepository(ctx context.Context, handle string, repoName
 	return nil
 }
 
-// FindWorkspace finds the workspace that contains the given directory.
-// It walks up the directory tree looking for a .workshed.json file.
-func (s *FSStore) FindWorkspace(ctx context.Context, dir string) (*Workspace, error) {
-	absDir, err := filepath.Abs(dir)
+// RenameRepository renames a repository's directory and its name in the
+// workspace metadata, and rewrites the executions and captures that refer to
+// it. It resolves directory name collisions without re-cloning.
+func (s *FSStore) RenameRepository(ctx context.Context, handle, oldName, newName string) error {
+	ws, err := s.Get(ctx, handle)
 	if err != nil {
-		return nil, fmt.Errorf("getting absolute path: %w", err)
+		return err
 	}
 
-	for {
-		metaPath := filepath.Join(absDir, metadataFileName)
-		if _, err := os.Stat(metaPath); err == nil {
-			return s.Get(ctx, filepath.Base(absDir))
-		} else if !os.IsNotExist(err) {
-			return nil, fmt.Errorf("checking for workspace: %w", err)
+	repo := ws.GetRepositoryByName(oldName)
+	if repo == nil {
+		return fmt.Errorf("repository not found: %s", oldName)
+	}
+	if err := ValidateRepoName(newName); err != nil {
+		return err
+	}
+	if newName == oldName {
+		return nil
+	}
+	for _, other := range ws.Repositories {
+		if other.Name != oldName && strings.EqualFold(other.Name, newName) {
+			return nameCollision("repository already exists", other.Name, newName)
 		}
+	}
 
-		parent := filepath.Dir(absDir)
-		if parent == absDir {
-			return nil, errors.New("not in a workspace directory")
+	oldDir := filepath.Join(ws.Path, oldName)
+	newDir := filepath.Join(ws.Path, newName)
+	if _, err := os.Lstat(newDir); err == nil {
+		return fmt.Errorf("%s already exists in the workspace directory", newName)
+	}
+	moved := false
+	if _, err := os.Stat(oldDir); err == nil {
+		if err := os.Rename(oldDir, newDir); err != nil {
+			return fmt.Errorf("renaming repository directory: %w", err)
 		}
-		absDir = parent
+		moved = true
 	}
-}
 
-type ExecOptions struct {
-	Target   string
-	Command  []string
-	Parallel bool
-}
+	repo.Name = newName
+	if err := s.writeMetadataToDir(ws, ws.Path); err != nil {
+		if moved {
+			_ = os.Rename(newDir, oldDir)
+		}
+		return fmt.Errorf("updating metadata: %w", err)
+	}
 
-type ExecResult struct {
-	Repository string
-	Dir        string
-	ExitCode   int
-	Output     []byte
-	Duration   time.Duration
+	if err := renameExecutionRepository(ws, oldName, newName); err != nil {
+		return fmt.Errorf("updating executions: %w", err)
+	}
+	if err := renameCaptureRepository(ws, oldName, newName); err != nil {
+		return fmt.Errorf("updating captures: %w", err)
+	}
+	return nil
 }
 
-func (s *FSStore) Exec(ctx context.Context, handle string, opts ExecOptions) ([]ExecResult, error) {
-	ws, err := s.Get(ctx, handle)
-	if err != nil {
-		return nil, err
+// ValidateRepoName checks that name can be a repository directory: a single
+// path component that is not the workspace's own .workshed directory.
+func ValidateRepoName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("repository name must not be empty")
+	case name == "." || name == ".." || name != filepath.Base(name) || strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("invalid repository name %q: must be a single path component", name)
+	case name == ".workshed":
+		return fmt.Errorf("invalid repository name %q: reserved for workspace data", name)
 	}
+	return nil
+}
 
-	var results []ExecResult
-
-	if len(opts.Command) == 0 {
-		return nil, errors.New("command cannot be empty")
+// renameExecutionRepository rewrites the results of every recorded execution
+// that ran in oldName, moving its stored output along with it.
+func renameExecutionRepository(ws *Workspace, oldName, newName string) error {
+	executionsDir := filepath.Join(ws.Path, ".workshed", executionsDirName)
+	entries, err := os.ReadDir(executionsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
 	}
 
-	if opts.Target == "" && len(ws.Repositories) == 0 {
-		opts.Target = "root"
-	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		execDir := filepath.Join(executionsDir, entry.Name())
+		recordPath := filepath.Join(execDir, "record.json")
+		data, err := os.ReadFile(recordPath)
+		if err != nil {
+			continue
+		}
+		var record ExecutionRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			continue
+		}
 
-	switch opts.Target {
-	case "", "all":
-		for _, repo := range ws.Repositories {
-			result, err := s.execInRepository(ctx, repo, ws.Path, opts.Command)
-			results = append(results, result)
-			if err != nil {
-				return results, err
+		changed := false
+		if record.Target == oldName {
+			record.Target = newName
+			changed = true
+		}
+		for i := range record.Results {
+			result := &record.Results[i]
+			if result.Repository != oldName {
+				continue
 			}
-			if result.ExitCode != 0 {
-				return results, fmt.Errorf("command failed in %s with exit code %d", repo.Name, result.ExitCode)
+			result.Repository = newName
+			if result.OutputPath != "" {
+				result.OutputPath = newName + ".txt"
+			}
+			for _, stream := range []string{"stdout", "stderr"} {
+				oldPath := filepath.Join(execDir, stream, oldName+".txt")
+				if err := os.Rename(oldPath, filepath.Join(execDir, stream, newName+".txt")); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("moving %s output of %s: %w", stream, record.ID, err)
+				}
 			}
+			changed = true
 		}
-	case "root":
-		result := ExecResult{
-			Repository: "root",
-			Dir:        ws.Path,
+		if !changed {
+			continue
 		}
-		start := time.Now()
-		cmd := exec.CommandContext(ctx, opts.Command[0], opts.Command[1:]...)
-		cmd.Dir = ws.Path
-		output, err := cmd.CombinedOutput()
-		result.Duration = time.Since(start)
 
-		result.Output = output
+		data, err = json.MarshalIndent(record, "", "  ")
 		if err != nil {
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				result.ExitCode = exitErr.ExitCode()
-			} else {
-				result.ExitCode = 1
-			}
+			return fmt.Errorf("marshaling execution record: %w", err)
 		}
-		results = append(results, result)
-		if result.ExitCode != 0 {
-			return results, fmt.Errorf("command failed with exit code %d", result.ExitCode)
+		if err := fs.WriteJson(recordPath, data); err != nil {
+			return fmt.Errorf("writing execution record: %w", err)
 		}
-	default:
-		repo := ws.GetRepositoryByName(opts.Target)
-		if repo == nil {
-			return nil, fmt.Errorf("repository not found: %s", opts.Target)
+	}
+	return nil
+}
+
+// renameCaptureRepository rewrites the git state of every capture that
+// recorded oldName, so applying it still finds the repository.
+func renameCaptureRepository(ws *Workspace, oldName, newName string) error {
+	capturesDir := filepath.Join(ws.Path, ".workshed", capturesDirName)
+	entries, err := os.ReadDir(capturesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
 		}
-		result, err := s.execInRepository(ctx, *repo, ws.Path, opts.Command)
-		results = append(results, result)
+		return err
+	}
+
+	for _, id := range captureIDs(entries) {
+		capture, err := readCapture(ws.Path, id)
 		if err != nil {
-			return results, err
+			continue
 		}
-		if result.ExitCode != 0 {
-			return results, fmt.Errorf("command failed in %s with exit code %d", repo.Name, result.ExitCode)
+		changed := false
+		for i := range capture.GitState {
+			if capture.GitState[i].Repository == oldName {
+				capture.GitState[i].Repository = newName
+				changed = true
+			}
+		}
+		if !changed {
+			continue
 		}
-	}
 
-	return results, nil
+		captureDir := filepath.Join(capturesDir, id)
+		if err := writeCaptureFile(captureDir, capture, captureIsCompressed(captureDir)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (s *FSStore) execInRepository(ctx context.Context, repo Repository, wsPath string, cmdArgs []string) (ExecResult, error) {
-	if len(cmdArgs) == 0 {
-		return ExecResult{}, errors.New("command cannot be empty")
+// CheckoutRepository checks out ref in a repository and records it. An empty
+// ref falls back to the recorded ref, then to the branch HEAD points at, which
+// is what a --no-checkout clone still needs to materialize.
+func (s *FSStore) CheckoutRepository(ctx context.Context, handle, repoName, ref string) error {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return err
 	}
 
-	repoDir := filepath.Join(wsPath, repo.Name)
-	result := ExecResult{
-		Repository: repo.Name,
-		Dir:        repoDir,
+	repo := ws.GetRepositoryByName(repoName)
+	if repo == nil {
+		return fmt.Errorf("repository not found: %s", repoName)
 	}
 
-	start := time.Now()
-	cmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
-	cmd.Dir = repoDir
-	output, err := cmd.CombinedOutput()
-	result.Duration = time.Since(start)
-
-	result.Output = output
-
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			result.ExitCode = exitErr.ExitCode()
+	repoDir := filepath.Join(ws.Path, repo.Name)
+	from := s.headCommit(ctx, repoDir)
+	if ref == "" {
+		ref = repo.Ref
+	}
+	if ref == "" {
+		branch, err := s.git.CurrentBranch(ctx, repoDir)
+		if err != nil {
+			return fmt.Errorf("detecting current branch: %w", err)
+		}
+		ref = branch
+	}
+	if ref == "" {
+		return fmt.Errorf("no ref recorded for %s; pass one explicitly", repoName)
+	}
+
+	if err := s.git.Checkout(ctx, repoDir, ref); err != nil {
+		return fmt.Errorf("checking out %s: %w", ref, err)
+	}
+	if repo.LFS {
+		if err := s.git.LFSPull(ctx, repoDir); err != nil {
+			return fmt.Errorf("pulling LFS objects: %w", err)
+		}
+	}
+	_ = s.recordHistory(ws, HistoryEntry{
+		Operation: HistoryCheckout,
+		Changes:   []RefChange{{Repository: repo.Name, From: from, To: s.headCommit(ctx, repoDir)}},
+	})
+
+	repo.Ref = ref
+	repo.NoCheckout = false
+	if err := s.writeMetadataToDir(ws, ws.Path); err != nil {
+		return fmt.Errorf("updating metadata: %w", err)
+	}
+
+	return nil
+}
+
+// UnshallowRepository fetches the full history of a shallow-cloned repository
+// with git fetch --unshallow and clears its depth from the workspace metadata.
+// It is a no-op for a repository that is already a full clone.
+func (s *FSStore) UnshallowRepository(ctx context.Context, handle string, repoName string) error {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return err
+	}
+
+	repo := ws.GetRepositoryByName(repoName)
+	if repo == nil {
+		return fmt.Errorf("repository not found: %s", repoName)
+	}
+
+	repoDir := filepath.Join(ws.Path, repo.Name)
+	if !IsShallow(*repo, repoDir) {
+		return nil
+	}
+
+	if err := s.git.Unshallow(ctx, repoDir); err != nil {
+		return fmt.Errorf("unshallowing %s: %w", repoName, err)
+	}
+
+	for i := range ws.Repositories {
+		if ws.Repositories[i].Name == repoName {
+			ws.Repositories[i].Depth = 0
+		}
+	}
+
+	if err := s.writeMetadataToDir(ws, ws.Path); err != nil {
+		return fmt.Errorf("updating metadata: %w", err)
+	}
+
+	return nil
+}
+
+// IsShallow reports whether a repository was cloned with limited history,
+// either per workspace metadata or git's own shallow marker.
+func IsShallow(repo Repository, repoDir string) bool {
+	if repo.Depth > 0 {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(repoDir, ".git", "shallow"))
+	return err == nil
+}
+
+// FindWorkspace finds the workspace that contains the given directory.
+// It walks up the directory tree looking for a .workshed.json file. The
+// directory holding it is named after the handle; RenameWorkspace moves the
+// directory along with the handle, so a renamed workspace is found under its
+// new name.
+func (s *FSStore) FindWorkspace(ctx context.Context, dir string) (*Workspace, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path: %w", err)
+	}
+	start := absDir
+
+	for {
+		metaPath := filepath.Join(absDir, metadataFileName)
+		if _, err := os.Stat(metaPath); err == nil {
+			return s.Get(ctx, filepath.Base(absDir))
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("checking for workspace: %w", err)
+		}
+
+		parent := filepath.Dir(absDir)
+		if parent == absDir {
+			return nil, &NotInWorkspaceError{Dir: start, Suggestion: s.nearestWorkspace(ctx, start)}
+		}
+		absDir = parent
+	}
+}
+
+// NotInWorkspaceError is returned by FindWorkspace when dir is not inside a
+// workspace. Suggestion, if set, is the workspace nearest to Dir.
+type NotInWorkspaceError struct {
+	Dir        string
+	Suggestion *Workspace
+}
+
+func (e *NotInWorkspaceError) Error() string {
+	if e.Suggestion == nil {
+		return "not in a workspace directory"
+	}
+	return fmt.Sprintf("not in a workspace directory; did you mean %s at %s? Run from there or pass the handle", e.Suggestion.Handle, e.Suggestion.Path)
+}
+
+// nearestWorkspace picks the workspace nearest to dir: one whose path is an
+// ancestor or descendant of dir, a sibling of dir, or any workspace when dir
+// is inside the store root. Among those it prefers the most shared leading
+// directories, then the shallowest below dir, then the most recently created.
+// With none nearby it falls back to the most recently used workspace, and it
+// returns nil when there is nothing to suggest.
+func (s *FSStore) nearestWorkspace(ctx context.Context, dir string) *Workspace {
+	workspaces, err := s.List(ctx, ListOptions{})
+	if err != nil || len(workspaces) == 0 {
+		return nil
+	}
+
+	dirParts := splitPath(dir)
+	inRoot := withinDir(s.root, dir)
+	type candidate struct {
+		ws     *Workspace
+		common int
+		extra  int
+	}
+	var best *candidate
+	for _, ws := range workspaces {
+		parts := splitPath(ws.Path)
+		common := 0
+		for common < len(parts) && common < len(dirParts) && parts[common] == dirParts[common] {
+			common++
+		}
+		related := inRoot ||
+			common == len(parts) || common == len(dirParts) ||
+			(len(parts) == len(dirParts) && common == len(dirParts)-1 && common > 1)
+		if !related {
+			continue
+		}
+		c := candidate{ws: ws, common: common, extra: len(parts) - common}
+		switch {
+		case best == nil,
+			c.common > best.common,
+			c.common == best.common && c.extra < best.extra,
+			c.common == best.common && c.extra == best.extra && c.ws.CreatedAt.After(best.ws.CreatedAt):
+			best = &c
+		}
+	}
+	if best != nil {
+		return best.ws
+	}
+
+	recent, err := s.RecentHandles(ctx)
+	if err != nil || len(recent) == 0 {
+		return nil
+	}
+	ws, err := s.Get(ctx, recent[0].Handle)
+	if err != nil {
+		return nil
+	}
+	return ws
+}
+
+func splitPath(path string) []string {
+	return strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+}
+
+type ExecOptions struct {
+	// Target is "all" (or empty), "root", a repository name, a glob such as
+	// "svc-*", or a comma-separated list of names and globs.
+	Target string
+	// Targets, when Target is empty or "all", limits the run to these
+	// repositories, in the given order.
+	Targets []string
+	Command []string
+
+	// Parallel runs the command in up to runtime.NumCPU() repositories at
+	// once. Every repository runs even if another fails; results keep the
+	// target order and the failures are reported together.
+	Parallel bool
+
+	// Env holds explicit KEY=VALUE overrides. They take precedence over the
+	// workspace env file, which in turn overrides the process environment.
+	Env []string
+
+	// Expand substitutes {{handle}}, {{repo}}, {{path}} and {{ref}} in the
+	// command arguments for each target before running it.
+	Expand bool
+
+	// Nice sets the scheduling niceness (1-19) of each command. It is applied
+	// on Linux and ignored elsewhere; see NiceSupported.
+	Nice int
+
+	// Retries re-runs the command in a target up to this many more times
+	// while it exits non-zero, waiting RetryDelay between attempts. Commands
+	// that cannot be started are not retried.
+	Retries    int
+	RetryDelay time.Duration
+
+	// ContinueOnError keeps running the command in the remaining repositories
+	// after it fails in one. Exec then reports failures only through the
+	// results' exit codes, leaving the caller to decide what counts as success.
+	ContinueOnError bool
+
+	// OnProgress, if set, is called before and after the command runs in each repository.
+	OnProgress func(ProgressEvent)
+}
+
+type ExecResult struct {
+	Repository string
+	Dir        string
+	ExitCode   int
+	Output     []byte
+	Duration   time.Duration
+
+	// Signal names the signal that killed the command, e.g. "SIGKILL" for a
+	// timeout or the OOM killer and "SIGSEGV" for a crash. ExitCode is then
+	// 128 plus the signal number, as a shell reports it.
+	Signal string
+
+	// Attempts lists every run of the command when ExecOptions.Retries is
+	// set; Output, ExitCode and Duration describe the last one.
+	Attempts []ExecAttempt
+}
+
+// ExecAttempt is one run of a command that was retried.
+type ExecAttempt struct {
+	ExitCode int
+	Duration time.Duration
+}
+
+func (s *FSStore) Exec(ctx context.Context, handle string, opts ExecOptions) ([]ExecResult, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ExecResult
+
+	if len(opts.Command) == 0 {
+		return nil, errors.New("command cannot be empty")
+	}
+
+	if opts.Nice < 0 || opts.Nice > 19 {
+		return nil, fmt.Errorf("nice must be between 0 and 19, got %d", opts.Nice)
+	}
+
+	if opts.Retries < 0 {
+		return nil, fmt.Errorf("retries must not be negative, got %d", opts.Retries)
+	}
+
+	if opts.Target == "" && len(ws.Repositories) == 0 {
+		opts.Target = "root"
+	}
+
+	env, err := s.execEnv(ws, opts.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	repos, root, err := execTargets(ws, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !root && opts.Parallel {
+		return s.execParallel(ctx, ws, repos, opts, env)
+	}
+
+	if !root {
+		for _, repo := range repos {
+			notifyProgress(opts.OnProgress, ProgressEvent{Type: EventRepoStart, Repository: repo.Name})
+			result, err := execWithRetries(ctx, opts, func() (ExecResult, error) {
+				return s.execInRepository(ctx, repo, ws.Path, execCommand(opts, ws, repo.Name, filepath.Join(ws.Path, repo.Name), repo.Ref), env, opts.Nice)
+			})
+			notifyProgress(opts.OnProgress, resultEvent(result))
+			results = append(results, result)
+			if opts.ContinueOnError {
+				continue
+			}
+			if result.Signal != "" {
+				return results, commandFailed(result)
+			}
+			if err != nil {
+				return results, err
+			}
+			if result.ExitCode != 0 {
+				return results, commandFailed(result)
+			}
+		}
+	} else {
+		notifyProgress(opts.OnProgress, ProgressEvent{Type: EventRepoStart, Repository: "root"})
+		result, _ := execWithRetries(ctx, opts, func() (ExecResult, error) {
+			result := ExecResult{
+				Repository: "root",
+				Dir:        ws.Path,
+			}
+			start := time.Now()
+			command := execCommand(opts, ws, "root", ws.Path, "")
+			cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+			cmd.Dir = ws.Path
+			cmd.Env = env
+			output, err := runCommand(cmd, opts.Nice)
+			result.Duration = time.Since(start)
+
+			result.Output = output
+			if err != nil {
+				if exitErr, ok := err.(*exec.ExitError); ok {
+					result.ExitCode, result.Signal = exitStatus(exitErr)
+				} else {
+					result.ExitCode = 1
+				}
+			}
+			return result, err
+		})
+		notifyProgress(opts.OnProgress, resultEvent(result))
+		results = append(results, result)
+		if result.Signal != "" {
+			return results, fmt.Errorf("command killed by %s", result.Signal)
+		}
+		if result.ExitCode != 0 {
+			return results, fmt.Errorf("command failed with exit code %d", result.ExitCode)
+		}
+	}
+
+	return results, nil
+}
+
+// execParallel runs the command in repos on a pool of runtime.NumCPU()
+// workers. Results are stored by index so they come back in repos order
+// however the runs interleave, and a failure in one repository neither stops
+// nor cancels the others.
+func (s *FSStore) execParallel(ctx context.Context, ws *Workspace, repos []Repository, opts ExecOptions, env []string) ([]ExecResult, error) {
+	var progressMu sync.Mutex
+	progress := func(event ProgressEvent) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		notifyProgress(opts.OnProgress, event)
+	}
+
+	results := make([]ExecResult, len(repos))
+	errs := make([]error, len(repos))
+	sem := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			progress(ProgressEvent{Type: EventRepoStart, Repository: repo.Name})
+			results[i], errs[i] = execWithRetries(ctx, opts, func() (ExecResult, error) {
+				return s.execInRepository(ctx, repo, ws.Path, execCommand(opts, ws, repo.Name, filepath.Join(ws.Path, repo.Name), repo.Ref), env, opts.Nice)
+			})
+			progress(resultEvent(results[i]))
+		}()
+	}
+	wg.Wait()
+
+	if opts.ContinueOnError {
+		return results, nil
+	}
+	var failures []error
+	for i, result := range results {
+		var exitErr *exec.ExitError
+		switch {
+		case errs[i] != nil && !errors.As(errs[i], &exitErr):
+			failures = append(failures, fmt.Errorf("%s: %w", result.Repository, errs[i]))
+		case result.ExitCode != 0:
+			failures = append(failures, commandFailed(result))
+		}
+	}
+	return results, errors.Join(failures...)
+}
+
+// ExecTarget is a directory Exec would run in and the command it would run
+// there, after --expand substitution.
+type ExecTarget struct {
+	Repository string
+	Dir        string
+	Command    []string
+}
+
+// PlanExec resolves where Exec would run opts.Command without running it.
+func (s *FSStore) PlanExec(ctx context.Context, handle string, opts ExecOptions) ([]ExecTarget, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Command) == 0 {
+		return nil, errors.New("command cannot be empty")
+	}
+	if opts.Target == "" && len(ws.Repositories) == 0 {
+		opts.Target = "root"
+	}
+
+	repos, root, err := execTargets(ws, opts)
+	if err != nil {
+		return nil, err
+	}
+	if root {
+		return []ExecTarget{{Repository: "root", Dir: ws.Path, Command: execCommand(opts, ws, "root", ws.Path, "")}}, nil
+	}
+
+	targets := make([]ExecTarget, len(repos))
+	for i, repo := range repos {
+		dir := filepath.Join(ws.Path, repo.Name)
+		targets[i] = ExecTarget{Repository: repo.Name, Dir: dir, Command: execCommand(opts, ws, repo.Name, dir, repo.Ref)}
+	}
+	return targets, nil
+}
+
+// execTargets resolves the repositories a command runs in, or root when it
+// runs in the workspace directory itself. opts.Target is "all" (or empty),
+// "root", a repository name, a glob such as "svc-*", or a comma-separated
+// list of names and globs. Matches keep the workspace's repository order.
+func execTargets(ws *Workspace, opts ExecOptions) ([]Repository, bool, error) {
+	switch opts.Target {
+	case "root":
+		return nil, true, nil
+	case "", "all":
+		if len(opts.Targets) == 0 {
+			return ws.Repositories, false, nil
+		}
+		repos := make([]Repository, 0, len(opts.Targets))
+		for _, name := range opts.Targets {
+			repo := ws.GetRepositoryByName(name)
+			if repo == nil {
+				return nil, false, fmt.Errorf("repository not found: %s", name)
+			}
+			repos = append(repos, *repo)
+		}
+		return repos, false, nil
+	}
+
+	selected := make(map[string]bool)
+	for _, pattern := range strings.Split(opts.Target, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if !strings.ContainsAny(pattern, "*?[") {
+			if ws.GetRepositoryByName(pattern) == nil {
+				return nil, false, repositoryNotFound(ws, pattern)
+			}
+			selected[pattern] = true
+			continue
+		}
+		matched := false
+		for _, repo := range ws.Repositories {
+			ok, err := path.Match(pattern, repo.Name)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid repository pattern %q: %w", pattern, err)
+			}
+			if ok {
+				selected[repo.Name] = true
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, false, fmt.Errorf("no repository matches %s", pattern)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, false, fmt.Errorf("no repositories selected by %q", opts.Target)
+	}
+
+	var repos []Repository
+	for _, repo := range ws.Repositories {
+		if selected[repo.Name] {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, false, nil
+}
+
+// execWithRetries calls run until it exits zero or opts.Retries extra attempts
+// are used up. Only non-zero exits are retried: a command that could not be
+// started fails the same way every time.
+func execWithRetries(ctx context.Context, opts ExecOptions, run func() (ExecResult, error)) (ExecResult, error) {
+	var attempts []ExecAttempt
+	for {
+		result, err := run()
+		if opts.Retries == 0 {
+			return result, err
+		}
+		attempts = append(attempts, ExecAttempt{ExitCode: result.ExitCode, Duration: result.Duration})
+		result.Attempts = attempts
+
+		var exitErr *exec.ExitError
+		if result.ExitCode == 0 || len(attempts) > opts.Retries || (err != nil && !errors.As(err, &exitErr)) {
+			return result, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(opts.RetryDelay):
+		}
+	}
+}
+
+// execCommand returns opts.Command with template variables filled in for one
+// target when opts.Expand is set, and unchanged otherwise.
+func execCommand(opts ExecOptions, ws *Workspace, repo, path, ref string) []string {
+	if !opts.Expand {
+		return opts.Command
+	}
+
+	replacer := strings.NewReplacer(
+		"{{handle}}", ws.Handle,
+		"{{repo}}", repo,
+		"{{path}}", path,
+		"{{ref}}", ref,
+	)
+	command := make([]string, len(opts.Command))
+	for i, arg := range opts.Command {
+		command[i] = replacer.Replace(arg)
+	}
+	return command
+}
+
+func resultEvent(result ExecResult) ProgressEvent {
+	return ProgressEvent{
+		Type:       EventRepoResult,
+		Repository: result.Repository,
+		ExitCode:   result.ExitCode,
+		Duration:   result.Duration,
+	}
+}
+
+func notifyProgress(onProgress func(ProgressEvent), event ProgressEvent) {
+	if onProgress != nil {
+		onProgress(event)
+	}
+}
+
+func (s *FSStore) execInRepository(ctx context.Context, repo Repository, wsPath string, cmdArgs []string, env []string, nice int) (ExecResult, error) {
+	if len(cmdArgs) == 0 {
+		return ExecResult{}, errors.New("command cannot be empty")
+	}
+
+	repoDir := filepath.Join(wsPath, repo.Name)
+	result := ExecResult{
+		Repository: repo.Name,
+		Dir:        repoDir,
+	}
+
+	start := time.Now()
+	cmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
+	cmd.Dir = repoDir
+	cmd.Env = env
+	output, err := runCommand(cmd, nice)
+	result.Duration = time.Since(start)
+
+	result.Output = output
+
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			result.ExitCode, result.Signal = exitStatus(exitErr)
 		} else {
 			result.ExitCode = 1
 		}
-		return result, err
+		return result, err
+	}
+
+	return result, nil
+}
+
+// commandFailed describes a non-zero result of a command in a repository.
+func commandFailed(result ExecResult) error {
+	if result.Signal != "" {
+		return fmt.Errorf("command in %s killed by %s", result.Repository, result.Signal)
+	}
+	return fmt.Errorf("command failed in %s with exit code %d", result.Repository, result.ExitCode)
+}
+
+// exitStatus returns the exit code of a failed command and, if a signal
+// killed it, the signal's name. ExitError.ExitCode is -1 for a killed
+// process, which would read as success wherever codes are compared with
+// max, so the shell's 128+n is used instead.
+func exitStatus(exitErr *exec.ExitError) (int, string) {
+	if name, number := exitSignal(exitErr); name != "" {
+		return 128 + number, name
+	}
+	return exitErr.ExitCode(), ""
+}
+
+// runCommand is cmd.CombinedOutput with the process reniced once started.
+func runCommand(cmd *exec.Cmd, nice int) ([]byte, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	setNice(cmd, nice)
+	err := cmd.Wait()
+	return output.Bytes(), err
+}
+
+// execEnv layers the process environment, the workspace env file and explicit
+// overrides, in increasing order of precedence.
+func (s *FSStore) execEnv(ws *Workspace, overrides []string) ([]string, error) {
+	fileEnv, err := readEnvFile(envFilePath(ws.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	env := os.Environ()
+	keys := make([]string, 0, len(fileEnv))
+	for key := range fileEnv {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+fileEnv[key])
+	}
+
+	return append(env, overrides...), nil
+}
+
+func envFilePath(wsPath string) string {
+	return filepath.Join(wsPath, ".workshed", envFileName)
+}
+
+// readEnvFile parses KEY=VALUE lines, skipping blank lines and # comments.
+func readEnvFile(path string) (map[string]string, error) {
+	vars := make(map[string]string)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return vars, nil
+		}
+		return nil, fmt.Errorf("reading env file: %w", err)
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("parsing env file line %d: expected KEY=VALUE", i+1)
+		}
+		vars[key] = strings.TrimSpace(value)
+	}
+
+	return vars, nil
+}
+
+func writeEnvFile(path string, vars map[string]string) error {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key + "=" + vars[key] + "\n")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating env file directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("writing env file: %w", err)
+	}
+	return nil
+}
+
+// WorkspaceEnv returns the variables from the workspace env file.
+func (s *FSStore) WorkspaceEnv(ctx context.Context, handle string) (map[string]string, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	return readEnvFile(envFilePath(ws.Path))
+}
+
+// SetWorkspaceEnv adds or replaces variables in the workspace env file.
+func (s *FSStore) SetWorkspaceEnv(ctx context.Context, handle string, vars map[string]string) error {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return err
+	}
+
+	path := envFilePath(ws.Path)
+	existing, err := readEnvFile(path)
+	if err != nil {
+		return err
+	}
+	for key, value := range vars {
+		if err := ValidateEnvVar(key, value); err != nil {
+			return err
+		}
+		existing[key] = value
 	}
+	return writeEnvFile(path, existing)
+}
 
-	return result, nil
+// ValidateEnvVar checks that key and value can be stored in the workspace
+// env file.
+func ValidateEnvVar(key, value string) error {
+	if key == "" || strings.ContainsAny(key, "= \t\n") {
+		return fmt.Errorf("invalid variable name: %q", key)
+	}
+	if strings.Contains(value, "\n") {
+		return fmt.Errorf("value for %s cannot contain newlines", key)
+	}
+	return nil
+}
+
+// UnsetWorkspaceEnv removes variables from the workspace env file.
+func (s *FSStore) UnsetWorkspaceEnv(ctx context.Context, handle string, keys []string) error {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return err
+	}
+
+	path := envFilePath(ws.Path)
+	existing, err := readEnvFile(path)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if _, ok := existing[key]; !ok {
+			return fmt.Errorf("variable not set: %s", key)
+		}
+		delete(existing, key)
+	}
+	return writeEnvFile(path, existing)
+}
+
+type FetchOptions struct {
+	Target string
+	Prune  bool
+	// Remote fetches only the named remote instead of all of them.
+	Remote string
+}
+
+type FetchResult struct {
+	Repository string
+	Summary    git.FetchSummary
+	Err        error
+}
+
+// FetchRepositories fetches refs for the workspace's repositories without touching working trees.
+// A failure in one repository is recorded in its result and does not stop the others.
+func (s *FSStore) FetchRepositories(ctx context.Context, handle string, opts FetchOptions) ([]FetchResult, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := ws.Repositories
+	if opts.Target != "" {
+		repo := ws.GetRepositoryByName(opts.Target)
+		if repo == nil {
+			return nil, fmt.Errorf("repository not found: %s", opts.Target)
+		}
+		repos = []Repository{*repo}
+	}
+
+	results := make([]FetchResult, 0, len(repos))
+	for _, repo := range repos {
+		repoDir := filepath.Join(ws.Path, repo.Name)
+		summary, err := s.git.Fetch(ctx, repoDir, git.FetchOptions{Prune: opts.Prune, Remote: opts.Remote})
+		results = append(results, FetchResult{
+			Repository: repo.Name,
+			Summary:    summary,
+			Err:        err,
+		})
+	}
+
+	return results, nil
+}
+
+// CloneResult reports the outcome of re-cloning one missing repository.
+type CloneResult struct {
+	Repository string
+	Err        error
+}
+
+// CloneMissingRepositories re-clones every repository whose directory is gone,
+// from its recorded URL, ref and depth. Present repositories are left alone, and
+// a failure in one repository is recorded in its result without stopping the others.
+func (s *FSStore) CloneMissingRepositories(ctx context.Context, handle string) ([]CloneResult, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []CloneResult{}
+	for _, repo := range ws.Repositories {
+		repoDir := filepath.Join(ws.Path, repo.Name)
+		if _, err := os.Stat(repoDir); !os.IsNotExist(err) {
+			continue
+		}
+
+		// Recorded URLs of local repositories are already absolute.
+		if _, err := s.cloneRepo(ctx, repo, ws.Path, "", nil); err != nil {
+			// Leave no partial clone behind so the repository still reads as missing.
+			_ = os.RemoveAll(repoDir)
+			results = append(results, CloneResult{Repository: repo.Name, Err: err})
+			continue
+		}
+		results = append(results, CloneResult{Repository: repo.Name})
+	}
+
+	return results, nil
+}
+
+// RepositoryStatus reports a repository's working tree state and how its
+// checked out branch compares with the branch's upstream.
+type RepositoryStatus struct {
+	Repository string
+	Dirty      bool
+	// HasUpstream is false when the branch tracks nothing or HEAD is detached;
+	// Ahead and Behind are then zero.
+	HasUpstream bool
+	Ahead       int
+	Behind      int
+	Err         error
+}
+
+// Summary describes the status in a few words, e.g. "clean, ahead 2".
+func (st RepositoryStatus) Summary() string {
+	if st.Err != nil {
+		return "unavailable"
+	}
+	parts := []string{"clean"}
+	if st.Dirty {
+		parts[0] = "dirty"
+	}
+	switch {
+	case !st.HasUpstream:
+		parts = append(parts, "no upstream")
+	case st.Ahead == 0 && st.Behind == 0:
+		parts = append(parts, "up to date")
+	default:
+		if st.Ahead > 0 {
+			parts = append(parts, fmt.Sprintf("ahead %d", st.Ahead))
+		}
+		if st.Behind > 0 {
+			parts = append(parts, fmt.Sprintf("behind %d", st.Behind))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RepositoryStatuses reports the status of every repository in the workspace.
+// Counts are against the last fetched upstream; nothing is fetched. A failure
+// in one repository is recorded in its result and does not stop the others.
+func (s *FSStore) RepositoryStatuses(ctx context.Context, handle string) ([]RepositoryStatus, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RepositoryStatus, 0, len(ws.Repositories))
+	for _, repo := range ws.Repositories {
+		results = append(results, s.repositoryStatus(ctx, ws, repo))
+	}
+
+	return results, nil
+}
+
+func (s *FSStore) repositoryStatus(ctx context.Context, ws *Workspace, repo Repository) RepositoryStatus {
+	repoDir := filepath.Join(ws.Path, repo.Name)
+	status := RepositoryStatus{Repository: repo.Name}
+
+	// A repository cloned without checkout has no working tree to be dirty.
+	if !repo.NoCheckout {
+		porcelain, err := s.git.StatusPorcelain(ctx, repoDir)
+		if err != nil {
+			status.Err = err
+			return status
+		}
+		status.Dirty = strings.TrimSpace(porcelain) != ""
+	}
+
+	var err error
+	status.Ahead, status.Behind, err = s.git.AheadBehind(ctx, repoDir)
+	switch {
+	case errors.Is(err, git.ErrNoUpstream):
+	case err != nil:
+		status.Err = err
+	default:
+		status.HasUpstream = true
+	}
+	return status
+}
+
+// RepositoryDetail is one repository's configuration together with its live
+// git state.
+type RepositoryDetail struct {
+	Repository Repository
+	Path       string
+	// State is the checked-out branch and commit, nil when it could not be read.
+	State    *GitRef
+	StateErr error
+	Status   RepositoryStatus
+}
+
+// repositoryNotFound is the error for a repository name ws does not have. It
+// lists the names it does have, so a typo can be fixed from the message.
+func repositoryNotFound(ws *Workspace, name string) error {
+	names := make([]string, len(ws.Repositories))
+	for i, r := range ws.Repositories {
+		names[i] = r.Name
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("repository not found: %s (workspace has no repositories)", name)
+	}
+	return fmt.Errorf("repository not found: %s (available: %s)", name, strings.Join(names, ", "))
+}
+
+// InspectRepository reports a single repository of a workspace. An unknown
+// name is an error listing the repositories the workspace has.
+func (s *FSStore) InspectRepository(ctx context.Context, handle, repoName string) (*RepositoryDetail, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := ws.GetRepositoryByName(repoName)
+	if repo == nil {
+		return nil, repositoryNotFound(ws, repoName)
+	}
+
+	detail := &RepositoryDetail{
+		Repository: *repo,
+		Path:       filepath.Join(ws.Path, repo.Name),
+		Status:     s.repositoryStatus(ctx, ws, *repo),
+	}
+	detail.State, detail.StateErr = s.gitState(ctx, detail.Path)
+	if detail.State != nil && repo.NoCheckout {
+		detail.State.Dirty = false
+		detail.State.Status = ""
+	}
+	return detail, nil
+}
+
+// Compare reports how the workspaces with handles left and right differ: a
+// changed purpose, repositories only one of them has, and repositories both
+// have at a different ref or checked-out commit. Commits are read from the
+// working trees and skipped when unreadable. It fails with the lookup error
+// if either workspace does not exist.
+func (s *FSStore) Compare(ctx context.Context, left, right string) (*WorkspaceDiff, error) {
+	leftWs, err := s.Get(ctx, left)
+	if err != nil {
+		return nil, err
+	}
+	rightWs, err := s.Get(ctx, right)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &WorkspaceDiff{Left: leftWs.Handle, Right: rightWs.Handle}
+	if leftWs.Purpose != rightWs.Purpose {
+		diff.LeftPurpose = leftWs.Purpose
+		diff.RightPurpose = rightWs.Purpose
+	}
+
+	for _, repo := range leftWs.Repositories {
+		other := rightWs.GetRepositoryByName(repo.Name)
+		if other == nil {
+			diff.OnlyInLeft = append(diff.OnlyInLeft, repo.Name)
+			continue
+		}
+
+		repoDiff := RepoDiff{
+			Repository: repo.Name,
+			LeftRef:    repo.Ref,
+			RightRef:   other.Ref,
+		}
+		// Live state is best-effort; a repository without readable git state is compared by ref only.
+		if state, err := s.gitState(ctx, filepath.Join(leftWs.Path, repo.Name)); err == nil {
+			repoDiff.LeftCommit = state.Commit
+		}
+		if state, err := s.gitState(ctx, filepath.Join(rightWs.Path, other.Name)); err == nil {
+			repoDiff.RightCommit = state.Commit
+		}
+		if repoDiff.LeftRef != repoDiff.RightRef || repoDiff.LeftCommit != repoDiff.RightCommit {
+			diff.Changed = append(diff.Changed, repoDiff)
+		}
+	}
+
+	for _, repo := range rightWs.Repositories {
+		if leftWs.GetRepositoryByName(repo.Name) == nil {
+			diff.OnlyInRight = append(diff.OnlyInRight, repo.Name)
+		}
+	}
+
+	return diff, nil
 }
 
 func (s *FSStore) GetRepositoryPath(ctx context.Context, handle, repoName string) (string, error) {
@@ -567,15 +1923,46 @@ func isLocalPath(path string) bool {
 }
 
 func resolveLocalPath(url, invocationCWD string) (string, error) {
-	expandedPath, err := expandPath(url, invocationCWD)
+	absPath, err := expandPath(url, invocationCWD)
 	if err != nil {
 		return "", err
 	}
-	absPath, err := filepath.Abs(expandedPath)
-	if err != nil {
-		return "", err
+	return withBareSuffix(absPath), nil
+}
+
+// repoKey identifies a repository source for duplicate checks. Local paths
+// resolve to their absolute form, so ./repo, repo/ and ../dir/repo match
+// when they name the same directory.
+func repoKey(url, invocationCWD string) string {
+	if isLocalPath(url) {
+		if absPath, err := resolveLocalPath(url, invocationCWD); err == nil {
+			return absPath
+		}
 	}
-	return absPath, nil
+	return url
+}
+
+// isBareRepository reports whether dir is itself a git directory, as left by
+// git init --bare or git clone --mirror.
+func isBareRepository(dir string) bool {
+	if info, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil || info.IsDir() {
+		return false
+	}
+	for _, sub := range []string{"objects", "refs"} {
+		if info, err := os.Stat(filepath.Join(dir, sub)); err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// withBareSuffix restores the .git suffix ParseRepoFlag strips when only the
+// suffixed path exists and is a bare repository, e.g. ./mirrors/api.git.
+func withBareSuffix(path string) string {
+	if _, err := os.Stat(path); os.IsNotExist(err) && isBareRepository(path+".git") {
+		return path + ".git"
+	}
+	return path
 }
 
 func validateLocalRepository(path, invocationCWD string) error {
@@ -588,7 +1975,7 @@ func validateLocalRepository(path, invocationCWD string) error {
 		return fmt.Errorf("expanding path: %w", err)
 	}
 
-	cleanedPath := filepath.Clean(expandedPath)
+	cleanedPath := withBareSuffix(expandedPath)
 
 	info, err := os.Stat(cleanedPath)
 	if err != nil {
@@ -605,6 +1992,9 @@ func validateLocalRepository(path, invocationCWD string) error {
 	gitDir := filepath.Join(cleanedPath, ".git")
 	if _, err := os.Stat(gitDir); err != nil {
 		if os.IsNotExist(err) {
+			if isBareRepository(cleanedPath) {
+				return nil
+			}
 			return fmt.Errorf("not a git repository (missing .git directory): %s", path)
 		}
 		return fmt.Errorf("checking .git directory: %w", err)
@@ -666,29 +2056,62 @@ func validateTemplatePath(path string) error {
 }
 
 func validateRepositories(repos []RepositoryOption, invocationCWD string) error {
-	seenURLs := make(map[string]bool)
-	seenNames := make(map[string]bool)
+	seenURLs := make(map[string]string)
+	seenNames := make(map[string]string)
 
 	for _, repo := range repos {
 		if err := validateRepoURL(repo.URL, invocationCWD); err != nil {
 			return fmt.Errorf("invalid repository URL %s: %w", repo.URL, err)
 		}
 
-		if seenURLs[repo.URL] {
-			return fmt.Errorf("duplicate repository URL: %s", repo.URL)
+		for name, url := range repo.Remotes {
+			if err := ValidateRemote(name, url); err != nil {
+				return fmt.Errorf("repository %s: %w", repo.URL, err)
+			}
+		}
+
+		if repo.CopyWorkingTree {
+			if !isLocalPath(repo.URL) {
+				return fmt.Errorf("cannot copy working tree of %s: not a local repository", repo.URL)
+			}
+			if repo.Ref != "" {
+				return fmt.Errorf("cannot copy working tree of %s at @%s: the copy keeps the current checkout", repo.URL, repo.Ref)
+			}
+			if repo.NoCheckout {
+				return fmt.Errorf("cannot copy working tree of %s without checking it out", repo.URL)
+			}
+		}
+
+		key := repoKey(repo.URL, invocationCWD)
+		if prev, ok := seenURLs[key]; ok {
+			if prev == repo.URL {
+				return fmt.Errorf("duplicate repository URL: %s", repo.URL)
+			}
+			return fmt.Errorf("duplicate repository: %s and %s are the same path", prev, repo.URL)
 		}
-		seenURLs[repo.URL] = true
+		seenURLs[key] = repo.URL
 
 		name := extractRepoName(repo.URL, invocationCWD)
-		if seenNames[name] {
-			return fmt.Errorf("duplicate repository name: %s", name)
+		folded := strings.ToLower(name)
+		if prev, ok := seenNames[folded]; ok {
+			return nameCollision("duplicate repository name", prev, name)
 		}
-		seenNames[name] = true
+		seenNames[folded] = name
 	}
 
 	return nil
 }
 
+// nameCollision reports two repositories that would share a directory.
+// Names are compared case-insensitively because on macOS and Windows Repo
+// and repo are the same directory, and one clone would overwrite the other.
+func nameCollision(msg, prev, name string) error {
+	if prev == name {
+		return fmt.Errorf("%s: %s", msg, name)
+	}
+	return fmt.Errorf("%s: %s conflicts with %s on case-insensitive filesystems", msg, name, prev)
+}
+
 func (s *FSStore) writeMetadataToDir(ws *Workspace, dir string) error {
 	metaPath := filepath.Join(dir, metadataFileName)
 
@@ -747,7 +2170,11 @@ func sshAgentAvailable() bool {
 	return false
 }
 
-func (s *FSStore) cloneRepo(ctx context.Context, repo Repository, wsDir, invocationCWD string) (string, error) {
+func (s *FSStore) cloneRepo(ctx context.Context, repo Repository, wsDir, invocationCWD string, onProgress func(git.CloneProgress)) (string, error) {
+	if repo.Source == RepositorySourceWorkingTree {
+		return s.copyWorkingTree(ctx, repo, wsDir, invocationCWD)
+	}
+
 	url := selectGitProtocol(repo.URL)
 	ref := repo.Ref
 
@@ -758,74 +2185,257 @@ func (s *FSStore) cloneRepo(ctx context.Context, repo Repository, wsDir, invocat
 			return "", fmt.Errorf("resolving local path: %w", err)
 		}
 
-		// Auto-detect current branch for local repos when no ref specified
-		if ref == "" {
-			branch, err := s.git.CurrentBranch(ctx, absPath)
+		// Auto-detect current branch for local repos when no ref specified
+		if ref == "" {
+			branch, err := s.git.CurrentBranch(ctx, absPath)
+			if err != nil {
+				return "", fmt.Errorf("detecting current branch: %w", err)
+			}
+			ref = branch
+		}
+
+		url = absPath
+	}
+
+	// Detect default branch for remote repos when no ref specified
+	if ref == "" && !isLocalPath(url) {
+		defaultBranch, err := s.git.DefaultBranch(ctx, url)
+		if err != nil {
+			return "", fmt.Errorf("detecting default branch: %w", err)
+		}
+		if defaultBranch == "" {
+			return "", fmt.Errorf("could not detect default branch for %s; specify @branch explicitly (e.g., github.com/org/repo@master)", url)
+		}
+		ref = defaultBranch
+	}
+
+	// For local repos with no ref and no current branch detected, require explicit ref
+	if ref == "" {
+		return "", fmt.Errorf("could not detect current branch for local repo %s; specify @branch explicitly", url)
+	}
+
+	repoDir := filepath.Join(wsDir, repo.Name)
+
+	// A sparse clone skips the initial checkout so only the requested
+	// directories are ever written to the working tree.
+	noCheckout := repo.NoCheckout || len(repo.Sparse) > 0
+	if err := s.git.Clone(ctx, url, repoDir, git.CloneOptions{Depth: repo.Depth, NoCheckout: noCheckout, Progress: onProgress}); err != nil {
+		return "", err
+	}
+
+	if err := s.addRemotes(ctx, repo, repoDir, invocationCWD); err != nil {
+		return "", err
+	}
+
+	if len(repo.Sparse) > 0 {
+		if err := s.git.SparseCheckout(ctx, repoDir, repo.Sparse); err != nil {
+			return "", err
+		}
+	}
+
+	if repo.NoCheckout {
+		return ref, nil
+	}
+
+	if err := s.git.Checkout(ctx, repoDir, ref); err != nil {
+		return "", err
+	}
+
+	if repo.LFS {
+		if err := s.git.LFSPull(ctx, repoDir); err != nil {
+			return "", err
+		}
+	}
+
+	return ref, nil
+}
+
+// addRemotes configures repo's additional remotes in name order, resolving
+// local paths the same way as the clone URL.
+func (s *FSStore) addRemotes(ctx context.Context, repo Repository, repoDir, invocationCWD string) error {
+	names := make([]string, 0, len(repo.Remotes))
+	for name := range repo.Remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := ValidateRemote(name, repo.Remotes[name]); err != nil {
+			return err
+		}
+		url := selectGitProtocol(repo.Remotes[name])
+		if isLocalPath(url) {
+			absPath, err := resolveLocalPath(url, invocationCWD)
+			if err != nil {
+				return fmt.Errorf("resolving remote %s: %w", name, err)
+			}
+			url = absPath
+		}
+		if err := s.git.RemoteAdd(ctx, repoDir, name, url); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FSStore) cloneRepositories(ctx context.Context, repos []Repository, wsDir, invocationCWD string, onProgress func(ProgressEvent), concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var progressMu sync.Mutex
+	progress := func(event ProgressEvent) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		notifyProgress(onProgress, event)
+	}
+
+	var (
+		errMu    sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range repos {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			progress(ProgressEvent{Type: EventCloneStart, Repository: repos[i].Name})
+			var onCloneProgress func(git.CloneProgress)
+			if onProgress != nil {
+				onCloneProgress = func(p git.CloneProgress) {
+					progress(ProgressEvent{Type: EventCloneProgress, Repository: repos[i].Name, Stage: p.Stage, Percent: p.Percent})
+				}
+			}
+			start := time.Now()
+			detectedRef, err := s.cloneRepo(ctx, repos[i], wsDir, invocationCWD, onCloneProgress)
+			progress(ProgressEvent{Type: EventCloneDone, Repository: repos[i].Name, Duration: time.Since(start), Err: err})
 			if err != nil {
-				return "", fmt.Errorf("detecting current branch: %w", err)
+				// Only the failure that triggered cancellation is reported;
+				// clones aborted because of it would just add noise.
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to clone %s: %w", repos[i].Name, err)
+					cancel()
+				}
+				errMu.Unlock()
+				return
 			}
-			ref = branch
-		}
+			if detectedRef != "" && repos[i].Ref == "" {
+				repos[i].Ref = detectedRef
+			}
+		}(i)
+	}
+	wg.Wait()
 
-		url = absPath
+	if firstErr == nil && ctx.Err() != nil {
+		return ctx.Err()
 	}
+	return firstErr
+}
 
-	// Detect default branch for remote repos when no ref specified
-	if ref == "" && !isLocalPath(url) {
-		defaultBranch, err := s.git.DefaultBranch(ctx, url)
-		if err != nil {
-			return "", fmt.Errorf("detecting default branch: %w", err)
-		}
-		if defaultBranch == "" {
-			return "", fmt.Errorf("could not detect default branch for %s; specify @branch explicitly (e.g., github.com/org/repo@master)", url)
-		}
-		ref = defaultBranch
+// copyWorkingTree copies a local repository as it currently sits on disk: its
+// .git directory plus every tracked and untracked file, so uncommitted work
+// comes along. Gitignored files are skipped unless repo.IncludeIgnored is set.
+// It returns the source's current branch as the ref.
+func (s *FSStore) copyWorkingTree(ctx context.Context, repo Repository, wsDir, invocationCWD string) (string, error) {
+	src, err := resolveLocalPath(repo.URL, invocationCWD)
+	if err != nil {
+		return "", fmt.Errorf("resolving local path: %w", err)
 	}
 
-	// For local repos with no ref and no current branch detected, require explicit ref
-	if ref == "" {
-		return "", fmt.Errorf("could not detect current branch for local repo %s; specify @branch explicitly", url)
+	ref, err := s.git.CurrentBranch(ctx, src)
+	if err != nil {
+		return "", fmt.Errorf("detecting current branch: %w", err)
 	}
 
-	repoDir := filepath.Join(wsDir, repo.Name)
+	dst := filepath.Join(wsDir, repo.Name)
+
+	if repo.IncludeIgnored {
+		return ref, copyTree(src, dst)
+	}
 
-	if err := s.git.Clone(ctx, url, repoDir, git.CloneOptions{Depth: repo.Depth}); err != nil {
+	if err := copyTree(filepath.Join(src, ".git"), filepath.Join(dst, ".git")); err != nil {
 		return "", err
 	}
 
-	if err := s.git.Checkout(ctx, repoDir, ref); err != nil {
+	files, err := s.git.ListFiles(ctx, src)
+	if err != nil {
 		return "", err
 	}
+	for _, rel := range files {
+		info, err := os.Lstat(filepath.Join(src, rel))
+		if os.IsNotExist(err) {
+			// Tracked but deleted in the working tree; the copy keeps the deletion.
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("reading %s: %w", rel, err)
+		}
+		if err := copyEntry(filepath.Join(src, rel), filepath.Join(dst, rel), info); err != nil {
+			return "", err
+		}
+	}
 
 	return ref, nil
 }
 
-func (s *FSStore) cloneRepositories(ctx context.Context, repos []Repository, wsDir, invocationCWD string) error {
-	for i := range repos {
-		detectedRef, err := s.cloneRepo(ctx, repos[i], wsDir, invocationCWD)
+// copyTree recursively copies src to dst, preserving file modes and symlinks.
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return fmt.Errorf("calculating relative path: %w", err)
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		return copyEntry(path, target, info)
+	})
+}
+
+func copyEntry(src, dst string, info os.FileInfo) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(src)
 		if err != nil {
-			return fmt.Errorf("failed to clone %s: %w", repos[i].Name, err)
+			return fmt.Errorf("reading symlink: %w", err)
 		}
-		if detectedRef != "" && repos[i].Ref == "" {
-			repos[i].Ref = detectedRef
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return fmt.Errorf("creating destination directory: %w", err)
 		}
+		return os.Symlink(link, dst)
 	}
-	return nil
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	return copyFile(src, dst, info.Mode().Perm())
 }
 
 func extractRepoName(url, invocationCWD string) string {
 	url = strings.TrimSuffix(url, ".git")
 
 	if isLocalPath(url) {
-		expandedPath, err := expandPath(url, invocationCWD)
+		absPath, err := expandPath(url, invocationCWD)
 		if err != nil {
 			return filepath.Base(url)
 		}
-		absPath, err := filepath.Abs(expandedPath)
-		if err == nil {
-			return filepath.Base(absPath)
-		}
-		return filepath.Base(expandedPath)
+		return filepath.Base(absPath)
 	}
 
 	if idx := strings.LastIndex(url, "/"); idx != -1 {
@@ -864,6 +2474,9 @@ func (s *FSStore) applyTemplate(ctx context.Context, templatePath string, vars m
 		substitutedPath := substituteVars(relPath, vars)
 
 		dstPath := filepath.Join(wsDir, substitutedPath)
+		if !withinDir(wsDir, dstPath) {
+			return fmt.Errorf("template path %q resolves to %q, outside the workspace", relPath, substitutedPath)
+		}
 
 		if info.IsDir() {
 			return os.MkdirAll(dstPath, info.Mode())
@@ -873,19 +2486,57 @@ func (s *FSStore) applyTemplate(ctx context.Context, templatePath string, vars m
 	})
 }
 
+// expandPath turns a local repository path into a clean absolute path. It
+// expands $VAR and ${VAR}, failing on an unset variable rather than dropping
+// it, then a leading ~ or ~user, and resolves what is still relative against
+// invocationCWD (the process directory when empty).
+// Every place that reads a local repository path goes through it, so a path
+// names the same directory whichever form it is written in.
 func expandPath(path, invocationCWD string) (string, error) {
+	if strings.Contains(path, "$") {
+		var unset string
+		path = os.Expand(path, func(name string) string {
+			value, ok := os.LookupEnv(name)
+			if !ok && unset == "" {
+				unset = name
+			}
+			return value
+		})
+		if unset != "" {
+			return "", fmt.Errorf("environment variable %s is not set", unset)
+		}
+	}
+
 	if strings.HasPrefix(path, "~") {
-		homeDir, err := os.UserHomeDir()
+		name, rest, _ := strings.Cut(filepath.ToSlash(path[1:]), "/")
+		homeDir, err := homeDirOf(name)
 		if err != nil {
-			return "", fmt.Errorf("getting user home directory: %w", err)
+			return "", err
 		}
-		return filepath.Join(homeDir, path[1:]), nil
+		path = filepath.Join(homeDir, filepath.FromSlash(rest))
 	}
 
 	if !filepath.IsAbs(path) {
-		return filepath.Abs(filepath.Join(invocationCWD, path))
+		path = filepath.Join(invocationCWD, path)
+	}
+	return filepath.Abs(path)
+}
+
+// homeDirOf returns the home directory of the named user, or of the current
+// user when name is empty.
+func homeDirOf(name string) (string, error) {
+	if name == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("getting user home directory: %w", err)
+		}
+		return homeDir, nil
 	}
-	return path, nil
+	u, err := user.Lookup(name)
+	if err != nil {
+		return "", fmt.Errorf("resolving ~%s: %w", name, err)
+	}
+	return u.HomeDir, nil
 }
 
 func substituteVars(path string, vars map[string]string) string {
@@ -896,6 +2547,15 @@ func substituteVars(path string, vars map[string]string) string {
 	return result
 }
 
+// withinDir reports whether path, once cleaned, is dir or lies beneath it.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 func copyFile(src, dst string, mode os.FileMode) error {
 	srcFile, err := os.Open(src)
 	if err != nil {
@@ -969,7 +2629,7 @@ func (s *FSStore) RecordExecution(ctx context.Context, handle string, record Exe
 	}
 
 	record.Handle = handle
-	record.Timestamp = time.Now()
+	record.Timestamp = s.clock.Now()
 	record.StartedAt = record.Timestamp
 
 	recordPath := filepath.Join(execDir, "record.json")
@@ -981,9 +2641,113 @@ func (s *FSStore) RecordExecution(ctx context.Context, handle string, record Exe
 		return fmt.Errorf("writing execution record: %w", err)
 	}
 
+	if _, err := s.pruneExecutions(ctx, ws, s.retentionFor(ws)); err != nil {
+		return fmt.Errorf("pruning executions: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FSStore) PruneExecutions(ctx context.Context, handle string, policy *RetentionPolicy) ([]string, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	p := s.retentionFor(ws)
+	if policy != nil {
+		p = *policy
+	}
+	return s.pruneExecutions(ctx, ws, p)
+}
+
+// PrunableExecutions returns the IDs of the records PruneExecutions would
+// delete for policy, without deleting them.
+func (s *FSStore) PrunableExecutions(ctx context.Context, handle string, policy *RetentionPolicy) ([]string, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	p := s.retentionFor(ws)
+	if policy != nil {
+		p = *policy
+	}
+	return s.prunableExecutions(ctx, ws, p)
+}
+
+// SetWorkspaceRetention stores policy in the workspace metadata.
+func (s *FSStore) SetWorkspaceRetention(ctx context.Context, handle string, policy *RetentionPolicy) error {
+	if policy != nil && (policy.MaxExecutions < 0 || policy.MaxAge < 0) {
+		return errors.New("retention limits cannot be negative")
+	}
+
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return err
+	}
+
+	ws.Retention = policy
+
+	if err := s.writeMetadataToDir(ws, ws.Path); err != nil {
+		return fmt.Errorf("updating retention: %w", err)
+	}
+
 	return nil
 }
 
+// retentionFor returns the workspace's own retention policy, or the store's
+// when it has none.
+func (s *FSStore) retentionFor(ws *Workspace) RetentionPolicy {
+	if ws.Retention != nil {
+		return *ws.Retention
+	}
+	return s.retention
+}
+
+// prunableExecutions returns the IDs of the executions outside the policy.
+func (s *FSStore) prunableExecutions(ctx context.Context, ws *Workspace, policy RetentionPolicy) ([]string, error) {
+	if policy.MaxExecutions <= 0 && policy.MaxAge <= 0 {
+		return nil, nil
+	}
+
+	// Newest first, so the records past MaxExecutions are the oldest ones.
+	records, err := s.ListExecutions(ctx, ws.Handle, ListExecutionsOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := s.clock.Now().Add(-policy.MaxAge)
+
+	var ids []string
+	for i, record := range records {
+		overLimit := policy.MaxExecutions > 0 && i >= policy.MaxExecutions
+		expired := policy.MaxAge > 0 && record.Timestamp.Before(cutoff)
+		if overLimit || expired {
+			ids = append(ids, record.ID)
+		}
+	}
+	return ids, nil
+}
+
+// pruneExecutions removes the directories (record and stored output) of executions outside the policy.
+func (s *FSStore) pruneExecutions(ctx context.Context, ws *Workspace, policy RetentionPolicy) ([]string, error) {
+	ids, err := s.prunableExecutions(ctx, ws, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	executionsDir := filepath.Join(ws.Path, ".workshed", executionsDirName)
+
+	var removed []string
+	for _, id := range ids {
+		if err := os.RemoveAll(filepath.Join(executionsDir, id)); err != nil {
+			return removed, fmt.Errorf("removing execution %s: %w", id, err)
+		}
+		removed = append(removed, id)
+	}
+
+	return removed, nil
+}
+
 func (s *FSStore) GetExecution(ctx context.Context, handle, execID string) (*ExecutionRecord, error) {
 	ws, err := s.Get(ctx, handle)
 	if err != nil {
@@ -1054,14 +2818,106 @@ func (s *FSStore) ListExecutions(ctx context.Context, handle string, opts ListEx
 	return records, nil
 }
 
+// executedSince reports whether the workspace's latest execution finished at
+// or after since. Records without a completion time count from their start.
+func (s *FSStore) executedSince(ctx context.Context, handle string, since time.Time) (bool, error) {
+	executions, err := s.ListExecutions(ctx, handle, ListExecutionsOptions{Limit: 1})
+	if err != nil {
+		return false, fmt.Errorf("reading latest execution of %s: %w", handle, err)
+	}
+	if len(executions) == 0 {
+		return false, nil
+	}
+	finished := executions[0].CompletedAt
+	if finished.IsZero() {
+		finished = executions[0].Timestamp
+	}
+	return !finished.Before(since), nil
+}
+
+// AutoIntentWindow is how recently an execution must have finished for
+// CaptureOptions.AutoIntent to describe a capture by it.
+const AutoIntentWindow = 15 * time.Minute
+
+// autoIntent describes a capture by the workspace's latest execution, or
+// returns "" when there is none within AutoIntentWindow of now.
+func (s *FSStore) autoIntent(ctx context.Context, handle string, now time.Time) (string, error) {
+	executions, err := s.ListExecutions(ctx, handle, ListExecutionsOptions{Limit: 1})
+	if err != nil {
+		return "", fmt.Errorf("reading latest execution: %w", err)
+	}
+	if len(executions) == 0 {
+		return "", nil
+	}
+	latest := executions[0]
+	finished := latest.CompletedAt
+	if finished.IsZero() {
+		finished = latest.Timestamp
+	}
+	if now.Sub(finished) > AutoIntentWindow {
+		return "", nil
+	}
+	return "after running: " + strings.Join(latest.Command, " "), nil
+}
+
+// PlanCapture validates opts the way CaptureState does and returns them with
+// the --auto-intent description filled in, without recording anything.
+func (s *FSStore) PlanCapture(ctx context.Context, handle string, opts CaptureOptions) (CaptureOptions, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return opts, err
+	}
+	return s.prepareCapture(ctx, ws, opts)
+}
+
+// prepareCapture fills in the auto-intent description and checks that opts
+// has an intent, a free name when UniqueName is set, and existing linked
+// captures.
+func (s *FSStore) prepareCapture(ctx context.Context, ws *Workspace, opts CaptureOptions) (CaptureOptions, error) {
+	if opts.AutoIntent && opts.Description == "" {
+		description, err := s.autoIntent(ctx, ws.Handle, s.clock.Now())
+		if err != nil {
+			return opts, err
+		}
+		opts.Description = description
+	}
+
+	if opts.Kind == "" && opts.Description == "" && len(opts.Tags) == 0 {
+		return opts, fmt.Errorf("capture must have intent: provide --kind, --description, or --tag")
+	}
+
+	if opts.UniqueName && opts.Name != "" {
+		captures, err := s.ListCaptures(ctx, ws.Handle)
+		if err != nil {
+			return opts, err
+		}
+		for _, c := range captures {
+			if c.Name == opts.Name {
+				return opts, fmt.Errorf("capture name already exists: %s (%s)", opts.Name, c.ID)
+			}
+		}
+	}
+
+	for _, linked := range []string{opts.Parent, opts.BackupOf} {
+		if linked == "" {
+			continue
+		}
+		if _, err := readCapture(ws.Path, linked); err != nil {
+			return opts, fmt.Errorf("linked capture: %w", err)
+		}
+	}
+	return opts, nil
+}
+
 func (s *FSStore) CaptureState(ctx context.Context, handle string, opts CaptureOptions) (*Capture, error) {
 	ws, err := s.Get(ctx, handle)
 	if err != nil {
 		return nil, err
 	}
 
-	if opts.Kind == "" && opts.Description == "" && len(opts.Tags) == 0 {
-		return nil, fmt.Errorf("capture must have intent: provide --kind, --description, or --tag")
+	opts, err = s.prepareCapture(ctx, ws, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	workshedDir := filepath.Join(ws.Path, ".workshed")
@@ -1071,7 +2927,7 @@ func (s *FSStore) CaptureState(ctx context.Context, handle string, opts CaptureO
 		return nil, fmt.Errorf("creating captures directory: %w", err)
 	}
 
-	id := ulid.Make()
+	id := s.newID()
 	captureDir := filepath.Join(capturesDir, id.String())
 
 	success := false
@@ -1092,12 +2948,15 @@ func (s *FSStore) CaptureState(ctx context.Context, handle string, opts CaptureO
 	}
 
 	capture := &Capture{
-		ID:        id.String(),
-		Timestamp: time.Now(),
-		Handle:    handle,
-		Name:      opts.Name,
-		Kind:      opts.Kind,
-		GitState:  make([]GitRef, 0, len(ws.Repositories)),
+		ID:            id.String(),
+		Timestamp:     s.clock.Now(),
+		Handle:        handle,
+		SourcePurpose: ws.Purpose,
+		Name:          opts.Name,
+		Kind:          opts.Kind,
+		Parent:        opts.Parent,
+		BackupOf:      opts.BackupOf,
+		GitState:      make([]GitRef, 0, len(ws.Repositories)),
 		Metadata: CaptureMetadata{
 			Description: opts.Description,
 			Tags:        opts.Tags,
@@ -1115,16 +2974,12 @@ func (s *FSStore) CaptureState(ctx context.Context, handle string, opts CaptureO
 		capture.GitState = append(capture.GitState, *ref)
 	}
 
-	capturePath := filepath.Join(captureDir, "capture.json")
-	data, err := json.MarshalIndent(capture, "", "  ")
-	if err != nil {
-		return nil, fmt.Errorf("marshaling capture: %w", err)
-	}
-	if err := fs.WriteJson(capturePath, data); err != nil {
-		return nil, fmt.Errorf("writing capture: %w", err)
+	if err := writeCaptureFile(captureDir, capture, s.compressCaptures); err != nil {
+		return nil, err
 	}
 
 	success = true
+	s.emit(ctx, StoreEvent{Type: EventCaptureCreated, Handle: handle, Purpose: ws.Purpose, Path: ws.Path, CaptureID: capture.ID, Capture: capture.Name})
 	return capture, nil
 }
 
@@ -1164,24 +3019,156 @@ func (s *FSStore) ApplyCapture(ctx context.Context, handle string, captureID str
 		return err
 	}
 
-	ws, err := s.Get(ctx, handle)
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return err
+	}
+
+	progress := &ApplyProgress{CaptureID: capture.ID, Applied: []string{}}
+	return s.applyRefs(ctx, ws, capture, capture.GitState, progress)
+}
+
+// ContinueApply resumes a partially applied capture. Repositories already at the
+// captured commit are left alone; the rest are preflighted and checked out.
+func (s *FSStore) ContinueApply(ctx context.Context, handle string, captureID string) ([]string, error) {
+	capture, err := s.GetCapture(ctx, handle, captureID)
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	var remaining []GitRef
+	for _, ref := range capture.GitState {
+		head, err := s.git.RevParse(ctx, filepath.Join(ws.Path, ref.Repository), "HEAD")
+		if err != nil || head != ref.Commit {
+			remaining = append(remaining, ref)
+		}
+	}
+
+	if len(remaining) == 0 {
+		if err := s.clearApplyProgress(ws, capture.ID); err != nil {
+			return nil, err
+		}
+		return []string{}, nil
+	}
+
+	result := s.preflightRefs(ctx, ws, remaining)
+	if !result.Valid {
+		return nil, fmt.Errorf("apply blocked by preflight errors")
+	}
+
+	progress, err := s.readApplyProgress(ws, capture.ID)
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil {
+		progress = &ApplyProgress{CaptureID: capture.ID, Applied: []string{}}
+	}
+
+	if err := s.applyRefs(ctx, ws, capture, remaining, progress); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(remaining))
+	for i, ref := range remaining {
+		names[i] = ref.Repository
+	}
+	return names, nil
+}
+
+// applyRefs checks out each ref in order, recording progress so an interrupted
+// apply can be resumed with ContinueApply. The repositories it moved, and
+// whether it finished, go into the workspace history.
+func (s *FSStore) applyRefs(ctx context.Context, ws *Workspace, capture *Capture, refs []GitRef, progress *ApplyProgress) error {
+	entry := HistoryEntry{Operation: HistoryApply, CaptureID: capture.ID, CaptureName: capture.Name, Changes: []RefChange{}}
+	for _, ref := range refs {
+		repoDir := filepath.Join(ws.Path, ref.Repository)
+		from := s.headCommit(ctx, repoDir)
+		if err := s.checkoutCommit(ctx, ws.GetRepositoryByName(ref.Repository), repoDir, ref.Commit); err != nil {
+			progress.Failed = ref.Repository
+			progress.Error = err.Error()
+			entry.Result = HistoryResultFailed
+			entry.Error = fmt.Sprintf("checking out %s: %v", ref.Repository, err)
+			_ = s.recordHistory(ws, entry)
+			if writeErr := s.writeApplyProgress(ws, progress); writeErr != nil {
+				return fmt.Errorf("checking out %s to %s: %w; %v", ref.Repository, ref.Commit, err, writeErr)
+			}
+			return fmt.Errorf("checking out %s to %s: %w", ref.Repository, ref.Commit, err)
+		}
+		progress.Applied = append(progress.Applied, ref.Repository)
+		progress.Failed = ""
+		progress.Error = ""
+		entry.Changes = append(entry.Changes, RefChange{Repository: ref.Repository, From: from, To: ref.Commit})
+	}
+	_ = s.recordHistory(ws, entry)
+
+	if err := s.clearApplyProgress(ws, capture.ID); err != nil {
+		return err
+	}
+
+	s.emit(ctx, StoreEvent{Type: EventCaptureApplied, Handle: ws.Handle, Purpose: ws.Purpose, Path: ws.Path, CaptureID: capture.ID, Capture: capture.Name})
+	return nil
+}
+
+// checkoutCommit checks out commit in repoDir and, when repo uses Git LFS,
+// pulls the LFS objects for it so files hold their content, not pointers.
+// repo may be nil for a directory the workspace does not track.
+func (s *FSStore) checkoutCommit(ctx context.Context, repo *Repository, repoDir, commit string) error {
+	if err := s.git.Checkout(ctx, repoDir, commit); err != nil {
+		return err
+	}
+	if repo != nil && repo.LFS {
+		if err := s.git.LFSPull(ctx, repoDir); err != nil {
+			return fmt.Errorf("pulling LFS objects: %w", err)
+		}
+	}
+	return nil
+}
+
+func applyProgressPath(ws *Workspace, captureID string) string {
+	return filepath.Join(ws.Path, ".workshed", capturesDirName, captureID, "apply-progress.json")
+}
+
+func (s *FSStore) readApplyProgress(ws *Workspace, captureID string) (*ApplyProgress, error) {
+	data, err := os.ReadFile(applyProgressPath(ws, captureID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading apply progress: %w", err)
+	}
+
+	var progress ApplyProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("parsing apply progress: %w", err)
+	}
+	return &progress, nil
+}
+
+func (s *FSStore) writeApplyProgress(ws *Workspace, progress *ApplyProgress) error {
+	progress.UpdatedAt = s.clock.Now()
+	data, err := json.MarshalIndent(progress, "", "  ")
 	if err != nil {
-		return err
+		return fmt.Errorf("marshaling apply progress: %w", err)
 	}
-
-	for _, ref := range capture.GitState {
-		repoDir := filepath.Join(ws.Path, ref.Repository)
-		if err := s.git.Checkout(ctx, repoDir, ref.Commit); err != nil {
-			return fmt.Errorf("checking out %s to %s: %w", ref.Repository, ref.Commit, err)
-		}
+	if err := fs.WriteJson(applyProgressPath(ws, progress.CaptureID), data); err != nil {
+		return fmt.Errorf("writing apply progress: %w", err)
 	}
+	return nil
+}
 
+func (s *FSStore) clearApplyProgress(ws *Workspace, captureID string) error {
+	if err := os.Remove(applyProgressPath(ws, captureID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing apply progress: %w", err)
+	}
 	return nil
 }
 
 func (s *FSStore) PreflightApply(ctx context.Context, handle string, captureID string) (ApplyPreflightResult, error) {
-	result := ApplyPreflightResult{Valid: true}
-
 	capture, err := s.GetCapture(ctx, handle, captureID)
 	if err != nil {
 		return ApplyPreflightResult{}, err
@@ -1192,12 +3179,40 @@ func (s *FSStore) PreflightApply(ctx context.Context, handle string, captureID s
 		return ApplyPreflightResult{}, err
 	}
 
+	return s.preflightRefs(ctx, ws, capture.GitState), nil
+}
+
+// PlanApply lists the commit each captured repository would move from and to
+// if the capture were applied now, in capture order. From is empty when the
+// repository's HEAD cannot be read, e.g. because it is missing.
+func (s *FSStore) PlanApply(ctx context.Context, handle string, captureID string) ([]RefChange, error) {
+	capture, err := s.GetCapture(ctx, handle, captureID)
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]RefChange, len(capture.GitState))
+	for i, ref := range capture.GitState {
+		from := s.headCommit(ctx, filepath.Join(ws.Path, ref.Repository))
+		changes[i] = RefChange{Repository: ref.Repository, From: from, To: ref.Commit}
+	}
+	return changes, nil
+}
+
+func (s *FSStore) preflightRefs(ctx context.Context, ws *Workspace, refs []GitRef) ApplyPreflightResult {
+	result := ApplyPreflightResult{Valid: true}
+
 	repoSet := make(map[string]bool)
 	for _, repo := range ws.Repositories {
 		repoSet[repo.Name] = true
 	}
 
-	for _, ref := range capture.GitState {
+	for _, ref := range refs {
 		repoDir := filepath.Join(ws.Path, ref.Repository)
 
 		if !repoSet[ref.Repository] {
@@ -1230,22 +3245,56 @@ func (s *FSStore) PreflightApply(ctx context.Context, handle string, captureID s
 				Details:    "working tree has uncommitted changes",
 			})
 		}
+
+		exists, err := s.git.CommitExists(ctx, repoDir, ref.Commit)
+		if err != nil || !exists {
+			result.Valid = false
+			if repo := ws.GetRepositoryByName(ref.Repository); repo != nil && IsShallow(*repo, repoDir) {
+				result.Errors = append(result.Errors, ApplyPreflightError{
+					Repository: ref.Repository,
+					Reason:     ReasonShallowCommit,
+					Details:    fmt.Sprintf("commit %s is not present in the shallow clone; run 'workshed repos unshallow %s --repo %s'", ref.Commit, ws.Handle, ref.Repository),
+				})
+				continue
+			}
+			result.Errors = append(result.Errors, ApplyPreflightError{
+				Repository: ref.Repository,
+				Reason:     ReasonCommitMissing,
+				Details:    fmt.Sprintf("commit %s is not present in the repository", ref.Commit),
+			})
+		}
 	}
 
-	return result, nil
+	return result
 }
 
+// GetCapture returns a capture by ID or, failing that, by name. A name shared
+// by several captures is an error rather than a guess.
 func (s *FSStore) GetCapture(ctx context.Context, handle, captureID string) (*Capture, error) {
 	ws, err := s.Get(ctx, handle)
 	if err != nil {
 		return nil, err
 	}
 
-	capturePath := filepath.Join(ws.Path, ".workshed", capturesDirName, captureID, "capture.json")
-	data, err := os.ReadFile(capturePath)
+	capture, err := readCapture(ws.Path, captureID)
+	if !errors.Is(err, errCaptureNotFound) {
+		return capture, err
+	}
+
+	captures, err := s.ListCaptures(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	return CaptureByName(captures, captureID)
+}
+
+var errCaptureNotFound = errors.New("capture not found")
+
+func readCapture(wsPath, captureID string) (*Capture, error) {
+	data, err := readCaptureData(filepath.Join(wsPath, ".workshed", capturesDirName, captureID))
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("capture not found: %s", captureID)
+			return nil, fmt.Errorf("%w: %s", errCaptureNotFound, captureID)
 		}
 		return nil, fmt.Errorf("reading capture: %w", err)
 	}
@@ -1258,6 +3307,28 @@ func (s *FSStore) GetCapture(ctx context.Context, handle, captureID string) (*Ca
 	return &capture, nil
 }
 
+// CaptureByName returns the capture in captures named name. It errors when
+// no capture or more than one capture has that name.
+func CaptureByName(captures []Capture, name string) (*Capture, error) {
+	var matches []*Capture
+	for i := range captures {
+		if captures[i].Name == name {
+			matches = append(matches, &captures[i])
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", errCaptureNotFound, name)
+	case 1:
+		return matches[0], nil
+	}
+	ids := make([]string, len(matches))
+	for i, c := range matches {
+		ids[i] = c.ID
+	}
+	return nil, fmt.Errorf("capture name %q is ambiguous: matches %s", name, strings.Join(ids, ", "))
+}
+
 func (s *FSStore) ListCaptures(ctx context.Context, handle string) ([]Capture, error) {
 	ws, err := s.Get(ctx, handle)
 	if err != nil {
@@ -1273,25 +3344,381 @@ func (s *FSStore) ListCaptures(ctx context.Context, handle string) ([]Capture, e
 		return nil, fmt.Errorf("reading captures directory: %w", err)
 	}
 
+	var captures []Capture
+	for _, id := range captureIDs(entries) {
+		capture, err := readCapture(ws.Path, id)
+		if err != nil {
+			continue
+		}
+		captures = append(captures, *capture)
+	}
+
+	return captures, nil
+}
+
+// CaptureSize returns the total size in bytes of the files stored for a
+// capture. It walks the capture directory, so callers should only ask for it
+// when the size will be shown.
+func (s *FSStore) CaptureSize(ctx context.Context, handle, captureID string) (int64, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return 0, err
+	}
+
+	captureDir := filepath.Join(ws.Path, ".workshed", capturesDirName, captureID)
+	if _, err := os.Stat(captureDir); err != nil {
+		if os.IsNotExist(err) {
+			return 0, fmt.Errorf("capture not found: %s", captureID)
+		}
+		return 0, fmt.Errorf("reading capture: %w", err)
+	}
+
+	var size int64
+	err = filepath.Walk(captureDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("measuring capture: %w", err)
+	}
+	return size, nil
+}
+
+// LatestCapture returns the newest of captures, which must be ordered
+// newest-first as ListCaptures returns them. A non-empty tag restricts the
+// choice to captures carrying that tag.
+func LatestCapture(captures []Capture, tag string) (*Capture, error) {
+	for i := range captures {
+		if tag == "" || slices.Contains(captures[i].Metadata.Tags, tag) {
+			return &captures[i], nil
+		}
+	}
+	if tag != "" {
+		return nil, fmt.Errorf("no captures tagged %q", tag)
+	}
+	return nil, errors.New("no captures found")
+}
+
+// captureIDs returns the capture directory names in entries, newest first.
+func captureIDs(entries []os.DirEntry) []string {
 	var ids []string
 	for _, entry := range entries {
 		if entry.IsDir() {
 			ids = append(ids, entry.Name())
 		}
 	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids
+}
+
+// LastActivity returns the latest of the workspace's creation time, its newest
+// execution and its newest capture. Only the newest record of each is read.
+func (s *FSStore) LastActivity(ctx context.Context, handle string) (time.Time, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	executions, err := s.ListExecutions(ctx, handle, ListExecutionsOptions{Limit: 1})
+	if err != nil {
+		return time.Time{}, err
+	}
+	var lastExecuted *time.Time
+	if len(executions) > 0 {
+		lastExecuted = &executions[0].Timestamp
+	}
+
+	lastCaptured, err := s.newestCaptureTime(ctx, ws)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return lastActivity(ws.CreatedAt, lastExecuted, lastCaptured), nil
+}
+
+func (s *FSStore) newestCaptureTime(ctx context.Context, ws *Workspace) (*time.Time, error) {
+	entries, err := os.ReadDir(filepath.Join(ws.Path, ".workshed", capturesDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading captures directory: %w", err)
+	}
+
+	for _, id := range captureIDs(entries) {
+		capture, err := readCapture(ws.Path, id)
+		if err != nil {
+			continue
+		}
+		return &capture.Timestamp, nil
+	}
+	return nil, nil
+}
+
+func lastActivity(created time.Time, times ...*time.Time) time.Time {
+	latest := created
+	for _, t := range times {
+		if t != nil && t.After(latest) {
+			latest = *t
+		}
+	}
+	return latest
+}
+
+func (s *FSStore) VerifyCapture(ctx context.Context, handle, captureID string) (*CaptureVerification, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CaptureVerification{CaptureID: captureID, Valid: true}
+
+	data, err := readCaptureData(filepath.Join(ws.Path, ".workshed", capturesDirName, captureID))
+	switch {
+	case errors.Is(err, errCorruptCaptureArchive):
+		result.Valid = false
+		result.Orphaned = true
+		result.Problems = append(result.Problems, ApplyPreflightError{
+			Reason:  ReasonCorruptCapture,
+			Details: err.Error(),
+		})
+		return result, nil
+	case os.IsNotExist(err):
+		return nil, fmt.Errorf("%w: %s", errCaptureNotFound, captureID)
+	case err != nil:
+		return nil, fmt.Errorf("reading capture: %w", err)
+	}
+
+	var capture Capture
+	if err := json.Unmarshal(data, &capture); err != nil {
+		result.Valid = false
+		result.Orphaned = true
+		result.Problems = append(result.Problems, ApplyPreflightError{
+			Reason:  ReasonCorruptCapture,
+			Details: fmt.Sprintf("capture.json does not parse: %v", err),
+		})
+		return result, nil
+	}
+	result.Name = capture.Name
+
+	for _, e := range s.preflightRefs(ctx, ws, capture.GitState).Errors {
+		if e.Reason == ReasonDirtyWorkingTree {
+			continue
+		}
+		result.Valid = false
+		result.Problems = append(result.Problems, e)
+	}
+
+	return result, nil
+}
+
+func (s *FSStore) VerifyCaptures(ctx context.Context, handle string) ([]CaptureVerification, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(ws.Path, ".workshed", capturesDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []CaptureVerification{}, nil
+		}
+		return nil, fmt.Errorf("reading captures directory: %w", err)
+	}
 
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			ids = append(ids, entry.Name())
+		}
+	}
 	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
 
-	var captures []Capture
+	results := make([]CaptureVerification, 0, len(ids))
 	for _, id := range ids {
-		capture, err := s.GetCapture(ctx, handle, id)
+		result, err := s.VerifyCapture(ctx, handle, id)
+		if errors.Is(err, errCaptureNotFound) {
+			results = append(results, CaptureVerification{
+				CaptureID: id,
+				Orphaned:  true,
+				Problems:  []ApplyPreflightError{{Reason: ReasonMissingCapture, Details: "capture.json is missing"}},
+			})
+			continue
+		}
 		if err != nil {
+			results = append(results, CaptureVerification{
+				CaptureID: id,
+				Problems:  []ApplyPreflightError{{Reason: ReasonCorruptCapture, Details: err.Error()}},
+			})
 			continue
 		}
-		captures = append(captures, *capture)
+		results = append(results, *result)
 	}
 
-	return captures, nil
+	return results, nil
+}
+
+// orphanGracePeriod keeps PruneOrphanedCaptures away from directories a
+// concurrent CaptureState may still be writing capture.json into.
+const orphanGracePeriod = time.Minute
+
+// OrphanedCaptures returns the verification results of the capture
+// directories PruneOrphanedCaptures would remove: those whose capture.json is
+// missing or does not parse and that are older than orphanGracePeriod.
+func (s *FSStore) OrphanedCaptures(ctx context.Context, handle string) ([]CaptureVerification, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := s.VerifyCaptures(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	orphaned := []CaptureVerification{}
+	for _, result := range results {
+		if !result.Orphaned {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(ws.Path, ".workshed", capturesDirName, result.CaptureID))
+		if err != nil {
+			return nil, fmt.Errorf("reading capture %s: %w", result.CaptureID, err)
+		}
+		if s.clock.Now().Sub(info.ModTime()) < orphanGracePeriod {
+			continue
+		}
+		orphaned = append(orphaned, result)
+	}
+	return orphaned, nil
+}
+
+// PruneOrphanedCaptures removes the capture directories OrphanedCaptures
+// returns and returns their IDs. Captures that parse but reference missing
+// repositories or commits are kept; they still describe a state and
+// VerifyCaptures reports them.
+func (s *FSStore) PruneOrphanedCaptures(ctx context.Context, handle string) ([]string, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	orphaned, err := s.OrphanedCaptures(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	for _, result := range orphaned {
+		captureDir := filepath.Join(ws.Path, ".workshed", capturesDirName, result.CaptureID)
+		if err := os.RemoveAll(captureDir); err != nil {
+			return removed, fmt.Errorf("removing capture %s: %w", result.CaptureID, err)
+		}
+		removed = append(removed, result.CaptureID)
+	}
+	return removed, nil
+}
+
+// DeleteCapture removes a capture's directory. captureID must be a capture
+// ID; names are not resolved, so a deletion never picks the wrong capture.
+func (s *FSStore) DeleteCapture(ctx context.Context, handle, captureID string) error {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return err
+	}
+
+	if captureID == "" || captureID == "." || captureID == ".." || filepath.Base(captureID) != captureID {
+		return fmt.Errorf("%w: %s", errCaptureNotFound, captureID)
+	}
+	captureDir := filepath.Join(ws.Path, ".workshed", capturesDirName, captureID)
+	info, err := os.Stat(captureDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", errCaptureNotFound, captureID)
+		}
+		return fmt.Errorf("reading capture: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s", errCaptureNotFound, captureID)
+	}
+
+	if err := os.RemoveAll(captureDir); err != nil {
+		return fmt.Errorf("removing capture %s: %w", captureID, err)
+	}
+	return nil
+}
+
+// Lock records the commit currently checked out in each repository.
+func (s *FSStore) Lock(ctx context.Context, handle string) (*Lockfile, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]LockedRepo, 0, len(ws.Repositories))
+	for _, repo := range ws.Repositories {
+		commit, err := s.git.RevParse(ctx, filepath.Join(ws.Path, repo.Name), "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("resolving HEAD for %s: %w", repo.Name, err)
+		}
+		repos = append(repos, LockedRepo{
+			Name:   repo.Name,
+			URL:    repo.URL,
+			Commit: commit,
+		})
+	}
+
+	return &Lockfile{
+		Version:      LockVersion,
+		GeneratedAt:  s.clock.Now(),
+		Repositories: repos,
+	}, nil
+}
+
+// RestoreLock checks out each locked repository at its recorded commit.
+func (s *FSStore) RestoreLock(ctx context.Context, handle string, lock *Lockfile) error {
+	if lock == nil {
+		return errors.New("lockfile is required")
+	}
+	if lock.Version != LockVersion {
+		return fmt.Errorf("unsupported lockfile version: %d (expected %d)", lock.Version, LockVersion)
+	}
+
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return err
+	}
+
+	entry := HistoryEntry{Operation: HistoryRestoreLock, Changes: []RefChange{}}
+	for _, locked := range lock.Repositories {
+		if ws.GetRepositoryByName(locked.Name) == nil {
+			return fmt.Errorf("locked repository not in workspace: %s", locked.Name)
+		}
+		repoDir := filepath.Join(ws.Path, locked.Name)
+		exists, err := s.git.CommitExists(ctx, repoDir, locked.Commit)
+		if err != nil {
+			return fmt.Errorf("checking commit for %s: %w", locked.Name, err)
+		}
+		if !exists {
+			return fmt.Errorf("commit %s is not present in %s", locked.Commit, locked.Name)
+		}
+		from := s.headCommit(ctx, repoDir)
+		if err := s.checkoutCommit(ctx, ws.GetRepositoryByName(locked.Name), repoDir, locked.Commit); err != nil {
+			entry.Result = HistoryResultFailed
+			entry.Error = fmt.Sprintf("checking out %s: %v", locked.Name, err)
+			_ = s.recordHistory(ws, entry)
+			return fmt.Errorf("checking out %s to %s: %w", locked.Name, locked.Commit, err)
+		}
+		entry.Changes = append(entry.Changes, RefChange{Repository: locked.Name, From: from, To: locked.Commit})
+	}
+	_ = s.recordHistory(ws, entry)
+
+	return nil
 }
 
 func (s *FSStore) ExportContext(ctx context.Context, handle string) (*WorkspaceContext, error) {
@@ -1327,6 +3754,9 @@ func (s *FSStore) ExportContext(ctx context.Context, handle string) (*WorkspaceC
 			URL:      repo.URL,
 			RootPath: repo.Name,
 			Ref:      ref,
+			Sparse:   repo.Sparse,
+			Remotes:  repo.Remotes,
+			LFS:      repo.LFS,
 		}
 	}
 
@@ -1345,21 +3775,24 @@ func (s *FSStore) ExportContext(ctx context.Context, handle string) (*WorkspaceC
 	contextCaptures := make([]ContextCapture, 0, len(captures))
 	for _, cap := range captures {
 		contextCaptures = append(contextCaptures, ContextCapture{
-			ID:          cap.ID,
-			Timestamp:   cap.Timestamp,
-			Name:        cap.Name,
-			Kind:        cap.Kind,
-			Description: cap.Metadata.Description,
-			Tags:        cap.Metadata.Tags,
-			RepoCount:   len(cap.GitState),
+			ID:            cap.ID,
+			Timestamp:     cap.Timestamp,
+			Name:          cap.Name,
+			Kind:          cap.Kind,
+			Description:   cap.Metadata.Description,
+			Tags:          cap.Metadata.Tags,
+			RepoCount:     len(cap.GitState),
+			SourceHandle:  cap.Handle,
+			SourcePurpose: cap.SourcePurpose,
 		})
 	}
 
 	return &WorkspaceContext{
 		Version:      ContextVersion,
-		GeneratedAt:  time.Now(),
+		GeneratedAt:  s.clock.Now(),
 		Handle:       handle,
 		Purpose:      ws.Purpose,
+		Project:      ws.Project,
 		Repositories: repos,
 		Captures:     contextCaptures,
 		Metadata: ContextMetadata{
@@ -1368,6 +3801,7 @@ func (s *FSStore) ExportContext(ctx context.Context, handle string) (*WorkspaceC
 			CapturesCount:   len(captures),
 			LastExecutedAt:  lastExecuted,
 			LastCapturedAt:  lastCaptured,
+			LastActivityAt:  lastActivity(ws.CreatedAt, lastExecuted, lastCaptured),
 		},
 	}, nil
 }
@@ -1377,17 +3811,8 @@ func (s *FSStore) GetClipboard() interface{ WriteAll(string) error } {
 }
 
 func (s *FSStore) ImportContext(ctx context.Context, opts ImportOptions) (*Workspace, error) {
-	if opts.Context == nil {
-		return nil, errors.New("context is required")
-	}
-	if opts.Context.Version != ContextVersion {
-		return nil, fmt.Errorf("unsupported context version: %d (expected %d)", opts.Context.Version, ContextVersion)
-	}
-	if opts.Context.Purpose == "" {
-		return nil, errors.New("purpose is required")
-	}
-	if len(opts.Context.Repositories) == 0 {
-		return nil, errors.New("at least one repository is required")
+	if err := ValidateContext(opts.Context); err != nil {
+		return nil, err
 	}
 
 	wsHandle := opts.Context.Handle
@@ -1418,15 +3843,20 @@ func (s *FSStore) ImportContext(ctx context.Context, opts ImportOptions) (*Works
 	repos := make([]RepositoryOption, len(opts.Context.Repositories))
 	for i, ctxRepo := range opts.Context.Repositories {
 		repos[i] = RepositoryOption{
-			URL: ctxRepo.URL,
-			Ref: ctxRepo.Ref,
+			URL:     ctxRepo.URL,
+			Ref:     ctxRepo.Ref,
+			Sparse:  ctxRepo.Sparse,
+			Remotes: ctxRepo.Remotes,
+			LFS:     ctxRepo.LFS,
 		}
 	}
 
 	workspace, err := s.Create(ctx, CreateOptions{
 		Purpose:       opts.Context.Purpose,
+		Project:       opts.Context.Project,
 		Repositories:  repos,
 		InvocationCWD: opts.InvocationCWD,
+		Concurrency:   opts.Concurrency,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("creating workspace: %w", err)
@@ -7,14 +7,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
+	"os/user"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/frodi/workshed/internal/git"
+	"github.com/frodi/workshed/internal/handle"
 )
 
 func TestCreateValidation(t *testing.T) {
@@ -85,6 +92,44 @@ func TestCreateValidation(t *testing.T) {
 			t.Errorf("Expected duplicate URL error, got: %v", err)
 		}
 	})
+
+	t.Run("should return error for differently spelled local paths to one repository", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		base := t.TempDir()
+		CreateFakeRepo(t, base, "repo")
+		if err := os.MkdirAll(filepath.Join(base, "dir"), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+
+		ctx := context.Background()
+		_, err := store.Create(ctx, CreateOptions{
+			Purpose:       "Test workspace",
+			InvocationCWD: filepath.Join(base, "dir"),
+			Repositories: []RepositoryOption{
+				{URL: "../repo"},
+				{URL: filepath.Join(base, "repo") + "/"},
+			},
+		})
+		if err == nil || !strings.Contains(err.Error(), "duplicate repository") {
+			t.Fatalf("Expected duplicate repository error, got: %v", err)
+		}
+		if len(mockGit.GetCloneCalls()) != 0 {
+			t.Error("Expected the duplicate to be rejected before cloning")
+		}
+
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:       "Test workspace",
+			InvocationCWD: base,
+			Repositories:  []RepositoryOption{{URL: "./repo"}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		err = store.AddRepositories(ctx, ws.Handle, []RepositoryOption{{URL: "dir/../repo/"}}, base)
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("Expected AddRepositories to reject the same path, got: %v", err)
+		}
+	})
 }
 
 func TestExtractRepoName(t *testing.T) {
@@ -184,6 +229,28 @@ func TestIsLocalPath(t *testing.T) {
 	}
 }
 
+func TestRepositoryLocation(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"/Users/test/repo", RepositoryLocal},
+		{"../parent/path", RepositoryLocal},
+		{"https://github.com/org/repo", RepositoryRemote},
+		{"git@github.com:org/repo", RepositoryRemote},
+		{"github.com/org/repo", RepositoryRemote},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			repo := Repository{URL: tt.url}
+			if got := repo.Location(); got != tt.want {
+				t.Errorf("Location() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestValidateLocalRepository(t *testing.T) {
 	t.Run("should accept existing git repository", func(t *testing.T) {
 		repoDir := t.TempDir()
@@ -246,6 +313,50 @@ func TestValidateLocalRepository(t *testing.T) {
 			t.Errorf("Expected 'cannot be empty' in error, got: %v", err)
 		}
 	})
+
+	t.Run("should accept bare repository", func(t *testing.T) {
+		repoDir := filepath.Join(t.TempDir(), "mirror.git")
+		if out, err := exec.Command("git", "init", "--bare", repoDir).CombinedOutput(); err != nil {
+			t.Fatalf("git init --bare failed: %v\n%s", err, out)
+		}
+
+		if err := validateLocalRepository(repoDir, ""); err != nil {
+			t.Errorf("Expected valid bare repository, got error: %v", err)
+		}
+		if err := validateLocalRepository(strings.TrimSuffix(repoDir, ".git"), ""); err != nil {
+			t.Errorf("Expected bare repository without .git suffix to resolve, got error: %v", err)
+		}
+	})
+}
+
+func TestCreateFromBareRepository(t *testing.T) {
+	ctx := context.Background()
+	store, _ := CreateTestStore(t)
+	source := CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"})
+
+	mirror := filepath.Join(t.TempDir(), "api.git")
+	if out, err := exec.Command("git", "clone", "-q", "--bare", source, mirror).CombinedOutput(); err != nil {
+		t.Fatalf("git clone --bare failed: %v\n%s", err, out)
+	}
+
+	url, ref, depth := ParseRepoFlag(mirror + "@main")
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "Bare source",
+		Repositories: []RepositoryOption{{URL: url, Ref: ref, Depth: depth}},
+	})
+	if err != nil {
+		t.Fatalf("Create from bare repository failed: %v", err)
+	}
+
+	if ws.Repositories[0].Name != "api" {
+		t.Errorf("Expected repository name api, got %s", ws.Repositories[0].Name)
+	}
+	if ws.Repositories[0].URL != mirror {
+		t.Errorf("Expected URL %s, got %s", mirror, ws.Repositories[0].URL)
+	}
+	if !FileExists(filepath.Join(ws.Path, "api", "README.md")) {
+		t.Error("Expected README.md to be checked out from the bare repository")
+	}
 }
 
 func TestExtractRepoNameLocalPaths(t *testing.T) {
@@ -271,6 +382,95 @@ func TestExtractRepoNameLocalPaths(t *testing.T) {
 	}
 }
 
+func TestExpandPathForms(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	repoDir := filepath.Join(home, "sub")
+	cmd := exec.Command("git", "init", repoDir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+
+	forms := []struct {
+		name string
+		path string
+		cwd  string
+	}{
+		{"tilde", "~/sub", ""},
+		{"tilde with trailing slash", "~/sub/", ""},
+		{"env var", "$HOME/sub", ""},
+		{"braced env var", "${HOME}/sub", ""},
+		{"relative", "sub", home},
+		{"dot relative", "./sub", home},
+		{"absolute", repoDir, "/elsewhere"},
+	}
+
+	for _, tc := range forms {
+		t.Run(tc.name, func(t *testing.T) {
+			expanded, err := expandPath(tc.path, tc.cwd)
+			if err != nil {
+				t.Fatalf("expandPath failed: %v", err)
+			}
+			if expanded != repoDir {
+				t.Errorf("expandPath: expected %s, got %s", repoDir, expanded)
+			}
+			if resolved, err := resolveLocalPath(tc.path, tc.cwd); err != nil || resolved != repoDir {
+				t.Errorf("resolveLocalPath: expected %s, got %s (%v)", repoDir, resolved, err)
+			}
+			if name := extractRepoName(tc.path, tc.cwd); name != "sub" {
+				t.Errorf("extractRepoName: expected sub, got %q", name)
+			}
+			if err := validateLocalRepository(tc.path, tc.cwd); err != nil {
+				t.Errorf("validateLocalRepository: %v", err)
+			}
+			if key := repoKey(tc.path, tc.cwd); key != repoDir {
+				t.Errorf("repoKey: expected %s, got %s", repoDir, key)
+			}
+		})
+	}
+
+	t.Run("bare tilde is the home directory", func(t *testing.T) {
+		expanded, err := expandPath("~", "")
+		if err != nil || expanded != home {
+			t.Errorf("Expected %s, got %s (%v)", home, expanded, err)
+		}
+	})
+
+	t.Run("tilde user", func(t *testing.T) {
+		current, err := user.Current()
+		if err != nil || current.Username == "" || current.HomeDir == "" {
+			t.Skip("current user cannot be looked up")
+		}
+		expanded, err := expandPath("~"+current.Username+"/sub", "")
+		if err != nil {
+			t.Fatalf("expandPath failed: %v", err)
+		}
+		if want := filepath.Join(current.HomeDir, "sub"); expanded != want {
+			t.Errorf("Expected %s, got %s", want, expanded)
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		if _, err := expandPath("~no-such-user-workshed/sub", ""); err == nil {
+			t.Error("Expected an error for an unknown user")
+		}
+	})
+
+	t.Run("unset env var", func(t *testing.T) {
+		t.Setenv("WORKSHED_UNSET_VAR", "")
+		if err := os.Unsetenv("WORKSHED_UNSET_VAR"); err != nil {
+			t.Fatal(err)
+		}
+		_, err := expandPath("$WORKSHED_UNSET_VAR/sub", home)
+		if err == nil || !strings.Contains(err.Error(), "WORKSHED_UNSET_VAR") {
+			t.Errorf("Expected an error naming the unset variable, got %v", err)
+		}
+		if err := validateLocalRepository("${WORKSHED_UNSET_VAR}/sub", home); err == nil {
+			t.Error("Expected validateLocalRepository to fail for an unset variable")
+		}
+	})
+}
+
 func TestWorkspaceGetRepositoryByName(t *testing.T) {
 	ws := &Workspace{
 		Repositories: []Repository{
@@ -381,6 +581,61 @@ func TestExec(t *testing.T) {
 	})
 }
 
+func TestExecExpand(t *testing.T) {
+	ctx := context.Background()
+	store, _ := CreateTestStore(t)
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose: "Expand test",
+		Repositories: []RepositoryOption{
+			{URL: CreateLocalGitRepo(t, "expand-api", map[string]string{"README.md": "# API"}), Ref: "main"},
+			{URL: CreateLocalGitRepo(t, "expand-web", map[string]string{"README.md": "# Web"}), Ref: "main"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	command := []string{"sh", "-c", "echo {{repo}}@{{ref}} {{handle}}"}
+
+	t.Run("expands variables per repository", func(t *testing.T) {
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Target: "all", Command: command, Expand: true})
+		if err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %d", len(results))
+		}
+		for _, result := range results {
+			want := result.Repository + "@main " + ws.Handle
+			if got := strings.TrimSpace(string(result.Output)); got != want {
+				t.Errorf("%s: expected %q, got %q", result.Repository, want, got)
+			}
+		}
+	})
+
+	t.Run("expands path for a single target", func(t *testing.T) {
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Target: "expand-web", Command: []string{"echo", "{{path}}"}, Expand: true})
+		if err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if got, want := strings.TrimSpace(string(results[0].Output)), filepath.Join(ws.Path, "expand-web"); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("preserves braces without expand", func(t *testing.T) {
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Target: "all", Command: command})
+		if err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		for _, result := range results {
+			if got := strings.TrimSpace(string(result.Output)); got != "{{repo}}@{{ref}} {{handle}}" {
+				t.Errorf("%s: expected literal braces, got %q", result.Repository, got)
+			}
+		}
+	})
+}
+
 func TestExecInRepository(t *testing.T) {
 	t.Run("should return error for missing directory", func(t *testing.T) {
 		root := t.TempDir()
@@ -399,7 +654,7 @@ func TestExecInRepository(t *testing.T) {
 		}
 
 		repo := Repository{Name: "nonexistent", URL: "https://github.com/test/repo"}
-		result, err := store.execInRepository(ctx, repo, ws.Path, []string{"echo", "hello"})
+		result, err := store.execInRepository(ctx, repo, ws.Path, []string{"echo", "hello"}, nil, 0)
 		if err == nil {
 			t.Error("Expected error for missing directory")
 		}
@@ -583,475 +838,1415 @@ func TestCloneRepo_AutoDetectsDefaultBranch(t *testing.T) {
 			t.Errorf("Expected ref 'develop' from auto-detection, got: %q", ws.Repositories[0].Ref)
 		}
 	})
-}
 
-func TestGetCapture(t *testing.T) {
-	t.Run("should return error for nonexistent capture", func(t *testing.T) {
-		root := t.TempDir()
-		store, err := NewFSStore(root)
-		if err != nil {
-			t.Fatalf("NewFSStore failed: %v", err)
-		}
+	t.Run("should check out master when the remote defaults to master", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		mockGit.SetDefaultBranchResult("master")
 
 		ctx := context.Background()
 		ws, err := store.Create(ctx, CreateOptions{
-			Purpose:      "Test workspace",
-			Repositories: []RepositoryOption{},
+			Purpose: "Test workspace",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/org/repo"},
+			},
 		})
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
 
-		_, err = store.GetCapture(ctx, ws.Handle, "nonexistent")
-		if err == nil {
-			t.Error("Expected error for nonexistent capture")
+		if ws.Repositories[0].Ref != "master" {
+			t.Errorf("Expected ref 'master', got: %q", ws.Repositories[0].Ref)
 		}
-		if !strings.Contains(err.Error(), "not found") {
-			t.Errorf("Expected 'not found' in error, got: %v", err)
+		checkouts := mockGit.GetCheckoutCalls()
+		if len(checkouts) != 1 || checkouts[0].Ref != "master" {
+			t.Errorf("Expected checkout of master, got: %v", checkouts)
 		}
 	})
-}
 
-func TestListExecutions(t *testing.T) {
-	t.Run("should return empty list for workspace without executions", func(t *testing.T) {
-		root := t.TempDir()
-		store, err := NewFSStore(root)
-		if err != nil {
-			t.Fatalf("NewFSStore failed: %v", err)
-		}
+	t.Run("should honor DefaultRef over detection", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		mockGit.SetDefaultBranchResult("master")
 
 		ctx := context.Background()
 		ws, err := store.Create(ctx, CreateOptions{
-			Purpose:      "Test workspace",
-			Repositories: []RepositoryOption{},
+			Purpose:    "Test workspace",
+			DefaultRef: "release",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/org/repo"},
+				{URL: "https://github.com/org/other", Ref: "feature"},
+			},
 		})
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
 
-		executions, err := store.ListExecutions(ctx, ws.Handle, ListExecutionsOptions{})
-		if err != nil {
-			t.Fatalf("ListExecutions failed: %v", err)
+		if ws.Repositories[0].Ref != "release" {
+			t.Errorf("Expected ref 'release' from DefaultRef, got: %q", ws.Repositories[0].Ref)
 		}
-		if len(executions) != 0 {
-			t.Errorf("Expected 0 executions, got %d", len(executions))
+		if ws.Repositories[1].Ref != "feature" {
+			t.Errorf("Expected explicit ref 'feature' to win, got: %q", ws.Repositories[1].Ref)
+		}
+		if calls := mockGit.GetDefaultBranchCalls(); len(calls) != 0 {
+			t.Errorf("Expected no default branch detection, got: %v", calls)
 		}
 	})
 }
 
-func TestExportContext(t *testing.T) {
-	t.Run("should export context for workspace", func(t *testing.T) {
-		root := t.TempDir()
-		store, err := NewFSStore(root)
-		if err != nil {
-			t.Fatalf("NewFSStore failed: %v", err)
-		}
-
-		ctx := context.Background()
-		ws, err := store.Create(ctx, CreateOptions{
-			Purpose:      "Test workspace",
-			Repositories: []RepositoryOption{},
+func TestFetchRepositories(t *testing.T) {
+	createWorkspace := func(t *testing.T, store *FSStore) *Workspace {
+		ws, err := store.Create(context.Background(), CreateOptions{
+			Purpose: "Test workspace",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/org/api", Ref: "main"},
+				{URL: "https://github.com/org/web", Ref: "main"},
+			},
 		})
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
+		return ws
+	}
 
-		context, err := store.ExportContext(ctx, ws.Handle)
+	t.Run("should fetch every repository with prune", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		mockGit.SetFetchResult(git.FetchSummary{New: 1, Updated: 2, Deleted: 3})
+		ws := createWorkspace(t, store)
+
+		results, err := store.FetchRepositories(context.Background(), ws.Handle, FetchOptions{Prune: true})
 		if err != nil {
-			t.Fatalf("ExportContext failed: %v", err)
+			t.Fatalf("FetchRepositories failed: %v", err)
 		}
-		if context.Version != ContextVersion {
-			t.Errorf("Expected version %d, got: %d", ContextVersion, context.Version)
+
+		calls := mockGit.GetFetchCalls()
+		if len(calls) != 2 {
+			t.Fatalf("Expected 2 fetch calls, got: %d", len(calls))
 		}
-		if context.Handle != ws.Handle {
-			t.Errorf("Expected handle %s, got: %s", ws.Handle, context.Handle)
+		for _, call := range calls {
+			if !call.Opts.Prune {
+				t.Errorf("Expected prune for %s", call.Dir)
+			}
 		}
-		if context.Purpose != ws.Purpose {
-			t.Errorf("Expected purpose %s, got: %s", ws.Purpose, context.Purpose)
+		if len(results) != 2 || results[0].Summary.Deleted != 3 {
+			t.Errorf("Expected summaries for both repos, got: %+v", results)
 		}
 	})
-}
 
-func TestCaptureKind(t *testing.T) {
-	t.Run("should set kind on capture", func(t *testing.T) {
-		root := t.TempDir()
-		mockGit := &git.MockGit{}
-		mockGit.SetRevParseResult("abc123")
-		mockGit.SetCurrentBranchResult("main")
-		mockGit.SetStatusPorcelainResult("")
-		mockGit.SetDefaultBranchResult("main")
-		store, err := NewFSStore(root, mockGit)
-		if err != nil {
-			t.Fatalf("NewFSStore failed: %v", err)
-		}
+	t.Run("should continue after a failing repository", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		ws := createWorkspace(t, store)
+		mockGit.SetFetchErr(filepath.Join(ws.Path, "api"), errors.New("network down"))
 
-		ctx := context.Background()
-		ws, err := store.Create(ctx, CreateOptions{
-			Purpose: "Test workspace",
-			Repositories: []RepositoryOption{
-				{URL: "https://github.com/test/repo"},
-			},
-		})
+		results, err := store.FetchRepositories(context.Background(), ws.Handle, FetchOptions{})
 		if err != nil {
-			t.Fatalf("Create failed: %v", err)
+			t.Fatalf("FetchRepositories failed: %v", err)
 		}
 
-		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{
-			Name: "Test capture",
-			Kind: CaptureKindCheckpoint,
-		})
-		if err != nil {
-			t.Fatalf("CaptureState failed: %v", err)
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got: %d", len(results))
 		}
-		if capture.Kind != CaptureKindCheckpoint {
-			t.Errorf("Expected kind '%s', got: %s", CaptureKindCheckpoint, capture.Kind)
+		if results[0].Err == nil {
+			t.Error("Expected error for api")
+		}
+		if results[1].Err != nil {
+			t.Errorf("Expected web to succeed, got: %v", results[1].Err)
 		}
 	})
 
-	t.Run("should fail without intent", func(t *testing.T) {
-		root := t.TempDir()
-		mockGit := &git.MockGit{}
-		mockGit.SetRevParseResult("abc123")
-		mockGit.SetCurrentBranchResult("main")
-		mockGit.SetStatusPorcelainResult("")
-		mockGit.SetDefaultBranchResult("main")
-		store, err := NewFSStore(root, mockGit)
+	t.Run("should target a single repository", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		ws := createWorkspace(t, store)
+
+		results, err := store.FetchRepositories(context.Background(), ws.Handle, FetchOptions{Target: "web"})
 		if err != nil {
-			t.Fatalf("NewFSStore failed: %v", err)
+			t.Fatalf("FetchRepositories failed: %v", err)
+		}
+
+		if len(results) != 1 || results[0].Repository != "web" {
+			t.Errorf("Expected only web, got: %+v", results)
+		}
+		if len(mockGit.GetFetchCalls()) != 1 {
+			t.Errorf("Expected 1 fetch call, got: %d", len(mockGit.GetFetchCalls()))
 		}
+	})
+}
 
+func TestLock(t *testing.T) {
+	t.Run("should restore repositories to locked commits", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
 		ctx := context.Background()
+		localRepo := CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"})
+
 		ws, err := store.Create(ctx, CreateOptions{
-			Purpose: "Test workspace",
-			Repositories: []RepositoryOption{
-				{URL: "https://github.com/test/repo"},
-			},
+			Purpose:      "Lock round trip",
+			Repositories: []RepositoryOption{{URL: localRepo, Ref: "main"}},
 		})
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
 
-		_, err = store.CaptureState(ctx, ws.Handle, CaptureOptions{
-			Name: "Test capture",
-		})
-		if err == nil {
-			t.Error("Expected error for capture without intent")
+		lockfile, err := store.Lock(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Lock failed: %v", err)
 		}
-		if !strings.Contains(err.Error(), "intent") {
-			t.Errorf("Expected 'intent' in error message, got: %v", err)
+		if len(lockfile.Repositories) != 1 || lockfile.Repositories[0].URL != localRepo {
+			t.Fatalf("Expected one locked repo for %s, got: %+v", localRepo, lockfile.Repositories)
 		}
-	})
+		locked := lockfile.Repositories[0].Commit
 
-	t.Run("should accept description as intent", func(t *testing.T) {
-		root := t.TempDir()
-		mockGit := &git.MockGit{}
-		mockGit.SetRevParseResult("abc123")
-		mockGit.SetCurrentBranchResult("main")
-		mockGit.SetStatusPorcelainResult("")
-		mockGit.SetDefaultBranchResult("main")
-		store, err := NewFSStore(root, mockGit)
+		repoDir := filepath.Join(ws.Path, "api")
+		for _, args := range [][]string{{"config", "user.email", "test@example.com"}, {"config", "user.name", "Test User"}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = repoDir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", args, err, out)
+			}
+		}
+		if err := AddGitCommit(repoDir, "Advance", map[string]string{"CHANGES.md": "advanced"}); err != nil {
+			t.Fatalf("AddGitCommit failed: %v", err)
+		}
+		advanced, err := git.RealGit{}.RevParse(ctx, repoDir, "HEAD")
 		if err != nil {
-			t.Fatalf("NewFSStore failed: %v", err)
+			t.Fatalf("RevParse failed: %v", err)
+		}
+		if advanced == locked {
+			t.Fatal("Expected repository to advance past locked commit")
 		}
 
-		ctx := context.Background()
-		ws, err := store.Create(ctx, CreateOptions{
-			Purpose: "Test workspace",
-			Repositories: []RepositoryOption{
-				{URL: "https://github.com/test/repo"},
-			},
-		})
-		if err != nil {
-			t.Fatalf("Create failed: %v", err)
+		if err := store.RestoreLock(ctx, ws.Handle, lockfile); err != nil {
+			t.Fatalf("RestoreLock failed: %v", err)
 		}
 
-		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{
-			Name:        "Test capture",
-			Description: "This is why I captured this state",
-		})
+		head, err := git.RealGit{}.RevParse(ctx, repoDir, "HEAD")
 		if err != nil {
-			t.Fatalf("CaptureState failed: %v", err)
+			t.Fatalf("RevParse failed: %v", err)
 		}
-		if capture.Metadata.Description != "This is why I captured this state" {
-			t.Errorf("Expected description, got: %s", capture.Metadata.Description)
+		if head != locked {
+			t.Errorf("Expected HEAD %s after restore, got: %s", locked, head)
 		}
 	})
 
-	t.Run("should accept tags as intent", func(t *testing.T) {
-		root := t.TempDir()
-		mockGit := &git.MockGit{}
-		mockGit.SetRevParseResult("abc123")
-		mockGit.SetCurrentBranchResult("main")
-		mockGit.SetStatusPorcelainResult("")
-		mockGit.SetDefaultBranchResult("main")
-		store, err := NewFSStore(root, mockGit)
+	t.Run("should reject repositories missing from the workspace", func(t *testing.T) {
+		store, _, _ := CreateMockedTestStore(t)
+		ws, err := store.Create(context.Background(), CreateOptions{
+			Purpose:      "Test workspace",
+			Repositories: []RepositoryOption{{URL: "https://github.com/org/api", Ref: "main"}},
+		})
 		if err != nil {
-			t.Fatalf("NewFSStore failed: %v", err)
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		err = store.RestoreLock(context.Background(), ws.Handle, &Lockfile{
+			Version:      LockVersion,
+			Repositories: []LockedRepo{{Name: "web", URL: "https://github.com/org/web", Commit: "abc123"}},
+		})
+		if err == nil || !strings.Contains(err.Error(), "web") {
+			t.Errorf("Expected error naming missing repo, got: %v", err)
+		}
+	})
+
+	t.Run("should reject missing commits", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		mockGit.SetCommitMissing("abc123")
+		ws, err := store.Create(context.Background(), CreateOptions{
+			Purpose:      "Test workspace",
+			Repositories: []RepositoryOption{{URL: "https://github.com/org/api", Ref: "main"}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		err = store.RestoreLock(context.Background(), ws.Handle, &Lockfile{
+			Version:      LockVersion,
+			Repositories: []LockedRepo{{Name: "api", URL: "https://github.com/org/api", Commit: "abc123"}},
+		})
+		if err == nil || !strings.Contains(err.Error(), "abc123") {
+			t.Errorf("Expected missing commit error, got: %v", err)
 		}
+	})
+}
 
+func TestCompare(t *testing.T) {
+	t.Run("should report differing repo sets, refs, and purposes", func(t *testing.T) {
+		store, _, _ := CreateMockedTestStore(t)
 		ctx := context.Background()
-		ws, err := store.Create(ctx, CreateOptions{
-			Purpose: "Test workspace",
+
+		left, err := store.Create(ctx, CreateOptions{
+			Purpose: "Left",
 			Repositories: []RepositoryOption{
-				{URL: "https://github.com/test/repo"},
+				{URL: "https://github.com/org/api", Ref: "main"},
+				{URL: "https://github.com/org/web", Ref: "main"},
 			},
 		})
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
-
-		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{
-			Name: "Test capture",
-			Tags: []string{"bugfix", "investigation"},
+		right, err := store.Create(ctx, CreateOptions{
+			Purpose: "Right",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/org/api", Ref: "develop"},
+				{URL: "https://github.com/org/docs", Ref: "main"},
+			},
 		})
 		if err != nil {
-			t.Fatalf("CaptureState failed: %v", err)
-		}
-		if len(capture.Metadata.Tags) != 2 {
-			t.Errorf("Expected 2 tags, got: %d", len(capture.Metadata.Tags))
+			t.Fatalf("Create failed: %v", err)
 		}
-	})
-}
 
-func TestPreflightApply(t *testing.T) {
-	t.Run("should detect missing repository in capture", func(t *testing.T) {
-		root := t.TempDir()
-		store, err := NewFSStore(root)
+		diff, err := store.Compare(ctx, left.Handle, right.Handle)
 		if err != nil {
-			t.Fatalf("NewFSStore failed: %v", err)
+			t.Fatalf("Compare failed: %v", err)
 		}
 
+		if diff.LeftPurpose != "Left" || diff.RightPurpose != "Right" {
+			t.Errorf("Expected purposes to differ, got: %q vs %q", diff.LeftPurpose, diff.RightPurpose)
+		}
+		if len(diff.OnlyInLeft) != 1 || diff.OnlyInLeft[0] != "web" {
+			t.Errorf("Expected web only in left, got: %v", diff.OnlyInLeft)
+		}
+		if len(diff.OnlyInRight) != 1 || diff.OnlyInRight[0] != "docs" {
+			t.Errorf("Expected docs only in right, got: %v", diff.OnlyInRight)
+		}
+		if len(diff.Changed) != 1 || diff.Changed[0].LeftRef != "main" || diff.Changed[0].RightRef != "develop" {
+			t.Errorf("Expected api ref change, got: %+v", diff.Changed)
+		}
+	})
+
+	t.Run("should report differing commits on a shared repo", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
 		ctx := context.Background()
-		ws, err := store.Create(ctx, CreateOptions{
-			Purpose:      "Test workspace",
-			Repositories: []RepositoryOption{},
-		})
+		localRepo := CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"})
+		opts := CreateOptions{
+			Purpose:      "Same purpose",
+			Repositories: []RepositoryOption{{URL: localRepo, Ref: "main"}},
+		}
+
+		left, err := store.Create(ctx, opts)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		right, err := store.Create(ctx, opts)
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
 
-		capture := &Capture{
-			ID:        "01H5V3ABCDEF",
-			Timestamp: time.Now(),
-			Handle:    ws.Handle,
-			Name:      "Test capture",
-			Kind:      "manual",
-			GitState: []GitRef{
-				{Repository: "nonexistent-repo", Commit: "abc123"},
-			},
+		diff, err := store.Compare(ctx, left.Handle, right.Handle)
+		if err != nil {
+			t.Fatalf("Compare failed: %v", err)
+		}
+		if !diff.Empty() {
+			t.Fatalf("Expected identical workspaces, got: %+v", diff)
 		}
 
-		capturePath := filepath.Join(ws.Path, ".workshed", "captures", capture.ID, "capture.json")
-		if err := os.MkdirAll(filepath.Dir(capturePath), 0755); err != nil {
-			t.Fatalf("Failed to create capture dir: %v", err)
+		repoDir := filepath.Join(right.Path, "api")
+		for _, args := range [][]string{{"config", "user.email", "test@example.com"}, {"config", "user.name", "Test User"}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = repoDir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", args, err, out)
+			}
 		}
-		data, _ := json.MarshalIndent(capture, "", "  ")
-		if err := os.WriteFile(capturePath, data, 0644); err != nil {
-			t.Fatalf("Failed to write capture: %v", err)
+		if err := AddGitCommit(repoDir, "Advance", map[string]string{"CHANGES.md": "advanced"}); err != nil {
+			t.Fatalf("AddGitCommit failed: %v", err)
 		}
 
-		result, err := store.PreflightApply(ctx, ws.Handle, capture.ID)
+		diff, err = store.Compare(ctx, left.Handle, right.Handle)
 		if err != nil {
-			t.Fatalf("PreflightApply failed: %v", err)
+			t.Fatalf("Compare failed: %v", err)
 		}
-		if result.Valid {
-			t.Error("Expected preflight to be invalid for missing repository")
+		if len(diff.Changed) != 1 {
+			t.Fatalf("Expected one changed repo, got: %+v", diff.Changed)
 		}
-		found := false
-		for _, e := range result.Errors {
-			if e.Reason == ReasonMissingRepository {
-				found = true
-				break
-			}
+		change := diff.Changed[0]
+		if change.LeftRef != change.RightRef {
+			t.Errorf("Expected equal refs, got: %s vs %s", change.LeftRef, change.RightRef)
 		}
-		if !found {
-			t.Errorf("Expected missing repository error, got: %v", result.Errors)
+		if change.LeftCommit == "" || change.LeftCommit == change.RightCommit {
+			t.Errorf("Expected differing commits, got: %s vs %s", change.LeftCommit, change.RightCommit)
 		}
 	})
 }
 
-func TestExportContext_RefHandling(t *testing.T) {
-	t.Run("includes ref from stored repository", func(t *testing.T) {
+func TestGetCapture(t *testing.T) {
+	t.Run("should return error for nonexistent capture", func(t *testing.T) {
 		root := t.TempDir()
-		mockGit := &git.MockGit{}
-		mockGit.SetCurrentBranchResult("main")
-		store, err := NewFSStore(root, mockGit)
+		store, err := NewFSStore(root)
 		if err != nil {
 			t.Fatalf("NewFSStore failed: %v", err)
 		}
 
 		ctx := context.Background()
 		ws, err := store.Create(ctx, CreateOptions{
-			Purpose: "Test workspace",
-			Repositories: []RepositoryOption{
-				{URL: "https://github.com/test/repo", Ref: "develop"},
-			},
+			Purpose:      "Test workspace",
+			Repositories: []RepositoryOption{},
 		})
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
 
-		context, err := store.ExportContext(ctx, ws.Handle)
-		if err != nil {
-			t.Fatalf("ExportContext failed: %v", err)
-		}
-
-		if len(context.Repositories) != 1 {
-			t.Fatalf("Expected 1 repository, got %d", len(context.Repositories))
+		_, err = store.GetCapture(ctx, ws.Handle, "nonexistent")
+		if err == nil {
+			t.Error("Expected error for nonexistent capture")
 		}
-
-		if context.Repositories[0].Ref != "develop" {
-			t.Errorf("Expected ref 'develop', got %q", context.Repositories[0].Ref)
+		if !strings.Contains(err.Error(), "not found") {
+			t.Errorf("Expected 'not found' in error, got: %v", err)
 		}
 	})
+}
 
-	t.Run("detects ref for workspace without stored ref", func(t *testing.T) {
+func TestListExecutions(t *testing.T) {
+	t.Run("should return empty list for workspace without executions", func(t *testing.T) {
 		root := t.TempDir()
-		mockGit := &git.MockGit{}
-		mockGit.SetCurrentBranchResult("feature-branch")
-		mockGit.SetDefaultBranchResult("main")
-		store, err := NewFSStore(root, mockGit)
+		store, err := NewFSStore(root)
 		if err != nil {
 			t.Fatalf("NewFSStore failed: %v", err)
 		}
 
 		ctx := context.Background()
 		ws, err := store.Create(ctx, CreateOptions{
-			Purpose: "Test workspace",
-			Repositories: []RepositoryOption{
-				{URL: "https://github.com/test/repo"},
-			},
+			Purpose:      "Test workspace",
+			Repositories: []RepositoryOption{},
 		})
 		if err != nil {
 			t.Fatalf("Create failed: %v", err)
 		}
 
-		context, err := store.ExportContext(ctx, ws.Handle)
+		executions, err := store.ListExecutions(ctx, ws.Handle, ListExecutionsOptions{})
 		if err != nil {
-			t.Fatalf("ExportContext failed: %v", err)
+			t.Fatalf("ListExecutions failed: %v", err)
 		}
-
-		if context.Repositories[0].Ref != "feature-branch" {
-			t.Errorf("Expected ref 'feature-branch', got %q", context.Repositories[0].Ref)
+		if len(executions) != 0 {
+			t.Errorf("Expected 0 executions, got %d", len(executions))
 		}
 	})
 }
 
-func TestImportContext_RefHandling(t *testing.T) {
-	t.Run("preserves ref when importing", func(t *testing.T) {
-		root := t.TempDir()
-		mockGit := &git.MockGit{}
-		store, err := NewFSStore(root, mockGit)
-		if err != nil {
-			t.Fatalf("NewFSStore failed: %v", err)
+func TestPruneExecutions(t *testing.T) {
+	recordN := func(t *testing.T, store *FSStore, handle string, n int) {
+		for i := 1; i <= n; i++ {
+			record := ExecutionRecord{
+				ID:      fmt.Sprintf("exec-%02d", i),
+				Command: []string{"echo", "hi"},
+				Results: []ExecutionRepoResult{{Repository: "api"}},
+			}
+			outputs := []ExecResult{{Repository: "api", Output: []byte("hi")}}
+			if err := store.RecordExecution(context.Background(), handle, record, outputs); err != nil {
+				t.Fatalf("RecordExecution failed: %v", err)
+			}
 		}
+	}
 
-		ctx := context.Background()
-		imported, err := store.ImportContext(ctx, ImportOptions{
-			Context: &WorkspaceContext{
-				Version: 1,
-				Handle:  "test-workspace",
-				Purpose: "Imported workspace",
-				Repositories: []ContextRepo{
-					{Name: "repo", URL: "https://github.com/test/repo", Ref: "v1.0.0"},
-				},
-			},
+	createWorkspace := func(t *testing.T, store *FSStore) *Workspace {
+		ws, err := store.Create(context.Background(), CreateOptions{
+			Purpose:      "Test workspace",
+			Repositories: []RepositoryOption{{URL: "https://github.com/org/api", Ref: "main"}},
 		})
 		if err != nil {
-			t.Fatalf("ImportContext failed: %v", err)
+			t.Fatalf("Create failed: %v", err)
 		}
+		return ws
+	}
 
-		if len(imported.Repositories) != 1 {
-			t.Fatalf("Expected 1 repository, got %d", len(imported.Repositories))
+	t.Run("should keep only the newest records after recording beyond the cap", func(t *testing.T) {
+		store, _, _ := CreateMockedTestStore(t)
+		store.SetRetention(RetentionPolicy{MaxExecutions: 3})
+		ws := createWorkspace(t, store)
+
+		recordN(t, store, ws.Handle, 5)
+
+		records, err := store.ListExecutions(context.Background(), ws.Handle, ListExecutionsOptions{})
+		if err != nil {
+			t.Fatalf("ListExecutions failed: %v", err)
+		}
+		var ids []string
+		for _, record := range records {
+			ids = append(ids, record.ID)
+		}
+		if strings.Join(ids, ",") != "exec-05,exec-04,exec-03" {
+			t.Errorf("Expected newest 3 executions, got: %v", ids)
 		}
 
-		if imported.Repositories[0].Ref != "v1.0.0" {
-			t.Errorf("Expected ref 'v1.0.0', got %q", imported.Repositories[0].Ref)
+		for _, id := range []string{"exec-01", "exec-02"} {
+			if FileExists(filepath.Join(ws.Path, ".workshed", executionsDirName, id)) {
+				t.Errorf("Expected execution directory %s to be deleted", id)
+			}
 		}
 	})
 
-	t.Run("generates new handle when not preserving", func(t *testing.T) {
-		root := t.TempDir()
-		mockGit := &git.MockGit{}
-		store, err := NewFSStore(root, mockGit)
+	t.Run("should remove records older than max age", func(t *testing.T) {
+		store, _, _ := CreateMockedTestStore(t)
+		store.SetRetention(RetentionPolicy{})
+		ws := createWorkspace(t, store)
+		recordN(t, store, ws.Handle, 2)
+
+		recordPath := filepath.Join(ws.Path, ".workshed", executionsDirName, "exec-01", "record.json")
+		record, err := store.GetExecution(context.Background(), ws.Handle, "exec-01")
 		if err != nil {
-			t.Fatalf("NewFSStore failed: %v", err)
+			t.Fatalf("GetExecution failed: %v", err)
+		}
+		record.Timestamp = time.Now().Add(-48 * time.Hour)
+		data, _ := json.Marshal(record)
+		if err := os.WriteFile(recordPath, data, 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
 		}
 
-		ctx := context.Background()
-		imported, err := store.ImportContext(ctx, ImportOptions{
-			Context: &WorkspaceContext{
-				Version: 1,
-				Handle:  "original-handle",
-				Purpose: "Imported workspace",
-				Repositories: []ContextRepo{
-					{Name: "repo", URL: "https://github.com/test/repo", Ref: "main"},
-				},
-			},
-			PreserveHandle: false,
-		})
+		removed, err := store.PruneExecutions(context.Background(), ws.Handle, &RetentionPolicy{MaxAge: 24 * time.Hour})
 		if err != nil {
-			t.Fatalf("ImportContext failed: %v", err)
+			t.Fatalf("PruneExecutions failed: %v", err)
 		}
-
-		if imported.Handle == "original-handle" {
-			t.Error("Expected new handle to be generated")
+		if len(removed) != 1 || removed[0] != "exec-01" {
+			t.Errorf("Expected exec-01 to be pruned, got: %v", removed)
+		}
+		if _, err := store.GetExecution(context.Background(), ws.Handle, "exec-02"); err != nil {
+			t.Errorf("Expected exec-02 to be retained: %v", err)
 		}
 	})
-}
 
-func TestCloneRepo_PropagatesDefaultBranchError(t *testing.T) {
-	t.Run("should return error when DefaultBranch fails for remote repo", func(t *testing.T) {
-		root := t.TempDir()
-		mockGit := &git.MockGit{}
-		mockGit.SetDefaultBranchErr(errors.New("network timeout"))
-		store, err := NewFSStore(root, mockGit)
+	t.Run("should list prunable records without deleting them", func(t *testing.T) {
+		store, _, _ := CreateMockedTestStore(t)
+		store.SetRetention(RetentionPolicy{})
+		ws := createWorkspace(t, store)
+		recordN(t, store, ws.Handle, 4)
+
+		ids, err := store.PrunableExecutions(context.Background(), ws.Handle, &RetentionPolicy{MaxExecutions: 2})
 		if err != nil {
-			t.Fatalf("NewFSStore failed: %v", err)
+			t.Fatalf("PrunableExecutions failed: %v", err)
+		}
+		if strings.Join(ids, ",") != "exec-02,exec-01" {
+			t.Errorf("Expected the two oldest executions, got: %v", ids)
 		}
 
-		ctx := context.Background()
-		ws, err := store.Create(ctx, CreateOptions{
-			Purpose: "Test workspace",
-			Repositories: []RepositoryOption{
-				{URL: "https://github.com/org/repo"},
-			},
-		})
-		if err == nil {
-			t.Fatalf("Expected error, got workspace %s", ws.Handle)
+		records, err := store.ListExecutions(context.Background(), ws.Handle, ListExecutionsOptions{})
+		if err != nil {
+			t.Fatalf("ListExecutions failed: %v", err)
 		}
-		if !strings.Contains(err.Error(), "detecting default branch") {
-			t.Errorf("Expected 'detecting default branch' error, got: %v", err)
+		if len(records) != 4 {
+			t.Errorf("Expected all 4 executions to remain, got %d", len(records))
 		}
 	})
 
-	t.Run("should return error when DefaultBranch returns empty for remote repo", func(t *testing.T) {
-		root := t.TempDir()
-		mockGit := &git.MockGit{}
-		mockGit.SetDefaultBranchResult("")
-		store, err := NewFSStore(root, mockGit)
+	t.Run("should not prune without limits", func(t *testing.T) {
+		store, _, _ := CreateMockedTestStore(t)
+		store.SetRetention(RetentionPolicy{})
+		ws := createWorkspace(t, store)
+		recordN(t, store, ws.Handle, 3)
+
+		removed, err := store.PruneExecutions(context.Background(), ws.Handle, nil)
 		if err != nil {
-			t.Fatalf("NewFSStore failed: %v", err)
+			t.Fatalf("PruneExecutions failed: %v", err)
 		}
-
-		ctx := context.Background()
-		ws, err := store.Create(ctx, CreateOptions{
-			Purpose: "Test workspace",
-			Repositories: []RepositoryOption{
-				{URL: "https://github.com/org/repo"},
-			},
-		})
-		if err == nil {
-			t.Fatalf("Expected error, got workspace %s", ws.Handle)
+		if len(removed) != 0 {
+			t.Errorf("Expected nothing pruned, got: %v", removed)
 		}
-		if !strings.Contains(err.Error(), "could not detect default branch") {
-			t.Errorf("Expected 'could not detect default branch' error, got: %v", err)
+	})
+
+	t.Run("should keep every record by default", func(t *testing.T) {
+		store, _, _ := CreateMockedTestStore(t)
+		ws := createWorkspace(t, store)
+		recordN(t, store, ws.Handle, 5)
+
+		records, err := store.ListExecutions(context.Background(), ws.Handle, ListExecutionsOptions{})
+		if err != nil {
+			t.Fatalf("ListExecutions failed: %v", err)
 		}
-		if !strings.Contains(err.Error(), "specify @branch explicitly") {
-			t.Errorf("Expected suggestion to specify @branch explicitly, got: %v", err)
+		if len(records) != 5 {
+			t.Errorf("Expected all 5 executions to be kept, got %d", len(records))
 		}
 	})
-}
+
+	t.Run("should apply a workspace's own policy over the store's", func(t *testing.T) {
+		store, _, _ := CreateMockedTestStore(t)
+		store.SetRetention(RetentionPolicy{MaxExecutions: 4})
+		ws := createWorkspace(t, store)
+		other := createWorkspace(t, store)
+
+		policy := &RetentionPolicy{MaxExecutions: 2, MaxAge: 48 * time.Hour}
+		if err := store.SetWorkspaceRetention(context.Background(), ws.Handle, policy); err != nil {
+			t.Fatalf("SetWorkspaceRetention failed: %v", err)
+		}
+		reloaded, err := store.Get(context.Background(), ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if reloaded.Retention == nil || *reloaded.Retention != *policy {
+			t.Errorf("Expected the policy to be stored, got %+v", reloaded.Retention)
+		}
+
+		recordN(t, store, ws.Handle, 5)
+		recordN(t, store, other.Handle, 5)
+		for handle, want := range map[string]int{ws.Handle: 2, other.Handle: 4} {
+			records, err := store.ListExecutions(context.Background(), handle, ListExecutionsOptions{})
+			if err != nil {
+				t.Fatalf("ListExecutions failed: %v", err)
+			}
+			if len(records) != want {
+				t.Errorf("Expected %d executions kept in %s, got %d", want, handle, len(records))
+			}
+		}
+
+		if err := store.SetWorkspaceRetention(context.Background(), ws.Handle, nil); err != nil {
+			t.Fatalf("SetWorkspaceRetention failed: %v", err)
+		}
+		if reloaded, _ := store.Get(context.Background(), ws.Handle); reloaded.Retention != nil {
+			t.Errorf("Expected the workspace policy to be cleared, got %+v", reloaded.Retention)
+		}
+	})
+}
+
+func TestExportContext(t *testing.T) {
+	t.Run("should export context for workspace", func(t *testing.T) {
+		root := t.TempDir()
+		store, err := NewFSStore(root)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		ctx := context.Background()
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Test workspace",
+			Repositories: []RepositoryOption{},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		context, err := store.ExportContext(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ExportContext failed: %v", err)
+		}
+		if context.Version != ContextVersion {
+			t.Errorf("Expected version %d, got: %d", ContextVersion, context.Version)
+		}
+		if context.Handle != ws.Handle {
+			t.Errorf("Expected handle %s, got: %s", ws.Handle, context.Handle)
+		}
+		if context.Purpose != ws.Purpose {
+			t.Errorf("Expected purpose %s, got: %s", ws.Purpose, context.Purpose)
+		}
+	})
+}
+
+func TestCaptureSize(t *testing.T) {
+	root := t.TempDir()
+	mockGit := &git.MockGit{}
+	mockGit.SetRevParseResult("abc123")
+	mockGit.SetCurrentBranchResult("main")
+	mockGit.SetStatusPorcelainResult("")
+	mockGit.SetDefaultBranchResult("main")
+	store, err := NewFSStore(root, mockGit)
+	if err != nil {
+		t.Fatalf("NewFSStore failed: %v", err)
+	}
+
+	ctx := context.Background()
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose: "Test workspace",
+		Repositories: []RepositoryOption{
+			{URL: "https://github.com/test/repo"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "Sized", Kind: CaptureKindCheckpoint})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+
+	captureDir := filepath.Join(ws.Path, ".workshed", capturesDirName, capture.ID)
+	stored := make([]byte, 4096)
+	if err := os.WriteFile(filepath.Join(captureDir, "snapshot.patch"), stored, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	metadata, err := os.Stat(filepath.Join(captureDir, "capture.json"))
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+
+	size, err := store.CaptureSize(ctx, ws.Handle, capture.ID)
+	if err != nil {
+		t.Fatalf("CaptureSize failed: %v", err)
+	}
+	if want := int64(len(stored)) + metadata.Size(); size != want {
+		t.Errorf("Expected size %d, got %d", want, size)
+	}
+
+	if _, err := store.CaptureSize(ctx, ws.Handle, "missing"); err == nil {
+		t.Error("Expected error for a missing capture")
+	}
+}
+
+func TestDeleteCapture(t *testing.T) {
+	store, _, mockGit := CreateMockedTestStore(t)
+	mockGit.SetRevParseResult("abc123")
+	mockGit.SetStatusPorcelainResult("")
+	mockGit.SetDefaultBranchResult("main")
+
+	ctx := context.Background()
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "Test workspace",
+		Repositories: []RepositoryOption{{URL: "https://github.com/test/repo"}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	kept, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "Kept", Kind: CaptureKindCheckpoint})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+	deleted, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "Deleted", Kind: CaptureKindCheckpoint})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+
+	t.Run("removes only the given capture", func(t *testing.T) {
+		if err := store.DeleteCapture(ctx, ws.Handle, deleted.ID); err != nil {
+			t.Fatalf("DeleteCapture failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(ws.Path, ".workshed", capturesDirName, deleted.ID)); !os.IsNotExist(err) {
+			t.Errorf("Expected the capture directory to be removed, got err=%v", err)
+		}
+		captures, err := store.ListCaptures(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ListCaptures failed: %v", err)
+		}
+		if len(captures) != 1 || captures[0].ID != kept.ID {
+			t.Errorf("Expected only %s to remain, got %+v", kept.ID, captures)
+		}
+	})
+
+	t.Run("reports a missing capture like GetCapture", func(t *testing.T) {
+		for _, id := range []string{deleted.ID, "missing", "..", "../" + kept.ID} {
+			err := store.DeleteCapture(ctx, ws.Handle, id)
+			if !errors.Is(err, errCaptureNotFound) {
+				t.Errorf("DeleteCapture(%q): expected errCaptureNotFound, got %v", id, err)
+			}
+		}
+		if _, err := store.GetCapture(ctx, ws.Handle, kept.ID); err != nil {
+			t.Errorf("Expected %s to be untouched: %v", kept.ID, err)
+		}
+	})
+}
+
+func TestCaptureKind(t *testing.T) {
+	t.Run("should set kind on capture", func(t *testing.T) {
+		root := t.TempDir()
+		mockGit := &git.MockGit{}
+		mockGit.SetRevParseResult("abc123")
+		mockGit.SetCurrentBranchResult("main")
+		mockGit.SetStatusPorcelainResult("")
+		mockGit.SetDefaultBranchResult("main")
+		store, err := NewFSStore(root, mockGit)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		ctx := context.Background()
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "Test workspace",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/test/repo"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{
+			Name: "Test capture",
+			Kind: CaptureKindCheckpoint,
+		})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		if capture.Kind != CaptureKindCheckpoint {
+			t.Errorf("Expected kind '%s', got: %s", CaptureKindCheckpoint, capture.Kind)
+		}
+	})
+
+	t.Run("should fail without intent", func(t *testing.T) {
+		root := t.TempDir()
+		mockGit := &git.MockGit{}
+		mockGit.SetRevParseResult("abc123")
+		mockGit.SetCurrentBranchResult("main")
+		mockGit.SetStatusPorcelainResult("")
+		mockGit.SetDefaultBranchResult("main")
+		store, err := NewFSStore(root, mockGit)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		ctx := context.Background()
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "Test workspace",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/test/repo"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		_, err = store.CaptureState(ctx, ws.Handle, CaptureOptions{
+			Name: "Test capture",
+		})
+		if err == nil {
+			t.Error("Expected error for capture without intent")
+		}
+		if !strings.Contains(err.Error(), "intent") {
+			t.Errorf("Expected 'intent' in error message, got: %v", err)
+		}
+	})
+
+	t.Run("should accept description as intent", func(t *testing.T) {
+		root := t.TempDir()
+		mockGit := &git.MockGit{}
+		mockGit.SetRevParseResult("abc123")
+		mockGit.SetCurrentBranchResult("main")
+		mockGit.SetStatusPorcelainResult("")
+		mockGit.SetDefaultBranchResult("main")
+		store, err := NewFSStore(root, mockGit)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		ctx := context.Background()
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "Test workspace",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/test/repo"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{
+			Name:        "Test capture",
+			Description: "This is why I captured this state",
+		})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		if capture.Metadata.Description != "This is why I captured this state" {
+			t.Errorf("Expected description, got: %s", capture.Metadata.Description)
+		}
+	})
+
+	t.Run("should accept tags as intent", func(t *testing.T) {
+		root := t.TempDir()
+		mockGit := &git.MockGit{}
+		mockGit.SetRevParseResult("abc123")
+		mockGit.SetCurrentBranchResult("main")
+		mockGit.SetStatusPorcelainResult("")
+		mockGit.SetDefaultBranchResult("main")
+		store, err := NewFSStore(root, mockGit)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		ctx := context.Background()
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "Test workspace",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/test/repo"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{
+			Name: "Test capture",
+			Tags: []string{"bugfix", "investigation"},
+		})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		if len(capture.Metadata.Tags) != 2 {
+			t.Errorf("Expected 2 tags, got: %d", len(capture.Metadata.Tags))
+		}
+	})
+}
+
+func TestPreflightApply(t *testing.T) {
+	t.Run("should detect missing repository in capture", func(t *testing.T) {
+		root := t.TempDir()
+		store, err := NewFSStore(root)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		ctx := context.Background()
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Test workspace",
+			Repositories: []RepositoryOption{},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		capture := &Capture{
+			ID:        "01H5V3ABCDEF",
+			Timestamp: time.Now(),
+			Handle:    ws.Handle,
+			Name:      "Test capture",
+			Kind:      "manual",
+			GitState: []GitRef{
+				{Repository: "nonexistent-repo", Commit: "abc123"},
+			},
+		}
+
+		capturePath := filepath.Join(ws.Path, ".workshed", "captures", capture.ID, "capture.json")
+		if err := os.MkdirAll(filepath.Dir(capturePath), 0755); err != nil {
+			t.Fatalf("Failed to create capture dir: %v", err)
+		}
+		data, _ := json.MarshalIndent(capture, "", "  ")
+		if err := os.WriteFile(capturePath, data, 0644); err != nil {
+			t.Fatalf("Failed to write capture: %v", err)
+		}
+
+		result, err := store.PreflightApply(ctx, ws.Handle, capture.ID)
+		if err != nil {
+			t.Fatalf("PreflightApply failed: %v", err)
+		}
+		if result.Valid {
+			t.Error("Expected preflight to be invalid for missing repository")
+		}
+		found := false
+		for _, e := range result.Errors {
+			if e.Reason == ReasonMissingRepository {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Expected missing repository error, got: %v", result.Errors)
+		}
+	})
+
+	t.Run("should detect commit missing from repository", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
+		ctx := context.Background()
+
+		repoDir := CreateLocalGitRepo(t, "repo", map[string]string{"README.md": "# Test"})
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Test workspace",
+			Repositories: []RepositoryOption{{URL: repoDir}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "snap", Kind: CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		capture.GitState[0].Commit = "0123456789abcdef0123456789abcdef01234567"
+
+		capturePath := filepath.Join(ws.Path, ".workshed", "captures", capture.ID, "capture.json")
+		data, _ := json.MarshalIndent(capture, "", "  ")
+		if err := os.WriteFile(capturePath, data, 0644); err != nil {
+			t.Fatalf("Failed to write capture: %v", err)
+		}
+
+		result, err := store.PreflightApply(ctx, ws.Handle, capture.ID)
+		if err != nil {
+			t.Fatalf("PreflightApply failed: %v", err)
+		}
+		if result.Valid {
+			t.Error("Expected preflight to be invalid for missing commit")
+		}
+		if len(result.Errors) != 1 || result.Errors[0].Reason != ReasonCommitMissing {
+			t.Errorf("Expected commit missing error, got: %v", result.Errors)
+		}
+	})
+
+	t.Run("should pass when captured commit is reachable", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
+		ctx := context.Background()
+
+		repoDir := CreateLocalGitRepo(t, "repo", map[string]string{"README.md": "# Test"})
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Test workspace",
+			Repositories: []RepositoryOption{{URL: repoDir}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "snap", Kind: CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+
+		result, err := store.PreflightApply(ctx, ws.Handle, capture.ID)
+		if err != nil {
+			t.Fatalf("PreflightApply failed: %v", err)
+		}
+		if !result.Valid {
+			t.Errorf("Expected preflight to pass, got: %v", result.Errors)
+		}
+	})
+}
+
+func TestExportContext_RefHandling(t *testing.T) {
+	t.Run("includes ref from stored repository", func(t *testing.T) {
+		root := t.TempDir()
+		mockGit := &git.MockGit{}
+		mockGit.SetCurrentBranchResult("main")
+		store, err := NewFSStore(root, mockGit)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		ctx := context.Background()
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "Test workspace",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/test/repo", Ref: "develop"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		context, err := store.ExportContext(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ExportContext failed: %v", err)
+		}
+
+		if len(context.Repositories) != 1 {
+			t.Fatalf("Expected 1 repository, got %d", len(context.Repositories))
+		}
+
+		if context.Repositories[0].Ref != "develop" {
+			t.Errorf("Expected ref 'develop', got %q", context.Repositories[0].Ref)
+		}
+	})
+
+	t.Run("detects ref for workspace without stored ref", func(t *testing.T) {
+		root := t.TempDir()
+		mockGit := &git.MockGit{}
+		mockGit.SetCurrentBranchResult("feature-branch")
+		mockGit.SetDefaultBranchResult("main")
+		store, err := NewFSStore(root, mockGit)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		ctx := context.Background()
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "Test workspace",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/test/repo"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		context, err := store.ExportContext(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ExportContext failed: %v", err)
+		}
+
+		if context.Repositories[0].Ref != "feature-branch" {
+			t.Errorf("Expected ref 'feature-branch', got %q", context.Repositories[0].Ref)
+		}
+	})
+}
+
+func TestValidateContext(t *testing.T) {
+	valid := func(version int) *WorkspaceContext {
+		return &WorkspaceContext{
+			Version:      version,
+			Purpose:      "Imported",
+			Repositories: []ContextRepo{{Name: "repo", URL: "https://github.com/test/repo"}},
+		}
+	}
+
+	t.Run("migrates an unversioned context", func(t *testing.T) {
+		c := valid(0)
+		if err := ValidateContext(c); err != nil {
+			t.Fatalf("ValidateContext failed: %v", err)
+		}
+		if c.Version != ContextVersion {
+			t.Errorf("Expected version %d after migration, got %d", ContextVersion, c.Version)
+		}
+	})
+
+	t.Run("every older version has a migration", func(t *testing.T) {
+		for v := 0; v < ContextVersion; v++ {
+			if _, ok := contextMigrations[v]; !ok {
+				t.Errorf("No migration from context version %d", v)
+			}
+		}
+	})
+
+	t.Run("rejects remotes that could be git options", func(t *testing.T) {
+		for _, remotes := range []map[string]string{
+			{"--mirror=fetch": "https://github.com/test/repo"},
+			{"upstream": "--upload-pack=touch /tmp/pwned"},
+		} {
+			c := valid(ContextVersion)
+			c.Repositories[0].Remotes = remotes
+			if err := ValidateContext(c); err == nil {
+				t.Errorf("Expected remotes %v to be rejected", remotes)
+			}
+		}
+	})
+
+	t.Run("rejects a newer version", func(t *testing.T) {
+		err := ValidateContext(valid(ContextVersion + 1))
+		if err == nil || !strings.Contains(err.Error(), "newer workshed") || !strings.Contains(err.Error(), "please upgrade") {
+			t.Errorf("Expected upgrade error, got %v", err)
+		}
+	})
+
+	t.Run("rejects a negative version", func(t *testing.T) {
+		if err := ValidateContext(valid(-1)); err == nil {
+			t.Error("Expected error for negative version")
+		}
+	})
+
+	t.Run("requires purpose and repositories", func(t *testing.T) {
+		c := valid(ContextVersion)
+		c.Purpose = ""
+		if err := ValidateContext(c); err == nil || err.Error() != "purpose is required" {
+			t.Errorf("Expected purpose error, got %v", err)
+		}
+		c = valid(ContextVersion)
+		c.Repositories[0].URL = ""
+		if err := ValidateContext(c); err == nil || !strings.Contains(err.Error(), "URL is required") {
+			t.Errorf("Expected URL error, got %v", err)
+		}
+	})
+
+	t.Run("import migrates an older export", func(t *testing.T) {
+		store, _, _ := CreateMockedTestStore(t)
+		c := valid(0)
+		c.Repositories[0].Ref = "main"
+		ws, err := store.ImportContext(context.Background(), ImportOptions{Context: c})
+		if err != nil {
+			t.Fatalf("ImportContext failed: %v", err)
+		}
+		if ws.Purpose != "Imported" || len(ws.Repositories) != 1 {
+			t.Errorf("Unexpected workspace: %+v", ws)
+		}
+	})
+
+	t.Run("import rejects a newer export before touching the store", func(t *testing.T) {
+		store, root, _ := CreateMockedTestStore(t)
+		_, err := store.ImportContext(context.Background(), ImportOptions{Context: valid(ContextVersion + 1)})
+		if err == nil || !strings.Contains(err.Error(), "please upgrade") {
+			t.Fatalf("Expected upgrade error, got %v", err)
+		}
+		entries, _ := os.ReadDir(root)
+		if len(entries) != 0 {
+			t.Errorf("Expected no workspaces, found %d entries", len(entries))
+		}
+	})
+}
+
+func TestImportContext_RefHandling(t *testing.T) {
+	t.Run("preserves ref when importing", func(t *testing.T) {
+		root := t.TempDir()
+		mockGit := &git.MockGit{}
+		store, err := NewFSStore(root, mockGit)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		ctx := context.Background()
+		imported, err := store.ImportContext(ctx, ImportOptions{
+			Context: &WorkspaceContext{
+				Version: 1,
+				Handle:  "test-workspace",
+				Purpose: "Imported workspace",
+				Repositories: []ContextRepo{
+					{Name: "repo", URL: "https://github.com/test/repo", Ref: "v1.0.0"},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("ImportContext failed: %v", err)
+		}
+
+		if len(imported.Repositories) != 1 {
+			t.Fatalf("Expected 1 repository, got %d", len(imported.Repositories))
+		}
+
+		if imported.Repositories[0].Ref != "v1.0.0" {
+			t.Errorf("Expected ref 'v1.0.0', got %q", imported.Repositories[0].Ref)
+		}
+	})
+
+	t.Run("generates new handle when not preserving", func(t *testing.T) {
+		root := t.TempDir()
+		mockGit := &git.MockGit{}
+		store, err := NewFSStore(root, mockGit)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		ctx := context.Background()
+		imported, err := store.ImportContext(ctx, ImportOptions{
+			Context: &WorkspaceContext{
+				Version: 1,
+				Handle:  "original-handle",
+				Purpose: "Imported workspace",
+				Repositories: []ContextRepo{
+					{Name: "repo", URL: "https://github.com/test/repo", Ref: "main"},
+				},
+			},
+			PreserveHandle: false,
+		})
+		if err != nil {
+			t.Fatalf("ImportContext failed: %v", err)
+		}
+
+		if imported.Handle == "original-handle" {
+			t.Error("Expected new handle to be generated")
+		}
+	})
+}
+
+// slowCloneGit records how many clones are in flight at once.
+type slowCloneGit struct {
+	*git.MockGit
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (g *slowCloneGit) Clone(ctx context.Context, url, dir string, opts git.CloneOptions) error {
+	g.mu.Lock()
+	g.inFlight++
+	if g.inFlight > g.maxInFlight {
+		g.maxInFlight = g.inFlight
+	}
+	g.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	g.mu.Lock()
+	g.inFlight--
+	g.mu.Unlock()
+	return g.MockGit.Clone(ctx, url, dir, opts)
+}
+
+func TestConcurrentClone(t *testing.T) {
+	urls := []string{
+		"https://github.com/test/alpha",
+		"https://github.com/test/bravo",
+		"https://github.com/test/charlie",
+		"https://github.com/test/delta",
+	}
+
+	assertRepos := func(t *testing.T, g *slowCloneGit, ws *Workspace) {
+		t.Helper()
+		if calls := g.GetCloneCalls(); len(calls) != len(urls) {
+			t.Fatalf("Expected %d clone calls, got %d", len(urls), len(calls))
+		}
+		if g.maxInFlight < 2 {
+			t.Errorf("Expected clones to overlap, max in flight was %d", g.maxInFlight)
+		}
+		if len(ws.Repositories) != len(urls) {
+			t.Fatalf("Expected %d repositories, got %d", len(urls), len(ws.Repositories))
+		}
+		for i, repo := range ws.Repositories {
+			if repo.URL != urls[i] {
+				t.Errorf("Repository %d: expected %s, got %s", i, urls[i], repo.URL)
+			}
+		}
+	}
+
+	t.Run("create clones in parallel and preserves order", func(t *testing.T) {
+		g := &slowCloneGit{MockGit: &git.MockGit{}}
+		store, err := NewFSStore(t.TempDir(), g)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		var repos []RepositoryOption
+		for _, url := range urls {
+			repos = append(repos, RepositoryOption{URL: url, Ref: "main"})
+		}
+		ws, err := store.Create(context.Background(), CreateOptions{
+			Purpose:      "Concurrent create",
+			Repositories: repos,
+			Concurrency:  len(urls),
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		assertRepos(t, g, ws)
+	})
+
+	t.Run("import clones in parallel and preserves order", func(t *testing.T) {
+		g := &slowCloneGit{MockGit: &git.MockGit{}}
+		store, err := NewFSStore(t.TempDir(), g)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		var repos []ContextRepo
+		for _, url := range urls {
+			repos = append(repos, ContextRepo{URL: url, Ref: "main"})
+		}
+		ws, err := store.ImportContext(context.Background(), ImportOptions{
+			Context: &WorkspaceContext{
+				Version:      1,
+				Purpose:      "Concurrent import",
+				Repositories: repos,
+			},
+			Concurrency: len(urls),
+		})
+		if err != nil {
+			t.Fatalf("ImportContext failed: %v", err)
+		}
+		assertRepos(t, g, ws)
+	})
+
+	t.Run("failed clone leaves no workspace behind", func(t *testing.T) {
+		g := &slowCloneGit{MockGit: &git.MockGit{}}
+		g.SetCloneErr(errors.New("clone failed"))
+		root := t.TempDir()
+		store, err := NewFSStore(root, g)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		var repos []ContextRepo
+		for _, url := range urls {
+			repos = append(repos, ContextRepo{URL: url, Ref: "main"})
+		}
+		_, err = store.ImportContext(context.Background(), ImportOptions{
+			Context: &WorkspaceContext{
+				Version:      1,
+				Purpose:      "Concurrent import",
+				Repositories: repos,
+			},
+			Concurrency: len(urls),
+		})
+		if err == nil {
+			t.Fatal("Expected ImportContext to fail")
+		}
+
+		workspaces, err := store.List(context.Background(), ListOptions{})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(workspaces) != 0 {
+			t.Errorf("Expected no workspaces after failed import, got %d", len(workspaces))
+		}
+	})
+}
+
+func TestCloneRepo_PropagatesDefaultBranchError(t *testing.T) {
+	t.Run("should return error when DefaultBranch fails for remote repo", func(t *testing.T) {
+		root := t.TempDir()
+		mockGit := &git.MockGit{}
+		mockGit.SetDefaultBranchErr(errors.New("network timeout"))
+		store, err := NewFSStore(root, mockGit)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		ctx := context.Background()
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "Test workspace",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/org/repo"},
+			},
+		})
+		if err == nil {
+			t.Fatalf("Expected error, got workspace %s", ws.Handle)
+		}
+		if !strings.Contains(err.Error(), "detecting default branch") {
+			t.Errorf("Expected 'detecting default branch' error, got: %v", err)
+		}
+	})
+
+	t.Run("should return error when DefaultBranch returns empty for remote repo", func(t *testing.T) {
+		root := t.TempDir()
+		mockGit := &git.MockGit{}
+		mockGit.SetDefaultBranchResult("")
+		store, err := NewFSStore(root, mockGit)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+
+		ctx := context.Background()
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "Test workspace",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/org/repo"},
+			},
+		})
+		if err == nil {
+			t.Fatalf("Expected error, got workspace %s", ws.Handle)
+		}
+		if !strings.Contains(err.Error(), "could not detect default branch") {
+			t.Errorf("Expected 'could not detect default branch' error, got: %v", err)
+		}
+		if !strings.Contains(err.Error(), "specify @branch explicitly") {
+			t.Errorf("Expected suggestion to specify @branch explicitly, got: %v", err)
+		}
+	})
+}
+
+func TestGitErrorDetailsPropagate(t *testing.T) {
+	details := "Cloning into 'repo'...\nfatal: could not read Username for 'https://github.com': terminal prompts disabled\n"
+	cloneErr := &git.GitError{
+		Operation: "clone",
+		Hint:      "authentication failed",
+		Details:   details,
+	}
+
+	t.Run("Create should surface git details", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		mockGit.SetCloneErr(cloneErr)
+
+		_, err := store.Create(context.Background(), CreateOptions{
+			Purpose:      "Test workspace",
+			Repositories: []RepositoryOption{{URL: "https://github.com/org/repo", Ref: "main"}},
+		})
+		if err == nil {
+			t.Fatal("Expected clone error")
+		}
+		if !strings.Contains(err.Error(), "terminal prompts disabled") {
+			t.Errorf("Expected git failure line in error, got: %v", err)
+		}
+		if git.Details(err) != details {
+			t.Errorf("Expected verbatim details through wrapping, got: %q", git.Details(err))
+		}
+	})
+
+	t.Run("AddRepositories should surface git details", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		ws, err := store.Create(context.Background(), CreateOptions{
+			Purpose:      "Test workspace",
+			Repositories: []RepositoryOption{{URL: "https://github.com/org/api", Ref: "main"}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		mockGit.SetCloneErr(cloneErr)
+
+		err = store.AddRepository(context.Background(), ws.Handle, RepositoryOption{URL: "https://github.com/org/repo", Ref: "main"}, "")
+		if err == nil {
+			t.Fatal("Expected clone error")
+		}
+		if !strings.Contains(err.Error(), "terminal prompts disabled") {
+			t.Errorf("Expected git failure line in error, got: %v", err)
+		}
+		if git.Details(err) != details {
+			t.Errorf("Expected verbatim details through wrapping, got: %q", git.Details(err))
+		}
+	})
+}
 
 func TestSelectGitProtocol(t *testing.T) {
 	origAuthSock := os.Getenv("SSH_AUTH_SOCK")
@@ -1061,102 +2256,3684 @@ func TestSelectGitProtocol(t *testing.T) {
 		_ = os.Setenv(envGitProtocol, origProtocol)
 	}()
 
-	t.Run("should convert github.com to https by default when no SSH", func(t *testing.T) {
-		_ = os.Unsetenv("SSH_AUTH_SOCK")
-		_ = os.Unsetenv(envGitProtocol)
-		url := selectGitProtocol("github.com/user/repo")
-		if url != "https://github.com/user/repo" {
-			t.Errorf("Expected https URL, got: %s", url)
+	t.Run("should convert github.com to https by default when no SSH", func(t *testing.T) {
+		_ = os.Unsetenv("SSH_AUTH_SOCK")
+		_ = os.Unsetenv(envGitProtocol)
+		url := selectGitProtocol("github.com/user/repo")
+		if url != "https://github.com/user/repo" {
+			t.Errorf("Expected https URL, got: %s", url)
+		}
+	})
+
+	t.Run("should convert github.com to ssh when SSH_AUTH_SOCK set", func(t *testing.T) {
+		t.Skip("flaky: depends on .ssh directory contents")
+		_ = os.Setenv("SSH_AUTH_SOCK", "/tmp/ssh-agent.sock")
+		_ = os.Unsetenv(envGitProtocol)
+		url := selectGitProtocol("github.com/user/repo")
+		if url != "git@github.com:user/repo" {
+			t.Errorf("Expected SSH URL, got: %s", url)
+		}
+	})
+
+	t.Run("should respect WORKSHED_GIT_PROTOCOL=ssh", func(t *testing.T) {
+		_ = os.Unsetenv("SSH_AUTH_SOCK")
+		_ = os.Setenv(envGitProtocol, "ssh")
+		url := selectGitProtocol("github.com/user/repo")
+		if url != "git@github.com:user/repo" {
+			t.Errorf("Expected SSH URL, got: %s", url)
+		}
+	})
+
+	t.Run("should respect WORKSHED_GIT_PROTOCOL=https", func(t *testing.T) {
+		_ = os.Setenv("SSH_AUTH_SOCK", "/tmp/ssh-agent.sock")
+		_ = os.Setenv(envGitProtocol, "https")
+		url := selectGitProtocol("github.com/user/repo")
+		if url != "https://github.com/user/repo" {
+			t.Errorf("Expected https URL, got: %s", url)
+		}
+	})
+
+	t.Run("should pass through non-github.com URLs unchanged", func(t *testing.T) {
+		_ = os.Setenv("SSH_AUTH_SOCK", "/tmp/ssh-agent.sock")
+		_ = os.Unsetenv(envGitProtocol)
+		tests := []string{
+			"https://gitlab.com/user/repo",
+			"git@gitlab.com:user/repo",
+			"/path/to/local/repo",
+			"./relative/repo",
+		}
+		for _, input := range tests {
+			url := selectGitProtocol(input)
+			if url != input {
+				t.Errorf("Expected URL unchanged for %q, got: %s", input, url)
+			}
+		}
+	})
+
+	t.Run("should preserve ref and depth in URL", func(t *testing.T) {
+		_ = os.Unsetenv("SSH_AUTH_SOCK")
+		_ = os.Unsetenv(envGitProtocol)
+		url := selectGitProtocol("github.com/user/repo")
+		if url != "https://github.com/user/repo" {
+			t.Errorf("Expected https URL, got: %s", url)
+		}
+	})
+}
+
+func TestSshAgentAvailable(t *testing.T) {
+	origAuthSock := os.Getenv("SSH_AUTH_SOCK")
+	origHome := os.Getenv("HOME")
+	defer func() {
+		_ = os.Setenv("SSH_AUTH_SOCK", origAuthSock)
+		if origHome != "" {
+			_ = os.Setenv("HOME", origHome)
+		} else {
+			_ = os.Unsetenv("HOME")
+		}
+	}()
+
+	t.Run("should return false when SSH_AUTH_SOCK not set", func(t *testing.T) {
+		_ = os.Unsetenv("SSH_AUTH_SOCK")
+		if sshAgentAvailable() {
+			t.Error("Expected false when SSH_AUTH_SOCK not set")
+		}
+	})
+
+	t.Run("should return false when HOME not set", func(t *testing.T) {
+		_ = os.Setenv("SSH_AUTH_SOCK", "/tmp/ssh-agent.sock")
+		_ = os.Unsetenv("HOME")
+		if sshAgentAvailable() {
+			t.Error("Expected false when HOME not set")
+		}
+	})
+
+	t.Run("should return false when .ssh directory does not exist", func(t *testing.T) {
+		_ = os.Setenv("SSH_AUTH_SOCK", "/tmp/ssh-agent.sock")
+		_ = os.Setenv("HOME", "/nonexistent-home")
+		if sshAgentAvailable() {
+			t.Error("Expected false when .ssh directory does not exist")
+		}
+	})
+}
+
+func TestCompressedCaptures(t *testing.T) {
+	ctx := context.Background()
+	store, _ := CreateTestStore(t)
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "Compressed captures",
+		Repositories: []RepositoryOption{{URL: CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"}), Ref: "main"}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	repoDir := filepath.Join(ws.Path, "api")
+	for _, args := range [][]string{{"config", "user.email", "test@example.com"}, {"config", "user.name", "Test User"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	plain, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "plain", Kind: CaptureKindManual, Tags: []string{"a"}})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+	store.SetCompressCaptures(true)
+	compressed, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "compressed", Kind: CaptureKindManual, Tags: []string{"a"}})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+	store.SetCompressCaptures(false)
+
+	capturesDir := filepath.Join(ws.Path, ".workshed", capturesDirName)
+	t.Run("stores a single archive", func(t *testing.T) {
+		entries, err := os.ReadDir(filepath.Join(capturesDir, compressed.ID))
+		if err != nil {
+			t.Fatalf("ReadDir failed: %v", err)
+		}
+		if len(entries) != 1 || entries[0].Name() != captureArchiveName {
+			t.Errorf("Expected only %s, got %v", captureArchiveName, entries)
+		}
+	})
+
+	t.Run("lists and reads like an uncompressed capture", func(t *testing.T) {
+		captures, err := store.ListCaptures(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ListCaptures failed: %v", err)
+		}
+		if len(captures) != 2 {
+			t.Fatalf("Expected 2 captures, got %d", len(captures))
+		}
+
+		got, err := store.GetCapture(ctx, ws.Handle, "compressed")
+		if err != nil {
+			t.Fatalf("GetCapture failed: %v", err)
+		}
+		if got.ID != compressed.ID || !got.Timestamp.Equal(compressed.Timestamp) || !slices.Equal(got.Metadata.Tags, []string{"a"}) {
+			t.Errorf("Unexpected capture: %+v", got)
+		}
+		if len(got.GitState) != 1 || got.GitState[0] != plain.GitState[0] {
+			t.Errorf("Expected the same git state as the plain capture, got %+v and %+v", got.GitState, plain.GitState)
+		}
+	})
+
+	t.Run("applies like an uncompressed capture", func(t *testing.T) {
+		if err := AddGitCommit(repoDir, "Later", map[string]string{"later.txt": "later"}); err != nil {
+			t.Fatalf("AddGitCommit failed: %v", err)
+		}
+		if err := store.ApplyCapture(ctx, ws.Handle, compressed.ID); err != nil {
+			t.Fatalf("ApplyCapture failed: %v", err)
+		}
+		head, err := exec.Command("git", "-C", repoDir, "rev-parse", "HEAD").Output()
+		if err != nil {
+			t.Fatalf("rev-parse failed: %v", err)
+		}
+		if strings.TrimSpace(string(head)) != compressed.GitState[0].Commit {
+			t.Errorf("Expected HEAD at %s, got %s", compressed.GitState[0].Commit, head)
+		}
+	})
+
+	t.Run("renaming a repository keeps the archive format", func(t *testing.T) {
+		if err := store.RenameRepository(ctx, ws.Handle, "api", "api-v2"); err != nil {
+			t.Fatalf("RenameRepository failed: %v", err)
+		}
+		if !captureIsCompressed(filepath.Join(capturesDir, compressed.ID)) || captureIsCompressed(filepath.Join(capturesDir, plain.ID)) {
+			t.Error("Expected each capture to keep its format")
+		}
+		got, err := store.GetCapture(ctx, ws.Handle, compressed.ID)
+		if err != nil {
+			t.Fatalf("GetCapture failed: %v", err)
+		}
+		if got.GitState[0].Repository != "api-v2" {
+			t.Errorf("Expected renamed repository in capture, got %q", got.GitState[0].Repository)
+		}
+	})
+
+	t.Run("a corrupt archive is reported as orphaned", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(capturesDir, compressed.ID, captureArchiveName), []byte("not gzip"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		result, err := store.VerifyCapture(ctx, ws.Handle, compressed.ID)
+		if err != nil {
+			t.Fatalf("VerifyCapture failed: %v", err)
+		}
+		if result.Valid || !result.Orphaned {
+			t.Errorf("Expected an orphaned capture, got %+v", result)
+		}
+	})
+}
+
+type recordingSink struct {
+	events []StoreEvent
+	err    error
+}
+
+func (r *recordingSink) Emit(ctx context.Context, event StoreEvent) error {
+	r.events = append(r.events, event)
+	return r.err
+}
+
+func TestEventSink(t *testing.T) {
+	t.Run("should emit lifecycle events with payloads", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
+		sink := &recordingSink{}
+		store.SetEventSink(sink)
+		ctx := context.Background()
+
+		ws, err := store.Create(ctx, CreateOptions{Purpose: "Hooked workspace", Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "start", Kind: CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		if err := store.ApplyCapture(ctx, ws.Handle, capture.ID); err != nil {
+			t.Fatalf("ApplyCapture failed: %v", err)
+		}
+		if err := store.Remove(ctx, ws.Handle); err != nil {
+			t.Fatalf("Remove failed: %v", err)
+		}
+
+		want := []StoreEvent{
+			{Type: EventWorkspaceCreated, Handle: ws.Handle, Purpose: "Hooked workspace", Path: ws.Path},
+			{Type: EventCaptureCreated, Handle: ws.Handle, Purpose: "Hooked workspace", Path: ws.Path, CaptureID: capture.ID, Capture: "start"},
+			{Type: EventCaptureApplied, Handle: ws.Handle, Purpose: "Hooked workspace", Path: ws.Path, CaptureID: capture.ID, Capture: "start"},
+			{Type: EventWorkspaceRemoved, Handle: ws.Handle, Purpose: "Hooked workspace", Path: ws.Path},
+		}
+		if len(sink.events) != len(want) {
+			t.Fatalf("Expected %d events, got %d: %+v", len(want), len(sink.events), sink.events)
+		}
+		for i, got := range sink.events {
+			if got.Timestamp.IsZero() {
+				t.Errorf("Event %d has no timestamp", i)
+			}
+			got.Timestamp = time.Time{}
+			if got != want[i] {
+				t.Errorf("Event %d = %+v, want %+v", i, got, want[i])
+			}
+		}
+	})
+
+	t.Run("should not fail operations when the sink errors", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
+		sink := &recordingSink{err: errors.New("sink unavailable")}
+		store.SetEventSink(sink)
+
+		ws, err := store.Create(context.Background(), CreateOptions{Purpose: "Failing sink", Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create should succeed despite sink error: %v", err)
+		}
+		if err := store.Remove(context.Background(), ws.Handle); err != nil {
+			t.Fatalf("Remove should succeed despite sink error: %v", err)
+		}
+		if len(sink.events) != 2 {
+			t.Errorf("Expected 2 events, got %d", len(sink.events))
+		}
+	})
+
+	t.Run("should not emit events for failed operations", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
+		sink := &recordingSink{}
+		store.SetEventSink(sink)
+
+		if err := store.Remove(context.Background(), "missing-handle"); err == nil {
+			t.Fatal("Expected error removing missing workspace")
+		}
+		if len(sink.events) != 0 {
+			t.Errorf("Expected no events, got: %+v", sink.events)
+		}
+	})
+
+	t.Run("webhook sink should post the JSON payload", func(t *testing.T) {
+		var received StoreEvent
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				t.Errorf("Expected POST, got %s", r.Method)
+			}
+			if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected application/json, got %s", ct)
+			}
+			if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+				t.Errorf("Decoding payload failed: %v", err)
+			}
+		}))
+		defer server.Close()
+
+		event := StoreEvent{Type: EventWorkspaceCreated, Handle: "calm-fox", Timestamp: time.Now().UTC()}
+		if err := (WebhookSink{URL: server.URL}).Emit(context.Background(), event); err != nil {
+			t.Fatalf("Emit failed: %v", err)
+		}
+		if received.Type != EventWorkspaceCreated || received.Handle != "calm-fox" {
+			t.Errorf("Unexpected payload: %+v", received)
+		}
+	})
+
+	t.Run("webhook sink should report error status codes", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		if err := (WebhookSink{URL: server.URL}).Emit(context.Background(), StoreEvent{Type: EventWorkspaceRemoved}); err == nil {
+			t.Error("Expected error for 500 response")
+		}
+	})
+
+	t.Run("command sink should pipe the payload to the command", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "event.json")
+		sink := CommandSink{Command: fmt.Sprintf(`cat > %q && echo "$WORKSHED_EVENT" >> %q`, out, out)}
+
+		if err := sink.Emit(context.Background(), StoreEvent{Type: EventCaptureCreated, Handle: "calm-fox"}); err != nil {
+			t.Fatalf("Emit failed: %v", err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if !strings.Contains(string(data), `"handle":"calm-fox"`) || !strings.HasSuffix(strings.TrimSpace(string(data)), EventCaptureCreated) {
+			t.Errorf("Unexpected hook output: %s", data)
+		}
+	})
+
+	t.Run("command sink should report failing commands", func(t *testing.T) {
+		if err := (CommandSink{Command: "exit 3"}).Emit(context.Background(), StoreEvent{Type: EventWorkspaceCreated}); err == nil {
+			t.Error("Expected error for failing hook command")
+		}
+	})
+}
+
+// failingCheckoutGit runs real git but fails the first checkout in failDir.
+type failingCheckoutGit struct {
+	git.RealGit
+	failDir string
+	failed  bool
+}
+
+func (g *failingCheckoutGit) Checkout(ctx context.Context, dir, ref string) error {
+	if dir == g.failDir && !g.failed {
+		g.failed = true
+		return errors.New("simulated checkout failure")
+	}
+	return g.RealGit.Checkout(ctx, dir, ref)
+}
+
+func TestContinueApply(t *testing.T) {
+	setup := func(t *testing.T) (*FSStore, *failingCheckoutGit, *Workspace, *Capture) {
+		g := &failingCheckoutGit{}
+		store, err := NewFSStore(t.TempDir(), g)
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+		ctx := context.Background()
+
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "Continue apply",
+			Repositories: []RepositoryOption{
+				{URL: CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"}), Ref: "main"},
+				{URL: CreateLocalGitRepo(t, "web", map[string]string{"README.md": "# Web"}), Ref: "main"},
+				{URL: CreateLocalGitRepo(t, "docs", map[string]string{"README.md": "# Docs"}), Ref: "main"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "baseline", Kind: CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+
+		for _, name := range []string{"api", "web", "docs"} {
+			repoDir := filepath.Join(ws.Path, name)
+			for _, args := range [][]string{{"config", "user.email", "test@example.com"}, {"config", "user.name", "Test User"}} {
+				cmd := exec.Command("git", args...)
+				cmd.Dir = repoDir
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("git %v failed: %v\n%s", args, err, out)
+				}
+			}
+			if err := AddGitCommit(repoDir, "Advance", map[string]string{"CHANGES.md": "advanced"}); err != nil {
+				t.Fatalf("AddGitCommit failed: %v", err)
+			}
+		}
+
+		g.failDir = filepath.Join(ws.Path, "web")
+		return store, g, ws, capture
+	}
+
+	headOf := func(t *testing.T, dir string) string {
+		head, err := git.RealGit{}.RevParse(context.Background(), dir, "HEAD")
+		if err != nil {
+			t.Fatalf("RevParse failed: %v", err)
+		}
+		return head
+	}
+
+	t.Run("should finish the repositories left by a failed apply", func(t *testing.T) {
+		store, _, ws, capture := setup(t)
+		ctx := context.Background()
+
+		if err := store.ApplyCapture(ctx, ws.Handle, capture.ID); err == nil {
+			t.Fatal("Expected ApplyCapture to fail midway")
+		}
+
+		progress, err := store.readApplyProgress(ws, capture.ID)
+		if err != nil || progress == nil {
+			t.Fatalf("Expected recorded apply progress, got %+v (err %v)", progress, err)
+		}
+		if len(progress.Applied) != 1 || progress.Applied[0] != "api" || progress.Failed != "web" {
+			t.Errorf("Unexpected progress: %+v", progress)
+		}
+
+		applied, err := store.ContinueApply(ctx, ws.Handle, capture.ID)
+		if err != nil {
+			t.Fatalf("ContinueApply failed: %v", err)
+		}
+		if strings.Join(applied, ",") != "web,docs" {
+			t.Errorf("Expected web and docs to be applied, got: %v", applied)
+		}
+
+		for _, ref := range capture.GitState {
+			if head := headOf(t, filepath.Join(ws.Path, ref.Repository)); head != ref.Commit {
+				t.Errorf("%s HEAD = %s, want %s", ref.Repository, head, ref.Commit)
+			}
+		}
+
+		if FileExists(applyProgressPath(ws, capture.ID)) {
+			t.Error("Apply progress should be cleared after a complete apply")
+		}
+	})
+
+	t.Run("should apply nothing when everything already matches", func(t *testing.T) {
+		store, g, ws, capture := setup(t)
+		g.failDir = ""
+		ctx := context.Background()
+
+		if err := store.ApplyCapture(ctx, ws.Handle, capture.ID); err != nil {
+			t.Fatalf("ApplyCapture failed: %v", err)
+		}
+
+		applied, err := store.ContinueApply(ctx, ws.Handle, capture.ID)
+		if err != nil {
+			t.Fatalf("ContinueApply failed: %v", err)
+		}
+		if len(applied) != 0 {
+			t.Errorf("Expected nothing to apply, got: %v", applied)
+		}
+	})
+
+	t.Run("should preflight only the remaining repositories", func(t *testing.T) {
+		store, _, ws, capture := setup(t)
+		ctx := context.Background()
+
+		if err := store.ApplyCapture(ctx, ws.Handle, capture.ID); err == nil {
+			t.Fatal("Expected ApplyCapture to fail midway")
+		}
+		if err := os.WriteFile(filepath.Join(ws.Path, "docs", "scratch.txt"), []byte("dirty"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		if _, err := store.ContinueApply(ctx, ws.Handle, capture.ID); err == nil {
+			t.Error("Expected ContinueApply to be blocked by the dirty remaining repository")
+		}
+	})
+}
+
+func TestPlanApply(t *testing.T) {
+	store, _ := CreateTestStore(t)
+	ctx := context.Background()
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose: "Plan apply",
+		Repositories: []RepositoryOption{
+			{URL: CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"}), Ref: "main"},
+			{URL: CreateLocalGitRepo(t, "web", map[string]string{"README.md": "# Web"}), Ref: "main"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "baseline", Kind: CaptureKindManual})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+
+	apiDir := filepath.Join(ws.Path, "api")
+	for _, args := range [][]string{{"config", "user.email", "test@example.com"}, {"config", "user.name", "Test User"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = apiDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	if err := AddGitCommit(apiDir, "Advance", map[string]string{"CHANGES.md": "advanced"}); err != nil {
+		t.Fatalf("AddGitCommit failed: %v", err)
+	}
+	advanced, err := git.RealGit{}.RevParse(ctx, apiDir, "HEAD")
+	if err != nil {
+		t.Fatalf("RevParse failed: %v", err)
+	}
+
+	changes, err := store.PlanApply(ctx, ws.Handle, capture.ID)
+	if err != nil {
+		t.Fatalf("PlanApply failed: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", changes)
+	}
+	for i, ref := range capture.GitState {
+		if changes[i].Repository != ref.Repository || changes[i].To != ref.Commit {
+			t.Errorf("Change %d = %+v, want %s to %s", i, changes[i], ref.Repository, ref.Commit)
+		}
+		want := ref.Commit
+		if ref.Repository == "api" {
+			want = advanced
+		}
+		if changes[i].From != want {
+			t.Errorf("Expected %s to move from %s, got %s", ref.Repository, want, changes[i].From)
+		}
+	}
+
+	if head, _ := (git.RealGit{}).RevParse(ctx, apiDir, "HEAD"); head != advanced {
+		t.Errorf("PlanApply moved HEAD to %s", head)
+	}
+}
+
+func TestWorkspaceEnv(t *testing.T) {
+	newWorkspace := func(t *testing.T) (*FSStore, *Workspace) {
+		store, _ := CreateTestStore(t)
+		ws, err := store.Create(context.Background(), CreateOptions{
+			Purpose:      "Env test",
+			Repositories: []RepositoryOption{{URL: CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"}), Ref: "main"}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		return store, ws
+	}
+
+	t.Run("should round trip set and unset", func(t *testing.T) {
+		store, ws := newWorkspace(t)
+		ctx := context.Background()
+
+		if err := store.SetWorkspaceEnv(ctx, ws.Handle, map[string]string{"API_URL": "http://localhost:8080", "DEBUG": "1"}); err != nil {
+			t.Fatalf("SetWorkspaceEnv failed: %v", err)
+		}
+		if err := store.UnsetWorkspaceEnv(ctx, ws.Handle, []string{"DEBUG"}); err != nil {
+			t.Fatalf("UnsetWorkspaceEnv failed: %v", err)
+		}
+
+		vars, err := store.WorkspaceEnv(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("WorkspaceEnv failed: %v", err)
+		}
+		if len(vars) != 1 || vars["API_URL"] != "http://localhost:8080" {
+			t.Errorf("Unexpected env: %v", vars)
+		}
+
+		if err := store.UnsetWorkspaceEnv(ctx, ws.Handle, []string{"MISSING"}); err == nil {
+			t.Error("Expected error unsetting a missing variable")
+		}
+	})
+
+	t.Run("should parse comments, blank lines and values containing =", func(t *testing.T) {
+		store, ws := newWorkspace(t)
+		content := "# service URLs\n\nAPI_URL=http://localhost:8080/?a=b\n  NAME = spaced\n"
+		if err := os.MkdirAll(filepath.Join(ws.Path, ".workshed"), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(ws.Path, ".workshed", "env"), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		vars, err := store.WorkspaceEnv(context.Background(), ws.Handle)
+		if err != nil {
+			t.Fatalf("WorkspaceEnv failed: %v", err)
+		}
+		if vars["API_URL"] != "http://localhost:8080/?a=b" || vars["NAME"] != "spaced" {
+			t.Errorf("Unexpected env: %q", vars)
+		}
+	})
+
+	t.Run("should reject malformed lines", func(t *testing.T) {
+		store, ws := newWorkspace(t)
+		if err := os.MkdirAll(filepath.Join(ws.Path, ".workshed"), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(ws.Path, ".workshed", "env"), []byte("NOT_AN_ASSIGNMENT\n"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if _, err := store.WorkspaceEnv(context.Background(), ws.Handle); err == nil {
+			t.Error("Expected error for malformed env file")
+		}
+	})
+
+	t.Run("should pass the env file to exec with explicit overrides winning", func(t *testing.T) {
+		store, ws := newWorkspace(t)
+		ctx := context.Background()
+		t.Setenv("WORKSHED_TEST_PROCESS", "process")
+		t.Setenv("WORKSHED_TEST_SHARED", "process")
+
+		if err := store.SetWorkspaceEnv(ctx, ws.Handle, map[string]string{
+			"WORKSHED_TEST_FILE":   "file",
+			"WORKSHED_TEST_SHARED": "file",
+			"WORKSHED_TEST_FLAG":   "file",
+		}); err != nil {
+			t.Fatalf("SetWorkspaceEnv failed: %v", err)
+		}
+
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{
+			Command: []string{"sh", "-c", `echo "$WORKSHED_TEST_PROCESS $WORKSHED_TEST_FILE $WORKSHED_TEST_SHARED $WORKSHED_TEST_FLAG"`},
+			Env:     []string{"WORKSHED_TEST_FLAG=flag"},
+		})
+		if err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if got := strings.TrimSpace(string(results[0].Output)); got != "process file file flag" {
+			t.Errorf("Exec env = %q, want %q", got, "process file file flag")
+		}
+	})
+}
+
+func TestUnshallowRepository(t *testing.T) {
+	createShallowWorkspace := func(t *testing.T, store *FSStore) *Workspace {
+		ws, err := store.Create(context.Background(), CreateOptions{
+			Purpose: "Shallow workspace",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/org/api", Ref: "main", Depth: 1},
+				{URL: "https://github.com/org/web", Ref: "main"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		return ws
+	}
+
+	t.Run("should unshallow and clear the depth metadata", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		ws := createShallowWorkspace(t, store)
+
+		if err := store.UnshallowRepository(context.Background(), ws.Handle, "api"); err != nil {
+			t.Fatalf("UnshallowRepository failed: %v", err)
+		}
+
+		calls := mockGit.GetUnshallowCalls()
+		if len(calls) != 1 || calls[0].Dir != filepath.Join(ws.Path, "api") {
+			t.Fatalf("Expected one unshallow call for api, got: %+v", calls)
+		}
+
+		updated, err := store.Get(context.Background(), ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if repo := updated.GetRepositoryByName("api"); repo == nil || repo.Depth != 0 {
+			t.Errorf("Expected api depth to be cleared, got: %+v", repo)
+		}
+	})
+
+	t.Run("should persist depth for shallow clones", func(t *testing.T) {
+		store, _, _ := CreateMockedTestStore(t)
+		ws := createShallowWorkspace(t, store)
+
+		loaded, err := store.Get(context.Background(), ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if repo := loaded.GetRepositoryByName("api"); repo == nil || repo.Depth != 1 {
+			t.Errorf("Expected api depth 1 in metadata, got: %+v", repo)
+		}
+	})
+
+	t.Run("should do nothing for repositories that are not shallow", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		ws := createShallowWorkspace(t, store)
+
+		if err := store.UnshallowRepository(context.Background(), ws.Handle, "web"); err != nil {
+			t.Errorf("Expected a full clone to be left alone, got: %v", err)
+		}
+		if len(mockGit.GetUnshallowCalls()) != 0 {
+			t.Error("Unshallow should not be invoked for a full clone")
+		}
+	})
+
+	t.Run("should keep depth when unshallow fails", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		ws := createShallowWorkspace(t, store)
+		mockGit.SetUnshallowErr(errors.New("network down"))
+
+		if err := store.UnshallowRepository(context.Background(), ws.Handle, "api"); err == nil {
+			t.Fatal("Expected unshallow error")
+		}
+		loaded, _ := store.Get(context.Background(), ws.Handle)
+		if repo := loaded.GetRepositoryByName("api"); repo == nil || repo.Depth != 1 {
+			t.Errorf("Depth should be kept after a failed unshallow, got: %+v", repo)
+		}
+	})
+
+	t.Run("preflight should suggest unshallow for missing commits", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		ws := createShallowWorkspace(t, store)
+		CreateFakeRepo(t, ws.Path, "api")
+		CreateFakeRepo(t, ws.Path, "web")
+		mockGit.SetRevParseResult("abc123")
+
+		capture, err := store.CaptureState(context.Background(), ws.Handle, CaptureOptions{Name: "old", Kind: CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		mockGit.SetCommitMissing("abc123")
+
+		result, err := store.PreflightApply(context.Background(), ws.Handle, capture.ID)
+		if err != nil {
+			t.Fatalf("PreflightApply failed: %v", err)
+		}
+
+		reasons := map[string]string{}
+		details := map[string]string{}
+		for _, e := range result.Errors {
+			reasons[e.Repository] = e.Reason
+			details[e.Repository] = e.Details
+		}
+		if reasons["api"] != ReasonShallowCommit {
+			t.Errorf("Expected shallow reason for api, got: %q", reasons["api"])
+		}
+		if want := "workshed repos unshallow " + ws.Handle + " --repo api"; !strings.Contains(details["api"], want) {
+			t.Errorf("Expected hint %q, got: %q", want, details["api"])
+		}
+		if reasons["web"] != ReasonCommitMissing {
+			t.Errorf("Expected commit missing reason for web, got: %q", reasons["web"])
+		}
+	})
+}
+
+func TestCheckHealth(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*FSStore, *Workspace, string) {
+		t.Helper()
+		store, _ := CreateTestStore(t)
+		repo := CreateLocalGitRepo(t, "healthrepo", map[string]string{"README.md": "# Health"})
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Health test",
+			Repositories: []RepositoryOption{{URL: repo, Ref: "main"}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		return store, ws, filepath.Join(ws.Path, ws.Repositories[0].Name)
+	}
+
+	hasIssue := func(report *HealthReport, kind string) bool {
+		for _, issue := range report.Issues {
+			if issue.Kind == kind {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("clean workspace is healthy", func(t *testing.T) {
+		store, ws, _ := setup(t)
+		report, err := store.CheckHealth(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("CheckHealth failed: %v", err)
+		}
+		if !report.Healthy {
+			t.Errorf("Expected healthy, got issues: %+v", report.Issues)
+		}
+	})
+
+	t.Run("reports ref drift", func(t *testing.T) {
+		store, ws, repoDir := setup(t)
+		cmd := exec.Command("git", "checkout", "-q", "-b", "feature", "--no-track")
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git checkout failed: %v\n%s", err, out)
+		}
+		for _, kv := range [][]string{{"user.email", "test@test.com"}, {"user.name", "Test"}} {
+			cmd := exec.Command("git", "config", kv[0], kv[1])
+			cmd.Dir = repoDir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git config failed: %v\n%s", err, out)
+			}
+		}
+		if err := AddGitCommit(repoDir, "Diverge", map[string]string{"feature.txt": "feature"}); err != nil {
+			t.Fatalf("AddGitCommit failed: %v", err)
+		}
+
+		report, err := store.CheckHealth(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("CheckHealth failed: %v", err)
+		}
+		if !hasIssue(report, HealthRefDrift) {
+			t.Errorf("Expected ref drift, got: %+v", report.Issues)
+		}
+	})
+
+	t.Run("reports missing repository", func(t *testing.T) {
+		store, ws, repoDir := setup(t)
+		if err := os.RemoveAll(repoDir); err != nil {
+			t.Fatalf("RemoveAll failed: %v", err)
+		}
+
+		report, err := store.CheckHealth(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("CheckHealth failed: %v", err)
+		}
+		if report.Healthy || !hasIssue(report, HealthMissingRepository) {
+			t.Errorf("Expected missing repository, got: %+v", report.Issues)
+		}
+		if report.Issues[0].Severity != HealthSeverityError || report.CountAtLeast(HealthSeverityError) != 1 {
+			t.Errorf("Expected a missing repository to be an error, got: %+v", report.Issues)
+		}
+	})
+
+	t.Run("classifies a dirty working tree as a warning", func(t *testing.T) {
+		store, ws, repoDir := setup(t)
+		if err := os.WriteFile(filepath.Join(repoDir, "wip.txt"), []byte("wip"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		report, err := store.CheckHealth(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("CheckHealth failed: %v", err)
+		}
+		if len(report.Issues) != 1 || report.Issues[0].Severity != HealthSeverityWarning {
+			t.Fatalf("Expected a single warning, got: %+v", report.Issues)
+		}
+		if report.CountAtLeast(HealthSeverityError) != 0 || report.CountAtLeast(HealthSeverityWarning) != 1 || report.CountAtLeast(HealthSeverityInfo) != 1 {
+			t.Errorf("Unexpected counts for a single warning: %+v", report.Issues)
+		}
+	})
+}
+
+func TestWorkspaceProject(t *testing.T) {
+	ctx := context.Background()
+	store, root := CreateTestStore(t)
+
+	for _, opts := range []CreateOptions{
+		{Purpose: "pay", Project: "payments"},
+		{Purpose: "search", Project: "search"},
+		{Purpose: "loose"},
+	} {
+		opts.Repositories = []RepositoryOption{}
+		if _, err := store.Create(ctx, opts); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	t.Run("filters by project case-insensitively", func(t *testing.T) {
+		workspaces, err := store.List(ctx, ListOptions{ProjectFilter: "Payments"})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(workspaces) != 1 || workspaces[0].Project != "payments" {
+			t.Errorf("Expected only the payments workspace, got %+v", workspaces)
+		}
+		if workspaces[0].Version != CurrentMetadataVersion {
+			t.Errorf("Expected version %d, got %d", CurrentMetadataVersion, workspaces[0].Version)
+		}
+	})
+
+	t.Run("reads version 1 metadata without a project", func(t *testing.T) {
+		dir := filepath.Join(root, "old-workspace")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		legacy := `{"version":1,"handle":"old-workspace","purpose":"legacy","repositories":[],"created_at":"2024-01-01T00:00:00Z"}`
+		if err := os.WriteFile(filepath.Join(dir, metadataFileName), []byte(legacy), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		ws, err := store.Get(ctx, "old-workspace")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if ws.Project != "" {
+			t.Errorf("Expected no project, got %q", ws.Project)
+		}
+	})
+}
+
+func TestFakeClock(t *testing.T) {
+	ctx := context.Background()
+	store, _ := CreateTestStore(t)
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	store.SetClock(clock)
+
+	ws, err := store.Create(ctx, CreateOptions{Purpose: "Clock test", Repositories: []RepositoryOption{}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	got, err := store.Get(ctx, ws.Handle)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !got.CreatedAt.Equal(start) {
+		t.Errorf("Expected CreatedAt %v, got %v", start, got.CreatedAt)
+	}
+
+	clock.Advance(time.Hour)
+	capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "later", Kind: CaptureKindManual})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+	if want := start.Add(time.Hour); !capture.Timestamp.Equal(want) {
+		t.Errorf("Expected capture timestamp %v, got %v", want, capture.Timestamp)
+	}
+
+	t.Run("execution timestamps and retention follow the clock", func(t *testing.T) {
+		store.SetRetention(RetentionPolicy{MaxAge: 30 * 24 * time.Hour})
+		if err := store.RecordExecution(ctx, ws.Handle, ExecutionRecord{ID: "exec-01", Command: []string{"true"}}, nil); err != nil {
+			t.Fatalf("RecordExecution failed: %v", err)
+		}
+
+		clock.Advance(31 * 24 * time.Hour)
+		if err := store.RecordExecution(ctx, ws.Handle, ExecutionRecord{ID: "exec-02", Command: []string{"true"}}, nil); err != nil {
+			t.Fatalf("RecordExecution failed: %v", err)
+		}
+
+		records, err := store.ListExecutions(ctx, ws.Handle, ListExecutionsOptions{})
+		if err != nil {
+			t.Fatalf("ListExecutions failed: %v", err)
+		}
+		if len(records) != 1 || records[0].ID != "exec-02" {
+			t.Fatalf("Expected only exec-02 retained, got %+v", records)
+		}
+		if !records[0].Timestamp.Equal(clock.Now()) {
+			t.Errorf("Expected execution timestamp %v, got %v", clock.Now(), records[0].Timestamp)
+		}
+	})
+
+	t.Run("history and trash timestamps follow the clock", func(t *testing.T) {
+		if err := store.RecordHistory(ctx, ws.Handle, HistoryEntry{Operation: HistoryCheckout}); err != nil {
+			t.Fatalf("RecordHistory failed: %v", err)
+		}
+		history, err := store.ListHistory(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ListHistory failed: %v", err)
+		}
+		if len(history) == 0 || !history[0].Timestamp.Equal(clock.Now()) {
+			t.Errorf("Expected the newest history entry at %v, got %+v", clock.Now(), history)
+		}
+
+		trashed, err := store.Create(ctx, CreateOptions{Purpose: "Trash clock", Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		entry, err := store.TrashWorkspace(ctx, trashed.Handle)
+		if err != nil {
+			t.Fatalf("TrashWorkspace failed: %v", err)
+		}
+		if !entry.TrashedAt.Equal(clock.Now()) {
+			t.Errorf("Expected TrashedAt %v, got %v", clock.Now(), entry.TrashedAt)
+		}
+
+		if removed, err := store.EmptyTrash(ctx, 24*time.Hour); err != nil || len(removed) != 0 {
+			t.Errorf("Expected nothing old enough to empty, got %+v (%v)", removed, err)
+		}
+		clock.Advance(25 * time.Hour)
+		if expired, err := store.ExpiredTrash(ctx, 24*time.Hour); err != nil || len(expired) != 1 {
+			t.Errorf("Expected the trashed workspace to have expired, got %+v (%v)", expired, err)
+		}
+		if removed, err := store.EmptyTrash(ctx, 24*time.Hour); err != nil || len(removed) != 1 {
+			t.Errorf("Expected the trashed workspace to be emptied, got %+v (%v)", removed, err)
+		}
+	})
+
+	t.Run("nil restores the system clock", func(t *testing.T) {
+		store.SetClock(nil)
+		other, err := store.Create(ctx, CreateOptions{Purpose: "Real time", Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if time.Since(other.CreatedAt) > time.Minute {
+			t.Errorf("Expected a current CreatedAt, got %v", other.CreatedAt)
+		}
+	})
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-05-01T08:30:00Z", time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)},
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"12h", now.Add(-12 * time.Hour)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"2w", now.AddDate(0, 0, -14)},
+	}
+	for _, tc := range tests {
+		got, err := ParseTimeBound(tc.value, now)
+		if err != nil {
+			t.Errorf("ParseTimeBound(%q) failed: %v", tc.value, err)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("ParseTimeBound(%q) = %v, want %v", tc.value, got, tc.want)
+		}
+	}
+
+	for _, value := range []string{"", "yesterday", "7x", "-3d", "2024-13-01"} {
+		if _, err := ParseTimeBound(value, now); err == nil {
+			t.Errorf("ParseTimeBound(%q) should fail", value)
+		}
+	}
+}
+
+func TestListCreatedRange(t *testing.T) {
+	ctx := context.Background()
+	store, _ := CreateTestStore(t)
+	now := time.Now()
+
+	ages := map[string]time.Duration{"old": 30 * 24 * time.Hour, "week": 5 * 24 * time.Hour, "new": 0}
+	for purpose, age := range ages {
+		ws, err := store.Create(ctx, CreateOptions{Purpose: purpose, Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		ws.CreatedAt = now.Add(-age)
+		if err := store.writeMetadataToDir(ws, ws.Path); err != nil {
+			t.Fatalf("writeMetadataToDir failed: %v", err)
+		}
+	}
+
+	purposes := func(opts ListOptions) []string {
+		t.Helper()
+		workspaces, err := store.List(ctx, opts)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		var got []string
+		for _, ws := range workspaces {
+			got = append(got, ws.Purpose)
+		}
+		slices.Sort(got)
+		return got
+	}
+
+	weekAgo := now.Add(-7 * 24 * time.Hour)
+	if got := purposes(ListOptions{CreatedAfter: weekAgo}); !slices.Equal(got, []string{"new", "week"}) {
+		t.Errorf("CreatedAfter: got %v", got)
+	}
+	if got := purposes(ListOptions{CreatedBefore: weekAgo}); !slices.Equal(got, []string{"old"}) {
+		t.Errorf("CreatedBefore: got %v", got)
+	}
+	if got := purposes(ListOptions{CreatedAfter: weekAgo, CreatedBefore: now.Add(-time.Hour)}); !slices.Equal(got, []string{"week"}) {
+		t.Errorf("range: got %v", got)
+	}
+	if got := purposes(ListOptions{CreatedAfter: now.Add(time.Hour)}); len(got) != 0 {
+		t.Errorf("future bound: got %v", got)
+	}
+}
+
+func TestListActive(t *testing.T) {
+	ctx := context.Background()
+	store, _ := CreateTestStore(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	store.SetClock(NewFakeClock(now))
+
+	lastRun := map[string]time.Duration{"recent": 5 * time.Minute, "old": 3 * time.Hour, "never": -1}
+	for purpose, ago := range lastRun {
+		ws, err := store.Create(ctx, CreateOptions{Purpose: purpose, Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if ago < 0 {
+			continue
+		}
+		finished := now.Add(-ago)
+		record := ExecutionRecord{ID: "exec-" + purpose, Timestamp: finished, StartedAt: finished, CompletedAt: finished, Command: []string{"true"}}
+		if err := store.RecordExecution(ctx, ws.Handle, record, nil); err != nil {
+			t.Fatalf("RecordExecution failed: %v", err)
+		}
+	}
+
+	workspaces, err := store.List(ctx, ListOptions{ActiveWithin: 30 * time.Minute})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(workspaces) != 1 || workspaces[0].Purpose != "recent" {
+		t.Errorf("Expected only the recently run workspace, got %+v", workspaces)
+	}
+
+	workspaces, err = store.List(ctx, ListOptions{ActiveWithin: 4 * time.Hour})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(workspaces) != 2 {
+		t.Errorf("Expected recent and old within 4h, got %d", len(workspaces))
+	}
+}
+
+func TestRenameWorkspace(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("moves the directory and rewrites the handle", func(t *testing.T) {
+		store, root := CreateTestStore(t)
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Rename",
+			Repositories: []RepositoryOption{{URL: CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"}), Ref: "main"}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := store.TouchRecent(ctx, ws.Handle); err != nil {
+			t.Fatalf("TouchRecent failed: %v", err)
+		}
+
+		renamed, err := store.RenameWorkspace(ctx, ws.Handle, "payments-timeout")
+		if err != nil {
+			t.Fatalf("RenameWorkspace failed: %v", err)
+		}
+		if renamed.Handle != "payments-timeout" || renamed.Path != filepath.Join(root, "payments-timeout") {
+			t.Errorf("Unexpected renamed workspace: %+v", renamed)
+		}
+		if _, err := os.Stat(ws.Path); !os.IsNotExist(err) {
+			t.Errorf("Expected old directory to be gone, stat err: %v", err)
+		}
+		if _, err := store.Get(ctx, ws.Handle); err == nil {
+			t.Error("Expected the old handle to be gone")
+		}
+		got, err := store.Get(ctx, "payments-timeout")
+		if err != nil {
+			t.Fatalf("Get after rename failed: %v", err)
+		}
+		if got.Handle != "payments-timeout" || got.Purpose != "Rename" || len(got.Repositories) != 1 {
+			t.Errorf("Unexpected metadata after rename: %+v", got)
+		}
+
+		found, err := store.FindWorkspace(ctx, filepath.Join(renamed.Path, "api"))
+		if err != nil {
+			t.Fatalf("FindWorkspace failed: %v", err)
+		}
+		if found.Handle != "payments-timeout" {
+			t.Errorf("Expected FindWorkspace to return the new handle, got %s", found.Handle)
+		}
+
+		recent, err := store.RecentHandles(ctx)
+		if err != nil {
+			t.Fatalf("RecentHandles failed: %v", err)
+		}
+		if len(recent) != 1 || recent[0].Handle != "payments-timeout" {
+			t.Errorf("Expected the recent list to follow the rename, got %+v", recent)
+		}
+	})
+
+	t.Run("rejects a taken or invalid handle without changes", func(t *testing.T) {
+		store, root := CreateTestStore(t)
+		ws, err := store.Create(ctx, CreateOptions{Purpose: "Source", Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		other, err := store.Create(ctx, CreateOptions{Purpose: "Other", Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := os.Mkdir(filepath.Join(root, "stray"), 0755); err != nil {
+			t.Fatalf("Mkdir failed: %v", err)
+		}
+
+		for newHandle, want := range map[string]string{
+			other.Handle: "workspace already exists",
+			"stray":      "already exists",
+			ws.Handle:    "already named",
+			"":           "cannot be empty",
+			"../escape":  "invalid handle",
+			".hidden":    "invalid handle",
+			"has space":  "invalid handle",
+		} {
+			_, err := store.RenameWorkspace(ctx, ws.Handle, newHandle)
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("RenameWorkspace(%q): expected %q, got %v", newHandle, want, err)
+			}
+		}
+
+		got, err := store.Get(ctx, ws.Handle)
+		if err != nil || got.Purpose != "Source" {
+			t.Errorf("Expected the workspace untouched, got %+v (err %v)", got, err)
+		}
+	})
+}
+
+func TestFindWorkspaceSuggestion(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("no suggestion without workspaces", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
+		_, err := store.FindWorkspace(ctx, t.TempDir())
+		var notIn *NotInWorkspaceError
+		if !errors.As(err, &notIn) {
+			t.Fatalf("Expected NotInWorkspaceError, got: %v", err)
+		}
+		if notIn.Suggestion != nil {
+			t.Errorf("Expected no suggestion, got %s", notIn.Suggestion.Handle)
+		}
+		if err.Error() != "not in a workspace directory" {
+			t.Errorf("Unexpected message: %v", err)
+		}
+	})
+
+	t.Run("suggests the workspace next to a sibling directory", func(t *testing.T) {
+		base := t.TempDir()
+		store, err := NewFSStore(filepath.Join(base, "workspaces"))
+		if err != nil {
+			t.Fatalf("NewFSStore failed: %v", err)
+		}
+		ws, err := store.Create(ctx, CreateOptions{Purpose: "Nearby", Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		sibling := filepath.Join(base, "workspaces", "scratch")
+		if err := os.MkdirAll(sibling, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+
+		_, err = store.FindWorkspace(ctx, sibling)
+		var notIn *NotInWorkspaceError
+		if !errors.As(err, &notIn) || notIn.Suggestion == nil {
+			t.Fatalf("Expected a suggestion, got: %v", err)
+		}
+		if notIn.Suggestion.Handle != ws.Handle {
+			t.Errorf("Expected suggestion %s, got %s", ws.Handle, notIn.Suggestion.Handle)
+		}
+		if !strings.Contains(err.Error(), "did you mean "+ws.Handle+" at "+ws.Path) {
+			t.Errorf("Expected suggestion in message, got: %v", err)
+		}
+	})
+
+	t.Run("no suggestion from an unrelated directory", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
+		if _, err := store.Create(ctx, CreateOptions{Purpose: "Elsewhere", Repositories: []RepositoryOption{}}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		_, err := store.FindWorkspace(ctx, t.TempDir())
+		var notIn *NotInWorkspaceError
+		if !errors.As(err, &notIn) {
+			t.Fatalf("Expected NotInWorkspaceError, got: %v", err)
+		}
+		if notIn.Suggestion != nil {
+			t.Errorf("Expected no suggestion, got %s at %s", notIn.Suggestion.Handle, notIn.Suggestion.Path)
+		}
+	})
+
+	t.Run("falls back to the most recently used workspace", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
+		used, err := store.Create(ctx, CreateOptions{Purpose: "Used", Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := store.Create(ctx, CreateOptions{Purpose: "Newer", Repositories: []RepositoryOption{}}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := store.TouchRecent(ctx, used.Handle); err != nil {
+			t.Fatalf("TouchRecent failed: %v", err)
+		}
+
+		_, err = store.FindWorkspace(ctx, t.TempDir())
+		var notIn *NotInWorkspaceError
+		if !errors.As(err, &notIn) || notIn.Suggestion == nil {
+			t.Fatalf("Expected a suggestion, got: %v", err)
+		}
+		if notIn.Suggestion.Handle != used.Handle {
+			t.Errorf("Expected recently used workspace %s, got %s", used.Handle, notIn.Suggestion.Handle)
+		}
+	})
+
+	t.Run("prefers the most recent among equally near workspaces", func(t *testing.T) {
+		store, root := CreateTestStore(t)
+		if _, err := store.Create(ctx, CreateOptions{Purpose: "First", Repositories: []RepositoryOption{}}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		second, err := store.Create(ctx, CreateOptions{Purpose: "Second", Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		_, err = store.FindWorkspace(ctx, root)
+		var notIn *NotInWorkspaceError
+		if !errors.As(err, &notIn) || notIn.Suggestion == nil {
+			t.Fatalf("Expected a suggestion, got: %v", err)
+		}
+		if notIn.Suggestion.Handle != second.Handle {
+			t.Errorf("Expected most recent workspace %s, got %s", second.Handle, notIn.Suggestion.Handle)
+		}
+	})
+}
+
+func TestCopyWorkingTree(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) string {
+		t.Helper()
+		src := CreateLocalGitRepo(t, "wip", map[string]string{
+			"README.md":  "# Committed",
+			".gitignore": "*.log\n",
+		})
+		for name, content := range map[string]string{
+			"README.md": "# Uncommitted edit",
+			"new.txt":   "untracked work",
+			"debug.log": "ignored",
+		} {
+			if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+		}
+		return src
+	}
+
+	t.Run("copies uncommitted work and skips ignored files", func(t *testing.T) {
+		src := setup(t)
+		store, _ := CreateTestStore(t)
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "WIP copy",
+			Repositories: []RepositoryOption{{URL: src, CopyWorkingTree: true}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		repo := ws.Repositories[0]
+		if repo.Source != RepositorySourceWorkingTree {
+			t.Errorf("Expected source %q, got %q", RepositorySourceWorkingTree, repo.Source)
+		}
+		if repo.Ref != "main" {
+			t.Errorf("Expected ref main, got %q", repo.Ref)
+		}
+
+		dir := filepath.Join(ws.Path, repo.Name)
+		data, err := os.ReadFile(filepath.Join(dir, "README.md"))
+		if err != nil || string(data) != "# Uncommitted edit" {
+			t.Errorf("Expected uncommitted edit, got %q (%v)", data, err)
+		}
+		if !FileExists(filepath.Join(dir, "new.txt")) {
+			t.Error("Expected untracked file in copy")
+		}
+		if FileExists(filepath.Join(dir, "debug.log")) {
+			t.Error("Expected ignored file to be skipped")
+		}
+
+		status, err := git.RealGit{}.StatusPorcelain(ctx, dir)
+		if err != nil {
+			t.Fatalf("StatusPorcelain failed: %v", err)
+		}
+		if !strings.Contains(status, "README.md") || !strings.Contains(status, "new.txt") {
+			t.Errorf("Expected copy to be a dirty git checkout, got status: %q", status)
+		}
+
+		reloaded, err := store.Get(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if reloaded.Repositories[0].Source != RepositorySourceWorkingTree {
+			t.Error("Expected source to be persisted in metadata")
+		}
+	})
+
+	t.Run("includes ignored files when asked", func(t *testing.T) {
+		src := setup(t)
+		store, _ := CreateTestStore(t)
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "WIP copy",
+			Repositories: []RepositoryOption{{URL: src, CopyWorkingTree: true, IncludeIgnored: true}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if !FileExists(filepath.Join(ws.Path, ws.Repositories[0].Name, "debug.log")) {
+			t.Error("Expected ignored file in copy")
+		}
+	})
+
+	t.Run("rejects a ref", func(t *testing.T) {
+		src := setup(t)
+		store, _ := CreateTestStore(t)
+		_, err := store.Create(ctx, CreateOptions{
+			Purpose:      "WIP copy",
+			Repositories: []RepositoryOption{{URL: src, Ref: "main", CopyWorkingTree: true}},
+		})
+		if err == nil {
+			t.Error("Expected error when combining a ref with a working tree copy")
+		}
+	})
+}
+
+func TestLFS(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("pulls LFS objects when requested", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "LFS test",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/org/assets", Ref: "main", LFS: true},
+				{URL: "https://github.com/org/code", Ref: "main"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		calls := mockGit.GetLFSPullCalls()
+		if len(calls) != 1 || filepath.Base(calls[0].Dir) != "assets" {
+			t.Errorf("Expected one LFS pull for assets, got %+v", calls)
+		}
+
+		got, err := store.Get(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if !got.GetRepositoryByName("assets").LFS || got.GetRepositoryByName("code").LFS {
+			t.Errorf("Expected LFS recorded for assets only, got %+v", got.Repositories)
+		}
+	})
+
+	t.Run("pulls LFS objects after apply and lock restore", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		mockGit.SetRevParseResult("abc123")
+		mockGit.SetStatusPorcelainResult("")
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "LFS apply test",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/org/assets", Ref: "main", LFS: true},
+				{URL: "https://github.com/org/code", Ref: "main"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		for _, repo := range ws.Repositories {
+			CreateFakeRepo(t, ws.Path, repo.Name)
+		}
+		pulls := len(mockGit.GetLFSPullCalls())
+
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "assets", Kind: CaptureKindCheckpoint})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		if err := store.ApplyCapture(ctx, ws.Handle, capture.ID); err != nil {
+			t.Fatalf("ApplyCapture failed: %v", err)
+		}
+		calls := mockGit.GetLFSPullCalls()
+		if len(calls) != pulls+1 || filepath.Base(calls[len(calls)-1].Dir) != "assets" {
+			t.Errorf("Expected one LFS pull for assets after apply, got %+v", calls[pulls:])
+		}
+
+		lock, err := store.Lock(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Lock failed: %v", err)
+		}
+		if err := store.RestoreLock(ctx, ws.Handle, lock); err != nil {
+			t.Fatalf("RestoreLock failed: %v", err)
+		}
+		calls = mockGit.GetLFSPullCalls()
+		if len(calls) != pulls+2 || filepath.Base(calls[len(calls)-1].Dir) != "assets" {
+			t.Errorf("Expected one LFS pull for assets after lock restore, got %+v", calls[pulls:])
+		}
+	})
+
+	t.Run("health warns when LFS content is present without git-lfs", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "LFS health test",
+			Repositories: []RepositoryOption{{URL: "https://github.com/org/assets", Ref: "main"}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		repoDir := filepath.Join(ws.Path, "assets")
+		if err := os.MkdirAll(filepath.Join(repoDir, ".git"), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(repoDir, ".gitattributes"), []byte("*.psd filter=lfs diff=lfs merge=lfs -text\n"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		report, err := store.CheckHealth(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("CheckHealth failed: %v", err)
+		}
+		if len(report.Issues) != 1 || report.Issues[0].Kind != HealthLFSUnavailable || report.Issues[0].Severity != HealthSeverityWarning {
+			t.Errorf("Expected an lfs_unavailable warning, got %+v", report.Issues)
+		}
+
+		mockGit.SetLFSAvailable(true)
+		report, err = store.CheckHealth(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("CheckHealth failed: %v", err)
+		}
+		if !report.Healthy {
+			t.Errorf("Expected no warning with git-lfs installed, got %+v", report.Issues)
+		}
+	})
+}
+
+func TestVerifyCapture(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*FSStore, *Workspace, string) {
+		t.Helper()
+		store, _ := CreateTestStore(t)
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Verify test",
+			Repositories: []RepositoryOption{{URL: CreateLocalGitRepo(t, "verifyrepo", map[string]string{"README.md": "# Verify"}), Ref: "main"}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		repoDir := filepath.Join(ws.Path, ws.Repositories[0].Name)
+		for _, kv := range [][]string{{"user.email", "test@test.com"}, {"user.name", "Test"}} {
+			cmd := exec.Command("git", "config", kv[0], kv[1])
+			cmd.Dir = repoDir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git config failed: %v\n%s", err, out)
+			}
+		}
+		return store, ws, repoDir
+	}
+
+	t.Run("healthy capture", func(t *testing.T) {
+		store, ws, repoDir := setup(t)
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "healthy", Kind: CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		// A dirty tree does not make a capture unrestorable.
+		if err := os.WriteFile(filepath.Join(repoDir, "README.md"), []byte("dirty"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		result, err := store.VerifyCapture(ctx, ws.Handle, capture.ID)
+		if err != nil {
+			t.Fatalf("VerifyCapture failed: %v", err)
+		}
+		if !result.Valid || len(result.Problems) != 0 {
+			t.Errorf("Expected valid capture, got %+v", result)
+		}
+	})
+
+	t.Run("capture referencing a garbage-collected commit", func(t *testing.T) {
+		store, ws, repoDir := setup(t)
+		if err := AddGitCommit(repoDir, "Soon gone", map[string]string{"gone.txt": "gone"}); err != nil {
+			t.Fatalf("AddGitCommit failed: %v", err)
+		}
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "doomed", Kind: CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		for _, args := range [][]string{
+			{"reset", "-q", "--hard", "HEAD~1"},
+			{"reflog", "expire", "--expire=now", "--all"},
+			{"gc", "-q", "--prune=now"},
+		} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = repoDir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %s failed: %v\n%s", args[0], err, out)
+			}
+		}
+
+		result, err := store.VerifyCapture(ctx, ws.Handle, capture.ID)
+		if err != nil {
+			t.Fatalf("VerifyCapture failed: %v", err)
+		}
+		if result.Valid {
+			t.Fatal("Expected capture to fail verification")
+		}
+		if len(result.Problems) != 1 || result.Problems[0].Reason != ReasonCommitMissing {
+			t.Errorf("Expected a single commit_missing problem, got %+v", result.Problems)
+		}
+	})
+
+	t.Run("corrupt capture file is reported by VerifyCaptures", func(t *testing.T) {
+		store, ws, _ := setup(t)
+		good, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "good", Kind: CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		corruptDir := filepath.Join(ws.Path, ".workshed", capturesDirName, "corrupt")
+		if err := os.MkdirAll(corruptDir, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(corruptDir, "capture.json"), []byte("{not json"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		results, err := store.VerifyCaptures(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("VerifyCaptures failed: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %d", len(results))
+		}
+		for _, result := range results {
+			switch result.CaptureID {
+			case good.ID:
+				if !result.Valid {
+					t.Errorf("Expected %s to be valid, got %+v", good.ID, result.Problems)
+				}
+			case "corrupt":
+				if result.Valid || result.Problems[0].Reason != ReasonCorruptCapture {
+					t.Errorf("Expected corrupt capture, got %+v", result)
+				}
+			default:
+				t.Errorf("Unexpected capture %s", result.CaptureID)
+			}
+		}
+	})
+
+	t.Run("orphaned capture directory is reported and pruned", func(t *testing.T) {
+		store, ws, _ := setup(t)
+		good, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "good", Kind: CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		orphanDir := filepath.Join(ws.Path, ".workshed", capturesDirName, "orphan")
+		if err := os.MkdirAll(orphanDir, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(orphanDir, "stray.txt"), []byte("left over"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		results, err := store.VerifyCaptures(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("VerifyCaptures failed: %v", err)
+		}
+		for _, result := range results {
+			switch result.CaptureID {
+			case good.ID:
+				if !result.Valid || result.Orphaned {
+					t.Errorf("Expected %s to be valid, got %+v", good.ID, result)
+				}
+			case "orphan":
+				if !result.Orphaned || result.Problems[0].Reason != ReasonMissingCapture {
+					t.Errorf("Expected orphaned capture, got %+v", result)
+				}
+			}
+		}
+
+		// A directory this new may still be getting its capture.json.
+		if orphaned, err := store.OrphanedCaptures(ctx, ws.Handle); err != nil || len(orphaned) != 0 {
+			t.Errorf("Expected a fresh directory not to be listed, got %+v (%v)", orphaned, err)
+		}
+		removed, err := store.PruneOrphanedCaptures(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("PruneOrphanedCaptures failed: %v", err)
+		}
+		if len(removed) != 0 {
+			t.Errorf("Expected a fresh directory to be kept, removed %v", removed)
+		}
+
+		old := time.Now().Add(-2 * orphanGracePeriod)
+		if err := os.Chtimes(orphanDir, old, old); err != nil {
+			t.Fatalf("Chtimes failed: %v", err)
+		}
+		if orphaned, err := store.OrphanedCaptures(ctx, ws.Handle); err != nil || len(orphaned) != 1 || orphaned[0].CaptureID != "orphan" {
+			t.Errorf("Expected the orphan to be listed, got %+v (%v)", orphaned, err)
+		}
+		removed, err = store.PruneOrphanedCaptures(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("PruneOrphanedCaptures failed: %v", err)
+		}
+		if len(removed) != 1 || removed[0] != "orphan" {
+			t.Errorf("Expected [orphan] removed, got %v", removed)
+		}
+		if _, err := os.Stat(orphanDir); !os.IsNotExist(err) {
+			t.Errorf("Expected orphan directory to be gone, got %v", err)
+		}
+		if _, err := store.GetCapture(ctx, ws.Handle, good.ID); err != nil {
+			t.Errorf("Expected valid capture to be untouched: %v", err)
+		}
+	})
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	store, _ := CreateTestStore(t)
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "History test",
+		Repositories: []RepositoryOption{{URL: CreateLocalGitRepo(t, "historyrepo", map[string]string{"README.md": "# History"}), Ref: "main"}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	repoDir := filepath.Join(ws.Path, ws.Repositories[0].Name)
+	for _, kv := range [][]string{{"user.email", "test@test.com"}, {"user.name", "Test"}} {
+		cmd := exec.Command("git", "config", kv[0], kv[1])
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git config failed: %v\n%s", err, out)
+		}
+	}
+
+	t.Run("create is recorded", func(t *testing.T) {
+		entries, err := store.ListHistory(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ListHistory failed: %v", err)
+		}
+		if len(entries) != 1 || entries[0].Operation != HistoryCreate {
+			t.Fatalf("Expected a single create entry, got %+v", entries)
+		}
+		if len(entries[0].Changes) != 1 || entries[0].Changes[0].Repository != "historyrepo" || entries[0].Changes[0].To == "" {
+			t.Errorf("Expected the cloned commit, got %+v", entries[0].Changes)
+		}
+	})
+
+	t.Run("apply records the capture and commit transitions", func(t *testing.T) {
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "before", Kind: CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		if err := AddGitCommit(repoDir, "After capture", map[string]string{"after.txt": "after"}); err != nil {
+			t.Fatalf("AddGitCommit failed: %v", err)
+		}
+		head, err := store.git.RevParse(ctx, repoDir, "HEAD")
+		if err != nil {
+			t.Fatalf("RevParse failed: %v", err)
+		}
+
+		if err := store.ApplyCapture(ctx, ws.Handle, capture.ID); err != nil {
+			t.Fatalf("ApplyCapture failed: %v", err)
+		}
+
+		entries, err := store.ListHistory(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ListHistory failed: %v", err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 entries, got %+v", entries)
+		}
+		applied := entries[0]
+		if applied.Operation != HistoryApply || applied.CaptureID != capture.ID || applied.CaptureName != "before" || applied.Result != HistoryResultOK {
+			t.Errorf("Unexpected apply entry: %+v", applied)
+		}
+		want := RefChange{Repository: "historyrepo", From: head, To: capture.GitState[0].Commit}
+		if len(applied.Changes) != 1 || applied.Changes[0] != want {
+			t.Errorf("Expected changes [%+v], got %+v", want, applied.Changes)
+		}
+		if applied.Timestamp.IsZero() {
+			t.Error("Expected a timestamp")
+		}
+	})
+}
+
+func TestRepositoryNamesDifferingOnlyInCase(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("create rejects the pair", func(t *testing.T) {
+		store, root, _ := CreateMockedTestStore(t)
+		_, err := store.Create(ctx, CreateOptions{
+			Purpose: "Case test",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/org/Repo"},
+				{URL: "https://github.com/fork/repo"},
+			},
+		})
+		if err == nil || !strings.Contains(err.Error(), "duplicate repository name: repo conflicts with Repo") {
+			t.Fatalf("Expected duplicate name error, got %v", err)
+		}
+		MustNotHaveTempDirs(t, root)
+	})
+
+	store, _, _ := CreateMockedTestStore(t)
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose: "Case test",
+		Repositories: []RepositoryOption{
+			{URL: "https://github.com/org/repo", Ref: "main"},
+			{URL: "https://github.com/org/web", Ref: "main"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	t.Run("adding rejects a name taken in another case", func(t *testing.T) {
+		err := store.AddRepositories(ctx, ws.Handle, []RepositoryOption{{URL: "https://github.com/fork/REPO", Ref: "main"}}, "")
+		if err == nil || !strings.Contains(err.Error(), "repository name already exists: REPO conflicts with repo") {
+			t.Fatalf("Expected name collision error, got %v", err)
+		}
+		got, err := store.Get(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if len(got.Repositories) != 2 {
+			t.Errorf("Expected 2 repositories, got %d", len(got.Repositories))
+		}
+	})
+
+	t.Run("renaming onto another case is rejected", func(t *testing.T) {
+		err := store.RenameRepository(ctx, ws.Handle, "web", "Repo")
+		if err == nil || !strings.Contains(err.Error(), "conflicts with repo") {
+			t.Errorf("Expected name collision error, got %v", err)
+		}
+	})
+}
+
+func TestRenameRepository(t *testing.T) {
+	ctx := context.Background()
+	store, _ := CreateTestStore(t)
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose: "Rename test",
+		Repositories: []RepositoryOption{
+			{URL: CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"}), Ref: "main"},
+			{URL: CreateLocalGitRepo(t, "web", map[string]string{"README.md": "# Web"}), Ref: "main"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	record := ExecutionRecord{ID: "exec-01", Target: "api", Command: []string{"make"}, Results: []ExecutionRepoResult{{Repository: "api"}}}
+	if err := store.RecordExecution(ctx, ws.Handle, record, []ExecResult{{Repository: "api", Output: []byte("built\n")}}); err != nil {
+		t.Fatalf("RecordExecution failed: %v", err)
+	}
+	capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "before", Kind: CaptureKindManual})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+
+	t.Run("renames the directory and references", func(t *testing.T) {
+		if err := store.RenameRepository(ctx, ws.Handle, "api", "api-v2"); err != nil {
+			t.Fatalf("RenameRepository failed: %v", err)
+		}
+
+		path, err := store.GetRepositoryPath(ctx, ws.Handle, "api-v2")
+		if err != nil {
+			t.Fatalf("GetRepositoryPath failed: %v", err)
+		}
+		if path != filepath.Join(ws.Path, "api-v2") {
+			t.Errorf("Expected path %s, got %s", filepath.Join(ws.Path, "api-v2"), path)
+		}
+		if _, err := os.Stat(filepath.Join(path, "README.md")); err != nil {
+			t.Errorf("Expected the clone to move with the rename: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(ws.Path, "api")); !os.IsNotExist(err) {
+			t.Error("Expected the old directory to be gone")
+		}
+		if _, err := store.GetRepositoryPath(ctx, ws.Handle, "api"); err == nil {
+			t.Error("Expected the old name to be unknown")
+		}
+
+		exec, err := store.GetExecution(ctx, ws.Handle, "exec-01")
+		if err != nil {
+			t.Fatalf("GetExecution failed: %v", err)
+		}
+		if exec.Target != "api-v2" || exec.Results[0].Repository != "api-v2" || exec.Results[0].OutputPath != "api-v2.txt" {
+			t.Errorf("Expected the execution to follow the rename, got %+v", exec)
+		}
+		if _, err := os.Stat(filepath.Join(ws.Path, ".workshed", "executions", "exec-01", "stdout", "api-v2.txt")); err != nil {
+			t.Errorf("Expected the output file to be renamed: %v", err)
+		}
+
+		renamed, err := store.GetCapture(ctx, ws.Handle, capture.ID)
+		if err != nil {
+			t.Fatalf("GetCapture failed: %v", err)
+		}
+		found := false
+		for _, ref := range renamed.GitState {
+			if ref.Repository == "api" {
+				t.Error("Expected the capture to drop the old name")
+			}
+			found = found || ref.Repository == "api-v2"
+		}
+		if !found {
+			t.Errorf("Expected the capture to use the new name, got %+v", renamed.GitState)
+		}
+	})
+
+	t.Run("rejects an existing name", func(t *testing.T) {
+		err := store.RenameRepository(ctx, ws.Handle, "api-v2", "web")
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Fatalf("Expected an already exists error, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(ws.Path, "api-v2")); err != nil {
+			t.Errorf("Expected the repository to stay in place: %v", err)
+		}
+	})
+
+	t.Run("rejects unsafe names", func(t *testing.T) {
+		for _, name := range []string{"", "..", "nested/api", ".workshed"} {
+			if err := store.RenameRepository(ctx, ws.Handle, "web", name); err == nil {
+				t.Errorf("Expected %q to be rejected", name)
+			}
+		}
+	})
+}
+
+func TestLastActivity(t *testing.T) {
+	ctx := context.Background()
+	store, _ := CreateTestStore(t)
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "Activity test",
+		Repositories: []RepositoryOption{},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	t.Run("defaults to creation time", func(t *testing.T) {
+		last, err := store.LastActivity(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("LastActivity failed: %v", err)
+		}
+		if !last.Equal(ws.CreatedAt) {
+			t.Errorf("Expected %v, got %v", ws.CreatedAt, last)
+		}
+	})
+
+	t.Run("recent capture wins over older creation", func(t *testing.T) {
+		ws.CreatedAt = time.Now().Add(-72 * time.Hour)
+		if err := store.writeMetadataToDir(ws, ws.Path); err != nil {
+			t.Fatalf("writeMetadataToDir failed: %v", err)
+		}
+
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "recent", Kind: CaptureKindCheckpoint})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+
+		last, err := store.LastActivity(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("LastActivity failed: %v", err)
+		}
+		if !last.Equal(capture.Timestamp) {
+			t.Errorf("Expected capture time %v, got %v", capture.Timestamp, last)
+		}
+
+		exported, err := store.ExportContext(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ExportContext failed: %v", err)
+		}
+		if !exported.Metadata.LastActivityAt.Equal(last) {
+			t.Errorf("Expected exported last activity %v, got %v", last, exported.Metadata.LastActivityAt)
+		}
+	})
+}
+
+func TestReconcileRepositories(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*FSStore, *Workspace, map[string]string) {
+		t.Helper()
+		store, _ := CreateTestStore(t)
+		api := CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"})
+		if err := CreateGitBranch(api, "feature"); err != nil {
+			t.Fatal(err)
+		}
+		if err := AddGitCommit(api, "Feature work", map[string]string{"feature.txt": "feature"}); err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command("git", "checkout", "-q", "main")
+		cmd.Dir = api
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git checkout main failed: %v\n%s", err, out)
+		}
+		repos := map[string]string{
+			"api":  api,
+			"web":  CreateLocalGitRepo(t, "web", map[string]string{"README.md": "# Web"}),
+			"docs": CreateLocalGitRepo(t, "docs", map[string]string{"README.md": "# Docs"}),
+		}
+
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Reconcile test",
+			Repositories: []RepositoryOption{{URL: repos["api"]}, {URL: repos["web"]}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		return store, ws, repos
+	}
+
+	t.Run("adds missing and updates changed refs", func(t *testing.T) {
+		store, ws, repos := setup(t)
+
+		manifest := strings.Join([]string{
+			"# desired repositories",
+			repos["api"] + "@feature",
+			repos["web"],
+			"",
+			repos["docs"],
+		}, "\n")
+		desired, err := ParseManifest(strings.NewReader(manifest))
+		if err != nil {
+			t.Fatalf("ParseManifest failed: %v", err)
+		}
+
+		result, err := store.ReconcileRepositories(ctx, ws.Handle, desired, "")
+		if err != nil {
+			t.Fatalf("ReconcileRepositories failed: %v", err)
+		}
+		if len(result.Added) != 1 || result.Added[0] != "docs" {
+			t.Errorf("Expected docs added, got %v", result.Added)
+		}
+		if len(result.Updated) != 1 || result.Updated[0] != "api" {
+			t.Errorf("Expected api updated, got %v", result.Updated)
+		}
+		if len(result.Removed) != 0 {
+			t.Errorf("Expected nothing removed, got %v", result.Removed)
+		}
+
+		got, err := store.Get(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if len(got.Repositories) != 3 {
+			t.Fatalf("Expected 3 repositories, got %d", len(got.Repositories))
+		}
+		if api := got.GetRepositoryByName("api"); api == nil || api.Ref != "feature" {
+			t.Errorf("Expected api at feature, got %+v", api)
+		}
+		if !FileExists(filepath.Join(ws.Path, "api", "feature.txt")) {
+			t.Error("Expected api working tree to be on feature")
+		}
+		if !FileExists(filepath.Join(ws.Path, "docs", "README.md")) {
+			t.Error("Expected docs to be cloned")
+		}
+	})
+
+	t.Run("removes repositories not in the manifest", func(t *testing.T) {
+		store, ws, repos := setup(t)
+
+		result, err := store.ReconcileRepositories(ctx, ws.Handle, []RepositoryOption{{URL: repos["api"]}}, "")
+		if err != nil {
+			t.Fatalf("ReconcileRepositories failed: %v", err)
+		}
+		if len(result.Removed) != 1 || result.Removed[0] != "web" {
+			t.Errorf("Expected web removed, got %v", result.Removed)
+		}
+		if FileExists(filepath.Join(ws.Path, "web")) {
+			t.Error("Expected web directory to be removed")
+		}
+	})
+
+	t.Run("plan reports changes without making them", func(t *testing.T) {
+		store, ws, repos := setup(t)
+
+		desired := []RepositoryOption{{URL: repos["api"], Ref: "feature"}, {URL: repos["docs"]}}
+		plan, err := store.PlanReconcile(ctx, ws.Handle, desired, "")
+		if err != nil {
+			t.Fatalf("PlanReconcile failed: %v", err)
+		}
+		if len(plan.Add) != 1 || plan.Add[0].Name != "docs" {
+			t.Errorf("Expected docs to be added, got %+v", plan.Add)
+		}
+		if len(plan.Remove) != 1 || plan.Remove[0].Name != "web" {
+			t.Errorf("Expected web to be removed, got %+v", plan.Remove)
+		}
+		if len(plan.Checkout) != 1 || plan.Checkout["api"] != "feature" {
+			t.Errorf("Expected api checkout to feature, got %v", plan.Checkout)
+		}
+
+		if FileExists(filepath.Join(ws.Path, "docs")) || !FileExists(filepath.Join(ws.Path, "web")) {
+			t.Error("Expected the workspace to be unchanged")
+		}
+		if FileExists(filepath.Join(ws.Path, "api", "feature.txt")) {
+			t.Error("Expected api to stay on main")
+		}
+	})
+
+	t.Run("failure leaves the original state intact", func(t *testing.T) {
+		store, ws, repos := setup(t)
+		before, err := store.Get(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+
+		desired := []RepositoryOption{
+			{URL: repos["api"], Ref: "feature"},
+			{URL: repos["web"], Ref: "does-not-exist"},
+			{URL: repos["docs"]},
+		}
+		if _, err := store.ReconcileRepositories(ctx, ws.Handle, desired, ""); err == nil {
+			t.Fatal("Expected ReconcileRepositories to fail")
+		}
+
+		got, err := store.Get(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if len(got.Repositories) != 2 {
+			t.Fatalf("Expected 2 repositories, got %d", len(got.Repositories))
+		}
+		if api := got.GetRepositoryByName("api"); api == nil || api.Ref != before.Repositories[0].Ref {
+			t.Errorf("Expected api metadata unchanged, got %+v", api)
+		}
+		if FileExists(filepath.Join(ws.Path, "docs")) {
+			t.Error("Expected cloned docs to be rolled back")
+		}
+		if FileExists(filepath.Join(ws.Path, "api", "feature.txt")) {
+			t.Error("Expected api working tree to be back on main")
+		}
+		branch, err := store.git.CurrentBranch(ctx, filepath.Join(ws.Path, "api"))
+		if err != nil || branch != "main" {
+			t.Errorf("Expected api on main, got %q (%v)", branch, err)
+		}
+	})
+}
+
+func TestCreateHandleCollisions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("concurrent creates in a tiny handle space never collide", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
+		store.SetHandleGenerator(handle.NewGenerator(handle.WithWords(
+			[]string{"calm", "bold"},
+			[]string{"fox", "owl"},
+			[]string{"runs", "hops", "naps", "digs"},
+		)))
+
+		const count = 12
+		var wg sync.WaitGroup
+		handles := make([]string, count)
+		errs := make([]error, count)
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				ws, err := store.Create(ctx, CreateOptions{
+					Purpose:      fmt.Sprintf("Stress %d", i),
+					Repositories: []RepositoryOption{},
+				})
+				errs[i] = err
+				if err == nil {
+					handles[i] = ws.Handle
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		seen := make(map[string]bool)
+		for i, h := range handles {
+			if errs[i] != nil {
+				t.Fatalf("Create %d failed: %v", i, errs[i])
+			}
+			if seen[h] {
+				t.Errorf("Handle %s was issued twice", h)
+			}
+			seen[h] = true
+		}
+
+		workspaces, err := store.List(ctx, ListOptions{})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(workspaces) != count {
+			t.Errorf("Expected %d workspaces on disk, got %d", count, len(workspaces))
+		}
+	})
+
+	t.Run("a handle claimed after the uniqueness check is regenerated", func(t *testing.T) {
+		store, root := CreateTestStore(t)
+		store.SetHandleGenerator(handle.NewGenerator(handle.WithWords(
+			[]string{"calm"}, []string{"fox"}, []string{"runs", "hops"},
+		)))
+
+		// An empty directory passes the Get probe but is taken, as it would be
+		// mid-way through another create.
+		if err := os.Mkdir(filepath.Join(root, "calm-fox-runs"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		ws, err := store.Create(ctx, CreateOptions{Purpose: "Claimed", Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if ws.Handle != "calm-fox-hops" {
+			t.Errorf("Expected calm-fox-hops, got %s", ws.Handle)
+		}
+		got, err := store.Get(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if got.Handle != ws.Handle {
+			t.Errorf("Expected metadata handle %s, got %s", ws.Handle, got.Handle)
+		}
+	})
+}
+
+func TestNoCheckout(t *testing.T) {
+	ctx := context.Background()
+	store, _, mockGit := CreateMockedTestStore(t)
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "History only",
+		Repositories: []RepositoryOption{{URL: "https://github.com/org/monorepo", Ref: "main", NoCheckout: true}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	clones := mockGit.GetCloneCalls()
+	if len(clones) != 1 || !clones[0].Opts.NoCheckout {
+		t.Fatalf("Expected a --no-checkout clone, got %+v", clones)
+	}
+	if calls := mockGit.GetCheckoutCalls(); len(calls) != 0 {
+		t.Errorf("Expected checkout to be skipped, got %+v", calls)
+	}
+
+	got, err := store.Get(ctx, ws.Handle)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !got.Repositories[0].NoCheckout {
+		t.Error("Expected metadata to record the repository as not checked out")
+	}
+
+	if err := store.CheckoutRepository(ctx, ws.Handle, "monorepo", ""); err != nil {
+		t.Fatalf("CheckoutRepository failed: %v", err)
+	}
+	calls := mockGit.GetCheckoutCalls()
+	if len(calls) != 1 || calls[0].Ref != "main" || calls[0].Dir != filepath.Join(ws.Path, "monorepo") {
+		t.Errorf("Expected checkout of main in monorepo, got %+v", calls)
+	}
+
+	got, err = store.Get(ctx, ws.Handle)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Repositories[0].NoCheckout {
+		t.Error("Expected checkout to clear the no-checkout marker")
+	}
+
+	if err := store.CheckoutRepository(ctx, ws.Handle, "missing", ""); err == nil {
+		t.Error("Expected an error for an unknown repository")
+	}
+}
+
+func TestSparseCheckout(t *testing.T) {
+	ctx := context.Background()
+	store, _, mockGit := CreateMockedTestStore(t)
+
+	sparse := []string{"services/api", "libs/common"}
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "One service",
+		Repositories: []RepositoryOption{{URL: "https://github.com/org/monorepo", Ref: "main", Sparse: sparse}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	clones := mockGit.GetCloneCalls()
+	if len(clones) != 1 || !clones[0].Opts.NoCheckout {
+		t.Fatalf("Expected the clone to skip its initial checkout, got %+v", clones)
+	}
+	calls := mockGit.GetSparseCheckoutCalls()
+	if len(calls) != 1 || filepath.Base(calls[0].Dir) != "monorepo" || !slices.Equal(calls[0].Paths, sparse) {
+		t.Fatalf("Expected sparse-checkout of %v in monorepo, got %+v", sparse, calls)
+	}
+	if checkouts := mockGit.GetCheckoutCalls(); len(checkouts) != 1 || checkouts[0].Ref != "main" {
+		t.Errorf("Expected main to be checked out after sparse-checkout, got %+v", checkouts)
+	}
+
+	got, err := store.Get(ctx, ws.Handle)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !slices.Equal(got.Repositories[0].Sparse, sparse) {
+		t.Errorf("Expected metadata to record sparse paths %v, got %v", sparse, got.Repositories[0].Sparse)
+	}
+	if got.Repositories[0].NoCheckout {
+		t.Error("Expected a sparse repository to count as checked out")
+	}
+
+	exported, err := store.ExportContext(ctx, ws.Handle)
+	if err != nil {
+		t.Fatalf("ExportContext failed: %v", err)
+	}
+	if !slices.Equal(exported.Repositories[0].Sparse, sparse) {
+		t.Fatalf("Expected export to carry sparse paths, got %v", exported.Repositories[0].Sparse)
+	}
+
+	imported, err := store.ImportContext(ctx, ImportOptions{Context: exported})
+	if err != nil {
+		t.Fatalf("ImportContext failed: %v", err)
+	}
+	if !slices.Equal(imported.Repositories[0].Sparse, sparse) {
+		t.Errorf("Expected imported metadata to record sparse paths, got %v", imported.Repositories[0].Sparse)
+	}
+	calls = mockGit.GetSparseCheckoutCalls()
+	if len(calls) != 2 || !slices.Equal(calls[1].Paths, sparse) {
+		t.Errorf("Expected import to reapply sparse paths, got %+v", calls)
+	}
+}
+
+func TestRepositoryRemotes(t *testing.T) {
+	ctx := context.Background()
+	store, _, mockGit := CreateMockedTestStore(t)
+
+	remotes := map[string]string{"upstream": "https://github.com/org/tool", "fork": "https://github.com/other/tool"}
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "Fork fix",
+		Repositories: []RepositoryOption{{URL: "https://github.com/me/tool", Ref: "main", Remotes: remotes}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	calls := mockGit.GetRemoteAddCalls()
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 remotes to be added, got %+v", calls)
+	}
+	if calls[0].Name != "fork" || calls[1].Name != "upstream" {
+		t.Errorf("Expected remotes in name order, got %+v", calls)
+	}
+	for _, call := range calls {
+		if filepath.Base(call.Dir) != "tool" || call.URL != remotes[call.Name] {
+			t.Errorf("Unexpected remote call %+v", call)
+		}
+	}
+
+	got, err := store.Get(ctx, ws.Handle)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(got.Repositories[0].Remotes) != 2 || got.Repositories[0].Remotes["upstream"] != remotes["upstream"] {
+		t.Errorf("Expected metadata to record remotes %v, got %v", remotes, got.Repositories[0].Remotes)
+	}
+
+	if _, err := store.FetchRepositories(ctx, ws.Handle, FetchOptions{Remote: "upstream"}); err != nil {
+		t.Fatalf("FetchRepositories failed: %v", err)
+	}
+	fetches := mockGit.GetFetchCalls()
+	if len(fetches) != 1 || fetches[0].Opts.Remote != "upstream" {
+		t.Errorf("Expected fetch to target upstream, got %+v", fetches)
+	}
+
+	exported, err := store.ExportContext(ctx, ws.Handle)
+	if err != nil {
+		t.Fatalf("ExportContext failed: %v", err)
+	}
+	imported, err := store.ImportContext(ctx, ImportOptions{Context: exported})
+	if err != nil {
+		t.Fatalf("ImportContext failed: %v", err)
+	}
+	if imported.Repositories[0].Remotes["fork"] != remotes["fork"] {
+		t.Errorf("Expected imported metadata to record remotes, got %v", imported.Repositories[0].Remotes)
+	}
+	if calls := mockGit.GetRemoteAddCalls(); len(calls) != 4 {
+		t.Errorf("Expected import to configure remotes again, got %+v", calls)
+	}
+}
+
+func TestRepositoryRemotesFailure(t *testing.T) {
+	ctx := context.Background()
+	store, _, mockGit := CreateMockedTestStore(t)
+	mockGit.SetRemoteAddErr(errors.New("remote add failed"))
+
+	_, err := store.Create(ctx, CreateOptions{
+		Purpose:      "Fork fix",
+		Repositories: []RepositoryOption{{URL: "https://github.com/me/tool", Ref: "main", Remotes: map[string]string{"upstream": "https://github.com/org/tool"}}},
+	})
+	if err == nil {
+		t.Fatal("Expected Create to fail when a remote cannot be added")
+	}
+}
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*FSStore, *Workspace) {
+		t.Helper()
+		store, _ := CreateTestStore(t)
+		repo := CreateLocalGitRepo(t, "trashrepo", map[string]string{"README.md": "# Trash"})
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Trash test",
+			Repositories: []RepositoryOption{{URL: repo, Ref: "main"}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(ws.Path, "trashrepo", "wip.txt"), []byte("wip"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		return store, ws
+	}
+
+	t.Run("trashed workspace is gone but recoverable", func(t *testing.T) {
+		store, ws := setup(t)
+
+		entry, err := store.TrashWorkspace(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("TrashWorkspace failed: %v", err)
+		}
+		if _, err := store.Get(ctx, ws.Handle); err == nil {
+			t.Error("Expected trashed workspace to be gone from the store")
+		}
+		workspaces, err := store.List(ctx, ListOptions{})
+		if err != nil || len(workspaces) != 0 {
+			t.Errorf("Expected List to skip the trash, got %d workspaces (%v)", len(workspaces), err)
+		}
+
+		entries, err := store.ListTrash(ctx)
+		if err != nil {
+			t.Fatalf("ListTrash failed: %v", err)
+		}
+		if len(entries) != 1 || entries[0].ID != entry.ID || entries[0].Handle != ws.Handle || entries[0].Purpose != "Trash test" {
+			t.Fatalf("Unexpected trash entries: %+v", entries)
+		}
+
+		restored, err := store.RestoreWorkspace(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("RestoreWorkspace failed: %v", err)
+		}
+		if restored.Handle != ws.Handle || restored.Path != ws.Path {
+			t.Errorf("Expected %s at %s, got %s at %s", ws.Handle, ws.Path, restored.Handle, restored.Path)
+		}
+		data, err := os.ReadFile(filepath.Join(ws.Path, "trashrepo", "wip.txt"))
+		if err != nil || string(data) != "wip" {
+			t.Errorf("Expected uncommitted file to survive the trash, got %q (%v)", data, err)
+		}
+		if entries, _ := store.ListTrash(ctx); len(entries) != 0 {
+			t.Errorf("Expected trash to be empty after restore, got %+v", entries)
+		}
+	})
+
+	t.Run("restore picks a new handle when the old one is taken", func(t *testing.T) {
+		store, ws := setup(t)
+		if _, err := store.TrashWorkspace(ctx, ws.Handle); err != nil {
+			t.Fatalf("TrashWorkspace failed: %v", err)
+		}
+		if err := os.MkdirAll(ws.Path, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := store.writeMetadataToDir(&Workspace{Version: CurrentMetadataVersion, Handle: ws.Handle, Purpose: "Squatter"}, ws.Path); err != nil {
+			t.Fatalf("writeMetadataToDir failed: %v", err)
+		}
+
+		restored, err := store.RestoreWorkspace(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("RestoreWorkspace failed: %v", err)
+		}
+		if restored.Handle == ws.Handle {
+			t.Fatal("Expected restore to pick a new handle")
+		}
+		got, err := store.Get(ctx, restored.Handle)
+		if err != nil || got.Purpose != "Trash test" {
+			t.Errorf("Expected restored workspace under %s, got %+v (%v)", restored.Handle, got, err)
+		}
+		if squatter, err := store.Get(ctx, ws.Handle); err != nil || squatter.Purpose != "Squatter" {
+			t.Errorf("Expected existing workspace to be untouched, got %+v (%v)", squatter, err)
+		}
+	})
+
+	t.Run("empty keeps recent entries unless told to delete all", func(t *testing.T) {
+		store, ws := setup(t)
+		if _, err := store.TrashWorkspace(ctx, ws.Handle); err != nil {
+			t.Fatalf("TrashWorkspace failed: %v", err)
+		}
+
+		removed, err := store.EmptyTrash(ctx, DefaultTrashRetention)
+		if err != nil || len(removed) != 0 {
+			t.Fatalf("Expected nothing past retention, removed %+v (%v)", removed, err)
+		}
+
+		removed, err = store.EmptyTrash(ctx, 0)
+		if err != nil || len(removed) != 1 {
+			t.Fatalf("Expected everything to be deleted, removed %+v (%v)", removed, err)
+		}
+		if _, err := store.RestoreWorkspace(ctx, ws.Handle); err == nil {
+			t.Error("Expected restore to fail after emptying the trash")
+		}
+	})
+}
+
+func TestCreateIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := CreateMockedTestStore(t)
+	repos := []RepositoryOption{{URL: "https://github.com/org/api", Ref: "main"}}
+
+	first, err := store.Create(ctx, CreateOptions{Purpose: "CI run", IdempotencyKey: "job-1", Repositories: repos})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if first.Existing {
+		t.Error("Expected first create to report a new workspace")
+	}
+
+	second, err := store.Create(ctx, CreateOptions{Purpose: "CI run retry", IdempotencyKey: "job-1", Repositories: repos})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if !second.Existing || second.Handle != first.Handle {
+		t.Errorf("Expected retry to return %s as existing, got %s (existing=%v)", first.Handle, second.Handle, second.Existing)
+	}
+	if second.Purpose != "CI run" {
+		t.Errorf("Expected the original purpose, got %q", second.Purpose)
+	}
+
+	third, err := store.Create(ctx, CreateOptions{Purpose: "CI run", IdempotencyKey: "job-2", Repositories: repos})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if third.Existing || third.Handle == first.Handle {
+		t.Errorf("Expected a different key to create a new workspace, got %s", third.Handle)
+	}
+
+	all, err := store.List(ctx, ListOptions{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("Expected 2 workspaces, got %d", len(all))
+	}
+}
+
+func TestCloneMissingRepositories(t *testing.T) {
+	ctx := context.Background()
+	store, _, mockGit := CreateMockedTestStore(t)
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose: "Recover",
+		Repositories: []RepositoryOption{
+			{URL: "https://github.com/org/api", Ref: "main"},
+			{URL: "https://github.com/org/web", Ref: "release", Depth: 5},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// The mock does not write clones, so lay out both repositories by hand and
+	// delete one of them.
+	apiDir := CreateFakeRepo(t, ws.Path, "api")
+	if err := os.RemoveAll(CreateFakeRepo(t, ws.Path, "web")); err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+	marker := filepath.Join(apiDir, "local-change.txt")
+	if err := os.WriteFile(marker, []byte("keep me"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	clonesBefore := len(mockGit.GetCloneCalls())
+
+	results, err := store.CloneMissingRepositories(ctx, ws.Handle)
+	if err != nil {
+		t.Fatalf("CloneMissingRepositories failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Repository != "web" || results[0].Err != nil {
+		t.Fatalf("Expected only web to be restored, got %+v", results)
+	}
+
+	clones := mockGit.GetCloneCalls()[clonesBefore:]
+	if len(clones) != 1 {
+		t.Fatalf("Expected 1 clone, got %+v", clones)
+	}
+	if clones[0].URL != "https://github.com/org/web" || clones[0].Dir != filepath.Join(ws.Path, "web") || clones[0].Opts.Depth != 5 {
+		t.Errorf("Expected web to be cloned from its recorded URL and depth, got %+v", clones[0])
+	}
+	checkouts := mockGit.GetCheckoutCalls()
+	if last := checkouts[len(checkouts)-1]; last.Ref != "release" {
+		t.Errorf("Expected recorded ref release to be checked out, got %+v", last)
+	}
+
+	if data, err := os.ReadFile(marker); err != nil || string(data) != "keep me" {
+		t.Errorf("Expected present repository to be untouched, got %q, %v", data, err)
+	}
+
+	t.Run("failure is reported per repository", func(t *testing.T) {
+		mockGit.SetCloneErr(errors.New("network down"))
+		defer mockGit.SetCloneErr(nil)
+
+		results, err := store.CloneMissingRepositories(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("CloneMissingRepositories failed: %v", err)
+		}
+		if len(results) != 1 || results[0].Err == nil {
+			t.Errorf("Expected web to fail, got %+v", results)
+		}
+		if _, err := os.Stat(filepath.Join(ws.Path, "web")); !os.IsNotExist(err) {
+			t.Errorf("Expected no partial clone to remain, got %v", err)
+		}
+	})
+}
+
+func TestCaptureNames(t *testing.T) {
+	ctx := context.Background()
+	store, _, mockGit := CreateMockedTestStore(t)
+	mockGit.SetRevParseResult("abc123")
+	mockGit.SetStatusPorcelainResult("")
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "Named captures",
+		Repositories: []RepositoryOption{{URL: "https://github.com/test/repo", Ref: "main"}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	first, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "release", Kind: CaptureKindCheckpoint, UniqueName: true})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+
+	t.Run("unique name rejects a duplicate", func(t *testing.T) {
+		_, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "release", Kind: CaptureKindCheckpoint, UniqueName: true})
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Fatalf("Expected duplicate name to be rejected, got %v", err)
+		}
+		captures, err := store.ListCaptures(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ListCaptures failed: %v", err)
+		}
+		if len(captures) != 1 {
+			t.Errorf("Expected the rejected capture not to be stored, got %d captures", len(captures))
+		}
+	})
+
+	t.Run("plan checks the name without recording", func(t *testing.T) {
+		if _, err := store.PlanCapture(ctx, ws.Handle, CaptureOptions{Name: "release", Kind: CaptureKindCheckpoint, UniqueName: true}); err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("Expected duplicate name to be rejected, got %v", err)
+		}
+		if _, err := store.PlanCapture(ctx, ws.Handle, CaptureOptions{Name: "next", Kind: CaptureKindCheckpoint, UniqueName: true}); err != nil {
+			t.Errorf("PlanCapture failed: %v", err)
+		}
+		captures, err := store.ListCaptures(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ListCaptures failed: %v", err)
+		}
+		if len(captures) != 1 {
+			t.Errorf("Expected PlanCapture to record nothing, got %d captures", len(captures))
+		}
+	})
+
+	t.Run("resolves by name", func(t *testing.T) {
+		got, err := store.GetCapture(ctx, ws.Handle, "release")
+		if err != nil {
+			t.Fatalf("GetCapture by name failed: %v", err)
+		}
+		if got.ID != first.ID {
+			t.Errorf("Expected %s, got %s", first.ID, got.ID)
+		}
+		if _, err := store.PreflightApply(ctx, ws.Handle, "release"); err != nil {
+			t.Errorf("PreflightApply by name failed: %v", err)
+		}
+	})
+
+	t.Run("ambiguous name is an error", func(t *testing.T) {
+		if _, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "wip", Kind: CaptureKindCheckpoint}); err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		if _, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "wip", Kind: CaptureKindCheckpoint}); err != nil {
+			t.Fatalf("CaptureState without UniqueName should allow duplicates: %v", err)
+		}
+		_, err := store.GetCapture(ctx, ws.Handle, "wip")
+		if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+			t.Errorf("Expected ambiguous name error, got %v", err)
+		}
+	})
+}
+
+func TestCreateNewBranch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates and records the branch in every repository", func(t *testing.T) {
+		store, _, mockGit := CreateMockedTestStore(t)
+
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "Login page",
+			Repositories: []RepositoryOption{
+				{URL: "https://github.com/org/api", Ref: "main"},
+				{URL: "https://github.com/org/web", Ref: "develop"},
+			},
+			NewBranch:     "feature/login",
+			NewBranchFrom: "release",
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		checkouts := mockGit.GetCheckoutCalls()
+		for _, call := range checkouts {
+			if call.Ref != "release" {
+				t.Errorf("Expected repositories to be cloned at release, got %+v", call)
+			}
+		}
+
+		calls := mockGit.GetCreateBranchCalls()
+		if len(calls) != 2 {
+			t.Fatalf("Expected a branch per repository, got %+v", calls)
+		}
+		for _, call := range calls {
+			if call.Name != "feature/login" {
+				t.Errorf("Expected feature/login, got %+v", call)
+			}
+		}
+
+		got, err := store.Get(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		for _, repo := range got.Repositories {
+			if repo.Ref != "feature/login" {
+				t.Errorf("Expected %s to record feature/login, got %q", repo.Name, repo.Ref)
+			}
+		}
+	})
+
+	t.Run("fails clearly when the branch exists", func(t *testing.T) {
+		store, root, mockGit := CreateMockedTestStore(t)
+		mockGit.SetCreateBranchErr(git.ClassifyError("create-branch", errors.New("exit status 128"), []byte("fatal: a branch named 'main' already exists")))
+
+		_, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Existing branch",
+			Repositories: []RepositoryOption{{URL: "https://github.com/org/api", Ref: "main"}},
+			NewBranch:    "main",
+		})
+		if err == nil {
+			t.Fatal("Expected Create to fail for an existing branch")
+		}
+		if !strings.Contains(err.Error(), "creating branch main in api") || !strings.Contains(err.Error(), "branch already exists") {
+			t.Errorf("Expected a clear existing-branch error, got: %v", err)
+		}
+
+		all, err := store.List(ctx, ListOptions{})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(all) != 0 {
+			t.Errorf("Expected no workspace to be left behind in %s, got %d", root, len(all))
+		}
+	})
+}
+
+func TestDiffExecutions(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := CreateMockedTestStore(t)
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "Flaky tests",
+		Repositories: []RepositoryOption{{URL: "https://github.com/org/api", Ref: "main"}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	outputs := map[string]string{
+		"exec-01": "ok  pkg/a\nok  pkg/b\nok  pkg/c\nok  pkg/d\nok  pkg/e\n",
+		"exec-02": "ok  pkg/a\nok  pkg/b\nFAIL pkg/c\nok  pkg/d\nok  pkg/e\n",
+	}
+	for _, id := range []string{"exec-01", "exec-02"} {
+		record := ExecutionRecord{
+			ID:      id,
+			Command: []string{"go", "test", "./..."},
+			Results: []ExecutionRepoResult{{Repository: "api"}},
+		}
+		if err := store.RecordExecution(ctx, ws.Handle, record, []ExecResult{{Repository: "api", Output: []byte(outputs[id])}}); err != nil {
+			t.Fatalf("RecordExecution failed: %v", err)
+		}
+	}
+
+	diffs, err := store.DiffExecutions(ctx, ws.Handle, "exec-01", "exec-02")
+	if err != nil {
+		t.Fatalf("DiffExecutions failed: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].Repository != "api" || len(diffs[0].Hunks) != 1 {
+		t.Fatalf("Expected one hunk for api, got %+v", diffs)
+	}
+
+	hunk := diffs[0].Hunks[0]
+	want := []string{" ok  pkg/a", " ok  pkg/b", "-ok  pkg/c", "+FAIL pkg/c", " ok  pkg/d", " ok  pkg/e"}
+	if !slices.Equal(hunk.Lines, want) {
+		t.Errorf("Expected lines %q, got %q", want, hunk.Lines)
+	}
+	if hunk.Header() != "@@ -1,5 +1,5 @@" {
+		t.Errorf("Unexpected header %q", hunk.Header())
+	}
+
+	same, err := store.DiffExecutions(ctx, ws.Handle, "exec-01", "exec-01")
+	if err != nil {
+		t.Fatalf("DiffExecutions failed: %v", err)
+	}
+	if len(same[0].Hunks) != 0 {
+		t.Errorf("Expected no hunks for identical output, got %+v", same[0].Hunks)
+	}
+
+	if _, err := store.DiffExecutions(ctx, ws.Handle, "exec-01", "missing"); err == nil {
+		t.Error("Expected an error for a missing execution")
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"}
+	b := []string{"1", "two", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13"}
+
+	hunks := DiffLines(a, b)
+	if len(hunks) != 2 {
+		t.Fatalf("Expected distant changes in separate hunks, got %+v", hunks)
+	}
+	if got := hunks[0].Header(); got != "@@ -1,5 +1,5 @@" {
+		t.Errorf("First hunk header = %q", got)
+	}
+	if got := hunks[1].Header(); got != "@@ -10,3 +10,4 @@" {
+		t.Errorf("Second hunk header = %q", got)
+	}
+
+	added := DiffLines(nil, []string{"new"})
+	if len(added) != 1 || added[0].Header() != "@@ -0,0 +1,1 @@" {
+		t.Errorf("Expected an added-file hunk, got %+v", added)
+	}
+}
+
+func TestExecTargets(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := CreateMockedTestStore(t)
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose: "Subset",
+		Repositories: []RepositoryOption{
+			{URL: "https://github.com/org/api", Ref: "main"},
+			{URL: "https://github.com/org/web", Ref: "main"},
+			{URL: "https://github.com/org/worker", Ref: "main"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, name := range []string{"api", "web", "worker"} {
+		CreateFakeRepo(t, ws.Path, name)
+	}
+
+	results, err := store.Exec(ctx, ws.Handle, ExecOptions{Command: []string{"pwd"}, Targets: []string{"api", "worker"}})
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	var ran []string
+	for _, result := range results {
+		ran = append(ran, result.Repository)
+	}
+	if !slices.Equal(ran, []string{"api", "worker"}) {
+		t.Errorf("Expected exec in api and worker only, got %v", ran)
+	}
+
+	if _, err := store.Exec(ctx, ws.Handle, ExecOptions{Command: []string{"pwd"}, Targets: []string{"missing"}}); err == nil {
+		t.Error("Expected an error for an unknown target")
+	}
+}
+
+func TestExecRetries(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := CreateMockedTestStore(t)
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose: "Flaky",
+		Repositories: []RepositoryOption{
+			{URL: "https://github.com/org/api", Ref: "main"},
+			{URL: "https://github.com/org/web", Ref: "main"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, name := range []string{"api", "web"} {
+		CreateFakeRepo(t, ws.Path, name)
+	}
+	// web has already "failed once", so only api fails on its first run.
+	if err := os.WriteFile(filepath.Join(ws.Path, "web", ".tried"), nil, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	failOnce := []string{"sh", "-c", "if [ -f .tried ]; then echo passed; exit 0; fi; touch .tried; exit 3"}
+
+	t.Run("passes within the retry budget", func(t *testing.T) {
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Command: failOnce, Retries: 2})
+		if err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %d", len(results))
+		}
+		api, web := results[0], results[1]
+		if api.ExitCode != 0 || strings.TrimSpace(string(api.Output)) != "passed" {
+			t.Errorf("Expected api to pass on retry, got exit %d: %s", api.ExitCode, api.Output)
+		}
+		if len(api.Attempts) != 2 || api.Attempts[0].ExitCode != 3 || api.Attempts[1].ExitCode != 0 {
+			t.Errorf("Expected a failed then a passing attempt in api, got %+v", api.Attempts)
+		}
+		if len(web.Attempts) != 1 {
+			t.Errorf("Expected web to run once, got %+v", web.Attempts)
+		}
+	})
+
+	t.Run("fails when retries are used up", func(t *testing.T) {
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Target: "api", Command: []string{"sh", "-c", "exit 4"}, Retries: 2})
+		if err == nil {
+			t.Fatal("Expected an error after exhausting retries")
+		}
+		if len(results) != 1 || results[0].ExitCode != 4 || len(results[0].Attempts) != 3 {
+			t.Errorf("Expected 3 attempts ending in exit 4, got %+v", results)
+		}
+	})
+
+	t.Run("does not retry a missing repository", func(t *testing.T) {
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Target: "missing", Command: []string{"true"}, Retries: 2})
+		if err == nil || !strings.Contains(err.Error(), "repository not found") || len(results) != 0 {
+			t.Errorf("Expected a repository not found error, got %v (%+v)", err, results)
+		}
+	})
+
+	t.Run("rejects negative retries", func(t *testing.T) {
+		if _, err := store.Exec(ctx, ws.Handle, ExecOptions{Command: []string{"true"}, Retries: -1}); err == nil {
+			t.Error("Expected an error for negative retries")
+		}
+	})
+}
+
+func TestCaptureLineage(t *testing.T) {
+	t.Run("links parents and backups", func(t *testing.T) {
+		captures := []Capture{
+			{ID: "01D", Name: "backup", BackupOf: "01C"},
+			{ID: "01C", Name: "step-2", Parent: "01B"},
+			{ID: "01B", Name: "step-1", Parent: "01A"},
+			{ID: "01A", Name: "base"},
+			{ID: "01E", Name: "unrelated"},
+		}
+
+		roots := CaptureLineage(captures)
+		if len(roots) != 2 || roots[0].Capture.ID != "01A" || roots[1].Capture.ID != "01E" {
+			t.Fatalf("Expected roots 01A and 01E, got %+v", roots)
+		}
+		step1 := roots[0].Children
+		if len(step1) != 1 || step1[0].Capture.ID != "01B" || step1[0].Relation != CaptureRelationChild {
+			t.Fatalf("Expected 01B under 01A, got %+v", step1)
+		}
+		step2 := step1[0].Children
+		if len(step2) != 1 || step2[0].Capture.ID != "01C" {
+			t.Fatalf("Expected 01C under 01B, got %+v", step2)
+		}
+		backups := step2[0].Children
+		if len(backups) != 1 || backups[0].Capture.ID != "01D" || backups[0].Relation != CaptureRelationBackup {
+			t.Errorf("Expected backup 01D under 01C, got %+v", backups)
+		}
+	})
+
+	t.Run("treats dangling and forward links as roots", func(t *testing.T) {
+		roots := CaptureLineage([]Capture{
+			{ID: "01A", Parent: "01B"},
+			{ID: "01B", Parent: "01A"},
+			{ID: "01C", Parent: "01Z"},
+		})
+		if len(roots) != 2 || roots[0].Capture.ID != "01A" || roots[1].Capture.ID != "01C" {
+			t.Errorf("Expected roots 01A and 01C, got %+v", roots)
+		}
+	})
+
+	t.Run("CaptureState validates and stores links", func(t *testing.T) {
+		ctx := context.Background()
+		store, _, _ := CreateMockedTestStore(t)
+		ws, err := store.Create(ctx, CreateOptions{Purpose: "Lineage", Repositories: []RepositoryOption{}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		base, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "base", Kind: CaptureKindManual})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		child, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "child", Kind: CaptureKindManual, Parent: base.ID})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		stored, err := store.GetCapture(ctx, ws.Handle, child.ID)
+		if err != nil {
+			t.Fatalf("GetCapture failed: %v", err)
+		}
+		if stored.Parent != base.ID {
+			t.Errorf("Expected parent %s, got %q", base.ID, stored.Parent)
+		}
+
+		if _, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "orphan", Kind: CaptureKindManual, BackupOf: "01NOTACAPTURE"}); err == nil {
+			t.Error("Expected an error for an unknown linked capture")
+		}
+	})
+}
+
+func TestRecentHandles(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := CreateMockedTestStore(t)
+
+	var handles []string
+	for _, purpose := range []string{"first", "second", "third"} {
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose:      purpose,
+			Repositories: []RepositoryOption{{URL: "https://github.com/test/repo", Ref: "main"}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		handles = append(handles, ws.Handle)
+	}
+
+	recentHandles := func() []string {
+		t.Helper()
+		entries, err := store.RecentHandles(ctx)
+		if err != nil {
+			t.Fatalf("RecentHandles failed: %v", err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Handle)
+		}
+		return got
+	}
+
+	t.Run("empty without history", func(t *testing.T) {
+		if got := recentHandles(); len(got) != 0 {
+			t.Errorf("Expected no recent handles, got %v", got)
+		}
+	})
+
+	t.Run("most recent first", func(t *testing.T) {
+		for _, h := range []string{handles[0], handles[1], handles[0]} {
+			if err := store.TouchRecent(ctx, h); err != nil {
+				t.Fatalf("TouchRecent failed: %v", err)
+			}
+		}
+		got := recentHandles()
+		want := []string{handles[0], handles[1]}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("skips removed workspaces", func(t *testing.T) {
+		if err := store.TouchRecent(ctx, handles[2]); err != nil {
+			t.Fatalf("TouchRecent failed: %v", err)
+		}
+		if err := store.Remove(ctx, handles[2]); err != nil {
+			t.Fatalf("Remove failed: %v", err)
+		}
+		got := recentHandles()
+		want := []string{handles[0], handles[1]}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("recent file is not listed as a workspace", func(t *testing.T) {
+		workspaces, err := store.List(ctx, ListOptions{})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(workspaces) != 2 {
+			t.Errorf("Expected 2 workspaces, got %d", len(workspaces))
+		}
+	})
+}
+
+func TestInspectRepository(t *testing.T) {
+	ctx := context.Background()
+	store, _, mockGit := CreateMockedTestStore(t)
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose: "Inspect",
+		Repositories: []RepositoryOption{
+			{URL: "https://github.com/test/api", Ref: "main"},
+			{URL: "https://github.com/test/web", Ref: "main"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	t.Run("reports configuration and live state", func(t *testing.T) {
+		mockGit.SetStatusPorcelainResult(" M file.go")
+		mockGit.SetAheadBehindResult(1, 0)
+
+		detail, err := store.InspectRepository(ctx, ws.Handle, "web")
+		if err != nil {
+			t.Fatalf("InspectRepository failed: %v", err)
+		}
+		if detail.Repository.URL != "https://github.com/test/web" || detail.Path != filepath.Join(ws.Path, "web") {
+			t.Errorf("Unexpected repository: %+v", detail)
+		}
+		if detail.State == nil || detail.State.Branch != "main" || !detail.State.Dirty {
+			t.Errorf("Unexpected state: %+v (err %v)", detail.State, detail.StateErr)
+		}
+		if !detail.Status.HasUpstream || detail.Status.Ahead != 1 {
+			t.Errorf("Unexpected status: %+v", detail.Status)
+		}
+	})
+
+	t.Run("unknown name lists the available repositories", func(t *testing.T) {
+		_, err := store.InspectRepository(ctx, ws.Handle, "cli")
+		if err == nil || err.Error() != "repository not found: cli (available: api, web)" {
+			t.Errorf("Expected not found error listing api and web, got %v", err)
+		}
+	})
+}
+
+func TestRepositoryStatuses(t *testing.T) {
+	ctx := context.Background()
+	store, _, mockGit := CreateMockedTestStore(t)
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "Status",
+		Repositories: []RepositoryOption{{URL: "https://github.com/test/repo", Ref: "main"}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	t.Run("reports ahead and behind counts", func(t *testing.T) {
+		mockGit.SetStatusPorcelainResult(" M file.go")
+		mockGit.SetAheadBehindResult(2, 1)
+
+		statuses, err := store.RepositoryStatuses(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("RepositoryStatuses failed: %v", err)
+		}
+		if len(statuses) != 1 {
+			t.Fatalf("Expected 1 status, got %d", len(statuses))
+		}
+		st := statuses[0]
+		if !st.HasUpstream || st.Ahead != 2 || st.Behind != 1 || !st.Dirty {
+			t.Errorf("Unexpected status: %+v", st)
+		}
+		if got := st.Summary(); got != "dirty, ahead 2, behind 1" {
+			t.Errorf("Expected summary 'dirty, ahead 2, behind 1', got %q", got)
+		}
+	})
+
+	t.Run("handles a branch without upstream", func(t *testing.T) {
+		mockGit.SetStatusPorcelainResult("")
+		mockGit.SetAheadBehindErr(git.ErrNoUpstream)
+
+		statuses, err := store.RepositoryStatuses(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("RepositoryStatuses failed: %v", err)
+		}
+		st := statuses[0]
+		if st.HasUpstream || st.Err != nil {
+			t.Errorf("Expected no upstream and no error, got %+v", st)
+		}
+		if got := st.Summary(); got != "clean, no upstream" {
+			t.Errorf("Expected summary 'clean, no upstream', got %q", got)
+		}
+	})
+
+	t.Run("records git failures per repository", func(t *testing.T) {
+		mockGit.SetAheadBehindErr(errors.New("rev-list failed"))
+
+		statuses, err := store.RepositoryStatuses(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("RepositoryStatuses failed: %v", err)
+		}
+		if statuses[0].Err == nil || statuses[0].Summary() != "unavailable" {
+			t.Errorf("Expected an unavailable status, got %+v", statuses[0])
+		}
+	})
+}
+
+func TestCaptureSourcePurpose(t *testing.T) {
+	ctx := context.Background()
+	store, _, mockGit := CreateMockedTestStore(t)
+	mockGit.SetRevParseResult("abc123")
+	mockGit.SetStatusPorcelainResult("")
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "Fix payment timeout",
+		Repositories: []RepositoryOption{{URL: "https://github.com/test/repo", Ref: "main"}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Kind: CaptureKindCheckpoint})
+	if err != nil {
+		t.Fatalf("CaptureState failed: %v", err)
+	}
+
+	t.Run("records the workspace purpose", func(t *testing.T) {
+		stored, err := store.GetCapture(ctx, ws.Handle, capture.ID)
+		if err != nil {
+			t.Fatalf("GetCapture failed: %v", err)
+		}
+		if stored.SourcePurpose != "Fix payment timeout" || stored.Handle != ws.Handle {
+			t.Errorf("Expected source %s (Fix payment timeout), got %s (%s)", ws.Handle, stored.Handle, stored.SourcePurpose)
 		}
 	})
 
-	t.Run("should convert github.com to ssh when SSH_AUTH_SOCK set", func(t *testing.T) {
-		t.Skip("flaky: depends on .ssh directory contents")
-		_ = os.Setenv("SSH_AUTH_SOCK", "/tmp/ssh-agent.sock")
-		_ = os.Unsetenv(envGitProtocol)
-		url := selectGitProtocol("github.com/user/repo")
-		if url != "git@github.com:user/repo" {
-			t.Errorf("Expected SSH URL, got: %s", url)
+	t.Run("is included in the exported context", func(t *testing.T) {
+		exported, err := store.ExportContext(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ExportContext failed: %v", err)
+		}
+		if len(exported.Captures) != 1 || exported.Captures[0].SourcePurpose != "Fix payment timeout" {
+			t.Errorf("Expected exported capture to carry the purpose, got %+v", exported.Captures)
 		}
 	})
 
-	t.Run("should respect WORKSHED_GIT_PROTOCOL=ssh", func(t *testing.T) {
-		_ = os.Unsetenv("SSH_AUTH_SOCK")
-		_ = os.Setenv(envGitProtocol, "ssh")
-		url := selectGitProtocol("github.com/user/repo")
-		if url != "git@github.com:user/repo" {
-			t.Errorf("Expected SSH URL, got: %s", url)
+	t.Run("older captures without the field still load", func(t *testing.T) {
+		var old Capture
+		if err := json.Unmarshal([]byte(`{"id":"01ABC","handle":"h","name":"n","kind":"manual","git_state":[],"metadata":{}}`), &old); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if old.SourcePurpose != "" {
+			t.Errorf("Expected empty source purpose, got %q", old.SourcePurpose)
 		}
 	})
+}
 
-	t.Run("should respect WORKSHED_GIT_PROTOCOL=https", func(t *testing.T) {
-		_ = os.Setenv("SSH_AUTH_SOCK", "/tmp/ssh-agent.sock")
-		_ = os.Setenv(envGitProtocol, "https")
-		url := selectGitProtocol("github.com/user/repo")
-		if url != "https://github.com/user/repo" {
-			t.Errorf("Expected https URL, got: %s", url)
+func TestExecTargetPatterns(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := CreateMockedTestStore(t)
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose: "Targets",
+		Repositories: []RepositoryOption{
+			{URL: "https://github.com/org/svc-api", Ref: "main"},
+			{URL: "https://github.com/org/docs", Ref: "main"},
+			{URL: "https://github.com/org/svc-web", Ref: "main"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, name := range []string{"svc-api", "docs", "svc-web"} {
+		CreateFakeRepo(t, ws.Path, name)
+	}
+
+	names := func(target string) ([]string, error) {
+		t.Helper()
+		targets, err := store.PlanExec(ctx, ws.Handle, ExecOptions{Target: target, Command: []string{"true"}})
+		var got []string
+		for _, target := range targets {
+			got = append(got, target.Repository)
+		}
+		return got, err
+	}
+
+	for target, want := range map[string][]string{
+		"":               {"svc-api", "docs", "svc-web"},
+		"all":            {"svc-api", "docs", "svc-web"},
+		"root":           {"root"},
+		"docs":           {"docs"},
+		"svc-*":          {"svc-api", "svc-web"},
+		"svc-web,docs":   {"docs", "svc-web"},
+		"svc-*, svc-api": {"svc-api", "svc-web"},
+	} {
+		got, err := names(target)
+		if err != nil {
+			t.Errorf("PlanExec(%q) failed: %v", target, err)
+			continue
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("PlanExec(%q) = %v, want %v", target, got, want)
+		}
+	}
+
+	for target, want := range map[string]string{
+		"cli":      "repository not found: cli",
+		"web-*":    "no repository matches web-*",
+		"svc-[":    "invalid repository pattern",
+		"docs,cli": "repository not found: cli",
+		" , ":      "no repositories selected",
+	} {
+		if _, err := names(target); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("PlanExec(%q): expected %q, got %v", target, want, err)
+		}
+	}
+
+	t.Run("exec runs in the globbed repositories", func(t *testing.T) {
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Target: "svc-*", Command: []string{"pwd"}})
+		if err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if len(results) != 2 || results[0].Repository != "svc-api" || results[1].Repository != "svc-web" {
+			t.Errorf("Unexpected results: %+v", results)
 		}
 	})
+}
 
-	t.Run("should pass through non-github.com URLs unchanged", func(t *testing.T) {
-		_ = os.Setenv("SSH_AUTH_SOCK", "/tmp/ssh-agent.sock")
-		_ = os.Unsetenv(envGitProtocol)
-		tests := []string{
-			"https://gitlab.com/user/repo",
-			"git@gitlab.com:user/repo",
-			"/path/to/local/repo",
-			"./relative/repo",
+func TestExecContinueOnError(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := CreateMockedTestStore(t)
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose: "Gating",
+		Repositories: []RepositoryOption{
+			{URL: "https://github.com/org/api", Ref: "main"},
+			{URL: "https://github.com/org/web", Ref: "main"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, name := range []string{"api", "web"} {
+		CreateFakeRepo(t, ws.Path, name)
+	}
+	failInAPI := []string{"sh", "-c", `[ "$(basename "$PWD")" != api ]`}
+
+	t.Run("stops at the first failure by default", func(t *testing.T) {
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Command: failInAPI})
+		if err == nil {
+			t.Fatal("Expected Exec to fail")
 		}
-		for _, input := range tests {
-			url := selectGitProtocol(input)
-			if url != input {
-				t.Errorf("Expected URL unchanged for %q, got: %s", input, url)
+		if len(results) != 1 {
+			t.Errorf("Expected to stop after api, got %d results", len(results))
+		}
+	})
+
+	t.Run("runs every repository when continuing", func(t *testing.T) {
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Command: failInAPI, ContinueOnError: true})
+		if err != nil {
+			t.Fatalf("Expected failures to be left to the caller, got %v", err)
+		}
+		if len(results) != 2 || results[0].ExitCode == 0 || results[1].ExitCode != 0 {
+			t.Errorf("Expected api to fail and web to pass, got %+v", results)
+		}
+	})
+}
+
+func TestExecParallel(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := CreateMockedTestStore(t)
+
+	names := []string{"svc-a", "svc-b", "svc-c", "svc-d", "svc-e", "svc-f", "svc-g", "svc-h"}
+	var opts []RepositoryOption
+	for _, name := range names {
+		opts = append(opts, RepositoryOption{URL: "https://github.com/org/" + name, Ref: "main"})
+	}
+	ws, err := store.Create(ctx, CreateOptions{Purpose: "Monorepo build", Repositories: opts})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, name := range names {
+		CreateFakeRepo(t, ws.Path, name)
+	}
+
+	t.Run("results keep repository order", func(t *testing.T) {
+		// Earlier repositories sleep longer, so they finish last.
+		command := []string{"sh", "-c", `case "$(basename "$PWD")" in svc-a) sleep 0.3;; svc-b) sleep 0.2;; esac; basename "$PWD"`}
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Command: command, Parallel: true})
+		if err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if len(results) != len(names) {
+			t.Fatalf("Expected %d results, got %d", len(names), len(results))
+		}
+		for i, result := range results {
+			if result.Repository != names[i] || strings.TrimSpace(string(result.Output)) != names[i] {
+				t.Errorf("Result %d: expected %s, got %s (%q)", i, names[i], result.Repository, result.Output)
 			}
 		}
 	})
 
-	t.Run("should preserve ref and depth in URL", func(t *testing.T) {
-		_ = os.Unsetenv("SSH_AUTH_SOCK")
-		_ = os.Unsetenv(envGitProtocol)
-		url := selectGitProtocol("github.com/user/repo")
-		if url != "https://github.com/user/repo" {
-			t.Errorf("Expected https URL, got: %s", url)
+	t.Run("a failure does not stop the others", func(t *testing.T) {
+		command := []string{"sh", "-c", `case "$(basename "$PWD")" in svc-b|svc-f) exit 3;; esac`}
+		results, err := store.Exec(ctx, ws.Handle, ExecOptions{Command: command, Parallel: true})
+		if err == nil {
+			t.Fatal("Expected Exec to fail")
+		}
+		if len(results) != len(names) {
+			t.Fatalf("Expected every repository to run, got %d results", len(results))
+		}
+		for _, name := range []string{"svc-b", "svc-f"} {
+			if !strings.Contains(err.Error(), "command failed in "+name+" with exit code 3") {
+				t.Errorf("Expected %s in the aggregated error, got: %v", name, err)
+			}
+		}
+		if strings.Contains(err.Error(), "svc-a") {
+			t.Errorf("Expected only failing repositories in the error, got: %v", err)
 		}
 	})
 }
 
-func TestSshAgentAvailable(t *testing.T) {
-	origAuthSock := os.Getenv("SSH_AUTH_SOCK")
-	origHome := os.Getenv("HOME")
-	defer func() {
-		_ = os.Setenv("SSH_AUTH_SOCK", origAuthSock)
-		if origHome != "" {
-			_ = os.Setenv("HOME", origHome)
-		} else {
-			_ = os.Unsetenv("HOME")
+func TestCaptureAutoIntent(t *testing.T) {
+	ctx := context.Background()
+	store, _, _ := CreateMockedTestStore(t)
+
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose:      "Auto intent",
+		Repositories: []RepositoryOption{{URL: "https://github.com/org/api", Ref: "main"}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	CreateFakeRepo(t, ws.Path, "api")
+
+	t.Run("no execution leaves the intent to the caller", func(t *testing.T) {
+		_, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "bare", AutoIntent: true})
+		if err == nil || !strings.Contains(err.Error(), "must have intent") {
+			t.Errorf("Expected the intent requirement to apply, got %v", err)
 		}
-	}()
+	})
 
-	t.Run("should return false when SSH_AUTH_SOCK not set", func(t *testing.T) {
-		_ = os.Unsetenv("SSH_AUTH_SOCK")
-		if sshAgentAvailable() {
-			t.Error("Expected false when SSH_AUTH_SOCK not set")
+	record := func(t *testing.T, id string, command []string, finished time.Time) {
+		t.Helper()
+		rec := ExecutionRecord{ID: id, Timestamp: finished, CompletedAt: finished, Command: command, Results: []ExecutionRepoResult{{Repository: "api"}}}
+		if err := store.RecordExecution(ctx, ws.Handle, rec, nil); err != nil {
+			t.Fatalf("RecordExecution failed: %v", err)
+		}
+	}
+
+	t.Run("stale execution is ignored", func(t *testing.T) {
+		record(t, "01HAAAAAAAAAAAAAAAAAAAAAAA", []string{"make", "old"}, time.Now().Add(-2*AutoIntentWindow))
+		_, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "stale", AutoIntent: true})
+		if err == nil {
+			t.Error("Expected a stale execution not to satisfy the intent requirement")
 		}
 	})
 
-	t.Run("should return false when HOME not set", func(t *testing.T) {
-		_ = os.Setenv("SSH_AUTH_SOCK", "/tmp/ssh-agent.sock")
-		_ = os.Unsetenv("HOME")
-		if sshAgentAvailable() {
-			t.Error("Expected false when HOME not set")
+	t.Run("recent execution describes the capture", func(t *testing.T) {
+		record(t, "01HBBBBBBBBBBBBBBBBBBBBBBB", []string{"go", "test", "./..."}, time.Now())
+		capture, err := store.CaptureState(ctx, ws.Handle, CaptureOptions{Name: "tested", AutoIntent: true})
+		if err != nil {
+			t.Fatalf("CaptureState failed: %v", err)
+		}
+		if capture.Metadata.Description != "after running: go test ./..." {
+			t.Errorf("Unexpected description %q", capture.Metadata.Description)
 		}
 	})
+}
 
-	t.Run("should return false when .ssh directory does not exist", func(t *testing.T) {
-		_ = os.Setenv("SSH_AUTH_SOCK", "/tmp/ssh-agent.sock")
-		_ = os.Setenv("HOME", "/nonexistent-home")
-		if sshAgentAvailable() {
-			t.Error("Expected false when .ssh directory does not exist")
+func TestPostClone(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("runs in the checked-out repository and is recorded", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
+		api := CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"})
+		web := CreateLocalGitRepo(t, "web", map[string]string{"README.md": "# Web"})
+
+		ws, err := store.Create(ctx, CreateOptions{
+			Purpose: "Setup",
+			Repositories: []RepositoryOption{
+				{URL: api, PostClone: ShellCommand("test -f README.md && pwd > setup.txt && echo configured")},
+				{URL: web},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(ws.Path, "api", "setup.txt"))
+		if err != nil {
+			t.Fatalf("Expected the setup command to write into the api directory: %v", err)
+		}
+		want, _ := filepath.EvalSymlinks(filepath.Join(ws.Path, "api"))
+		if got, _ := filepath.EvalSymlinks(strings.TrimSpace(string(data))); got != want {
+			t.Errorf("Expected the setup command to run in %s, ran in %s", want, got)
+		}
+		if _, err := os.Stat(filepath.Join(ws.Path, "web", "setup.txt")); !os.IsNotExist(err) {
+			t.Errorf("Expected no setup in web, got err=%v", err)
+		}
+
+		records, err := store.ListExecutions(ctx, ws.Handle, ListExecutionsOptions{})
+		if err != nil {
+			t.Fatalf("ListExecutions failed: %v", err)
+		}
+		if len(records) != 1 {
+			t.Fatalf("Expected one recorded setup execution, got %+v", records)
+		}
+		if records[0].Target != "api" || records[0].ExitCode != 0 || !slices.Equal(records[0].Command, ShellCommand("test -f README.md && pwd > setup.txt && echo configured")) {
+			t.Errorf("Unexpected setup record %+v", records[0])
+		}
+
+		got, err := store.Get(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if repo := got.GetRepositoryByName("api"); repo == nil || len(repo.PostClone) == 0 {
+			t.Errorf("Expected the setup command in metadata, got %+v", got.Repositories)
+		}
+	})
+
+	t.Run("a failing setup command aborts the create", func(t *testing.T) {
+		store, root := CreateTestStore(t)
+		api := CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"})
+
+		_, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Broken setup",
+			Repositories: []RepositoryOption{{URL: api, PostClone: ShellCommand("echo missing toolchain >&2; exit 3")}},
+		})
+		if err == nil {
+			t.Fatal("Expected Create to fail")
+		}
+		if !strings.Contains(err.Error(), "setup command in api failed") || !strings.Contains(err.Error(), "missing toolchain") {
+			t.Errorf("Expected the failing command and its output in the error, got: %v", err)
+		}
+
+		all, err := store.List(ctx, ListOptions{})
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(all) != 0 {
+			t.Errorf("Expected no workspace to be left behind, got %d", len(all))
+		}
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			t.Fatalf("ReadDir failed: %v", err)
+		}
+		for _, e := range entries {
+			if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+				t.Errorf("Expected the finalized workspace directory to be removed, found %s", e.Name())
+			}
+		}
+		MustNotHaveTempDirs(t, root)
+	})
+
+	t.Run("a failing setup command aborts the add", func(t *testing.T) {
+		store, _ := CreateTestStore(t)
+		api := CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"})
+		web := CreateLocalGitRepo(t, "web", map[string]string{"README.md": "# Web"})
+
+		ws, err := store.Create(ctx, CreateOptions{Purpose: "Add setup", Repositories: []RepositoryOption{{URL: api}}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		err = store.AddRepositories(ctx, ws.Handle, []RepositoryOption{{URL: web, PostClone: ShellCommand("exit 1")}}, "")
+		if err == nil || !strings.Contains(err.Error(), "setup command in web failed") {
+			t.Fatalf("Expected AddRepositories to fail on setup, got: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(ws.Path, "web")); !os.IsNotExist(err) {
+			t.Errorf("Expected the web clone to be removed, got err=%v", err)
+		}
+		got, err := store.Get(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if len(got.Repositories) != 1 {
+			t.Errorf("Expected metadata to be unchanged, got %+v", got.Repositories)
+		}
+	})
+
+	t.Run("manifest lines and --repo-setup values set the command", func(t *testing.T) {
+		repos, err := ParseManifest(strings.NewReader("github.com/org/api@main  make deps\ngithub.com/org/web\n"))
+		if err != nil {
+			t.Fatalf("ParseManifest failed: %v", err)
+		}
+		if len(repos) != 2 || repos[0].Ref != "main" || !slices.Equal(repos[0].PostClone, ShellCommand("make deps")) || repos[1].PostClone != nil {
+			t.Errorf("Unexpected manifest entries %+v", repos)
+		}
+
+		setups, err := ParseSetupFlags([]string{"web=npm ci"})
+		if err != nil {
+			t.Fatalf("ParseSetupFlags failed: %v", err)
+		}
+		if err := AssignPostClone(repos, setups, ""); err != nil {
+			t.Fatalf("AssignPostClone failed: %v", err)
+		}
+		if !slices.Equal(repos[1].PostClone, ShellCommand("npm ci")) {
+			t.Errorf("Expected web to get its setup command, got %+v", repos[1])
+		}
+
+		if err := AssignPostClone(repos, map[string][]string{"docs": ShellCommand("true")}, ""); err == nil {
+			t.Error("Expected a setup for an unknown repository to fail")
+		}
+		for _, bad := range []string{"api", "=make", "api="} {
+			if _, err := ParseSetupFlags([]string{bad}); err == nil {
+				t.Errorf("Expected %q to be rejected", bad)
+			}
+		}
+	})
+}
+
+func TestStash(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	ctx := context.Background()
+	store, _ := CreateTestStore(t)
+	ws, err := store.Create(ctx, CreateOptions{
+		Purpose: "Stash",
+		Repositories: []RepositoryOption{
+			{URL: CreateLocalGitRepo(t, "api", map[string]string{"README.md": "# API"})},
+			{URL: CreateLocalGitRepo(t, "web", map[string]string{"README.md": "# Web"})},
+			{URL: CreateLocalGitRepo(t, "docs", map[string]string{"README.md": "# Docs"})},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	readme := filepath.Join(ws.Path, "api", "README.md")
+	untracked := filepath.Join(ws.Path, "web", "notes.txt")
+	if err := os.WriteFile(readme, []byte("# API changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(untracked, []byte("wip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("stashes changed repositories and skips clean ones", func(t *testing.T) {
+		results, err := store.Stash(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("Stash failed: %v", err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("Expected a result per repository, got %+v", results)
+		}
+		for _, res := range results {
+			if stashed := res.Commit != ""; stashed != (res.Repository != "docs") {
+				t.Errorf("Unexpected stash result %+v", res)
+			}
+		}
+
+		if data, _ := os.ReadFile(readme); string(data) != "# API" {
+			t.Errorf("Expected the api change to be stashed, README is %q", data)
+		}
+		if _, err := os.Stat(untracked); !os.IsNotExist(err) {
+			t.Errorf("Expected the untracked web file to be stashed, got err=%v", err)
+		}
+
+		entries, err := store.ListStashes(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("ListStashes failed: %v", err)
+		}
+		if len(entries) != 1 || len(entries[0].Commits) != 2 {
+			t.Errorf("Expected one stash entry covering api and web, got %+v", entries)
+		}
+	})
+
+	t.Run("pop restores every repository", func(t *testing.T) {
+		results, err := store.StashPop(ctx, ws.Handle)
+		if err != nil {
+			t.Fatalf("StashPop failed: %v", err)
+		}
+		for _, res := range results {
+			if restored := res.Commit != ""; restored != (res.Repository != "docs") {
+				t.Errorf("Unexpected pop result %+v", res)
+			}
+		}
+
+		if data, _ := os.ReadFile(readme); string(data) != "# API changed" {
+			t.Errorf("Expected the api change to be restored, README is %q", data)
+		}
+		if data, _ := os.ReadFile(untracked); string(data) != "wip" {
+			t.Errorf("Expected the untracked web file to be restored, got %q", data)
+		}
+	})
+
+	t.Run("pop without a stash fails", func(t *testing.T) {
+		if _, err := store.StashPop(ctx, ws.Handle); !errors.Is(err, ErrNoStash) {
+			t.Errorf("Expected ErrNoStash, got %v", err)
+		}
+	})
+
+	t.Run("nothing to stash records no entry", func(t *testing.T) {
+		clean, err := store.Create(ctx, CreateOptions{
+			Purpose:      "Clean",
+			Repositories: []RepositoryOption{{URL: CreateLocalGitRepo(t, "lib", map[string]string{"README.md": "# Lib"})}},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := store.Stash(ctx, clean.Handle); err != nil {
+			t.Fatalf("Stash failed: %v", err)
+		}
+		if _, err := store.StashPop(ctx, clean.Handle); !errors.Is(err, ErrNoStash) {
+			t.Errorf("Expected ErrNoStash, got %v", err)
 		}
 	})
 }
@@ -0,0 +1,175 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// trashDirName holds trashed workspaces under the store root. The leading dot
+// keeps it out of handle space; List skips it because it has no metadata.
+const trashDirName = ".workshed-trash"
+
+// DefaultTrashRetention is how long EmptyTrash keeps trashed workspaces by default.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashEntry is a workspace moved to the trash by TrashWorkspace.
+type TrashEntry struct {
+	// ID names the entry's directory in the trash; its ULID encodes TrashedAt.
+	ID        string    `json:"id"`
+	Handle    string    `json:"handle"`
+	Purpose   string    `json:"purpose"`
+	TrashedAt time.Time `json:"trashed_at"`
+}
+
+func (s *FSStore) trashDir() string {
+	return filepath.Join(s.root, trashDirName)
+}
+
+// TrashWorkspace moves a workspace into the trash instead of deleting it, so
+// RestoreWorkspace can bring it back. The move is a rename, so the trash
+// lives under the store root to stay on the same filesystem.
+func (s *FSStore) TrashWorkspace(ctx context.Context, handle string) (*TrashEntry, error) {
+	ws, err := s.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(s.trashDir(), 0755); err != nil {
+		return nil, fmt.Errorf("creating trash directory: %w", err)
+	}
+
+	id := s.newID()
+	if err := os.Rename(ws.Path, filepath.Join(s.trashDir(), id.String())); err != nil {
+		return nil, fmt.Errorf("moving workspace to trash: %w", err)
+	}
+
+	s.emit(ctx, StoreEvent{Type: EventWorkspaceRemoved, Handle: ws.Handle, Purpose: ws.Purpose, Path: ws.Path})
+	return &TrashEntry{ID: id.String(), Handle: ws.Handle, Purpose: ws.Purpose, TrashedAt: ulid.Time(id.Time())}, nil
+}
+
+// ListTrash returns trashed workspaces, newest first.
+func (s *FSStore) ListTrash(ctx context.Context) ([]TrashEntry, error) {
+	dirEntries, err := os.ReadDir(s.trashDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []TrashEntry{}, nil
+		}
+		return nil, fmt.Errorf("reading trash directory: %w", err)
+	}
+
+	entries := []TrashEntry{}
+	for _, e := range dirEntries {
+		if !e.IsDir() {
+			continue
+		}
+		id, err := ulid.ParseStrict(e.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.trashDir(), e.Name(), metadataFileName))
+		if err != nil {
+			continue
+		}
+		var ws Workspace
+		if err := json.Unmarshal(data, &ws); err != nil {
+			continue
+		}
+		entries = append(entries, TrashEntry{
+			ID:        e.Name(),
+			Handle:    ws.Handle,
+			Purpose:   ws.Purpose,
+			TrashedAt: ulid.Time(id.Time()),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID > entries[j].ID
+	})
+	return entries, nil
+}
+
+// RestoreWorkspace moves a trashed workspace back into the store. ref is a
+// trash entry ID or a handle; a handle restores its most recently trashed
+// entry. If a live workspace has taken the handle meanwhile, the restored
+// workspace gets a new one.
+func (s *FSStore) RestoreWorkspace(ctx context.Context, ref string) (*Workspace, error) {
+	entries, err := s.ListTrash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := FindTrashEntry(entries, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	trashed := filepath.Join(s.trashDir(), entry.ID)
+	data, err := os.ReadFile(filepath.Join(trashed, metadataFileName))
+	if err != nil {
+		return nil, fmt.Errorf("reading metadata: %w", err)
+	}
+	var ws Workspace
+	if err := json.Unmarshal(data, &ws); err != nil {
+		return nil, fmt.Errorf("parsing metadata: %w", err)
+	}
+
+	finalDir, err := s.finalizeWorkspaceDir(&ws, trashed)
+	if err != nil {
+		return nil, fmt.Errorf("restoring workspace: %w", err)
+	}
+	ws.Path = finalDir
+	return &ws, nil
+}
+
+// FindTrashEntry returns the entry of entries whose ID or handle is ref. With
+// entries in ListTrash order, a handle picks its most recently trashed entry.
+func FindTrashEntry(entries []TrashEntry, ref string) (*TrashEntry, error) {
+	for i := range entries {
+		if entries[i].ID == ref || entries[i].Handle == ref {
+			return &entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no trashed workspace matches %q", ref)
+}
+
+// ExpiredTrash returns the trashed workspaces EmptyTrash would delete for
+// olderThan, newest first. Zero selects everything in the trash.
+func (s *FSStore) ExpiredTrash(ctx context.Context, olderThan time.Duration) ([]TrashEntry, error) {
+	entries, err := s.ListTrash(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expired := []TrashEntry{}
+	for _, entry := range entries {
+		if olderThan > 0 && s.clock.Now().Sub(entry.TrashedAt) < olderThan {
+			continue
+		}
+		expired = append(expired, entry)
+	}
+	return expired, nil
+}
+
+// EmptyTrash permanently deletes trashed workspaces older than olderThan and
+// returns them. Zero deletes everything in the trash.
+func (s *FSStore) EmptyTrash(ctx context.Context, olderThan time.Duration) ([]TrashEntry, error) {
+	expired, err := s.ExpiredTrash(ctx, olderThan)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []TrashEntry{}
+	for _, entry := range expired {
+		if err := os.RemoveAll(filepath.Join(s.trashDir(), entry.ID)); err != nil {
+			return removed, fmt.Errorf("deleting %s: %w", entry.Handle, err)
+		}
+		removed = append(removed, entry)
+	}
+	return removed, nil
+}
@@ -1,11 +1,16 @@
 package workspace
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
+// ContextVersion is written to exports. See contextMigrations before changing it.
 const ContextVersion = 1
 
+const LockVersion = 1
+
 type ExecutionRecord struct {
 	ID          string                `json:"id"`
 	Timestamp   time.Time             `json:"timestamp"`
@@ -23,9 +28,18 @@ type ExecutionRecord struct {
 type ExecutionRepoResult struct {
 	Repository string `json:"repository"`
 	ExitCode   int    `json:"exit_code"`
+	// Signal names the signal that killed the command; see ExecResult.Signal.
+	Signal     string `json:"signal,omitempty"`
 	Duration   int64  `json:"duration_ms"`
 	OutputPath string `json:"output_path,omitempty"`
 	Error      string `json:"error,omitempty"`
+	// Attempts records every run when the command was retried.
+	Attempts []ExecutionAttempt `json:"attempts,omitempty"`
+}
+
+type ExecutionAttempt struct {
+	ExitCode int   `json:"exit_code"`
+	Duration int64 `json:"duration_ms"`
 }
 
 type Capture struct {
@@ -36,6 +50,17 @@ type Capture struct {
 	Kind      string          `json:"kind"`
 	GitState  []GitRef        `json:"git_state"`
 	Metadata  CaptureMetadata `json:"metadata"`
+
+	// SourcePurpose is the workspace's purpose when the capture was taken, so
+	// a shared capture keeps its context. Captures from older versions lack it.
+	SourcePurpose string `json:"source_purpose,omitempty"`
+
+	// Parent is the ID of the capture this one builds on.
+	Parent string `json:"parent,omitempty"`
+
+	// BackupOf is the ID of the capture that was about to be applied when
+	// this one was taken as a backup.
+	BackupOf string `json:"backup_of,omitempty"`
 }
 
 // CaptureKind describes the intent behind a capture.
@@ -64,6 +89,7 @@ type WorkspaceContext struct {
 	GeneratedAt  time.Time        `json:"generated_at"`
 	Handle       string           `json:"handle"`
 	Purpose      string           `json:"purpose"`
+	Project      string           `json:"project,omitempty"`
 	Repositories []ContextRepo    `json:"repositories"`
 	Captures     []ContextCapture `json:"captures,omitempty"`
 	Metadata     ContextMetadata  `json:"metadata"`
@@ -77,14 +103,33 @@ type ContextCapture struct {
 	Description string    `json:"description,omitempty"`
 	Tags        []string  `json:"tags,omitempty"`
 	RepoCount   int       `json:"repo_count"`
+	// SourceHandle and SourcePurpose name the workspace the capture was taken in.
+	SourceHandle  string `json:"source_handle,omitempty"`
+	SourcePurpose string `json:"source_purpose,omitempty"`
 }
 
 type ContextRepo struct {
-	Name     string `json:"name"`
-	Path     string `json:"path"`
-	URL      string `json:"url"`
-	RootPath string `json:"root_path"`
-	Ref      string `json:"ref,omitempty"`
+	Name     string            `json:"name"`
+	Path     string            `json:"path"`
+	URL      string            `json:"url"`
+	RootPath string            `json:"root_path"`
+	Ref      string            `json:"ref,omitempty"`
+	Sparse   []string          `json:"sparse,omitempty"`
+	Remotes  map[string]string `json:"remotes,omitempty"`
+	LFS      bool              `json:"lfs,omitempty"`
+}
+
+// Lockfile pins every repository of a workspace to an exact commit.
+type Lockfile struct {
+	Version      int          `json:"version"`
+	GeneratedAt  time.Time    `json:"generated_at"`
+	Repositories []LockedRepo `json:"repositories"`
+}
+
+type LockedRepo struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Commit string `json:"commit"`
 }
 
 type ContextMetadata struct {
@@ -93,6 +138,50 @@ type ContextMetadata struct {
 	CapturesCount   int        `json:"captures_count"`
 	LastExecutedAt  *time.Time `json:"last_executed_at,omitempty"`
 	LastCapturedAt  *time.Time `json:"last_captured_at,omitempty"`
+	// LastActivityAt is the latest of creation, last execution and last capture.
+	LastActivityAt time.Time `json:"last_activity_at"`
+}
+
+// RetentionPolicy limits how many execution records a workspace keeps.
+// Zero values disable the corresponding limit, so the zero policy keeps
+// everything.
+type RetentionPolicy struct {
+	// MaxExecutions keeps only the newest N records.
+	MaxExecutions int
+
+	// MaxAge removes records older than this duration.
+	MaxAge time.Duration
+}
+
+// retentionPolicyJSON stores MaxAge as a duration string such as "168h".
+type retentionPolicyJSON struct {
+	MaxExecutions int    `json:"max_executions,omitempty"`
+	MaxAge        string `json:"max_age,omitempty"`
+}
+
+func (p RetentionPolicy) MarshalJSON() ([]byte, error) {
+	out := retentionPolicyJSON{MaxExecutions: p.MaxExecutions}
+	if p.MaxAge > 0 {
+		out.MaxAge = p.MaxAge.String()
+	}
+	return json.Marshal(out)
+}
+
+func (p *RetentionPolicy) UnmarshalJSON(data []byte) error {
+	var in retentionPolicyJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	p.MaxExecutions = in.MaxExecutions
+	p.MaxAge = 0
+	if in.MaxAge != "" {
+		d, err := time.ParseDuration(in.MaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid max_age %q: %w", in.MaxAge, err)
+		}
+		p.MaxAge = d
+	}
+	return nil
 }
 
 type ListExecutionsOptions struct {
@@ -107,6 +196,16 @@ type CaptureOptions struct {
 	Description string
 	Tags        []string
 	Custom      map[string]string
+	// UniqueName rejects the capture if another capture in the workspace
+	// already has Name, so the name can serve as a stable identifier.
+	UniqueName bool
+	// Parent and BackupOf link the capture to an existing one; see
+	// Capture.Parent and Capture.BackupOf.
+	Parent   string
+	BackupOf string
+	// AutoIntent fills an empty Description with the command of the latest
+	// execution, when it finished within AutoIntentWindow.
+	AutoIntent bool
 }
 
 type ImportOptions struct {
@@ -114,6 +213,8 @@ type ImportOptions struct {
 	InvocationCWD  string
 	PreserveHandle bool
 	Force          bool
+	// Concurrency is passed through to Create; see CreateOptions.Concurrency.
+	Concurrency int
 }
 
 type ApplyPreflightError struct {
@@ -127,10 +228,84 @@ type ApplyPreflightResult struct {
 	Errors []ApplyPreflightError `json:"errors,omitempty"`
 }
 
+// CaptureVerification is the outcome of checking one capture on disk.
+// Problems reuse the preflight error shape; a dirty working tree is not a
+// problem here since it says nothing about whether the capture can be restored.
+// Orphaned marks a capture directory without a readable capture.json, which
+// ListCaptures skips and PruneOrphanedCaptures removes.
+type CaptureVerification struct {
+	CaptureID string                `json:"capture_id"`
+	Name      string                `json:"name,omitempty"`
+	Valid     bool                  `json:"valid"`
+	Orphaned  bool                  `json:"orphaned,omitempty"`
+	Problems  []ApplyPreflightError `json:"problems,omitempty"`
+}
+
+// ApplyProgress records how far an interrupted ApplyCapture got.
+// It is removed once every repository has been checked out.
+type ApplyProgress struct {
+	CaptureID string    `json:"capture_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Applied   []string  `json:"applied"`
+	Failed    string    `json:"failed,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
 const (
 	ReasonDirtyWorkingTree  = "dirty_working_tree"
 	ReasonMissingRepository = "missing_repository"
 	ReasonCheckoutFailed    = "checkout_failed"
 	ReasonHeadMismatch      = "head_mismatch"
 	ReasonRepositoryNotGit  = "not_a_git_repository"
+	ReasonCommitMissing     = "commit_missing"
+	ReasonShallowCommit     = "shallow_commit_missing"
+	ReasonCorruptCapture    = "corrupt_capture"
+	ReasonMissingCapture    = "missing_capture_file"
 )
+
+// ProgressEvent describes a step of a long-running store operation.
+type ProgressEvent struct {
+	Type       string
+	Repository string
+	ExitCode   int
+	Duration   time.Duration
+	Err        error
+
+	// Stage and Percent are set on EventCloneProgress, from git's own
+	// progress output (e.g. "Receiving objects" at 42).
+	Stage   string
+	Percent int
+}
+
+const (
+	EventCloneStart    = "clone_start"
+	EventCloneProgress = "clone_progress"
+	EventCloneDone     = "clone_done"
+	EventRepoStart     = "repo_start"
+	EventRepoResult    = "repo_result"
+)
+
+// WorkspaceDiff describes how two workspaces differ.
+type WorkspaceDiff struct {
+	Left         string     `json:"left"`
+	Right        string     `json:"right"`
+	LeftPurpose  string     `json:"left_purpose,omitempty"`
+	RightPurpose string     `json:"right_purpose,omitempty"`
+	OnlyInLeft   []string   `json:"only_in_left,omitempty"`
+	OnlyInRight  []string   `json:"only_in_right,omitempty"`
+	Changed      []RepoDiff `json:"changed,omitempty"`
+}
+
+// RepoDiff describes a repository present in both workspaces at different refs or commits.
+type RepoDiff struct {
+	Repository  string `json:"repository"`
+	LeftRef     string `json:"left_ref"`
+	RightRef    string `json:"right_ref"`
+	LeftCommit  string `json:"left_commit,omitempty"`
+	RightCommit string `json:"right_commit,omitempty"`
+}
+
+// Empty reports whether the workspaces have no differences.
+func (d *WorkspaceDiff) Empty() bool {
+	return d.LeftPurpose == "" && len(d.OnlyInLeft) == 0 && len(d.OnlyInRight) == 0 && len(d.Changed) == 0
+}
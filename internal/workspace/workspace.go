@@ -2,10 +2,13 @@ package workspace
 
 import (
 	"context"
+	"path/filepath"
 	"time"
 )
 
-const CurrentMetadataVersion = 1
+// CurrentMetadataVersion is written to new workspaces.
+// Version 2 added Project; older metadata reads back with no project.
+const CurrentMetadataVersion = 2
 
 // Repository represents a git repository within a workspace.
 type Repository struct {
@@ -20,8 +23,58 @@ type Repository struct {
 	Name string `json:"name"`
 
 	// Depth is the clone depth used during initial clone.
-	// This is not persisted to JSON.
-	Depth int `json:"-"`
+	// Zero means full history, including after an unshallow.
+	Depth int `json:"depth,omitempty"`
+
+	// Source is empty for git clones and RepositorySourceWorkingTree when the
+	// repository was copied from a local working tree, uncommitted changes included.
+	Source string `json:"source,omitempty"`
+
+	// IncludeIgnored records whether a working tree copy also took gitignored files.
+	IncludeIgnored bool `json:"include_ignored,omitempty"`
+
+	// NoCheckout is set while the repository has been cloned without a working
+	// tree; CheckoutRepository materializes Ref and clears it.
+	NoCheckout bool `json:"no_checkout,omitempty"`
+
+	// Sparse lists the directories a sparse checkout is limited to. Empty
+	// means the full tree is checked out.
+	Sparse []string `json:"sparse,omitempty"`
+
+	// Remotes maps additional remote names to URLs, configured next to origin
+	// on clone, e.g. an upstream for a fork.
+	Remotes map[string]string `json:"remotes,omitempty"`
+
+	// LFS records that Git LFS objects were pulled after the clone, so files
+	// tracked by LFS hold their content rather than pointers.
+	LFS bool `json:"lfs,omitempty"`
+
+	// PostClone is a setup command run once in the repository directory after
+	// it was cloned and checked out, e.g. git config or make deps.
+	PostClone []string `json:"post_clone,omitempty"`
+}
+
+// RepositorySourceWorkingTree marks a repository copied from a local working tree.
+const RepositorySourceWorkingTree = "working_tree_copy"
+
+// Repository locations as reported by Repository.Location.
+const (
+	RepositoryLocal  = "local"
+	RepositoryRemote = "remote"
+)
+
+// RepositoryLocation reports whether url is a local path or a remote URL.
+// Local repositories cannot be recreated from an export on another machine.
+func RepositoryLocation(url string) string {
+	if isLocalPath(url) {
+		return RepositoryLocal
+	}
+	return RepositoryRemote
+}
+
+// Location reports whether the repository came from a local path or a remote URL.
+func (r Repository) Location() string {
+	return RepositoryLocation(r.URL)
 }
 
 // RepositoryOption specifies a repository to add during workspace creation.
@@ -34,6 +87,29 @@ type RepositoryOption struct {
 
 	// Depth specifies shallow clone depth. Zero means full history.
 	Depth int
+
+	// CopyWorkingTree copies a local repository's working tree, including
+	// uncommitted changes, instead of cloning its committed state.
+	CopyWorkingTree bool
+
+	// IncludeIgnored also copies gitignored files when CopyWorkingTree is set.
+	IncludeIgnored bool
+
+	// NoCheckout clones without checking out a ref; see Repository.NoCheckout.
+	NoCheckout bool
+
+	// Sparse limits the checkout to these directories; see Repository.Sparse.
+	Sparse []string
+
+	// Remotes adds named remotes besides origin; see Repository.Remotes.
+	Remotes map[string]string
+
+	// LFS pulls Git LFS objects after the clone; see Repository.LFS.
+	LFS bool
+
+	// PostClone runs after the checkout; see Repository.PostClone. A failing
+	// command fails the create or add.
+	PostClone []string
 }
 
 // Workspace represents a collection of repositories managed together.
@@ -47,15 +123,30 @@ type Workspace struct {
 	// Purpose describes what the workspace is used for.
 	Purpose string `json:"purpose"`
 
+	// Project optionally groups related workspaces.
+	Project string `json:"project,omitempty"`
+
 	// Repositories contains the repositories in this workspace.
 	Repositories []Repository `json:"repositories"`
 
 	// CreatedAt records when the workspace was created.
 	CreatedAt time.Time `json:"created_at"`
 
+	// IdempotencyKey identifies the request that created the workspace, so a
+	// retried create with the same key returns it instead of a duplicate.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// Retention overrides the store's execution retention policy for this
+	// workspace. Nil means the store's policy applies.
+	Retention *RetentionPolicy `json:"retention,omitempty"`
+
 	// Path is the filesystem location of the workspace.
 	// This field is not persisted to JSON.
 	Path string `json:"-"`
+
+	// Existing is set by Create when IdempotencyKey matched a workspace that
+	// already existed. This field is not persisted to JSON.
+	Existing bool `json:"-"`
 }
 
 func (ws *Workspace) GetRepositoryByName(name string) *Repository {
@@ -67,11 +158,29 @@ func (ws *Workspace) GetRepositoryByName(name string) *Repository {
 	return nil
 }
 
+// CapturesDir is the directory holding the workspace's captures, one
+// subdirectory per capture ID.
+func (ws *Workspace) CapturesDir() string {
+	return filepath.Join(ws.Path, ".workshed", capturesDirName)
+}
+
+// ExecutionsDir is the directory holding the workspace's execution records,
+// one subdirectory per execution ID.
+func (ws *Workspace) ExecutionsDir() string {
+	return filepath.Join(ws.Path, ".workshed", executionsDirName)
+}
+
+// DefaultCloneConcurrency is the clone concurrency the CLI uses when none is given.
+const DefaultCloneConcurrency = 4
+
 // CreateOptions specifies the configuration for a new workspace.
 type CreateOptions struct {
 	// Purpose describes the intended use of the workspace.
 	Purpose string
 
+	// Project optionally groups the workspace with related ones.
+	Project string
+
 	// Template is an optional directory whose contents will be copied into the workspace.
 	Template string
 
@@ -82,13 +191,49 @@ type CreateOptions struct {
 	// Repositories specifies the repositories to include in the workspace.
 	Repositories []RepositoryOption
 
+	// DefaultRef is checked out for repositories that do not specify a ref.
+	// Empty means the ref is detected (remote default branch or local current branch).
+	DefaultRef string
+
+	// NewBranch, if set, is created and checked out in every repository after
+	// cloning, and recorded as the repository's ref.
+	NewBranch string
+
+	// NewBranchFrom is the ref every repository is cloned at before NewBranch
+	// is created, overriding per-repository refs. Empty keeps each repository's ref.
+	NewBranchFrom string
+
 	InvocationCWD string
+
+	// OnProgress, if set, is called as each repository starts and finishes cloning.
+	// Calls are serialized even when cloning concurrently.
+	OnProgress func(ProgressEvent)
+
+	// Concurrency bounds how many repositories are cloned at once.
+	// Zero or one clones serially.
+	Concurrency int
+
+	// IdempotencyKey, if set, makes Create return the workspace already
+	// created with this key, marked Existing, instead of creating another.
+	IdempotencyKey string
 }
 
 // ListOptions specifies filtering criteria for listing workspaces.
 type ListOptions struct {
 	// PurposeFilter returns only workspaces whose purpose contains this string.
 	PurposeFilter string
+
+	// ProjectFilter returns only workspaces in this project (case-insensitive).
+	ProjectFilter string
+
+	// CreatedAfter and CreatedBefore, when set, return only workspaces
+	// created at or after, and at or before, these times.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	// ActiveWithin, when positive, returns only workspaces whose latest
+	// execution finished within this long of the store clock's now.
+	ActiveWithin time.Duration
 }
 
 // InvocationContext defines an interface for accessing the original invocation current working directory.
@@ -108,15 +253,48 @@ type Store interface {
 	// List returns all workspaces, optionally filtered by the provided options.
 	List(ctx context.Context, opts ListOptions) ([]*Workspace, error)
 
+	// ListStream calls fn for each workspace matching opts as it is read,
+	// without buffering the whole list.
+	ListStream(ctx context.Context, opts ListOptions, fn func(*Workspace) error) error
+
 	// Remove deletes a workspace identified by its handle.
 	Remove(ctx context.Context, handle string) error
 
+	// TrashWorkspace moves a workspace into the trash instead of deleting it.
+	TrashWorkspace(ctx context.Context, handle string) (*TrashEntry, error)
+
+	// ListTrash returns trashed workspaces, newest first.
+	ListTrash(ctx context.Context) ([]TrashEntry, error)
+
+	// RestoreWorkspace moves a trashed workspace, by trash ID or handle, back
+	// into the store, picking a new handle if the old one is taken.
+	RestoreWorkspace(ctx context.Context, ref string) (*Workspace, error)
+
+	// EmptyTrash permanently deletes trashed workspaces older than olderThan,
+	// or all of them when olderThan is zero. ExpiredTrash lists them without
+	// deleting anything.
+	EmptyTrash(ctx context.Context, olderThan time.Duration) ([]TrashEntry, error)
+	ExpiredTrash(ctx context.Context, olderThan time.Duration) ([]TrashEntry, error)
+
+	// TouchRecent records handle as the most recently used workspace.
+	TouchRecent(ctx context.Context, handle string) error
+
+	// RecentHandles returns recently used workspaces that still exist, newest first.
+	RecentHandles(ctx context.Context) ([]RecentEntry, error)
+
 	// Path returns the filesystem path where a workspace is stored.
 	Path(ctx context.Context, handle string) (string, error)
 
+	// GetRepositoryPath returns the directory of a repository in a workspace.
+	GetRepositoryPath(ctx context.Context, handle, repoName string) (string, error)
+
 	// UpdatePurpose modifies the purpose string for a given workspace.
 	UpdatePurpose(ctx context.Context, handle string, purpose string) error
 
+	// RenameWorkspace changes a workspace's handle and moves its directory to
+	// match, returning the renamed workspace.
+	RenameWorkspace(ctx context.Context, handle, newHandle string) (*Workspace, error)
+
 	// FindWorkspace locates a workspace based on a directory path.
 	// Returns nil if no workspace is found for the given directory.
 	FindWorkspace(ctx context.Context, dir string) (*Workspace, error)
@@ -124,6 +302,9 @@ type Store interface {
 	// Exec runs a command in all repositories belonging to a workspace.
 	Exec(ctx context.Context, handle string, opts ExecOptions) ([]ExecResult, error)
 
+	// PlanExec reports where Exec would run a command, and the command, without running it.
+	PlanExec(ctx context.Context, handle string, opts ExecOptions) ([]ExecTarget, error)
+
 	// AddRepository adds a repository to an existing workspace.
 	AddRepository(ctx context.Context, handle string, repo RepositoryOption, invocationCWD string) error
 
@@ -133,17 +314,109 @@ type Store interface {
 	// RemoveRepository removes a repository from an existing workspace.
 	RemoveRepository(ctx context.Context, handle string, repoName string) error
 
+	// RenameRepository renames a repository and its directory in place.
+	RenameRepository(ctx context.Context, handle, oldName, newName string) error
+
+	// CheckoutRepository checks out ref, or the recorded ref when empty, in a
+	// repository of the workspace. It is how --no-checkout clones get a working tree.
+	CheckoutRepository(ctx context.Context, handle, repoName, ref string) error
+
+	// ReconcileRepositories clones, removes and re-checks-out repositories so the
+	// workspace matches desired, rolling every change back if one fails.
+	ReconcileRepositories(ctx context.Context, handle string, desired []RepositoryOption, invocationCWD string) (*ReconcileResult, error)
+
+	// PlanReconcile reports what ReconcileRepositories would change without changing it.
+	PlanReconcile(ctx context.Context, handle string, desired []RepositoryOption, invocationCWD string) (*ReconcilePlan, error)
+
+	// UnshallowRepository fetches full history for a shallow clone and clears its depth.
+	UnshallowRepository(ctx context.Context, handle string, repoName string) error
+
+	// FetchRepositories fetches remote refs for repositories without changing working trees.
+	FetchRepositories(ctx context.Context, handle string, opts FetchOptions) ([]FetchResult, error)
+
+	// CloneMissingRepositories re-clones repositories whose directories have been deleted.
+	CloneMissingRepositories(ctx context.Context, handle string) ([]CloneResult, error)
+
+	// RepositoryStatuses reports each repository's dirty state and ahead/behind counts against its upstream.
+	RepositoryStatuses(ctx context.Context, handle string) ([]RepositoryStatus, error)
+
+	// InspectRepository reports one repository's configuration and live git state.
+	InspectRepository(ctx context.Context, handle, repoName string) (*RepositoryDetail, error)
+
+	// Compare reports differences in purpose, repositories, and live git state between two workspaces.
+	Compare(ctx context.Context, left, right string) (*WorkspaceDiff, error)
+
 	// Execution record operations
 	RecordExecution(ctx context.Context, handle string, record ExecutionRecord, outputs []ExecResult) error
 	GetExecution(ctx context.Context, handle, execID string) (*ExecutionRecord, error)
 	ListExecutions(ctx context.Context, handle string, opts ListExecutionsOptions) ([]ExecutionRecord, error)
+	// PruneExecutions deletes records outside the policy (nil uses the workspace's
+	// policy, else the store's) and returns their IDs.
+	PruneExecutions(ctx context.Context, handle string, policy *RetentionPolicy) ([]string, error)
+	// PrunableExecutions returns the IDs PruneExecutions would delete.
+	PrunableExecutions(ctx context.Context, handle string, policy *RetentionPolicy) ([]string, error)
+	// SetWorkspaceRetention sets the retention policy applied to one workspace's
+	// executions; nil reverts it to the store's policy.
+	SetWorkspaceRetention(ctx context.Context, handle string, policy *RetentionPolicy) error
+	// DiffExecutions diffs the stored output of two executions per repository.
+	DiffExecutions(ctx context.Context, handle, fromID, toID string) ([]OutputDiff, error)
+
+	// Workspace env file operations
+	WorkspaceEnv(ctx context.Context, handle string) (map[string]string, error)
+	SetWorkspaceEnv(ctx context.Context, handle string, vars map[string]string) error
+	UnsetWorkspaceEnv(ctx context.Context, handle string, keys []string) error
 
 	// Capture operations
 	CaptureState(ctx context.Context, handle string, opts CaptureOptions) (*Capture, error)
+	// PlanCapture validates opts as CaptureState would, without recording anything.
+	PlanCapture(ctx context.Context, handle string, opts CaptureOptions) (CaptureOptions, error)
 	ApplyCapture(ctx context.Context, handle string, captureID string) error
 	PreflightApply(ctx context.Context, handle string, captureID string) (ApplyPreflightResult, error)
+	PlanApply(ctx context.Context, handle string, captureID string) ([]RefChange, error)
+	// ContinueApply checks out only the repositories whose HEAD does not yet match the capture
+	// and returns their names.
+	ContinueApply(ctx context.Context, handle string, captureID string) ([]string, error)
 	GetCapture(ctx context.Context, handle, captureID string) (*Capture, error)
 	ListCaptures(ctx context.Context, handle string) ([]Capture, error)
+	// CaptureSize returns the total size in bytes of a capture's stored files.
+	CaptureSize(ctx context.Context, handle, captureID string) (int64, error)
+	// VerifyCapture checks that a capture parses and that every repository and
+	// commit it references is still present. VerifyCaptures does so for all of them,
+	// including captures whose capture.json no longer parses.
+	VerifyCapture(ctx context.Context, handle, captureID string) (*CaptureVerification, error)
+	VerifyCaptures(ctx context.Context, handle string) ([]CaptureVerification, error)
+	// OrphanedCaptures lists the capture directories without a readable
+	// capture.json that PruneOrphanedCaptures would remove; the latter removes
+	// them and returns their IDs.
+	OrphanedCaptures(ctx context.Context, handle string) ([]CaptureVerification, error)
+	PruneOrphanedCaptures(ctx context.Context, handle string) ([]string, error)
+	// DeleteCapture removes a capture by ID.
+	DeleteCapture(ctx context.Context, handle, captureID string) error
+
+	// History operations
+	// RecordHistory appends an entry to the workspace history; ListHistory
+	// returns it newest first. Create, apply, checkout and lock restores record
+	// themselves.
+	RecordHistory(ctx context.Context, handle string, entry HistoryEntry) error
+	ListHistory(ctx context.Context, handle string) ([]HistoryEntry, error)
+
+	// Stash operations
+	// Stash stashes uncommitted changes, untracked files included, in every
+	// repository; StashPop restores the newest stash and drops it. ListStashes
+	// returns the stashes newest first.
+	Stash(ctx context.Context, handle string) ([]StashResult, error)
+	StashPop(ctx context.Context, handle string) ([]StashResult, error)
+	ListStashes(ctx context.Context, handle string) ([]StashEntry, error)
+
+	// LastActivity returns the latest of a workspace's creation, execution and capture times.
+	LastActivity(ctx context.Context, handle string) (time.Time, error)
+
+	// CheckHealth reports problems with a workspace and its repositories.
+	CheckHealth(ctx context.Context, handle string) (*HealthReport, error)
+
+	// Lockfile operations
+	Lock(ctx context.Context, handle string) (*Lockfile, error)
+	RestoreLock(ctx context.Context, handle string, lock *Lockfile) error
 
 	// Context export
 	ExportContext(ctx context.Context, handle string) (*WorkspaceContext, error)
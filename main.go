@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 
 	"github.com/frodi/workshed/internal/cli"
@@ -9,18 +10,32 @@ import (
 	"github.com/frodi/workshed/internal/cli/capture"
 	"github.com/frodi/workshed/internal/cli/captures"
 	"github.com/frodi/workshed/internal/cli/completion"
+	"github.com/frodi/workshed/internal/cli/configcmd"
 	"github.com/frodi/workshed/internal/cli/create"
+	"github.com/frodi/workshed/internal/cli/doctor"
+	"github.com/frodi/workshed/internal/cli/envcmd"
 	"github.com/frodi/workshed/internal/cli/exec"
+	"github.com/frodi/workshed/internal/cli/executions"
 	"github.com/frodi/workshed/internal/cli/export"
 	"github.com/frodi/workshed/internal/cli/health"
+	"github.com/frodi/workshed/internal/cli/history"
 	"github.com/frodi/workshed/internal/cli/importcmd"
 	"github.com/frodi/workshed/internal/cli/inspect"
+	"github.com/frodi/workshed/internal/cli/last"
 	"github.com/frodi/workshed/internal/cli/list"
+	"github.com/frodi/workshed/internal/cli/lock"
 	mcpcmd "github.com/frodi/workshed/internal/cli/mcp"
 	"github.com/frodi/workshed/internal/cli/path"
+	"github.com/frodi/workshed/internal/cli/prune"
 	"github.com/frodi/workshed/internal/cli/remove"
+	"github.com/frodi/workshed/internal/cli/rename"
 	"github.com/frodi/workshed/internal/cli/repos"
+	"github.com/frodi/workshed/internal/cli/selftest"
+	"github.com/frodi/workshed/internal/cli/shellcmd"
+	"github.com/frodi/workshed/internal/cli/stash"
+	"github.com/frodi/workshed/internal/cli/trash"
 	"github.com/frodi/workshed/internal/cli/update"
+	"github.com/frodi/workshed/internal/cli/watch"
 	"github.com/frodi/workshed/internal/tui"
 	"github.com/frodi/workshed/internal/version"
 	"github.com/spf13/cobra"
@@ -49,28 +64,52 @@ Examples:
   workshed apply --name "Before changes"`,
 	}
 
+	root.PersistentFlags().String("cwd", "", "Resolve relative repository paths against this directory instead of the current one")
+	root.PersistentFlags().Bool("json-errors", false, "Print errors as a JSON object on stderr (implied by --format json)")
+
 	root.AddCommand(create.Command())
 	root.AddCommand(list.Command())
 	root.AddCommand(inspect.Command())
 	root.AddCommand(path.Command())
+	root.AddCommand(last.Command())
 	root.AddCommand(repos.Command())
 	root.AddCommand(captures.Command())
 	root.AddCommand(capture.Command())
 	root.AddCommand(apply.Command())
+	root.AddCommand(stash.Command())
 	root.AddCommand(exec.Command())
+	root.AddCommand(watch.Command())
+	root.AddCommand(executions.Command())
+	root.AddCommand(history.Command())
+	root.AddCommand(envcmd.Command())
 	root.AddCommand(export.Command())
+	root.AddCommand(lock.Command())
 	root.AddCommand(importcmd.Command())
 	root.AddCommand(remove.Command())
+	root.AddCommand(trash.Command())
+	root.AddCommand(prune.Command())
 	root.AddCommand(update.Command())
+	root.AddCommand(rename.Command())
 	root.AddCommand(health.Command())
+	root.AddCommand(shellcmd.Command())
+	root.AddCommand(selftest.Command())
+	root.AddCommand(configcmd.Command())
 
 	root.AddCommand(completion.NewCommand(root))
+	root.AddCommand(doctor.NewCommand(root))
 
 	root.AddCommand(mcpcmd.Command())
 
-	if err := root.Execute(); err != nil {
-		os.Exit(1)
+	defaults, err := cli.LoadDefaults(cli.ConfigPath())
+	if err == nil {
+		err = cli.ApplyConfigDefaults(root, defaults)
 	}
+	if err != nil {
+		// Keep going without defaults so 'workshed config set' can still repair the file.
+		fmt.Fprintf(os.Stderr, "Warning: ignoring config defaults: %v\n", err)
+	}
+
+	os.Exit(cli.Execute(root, os.Args[1:]))
 }
 
 func runDashboard() {